@@ -0,0 +1,53 @@
+package builder
+
+import (
+	"github.com/tinygo-org/tinygo/compileopts"
+)
+
+// List of functions in the Android libc (Bionic) that may be used by TinyGo
+// programs. This includes the functions used by the runtime, and some commonly
+// used functions from CGo.
+var androidLibcFunctions = []string{
+	"__errno",
+	"abort",
+	"calloc",
+	"clock_gettime",
+	"close",
+	"exit",
+	"free",
+	"getenv",
+	"getpagesize",
+	"malloc",
+	"memcmp",
+	"memcpy",
+	"memmove",
+	"memset",
+	"mmap",
+	"munmap",
+	"nanosleep",
+	"pthread_attr_destroy",
+	"pthread_attr_getstack",
+	"pthread_getattr_np",
+	"pthread_self",
+	"read",
+	"realloc",
+	"setenv",
+	"snprintf",
+	"strcmp",
+	"strlen",
+	"strncmp",
+	"unsetenv",
+	"usleep",
+	"write",
+}
+
+// List of data symbols in the Android libc (all of them pointer sized).
+var androidLibcObjects = []string{
+	"environ",
+}
+
+// Create a job that builds an Android libc.so stub library. Android libraries
+// are always loaded into a process that already has the real libc loaded.
+func makeAndroidLibcJob(config *compileopts.Config, tmpdir string) *compileJob {
+	return makeStubLibraryJob(config, tmpdir, "Android", "libc.so", androidLibcFunctions, androidLibcObjects)
+}
@@ -3,6 +3,7 @@ package builder
 import (
 	"bytes"
 	"debug/elf"
+	"debug/macho"
 	"debug/pe"
 	"encoding/binary"
 	"errors"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/blakesmith/ar"
+	"tinygo.org/x/go-llvm"
 )
 
 // makeArchive creates an arcive for static linking from a list of object files
@@ -33,6 +35,7 @@ func makeArchive(arfile *os.File, objs []string) error {
 		fileIndex int    // index into objfiles
 	}{}
 	archiveOffsets := make([]int32, len(objs))
+	isMachO := false
 	for i, objpath := range objs {
 		objfile, err := os.Open(objpath)
 		if err != nil {
@@ -74,8 +77,32 @@ func makeArchive(arfile *os.File, objs []string) error {
 					fileIndex int
 				}{symbol.Name, i})
 			}
+		} else if dbg, err := macho.NewFile(objfile); err == nil {
+			isMachO = true
+			var symbols []macho.Symbol
+			if dbg.Symtab != nil {
+				symbols = dbg.Symtab.Syms
+			}
+			for _, symbol := range symbols {
+				// Relevant constants from <mach-o/nlist.h>.
+				const (
+					N_STAB = 0xe0
+					N_TYPE = 0x0e
+					N_EXT  = 0x01
+					N_SECT = 0x0e
+				)
+				if symbol.Type&N_STAB != 0 || symbol.Type&N_EXT == 0 || symbol.Type&N_TYPE != N_SECT {
+					// Only include external symbols that are defined in this
+					// object file.
+					continue
+				}
+				symbolTable = append(symbolTable, struct {
+					name      string
+					fileIndex int
+				}{symbol.Name, i})
+			}
 		} else {
-			return fmt.Errorf("failed to open file %s as ELF or PE/COFF: %w", objpath, err)
+			return fmt.Errorf("failed to open file %s as ELF, PE/COFF or Mach-O: %w", objpath, err)
 		}
 
 		// Close file, to avoid issues with too many open files (especially on
@@ -84,33 +111,55 @@ func makeArchive(arfile *os.File, objs []string) error {
 	}
 
 	// Create the symbol table buffer.
-	// For some (sparse) details on the file format:
-	// https://en.wikipedia.org/wiki/Ar_(Unix)#System_V_(or_GNU)_variant
 	buf := &bytes.Buffer{}
-	binary.Write(buf, binary.BigEndian, int32(len(symbolTable)))
-	for range symbolTable {
-		// This is a placeholder index, it will be updated after all files have
-		// been written to the archive (see the end of this function).
-		err = binary.Write(buf, binary.BigEndian, int32(0))
-		if err != nil {
-			return err
+	symbolTableName := "/"
+	if isMachO {
+		// The Darwin linker expects a BSD style symbol table instead, which
+		// is a little endian list of (string offset, file offset) pairs
+		// followed by a string table. See struct ranlib in <mach-o/ranlib.h>.
+		symbolTableName = "__.SYMDEF"
+		stringTable := &bytes.Buffer{}
+		binary.Write(buf, binary.LittleEndian, int32(len(symbolTable)*8))
+		for _, sym := range symbolTable {
+			binary.Write(buf, binary.LittleEndian, int32(stringTable.Len()))
+			// This is a placeholder index, it will be updated after all
+			// files have been written to the archive.
+			binary.Write(buf, binary.LittleEndian, int32(0))
+			stringTable.WriteString(sym.name + "\x00")
 		}
-	}
-	for _, sym := range symbolTable {
-		_, err := buf.Write([]byte(sym.name + "\x00"))
-		if err != nil {
-			return err
+		for stringTable.Len()%8 != 0 {
+			stringTable.WriteByte(0)
+		}
+		binary.Write(buf, binary.LittleEndian, int32(stringTable.Len()))
+		buf.Write(stringTable.Bytes())
+	} else {
+		// For some (sparse) details on the file format:
+		// https://en.wikipedia.org/wiki/Ar_(Unix)#System_V_(or_GNU)_variant
+		binary.Write(buf, binary.BigEndian, int32(len(symbolTable)))
+		for range symbolTable {
+			// This is a placeholder index, it will be updated after all files have
+			// been written to the archive (see the end of this function).
+			err = binary.Write(buf, binary.BigEndian, int32(0))
+			if err != nil {
+				return err
+			}
+		}
+		for _, sym := range symbolTable {
+			_, err := buf.Write([]byte(sym.name + "\x00"))
+			if err != nil {
+				return err
+			}
+		}
+		for buf.Len()%2 != 0 {
+			// The symbol table must be aligned.
+			// This appears to be required by lld.
+			buf.WriteByte(0)
 		}
-	}
-	for buf.Len()%2 != 0 {
-		// The symbol table must be aligned.
-		// This appears to be required by lld.
-		buf.WriteByte(0)
 	}
 
 	// Write the symbol table.
 	err = arwriter.WriteHeader(&ar.Header{
-		Name:    "/",
+		Name:    symbolTableName,
 		ModTime: time.Unix(0, 0),
 		Uid:     0,
 		Gid:     0,
@@ -191,6 +240,20 @@ func makeArchive(arfile *os.File, objs []string) error {
 		objfile.Close()
 	}
 
+	if isMachO {
+		// Overwrite placeholder indices, which are interleaved with the
+		// string offsets.
+		for i, sym := range symbolTable {
+			offsetBuf := make([]byte, 4)
+			binary.LittleEndian.PutUint32(offsetBuf, uint32(archiveOffsets[sym.fileIndex]))
+			_, err = arfile.WriteAt(offsetBuf, symbolTableStart+4+int64(i)*8+4)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	// Create symbol indices.
 	indicesBuf := &bytes.Buffer{}
 	for _, sym := range symbolTable {
@@ -204,3 +267,77 @@ func makeArchive(arfile *os.File, objs []string) error {
 	_, err = arfile.WriteAt(indicesBuf.Bytes(), symbolTableStart+4)
 	return err
 }
+
+// makeCArchive creates a static library for -buildmode=c-archive from the given
+// object files. All LLVM bitcode files (the Go code, and C code compiled with
+// -flto) are linked together and compiled to a single native object file, so
+// that the archive can be used by a regular linker like the one in Xcode.
+// Native object files (from assembly files for example) are added as-is.
+func makeCArchive(path string, objfiles []string, machine llvm.TargetMachine) error {
+	ctx := llvm.NewContext()
+	defer ctx.Dispose()
+	mod := ctx.NewModule("main")
+	defer mod.Dispose()
+
+	var objs []string
+	for _, objfile := range objfiles {
+		isBitcode, err := isBitcodeFile(objfile)
+		if err != nil {
+			return err
+		}
+		if !isBitcode {
+			objs = append(objs, objfile)
+			continue
+		}
+		fileMod, err := ctx.ParseBitcodeFile(objfile)
+		if err != nil {
+			return fmt.Errorf("failed to load bitcode file: %w", err)
+		}
+		err = llvm.LinkModules(mod, fileMod)
+		if err != nil {
+			return fmt.Errorf("failed to link module: %w", err)
+		}
+	}
+
+	// Compile the combined module to a native object file.
+	llvmBuf, err := machine.EmitToMemoryBuffer(mod, llvm.ObjectFile)
+	if err != nil {
+		return err
+	}
+	defer llvmBuf.Dispose()
+	mainObj := filepath.Join(filepath.Dir(path), "main-native.o")
+	err = os.WriteFile(mainObj, llvmBuf.Bytes(), 0o666)
+	if err != nil {
+		return err
+	}
+	objs = append([]string{mainObj}, objs...)
+
+	arfile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer arfile.Close()
+	err = makeArchive(arfile, objs)
+	if err != nil {
+		return err
+	}
+	return arfile.Close()
+}
+
+// isBitcodeFile returns whether the given file is a LLVM bitcode file, either
+// raw or with a bitcode wrapper header.
+func isBitcodeFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	_, err = io.ReadFull(f, magic)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return bytes.Equal(magic, []byte("BC\xc0\xde")) || bytes.Equal(magic, []byte{0xde, 0xc0, 0x17, 0x0b}), nil
+}
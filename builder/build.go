@@ -61,6 +61,12 @@ type BuildResult struct {
 	// correctly printing test results: the import path isn't always the same as
 	// the path listed on the command line.
 	ImportPath string
+
+	// A path to the generated C header file with all exported functions. It is
+	// set for library build modes (like -buildmode=c-shared) and for programs
+	// that export functions. Like Binary, it is stored in the tmpdir directory
+	// of the Build function.
+	Header string
 }
 
 // packageAction is the struct that is serialized to JSON and hashed, to work as
@@ -106,6 +112,66 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		fmt.Printf("WORK=%s\n", tmpdir)
 	}
 
+	switch config.GC() {
+	case "incremental", "compacting":
+		// The incremental GC needs to scan goroutine stacks directly, which
+		// isn't possible when stack objects are used instead. The compacting
+		// GC can't move goroutine stacks on WebAssembly, as they're only
+		// referenced using an uintptr.
+		if config.NeedsStackObjects() {
+			return BuildResult{}, fmt.Errorf("-gc=%s is not supported on WebAssembly", config.GC())
+		}
+	}
+	if config.Allocator() != "linear" {
+		// The allocator is part of the block-based GC.
+		switch config.GC() {
+		case "conservative", "precise", "incremental", "compacting":
+		default:
+			return BuildResult{}, fmt.Errorf("-allocator=%s is not supported with -gc=%s", config.Allocator(), config.GC())
+		}
+	}
+	if config.Target.ExternalRAMSize != "" {
+		// Both are passed to the linker as-is, so check them here to get a
+		// readable error.
+		if _, err := strconv.ParseUint(config.Target.ExternalRAMStart, 0, 64); err != nil {
+			return BuildResult{}, fmt.Errorf("invalid external-ram-start in target: %w", err)
+		}
+		if _, err := strconv.ParseUint(config.Target.ExternalRAMSize, 0, 64); err != nil {
+			return BuildResult{}, fmt.Errorf("invalid external-ram-size in target: %w", err)
+		}
+	}
+	if config.LineTable() {
+		if err := checkLineTable(config); err != nil {
+			return BuildResult{}, err
+		}
+	}
+	if config.Options.AutoYield && !config.AutoYield() {
+		// Yield points can only switch goroutines when every goroutine has
+		// its own stack. Goroutines on WebAssembly are usually implemented
+		// using asyncify, which can't switch goroutines at arbitrary
+		// safepoints.
+		return BuildResult{}, fmt.Errorf("-autoyield is not supported with -scheduler=%s", config.Scheduler())
+	}
+	if config.Scheduler() == "cores" {
+		// Starting the other cores and locking between them is implemented
+		// per chip, and for WebAssembly threads. So far this has only been
+		// done for the RP2040: other multicore chips like the RP2350 and the
+		// ESP32 aren't supported yet. Also, only the conservative GC knows how
+		// to stop the other cores while it is running.
+		supported := false
+		for _, tag := range config.Target.BuildTags {
+			if tag == "rp2040" || tag == "wasm.threads" {
+				supported = true
+			}
+		}
+		if !supported {
+			return BuildResult{}, fmt.Errorf("-scheduler=cores is not supported on %s", config.Triple())
+		}
+		if config.GC() != "conservative" {
+			return BuildResult{}, fmt.Errorf("-scheduler=cores is not supported with -gc=%s", config.GC())
+		}
+	}
+
 	// Look up the build cache directory, which is used to speed up incremental
 	// builds.
 	cacheDir := goenv.Get("GOCACHE")
@@ -124,6 +190,12 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 	case "darwin-libSystem":
 		job := makeDarwinLibSystemJob(config, tmpdir)
 		libcDependencies = append(libcDependencies, job)
+	case "freebsd-libc":
+		job := makeFreeBSDLibcJob(config, tmpdir)
+		libcDependencies = append(libcDependencies, job)
+	case "android-libc":
+		job := makeAndroidLibcJob(config, tmpdir)
+		libcDependencies = append(libcDependencies, job)
 	case "musl":
 		job, unlock, err := Musl.load(config, tmpdir)
 		if err != nil {
@@ -138,11 +210,22 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 			return BuildResult{}, err
 		}
 		defer unlock()
+		if config.RunsUnderRTOS() {
+			// The firmware comes with its own C library. Only the headers of
+			// picolibc are used, to compile C code.
+			break
+		}
 		libcDependencies = append(libcDependencies, libcJob)
 	case "wasi-libc":
-		path := filepath.Join(root, "lib/wasi-libc/sysroot/lib/wasm32-wasi/libc.a")
+		// The sysroot has a separate directory for the thread-enabled
+		// libraries, named after the target triple without the vendor.
+		dir, makeTarget := "wasm32-wasi", "wasi-libc"
+		if strings.HasSuffix(config.Triple(), "-threads") {
+			dir, makeTarget = "wasm32-wasi-threads", "wasi-libc-threads"
+		}
+		path := filepath.Join(root, "lib/wasi-libc/sysroot/lib", dir, "libc.a")
 		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
-			return BuildResult{}, errors.New("could not find wasi-libc, perhaps you need to run `make wasi-libc`?")
+			return BuildResult{}, fmt.Errorf("could not find wasi-libc, perhaps you need to run `make %s`?", makeTarget)
 		}
 		libcDependencies = append(libcDependencies, dummyCompileJob(path))
 	case "mingw-w64":
@@ -172,9 +255,12 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		TinyGoVersion:   goenv.Version,
 
 		Scheduler:          config.Scheduler(),
+		Asyncify:           config.Asyncify(),
+		Reflect:            config.Reflect(),
 		AutomaticStackSize: config.AutomaticStackSize(),
 		DefaultStackSize:   config.StackSize(),
 		NeedsStackObjects:  config.NeedsStackObjects(),
+		FramePointers:      config.LineTable(),
 		Debug:              !config.Options.SkipDWARF, // emit DWARF except when -internal-nodwarf is passed
 	}
 
@@ -575,6 +661,19 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		},
 	}
 
+	// Declare the functions exported using //export in the main package in a
+	// C header file, so that they can be called from C code. Libraries always
+	// get a header, as these functions are the API of the library.
+	mainPkg := lprogram.MainPkg()
+	exports := findExportedFunctions(mainPkg)
+	if config.BuildsLibrary() || len(exports) != 0 {
+		result.Header = filepath.Join(tmpdir, "main.h")
+		err := writeCHeader(result.Header, mainPkg, exports, config)
+		if err != nil {
+			return result, err
+		}
+	}
+
 	// Check whether we only need to create an object file.
 	// If so, we don't need to link anything and will be finished quickly.
 	outext := filepath.Ext(outpath)
@@ -631,9 +730,44 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 	result.Binary = result.Executable // final file
 	ldflags := append(config.LDFlags(), "-o", result.Executable)
 
+	if config.BuildMode() == "c-shared" {
+		// Only the exported functions are part of the API of the library.
+		// Hide all other symbols (like those used internally by the runtime)
+		// from the dynamic symbol table.
+		versionScript := &strings.Builder{}
+		versionScript.WriteString("{\n")
+		if len(exports) != 0 {
+			versionScript.WriteString("\tglobal:\n")
+			for _, fn := range exports {
+				versionScript.WriteString("\t\t" + fn.name + ";\n")
+			}
+		}
+		versionScript.WriteString("\tlocal: *;\n};\n")
+		versionScriptPath := filepath.Join(tmpdir, "exports.map")
+		err = os.WriteFile(versionScriptPath, []byte(versionScript.String()), 0o666)
+		if err != nil {
+			return result, err
+		}
+
+		// Android requires a DT_SONAME entry, so always add one based on
+		// the output file name.
+		soname := filepath.Base(outpath)
+		if outpath == "" {
+			soname = filepath.Base(result.MainDir) + config.DefaultBinaryExtension()
+			if strings.HasSuffix(pkgName, ".go") {
+				soname = filepath.Base(pkgName[:len(pkgName)-3]) + config.DefaultBinaryExtension()
+			}
+		}
+		ldflags = append(ldflags,
+			"-shared",
+			"-soname", soname,
+			"--version-script="+versionScriptPath)
+	}
+
 	// Add compiler-rt dependency if needed. Usually this is a simple load from
-	// a cache.
-	if config.Target.RTLib == "compiler-rt" {
+	// a cache. Firmware that runs TinyGo under an RTOS is linked with the
+	// runtime library of its own toolchain instead.
+	if config.Target.RTLib == "compiler-rt" && !config.RunsUnderRTOS() {
 		job, unlock, err := CompilerRT.load(config, tmpdir)
 		if err != nil {
 			return result, err
@@ -689,6 +823,27 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 	// Add embedded files.
 	linkerDependencies = append(linkerDependencies, embedFileObjects...)
 
+	if config.BuildMode() == "c-archive" {
+		// Static libraries are not linked. Instead, all object files are
+		// bundled in an archive that is linked into the final program by the
+		// C toolchain (for example by Xcode).
+		result.Executable = filepath.Join(tmpdir, "main.a")
+		result.Binary = result.Executable
+		archiveJob := &compileJob{
+			description:  "create archive",
+			dependencies: linkerDependencies,
+			run: func(job *compileJob) error {
+				var objfiles []string
+				for _, dependency := range job.dependencies {
+					objfiles = append(objfiles, dependency.result)
+				}
+				return makeCArchive(result.Executable, objfiles, machine)
+			},
+		}
+		err := runJobs(archiveJob, config.Options.Semaphore)
+		return result, err
+	}
+
 	// Determine whether the compilation configuration would result in debug
 	// (DWARF) information in the object files.
 	var hasDebug = true
@@ -756,6 +911,15 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 				ldflags = append(ldflags,
 					"-mllvm", "--rotation-max-header-size=0")
 			}
+			var lineTableObject string
+			if config.LineTable() {
+				// Link with an empty line table first, see linetable.go.
+				lineTableObject, err = createLineTableObjectFile(make([]byte, 24), tmpdir, compilerConfig)
+				if err != nil {
+					return err
+				}
+				ldflags = append(ldflags, lineTableObject)
+			}
 			if config.Options.PrintCommands != nil {
 				config.Options.PrintCommands(config.Target.Linker, ldflags...)
 			}
@@ -763,6 +927,33 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 			if err != nil {
 				return &commandError{"failed to link", result.Executable, err}
 			}
+			if config.LineTable() {
+				// Link again, now with the real line table. The addresses in
+				// the table are fixed up afterwards.
+				table, err := makeLineTable(result.Executable)
+				if err != nil {
+					return fmt.Errorf("could not create line table: %w", err)
+				}
+				ldflags[len(ldflags)-1], err = createLineTableObjectFile(table, tmpdir, compilerConfig)
+				if err != nil {
+					return err
+				}
+				if config.Options.PrintCommands != nil {
+					config.Options.PrintCommands(config.Target.Linker, ldflags...)
+				}
+				err = link(config.Target.Linker, ldflags...)
+				if err != nil {
+					return &commandError{"failed to link", result.Executable, err}
+				}
+				table, err = makeLineTable(result.Executable)
+				if err != nil {
+					return fmt.Errorf("could not create line table: %w", err)
+				}
+				err = replaceElfSection(result.Executable, lineTableSection, table)
+				if err != nil {
+					return fmt.Errorf("could not update line table: %w", err)
+				}
+			}
 
 			var calculatedStacks []string
 			var stackSizes map[string]functionStackSize
@@ -794,7 +985,7 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 			}
 
 			// Run wasm-opt for wasm binaries
-			if arch := strings.Split(config.Triple(), "-")[0]; arch == "wasm32" {
+			if arch := strings.Split(config.Triple(), "-")[0]; arch == "wasm32" || arch == "wasm64" {
 				var opt string
 				switch config.Options.Opt {
 				case "none", "0":
@@ -813,10 +1004,16 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 
 				var args []string
 
-				if config.Scheduler() == "asyncify" {
+				if config.Asyncify() {
 					args = append(args, "--asyncify")
 				}
 
+				if arch == "wasm64" {
+					// Make sure asyncify uses 64-bit pointers for its
+					// data structure, to match task_asyncify_wasm64.S.
+					args = append(args, "--enable-memory64")
+				}
+
 				args = append(args,
 					opt,
 					"-g",
@@ -22,6 +22,7 @@ func TestClangAttributes(t *testing.T) {
 		"atmega1284p",
 		"atmega2560",
 		"attiny85",
+		"cortex-m-linux",
 		"cortex-m0",
 		"cortex-m0plus",
 		"cortex-m3",
@@ -31,10 +32,12 @@ func TestClangAttributes(t *testing.T) {
 		"esp32c3",
 		"fe310",
 		"gameboy-advance",
+		"ios",
 		"k210",
 		"nintendoswitch",
 		"riscv-qemu",
 		"wasi",
+		"wasi-threads",
 		"wasm",
 	}
 	if hasBuiltinTools {
@@ -56,14 +59,24 @@ func TestClangAttributes(t *testing.T) {
 		{GOOS: "linux", GOARCH: "arm", GOARM: "6"},
 		{GOOS: "linux", GOARCH: "arm", GOARM: "7"},
 		{GOOS: "linux", GOARCH: "arm64"},
+		{GOOS: "linux", GOARCH: "mips", GOMIPS: "hardfloat"},
+		{GOOS: "linux", GOARCH: "mipsle", GOMIPS: "hardfloat"},
+		{GOOS: "linux", GOARCH: "mips", GOMIPS: "softfloat"},
+		{GOOS: "linux", GOARCH: "mipsle", GOMIPS: "softfloat"},
+		{GOOS: "freebsd", GOARCH: "amd64"},
+		{GOOS: "freebsd", GOARCH: "arm64"},
+		{GOOS: "android", GOARCH: "arm64", BuildMode: "c-shared"},
 		{GOOS: "darwin", GOARCH: "amd64"},
 		{GOOS: "darwin", GOARCH: "arm64"},
 		{GOOS: "windows", GOARCH: "amd64"},
 		{GOOS: "windows", GOARCH: "arm64"},
 	} {
 		name := "GOOS=" + options.GOOS + ",GOARCH=" + options.GOARCH
-		if options.GOARCH == "arm" {
+		switch options.GOARCH {
+		case "arm":
 			name += ",GOARM=" + options.GOARM
+		case "mips", "mipsle":
+			name += ",GOMIPS=" + options.GOMIPS
 		}
 		t.Run(name, func(t *testing.T) {
 			testClangAttributes(t, options)
@@ -0,0 +1,273 @@
+package builder
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+	"github.com/tinygo-org/tinygo/loader"
+)
+
+// Typedefs for Go types in exported function signatures. These match the
+// memory layout used by TinyGo, in which int and uint are pointer sized.
+// Strings, slices and interfaces are only passed as a struct when returned
+// from a function. As parameters, they are split into separate fields.
+const cHeaderTypedefs = `typedef int8_t GoInt8;
+typedef uint8_t GoUint8;
+typedef int16_t GoInt16;
+typedef uint16_t GoUint16;
+typedef int32_t GoInt32;
+typedef uint32_t GoUint32;
+typedef int64_t GoInt64;
+typedef uint64_t GoUint64;
+typedef intptr_t GoInt;
+typedef uintptr_t GoUint;
+typedef uintptr_t GoUintptr;
+typedef float GoFloat32;
+typedef double GoFloat64;
+typedef float _Complex GoComplex64;
+typedef double _Complex GoComplex128;
+typedef _Bool GoBool;
+typedef struct { const char *p; uintptr_t n; } GoString;
+typedef struct { void *data; uintptr_t len; uintptr_t cap; } GoSlice;
+typedef struct { void *t; void *v; } GoInterface;
+`
+
+// exportedFunction is a function marked with //export (or //go:export) that is
+// defined in Go, as opposed to a function that is imported from C.
+type exportedFunction struct {
+	name string
+	sig  *types.Signature
+}
+
+// findExportedFunctions returns all functions in the given package that are
+// exported to C using a //export or //go:export pragma, in source order.
+func findExportedFunctions(pkg *loader.Package) []exportedFunction {
+	var exports []exportedFunction
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.FuncDecl)
+			if !ok || decl.Body == nil || decl.Recv != nil || decl.Doc == nil {
+				continue
+			}
+			for _, comment := range decl.Doc.List {
+				parts := strings.Fields(comment.Text)
+				if len(parts) != 2 || (parts[0] != "//export" && parts[0] != "//go:export") {
+					continue
+				}
+				fn, ok := pkg.Pkg.Scope().Lookup(decl.Name.Name).(*types.Func)
+				if !ok {
+					continue
+				}
+				exports = append(exports, exportedFunction{
+					name: parts[1],
+					sig:  fn.Type().(*types.Signature),
+				})
+			}
+		}
+	}
+	return exports
+}
+
+// writeCHeader writes a C header file with declarations for all the given
+// exported functions, so that they can be called from C (or C++, or from JNI
+// glue code) when the program is built as a library.
+func writeCHeader(path string, pkg *loader.Package, exports []exportedFunction, config *compileopts.Config) error {
+	buf := &strings.Builder{}
+	buf.WriteString("// Code generated by TinyGo. DO NOT EDIT.\n")
+	fmt.Fprintf(buf, "// Exported functions of package %s.\n", pkg.ImportPath)
+	buf.WriteString("//\n")
+	switch {
+	case config.RunsUnderRTOS():
+		buf.WriteString("// The Go runtime is started by calling tinygo_rtos_main from an RTOS task\n")
+		buf.WriteString("// (see tinygo_rtos.h). These functions may only be called from that task,\n")
+		buf.WriteString("// for example from C code called by Go.\n\n")
+	case config.BuildsLibrary():
+		buf.WriteString("// The Go runtime and all package initializers run when the library is\n")
+		buf.WriteString("// loaded, so these functions may be called right away (for example from\n")
+		buf.WriteString("// JNI_OnLoad).\n\n")
+	default:
+		buf.WriteString("// These functions may be called by C code that is linked into the program\n")
+		buf.WriteString("// (or by the host, for WebAssembly), after the Go runtime has started.\n\n")
+	}
+	buf.WriteString("#include <stddef.h>\n")
+	buf.WriteString("#include <stdint.h>\n\n")
+	buf.WriteString(cHeaderTypedefs)
+	buf.WriteString("\n#ifdef __cplusplus\nextern \"C\" {\n#endif\n\n")
+	for _, fn := range exports {
+		var resultType string
+		switch fn.sig.Results().Len() {
+		case 0:
+			resultType = "void"
+		case 1:
+			t, err := cHeaderType(fn.sig.Results().At(0).Type())
+			if err != nil {
+				return fmt.Errorf("cannot export %s: %w", fn.name, err)
+			}
+			resultType = t
+		default:
+			// Multiple return values are returned as a struct.
+			resultType = "struct " + fn.name + "_return"
+			buf.WriteString(resultType + " {\n")
+			for i := 0; i < fn.sig.Results().Len(); i++ {
+				t, err := cHeaderType(fn.sig.Results().At(i).Type())
+				if err != nil {
+					return fmt.Errorf("cannot export %s: %w", fn.name, err)
+				}
+				fmt.Fprintf(buf, "\t%s r%d;\n", t, i)
+			}
+			buf.WriteString("};\n")
+		}
+		var params []string
+		for i := 0; i < fn.sig.Params().Len(); i++ {
+			fields, err := cHeaderParams(fmt.Sprintf("p%d", i), fn.sig.Params().At(i).Type())
+			if err != nil {
+				return fmt.Errorf("cannot export %s: %w", fn.name, err)
+			}
+			params = append(params, fields...)
+		}
+		if len(params) == 0 {
+			params = append(params, "void")
+		}
+		fmt.Fprintf(buf, "extern %s %s(%s);\n", resultType, fn.name, strings.Join(params, ", "))
+	}
+	buf.WriteString("\n#ifdef __cplusplus\n}\n#endif\n")
+	return os.WriteFile(path, []byte(buf.String()), 0o666)
+}
+
+// cHeaderParams returns the C parameters for a parameter of an exported
+// function. Like the compiler, it splits strings, slices, interfaces, complex
+// numbers and small structs into their fields, which are passed as separate
+// parameters. For example, a string parameter p0 becomes two parameters:
+// "const char* p0_data" and "GoUintptr p0_len".
+func cHeaderParams(name string, typ types.Type) ([]string, error) {
+	params, err := cHeaderFields(name, typ)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) > 3 {
+		// The compiler passes larger structs as a single value, which can't be
+		// declared in C (see maxFieldsPerParam in the compiler).
+		return nil, fmt.Errorf("unsupported type in exported function: %s (struct parameters may have at most 3 fields)", typ)
+	}
+	return params, nil
+}
+
+// cHeaderFields returns the flattened fields of the given type as C parameter
+// declarations, with the field names appended to the given name.
+func cHeaderFields(name string, typ types.Type) ([]string, error) {
+	switch typ := typ.Underlying().(type) {
+	case *types.Basic:
+		switch typ.Kind() {
+		case types.String:
+			return []string{"const char* " + name + "_data", "GoUintptr " + name + "_len"}, nil
+		case types.Complex64:
+			return []string{"GoFloat32 " + name + "_r", "GoFloat32 " + name + "_i"}, nil
+		case types.Complex128:
+			return []string{"GoFloat64 " + name + "_r", "GoFloat64 " + name + "_i"}, nil
+		}
+	case *types.Slice:
+		return []string{"void* " + name + "_data", "GoUintptr " + name + "_len", "GoUintptr " + name + "_cap"}, nil
+	case *types.Interface:
+		return []string{"void* " + name + "_typecode", "void* " + name + "_value"}, nil
+	case *types.Struct:
+		var fields []string
+		for i := 0; i < typ.NumFields(); i++ {
+			field := typ.Field(i)
+			if isZeroSized(field.Type()) {
+				// Zero sized fields are not passed at all.
+				continue
+			}
+			fieldName := field.Name()
+			if fieldName == "_" {
+				fieldName = strconv.Itoa(i)
+			}
+			subfields, err := cHeaderFields(name+"_"+fieldName, field.Type())
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, subfields...)
+		}
+		return fields, nil
+	}
+	t, err := cHeaderType(typ)
+	if err != nil {
+		return nil, err
+	}
+	return []string{t + " " + name}, nil
+}
+
+// isZeroSized returns whether the given type has a size of zero, like struct{}
+// and [0]int. This doesn't depend on the target architecture.
+func isZeroSized(typ types.Type) bool {
+	switch typ := typ.Underlying().(type) {
+	case *types.Array:
+		return typ.Len() == 0 || isZeroSized(typ.Elem())
+	case *types.Struct:
+		for i := 0; i < typ.NumFields(); i++ {
+			if !isZeroSized(typ.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// cHeaderType returns the C type name for the given Go type, as used in
+// exported function signatures.
+func cHeaderType(typ types.Type) (string, error) {
+	switch typ := typ.Underlying().(type) {
+	case *types.Basic:
+		switch typ.Kind() {
+		case types.Bool:
+			return "GoBool", nil
+		case types.Int:
+			return "GoInt", nil
+		case types.Int8:
+			return "GoInt8", nil
+		case types.Int16:
+			return "GoInt16", nil
+		case types.Int32:
+			return "GoInt32", nil
+		case types.Int64:
+			return "GoInt64", nil
+		case types.Uint:
+			return "GoUint", nil
+		case types.Uint8:
+			return "GoUint8", nil
+		case types.Uint16:
+			return "GoUint16", nil
+		case types.Uint32:
+			return "GoUint32", nil
+		case types.Uint64:
+			return "GoUint64", nil
+		case types.Uintptr:
+			return "GoUintptr", nil
+		case types.Float32:
+			return "GoFloat32", nil
+		case types.Float64:
+			return "GoFloat64", nil
+		case types.Complex64:
+			return "GoComplex64", nil
+		case types.Complex128:
+			return "GoComplex128", nil
+		case types.String:
+			return "GoString", nil
+		case types.UnsafePointer:
+			return "void*", nil
+		}
+	case *types.Pointer, *types.Map, *types.Chan:
+		// These are all pointers in TinyGo. Their contents are opaque to C.
+		return "void*", nil
+	case *types.Slice:
+		return "GoSlice", nil
+	case *types.Interface:
+		return "GoInterface", nil
+	}
+	return "", fmt.Errorf("unsupported type in exported function: %s", typ)
+}
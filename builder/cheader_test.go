@@ -0,0 +1,58 @@
+package builder
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+// Test that parameters of exported functions are declared in the C header the
+// same way as the compiler passes them.
+func TestCHeaderParams(t *testing.T) {
+	const src = `package p
+type Small struct {
+	A int32
+	_ struct{}
+	B string
+}
+type Big struct {
+	A, B, C, D int
+}
+func F(string, []byte, interface{}, complex64, Small, *Big, struct{}, uintptr, Big) {}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal("could not parse test source:", err)
+	}
+	pkg, err := (&types.Config{}).Check("p", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal("could not typecheck test source:", err)
+	}
+	sig := pkg.Scope().Lookup("F").Type().(*types.Signature)
+
+	for i, expected := range []string{
+		"const char* p_data, GoUintptr p_len",
+		"void* p_data, GoUintptr p_len, GoUintptr p_cap",
+		"void* p_typecode, void* p_value",
+		"GoFloat32 p_r, GoFloat32 p_i",
+		"GoInt32 p_A, const char* p_B_data, GoUintptr p_B_len",
+		"void* p",
+		"",
+		"GoUintptr p",
+		"error: unsupported type in exported function: p.Big (struct parameters may have at most 3 fields)",
+	} {
+		typ := sig.Params().At(i).Type()
+		params, err := cHeaderParams("p", typ)
+		result := strings.Join(params, ", ")
+		if err != nil {
+			result = "error: " + err.Error()
+		}
+		if result != expected {
+			t.Errorf("unexpected C parameters for %s:\nexpected: %s\nactual:   %s", typ, expected, result)
+		}
+	}
+}
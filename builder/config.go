@@ -39,6 +39,46 @@ func NewConfig(options *compileopts.Options) (*compileopts.Config, error) {
 		return nil, fmt.Errorf("requires go version 1.18 through 1.20, got go%d.%d", major, minor)
 	}
 
+	buildMode := options.BuildMode
+	if buildMode == "" {
+		buildMode = spec.BuildMode
+	}
+	if spec.BuildMode != "" && buildMode != spec.BuildMode {
+		return nil, fmt.Errorf("target %s only supports -buildmode=%s", options.Target, spec.BuildMode)
+	}
+	switch buildMode {
+	case "c-shared":
+		if spec.GOOS != "android" {
+			return nil, fmt.Errorf("-buildmode=c-shared is only supported for GOOS=android, not for %s", spec.GOOS)
+		}
+	case "c-archive":
+		// Generic Cortex-M targets (like cortex-m4) can be used to run Go
+		// code as a task under an RTOS. Targets for specific chips have a
+		// linker script and startup code, which conflict with the firmware.
+		isGenericCortexM := false
+		for _, tag := range spec.BuildTags {
+			if tag == "cortexm" && spec.LinkerScript == "" {
+				isGenericCortexM = true
+			}
+		}
+		if spec.GOOS != "ios" && !isGenericCortexM {
+			target := options.Target
+			if target == "" {
+				target = spec.GOOS + "/" + spec.GOARCH
+			}
+			return nil, fmt.Errorf("-buildmode=c-archive is only supported for -target=ios and generic Cortex-M targets (like -target=cortex-m4), not for %s", target)
+		}
+	}
+	if buildMode == "c-archive" && spec.GOOS != "ios" {
+		// Goroutines are supported when running under an RTOS, but only with
+		// the tasks scheduler.
+		if options.Scheduler != "" && options.Scheduler != "none" && options.Scheduler != "tasks" {
+			return nil, fmt.Errorf("-buildmode=c-archive for an RTOS only supports -scheduler=tasks or -scheduler=none, not %s", options.Scheduler)
+		}
+	} else if (buildMode == "c-shared" || buildMode == "c-archive") && options.Scheduler != "" && options.Scheduler != "none" {
+		return nil, fmt.Errorf("-buildmode=%s does not support goroutines, use -scheduler=none", buildMode)
+	}
+
 	clangHeaderPath := getClangHeaderPath(goenv.Get("TINYGOROOT"))
 
 	return &compileopts.Config{
@@ -0,0 +1,83 @@
+package builder
+
+import (
+	"github.com/tinygo-org/tinygo/compileopts"
+)
+
+// List of functions in FreeBSD libc.so.7 that may be used by TinyGo programs.
+// This includes the functions used by the runtime and syscall packages, and
+// some commonly used functions from CGo.
+var freebsdLibcFunctions = []string{
+	"__error",
+	"abort",
+	"arc4random",
+	"arc4random_buf",
+	"atexit",
+	"calloc",
+	"chdir",
+	"chmod",
+	"clock_gettime",
+	"close",
+	"closedir",
+	"dup",
+	"exit",
+	"fdopendir",
+	"fflush",
+	"fprintf",
+	"free",
+	"fstat",
+	"fsync",
+	"fwrite",
+	"getenv",
+	"getpagesize",
+	"getpid",
+	"lseek",
+	"lstat",
+	"malloc",
+	"memcmp",
+	"memcpy",
+	"memmove",
+	"memset",
+	"mkdir",
+	"mmap",
+	"mprotect",
+	"munmap",
+	"nanosleep",
+	"open",
+	"pipe2",
+	"pread",
+	"printf",
+	"putchar",
+	"puts",
+	"pwrite",
+	"read",
+	"readdir_r",
+	"readlink",
+	"realloc",
+	"rename",
+	"rmdir",
+	"setenv",
+	"snprintf",
+	"stat",
+	"strcmp",
+	"strlen",
+	"strncmp",
+	"symlink",
+	"unlink",
+	"unsetenv",
+	"usleep",
+	"write",
+}
+
+// List of data symbols in FreeBSD libc.so.7 (all of them pointer sized).
+var freebsdLibcObjects = []string{
+	"__stderrp",
+	"__stdinp",
+	"__stdoutp",
+}
+
+// Create a job that builds a FreeBSD libc.so.7 stub library. At runtime, the
+// real libc shipped with the OS is loaded by the runtime linker.
+func makeFreeBSDLibcJob(config *compileopts.Config, tmpdir string) *compileJob {
+	return makeStubLibraryJob(config, tmpdir, "FreeBSD", "libc.so.7", freebsdLibcFunctions, freebsdLibcObjects)
+}
@@ -32,7 +32,7 @@ type Library struct {
 	librarySources func(target string) ([]string, error)
 
 	// The source code for the crt1.o file, relative to sourceDir.
-	crt1Source string
+	crt1Source func(config *compileopts.Config) string
 }
 
 // Load the library archive, possibly generating and caching it if needed.
@@ -165,6 +165,11 @@ func (l *Library) load(config *compileopts.Config, tmpdir string) (job *compileJ
 			args = append(args, "-fshort-enums", "-fomit-frame-pointer", "-mfloat-abi=soft", "-fno-unwind-tables", "-fno-asynchronous-unwind-tables")
 		}
 	}
+	if config.RelocationModel() == "pic" {
+		// The library is linked into a position independent executable or
+		// shared library.
+		args = append(args, "-fPIC")
+	}
 	if strings.HasPrefix(target, "avr") {
 		// AVR defaults to C float and double both being 32-bit. This deviates
 		// from what most code (and certainly compiler-rt) expects. So we need
@@ -258,8 +263,8 @@ func (l *Library) load(config *compileopts.Config, tmpdir string) (job *compileJ
 	// Add this as a (fake) dependency to the ar file so it gets compiled.
 	// (It could be done in parallel with creating the ar file, but it probably
 	// won't make much of a difference in speed).
-	if l.crt1Source != "" {
-		srcpath := filepath.Join(sourceDir, l.crt1Source)
+	if l.crt1Source != nil {
+		srcpath := filepath.Join(sourceDir, l.crt1Source(config))
 		job.dependencies = append(job.dependencies, &compileJob{
 			description: "compile " + srcpath,
 			run: func(*compileJob) error {
@@ -0,0 +1,348 @@
+package builder
+
+// This file creates the table that is used by runtime.Caller and
+// runtime.Callers to map PCs to functions and source locations (-linetable).
+//
+// The table can only be created after linking, as it contains addresses.
+// Therefore the program is linked twice: first with an empty table, to find
+// the function addresses and line information, and then with the real table.
+// Adding the table changes the layout of the executable, so the addresses in
+// the table are patched afterwards. The size of the table doesn't depend on
+// the layout, so this is always possible.
+//
+// The table has the following format, using the byte order of the target:
+//
+//	tableAddr uintptr // link-time address of the table itself
+//	base      uintptr // address of the first function
+//	numFuncs  uint32
+//	numFiles  uint32
+//	funcs     [numFuncs]struct{ start, size, name, lines uint32 }
+//	files     [numFiles]uint32
+//	data      []byte
+//
+// Functions are sorted by start address, which is stored as an offset from
+// base. Names and lines are offsets from the start of the table into data.
+// Strings are stored as an uvarint length followed by the string itself. The
+// lines of a function are stored as an uvarint number of rows, followed by the
+// rows. Every row is an uvarint PC delta (starting at the function start), an
+// uvarint index into files, and a varint line delta (starting at 0).
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+	"github.com/tinygo-org/tinygo/compiler"
+	"tinygo.org/x/go-llvm"
+)
+
+// Name of the table symbol and section.
+const (
+	lineTableSymbol  = "tinygo_pcln"
+	lineTableSection = ".tinygo_pcln"
+)
+
+// checkLineTable returns an error if -linetable isn't supported with the given
+// configuration.
+func checkLineTable(config *compileopts.Config) error {
+	if !config.Debug() || config.Options.SkipDWARF {
+		return errors.New("-linetable needs debug information and can't be combined with -no-debug")
+	}
+	if config.BuildMode() != "default" {
+		return fmt.Errorf("-linetable is not supported with -buildmode=%s", config.BuildMode())
+	}
+	if config.Target.Linker != "ld.lld" || config.GOOS() == "darwin" || config.GOOS() == "windows" {
+		// The table is created from the DWARF information in the ELF file.
+		return errors.New("-linetable is only supported for ELF executables")
+	}
+	arch := strings.Split(config.Triple(), "-")[0]
+	switch {
+	case arch == "x86_64", arch == "i386", arch == "i686", arch == "aarch64":
+	case strings.HasPrefix(arch, "arm"), strings.HasPrefix(arch, "thumb"):
+	case arch == "riscv32", arch == "riscv64":
+	default:
+		// The runtime only knows where frame records are stored on the
+		// architectures above.
+		return fmt.Errorf("-linetable is not supported on %s", arch)
+	}
+	return nil
+}
+
+// lineTableFunction is a single function in the line table.
+type lineTableFunction struct {
+	name  string
+	start uint64
+	size  uint64
+	rows  []lineTableRow
+}
+
+// lineTableRow is a single row of the DWARF line table.
+type lineTableRow struct {
+	address uint64
+	file    string
+	line    int
+}
+
+// makeLineTable creates the line table for the given executable. The size of
+// the returned table only depends on the code of the executable, not on where
+// it is placed in memory.
+func makeLineTable(executable string) ([]byte, error) {
+	f, err := elf.Open(executable)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ptrSize int
+	switch f.Class {
+	case elf.ELFCLASS32:
+		ptrSize = 4
+	case elf.ELFCLASS64:
+		ptrSize = 8
+	default:
+		return nil, fmt.Errorf("unknown ELF class: %s", f.Class)
+	}
+
+	// Find the address of the table itself.
+	var tableAddr uint64
+	if section := f.Section(lineTableSection); section != nil {
+		tableAddr = section.Addr
+	} else {
+		return nil, fmt.Errorf("could not find %s section", lineTableSection)
+	}
+
+	// Read all functions from the symbol table.
+	symbols, err := f.Symbols()
+	if err != nil {
+		return nil, err
+	}
+	var functions []*lineTableFunction
+	seen := make(map[uint64]bool)
+	for _, symbol := range symbols {
+		if elf.ST_TYPE(symbol.Info) != elf.STT_FUNC || symbol.Size == 0 {
+			continue
+		}
+		start := symbol.Value
+		if f.Machine == elf.EM_ARM {
+			// Clear the Thumb bit.
+			start &^= 1
+		}
+		if seen[start] {
+			// Aliases share the same code, only use the first name.
+			continue
+		}
+		seen[start] = true
+		functions = append(functions, &lineTableFunction{
+			name:  symbol.Name,
+			start: start,
+			size:  symbol.Size,
+		})
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		return functions[i].start < functions[j].start
+	})
+
+	// Read all rows of the DWARF line table.
+	dwarfData, err := f.DWARF()
+	if err != nil {
+		return nil, err
+	}
+	var rows []lineTableRow
+	reader := dwarfData.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			break
+		}
+		if entry.Tag != dwarf.TagCompileUnit {
+			reader.SkipChildren()
+			continue
+		}
+		lineReader, err := dwarfData.LineReader(entry)
+		if err != nil {
+			return nil, err
+		}
+		reader.SkipChildren()
+		if lineReader == nil {
+			continue
+		}
+		for {
+			var lineEntry dwarf.LineEntry
+			err := lineReader.Next(&lineEntry)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if lineEntry.EndSequence || lineEntry.File == nil {
+				continue
+			}
+			rows = append(rows, lineTableRow{
+				address: lineEntry.Address,
+				file:    lineEntry.File.Name,
+				line:    lineEntry.Line,
+			})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].address < rows[j].address
+	})
+
+	// Assign the rows to functions.
+	for _, fn := range functions {
+		i := sort.Search(len(rows), func(i int) bool {
+			return rows[i].address > fn.start
+		})
+		if i > 0 && rows[i-1].address <= fn.start {
+			// The function starts inside this row (or exactly at the start).
+			i--
+		}
+		for ; i < len(rows) && rows[i].address < fn.start+fn.size; i++ {
+			row := rows[i]
+			if row.address < fn.start {
+				row.address = fn.start
+			}
+			if n := len(fn.rows); n != 0 && fn.rows[n-1].address == row.address {
+				// Only the last row for an address is relevant.
+				fn.rows[n-1] = row
+				continue
+			}
+			fn.rows = append(fn.rows, row)
+		}
+	}
+
+	// Encode the functions, their rows and the file names in the data part
+	// of the table.
+	var base uint64
+	if len(functions) != 0 {
+		base = functions[0].start
+	}
+	var data bytes.Buffer
+	putUvarint := func(n uint64) {
+		var buf [binary.MaxVarintLen64]byte
+		data.Write(buf[:binary.PutUvarint(buf[:], n)])
+	}
+	putVarint := func(n int64) {
+		var buf [binary.MaxVarintLen64]byte
+		data.Write(buf[:binary.PutVarint(buf[:], n)])
+	}
+	putString := func(s string) uint32 {
+		offset := uint32(data.Len())
+		putUvarint(uint64(len(s)))
+		data.WriteString(s)
+		return offset
+	}
+	files := make(map[string]uint32)
+	var fileNames []string
+	funcEntries := make([][4]uint32, len(functions))
+	for i, fn := range functions {
+		funcEntries[i] = [4]uint32{
+			uint32(fn.start - base),
+			uint32(fn.size),
+			putString(fn.name),
+			uint32(data.Len()),
+		}
+		putUvarint(uint64(len(fn.rows)))
+		address := fn.start
+		line := 0
+		for _, row := range fn.rows {
+			index, ok := files[row.file]
+			if !ok {
+				index = uint32(len(fileNames))
+				files[row.file] = index
+				fileNames = append(fileNames, row.file)
+			}
+			putUvarint(row.address - address)
+			putUvarint(uint64(index))
+			putVarint(int64(row.line - line))
+			address = row.address
+			line = row.line
+		}
+	}
+	fileEntries := make([]uint32, len(fileNames))
+	for i, name := range fileNames {
+		fileEntries[i] = putString(name)
+	}
+
+	// Now that the size of the header is known, put everything together.
+	order := f.ByteOrder
+	dataStart := uint32(2*ptrSize + 8 + len(funcEntries)*16 + len(fileEntries)*4)
+	table := make([]byte, int(dataStart)+data.Len())
+	pos := 0
+	putPtr := func(n uint64) {
+		if ptrSize == 4 {
+			order.PutUint32(table[pos:], uint32(n))
+		} else {
+			order.PutUint64(table[pos:], n)
+		}
+		pos += ptrSize
+	}
+	putUint32 := func(n uint32) {
+		order.PutUint32(table[pos:], n)
+		pos += 4
+	}
+	putPtr(tableAddr)
+	putPtr(base)
+	putUint32(uint32(len(funcEntries)))
+	putUint32(uint32(len(fileEntries)))
+	for _, entry := range funcEntries {
+		putUint32(entry[0])
+		putUint32(entry[1])
+		putUint32(dataStart + entry[2])
+		putUint32(dataStart + entry[3])
+	}
+	for _, offset := range fileEntries {
+		putUint32(dataStart + offset)
+	}
+	copy(table[pos:], data.Bytes())
+	return table, nil
+}
+
+// createLineTableObjectFile creates an object file that contains the given
+// line table in the .tinygo_pcln section, under the tinygo_pcln symbol.
+func createLineTableObjectFile(table []byte, tmpdir string, compilerConfig *compiler.Config) (string, error) {
+	ctx := llvm.NewContext()
+	defer ctx.Dispose()
+	mod := ctx.NewModule("linetable")
+	defer mod.Dispose()
+
+	value := ctx.ConstString(string(table), false)
+	global := llvm.AddGlobal(mod, value.Type(), lineTableSymbol)
+	global.SetInitializer(value)
+	global.SetGlobalConstant(true)
+	global.SetSection(lineTableSection)
+	global.SetAlignment(8)
+
+	machine, err := compiler.NewTargetMachine(compilerConfig)
+	if err != nil {
+		return "", err
+	}
+	defer machine.Dispose()
+	outfile, err := os.CreateTemp(tmpdir, "linetable-*.o")
+	if err != nil {
+		return "", err
+	}
+	defer outfile.Close()
+	buf, err := machine.EmitToMemoryBuffer(mod, llvm.ObjectFile)
+	if err != nil {
+		return "", err
+	}
+	defer buf.Dispose()
+	_, err = outfile.Write(buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return outfile.Name(), outfile.Close()
+}
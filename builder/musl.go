@@ -177,5 +177,13 @@ var Musl = Library{
 		}
 		return sources, nil
 	},
-	crt1Source: "../crt/crt1.c", // lib/musl/crt/crt1.c
+	crt1Source: func(config *compileopts.Config) string {
+		if config.RelocationModel() == "pic" {
+			// Static PIE, which applies its own relocations at startup. This
+			// is used on systems without a MMU, where the kernel loads the
+			// executable at any free address.
+			return "../crt/rcrt1.c" // lib/musl/crt/rcrt1.c
+		}
+		return "../crt/crt1.c" // lib/musl/crt/crt1.c
+	},
 }
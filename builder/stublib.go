@@ -0,0 +1,74 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+)
+
+// Create a job that builds a stub shared library with the given soname. This
+// library contains all the symbols needed so that we can link against it, but
+// it doesn't contain any real symbol implementations. This avoids needing a
+// sysroot of the target OS to link against the system libc.
+// All objects are assumed to be pointer sized on a 64-bit system.
+func makeStubLibraryJob(config *compileopts.Config, tmpdir, osName, soname string, functions, objects []string) *compileJob {
+	return &compileJob{
+		description: "compile " + osName + " " + soname,
+		run: func(job *compileJob) (err error) {
+			job.result = filepath.Join(tmpdir, soname)
+			srcpath := filepath.Join(tmpdir, soname+".s")
+			objpath := filepath.Join(tmpdir, soname+".o")
+
+			// Create an assembly file with all the stub symbols.
+			buf := &strings.Builder{}
+			buf.WriteString(".text\n")
+			for _, name := range functions {
+				buf.WriteString(".globl " + name + "\n")
+				buf.WriteString(".type " + name + ", %function\n")
+				buf.WriteString(name + ":\n")
+			}
+			buf.WriteString(".data\n")
+			for _, name := range objects {
+				buf.WriteString(".globl " + name + "\n")
+				buf.WriteString(".type " + name + ", %object\n")
+				buf.WriteString(".size " + name + ", 8\n")
+				buf.WriteString(name + ":\n")
+				buf.WriteString(".quad 0\n")
+			}
+			err = os.WriteFile(srcpath, []byte(buf.String()), 0o666)
+			if err != nil {
+				return err
+			}
+
+			// Compile assembly file to object file.
+			flags := []string{
+				"-nostdlib",
+				"--target=" + config.Triple(),
+				"-c",
+				"-o", objpath,
+				srcpath,
+			}
+			if config.Options.PrintCommands != nil {
+				config.Options.PrintCommands("clang", flags...)
+			}
+			err = runCCompiler(flags...)
+			if err != nil {
+				return err
+			}
+
+			// Link object file to shared library.
+			flags = []string{
+				"-shared",
+				"-soname", soname,
+				"-o", job.result,
+				objpath,
+			}
+			if config.Options.PrintCommands != nil {
+				config.Options.PrintCommands("ld.lld", flags...)
+			}
+			return link("ld.lld", flags...)
+		},
+	}
+}
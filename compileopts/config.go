@@ -47,6 +47,20 @@ func (c *Config) Features() string {
 	return c.Target.Features + "," + c.Options.LLVMFeatures
 }
 
+// WasmSIMD returns whether this is a WebAssembly target with the simd128
+// feature enabled, for example using -llvm-features=+simd128.
+func (c *Config) WasmSIMD() bool {
+	if !strings.HasPrefix(c.Triple(), "wasm") {
+		return false
+	}
+	for _, feature := range strings.Split(c.Features(), ",") {
+		if feature == "+simd128" {
+			return true
+		}
+	}
+	return false
+}
+
 // ABI returns the -mabi= flag for this target (like -mabi=lp64). A zero-length
 // string is returned if the target doesn't specify an ABI.
 func (c *Config) ABI() string {
@@ -73,16 +87,92 @@ func (c *Config) GOARM() string {
 	return c.Options.GOARM
 }
 
+// GOMIPS will return the GOMIPS environment variable given to the compiler when
+// building a program.
+func (c *Config) GOMIPS() string {
+	return c.Options.GOMIPS
+}
+
 // BuildTags returns the complete list of build tags used during this build.
 func (c *Config) BuildTags() []string {
-	tags := append(c.Target.BuildTags, []string{"tinygo", "math_big_pure_go", "gc." + c.GC(), "scheduler." + c.Scheduler(), "serial." + c.Serial()}...)
+	tags := append(c.Target.BuildTags, []string{"tinygo", "math_big_pure_go", "gc." + c.GC(), "allocator." + c.Allocator(), "scheduler." + c.Scheduler(), "serial." + c.Serial()}...)
 	for i := 1; i <= c.GoMinorVersion; i++ {
 		tags = append(tags, fmt.Sprintf("go1.%d", i))
 	}
+	if c.Scheduler() == "cores" && c.Asyncify() {
+		// The cores scheduler runs goroutines on more than one thread, each
+		// of which switches between them using asyncify.
+		tags = append(tags, "scheduler.asyncify")
+	} else if c.Scheduler() == "cores" {
+		// The cores scheduler runs tasks on more than one core, using the
+		// same stack switching code.
+		tags = append(tags, "scheduler.tasks")
+	}
+	if c.LineTable() {
+		// The runtime can resolve PCs to source locations.
+		tags = append(tags, "tinygo.linetable")
+	}
+	if c.AutoYield() {
+		// The compiler inserts calls to runtime.safepoint in loops.
+		tags = append(tags, "tinygo.autoyield")
+	}
+	if c.Target.ExternalRAMSize != "" {
+		// Large buffers can be allocated in external RAM.
+		tags = append(tags, "tinygo.extram")
+	}
+	if c.WasmSIMD() {
+		// Code can choose between a SIMD and a scalar implementation.
+		tags = append(tags, "wasm.simd128")
+	}
+	if c.RunsUnderRTOS() {
+		// The runtime gets its heap and stack from the RTOS firmware, instead
+		// of from the linker script.
+		tags = append(tags, "tinygo.rtos")
+	} else if c.BuildsLibrary() {
+		// The runtime needs to know it is part of a library instead of an
+		// executable, for example to not define a main function.
+		tags = append(tags, "tinygo.library")
+	}
 	tags = append(tags, c.Options.Tags...)
 	return tags
 }
 
+// BuildMode returns the build mode (-buildmode flag). Valid values are
+// "default" (an executable), "c-shared" (a shared library that can be loaded
+// from C) and "c-archive" (a static library that can be linked into a C
+// program).
+func (c *Config) BuildMode() string {
+	if c.Options.BuildMode != "" {
+		return c.Options.BuildMode
+	}
+	if c.Target.BuildMode != "" {
+		return c.Target.BuildMode
+	}
+	return "default"
+}
+
+// BuildsLibrary returns whether the build output is a library that is used
+// from C, instead of an executable.
+func (c *Config) BuildsLibrary() bool {
+	mode := c.BuildMode()
+	return mode == "c-shared" || mode == "c-archive"
+}
+
+// RunsUnderRTOS returns whether the program is built as a static library that
+// runs as a task in existing RTOS firmware. This is the case for
+// -buildmode=c-archive on baremetal targets.
+func (c *Config) RunsUnderRTOS() bool {
+	if c.BuildMode() != "c-archive" {
+		return false
+	}
+	for _, tag := range c.Target.BuildTags {
+		if tag == "baremetal" {
+			return true
+		}
+	}
+	return false
+}
+
 // CgoEnabled returns true if (and only if) CGo is enabled. It is true by
 // default and false if CGO_ENABLED is set to "0".
 func (c *Config) CgoEnabled() bool {
@@ -90,7 +180,8 @@ func (c *Config) CgoEnabled() bool {
 }
 
 // GC returns the garbage collection strategy in use on this platform. Valid
-// values are "none", "leaking", "conservative" and "precise".
+// values are "none", "leaking", "conservative", "precise", "incremental" and
+// "compacting".
 func (c *Config) GC() string {
 	if c.Options.GC != "" {
 		return c.Options.GC
@@ -101,11 +192,22 @@ func (c *Config) GC() string {
 	return "conservative"
 }
 
+// Allocator returns the strategy the block-based garbage collectors use to find
+// free memory. Valid values are "linear" (a next-fit scan over the heap, which
+// is small but slow on large heaps) and "tlsf" (segregated free lists, which
+// take more RAM but allocate in constant time).
+func (c *Config) Allocator() string {
+	if c.Options.Allocator != "" {
+		return c.Options.Allocator
+	}
+	return "linear"
+}
+
 // NeedsStackObjects returns true if the compiler should insert stack objects
 // that can be traced by the garbage collector.
 func (c *Config) NeedsStackObjects() bool {
 	switch c.GC() {
-	case "conservative", "custom", "precise":
+	case "conservative", "custom", "precise", "incremental", "compacting":
 		for _, tag := range c.BuildTags() {
 			if tag == "tinygo.wasm" {
 				return true
@@ -119,11 +221,16 @@ func (c *Config) NeedsStackObjects() bool {
 }
 
 // Scheduler returns the scheduler implementation. Valid values are "none",
-// "asyncify" and "tasks".
+// "asyncify", "tasks" and "cores".
 func (c *Config) Scheduler() string {
 	if c.Options.Scheduler != "" {
 		return c.Options.Scheduler
 	}
+	if c.BuildsLibrary() && !c.RunsUnderRTOS() {
+		// Exported functions are called directly from C, outside of any
+		// goroutine. There is no main goroutine to run a scheduler in.
+		return "none"
+	}
 	if c.Target.Scheduler != "" {
 		return c.Target.Scheduler
 	}
@@ -131,8 +238,23 @@ func (c *Config) Scheduler() string {
 	return "none"
 }
 
+// Asyncify returns whether goroutines are switched using the asyncify
+// transform of wasm-opt. This is the case with -scheduler=asyncify, and with
+// -scheduler=cores on WebAssembly: every thread then switches between
+// goroutines in the same way.
+func (c *Config) Asyncify() bool {
+	switch c.Scheduler() {
+	case "asyncify":
+		return true
+	case "cores":
+		return strings.HasPrefix(c.Triple(), "wasm")
+	default:
+		return false
+	}
+}
+
 // Serial returns the serial implementation for this build configuration: uart,
-// usb (meaning USB-CDC), or none.
+// usb (meaning USB-CDC), rtt (meaning SEGGER RTT), itm (meaning SWO), or none.
 func (c *Config) Serial() string {
 	if c.Options.Serial != "" {
 		return c.Options.Serial
@@ -171,11 +293,47 @@ func (c *Config) PanicStrategy() string {
 	return c.Options.PanicStrategy
 }
 
+// Reflect returns how much type information is included for the reflect
+// package. Valid values are "full" (the default) and "min", which drops the
+// names and package paths of named types to reduce binary size.
+func (c *Config) Reflect() string {
+	if c.Options.Reflect != "" {
+		return c.Options.Reflect
+	}
+	return "full"
+}
+
+// LineTable returns whether a table should be included in the binary to map
+// PCs to functions and source locations, for runtime.Caller and
+// runtime.Callers. This also keeps frame pointers, to be able to walk the
+// stack.
+func (c *Config) LineTable() bool {
+	return c.Options.LineTable
+}
+
+// AutoYield returns whether the compiler inserts yield points in every loop, so
+// that a goroutine that runs for a long time without blocking gives other
+// goroutines a chance to run. It is enabled with -autoyield for the schedulers
+// that switch stacks.
+func (c *Config) AutoYield() bool {
+	switch c.Scheduler() {
+	case "tasks", "cores":
+		return c.Options.AutoYield && !c.Asyncify()
+	default:
+		return false
+	}
+}
+
 // AutomaticStackSize returns whether goroutine stack sizes should be determined
 // automatically at compile time, if possible. If it is false, no attempt is
 // made.
 func (c *Config) AutomaticStackSize() bool {
-	if c.Target.AutoStackSize != nil && c.Scheduler() == "tasks" {
+	if c.BuildsLibrary() {
+		// Stack sizes are read from the linked executable, which doesn't exist
+		// when building a library.
+		return false
+	}
+	if c.Target.AutoStackSize != nil && (c.Scheduler() == "tasks" || c.Scheduler() == "cores") {
 		return *c.Target.AutoStackSize
 	}
 	return false
@@ -206,6 +364,9 @@ func MuslArchitecture(triple string) string {
 	if strings.HasPrefix(arch, "arm") || strings.HasPrefix(arch, "thumb") {
 		arch = "arm"
 	}
+	if arch == "mipsel" {
+		arch = "mips"
+	}
 	return arch
 }
 
@@ -220,6 +381,10 @@ func (c *Config) LibcPath(name string) (path string, precompiled bool) {
 	if c.ABI() != "" {
 		archname += "-" + c.ABI()
 	}
+	if c.GOARCH() == "mips" || c.GOARCH() == "mipsle" {
+		// Hardfloat and softfloat libraries are not compatible with each other.
+		archname += "-" + c.GOMIPS()
+	}
 
 	// Try to load a precompiled library.
 	precompiledDir := filepath.Join(goenv.Get("TINYGOROOT"), "pkg", archname, name)
@@ -238,7 +403,7 @@ func (c *Config) LibcPath(name string) (path string, precompiled bool) {
 // .exe, .wasm, or no extension (depending on the target).
 func (c *Config) DefaultBinaryExtension() string {
 	parts := strings.Split(c.Triple(), "-")
-	if parts[0] == "wasm32" {
+	if parts[0] == "wasm32" || parts[0] == "wasm64" {
 		// WebAssembly files always have the .wasm file extension.
 		return ".wasm"
 	}
@@ -246,6 +411,13 @@ func (c *Config) DefaultBinaryExtension() string {
 		// Windows uses .exe.
 		return ".exe"
 	}
+	switch c.BuildMode() {
+	case "c-shared":
+		// Shared libraries on Linux (and Android) use the .so file extension.
+		return ".so"
+	case "c-archive":
+		return ".a"
+	}
 	if len(parts) >= 3 && parts[2] == "unknown" {
 		// There appears to be a convention to use the .elf file extension for
 		// ELF files intended for microcontrollers. I'm not aware of the origin
@@ -270,6 +442,10 @@ func (c *Config) CFlags() []string {
 		cflags = append(cflags,
 			"--sysroot="+filepath.Join(root, "lib/macos-minimal-sdk/src"),
 		)
+	case "freebsd-libc", "android-libc":
+		// There are no FreeBSD or Android headers in TinyGo. Only the headers
+		// that come with Clang (stddef.h etc) are available.
+		cflags = append(cflags, "-nostdlibinc")
 	case "picolibc":
 		root := goenv.Get("TINYGOROOT")
 		picolibcDir := filepath.Join(root, "lib", "picolibc", "newlib", "libc")
@@ -309,6 +485,11 @@ func (c *Config) CFlags() []string {
 		// usually this will be found by developers (not by TinyGo users).
 		panic("unknown libc: " + c.Target.Libc)
 	}
+	if c.BuildsLibrary() && !c.RunsUnderRTOS() {
+		// The library may be loaded or linked at any address, so all code
+		// (including C code) must be position independent.
+		cflags = append(cflags, "-fPIC")
+	}
 	// Always emit debug information. It is optionally stripped at link time.
 	cflags = append(cflags, "-gdwarf-4")
 	// Use the same optimization level as TinyGo.
@@ -317,8 +498,9 @@ func (c *Config) CFlags() []string {
 	cflags = append(cflags, "--target="+c.Triple())
 	// Set the -mcpu (or similar) flag.
 	if c.Target.CPU != "" {
-		if c.GOARCH() == "amd64" || c.GOARCH() == "386" {
-			// x86 prefers the -march flag (-mcpu is deprecated there).
+		if c.GOARCH() == "amd64" || c.GOARCH() == "386" || c.GOARCH() == "mips" || c.GOARCH() == "mipsle" {
+			// x86 prefers the -march flag (-mcpu is deprecated there), and MIPS
+			// doesn't support -mcpu at all.
 			cflags = append(cflags, "-march="+c.Target.CPU)
 		} else if strings.HasPrefix(c.Triple(), "avr") {
 			// AVR MCUs use -mmcu instead of -mcpu.
@@ -332,6 +514,10 @@ func (c *Config) CFlags() []string {
 	if c.ABI() != "" {
 		cflags = append(cflags, "-mabi="+c.ABI())
 	}
+	// Let Clang use SIMD instructions in C code too.
+	if c.WasmSIMD() {
+		cflags = append(cflags, "-msimd128")
+	}
 	return cflags
 }
 
@@ -349,13 +535,36 @@ func (c *Config) LDFlags() []string {
 	if c.Target.LinkerScript != "" {
 		ldflags = append(ldflags, "-T", c.Target.LinkerScript)
 	}
+	if c.Target.ExternalRAMSize != "" {
+		// Tell the runtime where external RAM is, see src/runtime/extram.go.
+		ldflags = append(ldflags,
+			"--defsym=_extram_start="+c.Target.ExternalRAMStart,
+			"--defsym=_extram_end="+c.Target.ExternalRAMStart+"+"+c.Target.ExternalRAMSize)
+	}
 	return ldflags
 }
 
 // ExtraFiles returns the list of extra files to be built and linked with the
 // executable. This can include extra C and assembly files.
 func (c *Config) ExtraFiles() []string {
-	return c.Target.ExtraFiles
+	if !c.RunsUnderRTOS() {
+		return c.Target.ExtraFiles
+	}
+	// The RTOS firmware owns the exception handlers and the stack pointer
+	// configuration, so leave those alone.
+	var files []string
+	for _, path := range c.Target.ExtraFiles {
+		switch path {
+		case "src/device/arm/cortexm.S":
+			// Defines HardFault_Handler.
+			continue
+		case "src/internal/task/task_stack_cortexm.S":
+			// Switches between MSP and PSP.
+			path = "src/internal/task/task_stack_arm.S"
+		}
+		files = append(files, path)
+	}
+	return files
 }
 
 // DumpSSA returns whether to dump Go SSA while compiling (-dumpssa flag). Only
@@ -485,6 +694,11 @@ func (c *Config) CodeModel() string {
 // RelocationModel returns the relocation model in use on this platform. Valid
 // values are "static", "pic", "dynamicnopic".
 func (c *Config) RelocationModel() string {
+	if c.BuildsLibrary() && !c.RunsUnderRTOS() {
+		// Libraries can be loaded or linked at any address. Firmware is
+		// linked statically, so that doesn't apply to RTOS tasks.
+		return "pic"
+	}
 	if c.Target.RelocationModel != "" {
 		return c.Target.RelocationModel
 	}
@@ -8,12 +8,15 @@ import (
 )
 
 var (
-	validGCOptions            = []string{"none", "leaking", "conservative", "custom", "precise"}
-	validSchedulerOptions     = []string{"none", "tasks", "asyncify"}
-	validSerialOptions        = []string{"none", "uart", "usb"}
+	validGCOptions            = []string{"none", "leaking", "conservative", "custom", "precise", "incremental", "compacting"}
+	validSchedulerOptions     = []string{"none", "tasks", "asyncify", "cores"}
+	validAllocatorOptions     = []string{"linear", "tlsf"}
+	validSerialOptions        = []string{"none", "uart", "usb", "rtt", "itm"}
 	validPrintSizeOptions     = []string{"none", "short", "full"}
 	validPanicStrategyOptions = []string{"print", "trap"}
+	validReflectOptions       = []string{"full", "min"}
 	validOptOptions           = []string{"none", "0", "1", "2", "s", "z"}
+	validBuildModeOptions     = []string{"default", "c-shared", "c-archive"}
 )
 
 // Options contains extra options to give to the compiler. These options are
@@ -23,10 +26,16 @@ type Options struct {
 	GOOS            string // environment variable
 	GOARCH          string // environment variable
 	GOARM           string // environment variable (only used with GOARCH=arm)
+	GOMIPS          string // environment variable (only used with GOARCH=mips and GOARCH=mipsle)
 	Target          string
+	BuildMode       string // -buildmode flag
 	Opt             string
 	GC              string
+	Allocator       string // -allocator flag
 	PanicStrategy   string
+	Reflect         string // -reflect flag
+	LineTable       bool   // -linetable flag
+	AutoYield       bool   // -autoyield flag
 	Scheduler       string
 	StackSize       uint64 // goroutine stack size (if none could be automatically determined)
 	Serial          string
@@ -75,6 +84,15 @@ func (o *Options) Verify() error {
 		}
 	}
 
+	if o.Allocator != "" {
+		valid := isInArray(validAllocatorOptions, o.Allocator)
+		if !valid {
+			return fmt.Errorf(`invalid allocator option '%s': valid values are %s`,
+				o.Allocator,
+				strings.Join(validAllocatorOptions, ", "))
+		}
+	}
+
 	if o.Serial != "" {
 		valid := isInArray(validSerialOptions, o.Serial)
 		if !valid {
@@ -102,12 +120,27 @@ func (o *Options) Verify() error {
 		}
 	}
 
+	if o.Reflect != "" {
+		valid := isInArray(validReflectOptions, o.Reflect)
+		if !valid {
+			return fmt.Errorf(`invalid reflect option '%s': valid values are %s`,
+				o.Reflect,
+				strings.Join(validReflectOptions, ", "))
+		}
+	}
+
 	if o.Opt != "" {
 		if !isInArray(validOptOptions, o.Opt) {
 			return fmt.Errorf("invalid -opt=%s: valid values are %s", o.Opt, strings.Join(validOptOptions, ", "))
 		}
 	}
 
+	if o.BuildMode != "" {
+		if !isInArray(validBuildModeOptions, o.BuildMode) {
+			return fmt.Errorf("invalid -buildmode=%s: valid values are %s", o.BuildMode, strings.Join(validBuildModeOptions, ", "))
+		}
+	}
+
 	return nil
 }
 
@@ -9,10 +9,13 @@ import (
 
 func TestVerifyOptions(t *testing.T) {
 
-	expectedGCError := errors.New(`invalid gc option 'incorrect': valid values are none, leaking, conservative, custom, precise`)
-	expectedSchedulerError := errors.New(`invalid scheduler option 'incorrect': valid values are none, tasks, asyncify`)
+	expectedGCError := errors.New(`invalid gc option 'incorrect': valid values are none, leaking, conservative, custom, precise, incremental, compacting`)
+	expectedSchedulerError := errors.New(`invalid scheduler option 'incorrect': valid values are none, tasks, asyncify, cores`)
+	expectedAllocatorError := errors.New(`invalid allocator option 'incorrect': valid values are linear, tlsf`)
 	expectedPrintSizeError := errors.New(`invalid size option 'incorrect': valid values are none, short, full`)
 	expectedPanicStrategyError := errors.New(`invalid panic option 'incorrect': valid values are print, trap`)
+	expectedReflectError := errors.New(`invalid reflect option 'incorrect': valid values are full, min`)
+	expectedBuildModeError := errors.New(`invalid -buildmode=incorrect: valid values are default, c-shared, c-archive`)
 
 	testCases := []struct {
 		name          string
@@ -54,6 +57,19 @@ func TestVerifyOptions(t *testing.T) {
 				GC: "custom",
 			},
 		},
+		{
+			name: "InvalidAllocatorOption",
+			opts: compileopts.Options{
+				Allocator: "incorrect",
+			},
+			expectedError: expectedAllocatorError,
+		},
+		{
+			name: "AllocatorOptionTLSF",
+			opts: compileopts.Options{
+				Allocator: "tlsf",
+			},
+		},
 		{
 			name: "InvalidSchedulerOption",
 			opts: compileopts.Options{
@@ -117,6 +133,44 @@ func TestVerifyOptions(t *testing.T) {
 				PanicStrategy: "trap",
 			},
 		},
+		{
+			name: "InvalidReflectOption",
+			opts: compileopts.Options{
+				Reflect: "incorrect",
+			},
+			expectedError: expectedReflectError,
+		},
+		{
+			name: "ReflectOptionFull",
+			opts: compileopts.Options{
+				Reflect: "full",
+			},
+		},
+		{
+			name: "ReflectOptionMin",
+			opts: compileopts.Options{
+				Reflect: "min",
+			},
+		},
+		{
+			name: "InvalidBuildModeOption",
+			opts: compileopts.Options{
+				BuildMode: "incorrect",
+			},
+			expectedError: expectedBuildModeError,
+		},
+		{
+			name: "BuildModeOptionCShared",
+			opts: compileopts.Options{
+				BuildMode: "c-shared",
+			},
+		},
+		{
+			name: "BuildModeOptionCArchive",
+			opts: compileopts.Options{
+				BuildMode: "c-archive",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
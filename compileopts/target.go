@@ -33,7 +33,7 @@ type TargetSpec struct {
 	BuildTags        []string `json:"build-tags"`
 	GC               string   `json:"gc"`
 	Scheduler        string   `json:"scheduler"`
-	Serial           string   `json:"serial"` // which serial output to use (uart, usb, none)
+	Serial           string   `json:"serial"` // which serial output to use (uart, usb, rtt, itm, none)
 	Linker           string   `json:"linker"`
 	RTLib            string   `json:"rtlib"` // compiler runtime library (libgcc, compiler-rt)
 	Libc             string   `json:"libc"`
@@ -42,6 +42,8 @@ type TargetSpec struct {
 	CFlags           []string `json:"cflags"`
 	LDFlags          []string `json:"ldflags"`
 	LinkerScript     string   `json:"linkerscript"`
+	ExternalRAMStart string   `json:"external-ram-start"` // address of external RAM (PSRAM, SDRAM) that is usable after startup
+	ExternalRAMSize  string   `json:"external-ram-size"`  // size of external RAM in bytes
 	ExtraFiles       []string `json:"extra-files"`
 	RP2040BootPatch  *bool    `json:"rp2040-boot-patch"` // Patch RP2040 2nd stage bootloader checksum
 	Emulator         string   `json:"emulator"`
@@ -54,6 +56,7 @@ type TargetSpec struct {
 	FlashFilename    string   `json:"msd-firmware-name"`
 	UF2FamilyID      string   `json:"uf2-family-id"`
 	BinaryFormat     string   `json:"binary-format"`
+	BuildMode        string   `json:"build-mode"` // default -buildmode for this target
 	OpenOCDInterface string   `json:"openocd-interface"`
 	OpenOCDTarget    string   `json:"openocd-target"`
 	OpenOCDTransport string   `json:"openocd-transport"`
@@ -171,6 +174,15 @@ func (spec *TargetSpec) resolveInherits() error {
 // Load a target specification.
 func LoadTarget(options *Options) (*TargetSpec, error) {
 	if options.Target == "" {
+		if options.GOOS == "android" && options.BuildMode != "c-shared" {
+			// Android can run static Linux executables just fine. Only
+			// libraries that are loaded into an Android process need to be
+			// built against the system libc (Bionic).
+			linuxOptions := *options
+			linuxOptions.GOOS = "linux"
+			options = &linuxOptions
+		}
+
 		// Configure based on GOOS/GOARCH environment variables (falling back to
 		// runtime.GOOS/runtime.GOARCH), and generate a LLVM target based on it.
 		var llvmarch string
@@ -192,6 +204,14 @@ func LoadTarget(options *Options) (*TargetSpec, error) {
 			default:
 				return nil, fmt.Errorf("invalid GOARM=%s, must be 5, 6, or 7", options.GOARM)
 			}
+		case "mips", "mipsle":
+			llvmarch = "mips"
+			if options.GOARCH == "mipsle" {
+				llvmarch = "mipsel"
+			}
+			if options.GOMIPS != "hardfloat" && options.GOMIPS != "softfloat" {
+				return nil, fmt.Errorf("invalid GOMIPS=%s, must be hardfloat or softfloat", options.GOMIPS)
+			}
 		default:
 			llvmarch = options.GOARCH
 		}
@@ -208,6 +228,9 @@ func LoadTarget(options *Options) (*TargetSpec, error) {
 				llvmos = "macosx11.0.0"
 			}
 			llvmvendor = "apple"
+		} else if llvmos == "android" {
+			// Android is Linux with a different environment (see below).
+			llvmos = "linux"
 		}
 		// Target triples (which actually have four components, but are called
 		// triples for historical reasons) have the form:
@@ -215,10 +238,13 @@ func LoadTarget(options *Options) (*TargetSpec, error) {
 		target := llvmarch + "-" + llvmvendor + "-" + llvmos
 		if options.GOOS == "windows" {
 			target += "-gnu"
+		} else if options.GOOS == "android" {
+			// The API level (21) is the first one with 64-bit support.
+			target += "-android21"
 		} else if options.GOARCH == "arm" {
 			target += "-gnueabihf"
 		}
-		return defaultTarget(options.GOOS, options.GOARCH, target)
+		return defaultTarget(options, target)
 	}
 
 	// See whether there is a target specification for this target (e.g.
@@ -235,16 +261,21 @@ func LoadTarget(options *Options) (*TargetSpec, error) {
 		return nil, fmt.Errorf("%s : %w", options.Target, err)
 	}
 
-	if spec.Scheduler == "asyncify" {
-		spec.ExtraFiles = append(spec.ExtraFiles, "src/internal/task/task_asyncify_wasm.S")
+	if spec.Scheduler == "asyncify" || (spec.Scheduler == "cores" && strings.HasPrefix(spec.Triple, "wasm")) {
+		if strings.HasPrefix(spec.Triple, "wasm64") {
+			spec.ExtraFiles = append(spec.ExtraFiles, "src/internal/task/task_asyncify_wasm64.S")
+		} else {
+			spec.ExtraFiles = append(spec.ExtraFiles, "src/internal/task/task_asyncify_wasm.S")
+		}
 	}
 
 	return spec, nil
 }
 
-func defaultTarget(goos, goarch, triple string) (*TargetSpec, error) {
+func defaultTarget(options *Options, triple string) (*TargetSpec, error) {
 	// No target spec available. Use the default one, useful on most systems
 	// with a regular OS.
+	goos, goarch := options.GOOS, options.GOARCH
 	spec := TargetSpec{
 		Triple:           triple,
 		GOOS:             goos,
@@ -278,6 +309,18 @@ func defaultTarget(goos, goarch, triple string) (*TargetSpec, error) {
 	case "arm64":
 		spec.CPU = "generic"
 		spec.Features = "+neon"
+	case "mips", "mipsle":
+		spec.CPU = "mips32r2"
+		spec.CFlags = append(spec.CFlags, "-fno-pic")
+		switch options.GOMIPS {
+		case "hardfloat":
+			spec.Features = "+fpxx,+mips32r2,+nooddspreg,-noabicalls"
+		case "softfloat":
+			// Many small MIPS chips (such as those used in routers) don't have
+			// a FPU, so floating point operations need to be emulated.
+			spec.Features = "+mips32r2,+soft-float,-noabicalls"
+			spec.CFlags = append(spec.CFlags, "-msoft-float")
+		}
 	}
 	if goos == "darwin" {
 		spec.Linker = "ld.lld"
@@ -295,6 +338,33 @@ func defaultTarget(goos, goarch, triple string) (*TargetSpec, error) {
 		spec.RTLib = "compiler-rt"
 		spec.Libc = "musl"
 		spec.LDFlags = append(spec.LDFlags, "--gc-sections")
+	} else if goos == "freebsd" {
+		// Link dynamically against the system libc, which is the stable
+		// interface to the kernel on FreeBSD (like on MacOS).
+		spec.Linker = "ld.lld"
+		spec.RTLib = "compiler-rt"
+		spec.Libc = "freebsd-libc"
+		spec.LDFlags = append(spec.LDFlags,
+			"--gc-sections",
+			"--dynamic-linker=/libexec/ld-elf.so.1",
+			"--hash-style=both",
+		)
+	} else if goos == "android" {
+		// Only shared libraries are built for Android (see LoadTarget). They
+		// are linked against the system libc (Bionic), which is already
+		// loaded in every Android process.
+		if goarch != "amd64" && goarch != "arm64" {
+			return nil, fmt.Errorf("GOOS=android is only supported on amd64 and arm64, not on %s", goarch)
+		}
+		spec.BuildTags = append(spec.BuildTags, "linux")
+		spec.Linker = "ld.lld"
+		spec.RTLib = "compiler-rt"
+		spec.Libc = "android-libc"
+		spec.LDFlags = append(spec.LDFlags,
+			"--gc-sections",
+			"--hash-style=both",
+			"-z", "max-page-size=16384",
+		)
 	} else if goos == "windows" {
 		spec.Linker = "ld.lld"
 		spec.Libc = "mingw-w64"
@@ -325,14 +395,19 @@ func defaultTarget(goos, goarch, triple string) (*TargetSpec, error) {
 		spec.LDFlags = append(spec.LDFlags, "-no-pie", "-Wl,--gc-sections") // WARNING: clang < 5.0 requires -nopie
 	}
 	if goarch != "wasm" {
+		asmGoarch := goarch
+		if goarch == "mips" || goarch == "mipsle" {
+			// Big and little endian MIPS share the same assembly files.
+			asmGoarch = "mipsx"
+		}
 		suffix := ""
 		if goos == "windows" && goarch == "amd64" {
 			// Windows uses a different calling convention on amd64 from other
 			// operating systems so we need separate assembly files.
 			suffix = "_windows"
 		}
-		spec.ExtraFiles = append(spec.ExtraFiles, "src/runtime/asm_"+goarch+suffix+".S")
-		spec.ExtraFiles = append(spec.ExtraFiles, "src/internal/task/task_stack_"+goarch+suffix+".S")
+		spec.ExtraFiles = append(spec.ExtraFiles, "src/runtime/asm_"+asmGoarch+suffix+".S")
+		spec.ExtraFiles = append(spec.ExtraFiles, "src/internal/task/task_stack_"+asmGoarch+suffix+".S")
 	}
 	if goarch != runtime.GOARCH {
 		// Some educated guesses as to how to invoke helper programs.
@@ -350,6 +425,10 @@ func defaultTarget(goos, goarch, triple string) (*TargetSpec, error) {
 				spec.Emulator = "qemu-arm {}"
 			case "arm64":
 				spec.Emulator = "qemu-aarch64 {}"
+			case "mips":
+				spec.Emulator = "qemu-mips {}"
+			case "mipsle":
+				spec.Emulator = "qemu-mipsel {}"
 			}
 		}
 	}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io/fs"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -21,6 +22,46 @@ func TestLoadTarget(t *testing.T) {
 	if !errors.Is(err, fs.ErrNotExist) {
 		t.Error("LoadTarget failed for wrong reason:", err)
 	}
+
+	spec, err := LoadTarget(&Options{GOOS: "linux", GOARCH: "mipsle", GOMIPS: "softfloat"})
+	if err != nil {
+		t.Error("LoadTarget test failed:", err)
+	} else if spec.Triple != "mipsel-unknown-linux" || !strings.Contains(spec.Features, "+soft-float") {
+		t.Errorf("LoadTarget returned unexpected target for linux/mipsle: %s %s", spec.Triple, spec.Features)
+	}
+
+	_, err = LoadTarget(&Options{GOOS: "linux", GOARCH: "mips", GOMIPS: "fpu"})
+	if err == nil {
+		t.Error("LoadTarget should have failed with an invalid GOMIPS value")
+	}
+
+	spec, err = LoadTarget(&Options{GOOS: "android", GOARCH: "arm64", BuildMode: "c-shared"})
+	if err != nil {
+		t.Error("LoadTarget test failed:", err)
+	} else if spec.Triple != "aarch64-unknown-linux-android21" || spec.Libc != "android-libc" {
+		t.Errorf("LoadTarget returned unexpected target for android/arm64: %s %s", spec.Triple, spec.Libc)
+	}
+
+	spec, err = LoadTarget(&Options{Target: "ios"})
+	if err != nil {
+		t.Error("LoadTarget test failed:", err)
+	} else if spec.GOOS != "ios" || spec.BuildMode != "c-archive" {
+		t.Errorf("LoadTarget returned unexpected target for ios: %s %s", spec.GOOS, spec.BuildMode)
+	}
+
+	spec, err = LoadTarget(&Options{GOOS: "android", GOARCH: "arm64"})
+	if err != nil {
+		t.Error("LoadTarget test failed:", err)
+	} else if spec.GOOS != "linux" || spec.Libc != "musl" {
+		t.Errorf("LoadTarget should build Linux executables for android/arm64, got: %s %s", spec.GOOS, spec.Libc)
+	}
+
+	spec, err = LoadTarget(&Options{Target: "wasm64"})
+	if err != nil {
+		t.Error("LoadTarget test failed:", err)
+	} else if spec.Triple != "wasm64-unknown-unknown" || !reflect.DeepEqual(spec.ExtraFiles, []string{"src/runtime/asm_tinygowasm64.S", "src/internal/task/task_asyncify_wasm64.S"}) {
+		t.Errorf("LoadTarget returned unexpected target for wasm64: %s %v", spec.Triple, spec.ExtraFiles)
+	}
 }
 
 func TestOverrideProperties(t *testing.T) {
@@ -12,8 +12,19 @@ import (
 )
 
 func (b *builder) createMakeChan(expr *ssa.MakeChan) llvm.Value {
-	elementSize := b.targetData.TypeAllocSize(b.getLLVMType(expr.Type().Underlying().(*types.Chan).Elem()))
+	elementType := b.getLLVMType(expr.Type().Underlying().(*types.Chan).Elem())
+	elementSize := b.targetData.TypeAllocSize(elementType)
 	elementSizeValue := llvm.ConstInt(b.uintptrType, elementSize, false)
+	if size, ok := expr.Size.(*ssa.Const); ok && b.fn.Synthetic == "package initializer" {
+		// The package initializer runs only once, so a channel with a constant
+		// size that is created there can be a global instead of being
+		// allocated on the heap. This is usually a channel in a package-level
+		// variable.
+		maxBufBytes := uint64(1)<<(b.uintptrType.IntTypeWidth()-1) - 1
+		if n := size.Int64(); n >= 0 && (elementSize == 0 || uint64(n) <= maxBufBytes/elementSize) {
+			return b.createStaticChan(elementType, elementSizeValue, uint64(n))
+		}
+	}
 	bufSize := b.getValue(expr.Size, getPos(expr))
 	b.createChanBoundsCheck(elementSize, bufSize, expr.Size.Type().Underlying().(*types.Basic), expr.Pos())
 	if bufSize.Type().IntTypeWidth() < b.uintptrType.IntTypeWidth() {
@@ -24,6 +35,30 @@ func (b *builder) createMakeChan(expr *ssa.MakeChan) llvm.Value {
 	return b.createRuntimeCall("chanMake", []llvm.Value{elementSizeValue, bufSize}, "")
 }
 
+// createStaticChan creates a channel with n buffered elements of the given type,
+// where both the channel and its buffer are globals. It is used for channels
+// created in package initializers, so that they don't use heap memory and
+// show up in the size of the program instead.
+func (b *builder) createStaticChan(elementType llvm.Type, elementSize llvm.Value, n uint64) llvm.Value {
+	prefix := b.pkg.Path()
+	channelType := b.getLLVMRuntimeType("channel")
+	channel := llvm.AddGlobal(b.mod, channelType, prefix+"$chan")
+	channel.SetInitializer(llvm.ConstNull(channelType))
+	channel.SetLinkage(llvm.InternalLinkage)
+	channel.SetAlignment(b.targetData.ABITypeAlignment(channelType))
+	buf := llvm.ConstPointerNull(b.i8ptrType)
+	if n != 0 && b.targetData.TypeAllocSize(elementType) != 0 {
+		bufType := llvm.ArrayType(elementType, int(n))
+		bufGlobal := llvm.AddGlobal(b.mod, bufType, prefix+"$chanbuf")
+		bufGlobal.SetInitializer(llvm.ConstNull(bufType))
+		bufGlobal.SetLinkage(llvm.InternalLinkage)
+		bufGlobal.SetAlignment(b.targetData.ABITypeAlignment(bufType))
+		buf = bufGlobal
+	}
+	bufSize := llvm.ConstInt(b.uintptrType, n, false)
+	return b.createRuntimeCall("chanMakeStatic", []llvm.Value{channel, elementSize, bufSize, buf}, "")
+}
+
 // createChanSend emits a pseudo chan send operation. It is lowered to the
 // actual channel send operation during goroutine lowering.
 func (b *builder) createChanSend(instr *ssa.Send) {
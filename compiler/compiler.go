@@ -51,9 +51,12 @@ type Config struct {
 
 	// Various compiler options that determine how code is generated.
 	Scheduler          string
+	Asyncify           bool   // goroutines are switched using asyncify
+	Reflect            string // "full" or "min" (without type names)
 	AutomaticStackSize bool
 	DefaultStackSize   uint64
 	NeedsStackObjects  bool
+	FramePointers      bool // keep frame pointers, to walk the stack at runtime
 	Debug              bool // Whether to emit debug information in the LLVM module.
 }
 
@@ -1178,6 +1181,31 @@ func (b *builder) createFunctionStart(intrinsic bool) {
 		b.llvmFn.AddFunctionAttr(noinline)
 	}
 
+	if b.info.nowritebarrier {
+		// Signal to the write barrier pass (used by -gc=incremental) that
+		// this function must not be modified.
+		b.llvmFn.AddFunctionAttr(b.ctx.CreateStringAttribute("tinygo-nowritebarrier", ""))
+	}
+
+	if b.info.noyield {
+		// Signal to the safepoint pass (used by -autoyield) that this
+		// function must not be modified.
+		b.llvmFn.AddFunctionAttr(b.ctx.CreateStringAttribute("tinygo-noyield", ""))
+	}
+
+	if strings.HasPrefix(b.fn.Synthetic, "bound method wrapper for ") {
+		// Tell the interface lowering pass which method is called by this
+		// wrapper, so that reflect can return the code pointer of the method
+		// for method values like obj.M.
+		for _, instr := range b.fn.Blocks[0].Instrs {
+			if call, ok := instr.(*ssa.Call); ok && call.Call.StaticCallee() != nil {
+				method := b.getFunctionInfo(call.Call.StaticCallee()).linkName
+				b.llvmFn.AddFunctionAttr(b.ctx.CreateStringAttribute("tinygo-bound-method", method))
+				break
+			}
+		}
+	}
+
 	if b.info.interrupt {
 		// Mark this function as an interrupt.
 		// This is necessary on MCUs that don't push caller saved registers when
@@ -1396,6 +1424,10 @@ func (b *builder) createFunction() {
 		b.llvmFn.SetLinkage(llvm.InternalLinkage)
 		b.createFunction()
 	}
+
+	if b.info.wasmExport != "" {
+		b.createWasmExport()
+	}
 }
 
 // posser is an interface that's implemented by both ssa.Value and
@@ -1821,6 +1853,9 @@ func (b *builder) createFunctionCall(instr *ssa.CallCommon) (llvm.Value, error)
 			return llvm.ConstInt(b.ctx.Int1Type(), supportsRecover, false), nil
 		case name == "runtime/interrupt.New":
 			return b.createInterruptGlobal(instr)
+		case name == "runtime.KeepAlive":
+			b.createKeepAlive(params[0])
+			return llvm.Value{}, nil
 		}
 
 		calleeType, callee = b.getFunction(fn)
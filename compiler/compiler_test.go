@@ -231,6 +231,7 @@ func testCompilePackage(t *testing.T, options *compileopts.Options, file string)
 		CodeModel:          config.CodeModel(),
 		RelocationModel:    config.RelocationModel(),
 		Scheduler:          config.Scheduler(),
+		Asyncify:           config.Asyncify(),
 		AutomaticStackSize: config.AutomaticStackSize(),
 		DefaultStackSize:   config.StackSize(),
 		NeedsStackObjects:  config.NeedsStackObjects(),
@@ -16,6 +16,7 @@ package compiler
 import (
 	"go/types"
 	"strconv"
+	"strings"
 
 	"github.com/tinygo-org/tinygo/compiler/llvmutil"
 	"golang.org/x/tools/go/ssa"
@@ -26,7 +27,7 @@ import (
 // for the current architecture.
 func (b *builder) supportsRecover() bool {
 	switch b.archFamily() {
-	case "wasm32":
+	case "wasm32", "wasm64":
 		// Probably needs to be implemented using the exception handling
 		// proposal of WebAssembly:
 		// https://github.com/WebAssembly/exception-handling
@@ -195,6 +196,27 @@ sw a2, 4(a1)
 li a0, 0
 1:`
 		constraints = "={a0},{a1},~{a1},~{a2},~{a3},~{a4},~{a5},~{a6},~{a7},~{s0},~{s1},~{s2},~{s3},~{s4},~{s5},~{s6},~{s7},~{s8},~{s9},~{s10},~{s11},~{t0},~{t1},~{t2},~{t3},~{t4},~{t5},~{t6},~{ra},~{f0},~{f1},~{f2},~{f3},~{f4},~{f5},~{f6},~{f7},~{f8},~{f9},~{f10},~{f11},~{f12},~{f13},~{f14},~{f15},~{f16},~{f17},~{f18},~{f19},~{f20},~{f21},~{f22},~{f23},~{f24},~{f25},~{f26},~{f27},~{f28},~{f29},~{f30},~{f31},~{memory}"
+	case "mips", "mipsel":
+		// The jal instruction is used to obtain the current PC in a position
+		// independent way. The assembler inserts a nop in its delay slot, so
+		// $ra points to the addiu instruction afterwards. The stored PC points
+		// just past the assembly fragment.
+		asmString = `
+.set noat
+move $$4, $$zero
+jal 1f
+1:
+addiu $$ra, $$ra, 8
+sw $$ra, 4($$5)
+.set at`
+		constraints = "={$4},{$5},~{$1},~{$2},~{$3},~{$5},~{$6},~{$7},~{$8},~{$9},~{$10},~{$11},~{$12},~{$13},~{$14},~{$15},~{$16},~{$17},~{$18},~{$19},~{$20},~{$21},~{$22},~{$23},~{$24},~{$25},~{$30},~{$31},~{hi},~{lo},~{memory}"
+		if !strings.Contains(b.Features, "+soft-float") {
+			// Floating point registers don't exist with GOMIPS=softfloat, so
+			// only mark them as clobbered when using hardfloat.
+			for i := 0; i < 32; i++ {
+				constraints += ",~{$f" + strconv.Itoa(i) + "}"
+			}
+		}
 	default:
 		// This case should have been handled by b.supportsRecover().
 		b.addError(b.fn.Pos(), "unknown architecture for defer: "+b.archFamily())
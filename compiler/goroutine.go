@@ -109,7 +109,7 @@ func (b *builder) createGo(instr *ssa.Go) {
 	} else {
 		// The stack size is fixed at compile time. By emitting it here as a
 		// constant, it can be optimized.
-		if (b.Scheduler == "tasks" || b.Scheduler == "asyncify") && b.DefaultStackSize == 0 {
+		if (b.Scheduler == "tasks" || b.Scheduler == "cores" || b.Scheduler == "asyncify") && b.DefaultStackSize == 0 {
 			b.addError(instr.Pos(), "default stack size for goroutines is not set")
 		}
 		stackSize = llvm.ConstInt(b.uintptrType, b.DefaultStackSize, false)
@@ -152,7 +152,7 @@ func (c *compilerContext) createGoroutineStartWrapper(fnType llvm.Type, fn llvm.
 
 	var deadlock llvm.Value
 	var deadlockType llvm.Type
-	if c.Scheduler == "asyncify" {
+	if c.Asyncify {
 		deadlockType, deadlock = c.getFunction(c.program.ImportedPackage("runtime").Members["deadlock"].(*ssa.Function))
 	}
 
@@ -209,7 +209,7 @@ func (c *compilerContext) createGoroutineStartWrapper(fnType llvm.Type, fn llvm.
 		// Create the call.
 		b.CreateCall(fnType, fn, params, "")
 
-		if c.Scheduler == "asyncify" {
+		if c.Asyncify {
 			b.CreateCall(deadlockType, deadlock, []llvm.Value{
 				llvm.Undef(c.i8ptrType),
 			}, "")
@@ -277,14 +277,14 @@ func (c *compilerContext) createGoroutineStartWrapper(fnType llvm.Type, fn llvm.
 		// Create the call.
 		b.CreateCall(fnType, fnPtr, params, "")
 
-		if c.Scheduler == "asyncify" {
+		if c.Asyncify {
 			b.CreateCall(deadlockType, deadlock, []llvm.Value{
 				llvm.Undef(c.i8ptrType),
 			}, "")
 		}
 	}
 
-	if c.Scheduler == "asyncify" {
+	if c.Asyncify {
 		// The goroutine was terminated via deadlock.
 		b.CreateUnreachable()
 	} else {
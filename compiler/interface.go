@@ -117,6 +117,158 @@ func (c *compilerContext) pkgPathPtr(pkgpath string) llvm.Value {
 	return pkgPathPtr
 }
 
+// namedTypeName returns the package path and the qualified name (like "pkg.T")
+// of a named type, as stored in its type code. Both are left empty with
+// -reflect=min, so that the strings don't take up space in the binary. The
+// reflect package then reports the type as having no name.
+func (c *compilerContext) namedTypeName(typ *types.Named) (pkgpath, name string) {
+	if c.Reflect == "min" {
+		return "", ""
+	}
+	var pkgname string
+	if pkg := typ.Obj().Pkg(); pkg != nil {
+		pkgpath = pkg.Path()
+		pkgname = pkg.Name()
+	}
+	return pkgpath, pkgname + "." + typ.Obj().Name() + typeArgsString(typ)
+}
+
+// typeArgsString returns the type arguments of an instantiated generic type
+// the way they are included in the type name, like "[int,example.com/foo.T]".
+// It returns the empty string for other named types.
+func typeArgsString(typ *types.Named) string {
+	args := typ.TypeArgs()
+	if args.Len() == 0 {
+		return ""
+	}
+	s := "["
+	for i := 0; i < args.Len(); i++ {
+		if i > 0 {
+			s += ","
+		}
+		s += reflectTypeString(args.At(i))
+	}
+	return s + "]"
+}
+
+// reflectTypeString returns the string for a type argument of a generic type,
+// in the format used by upstream Go. It is similar to types.TypeString, but
+// named types are qualified with their full package path and other types are
+// formatted like reflect.Type.String (for example "struct { X int }" instead
+// of "struct{X int}" and "uint8" instead of "byte").
+func reflectTypeString(typ types.Type) string {
+	switch typ := typ.(type) {
+	case *types.Basic:
+		switch typ.Kind() {
+		case types.Byte:
+			return "uint8"
+		case types.Rune:
+			return "int32"
+		case types.UnsafePointer:
+			return "unsafe.Pointer"
+		}
+		return typ.Name()
+	case *types.Named:
+		if typ.Obj().Pkg() == nil {
+			// Predeclared named type, like error.
+			return typ.Obj().Name()
+		}
+		return typ.Obj().Pkg().Path() + "." + typ.Obj().Name() + typeArgsString(typ)
+	case *types.Pointer:
+		return "*" + reflectTypeString(typ.Elem())
+	case *types.Slice:
+		return "[]" + reflectTypeString(typ.Elem())
+	case *types.Array:
+		return "[" + strconv.FormatInt(typ.Len(), 10) + "]" + reflectTypeString(typ.Elem())
+	case *types.Map:
+		return "map[" + reflectTypeString(typ.Key()) + "]" + reflectTypeString(typ.Elem())
+	case *types.Chan:
+		elem := reflectTypeString(typ.Elem())
+		switch typ.Dir() {
+		case types.SendOnly:
+			return "chan<- " + elem
+		case types.RecvOnly:
+			return "<-chan " + elem
+		default:
+			if strings.HasPrefix(elem, "<-") {
+				return "chan (" + elem + ")"
+			}
+			return "chan " + elem
+		}
+	case *types.Struct:
+		if typ.NumFields() == 0 {
+			return "struct {}"
+		}
+		s := "struct {"
+		for i := 0; i < typ.NumFields(); i++ {
+			field := typ.Field(i)
+			s += " "
+			if !field.Embedded() {
+				s += field.Name() + " "
+			}
+			s += reflectTypeString(field.Type())
+			if tag := typ.Tag(i); tag != "" {
+				s += " " + strconv.Quote(tag)
+			}
+			if i < typ.NumFields()-1 {
+				s += ";"
+			}
+		}
+		return s + " }"
+	case *types.Interface:
+		if typ.NumMethods() == 0 {
+			return "interface {}"
+		}
+		s := "interface {"
+		for i := 0; i < typ.NumMethods(); i++ {
+			method := typ.Method(i)
+			s += " "
+			if !method.Exported() {
+				s += method.Pkg().Name() + "."
+			}
+			s += method.Name() + strings.TrimPrefix(reflectTypeString(method.Type()), "func")
+			if i < typ.NumMethods()-1 {
+				s += ";"
+			}
+		}
+		return s + " }"
+	case *types.Signature:
+		s := "func("
+		for i := 0; i < typ.Params().Len(); i++ {
+			if i > 0 {
+				s += ", "
+			}
+			param := typ.Params().At(i).Type()
+			if typ.Variadic() && i == typ.Params().Len()-1 {
+				s += "..." + reflectTypeString(param.(*types.Slice).Elem())
+			} else {
+				s += reflectTypeString(param)
+			}
+		}
+		s += ")"
+		switch typ.Results().Len() {
+		case 0:
+		case 1:
+			s += " " + reflectTypeString(typ.Results().At(0).Type())
+		default:
+			s += " ("
+			for i := 0; i < typ.Results().Len(); i++ {
+				if i > 0 {
+					s += ", "
+				}
+				s += reflectTypeString(typ.Results().At(i).Type())
+			}
+			s += ")"
+		}
+		return s
+	default:
+		// Shouldn't happen, but fall back to the go/types format.
+		return types.TypeString(typ, func(pkg *types.Package) string {
+			return pkg.Path()
+		})
+	}
+}
+
 // getTypeCode returns a reference to a type code.
 // A type code is a pointer to a constant global that describes the type.
 // This function returns a pointer to the 'kind' field (which might not be the
@@ -124,8 +276,18 @@ func (c *compilerContext) pkgPathPtr(pkgpath string) llvm.Value {
 func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 	ms := c.program.MethodSets.MethodSet(typ)
 	hasMethodSet := ms.Len() != 0
+	numMethods := ms.Len()
 	if _, ok := typ.Underlying().(*types.Interface); ok {
 		hasMethodSet = false
+	} else {
+		// Like upstream Go, reflect only sees the exported methods of concrete
+		// types.
+		numMethods = 0
+		for i := 0; i < ms.Len(); i++ {
+			if ms.At(i).Obj().Exported() {
+				numMethods++
+			}
+		}
 	}
 
 	// Short-circuit all the global pointer logic here for pointers to pointers.
@@ -174,17 +336,13 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 				types.NewVar(token.NoPos, nil, "ptrTo", types.Typ[types.UnsafePointer]),
 			)
 		case *types.Named:
-			name := typ.Obj().Name()
-			var pkgname string
-			if pkg := typ.Obj().Pkg(); pkg != nil {
-				pkgname = pkg.Name()
-			}
+			_, name := c.namedTypeName(typ)
 			typeFieldTypes = append(typeFieldTypes,
 				types.NewVar(token.NoPos, nil, "numMethods", types.Typ[types.Uint16]),
 				types.NewVar(token.NoPos, nil, "ptrTo", types.Typ[types.UnsafePointer]),
 				types.NewVar(token.NoPos, nil, "underlying", types.Typ[types.UnsafePointer]),
 				types.NewVar(token.NoPos, nil, "pkgpath", types.Typ[types.UnsafePointer]),
-				types.NewVar(token.NoPos, nil, "name", types.NewArray(types.Typ[types.Int8], int64(len(pkgname)+1+len(name)+1))),
+				types.NewVar(token.NoPos, nil, "name", types.NewArray(types.Typ[types.Int8], int64(len(name)+1))),
 			)
 		case *types.Chan:
 			typeFieldTypes = append(typeFieldTypes,
@@ -209,6 +367,7 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 				types.NewVar(token.NoPos, nil, "ptrTo", types.Typ[types.UnsafePointer]),
 				types.NewVar(token.NoPos, nil, "elementType", types.Typ[types.UnsafePointer]),
 				types.NewVar(token.NoPos, nil, "length", types.Typ[types.Uintptr]),
+				types.NewVar(token.NoPos, nil, "sliceOf", types.Typ[types.UnsafePointer]),
 			)
 		case *types.Map:
 			typeFieldTypes = append(typeFieldTypes,
@@ -228,14 +387,21 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 			)
 		case *types.Interface:
 			typeFieldTypes = append(typeFieldTypes,
+				types.NewVar(token.NoPos, nil, "numMethods", types.Typ[types.Uint16]),
 				types.NewVar(token.NoPos, nil, "ptrTo", types.Typ[types.UnsafePointer]),
+				types.NewVar(token.NoPos, nil, "methods", types.NewArray(types.Typ[types.UnsafePointer], int64(typ.NumMethods()))),
 			)
-			// TODO: methods
 		case *types.Signature:
 			typeFieldTypes = append(typeFieldTypes,
+				types.NewVar(token.NoPos, nil, "numMethods", types.Typ[types.Uint16]),
 				types.NewVar(token.NoPos, nil, "ptrTo", types.Typ[types.UnsafePointer]),
+				types.NewVar(token.NoPos, nil, "call", reflectCallSignature),
+				types.NewVar(token.NoPos, nil, "makeFunc", typ),
+				types.NewVar(token.NoPos, nil, "numIn", types.Typ[types.Uint16]),
+				types.NewVar(token.NoPos, nil, "numOut", types.Typ[types.Uint16]),
+				types.NewVar(token.NoPos, nil, "variadic", types.Typ[types.Uint8]),
+				types.NewVar(token.NoPos, nil, "params", types.NewArray(types.Typ[types.UnsafePointer], int64(typ.Params().Len()+typ.Results().Len()))),
 			)
-			// TODO: signature params and return values
 		}
 		if hasMethodSet {
 			// This method set is appended at the start of the struct. It is
@@ -268,20 +434,14 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 		case *types.Basic:
 			typeFields = []llvm.Value{c.getTypeCode(types.NewPointer(typ))}
 		case *types.Named:
-			name := typ.Obj().Name()
-			var pkgpath string
-			var pkgname string
-			if pkg := typ.Obj().Pkg(); pkg != nil {
-				pkgpath = pkg.Path()
-				pkgname = pkg.Name()
-			}
+			pkgpath, name := c.namedTypeName(typ)
 			pkgPathPtr := c.pkgPathPtr(pkgpath)
 			typeFields = []llvm.Value{
-				llvm.ConstInt(c.ctx.Int16Type(), uint64(ms.Len()), false), // numMethods
-				c.getTypeCode(types.NewPointer(typ)),                      // ptrTo
-				c.getTypeCode(typ.Underlying()),                           // underlying
-				pkgPathPtr,                                                // pkgpath pointer
-				c.ctx.ConstString(pkgname+"."+name+"\x00", false),         // name
+				llvm.ConstInt(c.ctx.Int16Type(), uint64(numMethods), false), // numMethods
+				c.getTypeCode(types.NewPointer(typ)),                        // ptrTo
+				c.getTypeCode(typ.Underlying()),                             // underlying
+				pkgPathPtr,                                                  // pkgpath pointer
+				c.ctx.ConstString(name+"\x00", false),                       // name
 			}
 			metabyte |= 1 << 5 // "named" flag
 		case *types.Chan:
@@ -308,7 +468,7 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 			}
 		case *types.Pointer:
 			typeFields = []llvm.Value{
-				llvm.ConstInt(c.ctx.Int16Type(), uint64(ms.Len()), false), // numMethods
+				llvm.ConstInt(c.ctx.Int16Type(), uint64(numMethods), false), // numMethods
 				c.getTypeCode(typ.Elem()),
 			}
 		case *types.Array:
@@ -317,6 +477,7 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 				c.getTypeCode(types.NewPointer(typ)),                   // ptrTo
 				c.getTypeCode(typ.Elem()),                              // elementType
 				llvm.ConstInt(c.uintptrType, uint64(typ.Len()), false), // length
+				c.getTypeCode(types.NewSlice(typ.Elem())),              // sliceOf
 			}
 		case *types.Map:
 			typeFields = []llvm.Value{
@@ -337,8 +498,8 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 			llvmStructType := c.getLLVMType(typ)
 			size := c.targetData.TypeStoreSize(llvmStructType)
 			typeFields = []llvm.Value{
-				llvm.ConstInt(c.ctx.Int16Type(), uint64(ms.Len()), false), // numMethods
-				c.getTypeCode(types.NewPointer(typ)),                      // ptrTo
+				llvm.ConstInt(c.ctx.Int16Type(), uint64(numMethods), false), // numMethods
+				c.getTypeCode(types.NewPointer(typ)),                        // ptrTo
 				pkgPathPtr,
 				llvm.ConstInt(c.ctx.Int32Type(), uint64(size), false),            // size
 				llvm.ConstInt(c.ctx.Int16Type(), uint64(typ.NumFields()), false), // numFields
@@ -367,11 +528,10 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 				offLen := binary.PutUvarint(offsBytes[:], offset)
 
 				data := string(flags) + string(offsBytes[:offLen]) + field.Name() + "\x00"
-				if typ.Tag(i) != "" {
-					if len(typ.Tag(i)) > 0xff {
-						c.addError(field.Pos(), fmt.Sprintf("struct tag is %d bytes which is too long, max is 255", len(typ.Tag(i))))
-					}
-					data += string([]byte{byte(len(typ.Tag(i)))}) + typ.Tag(i)
+				if tag := typ.Tag(i); tag != "" {
+					var tagLenBytes [binary.MaxVarintLen32]byte
+					tagLenLen := binary.PutUvarint(tagLenBytes[:], uint64(len(tag)))
+					data += string(tagLenBytes[:tagLenLen]) + tag
 				}
 				dataInitializer := c.ctx.ConstString(data, false)
 				dataGlobal := llvm.AddGlobal(c.mod, dataInitializer.Type(), globalName+"."+field.Name())
@@ -391,11 +551,47 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 			}
 			typeFields = append(typeFields, llvm.ConstArray(structFieldType, fields))
 		case *types.Interface:
-			typeFields = []llvm.Value{c.getTypeCode(types.NewPointer(typ))}
-			// TODO: methods
+			// The methods are sorted by their unique name, like the method
+			// sets of concrete types, so that reflect can compare them.
+			var methods []llvm.Value
+			for i := 0; i < typ.NumMethods(); i++ {
+				methods = append(methods, c.getMethodSignature(typ.Method(i)))
+			}
+			typeFields = []llvm.Value{
+				llvm.ConstInt(c.ctx.Int16Type(), uint64(typ.NumMethods()), false), // numMethods
+				c.getTypeCode(types.NewPointer(typ)),                              // ptrTo
+				llvm.ConstArray(c.i8ptrType, methods),                             // methods
+			}
 		case *types.Signature:
-			typeFields = []llvm.Value{c.getTypeCode(types.NewPointer(typ))}
-			// TODO: params, return values, etc
+			var variadic uint64
+			if typ.Variadic() {
+				variadic = 1
+			}
+			var params []llvm.Value
+			for i := 0; i < typ.Params().Len(); i++ {
+				params = append(params, c.getTypeCode(typ.Params().At(i).Type()))
+			}
+			for i := 0; i < typ.Results().Len(); i++ {
+				params = append(params, c.getTypeCode(typ.Results().At(i).Type()))
+			}
+			call := c.getReflectCallWrapper(typ, typeCodeName, isLocal)
+			makeFunc := c.getReflectMakeFuncWrapper(typ, typeCodeName, isLocal)
+			typeFields = []llvm.Value{
+				llvm.ConstInt(c.ctx.Int16Type(), 0, false), // numMethods
+				c.getTypeCode(types.NewPointer(typ)),       // ptrTo
+				c.ctx.ConstStruct([]llvm.Value{ // call
+					llvm.ConstNull(c.i8ptrType),
+					llvm.ConstBitCast(call, c.rawVoidFuncType),
+				}, false),
+				c.ctx.ConstStruct([]llvm.Value{ // makeFunc
+					llvm.ConstNull(c.i8ptrType),
+					llvm.ConstBitCast(makeFunc, c.rawVoidFuncType),
+				}, false),
+				llvm.ConstInt(c.ctx.Int16Type(), uint64(typ.Params().Len()), false),  // numIn
+				llvm.ConstInt(c.ctx.Int16Type(), uint64(typ.Results().Len()), false), // numOut
+				llvm.ConstInt(c.ctx.Int8Type(), variadic, false),                     // variadic
+				llvm.ConstArray(c.i8ptrType, params),                                 // params
+			}
 		}
 		// Prepend metadata byte.
 		typeFields = append([]llvm.Value{
@@ -446,6 +642,217 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 	})
 }
 
+// reflectCallSignature is the signature of the call wrapper stored in the type
+// struct of a signature type:
+//
+//	func(fn *funcHeader, args, results *unsafe.Pointer)
+//
+// It must be kept up to date with funcType in src/reflect/type.go.
+var reflectCallSignature = types.NewSignature(nil, types.NewTuple(
+	types.NewVar(token.NoPos, nil, "fn", types.Typ[types.UnsafePointer]),
+	types.NewVar(token.NoPos, nil, "args", types.Typ[types.UnsafePointer]),
+	types.NewVar(token.NoPos, nil, "results", types.Typ[types.UnsafePointer]),
+), nil, false)
+
+// getReflectCallWrapper returns a function that calls a func value of the given
+// signature, for use by reflect.Value.Call. Because the calling convention
+// depends on the signature, the reflect package can't call a func value
+// directly. Instead, it passes the func value and pointers to each parameter
+// and result to this wrapper:
+//
+//	func call(fn *func(x, y int) int, args, results *unsafe.Pointer) {
+//	    x := *(*int)(args[0])
+//	    y := *(*int)(args[1])
+//	    *(*int)(results[0]) = (*fn)(x, y)
+//	}
+func (c *compilerContext) getReflectCallWrapper(sig *types.Signature, typeCodeName string, isLocal bool) llvm.Value {
+	if sig.Recv() != nil {
+		// Func values never have a receiver parameter.
+		sig = types.NewSignature(nil, sig.Params(), sig.Results(), sig.Variadic())
+	}
+	wrapperName := "reflect/types.call:" + typeCodeName
+	if !isLocal {
+		wrapper := c.mod.NamedFunction(wrapperName)
+		if !wrapper.IsNil() {
+			return wrapper
+		}
+	}
+
+	wrapperType := c.getRawFuncType(reflectCallSignature)
+	wrapper := llvm.AddFunction(c.mod, wrapperName, wrapperType)
+	c.addStandardAttributes(wrapper)
+	if isLocal {
+		wrapper.SetLinkage(llvm.InternalLinkage)
+	} else {
+		wrapper.SetLinkage(llvm.LinkOnceODRLinkage)
+	}
+	wrapper.SetUnnamedAddr(true)
+
+	// Create a new builder just to create this wrapper.
+	b := builder{
+		compilerContext: c,
+		Builder:         c.ctx.NewBuilder(),
+	}
+	defer b.Builder.Dispose()
+
+	block := b.ctx.AddBasicBlock(wrapper, "entry")
+	b.SetInsertPointAtEnd(block)
+
+	// Load the function value and all parameters.
+	funcValue := b.CreateLoad(c.getFuncType(sig), wrapper.Param(0), "fn")
+	fnType, fnPtr, context := b.decodeFuncValue(funcValue, sig)
+	var params []llvm.Value
+	for i := 0; i < sig.Params().Len(); i++ {
+		gep := b.CreateInBoundsGEP(c.i8ptrType, wrapper.Param(1), []llvm.Value{
+			llvm.ConstInt(c.ctx.Int32Type(), uint64(i), false),
+		}, "")
+		ptr := b.CreateLoad(c.i8ptrType, gep, "")
+		paramType := c.getLLVMType(sig.Params().At(i).Type())
+		ptr = b.CreateBitCast(ptr, llvm.PointerType(paramType, 0), "")
+		params = append(params, b.CreateLoad(paramType, ptr, ""))
+	}
+	params = append(params, context)
+
+	// Call the function and store the results.
+	result := b.createCall(fnType, fnPtr, params, "")
+	for i := 0; i < sig.Results().Len(); i++ {
+		value := result
+		if sig.Results().Len() > 1 {
+			value = b.CreateExtractValue(result, i, "")
+		}
+		gep := b.CreateInBoundsGEP(c.i8ptrType, wrapper.Param(2), []llvm.Value{
+			llvm.ConstInt(c.ctx.Int32Type(), uint64(i), false),
+		}, "")
+		ptr := b.CreateLoad(c.i8ptrType, gep, "")
+		ptr = b.CreateBitCast(ptr, llvm.PointerType(value.Type(), 0), "")
+		b.CreateStore(value, ptr)
+	}
+	b.CreateRetVoid()
+
+	return wrapper
+}
+
+// reflectMakeFuncSignature is the signature of the callback that is called by
+// the MakeFunc wrapper of a signature type:
+//
+//	func(args, results *unsafe.Pointer)
+//
+// It must be kept up to date with makeFuncImpl in src/reflect/makefunc.go.
+var reflectMakeFuncSignature = types.NewSignature(nil, types.NewTuple(
+	types.NewVar(token.NoPos, nil, "args", types.Typ[types.UnsafePointer]),
+	types.NewVar(token.NoPos, nil, "results", types.Typ[types.UnsafePointer]),
+), nil, false)
+
+// getReflectMakeFuncWrapper returns a function of the given signature that
+// calls into a function created by reflect.MakeFunc. It is the inverse of
+// getReflectCallWrapper: the context parameter points to an object that starts
+// with a callback (see reflectMakeFuncSignature), which is called with
+// pointers to each parameter and result:
+//
+//	func makeFunc(x, y int, context *func(args, results *unsafe.Pointer)) int {
+//	    var result int
+//	    (*context)(&[]unsafe.Pointer{&x, &y}[0], &[]unsafe.Pointer{&result}[0])
+//	    return result
+//	}
+func (c *compilerContext) getReflectMakeFuncWrapper(sig *types.Signature, typeCodeName string, isLocal bool) llvm.Value {
+	if sig.Recv() != nil {
+		// Func values never have a receiver parameter.
+		sig = types.NewSignature(nil, sig.Params(), sig.Results(), sig.Variadic())
+	}
+	wrapperName := "reflect/types.makefunc:" + typeCodeName
+	if !isLocal {
+		wrapper := c.mod.NamedFunction(wrapperName)
+		if !wrapper.IsNil() {
+			return wrapper
+		}
+	}
+
+	wrapperType := c.getRawFuncType(sig)
+	wrapper := llvm.AddFunction(c.mod, wrapperName, wrapperType)
+	c.addStandardAttributes(wrapper)
+	if isLocal {
+		wrapper.SetLinkage(llvm.InternalLinkage)
+	} else {
+		wrapper.SetLinkage(llvm.LinkOnceODRLinkage)
+	}
+	wrapper.SetUnnamedAddr(true)
+
+	// Create a new builder just to create this wrapper.
+	b := builder{
+		compilerContext: c,
+		Builder:         c.ctx.NewBuilder(),
+	}
+	defer b.Builder.Dispose()
+
+	block := b.ctx.AddBasicBlock(wrapper, "entry")
+	b.SetInsertPointAtEnd(block)
+
+	// Store all parameters in memory, and collect pointers to them.
+	argsType := llvm.ArrayType(c.i8ptrType, sig.Params().Len())
+	args := b.CreateAlloca(argsType, "args")
+	paramIndex := 0
+	for i := 0; i < sig.Params().Len(); i++ {
+		paramType := c.getLLVMType(sig.Params().At(i).Type())
+		var fields []llvm.Value
+		for range c.expandFormalParamType(paramType, "", nil) {
+			fields = append(fields, wrapper.Param(paramIndex))
+			paramIndex++
+		}
+		param := b.CreateAlloca(paramType, "")
+		b.CreateStore(b.collapseFormalParam(paramType, fields), param)
+		gep := b.CreateInBoundsGEP(argsType, args, []llvm.Value{
+			llvm.ConstInt(c.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(c.ctx.Int32Type(), uint64(i), false),
+		}, "")
+		b.CreateStore(b.CreateBitCast(param, c.i8ptrType, ""), gep)
+	}
+
+	// Allocate memory for the results.
+	resultsType := llvm.ArrayType(c.i8ptrType, sig.Results().Len())
+	results := b.CreateAlloca(resultsType, "results")
+	var resultPtrs []llvm.Value
+	for i := 0; i < sig.Results().Len(); i++ {
+		resultType := c.getLLVMType(sig.Results().At(i).Type())
+		result := b.CreateAlloca(resultType, "")
+		b.CreateStore(llvm.ConstNull(resultType), result)
+		gep := b.CreateInBoundsGEP(resultsType, results, []llvm.Value{
+			llvm.ConstInt(c.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(c.ctx.Int32Type(), uint64(i), false),
+		}, "")
+		b.CreateStore(b.CreateBitCast(result, c.i8ptrType, ""), gep)
+		resultPtrs = append(resultPtrs, result)
+	}
+
+	// Call the callback stored at the start of the context object.
+	context := wrapper.LastParam()
+	callbackType := c.getFuncType(reflectMakeFuncSignature)
+	callbackPtr := b.CreateBitCast(context, llvm.PointerType(callbackType, 0), "")
+	callback := b.CreateLoad(callbackType, callbackPtr, "callback")
+	fnType, fnPtr, fnContext := b.decodeFuncValue(callback, reflectMakeFuncSignature)
+	b.createCall(fnType, fnPtr, []llvm.Value{
+		b.CreateBitCast(args, c.i8ptrType, ""),
+		b.CreateBitCast(results, c.i8ptrType, ""),
+		fnContext,
+	}, "")
+
+	// Load and return the results.
+	switch sig.Results().Len() {
+	case 0:
+		b.CreateRetVoid()
+	case 1:
+		b.CreateRet(b.CreateLoad(wrapperType.ReturnType(), resultPtrs[0], ""))
+	default:
+		retval := llvm.Undef(wrapperType.ReturnType())
+		for i, ptr := range resultPtrs {
+			value := b.CreateLoad(wrapperType.ReturnType().StructElementTypes()[i], ptr, "")
+			retval = b.CreateInsertValue(retval, value, i, "")
+		}
+		b.CreateRet(retval)
+	}
+
+	return wrapper
+}
+
 // getTypeKind returns the type kind for the given type, as defined by
 // reflect.Kind.
 func getTypeKind(t types.Type) uint8 {
@@ -592,6 +999,12 @@ func getTypeCodeName(t types.Type) (string, bool) {
 
 // getTypeMethodSet returns a reference (GEP) to a global method set. This
 // method set should be unreferenced after the interface lowering pass.
+//
+// Besides the signatures and interface invoke wrappers, the method set contains
+// a table of the methods for reflect.Type.Method and reflect.Value.Method: the
+// exported methods first, followed by the unexported methods (which can only be
+// reached through an interface). The interface lowering pass only keeps this
+// table if the reflect package uses it.
 func (c *compilerContext) getTypeMethodSet(typ types.Type) llvm.Value {
 	globalName := typ.String() + "$methodset"
 	global := c.mod.NamedGlobal(globalName)
@@ -599,7 +1012,7 @@ func (c *compilerContext) getTypeMethodSet(typ types.Type) llvm.Value {
 		ms := c.program.MethodSets.MethodSet(typ)
 
 		// Create method set.
-		var signatures, wrappers []llvm.Value
+		var signatures, wrappers, methods, unexportedMethods []llvm.Value
 		for i := 0; i < ms.Len(); i++ {
 			method := ms.At(i)
 			signatureGlobal := c.getMethodSignature(method.Obj().(*types.Func))
@@ -612,13 +1025,23 @@ func (c *compilerContext) getTypeMethodSet(typ types.Type) llvm.Value {
 			}
 			wrapper := c.getInterfaceInvokeWrapper(fn, llvmFnType, llvmFn)
 			wrappers = append(wrappers, wrapper)
+			reflectMethod := c.getReflectMethod(typ, method, signatureGlobal, llvmFn)
+			if method.Obj().Exported() {
+				// The method set is sorted by name for exported methods, which
+				// is the order reflect.Type.Method uses.
+				methods = append(methods, reflectMethod)
+			} else {
+				unexportedMethods = append(unexportedMethods, reflectMethod)
+			}
 		}
+		methods = append(methods, unexportedMethods...)
 
 		// Construct global value.
 		globalValue := c.ctx.ConstStruct([]llvm.Value{
 			llvm.ConstInt(c.uintptrType, uint64(ms.Len()), false),
 			llvm.ConstArray(c.i8ptrType, signatures),
 			c.ctx.ConstStruct(wrappers, false),
+			llvm.ConstArray(c.getReflectMethodType(), methods),
 		}, false)
 		global = llvm.AddGlobal(c.mod, globalValue.Type(), globalName)
 		global.SetInitializer(globalValue)
@@ -642,22 +1065,75 @@ func (c *compilerContext) getMethodSignatureName(method *types.Func) string {
 	return globalName
 }
 
-// getMethodSignature returns a global variable which is a reference to an
-// external *i8 indicating the indicating the signature of this method. It is
-// used during the interface lowering pass.
+// getReflectMethodType returns the type of a single entry in the method table
+// of a type, as used by reflect.Type.Method. It must be kept up to date with
+// the method struct in src/reflect/type.go.
+func (c *compilerContext) getReflectMethodType() llvm.Type {
+	return c.ctx.StructType([]llvm.Type{
+		c.i8ptrType, // signature
+		c.i8ptrType, // method expression type
+		c.ctx.StructType([]llvm.Type{c.i8ptrType, c.rawVoidFuncType}, false), // func value
+	}, false)
+}
+
+// getReflectMethod returns an entry in the method table of the given type. The
+// method is stored as a func value of the method expression type, which has
+// the receiver as the first parameter. This matches the calling convention of
+// the method function itself, so it can be called directly by
+// reflect.Value.Call.
+func (c *compilerContext) getReflectMethod(typ types.Type, method *types.Selection, signatureGlobal, llvmFn llvm.Value) llvm.Value {
+	sig := method.Type().(*types.Signature)
+	params := []*types.Var{types.NewParam(token.NoPos, nil, "", typ)}
+	for i := 0; i < sig.Params().Len(); i++ {
+		params = append(params, sig.Params().At(i))
+	}
+	methodExprType := types.NewSignature(nil, types.NewTuple(params...), sig.Results(), sig.Variadic())
+	return c.ctx.ConstStruct([]llvm.Value{
+		signatureGlobal,
+		c.getTypeCode(methodExprType),
+		c.ctx.ConstStruct([]llvm.Value{
+			llvm.ConstNull(c.i8ptrType),
+			llvm.ConstBitCast(llvmFn, c.rawVoidFuncType),
+		}, false),
+	}, false)
+}
+
+// getMethodSignature returns a global variable that uniquely identifies the
+// name and signature of this method. It is used during the interface lowering
+// pass, and by the reflect package which compares method signatures by
+// pointer. The global looks like this (see methodSignature in
+// src/reflect/type.go):
+//
+//	typ     *typeStruct // func type without receiver
+//	pkgpath *byte       // package path for unexported methods, or nil
+//	name    [...]byte   // method name; null terminated
 func (c *compilerContext) getMethodSignature(method *types.Func) llvm.Value {
 	globalName := c.getMethodSignatureName(method)
 	signatureGlobal := c.mod.NamedGlobal(globalName)
 	if signatureGlobal.IsNil() {
-		// TODO: put something useful in these globals, such as the method
-		// signature. Useful to one day implement reflect.Value.Method(n).
-		signatureGlobal = llvm.AddGlobal(c.mod, c.ctx.Int8Type(), globalName)
-		signatureGlobal.SetInitializer(llvm.ConstInt(c.ctx.Int8Type(), 0, false))
+		globalType := c.ctx.StructType([]llvm.Type{
+			c.i8ptrType,
+			c.i8ptrType,
+			llvm.ArrayType(c.ctx.Int8Type(), len(method.Name())+1),
+		}, false)
+		// Create the global before the type code: the method signature may
+		// refer back to the interface that contains this method.
+		signatureGlobal = llvm.AddGlobal(c.mod, globalType, globalName)
+		sig := method.Type().(*types.Signature)
+		pkgPathPtr := llvm.ConstNull(c.i8ptrType)
+		if !method.Exported() {
+			pkgPathPtr = c.pkgPathPtr(method.Pkg().Path())
+		}
+		signatureGlobal.SetInitializer(c.ctx.ConstStruct([]llvm.Value{
+			c.getTypeCode(types.NewSignature(nil, sig.Params(), sig.Results(), sig.Variadic())),
+			pkgPathPtr,
+			c.ctx.ConstString(method.Name()+"\x00", false),
+		}, false))
 		signatureGlobal.SetLinkage(llvm.LinkOnceODRLinkage)
 		signatureGlobal.SetGlobalConstant(true)
-		signatureGlobal.SetAlignment(1)
+		signatureGlobal.SetAlignment(c.targetData.PrefTypeAlignment(c.i8ptrType))
 	}
-	return signatureGlobal
+	return llvm.ConstBitCast(signatureGlobal, c.i8ptrType)
 }
 
 // createTypeAssert will emit the code for a typeassert, used in if statements
@@ -26,10 +26,14 @@ func (b *builder) defineIntrinsicFunction() {
 		b.createMemoryZeroImpl()
 	case name == "runtime.KeepAlive":
 		b.createKeepAliveImpl()
+	case name == "runtime.frameAddress":
+		b.createFrameAddressImpl()
 	case strings.HasPrefix(name, "runtime/volatile.Load"):
 		b.createVolatileLoad()
 	case strings.HasPrefix(name, "runtime/volatile.Store"):
 		b.createVolatileStore()
+	case strings.HasPrefix(name, "device/wasm.") && token.IsExported(b.fn.Name()):
+		b.createSIMDOp()
 	case strings.HasPrefix(name, "sync/atomic.") && token.IsExported(b.fn.Name()):
 		b.createFunctionStart(true)
 		returnValue := b.createAtomicOp(b.fn.Name())
@@ -89,27 +93,54 @@ func (b *builder) createMemoryZeroImpl() {
 	b.CreateRetVoid()
 }
 
-// createKeepAlive creates the runtime.KeepAlive function. It is implemented
-// using inline assembly.
+// createFrameAddressImpl creates the runtime.frameAddress function, which
+// returns the frame pointer of its own stack frame. It is never inlined, so
+// that the frame it returns is always a frame of its own, with the return
+// address into the caller.
+func (b *builder) createFrameAddressImpl() {
+	b.createFunctionStart(true)
+	b.llvmFn.AddFunctionAttr(b.ctx.CreateEnumAttribute(llvm.AttributeKindID("noinline"), 0))
+	fnName := "llvm.frameaddress.p0"
+	if llvmutil.Major() < 15 { // compatibility with LLVM 14
+		fnName = "llvm.frameaddress.p0i8"
+	}
+	llvmFn := b.mod.NamedFunction(fnName)
+	if llvmFn.IsNil() {
+		fnType := llvm.FunctionType(b.i8ptrType, []llvm.Type{b.ctx.Int32Type()}, false)
+		llvmFn = llvm.AddFunction(b.mod, fnName, fnType)
+	}
+	frame := b.CreateCall(llvmFn.GlobalValueType(), llvmFn, []llvm.Value{llvm.ConstInt(b.ctx.Int32Type(), 0, false)}, "")
+	b.CreateRet(frame)
+}
+
+// createKeepAliveImpl creates the runtime.KeepAlive function, for when it is
+// not called directly (for example, when used as a func value). Direct calls
+// are lowered using createKeepAlive instead.
 func (b *builder) createKeepAliveImpl() {
 	b.createFunctionStart(true)
+	b.createKeepAlive(b.getValue(b.fn.Params[0], getPos(b.fn)))
+	b.CreateRetVoid()
+}
 
-	// Get the underlying value of the interface value.
-	interfaceValue := b.getValue(b.fn.Params[0], getPos(b.fn))
+// createKeepAlive lowers a call to runtime.KeepAlive with the given interface
+// value. It is implemented using inline assembly.
+func (b *builder) createKeepAlive(interfaceValue llvm.Value) {
+	// Get the underlying value of the interface value. For pointers, this is
+	// the pointer itself.
 	pointerValue := b.CreateExtractValue(interfaceValue, 1, "")
 
 	// Create an equivalent of the following C code, which is basically just a
 	// nop but ensures the pointerValue is kept alive:
 	//
-	//     __asm__ __volatile__("" : : "r"(pointerValue))
+	//     __asm__ __volatile__("" : : "r"(pointerValue) : "memory")
 	//
 	// It should be portable to basically everything as the "r" register type
-	// exists basically everywhere.
+	// exists basically everywhere. The memory clobber makes sure that all
+	// stores to the object happen before this point, and that the object
+	// (and everything else) is read again afterwards.
 	asmType := llvm.FunctionType(b.ctx.VoidType(), []llvm.Type{b.i8ptrType}, false)
-	asmFn := llvm.InlineAsm(asmType, "", "r", true, false, 0, false)
+	asmFn := llvm.InlineAsm(asmType, "", "r,~{memory}", true, false, 0, false)
 	b.createCall(asmType, asmFn, []llvm.Value{pointerValue}, "")
-
-	b.CreateRetVoid()
 }
 
 var mathToLLVMMapping = map[string]string{
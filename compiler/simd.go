@@ -0,0 +1,267 @@
+package compiler
+
+// This file implements the SIMD operations in the device/wasm package as
+// compiler builtins. They are lowered to operations on LLVM vector types, which
+// the WebAssembly backend turns into v128 instructions when the simd128
+// feature is enabled and splits into scalar instructions otherwise.
+
+import (
+	"strconv"
+	"strings"
+
+	"tinygo.org/x/go-llvm"
+)
+
+// Lane shapes that can be used as a prefix of a SIMD operation name.
+var simdShapes = []string{"I8x16", "I16x8", "I32x4", "I64x2", "F32x4", "F64x2"}
+
+// createSIMDOp is the implementation of the intrinsic functions in the
+// device/wasm package, like I32x4Add. The V128 type is a struct of two uint64
+// fields, which is converted to a vector of the lane type of the operation
+// before the operation and back afterwards. These conversions are optimized
+// away when operations follow each other.
+func (b *builder) createSIMDOp() {
+	b.createFunctionStart(true)
+	name := b.fn.Name()
+	shape, op := "I64x2", name // bitwise operations don't have a lane shape
+	for _, s := range simdShapes {
+		if strings.HasPrefix(name, s) {
+			shape, op = s, name[len(s):]
+			break
+		}
+	}
+	vecType := b.simdVectorType(shape)
+	laneType := vecType.ElementType()
+	isFloat := laneType.TypeKind() != llvm.IntegerTypeKind
+	lanes := vecType.VectorSize()
+
+	// Load all parameters, converting V128 values to vectors.
+	params := make([]llvm.Value, len(b.fn.Params))
+	for i, param := range b.fn.Params {
+		params[i] = b.getValue(param, getPos(b.fn))
+		if params[i].Type().TypeKind() == llvm.StructTypeKind {
+			params[i] = b.simdToVector(params[i], vecType)
+		}
+	}
+
+	var result llvm.Value
+	switch op {
+	case "Load":
+		b.createNilCheck(b.fn.Params[0], params[0], "deref")
+		ptr := b.CreateBitCast(params[0], llvm.PointerType(vecType, 0), "")
+		result = b.CreateLoad(vecType, ptr, "")
+		result.SetAlignment(1)
+	case "Store":
+		b.createNilCheck(b.fn.Params[0], params[0], "deref")
+		ptr := b.CreateBitCast(params[0], llvm.PointerType(vecType, 0), "")
+		store := b.CreateStore(params[1], ptr)
+		store.SetAlignment(1)
+		b.CreateRetVoid()
+		return
+	case "And":
+		result = b.CreateAnd(params[0], params[1], "")
+	case "Or":
+		result = b.CreateOr(params[0], params[1], "")
+	case "Xor":
+		result = b.CreateXor(params[0], params[1], "")
+	case "AndNot":
+		result = b.CreateAnd(params[0], b.CreateNot(params[1], ""), "")
+	case "Not":
+		result = b.CreateNot(params[0], "")
+	case "Bitselect":
+		result = b.CreateOr(
+			b.CreateAnd(params[0], params[2], ""),
+			b.CreateAnd(params[1], b.CreateNot(params[2], ""), ""), "")
+	case "AnyTrue":
+		reduced := b.createSIMDIntrinsic("llvm.vector.reduce.or."+simdTypeName(vecType), laneType, params[0])
+		b.CreateRet(b.CreateICmp(llvm.IntNE, reduced, llvm.ConstNull(laneType), ""))
+		return
+	case "AllTrue":
+		nonzero := b.CreateICmp(llvm.IntNE, params[0], llvm.ConstNull(vecType), "")
+		b.CreateRet(b.createSIMDIntrinsic("llvm.vector.reduce.and.v"+strconv.Itoa(lanes)+"i1", b.ctx.Int1Type(), nonzero))
+		return
+	case "Splat":
+		vec := b.CreateInsertElement(llvm.Undef(vecType), params[0], llvm.ConstInt(b.ctx.Int32Type(), 0, false), "")
+		mask := llvm.ConstNull(llvm.VectorType(b.ctx.Int32Type(), lanes))
+		result = b.CreateShuffleVector(vec, llvm.Undef(vecType), mask, "")
+	case "ExtractLane":
+		index := b.CreateAnd(params[1], llvm.ConstInt(params[1].Type(), uint64(lanes-1), false), "")
+		b.CreateRet(b.CreateExtractElement(params[0], index, ""))
+		return
+	case "ReplaceLane":
+		index := b.CreateAnd(params[1], llvm.ConstInt(params[1].Type(), uint64(lanes-1), false), "")
+		result = b.CreateInsertElement(params[0], params[2], index, "")
+	case "Add":
+		if isFloat {
+			result = b.CreateFAdd(params[0], params[1], "")
+		} else {
+			result = b.CreateAdd(params[0], params[1], "")
+		}
+	case "Sub":
+		if isFloat {
+			result = b.CreateFSub(params[0], params[1], "")
+		} else {
+			result = b.CreateSub(params[0], params[1], "")
+		}
+	case "Mul":
+		if isFloat {
+			result = b.CreateFMul(params[0], params[1], "")
+		} else {
+			result = b.CreateMul(params[0], params[1], "")
+		}
+	case "Div":
+		result = b.CreateFDiv(params[0], params[1], "")
+	case "Neg":
+		if isFloat {
+			result = b.CreateFNeg(params[0], "")
+		} else {
+			result = b.CreateNeg(params[0], "")
+		}
+	case "Sqrt":
+		result = b.createSIMDIntrinsic("llvm.sqrt."+simdTypeName(vecType), vecType, params[0])
+	case "Min":
+		result = b.createSIMDIntrinsic("llvm.minimum."+simdTypeName(vecType), vecType, params[0], params[1])
+	case "Max":
+		result = b.createSIMDIntrinsic("llvm.maximum."+simdTypeName(vecType), vecType, params[0], params[1])
+	case "AddSatS", "AddSatU", "SubSatS", "SubSatU":
+		// For example, llvm.sadd.sat.v8i16 for I16x8AddSatS.
+		intrinsicName := "llvm." + strings.ToLower(op[len(op)-1:]+op[:3]) + ".sat." + simdTypeName(vecType)
+		result = b.createSIMDIntrinsic(intrinsicName, vecType, params[0], params[1])
+	case "MinS", "MinU", "MaxS", "MaxU":
+		pred := map[string]llvm.IntPredicate{
+			"MinS": llvm.IntSLT,
+			"MinU": llvm.IntULT,
+			"MaxS": llvm.IntSGT,
+			"MaxU": llvm.IntUGT,
+		}[op]
+		cmp := b.CreateICmp(pred, params[0], params[1], "")
+		result = b.CreateSelect(cmp, params[0], params[1], "")
+	case "Shl", "ShrS", "ShrU":
+		// Like in WebAssembly, the shift count is taken modulo the lane width.
+		bits := laneType.IntTypeWidth()
+		n := b.CreateAnd(params[1], llvm.ConstInt(params[1].Type(), uint64(bits-1), false), "")
+		n = b.createZExtOrTrunc(n, laneType)
+		n = b.CreateInsertElement(llvm.Undef(vecType), n, llvm.ConstInt(b.ctx.Int32Type(), 0, false), "")
+		n = b.CreateShuffleVector(n, llvm.Undef(vecType), llvm.ConstNull(llvm.VectorType(b.ctx.Int32Type(), lanes)), "")
+		switch op {
+		case "Shl":
+			result = b.CreateShl(params[0], n, "")
+		case "ShrS":
+			result = b.CreateAShr(params[0], n, "")
+		case "ShrU":
+			result = b.CreateLShr(params[0], n, "")
+		}
+	case "Eq", "Ne", "LtS", "LtU", "GtS", "GtU", "Lt", "Le", "Gt", "Ge":
+		// Comparisons set all bits of a lane where they are true.
+		var cmp llvm.Value
+		if isFloat {
+			pred := map[string]llvm.FloatPredicate{
+				"Eq": llvm.FloatOEQ,
+				"Ne": llvm.FloatUNE,
+				"Lt": llvm.FloatOLT,
+				"Le": llvm.FloatOLE,
+				"Gt": llvm.FloatOGT,
+				"Ge": llvm.FloatOGE,
+			}[op]
+			cmp = b.CreateFCmp(pred, params[0], params[1], "")
+		} else {
+			pred := map[string]llvm.IntPredicate{
+				"Eq":  llvm.IntEQ,
+				"Ne":  llvm.IntNE,
+				"LtS": llvm.IntSLT,
+				"LtU": llvm.IntULT,
+				"GtS": llvm.IntSGT,
+				"GtU": llvm.IntUGT,
+			}[op]
+			cmp = b.CreateICmp(pred, params[0], params[1], "")
+		}
+		maskType := llvm.VectorType(b.ctx.IntType(128/lanes), lanes)
+		result = b.CreateSExt(cmp, maskType, "")
+	case "TruncSatF32x4S", "TruncSatF32x4U":
+		floats := b.CreateBitCast(params[0], b.simdVectorType("F32x4"), "")
+		intrinsicName := "llvm.fptosi.sat.v4i32.v4f32"
+		if op == "TruncSatF32x4U" {
+			intrinsicName = "llvm.fptoui.sat.v4i32.v4f32"
+		}
+		result = b.createSIMDIntrinsic(intrinsicName, vecType, floats)
+	case "ConvertI32x4S":
+		ints := b.CreateBitCast(params[0], b.simdVectorType("I32x4"), "")
+		result = b.CreateSIToFP(ints, vecType, "")
+	case "ConvertI32x4U":
+		ints := b.CreateBitCast(params[0], b.simdVectorType("I32x4"), "")
+		result = b.CreateUIToFP(ints, vecType, "")
+	default:
+		panic("unreachable: unknown SIMD operation: " + name) // sanity check
+	}
+	b.CreateRet(b.simdFromVector(result))
+}
+
+// simdVectorType returns the LLVM vector type for a lane shape like I32x4.
+func (b *builder) simdVectorType(shape string) llvm.Type {
+	switch shape {
+	case "I8x16":
+		return llvm.VectorType(b.ctx.Int8Type(), 16)
+	case "I16x8":
+		return llvm.VectorType(b.ctx.Int16Type(), 8)
+	case "I32x4":
+		return llvm.VectorType(b.ctx.Int32Type(), 4)
+	case "I64x2":
+		return llvm.VectorType(b.ctx.Int64Type(), 2)
+	case "F32x4":
+		return llvm.VectorType(b.ctx.FloatType(), 4)
+	case "F64x2":
+		return llvm.VectorType(b.ctx.DoubleType(), 2)
+	default:
+		panic("unreachable: unknown SIMD shape: " + shape)
+	}
+}
+
+// simdTypeName returns the name of a vector type as used in the names of
+// overloaded intrinsics, like v4i32 or v4f32.
+func simdTypeName(vecType llvm.Type) string {
+	name := "v" + strconv.Itoa(vecType.VectorSize())
+	switch laneType := vecType.ElementType(); laneType.TypeKind() {
+	case llvm.FloatTypeKind:
+		return name + "f32"
+	case llvm.DoubleTypeKind:
+		return name + "f64"
+	default:
+		return name + "i" + strconv.Itoa(laneType.IntTypeWidth())
+	}
+}
+
+// simdToVector converts a device/wasm.V128 value to a vector of the given type.
+func (b *builder) simdToVector(v llvm.Value, vecType llvm.Type) llvm.Value {
+	vec := llvm.Undef(llvm.VectorType(b.ctx.Int64Type(), 2))
+	for i := 0; i < 2; i++ {
+		field := b.CreateExtractValue(v, i, "")
+		vec = b.CreateInsertElement(vec, field, llvm.ConstInt(b.ctx.Int32Type(), uint64(i), false), "")
+	}
+	return b.CreateBitCast(vec, vecType, "")
+}
+
+// simdFromVector converts a 128-bit vector to a device/wasm.V128 value.
+func (b *builder) simdFromVector(vec llvm.Value) llvm.Value {
+	vec = b.CreateBitCast(vec, llvm.VectorType(b.ctx.Int64Type(), 2), "")
+	v := llvm.Undef(b.getLLVMType(b.fn.Signature.Results().At(0).Type()))
+	for i := 0; i < 2; i++ {
+		field := b.CreateExtractElement(vec, llvm.ConstInt(b.ctx.Int32Type(), uint64(i), false), "")
+		v = b.CreateInsertValue(v, field, i, "")
+	}
+	return v
+}
+
+// createSIMDIntrinsic calls the given LLVM intrinsic, declaring it first if
+// needed.
+func (b *builder) createSIMDIntrinsic(name string, resultType llvm.Type, args ...llvm.Value) llvm.Value {
+	argTypes := make([]llvm.Type, len(args))
+	for i, arg := range args {
+		argTypes[i] = arg.Type()
+	}
+	fnType := llvm.FunctionType(resultType, argTypes, false)
+	fn := b.mod.NamedFunction(name)
+	if fn.IsNil() {
+		fn = llvm.AddFunction(b.mod, name, fnType)
+	}
+	return b.createCall(fnType, fn, args, "")
+}
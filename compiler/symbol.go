@@ -23,15 +23,18 @@ import (
 // The linkName value contains a valid link name, even if //go:linkname is not
 // present.
 type functionInfo struct {
-	module     string     // go:wasm-module
-	importName string     // go:linkname, go:export - The name the developer assigns
-	linkName   string     // go:linkname, go:export - The name that we map for the particular module -> importName
-	section    string     // go:section - object file section name
-	exported   bool       // go:export, CGo
-	interrupt  bool       // go:interrupt
-	nobounds   bool       // go:nobounds
-	variadic   bool       // go:variadic (CGo only)
-	inline     inlineType // go:inline
+	module         string     // go:wasm-module
+	importName     string     // go:linkname, go:export - The name the developer assigns
+	linkName       string     // go:linkname, go:export - The name that we map for the particular module -> importName
+	section        string     // go:section - object file section name
+	exported       bool       // go:export, CGo
+	interrupt      bool       // go:interrupt
+	nobounds       bool       // go:nobounds
+	nowritebarrier bool       // go:nowritebarrier
+	noyield        bool       // go:noyield
+	wasmExport     string     // go:wasmexport
+	variadic       bool       // go:variadic (CGo only)
+	inline         inlineType // go:inline
 }
 
 type inlineType int
@@ -194,7 +197,7 @@ func (c *compilerContext) getFunction(fn *ssa.Function) (llvm.Type, llvm.Value)
 	// External/exported functions may not retain pointer values.
 	// https://golang.org/cmd/cgo/#hdr-Passing_pointers
 	if info.exported {
-		if c.archFamily() == "wasm32" {
+		if strings.HasPrefix(c.Triple, "wasm") {
 			// We need to add the wasm-import-module and the wasm-import-name
 			// attributes.
 			module := info.module
@@ -304,6 +307,16 @@ func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
 				info.exported = true
 				info.module = parts[1]
 				info.importName = parts[2]
+			case "//go:wasmexport":
+				// Export a function from the WebAssembly module with a plain
+				// WebAssembly signature. Unlike //export, the function runs in
+				// its own goroutine (see createWasmExport).
+				if len(parts) != 2 {
+					continue
+				}
+				if c.checkWasmExport(f, comment.Text) {
+					info.wasmExport = parts[1]
+				}
 			case "//go:inline":
 				info.inline = inlineHint
 			case "//go:noinline":
@@ -335,6 +348,23 @@ func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
 				if hasUnsafeImport(f.Pkg.Pkg) {
 					info.nobounds = true
 				}
+			case "//go:nowritebarrier":
+				// Don't insert write barriers in this function. This is used
+				// by the incremental GC in the runtime, which would otherwise
+				// call itself recursively.
+				// Like //go:nobounds, it is only allowed in packages that
+				// import unsafe.
+				if hasUnsafeImport(f.Pkg.Pkg) {
+					info.nowritebarrier = true
+				}
+			case "//go:noyield":
+				// Don't insert yield points in the loops of this function
+				// (see transform.InsertSafepoints), for code that must not
+				// be interrupted by other goroutines. It also implies
+				// go:noinline, as the loops would otherwise get yield points
+				// when inlined in another function.
+				info.noyield = true
+				info.inline = inlineNone
 			case "//go:variadic":
 				// The //go:variadic pragma is emitted by the CGo preprocessing
 				// pass for C variadic functions. This includes both explicit
@@ -377,6 +407,32 @@ func (c *compilerContext) checkWasmImport(f *ssa.Function, pragma string) {
 		c.addError(f.Pos(), fmt.Sprintf("can only use //go:wasmimport on declarations"))
 		return
 	}
+	c.checkWasmSignature(f, pragma)
+}
+
+// Check whether this function can be used in //go:wasmexport. It will add an
+// error if this is not the case, and returns false if no wrapper can be
+// created for it.
+func (c *compilerContext) checkWasmExport(f *ssa.Function, pragma string) bool {
+	if !strings.HasPrefix(c.Triple, "wasm") {
+		c.addError(f.Pos(), fmt.Sprintf("%s: only supported on WebAssembly", pragma))
+		return false
+	}
+	if f.Signature.Recv() != nil || f.TypeParams().Len() != 0 || len(f.TypeArgs()) != 0 {
+		c.addError(f.Pos(), fmt.Sprintf("%s: can only export plain functions, not methods or generic functions", pragma))
+		return false
+	}
+	if f.Blocks == nil {
+		c.addError(f.Pos(), "can only use //go:wasmexport on definitions")
+		return false
+	}
+	c.checkWasmSignature(f, pragma)
+	return true
+}
+
+// Check whether the parameters and result of a //go:wasmimport or
+// //go:wasmexport function map directly to WebAssembly types.
+func (c *compilerContext) checkWasmSignature(f *ssa.Function, pragma string) {
 	if f.Signature.Results().Len() > 1 {
 		c.addError(f.Signature.Results().At(1).Pos(), fmt.Sprintf("%s: too many return values", pragma))
 	} else if f.Signature.Results().Len() == 1 {
@@ -462,6 +518,10 @@ func (c *compilerContext) addStandardDefinedAttributes(llvmFn llvm.Value) {
 	// It reduces binary size on Linux a little bit on non-x86_64 targets by
 	// eliminating exception tables for these functions.
 	llvmFn.AddFunctionAttr(c.ctx.CreateEnumAttribute(llvm.AttributeKindID("nounwind"), 0))
+	if c.FramePointers {
+		// Needed to walk the stack in runtime.Callers.
+		llvmFn.AddFunctionAttr(c.ctx.CreateStringAttribute("frame-pointer", "all"))
+	}
 	if strings.Split(c.Triple, "-")[0] == "x86_64" {
 		// Required by the ABI.
 		if llvmutil.Major() < 15 {
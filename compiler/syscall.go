@@ -135,6 +135,72 @@ func (b *builder) createRawSyscall(call *ssa.CallCommon) (llvm.Value, error) {
 		fnType := llvm.FunctionType(b.uintptrType, argTypes, false)
 		target := llvm.InlineAsm(fnType, "svc #0", constraints, true, false, 0, false)
 		return b.CreateCall(fnType, target, args, ""), nil
+	case (b.GOARCH == "mips" || b.GOARCH == "mipsle") && b.GOOS == "linux":
+		// Implement the O32 system call convention for Linux.
+		// Sources:
+		//   syscall(2) man page
+		//   https://git.musl-libc.org/cgit/musl/tree/arch/mips/syscall_arch.h
+		// The system call number goes in $2 (v0) which also holds the result.
+		// Register $7 (a3) is both the fourth parameter and an error flag: if
+		// it is non-zero after the syscall, $2 contains a (positive) errno.
+		// Parameters beyond the fourth are passed on the stack.
+		args := []llvm.Value{num}
+		argTypes := []llvm.Type{b.uintptrType}
+		// Constraints will look something like:
+		//   ={$2},={$7},0,{$4},{$5},{$6},1,r,r,~{$3},...
+		constraints := "={$2},={$7},0"
+		syscallParams := call.Args[1:]
+		if len(syscallParams) > 7 {
+			// Only sync_file_range needs 7 parameters, no system call needs
+			// more. Ignore the extra parameters of Syscall9.
+			syscallParams = syscallParams[:7]
+		}
+		for i, arg := range syscallParams {
+			constraints += "," + [...]string{
+				"{$4}",
+				"{$5}",
+				"{$6}",
+				"1", // tie to error flag output
+				"r", // stored on the stack
+				"r", // stored on the stack
+				"r", // stored on the stack
+			}[i]
+			llvmValue := b.getValue(arg, getPos(call))
+			args = append(args, llvmValue)
+			argTypes = append(argTypes, llvmValue.Type())
+		}
+		if len(syscallParams) < 4 {
+			// The error flag is always set, even when $7 isn't a parameter.
+			args = append(args, llvm.ConstInt(b.uintptrType, 0, false))
+			argTypes = append(argTypes, b.uintptrType)
+			constraints += ",1"
+		}
+		asm := "syscall"
+		if len(syscallParams) > 4 {
+			// Store parameters 5-7 in the outgoing argument area. Operand $7 is
+			// the fifth parameter (after the two outputs, the system call
+			// number and the first four parameters).
+			// The ".set noat" is needed because LLVM may pick $1 (at) as an
+			// operand register.
+			asm = ".set noat\nsubu $$sp, $$sp, 32\n"
+			for i := 4; i < len(syscallParams); i++ {
+				asm += "sw $" + strconv.Itoa(i+3) + ", " + strconv.Itoa(i*4) + "($$sp)\n"
+			}
+			asm += "syscall\naddu $$sp, $$sp, 32\n.set at"
+		}
+		constraints += ",~{$3},~{$4},~{$5},~{$6},~{$8},~{$9},~{$10},~{$11},~{$12},~{$13},~{$14},~{$15},~{$24},~{$25},~{hi},~{lo},~{memory}"
+		returnType := b.ctx.StructType([]llvm.Type{b.uintptrType, b.uintptrType}, false)
+		fnType := llvm.FunctionType(returnType, argTypes, false)
+		target := llvm.InlineAsm(fnType, asm, constraints, true, false, 0, false)
+		call := b.CreateCall(fnType, target, args, "")
+		resultCode := b.CreateExtractValue(call, 0, "") // $2
+		errorFlag := b.CreateExtractValue(call, 1, "")  // $7
+		// Return the result with the same convention as other architectures:
+		//     return (errorFlag != 0) ? -resultCode : resultCode
+		zero := llvm.ConstInt(b.uintptrType, 0, false)
+		isError := b.CreateICmp(llvm.IntNE, errorFlag, zero, "")
+		negativeResult := b.CreateSub(zero, resultCode, "")
+		return b.CreateSelect(isError, negativeResult, resultCode, ""), nil
 	default:
 		return llvm.Value{}, b.makeError(call.Pos(), "unknown GOOS/GOARCH for syscall: "+b.GOOS+"/"+b.GOARCH)
 	}
@@ -41,3 +41,24 @@ func invalidreturn() int
 //
 //go:wasmimport modulename invalidUnsafePointerReturn
 func invalidUnsafePointerReturn() unsafe.Pointer
+
+//go:wasmexport validexport
+func validexport(a int32, b uint64, c float64, d unsafe.Pointer) int32 {
+	return a
+}
+
+// ERROR: //go:wasmexport invalidexport: unsupported result type int
+// ERROR: //go:wasmexport invalidexport: unsupported parameter type string
+//
+//go:wasmexport invalidexport
+func invalidexport(a string) int {
+	return 0
+}
+
+type exportType struct{}
+
+// ERROR: //go:wasmexport methodexport: can only export plain functions, not methods or generic functions
+//
+//go:wasmexport methodexport
+func (exportType) methodexport() {
+}
@@ -0,0 +1,93 @@
+package compiler
+
+// This file implements //go:wasmexport, which exports a Go function from the
+// WebAssembly module with a plain WebAssembly signature.
+
+import (
+	"go/types"
+
+	"github.com/tinygo-org/tinygo/compiler/llvmutil"
+	"tinygo.org/x/go-llvm"
+)
+
+// createWasmExport creates the exported wrapper of a //go:wasmexport function.
+// Unlike a function exported with //export, which runs on the stack of
+// whatever called it, the function is called in a new goroutine by
+// runtime.wasmExportRun so that it can block like any other goroutine. The
+// wrapper looks like this:
+//
+//	func wrapper(x, y int32) int32 {
+//	    var frame struct{ x, y, result int32 }
+//	    frame.x, frame.y = x, y
+//	    runtime.wasmExportRun(func() {
+//	        frame.result = fn(frame.x, frame.y)
+//	    })
+//	    return frame.result
+//	}
+//
+// All parameters and the result are basic types that map directly to
+// WebAssembly types (see checkWasmExport), so they don't need to be expanded.
+func (b *builder) createWasmExport() {
+	wb := &builder{
+		compilerContext: b.compilerContext,
+		Builder:         b.ctx.NewBuilder(),
+	}
+	defer wb.Dispose()
+
+	var paramTypes []llvm.Type
+	for _, param := range b.fn.Params {
+		paramTypes = append(paramTypes, b.getLLVMType(param.Type()))
+	}
+	frameTypes := append([]llvm.Type(nil), paramTypes...)
+	resultType := b.ctx.VoidType()
+	if b.fn.Signature.Results().Len() == 1 {
+		resultType = b.getLLVMType(b.fn.Signature.Results().At(0).Type())
+		frameTypes = append(frameTypes, resultType)
+	}
+	frameType := b.ctx.StructType(frameTypes, false)
+	frameField := func(frame llvm.Value, i int) llvm.Value {
+		return wb.CreateInBoundsGEP(frameType, frame, []llvm.Value{
+			llvm.ConstInt(b.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(b.ctx.Int32Type(), uint64(i), false),
+		}, "")
+	}
+
+	// Create the function that runs in the new goroutine. It is called as a
+	// func value, with a pointer to the frame as the context parameter.
+	callType := llvm.FunctionType(b.ctx.VoidType(), []llvm.Type{b.i8ptrType}, false)
+	call := llvm.AddFunction(b.mod, b.llvmFn.Name()+"$wasmexport.call", callType)
+	b.addStandardAttributes(call)
+	call.SetLinkage(llvm.InternalLinkage)
+	call.SetUnnamedAddr(true)
+	wb.SetInsertPointAtEnd(b.ctx.AddBasicBlock(call, "entry"))
+	var params []llvm.Value
+	for i, paramType := range paramTypes {
+		params = append(params, wb.CreateLoad(paramType, frameField(call.Param(0), i), ""))
+	}
+	params = append(params, llvm.Undef(b.i8ptrType)) // unused context parameter
+	result := wb.CreateCall(b.llvmFnType, b.llvmFn, params, "")
+	if len(frameTypes) > len(paramTypes) {
+		wb.CreateStore(result, frameField(call.Param(0), len(paramTypes)))
+	}
+	wb.CreateRetVoid()
+
+	// Create the exported wrapper.
+	wrapperType := llvm.FunctionType(resultType, paramTypes, false)
+	wrapper := llvm.AddFunction(b.mod, b.llvmFn.Name()+"$wasmexport", wrapperType)
+	b.addStandardAttributes(wrapper)
+	wrapper.AddFunctionAttr(b.ctx.CreateStringAttribute("wasm-export-name", b.info.wasmExport))
+	llvmutil.AppendToGlobal(b.mod, "llvm.used", wrapper)
+	wb.SetInsertPointAtEnd(b.ctx.AddBasicBlock(wrapper, "entry"))
+	frame := wb.CreateAlloca(frameType, "frame")
+	for i := range paramTypes {
+		wb.CreateStore(wrapper.Param(i), frameField(frame, i))
+	}
+	sig := types.NewSignatureType(nil, nil, nil, nil, nil, false)
+	fn := b.compilerContext.createFuncValue(wb.Builder, call, frame, sig)
+	wb.createRuntimeCall("wasmExportRun", []llvm.Value{fn}, "")
+	if len(frameTypes) > len(paramTypes) {
+		wb.CreateRet(wb.CreateLoad(resultType, frameField(frame, len(paramTypes)), ""))
+	} else {
+		wb.CreateRetVoid()
+	}
+}
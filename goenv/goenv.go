@@ -27,8 +27,11 @@ var Keys = []string{
 }
 
 func init() {
-	if Get("GOARCH") == "arm" {
+	switch Get("GOARCH") {
+	case "arm":
 		Keys = append(Keys, "GOARM")
+	case "mips", "mipsle":
+		Keys = append(Keys, "GOMIPS")
 	}
 }
 
@@ -45,9 +48,12 @@ func Get(name string) string {
 		goos := os.Getenv("GOOS")
 		if goos == "" {
 			goos = runtime.GOOS
-		}
-		if goos == "android" {
-			goos = "linux"
+			if goos == "android" {
+				// Build regular Linux executables when running on Android.
+				// GOOS=android has to be set explicitly to build libraries
+				// that can be loaded in Android apps.
+				goos = "linux"
+			}
 		}
 		return goos
 	case "GOARCH":
@@ -69,6 +75,13 @@ func Get(name string) string {
 		// difference between ARMv6 and ARMv7. ARMv6 binaries are much smaller,
 		// especially when floating point instructions are involved.
 		return "6"
+	case "GOMIPS":
+		if gomips := os.Getenv("GOMIPS"); gomips != "" {
+			return gomips
+		}
+		// Default to hardfloat, like upstream Go. Most routers need
+		// GOMIPS=softfloat however, as they don't have a FPU.
+		return "hardfloat"
 	case "GOROOT":
 		return getGoroot()
 	case "GOPATH":
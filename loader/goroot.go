@@ -214,7 +214,7 @@ func listGorootMergeLinks(goroot, tinygoroot string, overrides map[string]bool)
 // with the TinyGo version. This is the case on some targets.
 func needsSyscallPackage(buildTags []string) bool {
 	for _, tag := range buildTags {
-		if tag == "baremetal" || tag == "darwin" || tag == "nintendoswitch" || tag == "tinygo.wasm" {
+		if tag == "baremetal" || tag == "darwin" || tag == "freebsd" || tag == "nintendoswitch" || tag == "tinygo.wasm" {
 			return true
 		}
 	}
@@ -226,6 +226,7 @@ func needsSyscallPackage(buildTags []string) bool {
 func pathsToOverride(goMinor int, needsSyscallPackage bool) map[string]bool {
 	paths := map[string]bool{
 		"":                      true,
+		"arena/":                false,
 		"crypto/":               true,
 		"crypto/rand/":          false,
 		"device/":               false,
@@ -234,6 +235,7 @@ func pathsToOverride(goMinor int, needsSyscallPackage bool) map[string]bool {
 		"internal/bytealg/":     false,
 		"internal/fuzz/":        false,
 		"internal/reflectlite/": false,
+		"internal/semihosting/": false,
 		"internal/task/":        false,
 		"machine/":              false,
 		"net/":                  true,
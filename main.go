@@ -170,23 +170,31 @@ func Build(pkgName, outpath string, options *compileopts.Options) error {
 		return err
 	}
 
+	if result.Binary != "" && outpath == "" {
+		if strings.HasSuffix(pkgName, ".go") {
+			// A Go file was specified directly on the command line.
+			// Base the binary name off of it.
+			outpath = filepath.Base(pkgName[:len(pkgName)-3]) + config.DefaultBinaryExtension()
+		} else {
+			// Pick a default output path based on the main directory.
+			outpath = filepath.Base(result.MainDir) + config.DefaultBinaryExtension()
+		}
+	}
+
+	if result.Header != "" {
+		// Put the C header next to the output file, with the same base name
+		// (like libfoo.so and libfoo.h, or foo.o and foo.h).
+		headerPath := strings.TrimSuffix(outpath, filepath.Ext(outpath)) + ".h"
+		if err := moveFile(result.Header, headerPath); err != nil {
+			return err
+		}
+	}
+
 	if result.Binary != "" {
 		// If result.Binary is set, it means there is a build output (elf, hex,
 		// etc) that we need to move to the outpath. If it isn't set, it means
 		// the build output was a .ll, .bc or .o file that has already been
 		// written to outpath and so we don't need to do anything.
-
-		if outpath == "" {
-			if strings.HasSuffix(pkgName, ".go") {
-				// A Go file was specified directly on the command line.
-				// Base the binary name off of it.
-				outpath = filepath.Base(pkgName[:len(pkgName)-3]) + config.DefaultBinaryExtension()
-			} else {
-				// Pick a default output path based on the main directory.
-				outpath = filepath.Base(result.MainDir) + config.DefaultBinaryExtension()
-			}
-		}
-
 		if err := os.Rename(result.Binary, outpath); err != nil {
 			// Moving failed. Do a file copy.
 			inf, err := os.Open(result.Binary)
@@ -728,6 +736,9 @@ func Debug(debugger, pkgName string, ocdOutput bool, options *compileopts.Option
 	params := []string{result.Executable}
 	switch debugger {
 	case "gdb":
+		// Load the GDB extension for TinyGo, which adds goroutine support.
+		gdbScript := filepath.Join(goenv.Get("TINYGOROOT"), "src", "runtime", "runtime-gdb.py")
+		params = append(params, "-ex", "source "+gdbScript)
 		if port != "" {
 			params = append(params, "-ex", "target extended-remote "+port)
 		}
@@ -1395,10 +1406,15 @@ func main() {
 	command := os.Args[1]
 
 	opt := flag.String("opt", "z", "optimization level: 0, 1, 2, s, z")
-	gc := flag.String("gc", "", "garbage collector to use (none, leaking, conservative)")
+	buildMode := flag.String("buildmode", "", "build mode to use (default, c-shared, c-archive)")
+	gc := flag.String("gc", "", "garbage collector to use (none, leaking, conservative, precise, incremental, compacting)")
+	allocator := flag.String("allocator", "", "heap allocator used by the garbage collector (linear, tlsf)")
 	panicStrategy := flag.String("panic", "print", "panic strategy (print, trap)")
-	scheduler := flag.String("scheduler", "", "which scheduler to use (none, tasks, asyncify)")
-	serial := flag.String("serial", "", "which serial output to use (none, uart, usb)")
+	reflectLevel := flag.String("reflect", "", "reflect type information to include (full, min)")
+	lineTable := flag.Bool("linetable", false, "include a table to resolve runtime.Caller and runtime.Callers PCs to source locations (increases binary size)")
+	scheduler := flag.String("scheduler", "", "which scheduler to use (none, tasks, asyncify, cores)")
+	autoYield := flag.Bool("autoyield", false, "insert yield points in loops, so that goroutines that never block don't starve other goroutines (tasks and cores schedulers)")
+	serial := flag.String("serial", "", "which serial output to use (none, uart, usb, rtt, itm)")
 	work := flag.Bool("work", false, "print the name of the temporary build directory and do not delete this directory on exit")
 	interpTimeout := flag.Duration("interp-timeout", 180*time.Second, "interp optimization pass timeout")
 	var tags buildutil.TagsFlag
@@ -1502,11 +1518,17 @@ func main() {
 		GOOS:            goenv.Get("GOOS"),
 		GOARCH:          goenv.Get("GOARCH"),
 		GOARM:           goenv.Get("GOARM"),
+		GOMIPS:          goenv.Get("GOMIPS"),
 		Target:          *target,
+		BuildMode:       *buildMode,
 		StackSize:       stackSize,
 		Opt:             *opt,
 		GC:              *gc,
+		Allocator:       *allocator,
 		PanicStrategy:   *panicStrategy,
+		Reflect:         *reflectLevel,
+		LineTable:       *lineTable,
+		AutoYield:       *autoYield,
 		Scheduler:       *scheduler,
 		Serial:          *serial,
 		Work:            *work,
@@ -1781,6 +1803,7 @@ func main() {
 				GOOS       string   `json:"goos"`
 				GOARCH     string   `json:"goarch"`
 				GOARM      string   `json:"goarm"`
+				GOMIPS     string   `json:"gomips"`
 				BuildTags  []string `json:"build_tags"`
 				GC         string   `json:"garbage_collector"`
 				Scheduler  string   `json:"scheduler"`
@@ -1790,6 +1813,7 @@ func main() {
 				GOOS:       config.GOOS(),
 				GOARCH:     config.GOARCH(),
 				GOARM:      config.GOARM(),
+				GOMIPS:     config.GOMIPS(),
 				BuildTags:  config.BuildTags(),
 				GC:         config.GC(),
 				Scheduler:  config.Scheduler(),
@@ -30,10 +30,12 @@ const TESTDATA = "testdata"
 var testTarget = flag.String("target", "", "override test target")
 
 var supportedLinuxArches = map[string]string{
-	"AMD64Linux": "linux/amd64",
-	"X86Linux":   "linux/386",
-	"ARMLinux":   "linux/arm/6",
-	"ARM64Linux": "linux/arm64",
+	"AMD64Linux":  "linux/amd64",
+	"X86Linux":    "linux/386",
+	"ARMLinux":    "linux/arm/6",
+	"ARM64Linux":  "linux/arm64",
+	"MIPSLinux":   "linux/mips/hardfloat",
+	"MIPSLELinux": "linux/mipsle/softfloat",
 }
 
 var sema = make(chan struct{}, runtime.NumCPU())
@@ -223,6 +225,125 @@ func runPlatTests(options compileopts.Options, tests []string, t *testing.T) {
 			options.Scheduler = "none"
 			runTest("alias.go", options, t, nil, nil)
 		})
+		t.Run("simd.go", func(t *testing.T) {
+			t.Parallel()
+			runTest("simd.go", options, t, nil, nil)
+		})
+		t.Run("simd.go-simd128", func(t *testing.T) {
+			t.Parallel()
+			options := compileopts.Options(options)
+			options.LLVMFeatures = "+simd128"
+			runTest("simd.go", options, t, nil, nil)
+		})
+	}
+	if options.Target == "" {
+		t.Run("gc.go-compacting", func(t *testing.T) {
+			t.Parallel()
+			options := compileopts.Options(options)
+			options.GC = "compacting"
+			runTest("gc.go", options, t, nil, nil)
+		})
+		t.Run("gc.go-tlsf", func(t *testing.T) {
+			t.Parallel()
+			options := compileopts.Options(options)
+			options.Allocator = "tlsf"
+			runTest("gc.go", options, t, nil, nil)
+		})
+		t.Run("preempt.go-autoyield", func(t *testing.T) {
+			t.Parallel()
+			options := compileopts.Options(options)
+			options.Scheduler = "tasks"
+			options.AutoYield = true
+			runTest("preempt.go", options, t, nil, nil)
+		})
+		if runtime.GOOS == "linux" {
+			t.Run("callers.go", func(t *testing.T) {
+				t.Parallel()
+				options := compileopts.Options(options)
+				options.LineTable = true
+				runTest("callers.go", options, t, nil, nil)
+			})
+			t.Run("memprofile.go", func(t *testing.T) {
+				t.Parallel()
+				options := compileopts.Options(options)
+				options.LineTable = true
+				runTest("memprofile.go", options, t, nil, nil)
+			})
+		}
+		if runtime.GOOS != "windows" {
+			t.Run("cpuprofile.go", func(t *testing.T) {
+				t.Parallel()
+				runTest("cpuprofile.go", options, t, nil, nil)
+			})
+		}
+		t.Run("trace.go", func(t *testing.T) {
+			t.Parallel()
+			runTest("trace.go", options, t, nil, nil)
+		})
+		t.Run("watchdog.go", func(t *testing.T) {
+			t.Parallel()
+			runTest("watchdog.go", options, t, nil, nil)
+		})
+		t.Run("heappoison.go", func(t *testing.T) {
+			t.Parallel()
+			options := compileopts.Options(options)
+			options.Tags = []string{"heappoison"}
+			config, err := builder.NewConfig(&options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The program is expected to panic. The message includes
+			// addresses, so only check that it is there.
+			stdout := &bytes.Buffer{}
+			_, err = buildAndRun("./"+TESTDATA+"/heappoison.go", config, stdout, nil, nil, time.Minute, func(cmd *exec.Cmd, result builder.BuildResult) error {
+				return cmd.Run()
+			})
+			if err == nil {
+				t.Error("heappoison.go did not panic")
+			}
+			if !strings.Contains(stdout.String(), "write to freed heap memory") {
+				if err != nil {
+					printCompilerError(t.Log, err)
+				}
+				t.Log("stdout:", stdout.String())
+				t.Error("heappoison.go did not detect the write to freed memory")
+			}
+		})
+	}
+	if options.Target == "" || options.Target == "cortex-m-qemu" || options.Target == "riscv-qemu" {
+		t.Run("gccompact.go", func(t *testing.T) {
+			t.Parallel()
+			options := compileopts.Options(options)
+			options.GC = "compacting"
+			runTest("gccompact.go", options, t, nil, nil)
+		})
+	}
+	if options.Target == "riscv-qemu" {
+		t.Run("extram.go", func(t *testing.T) {
+			t.Parallel()
+			runTest("extram.go", options, t, nil, nil)
+		})
+	}
+	if options.Target == "wasi" {
+		t.Run("corechan.go-wasi-threads", func(t *testing.T) {
+			t.Parallel()
+			options := compileopts.Options(options)
+			options.Target = "wasi-threads"
+			runTest("corechan.go", options, t, nil, nil)
+		})
+		t.Run("corechan.go-wasi-threads-GOMAXPROCS=2", func(t *testing.T) {
+			t.Parallel()
+			options := compileopts.Options(options)
+			options.Target = "wasi-threads"
+			runTest("corechan.go", options, t, nil, []string{"GOMAXPROCS=2"})
+		})
+		t.Run("gc.go-wasi-threads", func(t *testing.T) {
+			t.Parallel()
+			options := compileopts.Options(options)
+			options.Target = "wasi-threads"
+			runTest("gc.go", options, t, nil, nil)
+		})
 	}
 	if options.Target == "" || options.Target == "wasi" {
 		t.Run("filesystem.go", func(t *testing.T) {
@@ -271,6 +392,7 @@ func optionsFromTarget(target string, sema chan struct{}) compileopts.Options {
 		GOOS:          goenv.Get("GOOS"),
 		GOARCH:        goenv.Get("GOARCH"),
 		GOARM:         goenv.Get("GOARM"),
+		GOMIPS:        goenv.Get("GOMIPS"),
 		Target:        target,
 		Semaphore:     sema,
 		InterpTimeout: 180 * time.Second,
@@ -294,8 +416,11 @@ func optionsFromOSARCH(osarch string, sema chan struct{}) compileopts.Options {
 		VerifyIR:      true,
 		Opt:           "z",
 	}
-	if options.GOARCH == "arm" {
+	switch options.GOARCH {
+	case "arm":
 		options.GOARM = parts[2]
+	case "mips", "mipsle":
+		options.GOMIPS = parts[2]
 	}
 	return options
 }
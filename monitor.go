@@ -102,6 +102,9 @@ func Monitor(executable, port string, options *compileopts.Options) error {
 							fmt.Printf("[tinygo: panic at %s]\n", loc.String())
 						}
 					}
+					if address := extractFaultAddress(line); address != 0 {
+						printFaultLocation(executable, address)
+					}
 					line = line[:0]
 				} else {
 					line = append(line, c)
@@ -142,6 +145,76 @@ func extractPanicAddress(line []byte) uint64 {
 	return 0
 }
 
+// faultAddressMatch matches the line with the pc in the stacked registers
+// printed by the Cortex-M HardFault handler.
+var faultAddressMatch = regexp.MustCompile(`^\s*r12=0x[0-9a-f]+ lr=0x[0-9a-f]+ pc=0x([0-9a-f]+) `)
+
+// Extract the address of the faulting instruction from the registers printed
+// after a hardware fault. It returns 0 if the line doesn't contain them.
+func extractFaultAddress(line []byte) uint64 {
+	matches := faultAddressMatch.FindSubmatch(line)
+	if matches != nil {
+		address, err := strconv.ParseUint(string(matches[1]), 16, 64)
+		if err == nil {
+			return address
+		}
+	}
+	return 0
+}
+
+// Print the function and source location of a hardware fault, if they can be
+// found in the debug information.
+func printFaultLocation(executable string, address uint64) {
+	loc, err := addressToLine(executable, address)
+	if err != nil || !loc.IsValid() {
+		return
+	}
+	name, err := addressToFunction(executable, address)
+	if err != nil || name == "" {
+		fmt.Printf("[tinygo: fault at %s]\n", loc.String())
+		return
+	}
+	fmt.Printf("[tinygo: fault in %s at %s]\n", name, loc.String())
+}
+
+// Find the name of the function that contains the given address in the binary.
+// It returns the empty string if the function could not be found.
+func addressToFunction(executable string, address uint64) (string, error) {
+	data, err := readDWARF(executable)
+	if err != nil {
+		return "", err
+	}
+	r := data.Reader()
+
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return "", err
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag != dwarf.TagSubprogram {
+			continue
+		}
+		// Inlined functions and nested scopes are children of the
+		// subprogram, skip them.
+		r.SkipChildren()
+		ranges, err := data.Ranges(e)
+		if err != nil {
+			return "", err
+		}
+		for _, rng := range ranges {
+			if rng[0] <= address && address < rng[1] {
+				name, _ := e.Val(dwarf.AttrName).(string)
+				return name, nil
+			}
+		}
+	}
+
+	return "", nil // function not found
+}
+
 // Convert an address in the binary to a source address location.
 func addressToLine(executable string, address uint64) (token.Position, error) {
 	data, err := readDWARF(executable)
@@ -64,3 +64,18 @@ func TestTraceback(t *testing.T) {
 		t.Errorf("expected panic location to be line 6, got line %d", location.Line)
 	}
 }
+
+func TestExtractFaultAddress(t *testing.T) {
+	for _, tc := range []struct {
+		line    string
+		address uint64
+	}{
+		{"  r12=0x00000000 lr=0x000012c5 pc=0x000012ce psr=0x61000000\r", 0x12ce},
+		{"  r0=0x00000000 r1=0x00000001 r2=0x00000002 r3=0x00000003\r", 0},
+		{"panic: runtime error at 0x000012ce: nil pointer dereference", 0},
+	} {
+		if address := extractFaultAddress([]byte(tc.line)); address != tc.address {
+			t.Errorf("extractFaultAddress(%q): expected %#x, got %#x", tc.line, tc.address, address)
+		}
+	}
+}
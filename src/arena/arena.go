@@ -0,0 +1,163 @@
+// Package arena provides the ability to allocate memory for a collection of Go
+// values and free that space manually all at once. It has the same API as the
+// experimental arena package in upstream Go.
+//
+// This is useful for code that allocates many short-lived objects, such as
+// parsers: instead of leaving the objects for the GC, the whole arena is freed
+// at once when the objects are no longer needed. Arenas allocate memory in
+// chunks, which are reused by later arenas after the arena is freed. This way
+// a program that repeatedly uses an arena (for example, to handle a request)
+// doesn't need to allocate from the heap at all once it has warmed up, and
+// therefore doesn't trigger GC cycles.
+//
+// Unlike in upstream Go, using memory after the arena was freed is not
+// detected: the memory will simply be reused for the next arena. Only free an
+// arena when no references into it remain.
+package arena
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+const (
+	// Size of each chunk of memory, including the header.
+	chunkSize = 1024
+
+	// Objects bigger than this are allocated on their own, to avoid wasting
+	// a large part of a chunk.
+	maxSmallSize = chunkSize / 4
+)
+
+//go:linkname runtime_alloc runtime.alloc
+func runtime_alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer
+
+// Chunks that are not used by any arena. They are released when the GC runs,
+// like other items in a sync.Pool.
+var chunkPool sync.Pool
+
+// chunkHeader is stored at the start of every chunk.
+type chunkHeader struct {
+	next unsafe.Pointer // previous chunk of the same arena
+}
+
+// Arena represents a collection of Go values allocated and freed together.
+// Arenas are useful for improving efficiency as they may be freed back to the
+// runtime manually, though any memory obtained from freed arenas must not be
+// accessed once that happens.
+//
+// An Arena is not safe for concurrent use by multiple goroutines.
+type Arena struct {
+	chunk  unsafe.Pointer // current chunk, which links to the previous chunks
+	offset uintptr        // number of bytes used in the current chunk
+}
+
+// NewArena allocates a new arena.
+func NewArena() *Arena {
+	return &Arena{}
+}
+
+// Free frees the arena (and all objects allocated from the arena) so that
+// memory backing the arena can be reused fairly quickly without garbage
+// collection overhead. Applications must not call any method on this arena
+// after it has been freed.
+func (a *Arena) Free() {
+	used := a.offset
+	for chunk := a.chunk; chunk != nil; {
+		next := (*chunkHeader)(chunk).next
+
+		// Clear the chunk, so that it doesn't keep old objects alive while in
+		// the pool and is ready to be used by the next arena.
+		buf := unsafe.Slice((*byte)(chunk), used)
+		for i := range buf {
+			buf[i] = 0
+		}
+		chunkPool.Put(chunk)
+
+		chunk = next
+		used = chunkSize
+	}
+	a.chunk = nil
+	a.offset = 0
+}
+
+// alloc returns size bytes of zeroed memory with the given alignment.
+func (a *Arena) alloc(size, align uintptr) unsafe.Pointer {
+	if size > maxSmallSize {
+		// The memory is managed by the GC, so it doesn't need to be tracked.
+		return runtime_alloc(size, nil)
+	}
+	if a.chunk != nil {
+		addr := (uintptr(a.chunk) + a.offset + align - 1) &^ (align - 1)
+		if end := addr + size - uintptr(a.chunk); end <= chunkSize {
+			a.offset = end
+			return unsafe.Add(a.chunk, addr-uintptr(a.chunk))
+		}
+	}
+
+	// Start a new chunk. The runtime allocates chunks without a known layout,
+	// so they're scanned conservatively and never moved.
+	chunk, _ := chunkPool.Get().(unsafe.Pointer)
+	if chunk == nil {
+		chunk = runtime_alloc(chunkSize, nil)
+	}
+	(*chunkHeader)(chunk).next = a.chunk
+	a.chunk = chunk
+	a.offset = unsafe.Sizeof(chunkHeader{})
+	return a.alloc(size, align)
+}
+
+// New creates a new *T in the provided arena. The *T must not be used after
+// the arena is freed. Accessing the value after free may result in a fault,
+// but this fault is also not guaranteed.
+func New[T any](a *Arena) *T {
+	var zero T
+	return (*T)(a.alloc(unsafe.Sizeof(zero), unsafe.Alignof(zero)))
+}
+
+// MakeSlice creates a new []T with the provided capacity and length. The []T
+// must not be used after the arena is freed. Accessing the underlying storage
+// of the slice after free may result in a fault, but this fault is also not
+// guaranteed.
+func MakeSlice[T any](a *Arena, len, cap int) []T {
+	if len < 0 {
+		panic("arena: makeslice: len out of range")
+	}
+	var zero T
+	size := unsafe.Sizeof(zero)
+	if cap < len || (size != 0 && uintptr(cap) > ^uintptr(0)/size) {
+		panic("arena: makeslice: cap out of range")
+	}
+	ptr := a.alloc(size*uintptr(cap), unsafe.Alignof(zero))
+	return unsafe.Slice((*T)(ptr), cap)[:len]
+}
+
+// Clone makes a shallow copy of the input value that is no longer bound to any
+// arena it may have been allocated from, returning the copy. Only pointers,
+// slices and strings are supported.
+//
+// Unlike upstream Go, the value is always copied as it isn't known whether it
+// was allocated from an arena.
+func Clone[T any](s T) T {
+	v := reflect.ValueOf(&s).Elem()
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			c := reflect.New(v.Type().Elem())
+			c.Elem().Set(v.Elem())
+			v.Set(c)
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+			reflect.Copy(c, v)
+			v.Set(c)
+		}
+	case reflect.String:
+		v.SetString(string([]byte(v.String())))
+	default:
+		panic("arena: Clone only supports pointers, slices, and strings")
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package arena_test
+
+import (
+	"arena"
+	"testing"
+)
+
+type node struct {
+	value int
+	next  *node
+}
+
+func TestArenaNew(t *testing.T) {
+	a := arena.NewArena()
+	var list *node
+	for i := 0; i < 1000; i++ {
+		n := arena.New[node](a)
+		if n.value != 0 || n.next != nil {
+			t.Fatalf("New returned non-zero memory: %+v", *n)
+		}
+		n.value = i
+		n.next = list
+		list = n
+	}
+	for i := 999; i >= 0; i-- {
+		if list.value != i {
+			t.Fatalf("got value %d, expected %d", list.value, i)
+		}
+		list = list.next
+	}
+	a.Free()
+
+	// Memory from the freed arena is reused, and must be cleared.
+	a = arena.NewArena()
+	for i := 0; i < 1000; i++ {
+		n := arena.New[node](a)
+		if n.value != 0 || n.next != nil {
+			t.Fatalf("New returned non-zero memory after Free: %+v", *n)
+		}
+	}
+	a.Free()
+}
+
+func TestArenaMakeSlice(t *testing.T) {
+	a := arena.NewArena()
+	defer a.Free()
+
+	for _, size := range []int{0, 1, 10, 100, 10000} {
+		s := arena.MakeSlice[uint64](a, size/2, size)
+		if len(s) != size/2 || cap(s) != size {
+			t.Errorf("MakeSlice(%d, %d) returned len %d, cap %d", size/2, size, len(s), cap(s))
+		}
+		s = s[:size]
+		for i := range s {
+			if s[i] != 0 {
+				t.Fatalf("MakeSlice returned non-zero memory")
+			}
+			s[i] = uint64(i)
+		}
+	}
+}
+
+func TestArenaClone(t *testing.T) {
+	a := arena.NewArena()
+	n := arena.New[node](a)
+	n.value = 5
+	s := arena.MakeSlice[byte](a, 3, 3)
+	copy(s, "abc")
+
+	n2 := arena.Clone(n)
+	s2 := arena.Clone(s)
+	a.Free()
+
+	if n2 == n || n2.value != 5 {
+		t.Errorf("Clone of pointer returned %p (%+v), original %p", n2, *n2, n)
+	}
+	if string(s2) != "abc" {
+		t.Errorf("Clone of slice returned %q", s2)
+	}
+}
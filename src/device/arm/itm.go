@@ -0,0 +1,102 @@
+// Hand created file. DO NOT DELETE.
+// Cortex-M Instrumentation Trace Macrocell (ITM), Data Watchpoint and Trace
+// (DWT) unit and Trace Port Interface Unit (TPIU) definitions. These are only available on ARMv7-M and ARMv8-M
+// Mainline (Cortex-M3/M33/M4/M7).
+
+//go:build cortexm
+
+package arm
+
+import (
+	"runtime/volatile"
+	"unsafe"
+)
+
+const (
+	ITM_BASE   = 0xE0000000
+	DWT_BASE   = 0xE0001000
+	DEMCR_ADDR = 0xE000EDFC
+	TPIU_BASE  = 0xE0040000
+)
+
+// Instrumentation Trace Macrocell (ITM)
+//
+// Source: https://developer.arm.com/documentation/ddi0403/e/ Appendix D4.2
+type ITM_Type struct {
+	STIM [32]volatile.Register32 // 0x000: Stimulus Port Registers
+	_    [864]uint32             // reserved
+	TER  volatile.Register32     // 0xE00: Trace Enable Register
+	_    [15]uint32              // reserved
+	TPR  volatile.Register32     // 0xE40: Trace Privilege Register
+	_    [15]uint32              // reserved
+	TCR  volatile.Register32     // 0xE80: Trace Control Register
+	_    [75]uint32              // reserved
+	LAR  volatile.Register32     // 0xFB0: Lock Access Register
+	LSR  volatile.Register32     // 0xFB4: Lock Status Register
+	_    [6]uint32               // reserved
+	PID  [8]volatile.Register32  // 0xFD0: Peripheral Identification Registers
+	CID  [4]volatile.Register32  // 0xFF0: Component Identification Registers
+}
+
+var ITM = (*ITM_Type)(unsafe.Pointer(uintptr(ITM_BASE)))
+
+// Data Watchpoint and Trace unit (DWT), only the profiling counters.
+//
+// Source: https://developer.arm.com/documentation/ddi0403/e/ Appendix C1.8
+type DWT_Type struct {
+	CTRL     volatile.Register32 // 0x000: Control Register
+	CYCCNT   volatile.Register32 // 0x004: Cycle Count Register
+	CPICNT   volatile.Register32 // 0x008: CPI Count Register
+	EXCCNT   volatile.Register32 // 0x00C: Exception Overhead Count Register
+	SLEEPCNT volatile.Register32 // 0x010: Sleep Count Register
+	LSUCNT   volatile.Register32 // 0x014: LSU Count Register
+	FOLDCNT  volatile.Register32 // 0x018: Folded-instruction Count Register
+	PCSR     volatile.Register32 // 0x01C: Program Counter Sample Register
+}
+
+var DWT = (*DWT_Type)(unsafe.Pointer(uintptr(DWT_BASE)))
+
+// Trace Port Interface Unit (TPIU)
+//
+// Source: https://developer.arm.com/documentation/ddi0403/e/ Appendix C1.10
+type TPIU_Type struct {
+	SSPSR volatile.Register32 // 0x000: Supported Parallel Port Sizes Register
+	CSPSR volatile.Register32 // 0x004: Current Parallel Port Size Register
+	_     [2]uint32           // reserved
+	ACPR  volatile.Register32 // 0x010: Asynchronous Clock Prescaler Register
+	_     [55]uint32          // reserved
+	SPPR  volatile.Register32 // 0x0F0: Selected Pin Protocol Register
+	_     [131]uint32         // reserved
+	FFSR  volatile.Register32 // 0x300: Formatter and Flush Status Register
+	FFCR  volatile.Register32 // 0x304: Formatter and Flush Control Register
+}
+
+var TPIU = (*TPIU_Type)(unsafe.Pointer(uintptr(TPIU_BASE)))
+
+// Debug Exception and Monitor Control Register (DEMCR)
+var DEMCR = (*volatile.Register32)(unsafe.Pointer(uintptr(DEMCR_ADDR)))
+
+const (
+	// DEMCR: Debug Exception and Monitor Control Register
+	DEMCR_TRCENA = 0x1000000 // Bit TRCENA: enable DWT, ITM, ETM and TPIU.
+
+	// DWT.CTRL: Control Register
+	DWT_CTRL_CYCCNTENA = 0x1       // Bit CYCCNTENA: enable the cycle counter.
+	DWT_CTRL_NOCYCCNT  = 0x2000000 // Bit NOCYCCNT: the cycle counter is not implemented.
+
+	// ITM.TCR: Trace Control Register
+	ITM_TCR_ITMENA          = 0x1      // Bit ITMENA: enable the ITM.
+	ITM_TCR_TSENA           = 0x2      // Bit TSENA: enable local timestamps.
+	ITM_TCR_SYNCENA         = 0x4      // Bit SYNCENA: enable synchronization packets.
+	ITM_TCR_TXENA           = 0x8      // Bit TXENA: forward DWT packets to the ITM.
+	ITM_TCR_SWOENA          = 0x10     // Bit SWOENA: use the SWO clock for timestamps.
+	ITM_TCR_TraceBusID_Pos  = 0x10     // Position of TraceBusID field.
+	ITM_TCR_TraceBusID_Msk  = 0x7f0000 // Bit mask of TraceBusID field.
+	ITM_TCR_BUSY            = 0x800000 // Bit BUSY.
+	ITM_LAR_KEY             = 0xC5ACCE55
+	ITM_STIM_FIFOREADY      = 0x1 // Bit FIFOREADY: the stimulus port can accept data.
+	TPIU_SPPR_TXMODE_PARALL = 0x0 // Parallel trace port.
+	TPIU_SPPR_TXMODE_MANCH  = 0x1 // Asynchronous SWO, using Manchester encoding.
+	TPIU_SPPR_TXMODE_NRZ    = 0x2 // Asynchronous SWO, using NRZ (UART) encoding.
+	TPIU_FFCR_TrigIn        = 0x100
+)
@@ -0,0 +1,57 @@
+// Hand created file. DO NOT DELETE.
+// Cortex-M Memory Protection Unit (MPU) definitions for the PMSAv7 MPU, as
+// found on ARMv7-M (Cortex-M3/M4/M7). The ARMv6-M MPU (Cortex-M0+) uses the
+// same registers but only supports regions of at least 256 bytes. The ARMv8-M
+// MPU (Cortex-M23/M33) has a different register layout.
+
+//go:build cortexm
+
+package arm
+
+import (
+	"runtime/volatile"
+	"unsafe"
+)
+
+const MPU_BASE = SCS_BASE + 0x0D90
+
+// Memory Protection Unit (MPU)
+//
+// Source: https://developer.arm.com/documentation/ddi0403/e/ Section B3.5
+type MPU_Type struct {
+	TYPE volatile.Register32 // 0xD90: MPU Type Register
+	CTRL volatile.Register32 // 0xD94: MPU Control Register
+	RNR  volatile.Register32 // 0xD98: MPU Region Number Register
+	RBAR volatile.Register32 // 0xD9C: MPU Region Base Address Register
+	RASR volatile.Register32 // 0xDA0: MPU Region Attribute and Size Register
+}
+
+var MPU = (*MPU_Type)(unsafe.Pointer(uintptr(MPU_BASE)))
+
+const (
+	// TYPE: MPU Type Register
+	MPU_TYPE_DREGION_Pos = 0x8    // Position of DREGION field.
+	MPU_TYPE_DREGION_Msk = 0xff00 // Bit mask of DREGION field.
+
+	// CTRL: MPU Control Register
+	MPU_CTRL_ENABLE     = 0x1 // Enable the MPU.
+	MPU_CTRL_HFNMIENA   = 0x2 // Enable the MPU during HardFault and NMI handlers.
+	MPU_CTRL_PRIVDEFENA = 0x4 // Use the default memory map as a background region for privileged accesses.
+
+	// RBAR: MPU Region Base Address Register
+	MPU_RBAR_REGION_Msk = 0xf  // Bit mask of REGION field.
+	MPU_RBAR_VALID      = 0x10 // Use the REGION field instead of RNR.
+
+	// RASR: MPU Region Attribute and Size Register
+	MPU_RASR_ENABLE   = 0x1        // Enable the region.
+	MPU_RASR_SIZE_Pos = 0x1        // Position of SIZE field: the region size is 2^(SIZE+1) bytes.
+	MPU_RASR_SIZE_Msk = 0x3e       // Bit mask of SIZE field.
+	MPU_RASR_SRD_Pos  = 0x8        // Position of SRD (subregion disable) field.
+	MPU_RASR_SRD_Msk  = 0xff00     // Bit mask of SRD field.
+	MPU_RASR_AP_Pos   = 0x18       // Position of AP (access permission) field.
+	MPU_RASR_AP_Msk   = 0x7000000  // Bit mask of AP field.
+	MPU_RASR_AP_NONE  = 0x0        // No access.
+	MPU_RASR_AP_RW    = 0x3        // Full access.
+	MPU_RASR_AP_RO    = 0x6        // Read-only access.
+	MPU_RASR_XN       = 0x10000000 // Instruction fetches are not allowed.
+)
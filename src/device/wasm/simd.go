@@ -0,0 +1,197 @@
+// Package wasm provides access to WebAssembly instructions that are not
+// otherwise reachable from Go. At the moment, this is the 128-bit SIMD
+// instruction set (the v128 type and its operations).
+//
+// The operations are implemented as compiler builtins. When the simd128 feature
+// is enabled (with -llvm-features=+simd128), each of them is usually a single
+// instruction. Otherwise they are split into scalar operations, so the same
+// code works on every WebAssembly host, just more slowly. The wasm.simd128
+// build tag is set when the feature is enabled, which can be used to select
+// between a SIMD and a plain Go implementation of an algorithm.
+//
+// With the simd128 feature, LLVM can also vectorize simple loops by itself.
+// This only happens with -opt=2, as loops are not vectorized when optimizing
+// for size.
+//
+// Operations are named after the WebAssembly instructions they correspond to,
+// with the lane shape (like I32x4, four 32-bit integer lanes) as a prefix. A
+// suffix of S or U means the lanes are interpreted as signed or unsigned.
+// Comparisons set all bits of the lanes where they are true and clear the
+// others. The lane index of ExtractLane and ReplaceLane is taken modulo the
+// number of lanes, and should be a constant to get a single instruction.
+package wasm
+
+import "unsafe"
+
+// V128 is a 128-bit SIMD value. Its lanes are interpreted according to the
+// operation it is used with, like in WebAssembly itself. The zero value has all
+// bits cleared.
+type V128 struct {
+	lo, hi uint64
+}
+
+// Load reads a V128 from the 16 bytes at p, which don't need to be aligned.
+func Load(p unsafe.Pointer) V128
+
+// Store writes v to the 16 bytes at p, which don't need to be aligned.
+func Store(p unsafe.Pointer, v V128)
+
+// LoadBytes reads a V128 from the first 16 bytes of b. It panics if b is
+// shorter than that.
+func LoadBytes(b []byte) V128 {
+	_ = b[15] // bounds check
+	return Load(unsafe.Pointer(&b[0]))
+}
+
+// StoreBytes writes v to the first 16 bytes of b. It panics if b is shorter
+// than that.
+func StoreBytes(b []byte, v V128) {
+	_ = b[15] // bounds check
+	Store(unsafe.Pointer(&b[0]), v)
+}
+
+// Bitwise operations, on all 128 bits.
+
+func And(a, b V128) V128          // a & b
+func Or(a, b V128) V128           // a | b
+func Xor(a, b V128) V128          // a ^ b
+func AndNot(a, b V128) V128       // a &^ b
+func Not(v V128) V128             // ^v
+func Bitselect(a, b, c V128) V128 // bits of a where c is set, bits of b elsewhere
+func AnyTrue(v V128) bool         // whether any bit is set
+
+// Operations on 16 lanes of 8 bits.
+
+func I8x16Splat(x int8) V128
+func I8x16ExtractLane(v V128, i int) int8
+func I8x16ReplaceLane(v V128, i int, x int8) V128
+func I8x16Add(a, b V128) V128
+func I8x16Sub(a, b V128) V128
+func I8x16Neg(v V128) V128
+func I8x16AddSatS(a, b V128) V128
+func I8x16AddSatU(a, b V128) V128
+func I8x16SubSatS(a, b V128) V128
+func I8x16SubSatU(a, b V128) V128
+func I8x16MinS(a, b V128) V128
+func I8x16MinU(a, b V128) V128
+func I8x16MaxS(a, b V128) V128
+func I8x16MaxU(a, b V128) V128
+func I8x16Eq(a, b V128) V128
+func I8x16Ne(a, b V128) V128
+func I8x16LtS(a, b V128) V128
+func I8x16LtU(a, b V128) V128
+func I8x16GtS(a, b V128) V128
+func I8x16GtU(a, b V128) V128
+func I8x16AllTrue(v V128) bool
+
+// Operations on 8 lanes of 16 bits.
+
+func I16x8Splat(x int16) V128
+func I16x8ExtractLane(v V128, i int) int16
+func I16x8ReplaceLane(v V128, i int, x int16) V128
+func I16x8Add(a, b V128) V128
+func I16x8Sub(a, b V128) V128
+func I16x8Mul(a, b V128) V128
+func I16x8Neg(v V128) V128
+func I16x8AddSatS(a, b V128) V128
+func I16x8AddSatU(a, b V128) V128
+func I16x8SubSatS(a, b V128) V128
+func I16x8SubSatU(a, b V128) V128
+func I16x8MinS(a, b V128) V128
+func I16x8MinU(a, b V128) V128
+func I16x8MaxS(a, b V128) V128
+func I16x8MaxU(a, b V128) V128
+func I16x8Shl(v V128, n uint32) V128
+func I16x8ShrS(v V128, n uint32) V128
+func I16x8ShrU(v V128, n uint32) V128
+func I16x8Eq(a, b V128) V128
+func I16x8Ne(a, b V128) V128
+func I16x8LtS(a, b V128) V128
+func I16x8LtU(a, b V128) V128
+func I16x8GtS(a, b V128) V128
+func I16x8GtU(a, b V128) V128
+func I16x8AllTrue(v V128) bool
+
+// Operations on 4 lanes of 32 bits.
+
+func I32x4Splat(x int32) V128
+func I32x4ExtractLane(v V128, i int) int32
+func I32x4ReplaceLane(v V128, i int, x int32) V128
+func I32x4Add(a, b V128) V128
+func I32x4Sub(a, b V128) V128
+func I32x4Mul(a, b V128) V128
+func I32x4Neg(v V128) V128
+func I32x4MinS(a, b V128) V128
+func I32x4MinU(a, b V128) V128
+func I32x4MaxS(a, b V128) V128
+func I32x4MaxU(a, b V128) V128
+func I32x4Shl(v V128, n uint32) V128
+func I32x4ShrS(v V128, n uint32) V128
+func I32x4ShrU(v V128, n uint32) V128
+func I32x4Eq(a, b V128) V128
+func I32x4Ne(a, b V128) V128
+func I32x4LtS(a, b V128) V128
+func I32x4LtU(a, b V128) V128
+func I32x4GtS(a, b V128) V128
+func I32x4GtU(a, b V128) V128
+func I32x4AllTrue(v V128) bool
+func I32x4TruncSatF32x4S(v V128) V128 // convert float32 lanes, saturating on overflow
+func I32x4TruncSatF32x4U(v V128) V128 // convert float32 lanes, saturating on overflow
+
+// Operations on 2 lanes of 64 bits.
+
+func I64x2Splat(x int64) V128
+func I64x2ExtractLane(v V128, i int) int64
+func I64x2ReplaceLane(v V128, i int, x int64) V128
+func I64x2Add(a, b V128) V128
+func I64x2Sub(a, b V128) V128
+func I64x2Mul(a, b V128) V128
+func I64x2Neg(v V128) V128
+func I64x2Shl(v V128, n uint32) V128
+func I64x2ShrS(v V128, n uint32) V128
+func I64x2ShrU(v V128, n uint32) V128
+func I64x2Eq(a, b V128) V128
+func I64x2Ne(a, b V128) V128
+func I64x2AllTrue(v V128) bool
+
+// Operations on 4 lanes of float32.
+
+func F32x4Splat(x float32) V128
+func F32x4ExtractLane(v V128, i int) float32
+func F32x4ReplaceLane(v V128, i int, x float32) V128
+func F32x4Add(a, b V128) V128
+func F32x4Sub(a, b V128) V128
+func F32x4Mul(a, b V128) V128
+func F32x4Div(a, b V128) V128
+func F32x4Neg(v V128) V128
+func F32x4Sqrt(v V128) V128
+func F32x4Min(a, b V128) V128 // NaN if either lane is NaN
+func F32x4Max(a, b V128) V128 // NaN if either lane is NaN
+func F32x4Eq(a, b V128) V128
+func F32x4Ne(a, b V128) V128
+func F32x4Lt(a, b V128) V128
+func F32x4Le(a, b V128) V128
+func F32x4Gt(a, b V128) V128
+func F32x4Ge(a, b V128) V128
+func F32x4ConvertI32x4S(v V128) V128 // convert signed int32 lanes
+func F32x4ConvertI32x4U(v V128) V128 // convert unsigned int32 lanes
+
+// Operations on 2 lanes of float64.
+
+func F64x2Splat(x float64) V128
+func F64x2ExtractLane(v V128, i int) float64
+func F64x2ReplaceLane(v V128, i int, x float64) V128
+func F64x2Add(a, b V128) V128
+func F64x2Sub(a, b V128) V128
+func F64x2Mul(a, b V128) V128
+func F64x2Div(a, b V128) V128
+func F64x2Neg(v V128) V128
+func F64x2Sqrt(v V128) V128
+func F64x2Min(a, b V128) V128 // NaN if either lane is NaN
+func F64x2Max(a, b V128) V128 // NaN if either lane is NaN
+func F64x2Eq(a, b V128) V128
+func F64x2Ne(a, b V128) V128
+func F64x2Lt(a, b V128) V128
+func F64x2Le(a, b V128) V128
+func F64x2Gt(a, b V128) V128
+func F64x2Ge(a, b V128) V128
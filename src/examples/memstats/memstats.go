@@ -17,6 +17,7 @@ func main() {
 		runtime.GC()
 		runtime.ReadMemStats(&ms)
 		println("Heap after  GC. Used: ", ms.HeapInuse, " Free: ", ms.HeapIdle, " Meta: ", ms.GCSys)
+		println("Objects: ", ms.HeapObjects, " GC cycles: ", ms.NumGC, " GC time (ns): ", ms.PauseTotalNs)
 		time.Sleep(5 * time.Second)
 	}
 
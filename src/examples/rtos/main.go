@@ -0,0 +1,34 @@
+// This example runs as a task in existing RTOS firmware. Build it with:
+//
+//	tinygo build -o rtos.a -target=cortex-m4 -buildmode=c-archive examples/rtos
+//
+// The firmware creates a queue of int32 values named sensor_queue, fills it
+// from C code, and starts the Go code by calling tinygo_rtos_main from an RTOS
+// task (see src/runtime/rtos/port).
+package main
+
+import (
+	"runtime/rtos"
+	"time"
+	"unsafe"
+)
+
+//go:extern sensor_queue
+var sensorQueue unsafe.Pointer
+
+func main() {
+	// Goroutines run concurrently inside the RTOS task.
+	go func() {
+		for {
+			println("still alive")
+			time.Sleep(time.Second)
+		}
+	}()
+
+	queue := rtos.NewQueue(sensorQueue)
+	for {
+		var value int32
+		queue.Receive(unsafe.Pointer(&value))
+		println("received:", value)
+	}
+}
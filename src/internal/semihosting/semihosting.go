@@ -0,0 +1,151 @@
+// Package semihosting implements the semihosting interface, through which a
+// program running under a debugger (like OpenOCD) or an emulator (like QEMU)
+// can use the files, command line and exit code of the host.
+//
+// The interface is defined by ARM, and RISC-V uses the same operations with a
+// different trap instruction:
+// https://github.com/ARM-software/abi-aa/blob/main/semihosting/semihosting.rst
+// https://github.com/riscv-non-isa/riscv-semihosting/blob/main/riscv-semihosting.adoc
+//
+// Semihosting calls stop the processor when no debugger is attached, so the
+// runtime and the os package only use it with the semihosting build tag.
+package semihosting
+
+import (
+	"unsafe"
+)
+
+// Semihosting operations.
+const (
+	sysOpen         = 0x01
+	sysClose        = 0x02
+	sysWrite        = 0x05
+	sysRead         = 0x06
+	sysSeek         = 0x0A
+	sysFileLen      = 0x0C
+	sysRemove       = 0x0E
+	sysRename       = 0x0F
+	sysErrno        = 0x13
+	sysGetCmdline   = 0x15
+	sysExitExtended = 0x20
+)
+
+// File modes for Open. They are the binary variants of the fopen modes.
+const (
+	ModeRead            = 1  // "rb"
+	ModeReadWrite       = 3  // "r+b"
+	ModeWrite           = 5  // "wb"
+	ModeWriteRead       = 7  // "w+b"
+	ModeAppend          = 9  // "ab"
+	ModeAppendReadWrite = 11 // "a+b"
+)
+
+// Implemented in assembly (src/device/arm/cortexm.S for ARM and
+// src/device/riscv/semihosting.S for RISC-V).
+//
+//go:linkname call SemihostingCall
+func call(op int, arg uintptr) int
+
+// cstring returns the string as a NUL-terminated byte slice.
+func cstring(s string) []byte {
+	buf := make([]byte, len(s)+1)
+	copy(buf, s)
+	return buf
+}
+
+// Open opens a file on the host with one of the Mode* constants, and returns
+// the file handle or -1 on failure.
+func Open(path string, mode int) int {
+	name := cstring(path)
+	args := [3]uintptr{uintptr(unsafe.Pointer(&name[0])), uintptr(mode), uintptr(len(path))}
+	return call(sysOpen, uintptr(unsafe.Pointer(&args)))
+}
+
+// Close closes the given file handle. It returns 0 on success and -1 on
+// failure.
+func Close(fd int) int {
+	args := [1]uintptr{uintptr(fd)}
+	return call(sysClose, uintptr(unsafe.Pointer(&args)))
+}
+
+// Write writes the buffer to the file and returns the number of bytes that were
+// not written (zero on success).
+func Write(fd int, buf []byte) int {
+	if len(buf) == 0 {
+		return 0
+	}
+	args := [3]uintptr{uintptr(fd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf))}
+	return call(sysWrite, uintptr(unsafe.Pointer(&args)))
+}
+
+// Read reads from the file into the buffer and returns the number of bytes
+// that were not read. If it is len(buf), the end of the file was reached.
+// A negative value indicates an error.
+func Read(fd int, buf []byte) int {
+	if len(buf) == 0 {
+		return 0
+	}
+	args := [3]uintptr{uintptr(fd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf))}
+	return call(sysRead, uintptr(unsafe.Pointer(&args)))
+}
+
+// Seek moves the file position to the given offset from the start of the file.
+// It returns 0 on success and a negative value on failure.
+func Seek(fd int, offset int) int {
+	args := [2]uintptr{uintptr(fd), uintptr(offset)}
+	return call(sysSeek, uintptr(unsafe.Pointer(&args)))
+}
+
+// FileLen returns the length of the file, or -1 on failure.
+func FileLen(fd int) int {
+	args := [1]uintptr{uintptr(fd)}
+	return call(sysFileLen, uintptr(unsafe.Pointer(&args)))
+}
+
+// Remove removes the file from the host. It returns 0 on success.
+func Remove(path string) int {
+	name := cstring(path)
+	args := [2]uintptr{uintptr(unsafe.Pointer(&name[0])), uintptr(len(path))}
+	return call(sysRemove, uintptr(unsafe.Pointer(&args)))
+}
+
+// Rename renames a file on the host. It returns 0 on success.
+func Rename(oldpath, newpath string) int {
+	oldname := cstring(oldpath)
+	newname := cstring(newpath)
+	args := [4]uintptr{
+		uintptr(unsafe.Pointer(&oldname[0])), uintptr(len(oldpath)),
+		uintptr(unsafe.Pointer(&newname[0])), uintptr(len(newpath)),
+	}
+	return call(sysRename, uintptr(unsafe.Pointer(&args)))
+}
+
+// Errno returns the errno value of the host after the last failed call.
+func Errno() int {
+	return call(sysErrno, 0)
+}
+
+// CommandLine returns the command line that the program was started with,
+// as a single string. It returns the empty string if it isn't available.
+func CommandLine() string {
+	buf := make([]byte, 1024)
+	args := [2]uintptr{uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf))}
+	if call(sysGetCmdline, uintptr(unsafe.Pointer(&args))) != 0 {
+		return ""
+	}
+	// The length is updated to the length of the command line, without the
+	// terminating NUL byte.
+	n := args[1]
+	if n > uintptr(len(buf)) {
+		n = uintptr(len(buf))
+	}
+	return string(buf[:n])
+}
+
+// Exit stops the program and reports the exit code to the host. Unlike the
+// older SYS_EXIT call, this call passes the whole exit code on 32-bit systems.
+func Exit(code int) {
+	// ADP_Stopped_ApplicationExit
+	args := [2]uintptr{0x20026, uintptr(code)}
+	call(sysExitExtended, uintptr(unsafe.Pointer(&args)))
+}
@@ -58,6 +58,14 @@ func (q *Queue) Append(other *Queue) {
 	interrupt.Restore(i)
 }
 
+// Tail returns the last task in the queue, or nil if the queue is empty.
+func (q *Queue) Tail() *Task {
+	i := interrupt.Disable()
+	t := q.tail
+	interrupt.Restore(i)
+	return t
+}
+
 // Empty checks if the queue is empty.
 func (q *Queue) Empty() bool {
 	i := interrupt.Disable()
@@ -24,6 +24,80 @@ type Task struct {
 	// DeferFrame stores a pointer to the (stack allocated) defer frame of the
 	// goroutine that is used for the recover builtin.
 	DeferFrame unsafe.Pointer
+
+	// WaitReason and WaitObject describe what the task is blocked on while it
+	// is paused using Wait. They are only used for goroutine dumps.
+	WaitReason WaitReason
+	WaitObject unsafe.Pointer
+
+	// LockCount is the number of nested runtime.LockOSThread calls, and
+	// LockedCore is the core the task is wired to plus one (or 0 if it isn't
+	// wired to a core). They are only used by the multicore scheduler.
+	LockCount  uint8
+	LockedCore uint8
+}
+
+// WaitReason describes why a task is paused.
+type WaitReason uint8
+
+const (
+	WaitNone WaitReason = iota
+	WaitChanSend
+	WaitChanReceive
+	WaitSelect
+	WaitForever
+	WaitSleep
+	WaitCond
+	WaitMutex
+	WaitRWMutex
+	WaitRWMutexRead
+	WaitSyncCond
+	WaitWaitGroup
+	WaitFinalizer
+)
+
+// String returns a short description of the wait reason, similar to the ones
+// used in goroutine dumps by the standard Go runtime.
+func (r WaitReason) String() string {
+	switch r {
+	case WaitChanSend:
+		return "chan send"
+	case WaitChanReceive:
+		return "chan receive"
+	case WaitSelect:
+		return "select"
+	case WaitForever:
+		return "blocked forever"
+	case WaitSleep:
+		return "sleep"
+	case WaitCond:
+		return "runtime.Cond.Wait"
+	case WaitMutex:
+		return "sync.Mutex.Lock"
+	case WaitRWMutex:
+		return "sync.RWMutex.Lock"
+	case WaitRWMutexRead:
+		return "sync.RWMutex.RLock"
+	case WaitSyncCond:
+		return "sync.Cond.Wait"
+	case WaitWaitGroup:
+		return "sync.WaitGroup.Wait"
+	case WaitFinalizer:
+		return "finalizer wait"
+	default:
+		return "runnable"
+	}
+}
+
+// Wait is like Pause, but also records what the current task is waiting for
+// (such as a channel or mutex) until it is resumed.
+func Wait(reason WaitReason, object unsafe.Pointer) {
+	t := Current()
+	t.WaitReason = reason
+	t.WaitObject = object
+	Pause()
+	t.WaitReason = WaitNone
+	t.WaitObject = nil
 }
 
 // getGoroutineStackSize is a compiler intrinsic that returns the stack size for
@@ -3,6 +3,7 @@
 package task
 
 import (
+	"runtime/interrupt"
 	"unsafe"
 )
 
@@ -11,8 +12,8 @@ import (
 // otherwise Go wouldn't allow the cast to a smaller integer size.
 const stackCanary = uintptr(uint64(0x670c1333b83bf575) & uint64(^uintptr(0)))
 
-//go:linkname runtimePanic runtime.runtimePanic
-func runtimePanic(str string)
+//go:linkname stackOverflow runtime.stackOverflow
+func stackOverflow(entry uintptr)
 
 // state is a structure which holds a reference to the state of the task.
 // When the task is suspended, the stack pointers are saved here.
@@ -28,6 +29,13 @@ type state struct {
 	stackState
 
 	launched bool
+
+	// paused is set by Pause right before the stack is unwound, so that Resume
+	// can tell whether the goroutine paused or exited.
+	paused bool
+
+	// allNext is the next task in the list of all goroutines, see allTasks.
+	allNext *Task
 }
 
 // stackState is the saved state of a stack while unwound.
@@ -47,9 +55,34 @@ type stackState struct {
 func start(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
 	t := &Task{}
 	t.state.initialize(fn, args, stackSize)
+	mask := interrupt.Disable()
+	t.state.allNext = allTasks
+	allTasks = t
+	numTasks++
+	interrupt.Restore(mask)
+	traceGoCreate(t)
 	runqueuePushBack(t)
 }
 
+// allTasks is a linked list of all goroutines that haven't exited yet, newest
+// first.
+var allTasks *Task
+
+// numTasks is the number of goroutines that haven't exited yet.
+var numTasks int
+
+// Count returns the number of goroutines that currently exist.
+func Count() int {
+	return numTasks
+}
+
+// ForEach calls fn for every goroutine that hasn't exited yet, newest first.
+func ForEach(fn func(t *Task)) {
+	for t := allTasks; t != nil; t = t.state.allNext {
+		fn(t)
+	}
+}
+
 //export tinygo_launch
 func (*state) launch()
 
@@ -74,25 +107,23 @@ func (s *state) initialize(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
 //go:linkname runqueuePushBack runtime.runqueuePushBack
 func runqueuePushBack(*Task)
 
-// currentTask is the current running task, or nil if currently in the scheduler.
-var currentTask *Task
-
-// Current returns the current active task.
-func Current() *Task {
-	return currentTask
-}
+//go:linkname traceGoCreate runtime.traceGoCreate
+func traceGoCreate(*Task)
 
 // Pause suspends the current task and returns to the scheduler.
 // This function may only be called when running on a goroutine stack, not when running on the system stack.
 func Pause() {
+	t := Current()
+
 	// This is mildly unsafe but this is also the only place we can do this.
-	if *(*uintptr)(unsafe.Pointer(currentTask.state.asyncifysp)) != stackCanary {
-		runtimePanic("stack overflow")
+	if *(*uintptr)(unsafe.Pointer(t.state.asyncifysp)) != stackCanary {
+		stackOverflow(t.state.entry)
 	}
 
-	currentTask.state.unwind()
+	t.state.paused = true
+	t.state.unwind()
 
-	*(*uintptr)(unsafe.Pointer(currentTask.state.asyncifysp)) = stackCanary
+	*(*uintptr)(unsafe.Pointer(t.state.asyncifysp)) = stackCanary
 }
 
 //export tinygo_unwind
@@ -102,20 +133,35 @@ func (*stackState) unwind()
 // This may only be called from the scheduler.
 func (t *Task) Resume() {
 	// The current task must be saved and restored because this can nest on WASM with JS.
-	prevTask := currentTask
+	prevTask := Current()
 	t.gcData.swap()
-	currentTask = t
+	setCurrent(t)
+	t.state.paused = false
 	if !t.state.launched {
 		t.state.launch()
 		t.state.launched = true
 	} else {
 		t.state.rewind()
 	}
-	currentTask = prevTask
+	if !t.state.paused {
+		// The goroutine returned without pausing, so it has exited.
+		mask := interrupt.Disable()
+		for l := &allTasks; *l != nil; l = &(*l).state.allNext {
+			if *l == t {
+				*l = t.state.allNext
+				break
+			}
+		}
+		numTasks--
+		interrupt.Restore(mask)
+	}
 	t.gcData.swap()
 	if t.state.asyncifysp > t.state.csp {
-		runtimePanic("stack overflow")
+		stackOverflow(t.state.entry)
 	}
+	// With -scheduler=cores, another thread may resume t as soon as it is no
+	// longer the current task here, so this must be done last.
+	setCurrent(prevTask)
 }
 
 //export tinygo_rewind
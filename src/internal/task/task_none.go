@@ -31,6 +31,22 @@ func (t *Task) Resume() {
 	runtimePanic("scheduler is disabled")
 }
 
+// StackBase returns nil, as there are no goroutine stacks.
+func (t *Task) StackBase() unsafe.Pointer {
+	return nil
+}
+
+// Count returns the number of goroutines, which is always 1 (the main
+// goroutine).
+func Count() int {
+	return 1
+}
+
+// ForEach calls fn for the main goroutine, which is the only goroutine.
+func ForEach(fn func(t *Task)) {
+	fn(&mainTask)
+}
+
 // OnSystemStack returns whether the caller is running on the system stack.
 func OnSystemStack() bool {
 	// This scheduler does not do any stack switching.
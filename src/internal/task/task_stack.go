@@ -10,11 +10,19 @@ import (
 //go:linkname runtimePanic runtime.runtimePanic
 func runtimePanic(str string)
 
+//go:linkname stackOverflow runtime.stackOverflow
+func stackOverflow(entry uintptr)
+
 // Stack canary, to detect a stack overflow. The number is a random number
 // generated by random.org. The bit fiddling dance is necessary because
 // otherwise Go wouldn't allow the cast to a smaller integer size.
 const stackCanary = uintptr(uint64(0x670c1333b83bf575) & uint64(^uintptr(0)))
 
+// Number of words at the bottom of the stack that are filled with stackCanary.
+// A large stack frame may skip over a single word without writing to it, so
+// several words are used.
+const stackCanaryWords = 4
+
 // state is a structure which holds a reference to the state of the task.
 // When the task is suspended, the registers are stored onto the stack and the stack pointer is stored into sp.
 type state struct {
@@ -27,46 +35,85 @@ type state struct {
 
 	// canaryPtr points to the top word of the stack (the lowest address).
 	// This is used to detect stack overflows.
-	// When initializing the goroutine, the stackCanary constant is stored there.
-	// If the stack overflowed, the word will likely no longer equal stackCanary.
+	// When initializing the goroutine, the stackCanary constant is stored in
+	// the first stackCanaryWords words there. If the stack overflowed, they
+	// will likely no longer all equal stackCanary.
 	canaryPtr *uintptr
-}
 
-// currentTask is the current running task, or nil if currently in the scheduler.
-var currentTask *Task
+	// entry is the function the goroutine was started with, to be able to
+	// report which goroutine overflowed its stack.
+	entry uintptr
 
-// Current returns the current active task.
-func Current() *Task {
-	return currentTask
+	// allNext is the next task in the list of all goroutines, see allTasks.
+	allNext *Task
 }
 
+// allTasks is a linked list of all goroutines that haven't exited yet, newest
+// first. It isn't used by the scheduler but allows debuggers (see
+// src/runtime/runtime-gdb.py) and goroutine dumps to find all goroutines,
+// including the ones that are blocked and thus not in any scheduler queue.
+var allTasks *Task
+
+// numTasks is the number of goroutines that haven't exited yet.
+var numTasks int
+
 // Pause suspends the current task and returns to the scheduler.
 // This function may only be called when running on a goroutine stack, not when running on the system stack or in an interrupt.
 func Pause() {
-	// Check whether the canary (the lowest address of the stack) is still
-	// valid. If it is not, a stack overflow has occured.
-	if *currentTask.state.canaryPtr != stackCanary {
-		runtimePanic("goroutine stack overflow")
-	}
+	t := Current()
+	t.state.checkCanary()
 	if interrupt.In() {
 		runtimePanic("blocked inside interrupt")
 	}
-	currentTask.state.pause()
+	t.state.pause()
+}
+
+// CheckStack panics if the current goroutine has overflowed its stack. It is
+// cheap enough to be called often, for example at safepoints.
+func CheckStack() {
+	if t := Current(); t != nil {
+		t.state.checkCanary()
+	}
 }
 
+// checkCanary checks whether the canary (at the lowest address of the stack) is
+// still intact. If it is not, a stack overflow has occured.
+func (s *state) checkCanary() {
+	canary := (*[stackCanaryWords]uintptr)(unsafe.Pointer(s.canaryPtr))
+	for _, word := range canary {
+		if word != stackCanary {
+			stackOverflow(s.entry)
+		}
+	}
+}
+
+// pause is called by tinygo_startTask when the goroutine exits.
+//
 //export tinygo_pause
 func pause() {
+	// Remove the goroutine from the list of all goroutines. This is a linear
+	// search, but there are usually only a few goroutines.
+	current := Current()
+	mask := interrupt.Disable()
+	for t := &allTasks; *t != nil; t = &(*t).state.allNext {
+		if *t == current {
+			*t = current.state.allNext
+			break
+		}
+	}
+	numTasks--
+	interrupt.Restore(mask)
 	Pause()
 }
 
 // Resume the task until it pauses or completes.
 // This may only be called from the scheduler.
 func (t *Task) Resume() {
-	currentTask = t
+	setCurrent(t)
 	t.gcData.swap()
 	t.state.resume()
 	t.gcData.swap()
-	currentTask = nil
+	setCurrent(nil)
 }
 
 // initialize the state and prepare to call the specified function with the specified argument bundle.
@@ -79,7 +126,11 @@ func (s *state) initialize(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
 	// points to the first word of the stack. If it has changed between now and
 	// the next stack switch, there was a stack overflow.
 	s.canaryPtr = (*uintptr)(stack)
-	*s.canaryPtr = stackCanary
+	canary := (*[stackCanaryWords]uintptr)(stack)
+	for i := range canary {
+		canary[i] = stackCanary
+	}
+	s.entry = fn
 
 	// Get a pointer to the top of the stack, where the initial register values
 	// are stored. They will be popped off the stack on the first stack switch
@@ -104,6 +155,9 @@ var startTask [0]uint8
 //go:linkname runqueuePushBack runtime.runqueuePushBack
 func runqueuePushBack(*Task)
 
+//go:linkname traceGoCreate runtime.traceGoCreate
+func traceGoCreate(*Task)
+
 //go:linkname runtime_alloc runtime.alloc
 func runtime_alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer
 
@@ -112,9 +166,44 @@ func runtime_alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer
 func start(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
 	t := &Task{}
 	t.state.initialize(fn, args, stackSize)
+	mask := interrupt.Disable()
+	t.state.allNext = allTasks
+	allTasks = t
+	numTasks++
+	interrupt.Restore(mask)
+	traceGoCreate(t)
 	runqueuePushBack(t)
 }
 
+// Count returns the number of goroutines that currently exist.
+func Count() int {
+	return numTasks
+}
+
+// ForEach calls fn for every goroutine that hasn't exited yet, newest first.
+func ForEach(fn func(t *Task)) {
+	for t := allTasks; t != nil; t = t.state.allNext {
+		fn(t)
+	}
+}
+
+// StackBase returns the lowest address of the goroutine stack. This is also
+// the start of the heap allocation that holds the stack.
+func (t *Task) StackBase() unsafe.Pointer {
+	return unsafe.Pointer(t.state.canaryPtr)
+}
+
+// Entry returns the function the goroutine was started with.
+func (t *Task) Entry() uintptr {
+	return t.state.entry
+}
+
+// StackGuard returns the lowest address of the goroutine stack that may be
+// used, after the stack canary.
+func (t *Task) StackGuard() uintptr {
+	return uintptr(unsafe.Pointer(t.state.canaryPtr)) + stackCanaryWords*unsafe.Sizeof(uintptr(0))
+}
+
 // OnSystemStack returns whether the caller is running on the system stack.
 func OnSystemStack() bool {
 	// If there is not an active goroutine, then this must be running on the system stack.
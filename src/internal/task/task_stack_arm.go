@@ -1,7 +1,12 @@
-//go:build scheduler.tasks && arm && !cortexm && !avr && !xtensa && !tinygo.riscv
+//go:build scheduler.tasks && arm && (!cortexm || tinygo.rtos) && !avr && !xtensa && !tinygo.riscv
 
 package task
 
+// This implementation is also used on Cortex-M when running as a task under an
+// RTOS (see task_stack_cortexm.go for the usual implementation). The RTOS
+// decides which stack pointer (MSP or PSP) is in use, so this code must not
+// switch between them.
+
 import "unsafe"
 
 var systemStack uintptr
@@ -0,0 +1,15 @@
+//go:build scheduler.cores
+
+package task
+
+// With -scheduler=cores, every core (or thread, on WebAssembly) runs its own
+// scheduler and so has its own current task. The runtime keeps track of them, see
+// src/runtime/scheduler_cores.go.
+
+// Current returns the current active task on this core.
+//
+//go:linkname Current runtime.currentTask
+func Current() *Task
+
+//go:linkname setCurrent runtime.setCurrentTask
+func setCurrent(t *Task)
@@ -1,4 +1,4 @@
-//go:build scheduler.tasks && cortexm
+//go:build scheduler.tasks && cortexm && !tinygo.rtos
 #include <stdint.h>
 
 uintptr_t SystemStack() {
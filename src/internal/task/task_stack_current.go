@@ -0,0 +1,15 @@
+//go:build scheduler.tasks && !scheduler.cores
+
+package task
+
+// currentTask is the current running task, or nil if currently in the scheduler.
+var currentTask *Task
+
+// Current returns the current active task.
+func Current() *Task {
+	return currentTask
+}
+
+func setCurrent(t *Task) {
+	currentTask = t
+}
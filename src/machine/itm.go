@@ -0,0 +1,101 @@
+//go:build cortexm
+
+package machine
+
+import (
+	"device/arm"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// ITM is a serial port that writes to a stimulus port of the Instrumentation
+// Trace Macrocell, which is sent out over the SWO pin of the debug port.
+// Every write is timestamped, and the SWO pin can run at several megabits per
+// second, so it can also be used for tracing. The ITM is only available on
+// Cortex-M3 and higher.
+//
+// Reading is not supported: SWO is output only.
+type ITM struct {
+	// Port is the stimulus port (0-31) that is written to.
+	Port uint8
+
+	// TraceClock is the frequency of the trace clock (TRACECLKIN) in Hz,
+	// which is usually the CPU frequency. It is only needed when the SWO
+	// speed is set in Configure.
+	TraceClock uint32
+}
+
+// DefaultITM is the ITM using stimulus port 0, which is the port most tools
+// show by default.
+var DefaultITM = &ITM{}
+
+// Configure enables the ITM and the stimulus port. If config.BaudRate is set
+// (and TraceClock is known), it also configures the SWO pin to send NRZ
+// (UART) encoded data at that speed. Otherwise, the SWO speed is left to the
+// debugger, which usually configures it when starting to capture SWO output.
+//
+// Note that on some chips (for example STM32), the SWO pin must also be
+// enabled in a chip specific debug register, which is usually done by the
+// debugger as well.
+func (itm *ITM) Configure(config UARTConfig) error {
+	// Enable the trace subsystem.
+	arm.DEMCR.SetBits(arm.DEMCR_TRCENA)
+
+	if config.BaudRate != 0 && itm.TraceClock != 0 {
+		arm.TPIU.CSPSR.Set(1) // port size of 1 bit
+		arm.TPIU.SPPR.Set(arm.TPIU_SPPR_TXMODE_NRZ)
+		arm.TPIU.ACPR.Set(itm.TraceClock/config.BaudRate - 1)
+		// Disable the formatter, so that ITM packets are sent directly.
+		arm.TPIU.FFCR.Set(arm.TPIU_FFCR_TrigIn)
+	}
+
+	arm.ITM.LAR.Set(arm.ITM_LAR_KEY)
+	arm.ITM.TCR.Set(1<<arm.ITM_TCR_TraceBusID_Pos | arm.ITM_TCR_SYNCENA | arm.ITM_TCR_TSENA | arm.ITM_TCR_ITMENA)
+	arm.ITM.TER.SetBits(1 << (itm.Port & 31))
+	return nil
+}
+
+// WriteByte writes a single byte to the stimulus port. It waits until the
+// stimulus port can accept data, which is usually very fast. If the ITM or
+// the stimulus port is disabled (for example because no debugger is
+// capturing SWO output), the byte is dropped.
+func (itm *ITM) WriteByte(c byte) error {
+	port := itm.Port & 31
+	if !arm.ITM.TCR.HasBits(arm.ITM_TCR_ITMENA) || !arm.ITM.TER.HasBits(1<<port) {
+		return nil
+	}
+	stim := &arm.ITM.STIM[port]
+	for !stim.HasBits(arm.ITM_STIM_FIFOREADY) {
+	}
+	// Do an 8-bit write, so that a single byte is sent.
+	volatile.StoreUint8((*uint8)(unsafe.Pointer(&stim.Reg)), c)
+	return nil
+}
+
+// Write writes the data to the stimulus port.
+func (itm *ITM) Write(data []byte) (n int, err error) {
+	for _, c := range data {
+		itm.WriteByte(c)
+	}
+	return len(data), nil
+}
+
+// ReadByte always returns an error: SWO is output only.
+func (itm *ITM) ReadByte() (byte, error) {
+	return 0, errNoByte
+}
+
+// Buffered always returns 0: SWO is output only.
+func (itm *ITM) Buffered() int {
+	return 0
+}
+
+// DTR always returns true.
+func (itm *ITM) DTR() bool {
+	return true
+}
+
+// RTS always returns true.
+func (itm *ITM) RTS() bool {
+	return true
+}
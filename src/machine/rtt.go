@@ -0,0 +1,154 @@
+//go:build baremetal
+
+package machine
+
+import (
+	"runtime/volatile"
+	"unsafe"
+)
+
+// Sizes of the RTT buffers. The up buffer (from the chip to the debugger) is
+// the one used for output, so it is a lot bigger than the down buffer.
+const (
+	rttUpBufferSize   = 1024
+	rttDownBufferSize = 16
+)
+
+// Operating mode of an RTT buffer: when the buffer is full, write as much as
+// fits and drop the rest. Writes never wait for the debugger.
+const rttModeNoBlockTrim = 1
+
+// rttBuffer is a ring buffer descriptor in the RTT control block, as defined
+// by SEGGER_RTT.h.
+type rttBuffer struct {
+	name        unsafe.Pointer
+	buffer      unsafe.Pointer
+	size        uint32
+	writeOffset volatile.Register32
+	readOffset  volatile.Register32
+	flags       uint32
+}
+
+// rttControlBlock is the data structure the debugger looks for in RAM, by
+// searching for the ID string.
+type rttControlBlock struct {
+	id             [16]byte
+	maxUpBuffers   int32
+	maxDownBuffers int32
+	up             [1]rttBuffer
+	down           [1]rttBuffer
+}
+
+// RTT is a serial port implemented using SEGGER RTT (Real Time Transfer).
+// Data is exchanged through ring buffers in RAM that are read and written by a
+// debug probe (J-Link, or OpenOCD and probe-rs with other probes) while the
+// chip is running, so it doesn't need any pins besides the debug port.
+//
+// Writes only copy data to RAM, so they are fast and don't use interrupts. If
+// no debugger reads the data, output is dropped once the buffer is full.
+type RTT struct {
+	controlBlock rttControlBlock
+	upBuffer     [rttUpBufferSize]byte
+	downBuffer   [rttDownBufferSize]byte
+}
+
+// DefaultRTT is the RTT control block of this program, with one up and one
+// down buffer (both called "Terminal").
+var DefaultRTT = &RTT{}
+
+var rttTerminalName = [...]byte{'T', 'e', 'r', 'm', 'i', 'n', 'a', 'l', 0}
+
+// Configure initializes the RTT control block. The config is ignored.
+func (rtt *RTT) Configure(config UARTConfig) error {
+	cb := &rtt.controlBlock
+	if cb.id[0] != 0 {
+		// Already configured.
+		return nil
+	}
+	cb.maxUpBuffers = int32(len(cb.up))
+	cb.maxDownBuffers = int32(len(cb.down))
+	cb.up[0] = rttBuffer{
+		name:   unsafe.Pointer(&rttTerminalName[0]),
+		buffer: unsafe.Pointer(&rtt.upBuffer[0]),
+		size:   uint32(len(rtt.upBuffer)),
+		flags:  rttModeNoBlockTrim,
+	}
+	cb.down[0] = rttBuffer{
+		name:   unsafe.Pointer(&rttTerminalName[0]),
+		buffer: unsafe.Pointer(&rtt.downBuffer[0]),
+		size:   uint32(len(rtt.downBuffer)),
+		flags:  rttModeNoBlockTrim,
+	}
+
+	// Set the ID last, so that a debugger never finds a partially initialized
+	// control block.
+	id := "SEGGER RTT"
+	for i := len(id) - 1; i >= 0; i-- {
+		volatile.StoreUint8(&cb.id[i], id[i])
+	}
+	return nil
+}
+
+// WriteByte writes a single byte to the up buffer. The byte is dropped if the
+// buffer is full.
+func (rtt *RTT) WriteByte(c byte) error {
+	up := &rtt.controlBlock.up[0]
+	writeOffset := up.writeOffset.Get()
+	next := writeOffset + 1
+	if next == up.size {
+		next = 0
+	}
+	if next == up.readOffset.Get() {
+		// Buffer is full.
+		return nil
+	}
+	rtt.upBuffer[writeOffset] = c
+	up.writeOffset.Set(next)
+	return nil
+}
+
+// Write writes the data to the up buffer. Data that doesn't fit in the buffer
+// is dropped.
+func (rtt *RTT) Write(data []byte) (n int, err error) {
+	for _, c := range data {
+		rtt.WriteByte(c)
+	}
+	return len(data), nil
+}
+
+// ReadByte reads a single byte from the down buffer, which contains the data
+// sent by the debugger. It returns an error if there is no data.
+func (rtt *RTT) ReadByte() (byte, error) {
+	down := &rtt.controlBlock.down[0]
+	readOffset := down.readOffset.Get()
+	if readOffset == down.writeOffset.Get() {
+		return 0, errNoByte
+	}
+	c := volatile.LoadUint8(&rtt.downBuffer[readOffset])
+	readOffset++
+	if readOffset == down.size {
+		readOffset = 0
+	}
+	down.readOffset.Set(readOffset)
+	return c, nil
+}
+
+// Buffered returns the number of bytes in the down buffer.
+func (rtt *RTT) Buffered() int {
+	down := &rtt.controlBlock.down[0]
+	n := int(down.writeOffset.Get()) - int(down.readOffset.Get())
+	if n < 0 {
+		n += int(down.size)
+	}
+	return n
+}
+
+// DTR always returns true: a debugger may be connected at any time.
+func (rtt *RTT) DTR() bool {
+	return true
+}
+
+// RTS always returns true: a debugger may be connected at any time.
+func (rtt *RTT) RTS() bool {
+	return true
+}
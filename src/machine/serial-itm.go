@@ -0,0 +1,10 @@
+//go:build baremetal && serial.itm
+
+package machine
+
+// Serial is implemented via the ITM, through the SWO pin of the debug port.
+var Serial = DefaultITM
+
+func InitSerial() {
+	Serial.Configure(UARTConfig{})
+}
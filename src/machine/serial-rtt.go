@@ -0,0 +1,10 @@
+//go:build baremetal && serial.rtt
+
+package machine
+
+// Serial is implemented via SEGGER RTT, through the debug port.
+var Serial = DefaultRTT
+
+func InitSerial() {
+	Serial.Configure(UARTConfig{})
+}
@@ -2,6 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+//go:build darwin || freebsd
+
 package os
 
 import (
@@ -26,7 +28,7 @@ func (d *dirInfo) close() {
 
 func (f *File) readdir(n int, mode readdirMode) (names []string, dirents []DirEntry, infos []FileInfo, err error) {
 	if f.dirinfo == nil {
-		dir, call, errno := darwinOpenDir(syscallFd(f.handle.(unixFileHandle)))
+		dir, call, errno := fdOpenDir(syscallFd(f.handle.(unixFileHandle)))
 		if errno != nil {
 			return nil, nil, nil, &PathError{Op: call, Path: f.name, Err: errno}
 		}
@@ -54,7 +56,7 @@ func (f *File) readdir(n int, mode readdirMode) (names []string, dirents []DirEn
 		if entptr == nil { // EOF
 			break
 		}
-		if dirent.Ino == 0 {
+		if direntIno(&dirent) == 0 {
 			continue
 		}
 		name := (*[len(syscall.Dirent{}.Name)]byte)(unsafe.Pointer(&dirent.Name))[:]
@@ -122,11 +124,11 @@ func dtToType(typ uint8) FileMode {
 	return ^FileMode(0)
 }
 
-// darwinOpenDir returns a pointer to a DIR structure suitable for
+// fdOpenDir returns a pointer to a DIR structure suitable for
 // ReadDir. In case of an error, the name of the failed
 // syscall is returned along with a syscall.Errno.
 // Borrowed from upstream's internal/poll/fd_opendir_darwin.go
-func darwinOpenDir(fd syscallFd) (uintptr, string, error) {
+func fdOpenDir(fd syscallFd) (uintptr, string, error) {
 	// fdopendir(3) takes control of the file descriptor,
 	// so use a dup.
 	fd2, err := syscall.Dup(fd)
@@ -147,7 +149,8 @@ func darwinOpenDir(fd syscallFd) (uintptr, string, error) {
 	return dir, "", nil
 }
 
-// Implemented in syscall/syscall_libc_darwin_*.go.
+// Implemented in syscall/syscall_libc_darwin_*.go and
+// syscall/syscall_libc_freebsd.go.
 
 //go:linkname closedir syscall.closedir
 func closedir(dir uintptr) (err error)
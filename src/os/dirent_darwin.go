@@ -0,0 +1,7 @@
+package os
+
+import "syscall"
+
+func direntIno(dirent *syscall.Dirent) uint64 {
+	return dirent.Ino
+}
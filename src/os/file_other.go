@@ -16,10 +16,6 @@ var (
 
 const DevNull = "/dev/null"
 
-// isOS indicates whether we're running on a real operating system with
-// filesystem support.
-const isOS = false
-
 // stdioFileHandle represents one of stdin, stdout, or stderr depending on the
 // number. It implements the FileHandle interface.
 type stdioFileHandle uint8
@@ -39,7 +35,7 @@ func (f *file) close() error {
 }
 
 func NewFile(fd uintptr, name string) *File {
-	return &File{&file{handle: stdioFileHandle(fd), name: name}}
+	return &File{&file{handle: newFileHandle(fd), name: name}}
 }
 
 // Read reads up to len(b) bytes from machine.Serial.
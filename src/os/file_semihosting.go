@@ -0,0 +1,231 @@
+//go:build baremetal && semihosting
+
+package os
+
+// This file implements file I/O using semihosting, for programs running under
+// an emulator (like QEMU) or a debugger (like OpenOCD). All files are opened on
+// the host, relative to the working directory of the emulator or debugger.
+
+import (
+	"internal/semihosting"
+	"io"
+	"syscall"
+)
+
+func init() {
+	// Mount the host filesystem at the root directory, like on a real OS.
+	Mount("/", semihostingFilesystem{})
+}
+
+// isOS indicates whether we're running on a real operating system with
+// filesystem support.
+const isOS = true
+
+// newFileHandle returns the FileHandle for the given file descriptor. The
+// lowest file descriptors are reserved for the console by semihosting hosts,
+// so they are written to the same output as print and println.
+func newFileHandle(fd uintptr) FileHandle {
+	if fd <= 2 {
+		return stdioFileHandle(fd)
+	}
+	return &semihostingFileHandle{fd: int(fd)}
+}
+
+// Rename renames (moves) oldpath to newpath.
+// If there is an error, it will be of type *LinkError.
+func Rename(oldpath, newpath string) error {
+	if semihosting.Rename(oldpath, newpath) != 0 {
+		return &LinkError{"rename", oldpath, newpath, semihostingError()}
+	}
+	return nil
+}
+
+// semihostingError returns the error for the last failed semihosting call.
+func semihostingError() error {
+	switch errno := syscall.Errno(semihosting.Errno()); errno {
+	case syscall.ENOENT:
+		return ErrNotExist
+	case syscall.EEXIST:
+		return ErrExist
+	case syscall.EACCES, syscall.EPERM:
+		return ErrPermission
+	default:
+		return errno
+	}
+}
+
+// semihostingFilesystem is the filesystem of the semihosting host.
+type semihostingFilesystem struct {
+}
+
+func (fs semihostingFilesystem) OpenFile(name string, flag int, perm FileMode) (uintptr, error) {
+	// Semihosting only supports the fopen modes, so emulate the other flags
+	// by checking whether the file exists first.
+	exists := func() bool {
+		fd := semihosting.Open(name, semihosting.ModeRead)
+		if fd < 0 {
+			return false
+		}
+		semihosting.Close(fd)
+		return true
+	}
+	if flag&O_CREATE != 0 && flag&O_EXCL != 0 && exists() {
+		return 0, ErrExist
+	}
+
+	var mode int
+	switch flag & (O_RDONLY | O_WRONLY | O_RDWR) {
+	case O_RDONLY:
+		mode = semihosting.ModeRead
+	case O_WRONLY:
+		switch {
+		case flag&O_APPEND != 0:
+			mode = semihosting.ModeAppend
+		case flag&O_TRUNC != 0:
+			mode = semihosting.ModeWrite
+		default:
+			mode = semihosting.ModeReadWrite
+		}
+	case O_RDWR:
+		switch {
+		case flag&O_APPEND != 0:
+			mode = semihosting.ModeAppendReadWrite
+		case flag&O_TRUNC != 0:
+			mode = semihosting.ModeWriteRead
+		default:
+			mode = semihosting.ModeReadWrite
+		}
+	default:
+		return 0, ErrInvalid
+	}
+	if mode != semihosting.ModeRead && mode != semihosting.ModeReadWrite && flag&O_CREATE == 0 && !exists() {
+		// The other modes create the file if it doesn't exist.
+		return 0, ErrNotExist
+	}
+
+	fd := semihosting.Open(name, mode)
+	if fd < 0 && mode == semihosting.ModeReadWrite && flag&O_CREATE != 0 {
+		// The "r+" mode doesn't create the file, try again with "w+" which
+		// does. The file doesn't exist, so it won't truncate anything.
+		fd = semihosting.Open(name, semihosting.ModeWriteRead)
+	}
+	if fd < 0 {
+		return 0, semihostingError()
+	}
+	return uintptr(fd), nil
+}
+
+func (fs semihostingFilesystem) Mkdir(name string, perm FileMode) error {
+	return ErrUnsupported
+}
+
+func (fs semihostingFilesystem) Remove(name string) error {
+	if semihosting.Remove(name) != 0 {
+		return &PathError{Op: "remove", Path: name, Err: semihostingError()}
+	}
+	return nil
+}
+
+// semihostingFileHandle is a file opened on the semihosting host. Semihosting
+// has no call to get the current file position, so it is tracked here.
+type semihostingFileHandle struct {
+	fd  int
+	pos int64
+}
+
+func (f *semihostingFileHandle) Read(b []byte) (n int, err error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	// The return value is the number of bytes that were not read.
+	ret := semihosting.Read(f.fd, b)
+	if ret < 0 || ret > len(b) {
+		return 0, semihostingError()
+	}
+	n = len(b) - ret
+	f.pos += int64(n)
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func (f *semihostingFileHandle) ReadAt(b []byte, offset int64) (n int, err error) {
+	pos := f.pos
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	defer f.Seek(pos, io.SeekStart)
+	for len(b) != 0 {
+		m, err := f.Read(b)
+		n += m
+		if err != nil {
+			return n, err
+		}
+		b = b[m:]
+	}
+	return n, nil
+}
+
+func (f *semihostingFileHandle) Write(b []byte) (n int, err error) {
+	// The return value is the number of bytes that were not written.
+	ret := semihosting.Write(f.fd, b)
+	if ret < 0 || ret > len(b) {
+		return 0, semihostingError()
+	}
+	n = len(b) - ret
+	f.pos += int64(n)
+	if ret != 0 {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
+
+func (f *semihostingFileHandle) WriteAt(b []byte, offset int64) (n int, err error) {
+	pos := f.pos
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	defer f.Seek(pos, io.SeekStart)
+	return f.Write(b)
+}
+
+func (f *semihostingFileHandle) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		length := semihosting.FileLen(f.fd)
+		if length < 0 {
+			return 0, semihostingError()
+		}
+		offset += int64(length)
+	default:
+		return 0, ErrInvalid
+	}
+	if offset < 0 {
+		return 0, ErrInvalid
+	}
+	if semihosting.Seek(f.fd, int(offset)) != 0 {
+		return 0, semihostingError()
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+func (f *semihostingFileHandle) Sync() error {
+	// Writes are passed to the host directly.
+	return nil
+}
+
+func (f *semihostingFileHandle) Close() error {
+	if semihosting.Close(f.fd) != 0 {
+		return semihostingError()
+	}
+	return nil
+}
+
+func (f *semihostingFileHandle) Fd() uintptr {
+	return uintptr(f.fd)
+}
@@ -0,0 +1,13 @@
+//go:build (baremetal && !semihosting) || (wasm && !wasi)
+
+package os
+
+// isOS indicates whether we're running on a real operating system with
+// filesystem support.
+const isOS = false
+
+// newFileHandle returns the FileHandle for the given file descriptor. Without
+// a filesystem, only stdin, stdout and stderr exist.
+func newFileHandle(fd uintptr) FileHandle {
+	return stdioFileHandle(fd)
+}
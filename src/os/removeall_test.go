@@ -1,4 +1,4 @@
-//go:build darwin || (linux && !baremetal && !js && !wasi)
+//go:build darwin || freebsd || (linux && !baremetal && !js && !wasi)
 
 // TODO: implement ReadDir on windows
 
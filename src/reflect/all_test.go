@@ -129,7 +129,6 @@ var typeTests = []pair{
 	}{},
 		"struct { c chan *int32; d float32 }",
 	},
-	/* // TODO(tinygo): No function support
 	{struct{ x (func(a int8, b int32)) }{}, "func(int8, int32)"},
 	{struct {
 		x struct {
@@ -137,7 +136,7 @@ var typeTests = []pair{
 		}
 	}{},
 		"struct { c func(chan *reflect_test.integer, *int8) }",
-	}, */
+	},
 	{struct {
 		x struct {
 			a int8
@@ -202,7 +201,6 @@ var typeTests = []pair{
 	}{},
 		`struct { a int8 "reflect:\"hi \\x00there\\t\\n\\\"\\\\\"" }`,
 	},
-	/* // TODO(tinygo):  Functions not supported
 	{struct {
 		x struct {
 			f func(args ...int)
@@ -226,7 +224,6 @@ var typeTests = []pair{
 	}{},
 		"struct { int32; int64 }",
 	},
-	*/
 }
 
 var valueTests = []pair{
@@ -381,8 +378,6 @@ func TestSetValue(t *testing.T) {
 	}
 }
 
-/*
-
 func TestMapIterSet(t *testing.T) {
 	m := make(map[string]any, len(valueTests))
 	for _, tt := range valueTests {
@@ -421,17 +416,15 @@ func TestMapIterSet(t *testing.T) {
 			e.SetIterValue(iter)
 		}
 	}))
-	// Calling MapRange should not allocate even though it returns a *MapIter.
-	// The function is inlineable, so if the local usage does not escape
-	// the *MapIter, it can remain stack allocated.
-	want := 0
-	if got != want {
-		t.Errorf("wanted %d alloc, got %d", want, got)
+	// TODO(tinygo): MapRange still allocates the iterator, and the first call
+	// to Next allocates the key and value buffers. Iterating over the entries
+	// must not allocate any further.
+	want := 4
+	if got > want {
+		t.Errorf("wanted at most %d allocs, got %d", want, got)
 	}
 }
 
-*/
-
 func TestCanIntUintFloatComplex(t *testing.T) {
 	type integer int
 	type uinteger uint
@@ -790,6 +783,8 @@ func TestFunctionValue(t *testing.T) {
 	assert(t, v.Type().String(), "func()")
 }
 
+*/
+
 func TestGrow(t *testing.T) {
 	v := ValueOf([]int(nil))
 	shouldPanic("reflect.Value.Grow using unaddressable value", func() { v.Grow(0) })
@@ -857,8 +852,6 @@ func TestGrow(t *testing.T) {
 	})
 }
 
-*/
-
 var appendTests = []struct {
 	orig, extra []int
 }{
@@ -1696,8 +1689,6 @@ func TestNilMap(t *testing.T) {
 	mv.SetMapIndex(ValueOf("hi"), Value{})
 }
 
-/* // TODO(tinygo): missing chan reflect support
-
 func TestChan(t *testing.T) {
 	for loop := 0; loop < 2; loop++ {
 		var c chan int
@@ -1936,6 +1927,7 @@ func TestSelect(t *testing.T) {
 			info = append(info, caseInfo{desc: "nil Chan recv"})
 		}
 
+		/* // TODO(tinygo): panic/recover support
 		// closed Chan send.
 		if x.Maybe() {
 			ch := make(chan int)
@@ -1947,6 +1939,7 @@ func TestSelect(t *testing.T) {
 			})
 			info = append(info, caseInfo{desc: "closed Chan send", canSelect: true, panic: true})
 		}
+		*/
 
 		// closed Chan recv.
 		if x.Maybe() {
@@ -2065,6 +2058,7 @@ func TestSelect(t *testing.T) {
 	}
 }
 
+/* // TODO(tinygo): panic/recover support
 func TestSelectMaxCases(t *testing.T) {
 	var sCases []SelectCase
 	channel := make(chan int)
@@ -2093,6 +2087,7 @@ func TestSelectMaxCases(t *testing.T) {
 	// Should panic
 	_, _, _ = Select(sCases)
 }
+*/
 
 func TestSelectNop(t *testing.T) {
 	// "select { default: }" should always return the default case.
@@ -2166,8 +2161,6 @@ func fmtSelect(info []caseInfo) string {
 	return buf.String()
 }
 
-// TODO(tinygo): missing func/method/call support
-
 type two [2]uintptr
 
 // Difficult test for function call because of
@@ -2258,6 +2251,7 @@ func TestCallWithStruct(t *testing.T) {
 	}
 }
 
+/* // TODO(tinygo): missing finalizer support
 func TestCallReturnsEmpty(t *testing.T) {
 	// Issue 21717: past-the-end pointer write in Call with
 	// nonzero-sized frame and zero-sized return value.
@@ -2281,6 +2275,7 @@ func TestCallReturnsEmpty(t *testing.T) {
 	}
 	runtime.KeepAlive(v)
 }
+*/
 
 func TestMakeFunc(t *testing.T) {
 	f := dummy
@@ -2441,8 +2436,6 @@ func TestMakeFuncInvalidReturnAssignments(t *testing.T) {
 	})
 }
 
-*/
-
 type Point struct {
 	x, y int
 }
@@ -2491,8 +2484,6 @@ func (p *Point) Int32Method(x int32) int32 {
 	return x
 }
 
-/*
-// TODO(tinygo): missing method support
 func TestMethod(t *testing.T) {
 	// Non-curried method of type.
 	p := Point{3, 4}
@@ -2799,6 +2790,9 @@ func TestDirectIfaceMethod(t *testing.T) {
 	}
 }
 
+/*
+// TODO(tinygo): missing support for unexported interface methods
+
 // Reflect version of $GOROOT/test/method5.go
 
 // Concrete types implementing M method.
@@ -3135,8 +3129,6 @@ func TestFieldByIndex(t *testing.T) {
 	}
 }
 
-/*
-
 func TestFieldByName(t *testing.T) {
 	for _, test := range fieldTests {
 		s := TypeOf(test.s)
@@ -3177,8 +3169,6 @@ func TestFieldByName(t *testing.T) {
 	}
 }
 
-*/
-
 func TestImportPath(t *testing.T) {
 	tests := []struct {
 		t    Type
@@ -3603,8 +3593,6 @@ func TestIndex(t *testing.T) {
 	}
 }
 
-/*
-
 func TestSlice(t *testing.T) {
 	xs := []int{1, 2, 3, 4, 5, 6, 7, 8}
 	v := ValueOf(xs).Slice(3, 5).Interface().([]int)
@@ -3691,6 +3679,7 @@ func TestSlice3(t *testing.T) {
 	}
 }
 
+/* // TODO(tinygo): missing SetCap support
 func TestSetLenCap(t *testing.T) {
 	xs := []int{1, 2, 3, 4, 5, 6, 7, 8}
 	xa := [8]int{10, 20, 30, 40, 50, 60, 70, 80}
@@ -3738,6 +3727,8 @@ func TestVariadic(t *testing.T) {
 	}
 }
 
+*/
+
 func TestFuncArg(t *testing.T) {
 	f1 := func(i int, f func(int) int) int { return f(i) }
 	f2 := func(i int) int { return i + 1 }
@@ -3767,8 +3758,6 @@ func TestStructArg(t *testing.T) {
 	}
 }
 
-*/
-
 var tagGetTests = []struct {
 	Tag   StructTag
 	Key   string
@@ -4784,6 +4773,8 @@ func TestConvert(t *testing.T) {
 	}
 }
 
+*/
+
 func TestConvertPanic(t *testing.T) {
 	s := make([]byte, 4)
 	p := new([8]byte)
@@ -4850,8 +4841,6 @@ func TestConvertNaNs(t *testing.T) {
 	}
 }
 
-*/
-
 type ComparableStruct struct {
 	X int
 }
@@ -4929,14 +4918,14 @@ func TestOverflow(t *testing.T) {
 	}
 }
 
-/*
-
 func checkSameType(t *testing.T, x Type, y any) {
 	if x != TypeOf(y) || TypeOf(Zero(x).Interface()) != TypeOf(y) {
 		t.Errorf("did not find preexisting type for %s (vs %s)", TypeOf(x), TypeOf(y))
 	}
 }
 
+/*
+
 func TestArrayOf(t *testing.T) {
 	// check construction and use of type not in binary
 	tests := []struct {
@@ -5255,6 +5244,8 @@ func TestSliceOfGC(t *testing.T) {
 	}
 }
 
+*/
+
 func TestStructOfFieldName(t *testing.T) {
 	// invalid field name "1nvalid"
 	shouldPanic("has invalid name", func() {
@@ -5433,6 +5424,8 @@ func TestStructOf(t *testing.T) {
 		struct{ F structFieldType }{})
 }
 
+/*
+
 func TestStructOfExportRules(t *testing.T) {
 	type S1 struct{}
 	type s2 struct{}
@@ -6174,6 +6167,8 @@ func TestChanOf(t *testing.T) {
 	}
 }
 
+*/
+
 func TestChanOfDir(t *testing.T) {
 	// check construction and use of type not in binary
 	type T string
@@ -6194,6 +6189,8 @@ func TestChanOfDir(t *testing.T) {
 	}
 }
 
+/*
+
 func TestChanOfGC(t *testing.T) {
 	done := make(chan bool, 1)
 	go func() {
@@ -6242,6 +6239,8 @@ func TestChanOfGC(t *testing.T) {
 	}
 }
 
+*/
+
 func TestMapOf(t *testing.T) {
 	// check construction and use of type not in binary
 	type K string
@@ -6265,6 +6264,8 @@ func TestMapOf(t *testing.T) {
 	shouldPanic("invalid key type", func() { MapOf(TypeOf((func())(nil)), TypeOf(false)) })
 }
 
+/*
+
 func TestMapOfGCKeys(t *testing.T) {
 	type T *uintptr
 	tt := TypeOf(T(nil))
@@ -6507,6 +6508,8 @@ func TestAllocsInterfaceSmall(t *testing.T) {
 	}
 }
 
+*/
+
 // An exhaustive is a mechanism for writing exhaustive or stochastic tests.
 // The basic usage is:
 //
@@ -6597,6 +6600,8 @@ func (x *exhaustive) Maybe() bool {
 	return x.Choose(2) == 1
 }
 
+/*
+
 func GCFunc(args []Value) []Value {
 	runtime.GC()
 	return []Value{}
@@ -7890,8 +7895,6 @@ func iterateToString(it *MapIter) string {
 	return "[" + strings.Join(got, ", ") + "]"
 }
 
-/*
-
 func TestConvertibleTo(t *testing.T) {
 	t1 := ValueOf(example1.MyStruct{}).Type()
 	t2 := ValueOf(example2.MyStruct{}).Type()
@@ -7909,6 +7912,8 @@ func TestConvertibleTo(t *testing.T) {
 	}
 }
 
+/*
+
 func TestSetIter(t *testing.T) {
 	data := map[string]int{
 		"foo": 1,
@@ -8290,8 +8295,6 @@ func TestValue_Comparable(t *testing.T) {
 	}
 }
 
-/*
-
 type ValueEqualTest struct {
 	v, u           any
 	eq             bool
@@ -8494,6 +8497,8 @@ func TestValue_EqualNonComparable(t *testing.T) {
 	}
 }
 
+/*
+
 func TestInitFuncTypes(t *testing.T) {
 	n := 100
 	var wg sync.WaitGroup
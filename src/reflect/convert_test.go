@@ -3,6 +3,7 @@ package reflect_test
 import (
 	. "reflect"
 	"testing"
+	"unsafe"
 )
 
 func TestTinyConvert(t *testing.T) {
@@ -190,3 +191,121 @@ func TestTinyConvert(t *testing.T) {
 		}
 	}
 }
+
+func TestTinyConvertibleTo(t *testing.T) {
+	type namedInt int
+	type namedSlice []int
+	type tagged struct {
+		A int `json:"a"`
+	}
+	type untagged struct {
+		A int
+	}
+	type namedPtr *int
+
+	var tests = []struct {
+		from, to any
+		ok       bool
+	}{
+		{int(0), namedInt(0), true},
+		{namedInt(0), float64(0), true},
+		{[]int(nil), namedSlice(nil), true},
+		{[]int(nil), (*[2]int)(nil), true},
+		{[]int(nil), [2]int{}, true},
+		{[]int(nil), (*[2]uint)(nil), false},
+		{tagged{}, untagged{}, true},
+		{&tagged{}, &untagged{}, true},
+		{[]tagged(nil), []untagged(nil), true},
+		{(*int)(nil), namedPtr(nil), true},
+		{namedPtr(nil), (*namedInt)(nil), false},
+		{(*int)(nil), (*namedInt)(nil), false},
+		{(*int)(nil), unsafe.Pointer(nil), false},
+		{unsafe.Pointer(nil), uintptr(0), false},
+		{complex64(0), complex128(0), true},
+		{complex64(0), float64(0), false},
+		{int32(0), "", true},
+		{"", []rune(nil), true},
+		{"", []namedInt(nil), false},
+		{3, (*error)(nil), false},
+		{map[string]int(nil), map[string]namedInt(nil), false},
+	}
+	for _, tt := range tests {
+		from, to := TypeOf(tt.from), TypeOf(tt.to)
+		if ok := from.ConvertibleTo(to); ok != tt.ok {
+			t.Errorf("(%s).ConvertibleTo(%s) = %v, want %v", from, to, ok, tt.ok)
+		}
+	}
+
+	// Converting to an interface type.
+	errType := TypeOf((*error)(nil)).Elem()
+	anyType := TypeOf((*any)(nil)).Elem()
+	if !TypeOf(myError("")).ConvertibleTo(errType) {
+		t.Errorf("myError is not convertible to error")
+	}
+	if !errType.ConvertibleTo(anyType) || anyType.ConvertibleTo(errType) {
+		t.Errorf("wrong ConvertibleTo result for interface types")
+	}
+	v := ValueOf(myError("x")).Convert(errType)
+	if v.Kind() != Interface || v.Interface().(error).Error() != "x" {
+		t.Errorf("Convert to error returned %v", v)
+	}
+
+	// Converted values are not addressable and don't alias the original.
+	x := struct{ A [4]int }{}
+	tv := ValueOf(&x).Elem().Convert(TypeOf(struct{ A [4]int }{}))
+	x.A[0] = 1
+	if tv.CanAddr() || tv.Field(0).Index(0).Int() != 0 {
+		t.Errorf("converted value aliases the original")
+	}
+	if s := ValueOf(namedInt(0x263a)).Convert(TypeOf("")).String(); s != "☺" {
+		t.Errorf("Convert(int -> string) = %q", s)
+	}
+	if s := ValueOf([]rune("héllo")).Convert(TypeOf("")).String(); s != "héllo" {
+		t.Errorf("Convert([]rune -> string) = %q", s)
+	}
+	if c := ValueOf(complex64(1 + 2i)).Convert(TypeOf(complex128(0))).Complex(); c != 1+2i {
+		t.Errorf("Convert(complex64 -> complex128) = %v", c)
+	}
+}
+
+type myError string
+
+func (e myError) Error() string { return string(e) }
+
+func TestTinyConvertChanDir(t *testing.T) {
+	type namedChan chan int
+	type namedRecvChan <-chan int
+
+	ch := make(chan int, 1)
+	v := ValueOf(ch)
+	recvType := ChanOf(RecvDir, TypeOf(0))
+	sendType := ChanOf(SendDir, TypeOf(0))
+
+	recv := v.Convert(recvType)
+	if recv.Type() != recvType || recv.Type() != TypeOf((<-chan int)(nil)) {
+		t.Errorf("Convert to <-chan int returned type %s", recv.Type())
+	}
+	send := v.Convert(sendType).Interface().(chan<- int)
+	send <- 3
+	if x := recv.Interface().(<-chan int); len(x) != 1 || <-x != 3 {
+		t.Errorf("converted channels don't refer to the same channel")
+	}
+
+	tests := []struct {
+		from, to Type
+		ok       bool
+	}{
+		{TypeOf(ch), recvType, true},
+		{TypeOf(ch), TypeOf(namedRecvChan(nil)), true},
+		{TypeOf(namedChan(nil)), recvType, true},
+		{TypeOf(namedChan(nil)), TypeOf(namedRecvChan(nil)), false},
+		{recvType, TypeOf(ch), false},
+		{recvType, sendType, false},
+		{TypeOf(ch), ChanOf(RecvDir, TypeOf(int8(0))), false},
+	}
+	for _, tt := range tests {
+		if ok := tt.from.ConvertibleTo(tt.to); ok != tt.ok {
+			t.Errorf("(%s).ConvertibleTo(%s) = %v, want %v", tt.from, tt.to, ok, tt.ok)
+		}
+	}
+}
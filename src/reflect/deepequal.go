@@ -11,17 +11,47 @@ import "unsafe"
 // During deepValueEqual, must keep track of checks that are
 // in progress. The comparison algorithm assumes that all
 // checks in progress are true when it reencounters them.
-// Visited comparisons are stored in a map indexed by visit.
+// Visited comparisons are stored in a visitSet.
 type visit struct {
 	a1  unsafe.Pointer
 	a2  unsafe.Pointer
 	typ *rawType
 }
 
-// Tests for deep equality using reflected types. The map argument tracks
+// visitSet is the set of visited comparisons. Most values contain only a few
+// pointers, so the first few visits are stored in a small fixed-capacity array
+// that is searched linearly. Only larger values need a map.
+type visitSet struct {
+	n     int
+	small [8]visit
+	large map[visit]struct{}
+}
+
+// add adds v to the set. It returns false if v was already in the set.
+func (s *visitSet) add(v visit) bool {
+	for i := 0; i < s.n; i++ {
+		if s.small[i] == v {
+			return false
+		}
+	}
+	if s.n < len(s.small) {
+		s.small[s.n] = v
+		s.n++
+		return true
+	}
+	if s.large == nil {
+		s.large = make(map[visit]struct{})
+	} else if _, ok := s.large[v]; ok {
+		return false
+	}
+	s.large[v] = struct{}{}
+	return true
+}
+
+// Tests for deep equality using reflected types. The visited set tracks
 // comparisons that have already been seen, which allows short circuiting on
 // recursive types.
-func deepValueEqual(v1, v2 Value, visited map[visit]struct{}) bool {
+func deepValueEqual(v1, v2 Value, visited *visitSet) bool {
 	if !v1.IsValid() || !v2.IsValid() {
 		return v1.IsValid() == v2.IsValid()
 	}
@@ -42,23 +72,31 @@ func deepValueEqual(v1, v2 Value, visited map[visit]struct{}) bool {
 		return false
 	}
 
+	// For a Ptr or Map value, we need the pointer itself. For a Slice or
+	// Interface value, the address of the slice header or interface is used.
+	ptrval := func(v Value) unsafe.Pointer {
+		switch v.Kind() {
+		case Ptr, Map:
+			return v.pointer()
+		default:
+			return v.value
+		}
+	}
+
 	if hard(v1, v2) {
-		addr1 := v1.pointer()
-		addr2 := v2.pointer()
+		addr1 := ptrval(v1)
+		addr2 := ptrval(v2)
 		if uintptr(addr1) > uintptr(addr2) {
 			// Canonicalize order to reduce number of entries in visited.
 			// Assumes non-moving garbage collector.
 			addr1, addr2 = addr2, addr1
 		}
 
-		// Short circuit if references are already seen.
-		v := visit{addr1, addr2, v1.typecode}
-		if _, ok := visited[v]; ok {
+		// Short circuit if references are already seen, or else remember them
+		// for later.
+		if !visited.add(visit{addr1, addr2, v1.typecode}) {
 			return true
 		}
-
-		// Remember for later.
-		visited[v] = struct{}{}
 	}
 
 	switch v1.Kind() {
@@ -192,5 +230,6 @@ func DeepEqual(x, y interface{}) bool {
 	if v1.typecode != v2.typecode {
 		return false
 	}
-	return deepValueEqual(v1, v2, make(map[visit]struct{}))
+	var visited visitSet
+	return deepValueEqual(v1, v2, &visited)
 }
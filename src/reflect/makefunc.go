@@ -1,5 +1,115 @@
 package reflect
 
+import "unsafe"
+
+// makeFuncImpl is the context of a function created by MakeFunc. Every
+// signature type has a compiler generated wrapper function (see
+// funcType.makeFunc) that stores its parameters in memory and calls the call
+// field with pointers to the parameters and results. Therefore, call must be
+// the first field of this struct.
+type makeFuncImpl struct {
+	call   func(args, results *unsafe.Pointer)
+	typ    *rawType
+	fn     func([]Value) []Value
+	method unsafe.Pointer // code pointer of the method, for method values
+}
+
+// MakeFunc returns a new function of the given Type that wraps the function fn.
+// When called, that new function does the following:
+//
+//   - converts its arguments to a slice of Values.
+//   - runs results := fn(args).
+//   - returns the results as a slice of Values, one per formal result.
+//
+// The function fn must return as many results as there are results in typ, and
+// each result must be assignable to the corresponding result type.
 func MakeFunc(typ Type, fn func(args []Value) (results []Value)) Value {
-	panic("unimplemented: reflect.MakeFunc()")
+	t := typ.(*rawType)
+	if t.Kind() != Func {
+		panic("reflect: call of MakeFunc with non-Func type")
+	}
+	ft := t.funcType("MakeFunc")
+
+	impl := &makeFuncImpl{
+		typ: t,
+		fn:  fn,
+	}
+	impl.call = impl.callback
+	return Value{
+		typecode: t,
+		value: unsafe.Pointer(&funcHeader{
+			Context: unsafe.Pointer(impl),
+			Code:    ft.makeFunc.Code,
+		}),
+		flags: valueFlagExported,
+	}
+}
+
+// makeMethodValue returns a method value of type typ, which calls the method
+// expression fn with rcvr as the receiver. It is a function created by MakeFunc
+// that is marked as a method value, so that Value.Pointer can return the code
+// pointer of the method instead of the MakeFunc wrapper.
+func makeMethodValue(typ *rawType, fn, rcvr Value, flags valueFlags) Value {
+	variadic := typ.IsVariadic()
+	impl := &makeFuncImpl{
+		typ: typ,
+		fn: func(args []Value) []Value {
+			in := append([]Value{rcvr}, args...)
+			if variadic {
+				// The variadic arguments are already collected in a slice.
+				return fn.CallSlice(in)
+			}
+			return fn.Call(in)
+		},
+		method: (*funcHeader)(fn.value).Code,
+	}
+	impl.call = impl.callback
+	return Value{
+		typecode: typ,
+		value: unsafe.Pointer(&funcHeader{
+			Context: unsafe.Pointer(impl),
+			Code:    typ.funcType("Method").makeFunc.Code,
+		}),
+		flags: flags | valueFlagMethod,
+	}
+}
+
+// callback is called from the compiler generated wrapper with a pointer to
+// each parameter and each result.
+func (impl *makeFuncImpl) callback(args, results *unsafe.Pointer) {
+	ft := impl.typ.funcType("MakeFunc")
+
+	// Copy the arguments: they are stored on the stack of the wrapper but fn
+	// may keep them around.
+	in := make([]Value, ft.numIn)
+	for i := range in {
+		typ := ft.param(i)
+		ptr := *(*unsafe.Pointer)(unsafe.Add(unsafe.Pointer(args), uintptr(i)*unsafe.Sizeof(uintptr(0))))
+		in[i] = loadResult(typ, ptr, valueFlagExported)
+	}
+
+	out := impl.fn(in)
+	if len(out) != int(ft.numOut) {
+		panic("reflect: wrong return count from function created by MakeFunc")
+	}
+
+	// Store the results in the memory provided by the wrapper.
+	for i, v := range out {
+		typ := ft.param(int(ft.numIn) + i)
+		if v.typecode == nil {
+			panic("reflect: function created by MakeFunc using closure returned zero Value")
+		}
+		if !v.isExported() {
+			panic("reflect: function created by MakeFunc using closure returned value obtained from unexported field")
+		}
+		if !v.typecode.AssignableTo(typ) {
+			panic("reflect: function created by MakeFunc using closure returned wrong type: have " + v.typecode.String() + " for " + typ.String())
+		}
+		result := Value{
+			typecode: typ,
+			value:    *(*unsafe.Pointer)(unsafe.Add(unsafe.Pointer(results), uintptr(i)*unsafe.Sizeof(uintptr(0)))),
+			flags:    valueFlagExported | valueFlagIndirect,
+		}
+		result.Set(v)
+	}
 }
@@ -29,6 +29,7 @@
 //     ptrTo        *typeStruct
 //     elem         *typeStruct // element type of the array
 //     arrayLen     uintptr     // length of the array (this is part of the type)
+//     sliceOf      *typeStruct // slice type of the same element type
 // - map types (this is still missing the key and element types)
 //     meta         uint8
 //     nmethods     uint16 (0)
@@ -43,12 +44,25 @@
 //     pkgpath      *byte       // package path; null terminated
 //     numField     uint16
 //     fields       [...]structField // the remaining fields are all of type structField
-// - interface types (this is missing the interface methods):
+// - interface types (see interfaceType):
 //     meta         uint8
+//     nmethods     uint16
 //     ptrTo        *typeStruct
-// - signature types (this is missing input and output parameters):
+//     methods      [...]*methodSignature // sorted like method sets
+// - method signatures (see methodSignature):
+//     typ          *typeStruct // func type without receiver
+//     pkgpath      *byte       // package path of unexported methods; null terminated
+//     name         [1]byte     // method name; null terminated
+// - signature types (see funcType):
 //     meta         uint8
+//     nmethods     uint16 (0)
 //     ptrTo        *typeStruct
+//     call         func(...)   // calls a func value of this type
+//     makeFunc     func(...)   // func value of this type, used by MakeFunc
+//     numIn        uint16
+//     numOut       uint16
+//     variadic     uint8
+//     params       [...]*typeStruct // input parameters followed by results
 // - named types
 //     meta         uint8
 //     nmethods     uint16      // number of methods
@@ -188,6 +202,18 @@ const (
 	BothDir = RecvDir | SendDir             // chan
 )
 
+func (d ChanDir) String() string {
+	switch d {
+	case SendDir:
+		return "chan<-"
+	case RecvDir:
+		return "<-chan"
+	case BothDir:
+		return "chan"
+	}
+	return "ChanDir" + itoa.Itoa(int(d))
+}
+
 // Method represents a single method.
 type Method struct {
 	// Name is the method name.
@@ -360,7 +386,7 @@ type Type interface {
 	// and FieldByNameFunc returns no match.
 	// This behavior mirrors Go's handling of name lookup in
 	// structs containing embedded fields.
-	//FieldByNameFunc(match func(string) bool) (StructField, bool)
+	FieldByNameFunc(match func(string) bool) (StructField, bool)
 
 	// In returns the type of a function type's i'th input parameter.
 	// It panics if the type's Kind is not Func.
@@ -427,6 +453,7 @@ type arrayType struct {
 	ptrTo     *rawType
 	elem      *rawType
 	arrayLen  uintptr
+	sliceOf   *rawType
 }
 
 type mapType struct {
@@ -463,6 +490,30 @@ type structType struct {
 	fields    [1]structField // the remaining fields are all of type structField
 }
 
+// Type for interface types. Like the fields array of structType, the methods
+// array is as long as numMethod. Each method is a pointer to a unique method
+// signature, which identifies both the name and the signature of a method.
+type interfaceType struct {
+	rawType
+	numMethod uint16
+	ptrTo     *rawType
+	methods   [1]unsafe.Pointer
+}
+
+// Type for signature types. Like the fields array of structType, the params
+// array is as long as numIn+numOut.
+type funcType struct {
+	rawType
+	numMethod uint16
+	ptrTo     *rawType
+	call      func(fn *funcHeader, args, results *unsafe.Pointer) // see Value.Call
+	makeFunc  funcHeader                                          // see MakeFunc
+	numIn     uint16
+	numOut    uint16
+	variadic  uint8
+	params    [1]*rawType
+}
+
 type structField struct {
 	fieldType *rawType
 	data      unsafe.Pointer // various bits of information, packed in a byte array
@@ -527,6 +578,11 @@ func pointerTo(t *rawType) *rawType {
 func (t *rawType) String() string {
 	if t.isNamed() {
 		s := t.name()
+		if s == "" {
+			// Type names were left out of the binary (-reflect=min), so
+			// describe the underlying type instead.
+			return t.underlying().String()
+		}
 		if s[0] == '.' {
 			return s[1:]
 		}
@@ -568,7 +624,12 @@ func (t *rawType) String() string {
 		s := "struct {"
 		for i := 0; i < numField; i++ {
 			f := t.rawField(i)
-			s += " " + f.Name + " " + f.Type.String()
+			if f.Anonymous {
+				// Embedded fields are written as only their type.
+				s += " " + f.Type.String()
+			} else {
+				s += " " + f.Name + " " + f.Type.String()
+			}
 			if f.Tag != "" {
 				s += " " + quote(string(f.Tag))
 			}
@@ -580,8 +641,62 @@ func (t *rawType) String() string {
 		s += " }"
 		return s
 	case Interface:
-		// TODO(dgryski): Needs actual method set info
-		return "interface {}"
+		methods := t.interfaceMethods()
+		if len(methods) == 0 {
+			return "interface {}"
+		}
+		s := "interface {"
+		for i, ptr := range methods {
+			sig := (*methodSignature)(ptr)
+			s += " "
+			if pkgPath := sig.PkgPath(); pkgPath != "" {
+				// Unexported methods are qualified with the package name (the
+				// last element of the package path), like upstream Go does.
+				pkgName := pkgPath
+				for j := len(pkgPath) - 1; j >= 0; j-- {
+					if pkgPath[j] == '/' {
+						pkgName = pkgPath[j+1:]
+						break
+					}
+				}
+				s += pkgName + "."
+			}
+			// Strip the "func" prefix of the signature.
+			s += sig.Name() + sig.typ.String()[len("func"):]
+			if i < len(methods)-1 {
+				s += ";"
+			}
+		}
+		return s + " }"
+	case Func:
+		ft := t.funcType("String")
+		s := "func("
+		for i := 0; i < int(ft.numIn); i++ {
+			if i > 0 {
+				s += ", "
+			}
+			if i == int(ft.numIn)-1 && ft.variadic != 0 {
+				s += "..." + ft.param(i).elem().String()
+			} else {
+				s += ft.param(i).String()
+			}
+		}
+		s += ")"
+		switch ft.numOut {
+		case 0:
+		case 1:
+			s += " " + ft.param(int(ft.numIn)).String()
+		default:
+			s += " ("
+			for i := 0; i < int(ft.numOut); i++ {
+				if i > 0 {
+					s += ", "
+				}
+				s += ft.param(int(ft.numIn) + i).String()
+			}
+			s += ")"
+		}
+		return s
 	default:
 		return t.Kind().String()
 	}
@@ -651,11 +766,11 @@ func rawStructFieldFromPointer(descriptor *structType, fieldType *rawType, data
 	var tag string
 	if flagsByte&structFieldFlagHasTag != 0 {
 		data = unsafe.Add(data, 1) // C: data+1
-		tagLen := uintptr(*(*byte)(data))
-		data = unsafe.Add(data, 1) // C: data+1
+		tagLen, lenLen := uvarint32(unsafe.Slice((*byte)(data), maxVarintLen32))
+		data = unsafe.Add(data, lenLen)
 		tag = *(*string)(unsafe.Pointer(&stringHeader{
 			data: data,
-			len:  tagLen,
+			len:  uintptr(tagLen),
 		}))
 	}
 
@@ -689,11 +804,12 @@ func (t *rawType) rawField(n int) rawStructField {
 	if uint(n) >= uint(descriptor.numField) {
 		panic("reflect: field index out of range")
 	}
+	return descriptor.rawField(n)
+}
 
-	// Iterate over all the fields to calculate the offset.
-	// This offset could have been stored directly in the array (to make the
-	// lookup faster), but by calculating it on-the-fly a bit of storage can be
-	// saved.
+// rawField decodes the n'th field of this struct type. The index is not
+// checked.
+func (descriptor *structType) rawField(n int) rawStructField {
 	field := (*structField)(unsafe.Add(unsafe.Pointer(&descriptor.fields[0]), uintptr(n)*unsafe.Sizeof(structField{})))
 	data := field.data
 
@@ -715,6 +831,16 @@ func (t *rawType) rawField(n int) rawStructField {
 //
 // For internal use only.
 func (t *rawType) rawFieldByName(n string) (rawStructField, []int, bool) {
+	return t.rawFieldByNameFunc(func(name string) bool {
+		return name == n
+	})
+}
+
+// rawFieldByNameFunc returns nearly the same value as FieldByNameFunc but
+// without converting the Type member to an interface.
+//
+// For internal use only.
+func (t *rawType) rawFieldByNameFunc(match func(string) bool) (rawStructField, []int, bool) {
 	if t.Kind() != Struct {
 		panic(&TypeError{"Field"})
 	}
@@ -724,23 +850,31 @@ func (t *rawType) rawFieldByName(n string) (rawStructField, []int, bool) {
 		index []int
 	}
 
+	// This is a breadth-first search over the embedded structs, like upstream
+	// Go. The count maps track how often a struct type is embedded at the
+	// current and the next level: fields of a struct that is embedded more than
+	// once at the same level are ambiguous.
 	queue := make([]fieldWalker, 0, 4)
 	queue = append(queue, fieldWalker{t, nil})
+	var nextlevel []fieldWalker
+	var count, nextCount map[*rawType]int
+	visited := map[*rawType]bool{}
 
 	for len(queue) > 0 {
-		type result struct {
-			r     rawStructField
-			index []int
-		}
-
-		var found []result
-		var nextlevel []fieldWalker
+		var found rawStructField
+		var foundIndex []int
+		ok := false
 
 		// For all the structs at this level..
 		for _, ll := range queue {
-			// Iterate over all the fields looking for the matching name
-			// Also calculate field offset.
+			if visited[ll.t] {
+				// Already looked at this struct at a shallower level (or
+				// earlier at this level, which was noted in count).
+				continue
+			}
+			visited[ll.t] = true
 
+			// Iterate over all the fields looking for a matching name.
 			descriptor := (*structType)(unsafe.Pointer(ll.t.underlying()))
 			field := &descriptor.fields[0]
 
@@ -757,24 +891,38 @@ func (t *rawType) rawFieldByName(n string) (rawStructField, []int, bool) {
 
 				name := readStringZ(data)
 				data = unsafe.Add(data, len(name))
-				if name == n {
-					found = append(found, result{
-						rawStructFieldFromPointer(descriptor, field.fieldType, data, flagsByte, name, offset),
-						append(ll.index, int(i)),
-					})
-				}
-
-				structOrPtrToStruct := field.fieldType.Kind() == Struct || (field.fieldType.Kind() == Pointer && field.fieldType.elem().Kind() == Struct)
-				if flagsByte&structFieldFlagIsEmbedded == structFieldFlagIsEmbedded && structOrPtrToStruct {
+				if match(name) {
+					if count[ll.t] > 1 || ok {
+						// Name appeared multiple times at this level: the
+						// fields cancel each other out.
+						return rawStructField{}, nil, false
+					}
+					found = rawStructFieldFromPointer(descriptor, field.fieldType, data, flagsByte, name, offset)
+					foundIndex = append(append([]int(nil), ll.index...), int(i))
+					ok = true
+				} else if !ok && flagsByte&structFieldFlagIsEmbedded != 0 {
 					embedded := field.fieldType
 					if embedded.Kind() == Pointer {
 						embedded = embedded.elem()
 					}
-
-					nextlevel = append(nextlevel, fieldWalker{
-						t:     embedded,
-						index: append(ll.index, int(i)),
-					})
+					if embedded.Kind() == Struct {
+						if nextCount[embedded] > 0 {
+							// Embedded more than once at the next level.
+							nextCount[embedded] = 2
+						} else {
+							if nextCount == nil {
+								nextCount = map[*rawType]int{}
+							}
+							nextCount[embedded] = 1
+							if count[ll.t] > 1 {
+								nextCount[embedded] = 2
+							}
+							nextlevel = append(nextlevel, fieldWalker{
+								t:     embedded,
+								index: append(append([]int(nil), ll.index...), int(i)),
+							})
+						}
+					}
 				}
 
 				// update offset/field pointer if there *is* a next field
@@ -785,19 +933,14 @@ func (t *rawType) rawFieldByName(n string) (rawStructField, []int, bool) {
 			}
 		}
 
-		// found multiple hits at this level
-		if len(found) > 1 {
-			return rawStructField{}, nil, false
-		}
-
 		// found the field we were looking for
-		if len(found) == 1 {
-			r := found[0]
-			return r.r, r.index, true
+		if ok {
+			return found, foundIndex, true
 		}
 
-		// else len(found) == 0, move on to the next level
-		queue = append(queue[:0], nextlevel...)
+		// else move on to the next level
+		queue, nextlevel = nextlevel, queue[:0]
+		count, nextCount = nextCount, nil
 	}
 
 	// didn't find it
@@ -940,25 +1083,190 @@ func (t *rawType) FieldAlign() int {
 // AssignableTo returns whether a value of type t can be assigned to a variable
 // of type u.
 func (t *rawType) AssignableTo(u Type) bool {
-	if t == u.(*rawType) {
+	if u == nil {
+		panic("reflect: nil type passed to Type.AssignableTo")
+	}
+	uu := u.(*rawType)
+	if uu.Kind() == Interface && t.implements(uu) {
 		return true
 	}
+	return directlyAssignable(uu, t)
+}
 
-	if u.Kind() == Interface && u.NumMethod() == 0 {
+// directlyAssignable returns whether a value x of type V can be directly
+// assigned (using memmove) to a value of type T.
+// https://go.dev/ref/spec#Assignability
+// Ignoring the interface rules (implemented elsewhere) and the ideal constant
+// rules (no ideal constants at run time).
+func directlyAssignable(T, V *rawType) bool {
+	// x's type V is identical to T?
+	if T == V {
 		return true
 	}
 
-	if u.Kind() == Interface {
-		panic("reflect: unimplemented: AssignableTo with interface")
+	// Otherwise at least one of T and V must not be defined and they must
+	// have the same kind.
+	if T.isNamed() && V.isNamed() || T.Kind() != V.Kind() {
+		return false
 	}
-	return false
+
+	if T.Kind() == Chan && specialChannelAssignability(T, V) {
+		return true
+	}
+
+	// x's type T and V must have identical underlying types.
+	return haveIdenticalUnderlyingType(T, V, true)
+}
+
+// specialChannelAssignability returns whether a value x of channel type V can
+// be directly assigned to a value of channel type T.
+// https://go.dev/ref/spec#Assignability
+func specialChannelAssignability(T, V *rawType) bool {
+	// Special case:
+	// x is a bidirectional channel value, T is a channel type,
+	// x's type V and T have identical element types,
+	// and at least one of V or T is not a defined type.
+	return V.ChanDir() == BothDir && (!T.isNamed() || !V.isNamed()) && haveIdenticalType(T.elem(), V.elem(), true)
 }
 
 func (t *rawType) Implements(u Type) bool {
 	if u.Kind() != Interface {
 		panic("reflect: non-interface type passed to Type.Implements")
 	}
-	return t.AssignableTo(u)
+	return t.implements(u.(*rawType))
+}
+
+// implements returns whether t implements the interface type u.
+func (t *rawType) implements(u *rawType) bool {
+	itfMethods := u.underlying().interfaceMethods()
+	if len(itfMethods) == 0 {
+		return true
+	}
+
+	var methods []unsafe.Pointer
+	if t.Kind() == Interface {
+		methods = t.underlying().interfaceMethods()
+	} else {
+		methods = t.methodSignatures()
+	}
+
+	// Both lists are sorted in the same order, so they can be compared in a
+	// single pass.
+	i := 0
+	for _, method := range methods {
+		if method == itfMethods[i] {
+			i++
+			if i == len(itfMethods) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// interfaceMethods returns the method signatures of the interface type t.
+func (t *rawType) interfaceMethods() []unsafe.Pointer {
+	itf := (*interfaceType)(unsafe.Pointer(t))
+	if itf.numMethod == 0 {
+		return nil
+	}
+	return unsafe.Slice(&itf.methods[0], itf.numMethod)
+}
+
+// methodSignature is a unique global created by the compiler for each method
+// name and signature. Two methods are the same if they point to the same
+// methodSignature.
+type methodSignature struct {
+	typ     *rawType // func type without receiver
+	pkgpath *byte    // null terminated, or nil for exported methods
+	name    [1]byte  // null terminated
+}
+
+func (sig *methodSignature) Name() string {
+	return readStringZ(unsafe.Pointer(&sig.name[0]))
+}
+
+func (sig *methodSignature) PkgPath() string {
+	if sig.pkgpath == nil {
+		return ""
+	}
+	return readStringZ(unsafe.Pointer(sig.pkgpath))
+}
+
+// methodSet is the list of method signatures of a concrete type, sorted by
+// name.
+type methodSet struct {
+	length     uintptr
+	signatures [1]unsafe.Pointer
+}
+
+// methodSets contains the method set of every type that has methods. It is
+// created by the interface lowering pass, but only when it is used.
+//
+//go:extern reflect.methodSets
+var methodSets []struct {
+	typ     *rawType
+	methods *methodSet
+}
+
+// methodSignatures returns the method signatures in the method set of t, which
+// must not be an interface type.
+func (t *rawType) methodSignatures() []unsafe.Pointer {
+	for _, entry := range methodSets {
+		if entry.typ == t {
+			return unsafe.Slice(&entry.methods.signatures[0], entry.methods.length)
+		}
+	}
+	return nil
+}
+
+// method is a single exported method of a concrete type, as used by
+// Type.Method. It must be kept up to date with getReflectMethod in
+// compiler/interface.go.
+type method struct {
+	signature *methodSignature
+	typ       *rawType   // method expression type, with the receiver first
+	fn        funcHeader // func value of type typ
+}
+
+// methodTable is the list of methods of a concrete type: first the exported
+// methods sorted by name, then the unexported methods. Like the fields array of
+// structType, the methods array is as long as length.
+type methodTable struct {
+	length   uintptr
+	exported uintptr // number of exported methods
+	methods  [1]method
+}
+
+// methodTables contains the methods of every type that has them. It is created
+// by the interface lowering pass, but only when it is used.
+//
+//go:extern reflect.methodTables
+var methodTables []struct {
+	typ     *rawType
+	methods *methodTable
+}
+
+// methodTable returns the exported methods of t, which must not be an
+// interface type.
+func (t *rawType) methodTable() []method {
+	for _, entry := range methodTables {
+		if entry.typ == t {
+			return unsafe.Slice(&entry.methods.methods[0], entry.methods.exported)
+		}
+	}
+	return nil
+}
+
+// allMethods returns all methods of t, including the unexported methods that
+// can only be called through an interface. The exported methods come first.
+func (t *rawType) allMethods() []method {
+	for _, entry := range methodTables {
+		if entry.typ == t {
+			return unsafe.Slice(&entry.methods.methods[0], entry.methods.length)
+		}
+	}
+	return nil
 }
 
 // Comparable returns whether values of this type can be compared to each other.
@@ -973,29 +1281,51 @@ func (t *rawType) isBinary() bool {
 
 func (t *rawType) ChanDir() ChanDir {
 	if t.Kind() != Chan {
-		panic(TypeError{"ChanDir"})
+		panic(&TypeError{"ChanDir"})
 	}
 
-	dir := int((*elemType)(unsafe.Pointer(t)).numMethod)
+	dir := int((*elemType)(unsafe.Pointer(t.underlying())).numMethod)
 
 	// nummethod is overloaded for channel to store channel direction
 	return ChanDir(dir)
 }
 
+// ConvertibleTo reports whether a value of the type is convertible to type u.
+// Even if ConvertibleTo returns true, the conversion may still panic. For
+// example, a slice of type []T is convertible to *[N]T, but the conversion
+// will panic if its length is less than N.
 func (t *rawType) ConvertibleTo(u Type) bool {
-	panic("unimplemented: (reflect.Type).ConvertibleTo()")
+	if u == nil {
+		panic("reflect: nil type passed to Type.ConvertibleTo")
+	}
+	return convertOp(u.(*rawType), t) != nil
+}
+
+// funcType returns the signature type struct of this func type. It panics if
+// the type's Kind is not Func.
+func (t *rawType) funcType(method string) *funcType {
+	if t.Kind() != Func {
+		panic(&TypeError{method})
+	}
+	return (*funcType)(unsafe.Pointer(t.underlying()))
+}
+
+// param returns parameter i of this signature, where the results come after
+// the input parameters.
+func (t *funcType) param(i int) *rawType {
+	return *(**rawType)(unsafe.Add(unsafe.Pointer(&t.params[0]), uintptr(i)*unsafe.Sizeof(t.params[0])))
 }
 
 func (t *rawType) IsVariadic() bool {
-	panic("unimplemented: (reflect.Type).IsVariadic()")
+	return t.funcType("IsVariadic").variadic != 0
 }
 
 func (t *rawType) NumIn() int {
-	panic("unimplemented: (reflect.Type).NumIn()")
+	return int(t.funcType("NumIn").numIn)
 }
 
 func (t *rawType) NumOut() int {
-	panic("unimplemented: (reflect.Type).NumOut()")
+	return int(t.funcType("NumOut").numOut)
 }
 
 func (t *rawType) NumMethod() int {
@@ -1009,6 +1339,8 @@ func (t *rawType) NumMethod() int {
 		return int((*ptrType)(unsafe.Pointer(t)).numMethod)
 	case Struct:
 		return int((*structType)(unsafe.Pointer(t)).numMethod)
+	case Interface:
+		return int((*interfaceType)(unsafe.Pointer(t)).numMethod)
 	}
 
 	// Other types have no methods attached.  Note we don't panic here.
@@ -1038,6 +1370,10 @@ func (t *rawType) name() string {
 func (t *rawType) Name() string {
 	if t.isNamed() {
 		name := t.name()
+		if name == "" {
+			// Type names were left out of the binary (-reflect=min).
+			return ""
+		}
 		for i := 0; i < len(name); i++ {
 			if name[i] == '.' {
 				return name[i+1:]
@@ -1057,20 +1393,71 @@ func (t *rawType) Key() Type {
 	return t.key()
 }
 
-func (t rawType) In(i int) Type {
-	panic("unimplemented: (reflect.Type).In()")
+func (t *rawType) In(i int) Type {
+	ft := t.funcType("In")
+	if uint(i) >= uint(ft.numIn) {
+		panic("reflect: Function index out of range")
+	}
+	return ft.param(i)
 }
 
-func (t rawType) Out(i int) Type {
-	panic("unimplemented: (reflect.Type).Out()")
+func (t *rawType) Out(i int) Type {
+	ft := t.funcType("Out")
+	if uint(i) >= uint(ft.numOut) {
+		panic("reflect: Function index out of range")
+	}
+	return ft.param(int(ft.numIn) + i)
 }
 
-func (t rawType) Method(i int) Method {
-	panic("unimplemented: (reflect.Type).Method()")
+func (t *rawType) Method(i int) Method {
+	if t.Kind() == Interface {
+		methods := t.underlying().interfaceMethods()
+		if uint(i) >= uint(len(methods)) {
+			panic("reflect: Method index out of range")
+		}
+		sig := (*methodSignature)(methods[i])
+		return Method{
+			Name:    sig.Name(),
+			PkgPath: sig.PkgPath(),
+			Type:    sig.typ,
+			Index:   i,
+		}
+	}
+
+	methods := t.methodTable()
+	if uint(i) >= uint(len(methods)) {
+		panic("reflect: Method index out of range")
+	}
+	m := &methods[i]
+	fn := m.fn
+	return Method{
+		Name: m.signature.Name(),
+		Type: m.typ,
+		Func: Value{
+			typecode: m.typ,
+			value:    unsafe.Pointer(&fn),
+			flags:    valueFlagExported,
+		},
+		Index: i,
+	}
 }
 
-func (t rawType) MethodByName(name string) (Method, bool) {
-	panic("unimplemented: (reflect.Type).MethodByName()")
+func (t *rawType) MethodByName(name string) (Method, bool) {
+	if t.Kind() == Interface {
+		for i, sig := range t.underlying().interfaceMethods() {
+			if (*methodSignature)(sig).Name() == name {
+				return t.Method(i), true
+			}
+		}
+		return Method{}, false
+	}
+
+	for i, m := range t.methodTable() {
+		if m.signature.Name() == name {
+			return t.Method(i), true
+		}
+	}
+	return Method{}, false
 }
 
 func (t *rawType) PkgPath() string {
@@ -1103,6 +1490,27 @@ func (t *rawType) FieldByName(name string) (StructField, bool) {
 	}, true
 }
 
+func (t *rawType) FieldByNameFunc(match func(string) bool) (StructField, bool) {
+	if t.Kind() != Struct {
+		panic(TypeError{"FieldByNameFunc"})
+	}
+
+	field, index, ok := t.rawFieldByNameFunc(match)
+	if !ok {
+		return StructField{}, false
+	}
+
+	return StructField{
+		Name:      field.Name,
+		PkgPath:   field.PkgPath,
+		Type:      field.Type, // note: converts rawType to Type
+		Tag:       field.Tag,
+		Anonymous: field.Anonymous,
+		Offset:    field.Offset,
+		Index:     index,
+	}, true
+}
+
 func (t *rawType) FieldByIndex(index []int) StructField {
 	ftype := t
 	var field rawStructField
@@ -1165,6 +1573,119 @@ type rawStructField struct {
 	Anonymous bool
 }
 
+// A StructFieldIter iterates over the fields of a struct type without
+// allocating, unlike Type.Field which allocates the Index slice of every
+// StructField it returns. The name, package path and tag of each field refer
+// directly to the type information of the program. See StructFields.
+//
+// This is a TinyGo extension, for use by code that needs to walk struct types
+// on systems with very little memory (such as serialization libraries).
+type StructFieldIter struct {
+	descriptor *structType
+	index      int
+	field      rawStructField
+}
+
+// StructFields returns an iterator over the fields of the struct type t. It
+// panics if t is not a struct type. Call Next to advance the iterator, and the
+// other methods to inspect the current field:
+//
+//	iter := reflect.StructFields(t)
+//	for iter.Next() {
+//		name := iter.Name()
+//		tag := iter.Tag()
+//		...
+//	}
+func StructFields(t Type) StructFieldIter {
+	rt := t.(*rawType)
+	if rt.Kind() != Struct {
+		panic(&TypeError{"StructFields"})
+	}
+	return StructFieldIter{
+		descriptor: (*structType)(unsafe.Pointer(rt.underlying())),
+		index:      -1,
+	}
+}
+
+// Next advances the iterator and reports whether there is another field. It
+// returns false when all fields have been visited.
+func (it *StructFieldIter) Next() bool {
+	if it.descriptor == nil {
+		panic("reflect: Next called on uninitialized StructFieldIter")
+	}
+	if it.index+1 >= int(it.descriptor.numField) {
+		it.index = int(it.descriptor.numField)
+		return false
+	}
+	it.index++
+	it.field = it.descriptor.rawField(it.index)
+	return true
+}
+
+func (it *StructFieldIter) current() *rawStructField {
+	if it.descriptor == nil || it.index < 0 || it.index >= int(it.descriptor.numField) {
+		panic("reflect: StructFieldIter has no current field")
+	}
+	return &it.field
+}
+
+// Index returns the index of the current field, as used by Value.Field.
+func (it *StructFieldIter) Index() int {
+	it.current()
+	return it.index
+}
+
+// Name returns the name of the current field.
+func (it *StructFieldIter) Name() string {
+	return it.current().Name
+}
+
+// PkgPath returns the package path of the current field if it is unexported,
+// or the empty string if it is exported.
+func (it *StructFieldIter) PkgPath() string {
+	return it.current().PkgPath
+}
+
+// IsExported reports whether the current field is exported.
+func (it *StructFieldIter) IsExported() bool {
+	return it.current().PkgPath == ""
+}
+
+// Type returns the type of the current field.
+func (it *StructFieldIter) Type() Type {
+	return it.current().Type
+}
+
+// Tag returns the tag string of the current field.
+func (it *StructFieldIter) Tag() StructTag {
+	return it.current().Tag
+}
+
+// Offset returns the offset of the current field within the struct, in bytes.
+func (it *StructFieldIter) Offset() uintptr {
+	return it.current().Offset
+}
+
+// Anonymous reports whether the current field is an embedded field.
+func (it *StructFieldIter) Anonymous() bool {
+	return it.current().Anonymous
+}
+
+// Field returns the current field as a StructField, like Type.Field. Unlike
+// the other methods, this allocates.
+func (it *StructFieldIter) Field() StructField {
+	field := it.current()
+	return StructField{
+		Name:      field.Name,
+		PkgPath:   field.PkgPath,
+		Type:      field.Type,
+		Tag:       field.Tag,
+		Anonymous: field.Anonymous,
+		Offset:    field.Offset,
+		Index:     []int{it.index},
+	}
+}
+
 // A StructTag is the tag string in a struct field.
 type StructTag string
 
@@ -1251,14 +1772,6 @@ func ArrayOf(n int, t Type) Type {
 	panic("unimplemented: reflect.ArrayOf()")
 }
 
-func StructOf([]StructField) Type {
-	panic("unimplemented: reflect.StructOf()")
-}
-
-func MapOf(key, value Type) Type {
-	panic("unimplemented: reflect.MapOf()")
-}
-
 const maxVarintLen32 = 5
 
 // encoding/binary.Uvarint, specialized for uint32
@@ -0,0 +1,313 @@
+package reflect
+
+// This file implements functions that create new types at runtime, like
+// StructOf. A type created at runtime must be identical (pointer equal) to the
+// same type elsewhere in the program, so these functions first look for an
+// existing type code before creating a new one.
+
+import (
+	"internal/itoa"
+	"runtime/interrupt"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// typecodes is a list of all type codes in the program. It is created by the
+// interface lowering pass, but only when it is used.
+//
+//go:extern reflect.typecodes
+var typecodes []*rawType
+
+// dynamicTypes is a list of all type codes created at runtime. It is protected
+// by disabling interrupts, like data structures in the runtime.
+var dynamicTypes []*rawType
+
+// findType returns the type for which match returns true, or nil if there is no
+// such type yet.
+func findType(match func(t *rawType) bool) *rawType {
+	for _, t := range typecodes {
+		if match(t) {
+			return t
+		}
+	}
+	// Types are only ever appended, so it is safe to search a copy of the
+	// slice.
+	mask := interrupt.Disable()
+	types := dynamicTypes
+	interrupt.Restore(mask)
+	for _, t := range types {
+		if match(t) {
+			return t
+		}
+	}
+	return nil
+}
+
+// addType registers a type created at runtime, so that findType can find it.
+// If another goroutine registered a matching type since findType was called,
+// that type is returned instead.
+func addType(t *rawType, match func(t *rawType) bool) *rawType {
+	mask := interrupt.Disable()
+	for _, existing := range dynamicTypes {
+		if match(existing) {
+			interrupt.Restore(mask)
+			return existing
+		}
+	}
+	dynamicTypes = append(dynamicTypes, t)
+	interrupt.Restore(mask)
+	return t
+}
+
+// newPointerType creates the pointer type *elem for a type that was created
+// at runtime.
+func newPointerType(elem *rawType) *rawType {
+	ptr := &ptrType{
+		rawType: rawType{meta: uint8(Pointer) | flagComparable | flagIsBinary},
+		elem:    elem,
+	}
+	return (*rawType)(unsafe.Pointer(ptr))
+}
+
+// StructOf returns the struct type containing fields. The Offset and Index
+// fields are ignored and computed as they would be by the compiler.
+//
+// Embedded fields must not have methods, because TinyGo can't create method
+// sets at runtime.
+func StructOf(fields []StructField) Type {
+	var (
+		offset   uintptr
+		maxAlign = 1
+		pkgpath  string
+		hasPkg   bool
+		meta     = uint8(Struct) | flagComparable | flagIsBinary
+		names    = make(map[string]struct{}, len(fields))
+		offsets  = make([]uintptr, len(fields))
+		metadata = make([]unsafe.Pointer, len(fields))
+	)
+	for i, field := range fields {
+		if field.Name == "" {
+			panic("reflect.StructOf: field " + itoa.Itoa(i) + " has no name")
+		}
+		if !isValidFieldName(field.Name) {
+			panic("reflect.StructOf: field " + itoa.Itoa(i) + " has invalid name")
+		}
+		if field.Type == nil {
+			panic("reflect.StructOf: field " + itoa.Itoa(i) + " has no type")
+		}
+		if _, dup := names[field.Name]; dup {
+			panic("reflect.StructOf: duplicate field " + field.Name)
+		}
+		names[field.Name] = struct{}{}
+
+		typ := field.Type.(*rawType)
+		var flags uint8
+		if field.Anonymous {
+			if typ.NumMethod() != 0 {
+				panic("reflect.StructOf: embedded field with methods not implemented")
+			}
+			flags |= structFieldFlagAnonymous | structFieldFlagIsEmbedded
+		}
+		if field.Tag != "" {
+			flags |= structFieldFlagHasTag
+		}
+		if field.PkgPath == "" {
+			// Best-effort check for misuse, like upstream Go.
+			if c := field.Name[0]; 'a' <= c && c <= 'z' || c == '_' {
+				panic("reflect.StructOf: field \"" + field.Name + "\" is unexported but missing PkgPath")
+			}
+			flags |= structFieldFlagIsExported
+		} else {
+			// All unexported fields share the package path of the struct.
+			if hasPkg && field.PkgPath != pkgpath {
+				panic("reflect.StructOf: unexported fields from different packages not implemented")
+			}
+			pkgpath = field.PkgPath
+			hasPkg = true
+		}
+
+		if !typ.Comparable() {
+			meta &^= flagComparable
+		}
+		if !typ.isBinary() {
+			meta &^= flagIsBinary
+		}
+
+		fieldAlign := typ.Align()
+		if fieldAlign > maxAlign {
+			maxAlign = fieldAlign
+		}
+		offset = align(offset, uintptr(fieldAlign))
+		offsets[i] = offset
+		offset += typ.Size()
+
+		// Encode the field information like the compiler does in
+		// compiler/interface.go.
+		var varintBytes [maxVarintLen32]byte
+		data := append([]byte{flags}, varintBytes[:putUvarint32(varintBytes[:], uint32(offset-typ.Size()))]...)
+		data = append(data, field.Name...)
+		data = append(data, 0)
+		if field.Tag != "" {
+			data = append(data, varintBytes[:putUvarint32(varintBytes[:], uint32(len(field.Tag)))]...)
+			data = append(data, field.Tag...)
+		}
+		metadata[i] = unsafe.Pointer(&data[0])
+	}
+	size := align(offset, uintptr(maxAlign))
+
+	// Check whether this struct type already exists.
+	match := func(t *rawType) bool {
+		if t.meta != meta || t.Size() != size || t.NumField() != len(fields) {
+			return false
+		}
+		if hasPkg && readStringZ(unsafe.Pointer((*structType)(unsafe.Pointer(t)).pkgpath)) != pkgpath {
+			return false
+		}
+		for i, field := range fields {
+			f := t.rawField(i)
+			if f.Name != field.Name || f.Type != field.Type.(*rawType) || f.Tag != field.Tag || f.Anonymous != field.Anonymous || f.Offset != offsets[i] {
+				return false
+			}
+		}
+		return true
+	}
+	if t := findType(match); t != nil {
+		return t
+	}
+
+	// Create a new struct type. The fields array is allocated together with the
+	// rest of the struct.
+	structSize := unsafe.Sizeof(structType{}) + uintptr(len(fields)-1)*unsafe.Sizeof(structField{})
+	if len(fields) == 0 {
+		structSize = unsafe.Sizeof(structType{})
+	}
+	st := (*structType)(alloc(structSize, nil))
+	st.meta = meta
+	st.pkgpath = stringPtr(pkgpath)
+	st.size = uint32(size)
+	st.numField = uint16(len(fields))
+	for i, field := range fields {
+		f := (*structField)(unsafe.Add(unsafe.Pointer(&st.fields[0]), uintptr(i)*unsafe.Sizeof(structField{})))
+		f.fieldType = field.Type.(*rawType)
+		f.data = metadata[i]
+	}
+	t := (*rawType)(unsafe.Pointer(st))
+	st.ptrTo = newPointerType(t)
+	return addType(t, match)
+}
+
+// stringPtr returns a pointer to a null terminated copy of s.
+func stringPtr(s string) *byte {
+	buf := make([]byte, len(s)+1)
+	copy(buf, s)
+	return &buf[0]
+}
+
+// putUvarint32 is the inverse of uvarint32: it encodes x into buf and returns
+// the number of bytes written.
+func putUvarint32(buf []byte, x uint32) int {
+	i := 0
+	for x >= 0x80 {
+		buf[i] = byte(x) | 0x80
+		x >>= 7
+		i++
+	}
+	buf[i] = byte(x)
+	return i + 1
+}
+
+// isValidFieldName checks if a string is a valid (struct) field name or not.
+//
+// According to the language spec, a field name should be an identifier.
+//
+// identifier = letter { letter | unicode_digit } .
+// letter = unicode_letter | "_" .
+func isValidFieldName(fieldName string) bool {
+	for i, c := range fieldName {
+		if i == 0 && !isLetter(c) {
+			return false
+		}
+
+		if !(isLetter(c) || unicode.IsDigit(c)) {
+			return false
+		}
+	}
+
+	return len(fieldName) > 0
+}
+
+// isLetter reports whether a given 'rune' is classified as a Letter.
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch >= utf8.RuneSelf && unicode.IsLetter(ch)
+}
+
+// MapOf returns the map type with the given key and element types. For
+// example, if k represents int and e represents string, MapOf(k, e) represents
+// map[int]string.
+//
+// If the key type is not a valid map key type (that is, if it does not
+// implement Go's == operator), MapOf panics.
+func MapOf(key, elem Type) Type {
+	k := key.(*rawType)
+	e := elem.(*rawType)
+	if !k.Comparable() {
+		panic("reflect.MapOf: invalid key type " + k.String())
+	}
+
+	// Look for an existing map type, so that the key type check in MapIndex
+	// and friends works on the returned type. The hashmap algorithm is
+	// picked from the key type in MakeMapWithSize, so it is the same for
+	// both.
+	match := func(t *rawType) bool {
+		if t.meta != uint8(Map) {
+			return false
+		}
+		mt := (*mapType)(unsafe.Pointer(t))
+		return mt.key == k && mt.elem == e
+	}
+	if t := findType(match); t != nil {
+		return t
+	}
+
+	mt := &mapType{
+		rawType: rawType{meta: uint8(Map)},
+		elem:    e,
+		key:     k,
+	}
+	t := (*rawType)(unsafe.Pointer(mt))
+	mt.ptrTo = newPointerType(t)
+	return addType(t, match)
+}
+
+// ChanOf returns the channel type with the given direction and element type.
+// For example, if t represents int, ChanOf(RecvDir, t) represents <-chan int.
+func ChanOf(dir ChanDir, t Type) Type {
+	elem := t.(*rawType)
+	switch dir {
+	case SendDir, RecvDir, BothDir:
+	default:
+		panic("reflect.ChanOf: invalid dir")
+	}
+
+	// Look for an existing channel type. The direction is stored in the
+	// numMethod field, like the compiler does.
+	match := func(t *rawType) bool {
+		if t.meta != uint8(Chan)|flagComparable {
+			return false
+		}
+		ct := (*elemType)(unsafe.Pointer(t))
+		return ct.elem == elem && ChanDir(ct.numMethod) == dir
+	}
+	if t := findType(match); t != nil {
+		return t
+	}
+
+	ct := &elemType{
+		rawType:   rawType{meta: uint8(Chan) | flagComparable},
+		numMethod: uint16(dir),
+		elem:      elem,
+	}
+	ct.ptrTo = newPointerType((*rawType)(unsafe.Pointer(ct)))
+	return addType((*rawType)(unsafe.Pointer(ct)), match)
+}
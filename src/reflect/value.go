@@ -1,6 +1,7 @@
 package reflect
 
 import (
+	"internal/itoa"
 	"math"
 	"unsafe"
 )
@@ -16,6 +17,7 @@ const (
 	valueFlagExported
 	valueFlagEmbedRO
 	valueFlagStickyRO
+	valueFlagMethod // method value created by Value.Method
 
 	valueFlagRO = valueFlagEmbedRO | valueFlagStickyRO
 )
@@ -187,7 +189,7 @@ func (v Value) IsNil() bool {
 		val := *(*interface{})(v.value)
 		return val == nil
 	default:
-		panic(&ValueError{Method: "IsNil", Kind: v.Kind()})
+		panic(v.kindError("IsNil", nillableKinds))
 	}
 }
 
@@ -199,6 +201,11 @@ func (v Value) Pointer() uintptr {
 
 // UnsafePointer returns the underlying pointer of the given value for the
 // following types: chan, map, pointer, unsafe.Pointer, slice, func.
+//
+// If v's Kind is Func, the returned pointer is an underlying code pointer, but
+// not necessarily enough to identify a single function uniquely. For a method
+// value (created by Value.Method, or like obj.M on a concrete type), it is the
+// code pointer of the method.
 func (v Value) UnsafePointer() unsafe.Pointer {
 	switch v.Kind() {
 	case Chan, Map, Ptr, UnsafePointer:
@@ -208,15 +215,30 @@ func (v Value) UnsafePointer() unsafe.Pointer {
 		return slice.data
 	case Func:
 		fn := (*funcHeader)(v.value)
-		if fn.Context != nil {
-			return fn.Context
+		if v.flags&valueFlagMethod != 0 {
+			return (*makeFuncImpl)(fn.Context).method
+		}
+		for _, entry := range boundMethods {
+			if entry.wrapper == fn.Code {
+				return entry.method
+			}
 		}
 		return fn.Code
 	default:
-		panic(&ValueError{Method: "UnsafePointer", Kind: v.Kind()})
+		panic(v.kindError("UnsafePointer", 1<<Chan|1<<Func|1<<Map|1<<Pointer|1<<Slice|1<<UnsafePointer))
 	}
 }
 
+// boundMethods contains the wrapper functions that the compiler creates for
+// method values like obj.M, together with the method they call. It is created
+// by the interface lowering pass, but only when it is used.
+//
+//go:extern reflect.boundMethods
+var boundMethods []struct {
+	wrapper unsafe.Pointer
+	method  unsafe.Pointer
+}
+
 // pointer returns the underlying pointer represented by v.
 // v.Kind() must be Ptr, Map, Chan, or UnsafePointer
 func (v Value) pointer() unsafe.Pointer {
@@ -257,7 +279,7 @@ func (v Value) Comparable() bool {
 		return v.Type().Comparable()
 
 	case Interface:
-		return v.Elem().Comparable()
+		return v.IsNil() || v.Elem().Comparable()
 
 	case Struct:
 		for i := 0; i < v.NumField(); i++ {
@@ -272,6 +294,74 @@ func (v Value) Comparable() bool {
 	}
 }
 
+// Equal reports true if v is equal to u.
+// For two invalid values, Equal will report true.
+// For an interface value, Equal will compare the value within the interface.
+// Otherwise, If the values have different types, Equal will report false.
+// Otherwise, for arrays and structs Equal will compare each element in order,
+// and report false if it finds non-equal elements.
+// During all comparisons, if values of the same type are compared,
+// and the type is not comparable, Equal will panic.
+func (v Value) Equal(u Value) bool {
+	if v.Kind() == Interface {
+		v = v.Elem()
+	}
+	if u.Kind() == Interface {
+		u = u.Elem()
+	}
+
+	if !v.IsValid() || !u.IsValid() {
+		return v.IsValid() == u.IsValid()
+	}
+
+	if v.typecode != u.typecode {
+		return false
+	}
+
+	switch v.Kind() {
+	case Bool:
+		return v.Bool() == u.Bool()
+	case Int, Int8, Int16, Int32, Int64:
+		return v.Int() == u.Int()
+	case Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+		return v.Uint() == u.Uint()
+	case Float32, Float64:
+		return v.Float() == u.Float()
+	case Complex64, Complex128:
+		return v.Complex() == u.Complex()
+	case String:
+		return v.String() == u.String()
+	case Chan, Pointer, UnsafePointer:
+		return v.pointer() == u.pointer()
+	case Array:
+		// u and v have the same type so they have the same length
+		vl := v.Len()
+		if vl == 0 {
+			// panic on [0]func()
+			if !v.typecode.elem().Comparable() {
+				break
+			}
+			return true
+		}
+		for i := 0; i < vl; i++ {
+			if !v.Index(i).Equal(u.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case Struct:
+		// u and v have the same type so they have the same fields
+		nf := v.NumField()
+		for i := 0; i < nf; i++ {
+			if !v.Field(i).Equal(u.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	panic("reflect.Value.Equal: values of type " + v.typecode.String() + " are not comparable")
+}
+
 func (v Value) Addr() Value {
 	if !v.CanAddr() {
 		panic("reflect.Value.Addr of unaddressable value")
@@ -303,7 +393,7 @@ func (v Value) Bool() bool {
 			return uintptr(v.value) != 0
 		}
 	default:
-		panic(&ValueError{Method: "Bool", Kind: v.Kind()})
+		panic(v.kindError("Bool", 1<<Bool))
 	}
 }
 
@@ -350,7 +440,7 @@ func (v Value) Int() int64 {
 			return int64(int64(uintptr(v.value)))
 		}
 	default:
-		panic(&ValueError{Method: "Int", Kind: v.Kind()})
+		panic(v.kindError("Int", intKinds))
 	}
 }
 
@@ -403,7 +493,7 @@ func (v Value) Uint() uint64 {
 			return uint64(uintptr(v.value))
 		}
 	default:
-		panic(&ValueError{Method: "Uint", Kind: v.Kind()})
+		panic(v.kindError("Uint", uintKinds))
 	}
 }
 
@@ -435,7 +525,7 @@ func (v Value) Float32() float32 {
 
 	}
 
-	panic(&ValueError{Method: "Float", Kind: v.Kind()})
+	panic(v.kindError("Float", floatKinds))
 }
 
 func (v Value) Float() float64 {
@@ -460,7 +550,7 @@ func (v Value) Float() float64 {
 			return *(*float64)(unsafe.Pointer(&v.value))
 		}
 	default:
-		panic(&ValueError{Method: "Float", Kind: v.Kind()})
+		panic(v.kindError("Float", floatKinds))
 	}
 }
 
@@ -492,7 +582,7 @@ func (v Value) Complex() complex128 {
 		// architectures with 128-bit pointers, however.
 		return *(*complex128)(v.value)
 	default:
-		panic(&ValueError{Method: "Complex", Kind: v.Kind()})
+		panic(v.kindError("Complex", complexKinds))
 	}
 }
 
@@ -512,7 +602,7 @@ func (v Value) Bytes() []byte {
 	switch v.Kind() {
 	case Slice:
 		if v.typecode.elem().Kind() != Uint8 {
-			panic(&ValueError{Method: "Bytes", Kind: v.Kind()})
+			panic(v.kindError("Bytes", 0))
 		}
 		return *(*[]byte)(v.value)
 
@@ -520,16 +610,28 @@ func (v Value) Bytes() []byte {
 		v.checkAddressable()
 
 		if v.typecode.elem().Kind() != Uint8 {
-			panic(&ValueError{Method: "Bytes", Kind: v.Kind()})
+			panic(v.kindError("Bytes", 0))
 		}
 
 		// Small inline arrays are not addressable, so we only have to
 		// handle addressable arrays which will be stored as pointers
 		// in v.value
 		return unsafe.Slice((*byte)(v.value), v.Len())
+
+	case Pointer:
+		// Pointer to a byte array, like *[32]byte.
+		elem := v.typecode.elem()
+		if elem.Kind() != Array || elem.elem().Kind() != Uint8 {
+			panic(v.kindError("Bytes", 0))
+		}
+		ptr := v.pointer()
+		if ptr == nil {
+			return nil
+		}
+		return unsafe.Slice((*byte)(ptr), elem.Len())
 	}
 
-	panic(&ValueError{Method: "Bytes", Kind: v.Kind()})
+	panic(v.kindError("Bytes", 1<<Array|1<<Pointer|1<<Slice))
 }
 
 func (v Value) Slice(i, j int) Value {
@@ -546,7 +648,11 @@ func (v Value) Slice(i, j int) Value {
 
 		hdr.len = j - i
 		hdr.cap = hdr.cap - i
-		hdr.data = unsafe.Add(hdr.data, i*elemSize)
+		if hdr.cap != 0 {
+			// Do not advance the pointer when the capacity is zero, to avoid
+			// pointing beyond the end of the slice.
+			hdr.data = unsafe.Add(hdr.data, i*elemSize)
+		}
 
 		return Value{
 			typecode: v.typecode,
@@ -555,8 +661,10 @@ func (v Value) Slice(i, j int) Value {
 		}
 
 	case Array:
-		// TODO(dgryski): can't do this yet because the resulting value needs type slice of v.elem(), not array of v.elem().
-		// need to be able to look up this "new" type so pointer equality of types still works
+		if !v.isIndirect() {
+			panic("reflect.Value.Slice: slice of unaddressable array")
+		}
+		return v.sliceArray(uintptr(i), uintptr(j), uintptr(v.typecode.Len()))
 
 	case String:
 		i, j := uintptr(i), uintptr(j)
@@ -578,7 +686,7 @@ func (v Value) Slice(i, j int) Value {
 		}
 	}
 
-	panic(&ValueError{Method: "Slice", Kind: v.Kind()})
+	panic(v.kindError("Slice", 1<<Array|1<<Slice|1<<String))
 }
 
 func (v Value) Slice3(i, j, k int) Value {
@@ -587,7 +695,7 @@ func (v Value) Slice3(i, j, k int) Value {
 		hdr := *(*sliceHeader)(v.value)
 		i, j, k := uintptr(i), uintptr(j), uintptr(k)
 
-		if j < i || k < j || hdr.len < k {
+		if j < i || k < j || hdr.cap < k {
 			slicePanic()
 		}
 
@@ -595,7 +703,11 @@ func (v Value) Slice3(i, j, k int) Value {
 
 		hdr.len = j - i
 		hdr.cap = k - i
-		hdr.data = unsafe.Add(hdr.data, i*elemSize)
+		if hdr.cap != 0 {
+			// Do not advance the pointer when the capacity is zero, to avoid
+			// pointing beyond the end of the slice.
+			hdr.data = unsafe.Add(hdr.data, i*elemSize)
+		}
 
 		return Value{
 			typecode: v.typecode,
@@ -604,12 +716,37 @@ func (v Value) Slice3(i, j, k int) Value {
 		}
 
 	case Array:
-		// TODO(dgryski): can't do this yet because the resulting value needs type v.elem(), not array of v.elem().
-		// need to be able to look up this "new" type so pointer equality of types still works
+		if !v.isIndirect() {
+			panic("reflect.Value.Slice3: slice of unaddressable array")
+		}
+		return v.sliceArray(uintptr(i), uintptr(j), uintptr(k))
+	}
 
+	panic(v.kindError("Slice3", 1<<Array|1<<Slice))
+}
+
+// sliceArray returns the slice array[i:j:k] of the addressable array v. The
+// slice type is stored in the array type, so that it is the same type as the
+// slice type of the same element type elsewhere in the program.
+func (v Value) sliceArray(i, j, k uintptr) Value {
+	if j < i || k < j || uintptr(v.typecode.Len()) < k {
+		slicePanic()
 	}
 
-	panic("unimplemented: (reflect.Value).Slice3()")
+	array := (*arrayType)(unsafe.Pointer(v.typecode.underlying()))
+	hdr := sliceHeader{
+		data: v.value,
+		len:  j - i,
+		cap:  k - i,
+	}
+	if hdr.cap != 0 {
+		hdr.data = unsafe.Add(hdr.data, i*array.elem.Size())
+	}
+	return Value{
+		typecode: array.sliceOf,
+		value:    unsafe.Pointer(&hdr),
+		flags:    v.flags &^ valueFlagIndirect,
+	}
 }
 
 //go:linkname maplen runtime.hashmapLenUnsafePointer
@@ -618,8 +755,8 @@ func maplen(p unsafe.Pointer) int
 //go:linkname chanlen runtime.chanLenUnsafePointer
 func chanlen(p unsafe.Pointer) int
 
-// Len returns the length of this value for slices, strings, arrays, channels,
-// and maps. For other types, it panics.
+// Len returns the length of this value for slices, strings, arrays, pointers
+// to arrays, channels, and maps. For other types, it panics.
 func (v Value) Len() int {
 	switch v.typecode.Kind() {
 	case Array:
@@ -628,30 +765,42 @@ func (v Value) Len() int {
 		return chanlen(v.pointer())
 	case Map:
 		return maplen(v.pointer())
+	case Pointer:
+		// Like the len builtin, the length of a pointer to an array is the
+		// length of the array.
+		if elem := v.typecode.elem(); elem.Kind() == Array {
+			return elem.Len()
+		}
+		panic("reflect: call of reflect.Value.Len on ptr to non-array Value")
 	case Slice:
 		return int((*sliceHeader)(v.value).len)
 	case String:
 		return int((*stringHeader)(v.value).len)
 	default:
-		panic(&ValueError{Method: "Len", Kind: v.Kind()})
+		panic(v.kindError("Len", 1<<Array|1<<Chan|1<<Map|1<<Pointer|1<<Slice|1<<String))
 	}
 }
 
 //go:linkname chancap runtime.chanCapUnsafePointer
 func chancap(p unsafe.Pointer) int
 
-// Cap returns the capacity of this value for arrays, channels and slices.
-// For other types, it panics.
+// Cap returns the capacity of this value for arrays, pointers to arrays,
+// channels and slices. For other types, it panics.
 func (v Value) Cap() int {
 	switch v.typecode.Kind() {
 	case Array:
 		return v.typecode.Len()
 	case Chan:
 		return chancap(v.pointer())
+	case Pointer:
+		if elem := v.typecode.elem(); elem.Kind() == Array {
+			return elem.Len()
+		}
+		panic("reflect: call of reflect.Value.Cap on ptr to non-array Value")
 	case Slice:
 		return int((*sliceHeader)(v.value).cap)
 	default:
-		panic(&ValueError{Method: "Cap", Kind: v.Kind()})
+		panic(v.kindError("Cap", 1<<Array|1<<Chan|1<<Pointer|1<<Slice))
 	}
 }
 
@@ -683,14 +832,14 @@ func (v Value) Elem() Value {
 			flags:    v.flags &^ valueFlagIndirect,
 		}
 	default:
-		panic(&ValueError{Method: "Elem", Kind: v.Kind()})
+		panic(v.kindError("Elem", 1<<Interface|1<<Pointer))
 	}
 }
 
 // Field returns the value of the i'th field of this struct.
 func (v Value) Field(i int) Value {
 	if v.Kind() != Struct {
-		panic(&ValueError{Method: "Field", Kind: v.Kind()})
+		panic(v.kindError("Field", 1<<Struct))
 	}
 	structField := v.typecode.rawField(i)
 
@@ -799,14 +948,19 @@ func (v Value) Index(i int) Value {
 		}
 	case Array:
 		// Extract an element from the array.
+		if uint(i) >= uint(v.typecode.Len()) {
+			panic("reflect: array index out of range")
+		}
 		elemType := v.typecode.elem()
 		elemSize := elemType.Size()
 		size := v.typecode.Size()
 		if size == 0 {
-			// The element size is 0 and/or the length of the array is 0.
+			// The element size is 0. Keep the pointer to the array (if any)
+			// so that the element is still addressable.
 			return Value{
 				typecode: v.typecode.elem(),
 				flags:    v.flags,
+				value:    v.value,
 			}
 		}
 		if elemSize > unsafe.Sizeof(uintptr(0)) {
@@ -849,7 +1003,7 @@ func (v Value) Index(i int) Value {
 			value:    unsafe.Pointer(value),
 		}
 	default:
-		panic(&ValueError{Method: "Index", Kind: v.Kind()})
+		panic(v.kindError("Index", 1<<Array|1<<Slice|1<<String))
 	}
 }
 
@@ -874,9 +1028,28 @@ func maskAndShift(value, offset, size uintptr) uintptr {
 }
 
 func (v Value) NumMethod() int {
+	if v.typecode == nil {
+		panic(&ValueError{Method: "reflect.Value.NumMethod", Kind: Invalid})
+	}
+	if v.flags&valueFlagMethod != 0 {
+		return 0
+	}
 	return v.typecode.NumMethod()
 }
 
+// OverflowComplex reports whether the complex128 x cannot be represented by v's
+// type. It panics if v's Kind is not Complex64 or Complex128.
+func (v Value) OverflowComplex(x complex128) bool {
+	k := v.Kind()
+	switch k {
+	case Complex64:
+		return overflowFloat32(real(x)) || overflowFloat32(imag(x))
+	case Complex128:
+		return false
+	}
+	panic(v.kindError("reflect.Value.OverflowComplex", complexKinds))
+}
+
 // OverflowFloat reports whether the float64 x cannot be represented by v's type.
 // It panics if v's Kind is not Float32 or Float64.
 func (v Value) OverflowFloat(x float64) bool {
@@ -887,7 +1060,7 @@ func (v Value) OverflowFloat(x float64) bool {
 	case Float64:
 		return false
 	}
-	panic(&ValueError{Method: "reflect.Value.OverflowFloat", Kind: v.Kind()})
+	panic(v.kindError("reflect.Value.OverflowFloat", floatKinds))
 }
 
 func overflowFloat32(x float64) bool {
@@ -899,7 +1072,7 @@ func overflowFloat32(x float64) bool {
 
 func (v Value) MapKeys() []Value {
 	if v.Kind() != Map {
-		panic(&ValueError{Method: "MapKeys", Kind: v.Kind()})
+		panic(v.kindError("MapKeys", 1<<Map))
 	}
 
 	// empty map
@@ -907,24 +1080,37 @@ func (v Value) MapKeys() []Value {
 		return nil
 	}
 
-	keys := make([]Value, 0, v.Len())
+	n := v.Len()
+	keys := make([]Value, 0, n)
 
 	it := hashmapNewIterator()
-	k := New(v.typecode.Key())
-	e := New(v.typecode.Elem())
+	e := alloc(v.typecode.elem().Size(), nil)
 
-	keyType := v.typecode.Key().(*rawType)
+	keyType := v.typecode.key()
 	isKeyStoredAsInterface := keyType.Kind() != String && !keyType.isBinary()
 
-	for hashmapNext(v.pointer(), it, k.value, e.value) {
-		if isKeyStoredAsInterface {
-			intf := *(*interface{})(k.value)
-			v := ValueOf(intf)
-			keys = append(keys, v)
-		} else {
-			keys = append(keys, k.Elem())
+	if isKeyStoredAsInterface {
+		var intf interface{}
+		for hashmapNext(v.pointer(), it, unsafe.Pointer(&intf), e) {
+			keys = append(keys, ValueOf(intf))
+		}
+		return keys
+	}
+
+	// Copy all keys into a single buffer, instead of allocating each key
+	// separately.
+	keySize := keyType.Size()
+	buf := alloc(keySize*uintptr(n), nil)
+	for i := 0; i < n; i++ {
+		k := unsafe.Add(buf, uintptr(i)*keySize)
+		if !hashmapNext(v.pointer(), it, k, e) {
+			break
 		}
-		k = New(v.typecode.Key())
+		keys = append(keys, Value{
+			typecode: keyType,
+			value:    k,
+			flags:    valueFlagExported | valueFlagIndirect | v.flags.ro(),
+		})
 	}
 
 	return keys
@@ -941,7 +1127,7 @@ func hashmapInterfaceGet(m unsafe.Pointer, key interface{}, value unsafe.Pointer
 
 func (v Value) MapIndex(key Value) Value {
 	if v.Kind() != Map {
-		panic(&ValueError{Method: "MapIndex", Kind: v.Kind()})
+		panic(v.kindError("MapIndex", 1<<Map))
 	}
 
 	vkey := v.typecode.key()
@@ -952,31 +1138,86 @@ func (v Value) MapIndex(key Value) Value {
 		panic("reflect.Value.MapIndex: incompatible types for key")
 	}
 
-	elemType := v.typecode.Elem()
-	elem := New(elemType)
+	// Elements that fit in a pointer are read into a local variable and
+	// stored directly in the returned Value, so that they don't need a heap
+	// allocation.
+	elemType := v.typecode.elem()
+	elemSize := elemType.Size()
+	var small uintptr
+	elem := unsafe.Pointer(&small)
+	if elemSize > unsafe.Sizeof(small) {
+		elem = alloc(elemSize, nil)
+	}
 
+	var ok bool
 	if vkey.Kind() == String {
-		if ok := hashmapStringGet(v.pointer(), *(*string)(key.value), elem.value, elemType.Size()); !ok {
-			return Value{}
-		}
-		return elem.Elem()
+		ok = hashmapStringGet(v.pointer(), *(*string)(key.value), elem, elemSize)
 	} else if vkey.isBinary() {
-		var keyptr unsafe.Pointer
-		if key.isIndirect() || key.typecode.Size() > unsafe.Sizeof(uintptr(0)) {
-			keyptr = key.value
-		} else {
-			keyptr = unsafe.Pointer(&key.value)
-		}
-		//TODO(dgryski): zero out padding bytes in key, if any
-		if ok := hashmapBinaryGet(v.pointer(), keyptr, elem.value, elemType.Size()); !ok {
-			return Value{}
-		}
-		return elem.Elem()
+		ok = hashmapBinaryGet(v.pointer(), key.binaryKey(), elem, elemSize)
 	} else {
-		if ok := hashmapInterfaceGet(v.pointer(), key.Interface(), elem.value, elemType.Size()); !ok {
-			return Value{}
+		ok = hashmapInterfaceGet(v.pointer(), key.Interface(), elem, elemSize)
+	}
+	if !ok {
+		return Value{}
+	}
+	flags := valueFlagExported | (v.flags | key.flags).ro()
+	if elemSize > unsafe.Sizeof(small) {
+		return Value{typecode: elemType, value: elem, flags: flags}
+	}
+	return Value{typecode: elemType, value: unsafe.Pointer(loadValue(elem, elemSize)), flags: flags}
+}
+
+// binaryKey returns a pointer to the map key v, for use with the
+// hashmapBinary* functions. These functions hash and compare all bytes of the
+// key, so padding bytes in the key are zeroed like the compiler does.
+func (v Value) binaryKey() unsafe.Pointer {
+	var keyptr unsafe.Pointer
+	if v.isIndirect() || v.typecode.Size() > unsafe.Sizeof(uintptr(0)) {
+		keyptr = v.value
+	} else {
+		keyptr = unsafe.Pointer(&v.value)
+	}
+	if !v.typecode.mayHavePadding() {
+		return keyptr
+	}
+	key := alloc(v.typecode.Size(), nil)
+	copyWithoutPadding(key, keyptr, v.typecode)
+	return key
+}
+
+// mayHavePadding returns whether values of type t may contain padding bytes.
+// This is only true for structs, and arrays of them.
+func (t *rawType) mayHavePadding() bool {
+	switch t.Kind() {
+	case Struct:
+		return true
+	case Array:
+		return t.elem().mayHavePadding()
+	default:
+		return false
+	}
+}
+
+// copyWithoutPadding copies a value of type t from src to dst, which must be
+// zeroed memory. Padding bytes in src are not copied, so they remain zero in
+// dst.
+func copyWithoutPadding(dst, src unsafe.Pointer, t *rawType) {
+	if !t.mayHavePadding() {
+		memcpy(dst, src, t.Size())
+		return
+	}
+	switch t.Kind() {
+	case Struct:
+		for i, n := 0, t.NumField(); i < n; i++ {
+			field := t.rawField(i)
+			copyWithoutPadding(unsafe.Add(dst, field.Offset), unsafe.Add(src, field.Offset), field.Type)
+		}
+	case Array:
+		elem := t.elem()
+		for i, n := uintptr(0), uintptr(t.Len()); i < n; i++ {
+			offset := i * elem.Size()
+			copyWithoutPadding(unsafe.Add(dst, offset), unsafe.Add(src, offset), elem)
 		}
-		return elem.Elem()
 	}
 }
 
@@ -988,7 +1229,7 @@ func hashmapNext(m unsafe.Pointer, it unsafe.Pointer, key, value unsafe.Pointer)
 
 func (v Value) MapRange() *MapIter {
 	if v.Kind() != Map {
-		panic(&ValueError{Method: "MapRange", Kind: v.Kind()})
+		panic(v.kindError("MapRange", 1<<Map))
 	}
 
 	keyType := v.typecode.key()
@@ -1001,46 +1242,106 @@ func (v Value) MapRange() *MapIter {
 	}
 }
 
+// A MapIter is an iterator for ranging over a map. See Value.MapRange.
+//
+// The key and value of the current entry are stored in buffers that are
+// allocated on the first call to Next and reused afterwards, so that iterating
+// over a map doesn't allocate for every entry.
 type MapIter struct {
 	m   Value
 	it  unsafe.Pointer
-	key Value
-	val Value
+	key unsafe.Pointer
+	val unsafe.Pointer
 
 	valid        bool
 	keyInterface bool
 }
 
+// Key returns the key of iter's current map entry.
 func (it *MapIter) Key() Value {
 	if !it.valid {
 		panic("reflect.MapIter.Key called on invalid iterator")
 	}
 
-	if it.keyInterface {
-		intf := *(*interface{})(it.key.value)
-		v := ValueOf(intf)
-		return v
+	keyType := it.m.typecode.key()
+	if it.keyInterface && keyType.Kind() != Interface {
+		// The key is stored as an interface with the dynamic type keyType.
+		return ValueOf(*(*interface{})(it.key))
 	}
-
-	return it.key.Elem()
+	return loadResult(keyType, it.key, valueFlagExported|it.m.flags.ro())
 }
 
+// Value returns the value of iter's current map entry.
 func (it *MapIter) Value() Value {
 	if !it.valid {
 		panic("reflect.MapIter.Value called on invalid iterator")
 	}
 
-	return it.val.Elem()
+	return loadResult(it.m.typecode.elem(), it.val, valueFlagExported|it.m.flags.ro())
 }
 
+// Next advances the map iterator and reports whether there is another entry.
+// It returns false when iter is exhausted.
 func (it *MapIter) Next() bool {
-	it.key = New(it.m.typecode.Key())
-	it.val = New(it.m.typecode.Elem())
+	if it.key == nil {
+		keySize := it.m.typecode.key().Size()
+		if it.keyInterface {
+			keySize = unsafe.Sizeof(interface{}(nil))
+		}
+		it.key = alloc(keySize, nil)
+		it.val = alloc(it.m.typecode.elem().Size(), nil)
+	}
 
-	it.valid = hashmapNext(it.m.pointer(), it.it, it.key.value, it.val.value)
+	it.valid = hashmapNext(it.m.pointer(), it.it, it.key, it.val)
 	return it.valid
 }
 
+// SetIterKey assigns to v the key of iter's current map entry. It is
+// equivalent to v.Set(iter.Key()), but it avoids allocating a new Value.
+func (v Value) SetIterKey(iter *MapIter) {
+	if !iter.valid {
+		panic("reflect: Value.SetIterKey called before Next")
+	}
+
+	keyType := iter.m.typecode.key()
+	if iter.keyInterface && keyType.Kind() != Interface {
+		v.Set(ValueOf(*(*interface{})(iter.key)))
+		return
+	}
+	v.Set(Value{
+		typecode: keyType,
+		value:    iter.key,
+		flags:    valueFlagExported | valueFlagIndirect | iter.m.flags.ro(),
+	})
+}
+
+// SetIterValue assigns to v the value of iter's current map entry. It is
+// equivalent to v.Set(iter.Value()), but it avoids allocating a new Value.
+func (v Value) SetIterValue(iter *MapIter) {
+	if !iter.valid {
+		panic("reflect: Value.SetIterValue called before Next")
+	}
+
+	v.Set(Value{
+		typecode: iter.m.typecode.elem(),
+		value:    iter.val,
+		flags:    valueFlagExported | valueFlagIndirect | iter.m.flags.ro(),
+	})
+}
+
+// loadResult returns a Value of type typ that holds a copy of the value at ptr.
+// Like other values, it is stored directly in the Value when it fits in a
+// pointer, so that only larger values need a heap allocation.
+func loadResult(typ *rawType, ptr unsafe.Pointer, flags valueFlags) Value {
+	size := typ.Size()
+	if size <= unsafe.Sizeof(uintptr(0)) {
+		return Value{typecode: typ, value: unsafe.Pointer(loadValue(ptr, size)), flags: flags}
+	}
+	value := alloc(size, nil)
+	memcpy(value, ptr, size)
+	return Value{typecode: typ, value: value, flags: flags}
+}
+
 func (v Value) Set(x Value) {
 	v.checkAddressable()
 	v.checkRO()
@@ -1049,11 +1350,9 @@ func (v Value) Set(x Value) {
 	}
 
 	if v.typecode.Kind() == Interface && x.typecode.Kind() != Interface {
-		intf := composeInterface(unsafe.Pointer(x.typecode), x.value)
-		x = Value{
-			typecode: v.typecode,
-			value:    unsafe.Pointer(&intf),
-		}
+		// Store a copy of x in an interface. This also takes care of loading
+		// small values that are stored indirectly.
+		x = cvtT2I(x, v.typecode)
 	}
 
 	size := v.typecode.Size()
@@ -1072,7 +1371,7 @@ func (v Value) SetBool(x bool) {
 	case Bool:
 		*(*bool)(v.value) = x
 	default:
-		panic(&ValueError{Method: "SetBool", Kind: v.Kind()})
+		panic(v.kindError("SetBool", 1<<Bool))
 	}
 }
 
@@ -1091,7 +1390,7 @@ func (v Value) SetInt(x int64) {
 	case Int64:
 		*(*int64)(v.value) = x
 	default:
-		panic(&ValueError{Method: "SetInt", Kind: v.Kind()})
+		panic(v.kindError("SetInt", intKinds))
 	}
 }
 
@@ -1112,7 +1411,7 @@ func (v Value) SetUint(x uint64) {
 	case Uintptr:
 		*(*uintptr)(v.value) = uintptr(x)
 	default:
-		panic(&ValueError{Method: "SetUint", Kind: v.Kind()})
+		panic(v.kindError("SetUint", uintKinds))
 	}
 }
 
@@ -1125,7 +1424,7 @@ func (v Value) SetFloat(x float64) {
 	case Float64:
 		*(*float64)(v.value) = x
 	default:
-		panic(&ValueError{Method: "SetFloat", Kind: v.Kind()})
+		panic(v.kindError("SetFloat", floatKinds))
 	}
 }
 
@@ -1138,7 +1437,7 @@ func (v Value) SetComplex(x complex128) {
 	case Complex128:
 		*(*complex128)(v.value) = x
 	default:
-		panic(&ValueError{Method: "SetComplex", Kind: v.Kind()})
+		panic(v.kindError("SetComplex", complexKinds))
 	}
 }
 
@@ -1149,7 +1448,7 @@ func (v Value) SetString(x string) {
 	case String:
 		*(*string)(v.value) = x
 	default:
-		panic(&ValueError{Method: "SetString", Kind: v.Kind()})
+		panic(v.kindError("SetString", 1<<String))
 	}
 }
 
@@ -1164,13 +1463,26 @@ func (v Value) SetBytes(x []byte) {
 	*(*[]byte)(v.value) = x
 }
 
+// SetPointer sets the unsafe.Pointer value v to x. It panics if v's Kind is
+// not UnsafePointer.
+func (v Value) SetPointer(x unsafe.Pointer) {
+	v.checkAddressable()
+	v.checkRO()
+	switch v.Kind() {
+	case UnsafePointer:
+		*(*unsafe.Pointer)(v.value) = x
+	default:
+		panic(v.kindError("SetPointer", 1<<UnsafePointer))
+	}
+}
+
 func (v Value) SetCap(n int) {
 	panic("unimplemented: (reflect.Value).SetCap()")
 }
 
 func (v Value) SetLen(n int) {
 	if v.typecode.Kind() != Slice {
-		panic(&ValueError{Method: "reflect.Value.SetLen", Kind: v.Kind()})
+		panic(v.kindError("reflect.Value.SetLen", 1<<Slice))
 	}
 	v.checkAddressable()
 	hdr := (*sliceHeader)(v.value)
@@ -1180,6 +1492,31 @@ func (v Value) SetLen(n int) {
 	hdr.len = uintptr(n)
 }
 
+// Grow increases the slice's capacity, if necessary, to guarantee space for
+// another n elements. After Grow(n), at least n elements can be appended
+// to the slice without another allocation.
+//
+// It panics if v's Kind is not a Slice or if n is negative or too large to
+// allocate the memory.
+func (v Value) Grow(n int) {
+	if v.typecode.Kind() != Slice {
+		panic(v.kindError("reflect.Value.Grow", 1<<Slice))
+	}
+	v.checkAddressable()
+	v.checkRO()
+	if n < 0 {
+		panic("reflect.Value.Grow: negative len")
+	}
+	hdr := (*sliceHeader)(v.value)
+	newLen := hdr.len + uintptr(n)
+	if newLen < hdr.len {
+		panic("reflect.Value.Grow: slice overflow")
+	}
+	if newLen > hdr.cap {
+		hdr.data, _, hdr.cap = sliceGrow(hdr.data, hdr.len, hdr.cap, newLen, v.typecode.elem().Size())
+	}
+}
+
 func (v Value) checkAddressable() {
 	if !v.isIndirect() {
 		panic("reflect: value is not addressable")
@@ -1195,7 +1532,7 @@ func (v Value) OverflowInt(x int64) bool {
 		trunc := (x << (64 - bitSize)) >> (64 - bitSize)
 		return x != trunc
 	}
-	panic(&ValueError{Method: "reflect.Value.OverflowInt", Kind: v.Kind()})
+	panic(v.kindError("reflect.Value.OverflowInt", intKinds))
 }
 
 // OverflowUint reports whether the uint64 x cannot be represented by v's type.
@@ -1208,125 +1545,246 @@ func (v Value) OverflowUint(x uint64) bool {
 		trunc := (x << (64 - bitSize)) >> (64 - bitSize)
 		return x != trunc
 	}
-	panic(&ValueError{Method: "reflect.Value.OverflowUint", Kind: v.Kind()})
+	panic(v.kindError("reflect.Value.OverflowUint", uintKinds))
 }
 
+// CanConvert reports whether the value v can be converted to type t. If
+// v.CanConvert(t) returns true then v.Convert(t) will not panic.
 func (v Value) CanConvert(t Type) bool {
-	panic("unimplemented: (reflect.Value).CanConvert()")
+	vt := v.Type()
+	if !vt.ConvertibleTo(t) {
+		return false
+	}
+	// Converting a slice to an array or a pointer to an array panics if the
+	// slice is too short.
+	switch {
+	case vt.Kind() == Slice && t.Kind() == Array:
+		return t.Len() <= v.Len()
+	case vt.Kind() == Slice && t.Kind() == Pointer && t.Elem().Kind() == Array:
+		return t.Elem().Len() <= v.Len()
+	}
+	return true
 }
 
+// Convert returns the value v converted to type t. If the usual Go conversion
+// rules do not allow conversion of the value v to type t, or if converting v
+// to type t panics, Convert panics.
 func (v Value) Convert(t Type) Value {
-	if v, ok := convertOp(v, t); ok {
-		return v
+	if v.typecode == nil {
+		panic(&ValueError{Method: "Convert", Kind: Invalid})
 	}
-
-	panic("reflect.Value.Convert: value of type " + v.typecode.String() + " cannot be converted to type " + t.String())
-}
-
-func convertOp(src Value, typ Type) (Value, bool) {
-
-	// Easy check first.  Do we even need to do anything?
-	if src.typecode.underlying() == typ.(*rawType).underlying() {
-		return Value{
-			typecode: typ.(*rawType),
-			value:    src.value,
-			flags:    src.flags,
-		}, true
+	typ := t.(*rawType)
+	op := convertOp(typ, v.typecode)
+	if op == nil {
+		panic("reflect.Value.Convert: value of type " + v.typecode.String() + " cannot be converted to type " + t.String())
 	}
+	return op(v, typ)
+}
 
+// convertOp returns the function to convert a value of type src to type dst,
+// or nil if the conversion is not allowed. Like upstream Go, conversions to
+// and from unsafe.Pointer are not supported.
+func convertOp(dst, src *rawType) func(Value, *rawType) Value {
 	switch src.Kind() {
 	case Int, Int8, Int16, Int32, Int64:
-		switch rtype := typ.(*rawType); rtype.Kind() {
+		switch dst.Kind() {
 		case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
-			return cvtInt(src, rtype), true
+			return cvtInt
 		case Float32, Float64:
-			return cvtIntFloat(src, rtype), true
+			return cvtIntFloat
 		case String:
-			return cvtIntString(src, rtype), true
+			return cvtIntString
 		}
 
 	case Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
-		switch rtype := typ.(*rawType); rtype.Kind() {
+		switch dst.Kind() {
 		case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
-			return cvtUint(src, rtype), true
+			return cvtUint
 		case Float32, Float64:
-			return cvtUintFloat(src, rtype), true
+			return cvtUintFloat
 		case String:
-			return cvtUintString(src, rtype), true
+			return cvtUintString
 		}
 
 	case Float32, Float64:
-		switch rtype := typ.(*rawType); rtype.Kind() {
+		switch dst.Kind() {
 		case Int, Int8, Int16, Int32, Int64:
-			return cvtFloatInt(src, rtype), true
+			return cvtFloatInt
 		case Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
-			return cvtFloatUint(src, rtype), true
+			return cvtFloatUint
 		case Float32, Float64:
-			return cvtFloat(src, rtype), true
+			return cvtFloat
 		}
 
-		/*
-			case Complex64, Complex128:
-				switch src.Kind() {
-				case Complex64, Complex128:
-					return cvtComplex
-				}
-		*/
-
-	case Slice:
-		if typ.Kind() == String && !src.typecode.elem().isNamed() {
-			rtype := typ.(*rawType)
+	case Complex64, Complex128:
+		switch dst.Kind() {
+		case Complex64, Complex128:
+			return cvtComplex
+		}
 
-			switch src.Type().Elem().Kind() {
+	case String:
+		if dst.Kind() == Slice && !dst.elem().isNamed() {
+			switch dst.elem().Kind() {
 			case Uint8:
-				return cvtBytesString(src, rtype), true
+				return cvtStringBytes
 			case Int32:
-				return cvtRunesString(src, rtype), true
+				return cvtStringRunes
 			}
 		}
 
-	case String:
-		rtype := typ.(*rawType)
-		if typ.Kind() == Slice && !rtype.elem().isNamed() {
-			switch typ.Elem().Kind() {
+	case Slice:
+		if dst.Kind() == String && !src.elem().isNamed() {
+			switch src.elem().Kind() {
 			case Uint8:
-				return cvtStringBytes(src, rtype), true
+				return cvtBytesString
 			case Int32:
-				return cvtStringRunes(src, rtype), true
+				return cvtRunesString
 			}
 		}
+		// "x is a slice, T is an array or a pointer to an array, and the slice
+		// and array types have identical element types."
+		if dst.Kind() == Array && src.elem() == dst.elem() {
+			return cvtSliceArray
+		}
+		if dst.Kind() == Pointer && dst.elem().Kind() == Array && src.elem() == dst.elem().elem() {
+			return cvtSliceArrayPtr
+		}
+
+	case Chan:
+		// A bidirectional channel can be converted to a channel type with a
+		// direction, like in an assignment.
+		if dst.Kind() == Chan && specialChannelAssignability(dst, src) {
+			return cvtDirect
+		}
+	}
+
+	// dst and src have the same underlying type, ignoring struct tags.
+	if haveIdenticalUnderlyingType(dst, src, false) {
+		return cvtDirect
+	}
+
+	// dst and src are unnamed pointer types with the same underlying base
+	// type.
+	if dst.Kind() == Pointer && !dst.isNamed() && src.Kind() == Pointer && !src.isNamed() &&
+		haveIdenticalUnderlyingType(dst.elem(), src.elem(), false) {
+		return cvtDirect
 	}
 
-	// TODO(dgryski): Unimplemented:
-	// Chan
-	// Non-defined pointers types with same underlying base type
-	// Interface <-> Type conversions
+	if dst.Kind() == Interface && src.implements(dst) {
+		if src.Kind() == Interface {
+			return cvtI2I
+		}
+		return cvtT2I
+	}
 
-	return Value{}, false
+	return nil
+}
+
+// haveIdenticalType returns whether T and V are identical types. Struct tags
+// are ignored unless cmpTags is set.
+func haveIdenticalType(T, V *rawType, cmpTags bool) bool {
+	if T == V {
+		return true
+	}
+	if cmpTags || T.isNamed() || V.isNamed() {
+		// Identical named types have the same type code.
+		return false
+	}
+	return haveIdenticalUnderlyingType(T, V, false)
+}
+
+// haveIdenticalUnderlyingType returns whether the underlying types of T and V
+// are identical. Struct tags are ignored unless cmpTags is set.
+func haveIdenticalUnderlyingType(T, V *rawType, cmpTags bool) bool {
+	T, V = T.underlying(), V.underlying()
+	if T == V {
+		return true
+	}
+	if cmpTags || T.Kind() != V.Kind() {
+		// Types that are identical including struct tags have the same type
+		// code.
+		return false
+	}
+
+	switch T.Kind() {
+	case Array:
+		return T.Len() == V.Len() && haveIdenticalType(T.elem(), V.elem(), false)
+	case Chan:
+		return T.ChanDir() == V.ChanDir() && haveIdenticalType(T.elem(), V.elem(), false)
+	case Pointer, Slice:
+		return haveIdenticalType(T.elem(), V.elem(), false)
+	case Map:
+		return haveIdenticalType(T.key(), V.key(), false) && haveIdenticalType(T.elem(), V.elem(), false)
+	case Func:
+		tt, vt := T.funcType("ConvertibleTo"), V.funcType("ConvertibleTo")
+		if tt.variadic != vt.variadic || tt.numIn != vt.numIn || tt.numOut != vt.numOut {
+			return false
+		}
+		for i := 0; i < int(tt.numIn)+int(tt.numOut); i++ {
+			if !haveIdenticalType(tt.param(i), vt.param(i), false) {
+				return false
+			}
+		}
+		return true
+	case Struct:
+		if T.NumField() != V.NumField() {
+			return false
+		}
+		for i := 0; i < T.NumField(); i++ {
+			tf, vf := T.rawField(i), V.rawField(i)
+			if tf.Name != vf.Name || tf.PkgPath != vf.PkgPath || tf.Anonymous != vf.Anonymous || tf.Offset != vf.Offset {
+				return false
+			}
+			if !haveIdenticalType(tf.Type, vf.Type, false) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// convFlags returns the flags of a value converted from v. The result is never
+// addressable, but it is read-only if v is.
+func (v Value) convFlags() valueFlags {
+	return v.flags&valueFlagExported | v.flags.ro()
+}
+
+// cvtDirect converts v to a type with the same underlying type. The value
+// is copied if it is addressable, so that the result doesn't alias it.
+func cvtDirect(v Value, t *rawType) Value {
+	if v.isIndirect() {
+		return loadResult(t, v.value, v.convFlags())
+	}
+	return Value{
+		typecode: t,
+		value:    v.value,
+		flags:    v.convFlags(),
+	}
 }
 
 func cvtInt(v Value, t *rawType) Value {
-	return makeInt(v.flags, uint64(v.Int()), t)
+	return makeInt(v.convFlags(), uint64(v.Int()), t)
 }
 
 func cvtUint(v Value, t *rawType) Value {
-	return makeInt(v.flags, v.Uint(), t)
+	return makeInt(v.convFlags(), v.Uint(), t)
 }
 
 func cvtIntFloat(v Value, t *rawType) Value {
-	return makeFloat(v.flags, float64(v.Int()), t)
+	return makeFloat(v.convFlags(), float64(v.Int()), t)
 }
 
 func cvtUintFloat(v Value, t *rawType) Value {
-	return makeFloat(v.flags, float64(v.Uint()), t)
+	return makeFloat(v.convFlags(), float64(v.Uint()), t)
 }
 
 func cvtFloatInt(v Value, t *rawType) Value {
-	return makeInt(v.flags, uint64(int64(v.Float())), t)
+	return makeInt(v.convFlags(), uint64(int64(v.Float())), t)
 }
 
 func cvtFloatUint(v Value, t *rawType) Value {
-	return makeInt(v.flags, uint64(v.Float()), t)
+	return makeInt(v.convFlags(), uint64(v.Float()), t)
 }
 
 func cvtFloat(v Value, t *rawType) Value {
@@ -1334,9 +1792,9 @@ func cvtFloat(v Value, t *rawType) Value {
 		// Don't do any conversion if both types have underlying type float32.
 		// This avoids converting to float64 and back, which will
 		// convert a signaling NaN to a quiet NaN. See issue 36400.
-		return makeFloat32(v.flags, v.Float32(), t)
+		return makeFloat32(v.convFlags(), v.Float32(), t)
 	}
-	return makeFloat(v.flags, v.Float(), t)
+	return makeFloat(v.convFlags(), v.Float(), t)
 }
 
 //go:linkname stringToBytes runtime.stringToBytes
@@ -1347,7 +1805,7 @@ func cvtStringBytes(v Value, t *rawType) Value {
 	return Value{
 		typecode: t,
 		value:    unsafe.Pointer(&b),
-		flags:    v.flags,
+		flags:    v.convFlags(),
 	}
 }
 
@@ -1359,7 +1817,7 @@ func cvtBytesString(v Value, t *rawType) Value {
 	return Value{
 		typecode: t,
 		value:    unsafe.Pointer(&s),
-		flags:    v.flags,
+		flags:    v.convFlags(),
 	}
 }
 
@@ -1422,20 +1880,112 @@ func makeFloat32(flags valueFlags, f float32, t *rawType) Value {
 	return v
 }
 
-func cvtIntString(src Value, t *rawType) Value {
-	panic("cvtUintString: unimplemented")
+func makeComplex(flags valueFlags, c complex128, t *rawType) Value {
+	v := Value{
+		typecode: t,
+		flags:    flags,
+	}
+
+	switch t.Size() {
+	case 8:
+		if unsafe.Sizeof(complex64(0)) <= unsafe.Sizeof(uintptr(0)) {
+			*(*complex64)(unsafe.Pointer(&v.value)) = complex64(c)
+		} else {
+			ptr := alloc(8, nil)
+			*(*complex64)(ptr) = complex64(c)
+			v.value = ptr
+		}
+	case 16:
+		ptr := alloc(16, nil)
+		*(*complex128)(ptr) = c
+		v.value = ptr
+	}
+	return v
+}
+
+func makeString(flags valueFlags, s string, t *rawType) Value {
+	return Value{
+		typecode: t,
+		value:    unsafe.Pointer(&s),
+		flags:    flags,
+	}
+}
+
+func cvtComplex(v Value, t *rawType) Value {
+	return makeComplex(v.convFlags(), v.Complex(), t)
+}
+
+func cvtIntString(v Value, t *rawType) Value {
+	s := "\uFFFD"
+	if x := v.Int(); int64(rune(x)) == x {
+		s = string(rune(x))
+	}
+	return makeString(v.convFlags(), s, t)
+}
+
+func cvtUintString(v Value, t *rawType) Value {
+	s := "\uFFFD"
+	if x := v.Uint(); uint64(rune(x)) == x {
+		s = string(rune(x))
+	}
+	return makeString(v.convFlags(), s, t)
+}
+
+func cvtStringRunes(v Value, t *rawType) Value {
+	r := []rune(*(*string)(v.value))
+	return Value{
+		typecode: t,
+		value:    unsafe.Pointer(&r),
+		flags:    v.convFlags(),
+	}
+}
+
+func cvtRunesString(v Value, t *rawType) Value {
+	return makeString(v.convFlags(), string(*(*[]rune)(v.value)), t)
 }
 
-func cvtUintString(src Value, t *rawType) Value {
-	panic("cvtUintString: unimplemented")
+func cvtSliceArrayPtr(v Value, t *rawType) Value {
+	n := t.elem().Len()
+	h := (*sliceHeader)(v.value)
+	if n > int(h.len) {
+		panic("reflect: cannot convert slice with length " + itoa.Itoa(int(h.len)) + " to pointer to array with length " + itoa.Itoa(n))
+	}
+	return Value{
+		typecode: t,
+		value:    h.data,
+		flags:    v.convFlags(),
+	}
 }
 
-func cvtStringRunes(src Value, t *rawType) Value {
-	panic("cvsStringRunes: unimplemented")
+func cvtSliceArray(v Value, t *rawType) Value {
+	n := t.Len()
+	h := (*sliceHeader)(v.value)
+	if n > int(h.len) {
+		panic("reflect: cannot convert slice with length " + itoa.Itoa(int(h.len)) + " to array with length " + itoa.Itoa(n))
+	}
+	// The result is a copy, so that changes to the slice are not visible in
+	// the array.
+	return loadResult(t, h.data, v.convFlags())
 }
 
-func cvtRunesString(src Value, t *rawType) Value {
-	panic("cvsRunesString: unimplemented")
+// cvtT2I converts the non-interface value v to the interface type t.
+func cvtT2I(v Value, t *rawType) Value {
+	intf := valueInterfaceUnsafe(cvtDirect(v, v.typecode))
+	return Value{
+		typecode: t,
+		value:    unsafe.Pointer(&intf),
+		flags:    v.convFlags(),
+	}
+}
+
+// cvtI2I converts the interface value v to the interface type t.
+func cvtI2I(v Value, t *rawType) Value {
+	if v.IsNil() {
+		ret := Zero(t)
+		ret.flags = v.convFlags()
+		return ret
+	}
+	return cvtT2I(v.Elem(), t)
 }
 
 //go:linkname slicePanic runtime.slicePanic
@@ -1485,7 +2035,39 @@ func init() {
 	zerobuffer = s.data
 }
 
+// zeroRegion is a region of zero bytes that is shared by all large values
+// returned by Zero. It is allocated on first use, and replaced by a bigger one
+// when Zero is called with a type that doesn't fit. Both fields are stored
+// together behind one pointer so that they are always updated together.
+var zeroRegion *struct {
+	size uintptr
+	data unsafe.Pointer
+}
+
+// zeroPointer returns a pointer to at least size zero bytes. The memory must
+// not be modified.
+func zeroPointer(size uintptr) unsafe.Pointer {
+	if size <= zerobufferLen {
+		return zerobuffer
+	}
+	region := zeroRegion
+	if region == nil || region.size < size {
+		region = &struct {
+			size uintptr
+			data unsafe.Pointer
+		}{size, alloc(size, nil)}
+		zeroRegion = region
+	}
+	return region.data
+}
+
+// Zero returns a Value representing the zero value for the specified type.
+// The result is read-only and not addressable, so the memory of large zero
+// values can be shared instead of allocated for every call.
 func Zero(typ Type) Value {
+	if typ == nil {
+		panic("reflect: Zero(nil)")
+	}
 	size := typ.Size()
 	if size <= unsafe.Sizeof(uintptr(0)) {
 		return Value{
@@ -1495,17 +2077,9 @@ func Zero(typ Type) Value {
 		}
 	}
 
-	if size <= zerobufferLen {
-		return Value{
-			typecode: typ.(*rawType),
-			value:    unsafe.Pointer(zerobuffer),
-			flags:    valueFlagExported | valueFlagRO,
-		}
-	}
-
 	return Value{
 		typecode: typ.(*rawType),
-		value:    alloc(size, nil),
+		value:    zeroPointer(size),
 		flags:    valueFlagExported | valueFlagRO,
 	}
 }
@@ -1554,13 +2128,34 @@ type stringHeader struct {
 type ValueError struct {
 	Method string
 	Kind   Kind
+
+	// Only included in the error message with -tags=reflect_debug.
+	typ      *rawType
+	expected kindSet
+}
+
+// kindSet is a bitset of kinds, with bit n set for Kind(n).
+type kindSet uint32
+
+const (
+	intKinds      kindSet = 1<<Int | 1<<Int8 | 1<<Int16 | 1<<Int32 | 1<<Int64
+	uintKinds     kindSet = 1<<Uint | 1<<Uint8 | 1<<Uint16 | 1<<Uint32 | 1<<Uint64 | 1<<Uintptr
+	floatKinds    kindSet = 1<<Float32 | 1<<Float64
+	complexKinds  kindSet = 1<<Complex64 | 1<<Complex128
+	nillableKinds kindSet = 1<<Chan | 1<<Func | 1<<Interface | 1<<Map | 1<<Pointer | 1<<Slice | 1<<UnsafePointer
+)
+
+// kindError returns a ValueError for calling the given method on v, where the
+// method only accepts values of the expected kinds (if known).
+func (v Value) kindError(method string, expected kindSet) *ValueError {
+	return &ValueError{Method: method, Kind: v.Kind(), typ: v.typecode, expected: expected}
 }
 
 func (e *ValueError) Error() string {
 	if e.Kind == 0 {
-		return "reflect: call of " + e.Method + " on zero Value"
+		return "reflect: call of " + e.Method + " on zero Value" + e.details()
 	}
-	return "reflect: call of " + e.Method + " on " + e.Kind.String() + " Value"
+	return "reflect: call of " + e.Method + " on " + e.Kind.String() + " Value" + e.details()
 }
 
 //go:linkname memcpy runtime.memcpy
@@ -1640,7 +2235,7 @@ func sliceGrow(buf unsafe.Pointer, oldLen, oldCap, newCap, elemSize uintptr) (un
 // extend slice to hold n new elements
 func (v *Value) extendSlice(n int) {
 	if v.Kind() != Slice {
-		panic(&ValueError{Method: "extendSlice", Kind: v.Kind()})
+		panic(v.kindError("extendSlice", 1<<Slice))
 	}
 
 	var old sliceHeader
@@ -1675,12 +2270,28 @@ func (v *Value) extendSlice(n int) {
 // As in Go, each x's value must be assignable to the slice's element type.
 func Append(v Value, x ...Value) Value {
 	if v.Kind() != Slice {
-		panic(&ValueError{Method: "Append", Kind: v.Kind()})
+		panic(v.kindError("Append", 1<<Slice))
 	}
 	oldLen := v.Len()
 	v.extendSlice(len(x))
+	elem := v.typecode.elem()
+	elemSize := elem.Size()
+	data := (*sliceHeader)(v.value).data
 	for i, xx := range x {
-		v.Index(oldLen + i).Set(xx)
+		if xx.typecode != elem {
+			// The value may need to be converted, for example to an
+			// interface. Let Set take care of that.
+			v.Index(oldLen + i).Set(xx)
+			continue
+		}
+		// Fast path: the value has the element type, so it can be copied
+		// directly into the slice.
+		dst := unsafe.Add(data, uintptr(oldLen+i)*elemSize)
+		if xx.isIndirect() || elemSize > unsafe.Sizeof(uintptr(0)) {
+			memcpy(dst, xx.value, elemSize)
+		} else {
+			memcpy(dst, unsafe.Pointer(&xx.value), elemSize)
+		}
 	}
 	return v
 }
@@ -1734,7 +2345,7 @@ func hashmapInterfaceDelete(m unsafe.Pointer, key interface{})
 func (v Value) SetMapIndex(key, elem Value) {
 	v.checkRO()
 	if v.Kind() != Map {
-		panic(&ValueError{Method: "SetMapIndex", Kind: v.Kind()})
+		panic(v.kindError("SetMapIndex", 1<<Map))
 	}
 
 	vkey := v.typecode.key()
@@ -1753,11 +2364,7 @@ func (v Value) SetMapIndex(key, elem Value) {
 
 	// make elem an interface if it needs to be converted
 	if v.typecode.elem().Kind() == Interface && elem.typecode.Kind() != Interface {
-		intf := composeInterface(unsafe.Pointer(elem.typecode), elem.value)
-		elem = Value{
-			typecode: v.typecode.elem(),
-			value:    unsafe.Pointer(&intf),
-		}
+		elem = cvtT2I(elem, v.typecode.elem())
 	}
 
 	if key.Kind() == String {
@@ -1774,13 +2381,7 @@ func (v Value) SetMapIndex(key, elem Value) {
 		}
 
 	} else if key.typecode.isBinary() {
-		var keyptr unsafe.Pointer
-		if key.isIndirect() || key.typecode.Size() > unsafe.Sizeof(uintptr(0)) {
-			keyptr = key.value
-		} else {
-			keyptr = unsafe.Pointer(&key.value)
-		}
-
+		keyptr := key.binaryKey()
 		if del {
 			hashmapBinaryDelete(v.pointer(), keyptr)
 		} else {
@@ -1814,7 +2415,7 @@ func (v Value) FieldByIndex(index []int) Value {
 		return v.Field(index[0])
 	}
 	if v.Kind() != Struct {
-		panic(&ValueError{"FieldByIndex", v.Kind()})
+		panic(v.kindError("FieldByIndex", 1<<Struct))
 	}
 	for i, x := range index {
 		if i > 0 {
@@ -1830,14 +2431,43 @@ func (v Value) FieldByIndex(index []int) Value {
 	return v
 }
 
-// FieldByIndexErr returns the nested field corresponding to index.
+// FieldByIndexErr returns the nested field corresponding to index. It returns
+// an error if evaluation requires stepping through a nil pointer, but panics if
+// it must step through a field that is not a struct.
 func (v Value) FieldByIndexErr(index []int) (Value, error) {
-	return Value{}, &ValueError{Method: "FieldByIndexErr"}
+	if len(index) == 1 {
+		return v.Field(index[0]), nil
+	}
+	if v.Kind() != Struct {
+		panic(v.kindError("FieldByIndexErr", 1<<Struct))
+	}
+	for i, x := range index {
+		if i > 0 {
+			if v.Kind() == Pointer && v.typecode.elem().Kind() == Struct {
+				if v.IsNil() {
+					return Value{}, &nilEmbeddedError{v.typecode.elem()}
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// nilEmbeddedError is returned by FieldByIndexErr. The errors package can't be
+// used here, because it imports reflect through internal/reflectlite.
+type nilEmbeddedError struct {
+	typ *rawType
+}
+
+func (e *nilEmbeddedError) Error() string {
+	return "reflect: indirection through nil pointer to embedded struct field " + e.typ.Name()
 }
 
 func (v Value) FieldByName(name string) Value {
 	if v.Kind() != Struct {
-		panic(&ValueError{"FieldByName", v.Kind()})
+		panic(v.kindError("FieldByName", 1<<Struct))
 	}
 
 	if field, ok := v.typecode.FieldByName(name); ok {
@@ -1846,11 +2476,26 @@ func (v Value) FieldByName(name string) Value {
 	return Value{}
 }
 
+// FieldByNameFunc returns the struct field with a name that satisfies the
+// match function. It panics if v's Kind is not struct. It returns the zero
+// Value if no field was found.
+func (v Value) FieldByNameFunc(match func(string) bool) Value {
+	if v.Kind() != Struct {
+		panic(v.kindError("FieldByNameFunc", 1<<Struct))
+	}
+
+	if field, ok := v.typecode.FieldByNameFunc(match); ok {
+		return v.FieldByIndex(field.Index)
+	}
+	return Value{}
+}
+
 //go:linkname hashmapMake runtime.hashmapMakeUnsafePointer
 func hashmapMake(keySize, valueSize uintptr, sizeHint uintptr, alg uint8) unsafe.Pointer
 
 // MakeMapWithSize creates a new map with the specified type and initial space
-// for approximately n elements.
+// for approximately n elements. The map won't need to grow while the first n
+// elements are added to it.
 func MakeMapWithSize(typ Type, n int) Value {
 
 	// TODO(dgryski): deduplicate these?  runtime and reflect both need them.
@@ -1905,16 +2550,230 @@ type SelectCase struct {
 	Send Value     // value to send (for send)
 }
 
+// chanSelectState has the same layout as runtime.chanSelectState. A nil value
+// means a receive operation.
+type chanSelectState struct {
+	ch    unsafe.Pointer
+	value unsafe.Pointer
+}
+
+//go:linkname chanselect runtime.chanSelectUnsafePointer
+func chanselect(recvbuf, states unsafe.Pointer, numStates uintptr, block bool) (uintptr, bool)
+
+// Select executes a select operation described by the list of cases.
+// Like the Go select statement, it blocks until at least one of the cases
+// can proceed and then executes that case. It returns the index of the chosen
+// case and, if that case was a receive operation, the value received and a
+// boolean indicating whether the value corresponds to a send on the channel
+// (as opposed to a zero value received because the channel is closed).
 func Select(cases []SelectCase) (chosen int, recv Value, recvOK bool) {
-	panic("unimplemented: reflect.Select")
+	// Cases with a zero Chan Value, and the default case, are stored as a
+	// receive from a nil channel: the runtime never selects those.
+	states := make([]chanSelectState, len(cases))
+	defaultIndex := -1
+	var recvSize uintptr
+	for i, c := range cases {
+		switch c.Dir {
+		case SelectDefault:
+			if defaultIndex >= 0 {
+				panic("reflect.Select: multiple default cases")
+			}
+			if c.Chan.IsValid() {
+				panic("reflect.Select: default case has Chan value")
+			}
+			if c.Send.IsValid() {
+				panic("reflect.Select: default case has Send value")
+			}
+			defaultIndex = i
+		case SelectSend:
+			ch := c.Chan
+			if !ch.IsValid() {
+				break
+			}
+			ch.checkChan("Select", SendDir)
+			if !c.Send.IsValid() {
+				panic("reflect.Select: SendDir case missing Send value")
+			}
+			states[i] = chanSelectState{
+				ch:    ch.pointer(),
+				value: chanSendValue("reflect.Select", ch.typecode.elem(), c.Send),
+			}
+		case SelectRecv:
+			if c.Send.IsValid() {
+				panic("reflect.Select: RecvDir case has Send value")
+			}
+			ch := c.Chan
+			if !ch.IsValid() {
+				break
+			}
+			ch.checkChan("Select", RecvDir)
+			if size := ch.typecode.elem().Size(); size > recvSize {
+				recvSize = size
+			}
+			states[i].ch = ch.pointer()
+		default:
+			panic("reflect.Select: invalid Dir")
+		}
+	}
+
+	// All receive cases share a single buffer, like in a select statement.
+	recvbuf := alloc(recvSize, nil)
+	var statesPtr unsafe.Pointer
+	if len(states) != 0 {
+		statesPtr = unsafe.Pointer(&states[0])
+	}
+	index, ok := chanselect(recvbuf, statesPtr, uintptr(len(states)), defaultIndex < 0)
+	if index == ^uintptr(0) {
+		return defaultIndex, Value{}, false
+	}
+	chosen = int(index)
+	if cases[chosen].Dir == SelectRecv {
+		recv = chanRecvValue(cases[chosen].Chan.typecode.elem(), recvbuf)
+		recvOK = ok
+	}
+	return chosen, recv, recvOK
 }
 
+// checkChan panics if v is not a channel that can be used in the given
+// direction.
+func (v Value) checkChan(method string, dir ChanDir) {
+	if v.Kind() != Chan {
+		panic(v.kindError(method, 1<<Chan))
+	}
+	if !v.isExported() {
+		panic("reflect: " + method + " using value obtained using unexported field")
+	}
+	if v.typecode.ChanDir()&dir == 0 {
+		switch dir {
+		case SendDir:
+			panic("reflect: send on recv-only channel")
+		default:
+			panic("reflect: recv on send-only channel")
+		}
+	}
+}
+
+// chanSendValue stores x in newly allocated memory of the channel element type
+// elem and returns a pointer to it, for use in a send operation.
+func chanSendValue(method string, elem *rawType, x Value) unsafe.Pointer {
+	if !x.isExported() {
+		panic("reflect: " + method + " using value obtained using unexported field")
+	}
+	if !x.typecode.AssignableTo(elem) {
+		panic(method + ": value of type " + x.typecode.String() + " is not assignable to type " + elem.String())
+	}
+	value := New(elem).Elem()
+	value.Set(x)
+	return value.value
+}
+
+// chanRecvValue returns the received value stored at ptr as a non-addressable
+// Value. The memory at ptr must not be reused afterwards.
+func chanRecvValue(elem *rawType, ptr unsafe.Pointer) Value {
+	if elem.Size() <= unsafe.Sizeof(uintptr(0)) {
+		ptr = unsafe.Pointer(loadValue(ptr, elem.Size()))
+	}
+	return Value{
+		typecode: elem,
+		value:    ptr,
+		flags:    valueFlagExported,
+	}
+}
+
+// Send sends x on the channel v. It panics if v's kind is not Chan or if x's
+// type is not the same type as v's element type.
 func (v Value) Send(x Value) {
-	panic("unimplemented: reflect.Value.Send()")
+	v.send("Send", x, true)
+}
+
+// TrySend attempts to send x on the channel v but will not block. It panics
+// if v's Kind is not Chan. It reports whether the value was sent.
+func (v Value) TrySend(x Value) bool {
+	return v.send("TrySend", x, false)
+}
+
+func (v Value) send(method string, x Value, block bool) (selected bool) {
+	v.checkChan(method, SendDir)
+	state := chanSelectState{
+		ch:    v.pointer(),
+		value: chanSendValue("reflect.Value."+method, v.typecode.elem(), x),
+	}
+	index, _ := chanselect(nil, unsafe.Pointer(&state), 1, block)
+	return index == 0
+}
+
+// Recv receives and returns a value from the channel v. It panics if v's Kind
+// is not Chan. The receive blocks until a value is ready. The boolean value ok
+// is true if the value x corresponds to a send on the channel, false if it is
+// a zero value received because the channel is closed.
+func (v Value) Recv() (x Value, ok bool) {
+	return v.recv("Recv", true)
 }
 
+// TryRecv attempts to receive a value from the channel v but will not block.
+// It panics if v's Kind is not Chan. If the receive delivers a value, x is the
+// transferred value and ok is true. If the receive cannot finish without
+// blocking, x is the zero Value and ok is false. If the channel is closed, x is
+// the zero value for the channel's element type and ok is false.
+func (v Value) TryRecv() (x Value, ok bool) {
+	return v.recv("TryRecv", false)
+}
+
+func (v Value) recv(method string, block bool) (x Value, ok bool) {
+	v.checkChan(method, RecvDir)
+	elem := v.typecode.elem()
+	state := chanSelectState{
+		ch: v.pointer(),
+	}
+	buf := alloc(elem.Size(), nil)
+	index, ok := chanselect(buf, unsafe.Pointer(&state), 1, block)
+	if index != 0 {
+		return Value{}, false
+	}
+	return chanRecvValue(elem, buf), ok
+}
+
+//go:linkname chanclose runtime.chanCloseUnsafePointer
+func chanclose(p unsafe.Pointer)
+
+// Close closes the channel v. It panics if v's Kind is not Chan or v is a
+// receive-only channel.
 func (v Value) Close() {
-	panic("unimplemented: reflect.Value.Close()")
+	if v.Kind() != Chan {
+		panic(v.kindError("Close", 1<<Chan))
+	}
+	if !v.isExported() {
+		panic("reflect: Close using value obtained using unexported field")
+	}
+	if v.typecode.ChanDir()&SendDir == 0 {
+		panic("reflect: close of receive-only channel")
+	}
+	chanclose(v.pointer())
+}
+
+//go:linkname chanmake runtime.chanMakeUnsafePointer
+func chanmake(elementSize uintptr, bufSize uintptr) unsafe.Pointer
+
+// MakeChan creates a new channel with the specified type and buffer size.
+func MakeChan(typ Type, buffer int) Value {
+	if typ.Kind() != Chan {
+		panic("reflect.MakeChan of non-chan type")
+	}
+	if buffer < 0 {
+		panic("reflect.MakeChan: negative buffer size")
+	}
+	if typ.ChanDir() != BothDir {
+		panic("reflect.MakeChan: unidirectional channel type")
+	}
+	elemSize := typ.Elem().Size()
+	if elemSize != 0 && uintptr(buffer) > (^uintptr(0)>>1)/elemSize {
+		panic("reflect.MakeChan: buffer size too large")
+	}
+	return Value{
+		typecode: typ.(*rawType),
+		value:    chanmake(elemSize, uintptr(buffer)),
+		flags:    valueFlagExported,
+	}
 }
 
 // MakeMap creates a new map with the specified type.
@@ -1922,22 +2781,191 @@ func MakeMap(typ Type) Value {
 	return MakeMapWithSize(typ, 8)
 }
 
+// Call calls the function v with the input arguments in. For example, if
+// len(in) == 3, v.Call(in) represents the Go call v(in[0], in[1], in[2]). Call
+// panics if v's Kind is not Func. It returns the output results as Values. As
+// in Go, each input argument must be assignable to the type of the function's
+// corresponding input parameter. If v is a variadic function, Call creates the
+// variadic slice parameter itself, copying in the corresponding values.
 func (v Value) Call(in []Value) []Value {
-	panic("unimplemented: (reflect.Value).Call()")
+	return v.call("Call", in, false)
 }
 
-func (v Value) Method(i int) Value {
-	panic("unimplemented: (reflect.Value).Method()")
+// CallSlice calls the variadic function v with the input arguments in,
+// assigning the slice in[len(in)-1] to v's final variadic argument. For
+// example, if len(in) == 3, v.CallSlice(in) represents the Go call v(in[0],
+// in[1], in[2]...). CallSlice panics if v's Kind is not Func or if v is not
+// variadic.
+func (v Value) CallSlice(in []Value) []Value {
+	return v.call("CallSlice", in, true)
 }
 
-func (v Value) MethodByName(name string) Value {
-	panic("unimplemented: (reflect.Value).MethodByName()")
+func (v Value) call(method string, in []Value, isSlice bool) []Value {
+	if v.Kind() != Func {
+		panic(v.kindError(method, 1<<Func))
+	}
+	if v.IsNil() {
+		panic("reflect: call of nil function")
+	}
+	ft := v.typecode.funcType(method)
+	numIn := int(ft.numIn)
+	variadic := ft.variadic != 0
+
+	if isSlice {
+		if !variadic {
+			panic("reflect: CallSlice of non-variadic function")
+		}
+		if len(in) != numIn {
+			panic("reflect: CallSlice with wrong number of input arguments")
+		}
+	} else {
+		if variadic {
+			numIn--
+		}
+		if len(in) < numIn || (!variadic && len(in) > numIn) {
+			panic("reflect: Call with wrong number of input arguments")
+		}
+	}
+	for _, x := range in {
+		if x.Kind() == Invalid {
+			panic("reflect: " + method + " using zero Value argument")
+		}
+		if !x.isExported() {
+			panic("reflect: " + method + " using value obtained using unexported field")
+		}
+	}
+
+	if variadic && !isSlice {
+		// Put the remaining arguments in the variadic slice.
+		sliceType := ft.param(numIn)
+		elemType := sliceType.elem()
+		extra := in[numIn:]
+		slice := MakeSlice(sliceType, len(extra), len(extra))
+		for i, x := range extra {
+			if !x.typecode.AssignableTo(elemType) {
+				panic("reflect: cannot use " + x.typecode.String() + " as type " + elemType.String() + " in " + method)
+			}
+			slice.Index(i).Set(x)
+		}
+		in = append(in[:numIn:numIn], slice)
+	}
+
+	// Store each argument in memory of the parameter type, and pass a pointer
+	// to it to the call wrapper of this signature.
+	args := make([]unsafe.Pointer, len(in))
+	for i, x := range in {
+		paramType := ft.param(i)
+		if !x.typecode.AssignableTo(paramType) {
+			panic("reflect: " + method + " using " + x.typecode.String() + " as type " + paramType.String())
+		}
+		arg := New(paramType).Elem()
+		arg.Set(x)
+		args[i] = arg.value
+	}
+
+	// Allocate memory for the results, and pass a pointer to each of them.
+	resultPtrs := make([]unsafe.Pointer, ft.numOut)
+	for i := range resultPtrs {
+		resultPtrs[i] = alloc(ft.param(int(ft.numIn)+i).Size(), nil)
+	}
+
+	var argsPtr, resultsPtr *unsafe.Pointer
+	if len(args) != 0 {
+		argsPtr = &args[0]
+	}
+	if len(resultPtrs) != 0 {
+		resultsPtr = &resultPtrs[0]
+	}
+	ft.call((*funcHeader)(v.value), argsPtr, resultsPtr)
+
+	// The results are not addressable, like the results of a regular call.
+	results := make([]Value, ft.numOut)
+	for i, ptr := range resultPtrs {
+		typ := ft.param(int(ft.numIn) + i)
+		if typ.Size() <= unsafe.Sizeof(uintptr(0)) {
+			ptr = unsafe.Pointer(loadValue(ptr, typ.Size()))
+		}
+		results[i] = Value{
+			typecode: typ,
+			value:    ptr,
+			flags:    valueFlagExported,
+		}
+	}
+	return results
 }
 
-func (v Value) Recv() (x Value, ok bool) {
-	panic("unimplemented: (reflect.Value).Recv()")
+// Method returns a function value corresponding to v's i'th method. The
+// arguments to a Call on the returned function should not include a receiver;
+// the returned function will always use v as the receiver. Method panics if i
+// is out of range or if v is a nil interface value.
+func (v Value) Method(i int) Value {
+	if v.typecode == nil {
+		panic(&ValueError{Method: "reflect.Value.Method", Kind: Invalid})
+	}
+	if v.flags&valueFlagMethod != 0 || uint(i) >= uint(v.typecode.NumMethod()) {
+		panic("reflect: Method index out of range")
+	}
+
+	rcvr := v
+	var m *method
+	if v.Kind() == Interface {
+		if v.IsNil() {
+			panic("reflect: Method on nil interface value")
+		}
+		// Look up the method of the dynamic type with the same signature.
+		sig := v.typecode.underlying().interfaceMethods()[i]
+		rcvr = v.Elem()
+		methods := rcvr.typecode.allMethods()
+		for j := range methods {
+			if unsafe.Pointer(methods[j].signature) == sig {
+				m = &methods[j]
+				break
+			}
+		}
+	} else {
+		m = &v.typecode.methodTable()[i]
+	}
+
+	fn := m.fn
+	return makeMethodValue(m.signature.typ, Value{
+		typecode: m.typ,
+		value:    unsafe.Pointer(&fn),
+		flags:    valueFlagExported,
+	}, rcvr, v.flags&valueFlagExported|v.flags.ro())
+}
+
+// MethodByName returns a function value corresponding to the method of v with
+// the given name. The arguments to a Call on the returned function should not
+// include a receiver; the returned function will always use v as the receiver.
+// It returns the zero Value if no method was found.
+func (v Value) MethodByName(name string) Value {
+	if v.typecode == nil {
+		panic(&ValueError{Method: "reflect.Value.MethodByName", Kind: Invalid})
+	}
+	if v.flags&valueFlagMethod != 0 {
+		panic(&ValueError{Method: "reflect.Value.MethodByName", Kind: Func})
+	}
+	m, ok := v.typecode.MethodByName(name)
+	if !ok {
+		return Value{}
+	}
+	return v.Method(m.Index)
 }
 
+// NewAt returns a Value representing a pointer to a value of the specified
+// type, using p as that pointer.
+//
+// The returned Value is exported, even if p points into an unexported struct
+// field. This is what libraries like go-cmp rely on to read unexported fields
+// of an addressable struct:
+//
+//	f := v.Field(i) // unexported field of an addressable struct
+//	f = reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+//	x := f.Interface() // doesn't panic
 func NewAt(typ Type, p unsafe.Pointer) Value {
-	panic("unimplemented: reflect.New()")
+	return Value{
+		typecode: pointerTo(typ.(*rawType)),
+		value:    p,
+		flags:    valueFlagExported,
+	}
 }
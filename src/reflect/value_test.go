@@ -4,7 +4,9 @@ import (
 	"encoding/base64"
 	. "reflect"
 	"sort"
+	"strings"
 	"testing"
+	"unsafe"
 )
 
 func TestTinyIndirectPointers(t *testing.T) {
@@ -206,6 +208,34 @@ func (c *count) count() int {
 	return c.i
 }
 
+type paddedKey struct {
+	A int8
+	B int64
+}
+
+func TestTinyMapPaddedKey(t *testing.T) {
+	m := map[paddedKey]int{{1, 2}: 3}
+
+	// Create a key with non-zero padding bytes.
+	buf := [2]uint64{^uint64(0), ^uint64(0)}
+	k := (*paddedKey)(unsafe.Pointer(&buf))
+	k.A, k.B = 1, 2
+	key := ValueOf(k).Elem()
+
+	mv := ValueOf(m)
+	if v := mv.MapIndex(key); !v.IsValid() || v.Int() != 3 {
+		t.Errorf("MapIndex with padded key = %v, want 3", v)
+	}
+	mv.SetMapIndex(key, ValueOf(4))
+	if len(m) != 1 || m[paddedKey{1, 2}] != 4 {
+		t.Errorf("SetMapIndex with padded key: got %v", m)
+	}
+	mv.SetMapIndex(key, Value{})
+	if len(m) != 0 {
+		t.Errorf("SetMapIndex delete with padded key: got %v", m)
+	}
+}
+
 func TestMapInterfaceKeys(t *testing.T) {
 	m := make(map[interface{}]int)
 	for i := 0; i < 20; i++ {
@@ -365,6 +395,76 @@ func TestTinyBytes(t *testing.T) {
 			t.Errorf("vslice[%d]=%d, want %d", i, got, want)
 		}
 	}
+
+	// test pointers to arrays
+	pslice := v.Bytes()
+	if len(pslice) != 3 || &pslice[0] != &a[0] {
+		t.Errorf("Bytes() of *[3]byte = %v, want slice of a", pslice)
+	}
+	big := [40]byte{39: 1}
+	if got := ValueOf(&big).Bytes(); len(got) != 40 || &got[39] != &big[39] {
+		t.Errorf("Bytes() of *[40]byte = %v, want slice of big", got)
+	}
+	if got := ValueOf((*[4]byte)(nil)).Bytes(); got != nil {
+		t.Errorf("Bytes() of nil *[4]byte = %v, want nil", got)
+	}
+}
+
+func TestTinyIndexPointerToArray(t *testing.T) {
+	type small struct {
+		A int8
+		B int16
+	}
+	a8 := [3]int8{1, -2, 3}
+	a16 := [3]int16{1, -2, 3}
+	a32 := [3]int32{1, -2, 3}
+	a64 := [3]int64{1, -2, 3}
+	a24 := [3][3]byte{{1}, {2}, {3}}
+	as := [3]small{{A: 1}, {A: 2}, {A: 3}}
+	for _, tc := range []struct {
+		ptr  any
+		get  func(v Value) int64
+		set  func(v Value, x int64)
+		want func(i int) int64
+	}{
+		{&a8, Value.Int, Value.SetInt, func(i int) int64 { return int64(a8[i]) }},
+		{&a16, Value.Int, Value.SetInt, func(i int) int64 { return int64(a16[i]) }},
+		{&a32, Value.Int, Value.SetInt, func(i int) int64 { return int64(a32[i]) }},
+		{&a64, Value.Int, Value.SetInt, func(i int) int64 { return a64[i] }},
+		{&a24,
+			func(v Value) int64 { return int64(v.Index(0).Uint()) },
+			func(v Value, x int64) { v.Index(0).SetUint(uint64(x)) },
+			func(i int) int64 { return int64(a24[i][0]) }},
+		{&as,
+			func(v Value) int64 { return v.Field(0).Int() },
+			func(v Value, x int64) { v.Field(0).SetInt(x) },
+			func(i int) int64 { return int64(as[i].A) }},
+	} {
+		ptr := ValueOf(tc.ptr)
+		if ptr.Len() != 3 || ptr.Cap() != 3 {
+			t.Errorf("%s: Len()=%d, Cap()=%d, want 3", ptr.Type(), ptr.Len(), ptr.Cap())
+		}
+		arr := ptr.Elem()
+		for i := 0; i < arr.Len(); i++ {
+			elem := arr.Index(i)
+			if got, want := tc.get(elem), tc.want(i); got != want {
+				t.Errorf("%s: Index(%d)=%d, want %d", ptr.Type(), i, got, want)
+			}
+			if !elem.CanSet() {
+				t.Errorf("%s: Index(%d) is not settable", ptr.Type(), i)
+				continue
+			}
+			tc.set(elem, int64(i)+10)
+			if got, want := tc.want(i), int64(i)+10; got != want {
+				t.Errorf("%s: after set, element %d=%d, want %d", ptr.Type(), i, got, want)
+			}
+		}
+	}
+
+	var empty [4]struct{}
+	if v := ValueOf(&empty).Elem().Index(3); !v.CanAddr() {
+		t.Errorf("Index of zero-sized element is not addressable")
+	}
 }
 
 func TestTinyNamedTypes(t *testing.T) {
@@ -456,6 +556,15 @@ func TestTinyStruct(t *testing.T) {
 	if q.Name != "" || ok {
 		t.Errorf("FieldByName(Snorble)=%v,%v, want ``, false", q.Name, ok)
 	}
+
+	q, ok = reffb.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, "bar") })
+	if q.Name != "Bar" || !ok {
+		t.Errorf("FieldByNameFunc(bar)=%v,%v, want Bar, true", q.Name, ok)
+	}
+
+	if got, want := ValueOf(fb).FieldByNameFunc(func(name string) bool { return name == "Bar" }).Field(0).String(), "qux"; got != want {
+		t.Errorf("FieldByNameFunc(Bar).QuxString=%v, want %v", got, want)
+	}
 }
 
 func TestTinyZero(t *testing.T) {
@@ -551,16 +660,336 @@ func (m *methodStruct) pointerMethod3() int {
 	return m.i
 }
 
+func (m methodStruct) ValueMethod(x int) int {
+	return m.i + x
+}
+
+func (m *methodStruct) PointerMethod() int {
+	return m.i
+}
+
 func TestTinyNumMethods(t *testing.T) {
+	// Only exported methods are counted.
 	refptrt := TypeOf(&methodStruct{})
-	if got, want := refptrt.NumMethod(), 2+3; got != want {
+	if got, want := refptrt.NumMethod(), 1+1; got != want {
 		t.Errorf("Pointer Methods=%v, want %v", got, want)
 	}
 
 	reft := refptrt.Elem()
-	if got, want := reft.NumMethod(), 2; got != want {
+	if got, want := reft.NumMethod(), 1; got != want {
 		t.Errorf("Value Methods=%v, want %v", got, want)
 	}
+
+	// Interfaces also count unexported methods.
+	if got, want := TypeOf((*pointerMethods)(nil)).Elem().NumMethod(), 3; got != want {
+		t.Errorf("Interface Methods=%v, want %v", got, want)
+	}
+}
+
+func TestTinyMethod(t *testing.T) {
+	typ := TypeOf(&methodStruct{})
+	for i, name := range []string{"PointerMethod", "ValueMethod"} {
+		m := typ.Method(i)
+		if m.Name != name || m.Index != i || m.PkgPath != "" {
+			t.Errorf("Method(%d) = %q (index %d, pkgpath %q), want %q", i, m.Name, m.Index, m.PkgPath, name)
+		}
+		byName, ok := typ.MethodByName(name)
+		if !ok || byName.Index != i {
+			t.Errorf("MethodByName(%q) = %d, %v, want %d", name, byName.Index, ok, i)
+		}
+	}
+	if _, ok := typ.MethodByName("pointerMethod1"); ok {
+		t.Errorf("MethodByName found unexported method")
+	}
+
+	m, _ := typ.Elem().MethodByName("ValueMethod")
+	if got, want := m.Type.String(), "func(reflect_test.methodStruct, int) int"; got != want {
+		t.Errorf("ValueMethod type = %s, want %s", got, want)
+	}
+	out := m.Func.Call([]Value{ValueOf(methodStruct{i: 3}), ValueOf(4)})
+	if got := out[0].Int(); got != 7 {
+		t.Errorf("ValueMethod returned %d, want 7", got)
+	}
+	out = typ.Method(0).Func.Call([]Value{ValueOf(&methodStruct{i: 5})})
+	if got := out[0].Int(); got != 5 {
+		t.Errorf("PointerMethod returned %d, want 5", got)
+	}
+
+	itf := TypeOf((*pointerMethods)(nil)).Elem()
+	m = itf.Method(0)
+	if m.Name != "pointerMethod2" || m.PkgPath != "reflect_test" || m.Type.String() != "func() int" || m.Func.IsValid() {
+		t.Errorf("interface Method(0) = %q %q %s", m.PkgPath, m.Name, m.Type)
+	}
+}
+
+func TestTinyMethodValuePointer(t *testing.T) {
+	// Method values point to the code of the method, regardless of the
+	// receiver.
+	v1 := ValueOf(&methodStruct{i: 1})
+	v2 := ValueOf(&methodStruct{i: 2})
+	if v1.Method(0).Pointer() != v2.Method(0).Pointer() {
+		t.Errorf("method values of the same method have a different code pointer")
+	}
+	if v1.Method(0).Pointer() == v1.Method(1).Pointer() {
+		t.Errorf("method values of different methods have the same code pointer")
+	}
+	if got, want := v1.MethodByName("PointerMethod").Pointer(), v1.Type().Method(0).Func.Pointer(); got != want {
+		t.Errorf("method value code pointer = %#x, want %#x", got, want)
+	}
+	if got := v2.Method(0).Call(nil)[0].Int(); got != 2 {
+		t.Errorf("PointerMethod returned %d, want 2", got)
+	}
+
+	// Method values created by the compiler also point to the method.
+	obj := &methodStruct{i: 3}
+	if got, want := ValueOf(obj.PointerMethod).Pointer(), v1.Type().Method(0).Func.Pointer(); got != want {
+		t.Errorf("obj.PointerMethod code pointer = %#x, want %#x", got, want)
+	}
+	if got, want := ValueOf(obj.ValueMethod).Pointer(), TypeOf(*obj).Method(0).Func.Pointer(); got != want {
+		t.Errorf("obj.ValueMethod code pointer = %#x, want %#x", got, want)
+	}
+	if ValueOf(obj.PointerMethod).Pointer() == ValueOf(obj.ValueMethod).Pointer() {
+		t.Errorf("method values of different methods have the same code pointer")
+	}
+
+	// Unexported methods can be reached through an interface.
+	var itf pointerMethods = obj
+	m := ValueOf(&itf).Elem().Method(0) // pointerMethod2
+	if got := m.Call(nil)[0].Int(); got != 3 {
+		t.Errorf("pointerMethod2 returned %d, want 3", got)
+	}
+	if got, want := m.Pointer(), ValueOf(obj.pointerMethod2).Pointer(); got != want {
+		t.Errorf("interface method value code pointer = %#x, want %#x", got, want)
+	}
+}
+
+type valueMethods interface {
+	valueMethod1() int
+	valueMethod2() int
+}
+
+type pointerMethods interface {
+	valueMethods
+	pointerMethod2() int
+}
+
+func TestTinyImplements(t *testing.T) {
+	valueMethodsType := TypeOf((*valueMethods)(nil)).Elem()
+	pointerMethodsType := TypeOf((*pointerMethods)(nil)).Elem()
+	errorType := TypeOf((*error)(nil)).Elem()
+	anyType := TypeOf((*any)(nil)).Elem()
+
+	for _, tc := range []struct {
+		typ  Type
+		itf  Type
+		want bool
+	}{
+		{TypeOf(methodStruct{}), valueMethodsType, true},
+		{TypeOf(methodStruct{}), pointerMethodsType, false},
+		{TypeOf(&methodStruct{}), valueMethodsType, true},
+		{TypeOf(&methodStruct{}), pointerMethodsType, true},
+		{TypeOf(&methodStruct{}), errorType, false},
+		{TypeOf(0), valueMethodsType, false},
+		{TypeOf(0), anyType, true},
+		{pointerMethodsType, valueMethodsType, true},
+		{valueMethodsType, pointerMethodsType, false},
+		{errorType, anyType, true},
+	} {
+		if got := tc.typ.Implements(tc.itf); got != tc.want {
+			t.Errorf("%v.Implements(%v) = %v, want %v", tc.typ, tc.itf, got, tc.want)
+		}
+	}
+
+	if got, want := pointerMethodsType.NumMethod(), 3; got != want {
+		t.Errorf("Interface Methods=%v, want %v", got, want)
+	}
+}
+
+type deepEqualNode struct {
+	next  *deepEqualNode
+	value any
+}
+
+func TestTinyDeepEqual(t *testing.T) {
+	// Interfaces of the same type must not be treated as already visited.
+	if DeepEqual(&struct{ A, B any }{1, 2}, &struct{ A, B any }{1, 3}) {
+		t.Errorf("DeepEqual of different interface fields returned true")
+	}
+
+	// Long cycles need more than the fixed-capacity part of the visited set.
+	makeRing := func(n, last int) *deepEqualNode {
+		first := &deepEqualNode{value: 0}
+		node := first
+		for i := 1; i < n; i++ {
+			node.next = &deepEqualNode{value: i}
+			node = node.next
+		}
+		node.value = last
+		node.next = first
+		return first
+	}
+	if !DeepEqual(makeRing(100, 99), makeRing(100, 99)) {
+		t.Errorf("DeepEqual of equal rings returned false")
+	}
+	if DeepEqual(makeRing(100, 99), makeRing(100, -1)) {
+		t.Errorf("DeepEqual of different rings returned true")
+	}
+}
+
+type longTagStruct struct {
+	A int `validate:"required,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx,oneof=xxxxxxxxxxxxxxxxxxxx" json:"a"`
+	B int `json:""`
+}
+
+func TestTinyStructTag(t *testing.T) {
+	// Tags longer than 255 bytes must be stored in full.
+	f := TypeOf(longTagStruct{}).Field(0)
+	if v, ok := f.Tag.Lookup("json"); !ok || v != "a" {
+		t.Errorf("Lookup(json) on long tag = %q, %v", v, ok)
+	}
+	if v := f.Tag.Get("validate"); len(v) != 386 {
+		t.Errorf("Get(validate) on long tag has length %d", len(v))
+	}
+
+	// Lookup must distinguish an empty value from an absent key.
+	f = TypeOf(longTagStruct{}).Field(1)
+	if v, ok := f.Tag.Lookup("json"); !ok || v != "" {
+		t.Errorf("Lookup(json) = %q, %v, want \"\", true", v, ok)
+	}
+	if _, ok := f.Tag.Lookup("xml"); ok {
+		t.Errorf("Lookup(xml) found absent key")
+	}
+
+	// Malformed tags are ignored from the point of the error.
+	for _, tag := range []StructTag{`json:"a`, `json:a`, `json :"a"`, `"json":"a"`, `json:"\q"`} {
+		if v, ok := tag.Lookup("json"); ok {
+			t.Errorf("StructTag(%#q).Lookup(json) = %q, want not found", tag, v)
+		}
+	}
+	if v, ok := StructTag(`a:"1" b:"2`).Lookup("a"); !ok || v != "1" {
+		t.Errorf("Lookup before malformed part = %q, %v", v, ok)
+	}
+
+	// StructOf must keep long tags too.
+	st := StructOf([]StructField{{Name: "A", Type: TypeOf(0), Tag: f.Tag + StructTag(strings.Repeat(" ", 300)) + `x:"y"`}})
+	if v := st.Field(0).Tag.Get("x"); v != "y" {
+		t.Errorf("StructOf long tag Get(x) = %q", v)
+	}
+}
+
+func TestTinyNewAtUnexported(t *testing.T) {
+	type inner struct {
+		x []int
+	}
+	type outer struct {
+		a int
+		b inner
+	}
+	s := outer{a: 3, b: inner{x: []int{1, 2}}}
+	v := ValueOf(&s).Elem()
+
+	f := v.Field(0)
+	if f.CanInterface() {
+		t.Fatalf("unexported field can be converted to an interface")
+	}
+	f = NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+	if !f.CanInterface() || !f.CanSet() {
+		t.Fatalf("field obtained through NewAt is not exported")
+	}
+	if x := f.Interface().(int); x != 3 {
+		t.Errorf("field obtained through NewAt = %d, want 3", x)
+	}
+	f.SetInt(4)
+	if s.a != 4 {
+		t.Errorf("Set through NewAt didn't modify the field")
+	}
+
+	// Nested unexported fields, like go-cmp reads them.
+	sf := v.Type().Field(1)
+	b := NewAt(sf.Type, unsafe.Add(unsafe.Pointer(v.UnsafeAddr()), sf.Offset)).Elem()
+	x := b.Field(0)
+	x = NewAt(x.Type(), unsafe.Pointer(x.UnsafeAddr())).Elem()
+	if got := x.Interface().([]int); len(got) != 2 || got[1] != 2 {
+		t.Errorf("nested field obtained through NewAt = %v", got)
+	}
+}
+
+func TestTinySetPointer(t *testing.T) {
+	type pointers struct {
+		P   unsafe.Pointer
+		Any any
+		N   int
+	}
+	a, b := 1, 2
+	s := pointers{N: 5}
+	v := ValueOf(&s).Elem()
+
+	v.Field(0).SetPointer(unsafe.Pointer(&a))
+	if s.P != unsafe.Pointer(&a) {
+		t.Errorf("SetPointer didn't set the field")
+	}
+	if p := v.Field(0).UnsafePointer(); p != unsafe.Pointer(&a) {
+		t.Errorf("UnsafePointer after SetPointer = %p, want %p", p, &a)
+	}
+	v.Field(0).Set(ValueOf(unsafe.Pointer(&b)))
+	if s.P != unsafe.Pointer(&b) {
+		t.Errorf("Set didn't set the unsafe.Pointer field")
+	}
+
+	// Setting an interface from a value that is stored indirectly must
+	// store the value itself, not the pointer to it.
+	v.Field(1).Set(v.Field(0))
+	if s.Any != unsafe.Pointer(&b) {
+		t.Errorf("Set of interface from unsafe.Pointer field = %v, want %p", s.Any, &b)
+	}
+	v.Field(1).Set(v.Field(2))
+	if s.Any != 5 {
+		t.Errorf("Set of interface from int field = %v, want 5", s.Any)
+	}
+
+	shouldPanic("SetPointer", func() { v.Field(2).SetPointer(nil) })
+}
+
+type genericList[T any] struct {
+	items []T
+}
+
+type genericPair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+func TestTinyGenericTypeName(t *testing.T) {
+	tests := []struct {
+		typ  Type
+		name string
+		str  string
+	}{
+		{TypeOf(genericList[int]{}), "genericList[int]", "reflect_test.genericList[int]"},
+		{TypeOf(&genericList[string]{}), "", "*reflect_test.genericList[string]"},
+		{TypeOf(genericPair[string, []int]{}), "genericPair[string,[]int]", "reflect_test.genericPair[string,[]int]"},
+		{TypeOf(genericList[myError]{}), "genericList[reflect_test.myError]", "reflect_test.genericList[reflect_test.myError]"},
+		{TypeOf(genericList[byte]{}), "genericList[uint8]", "reflect_test.genericList[uint8]"},
+		{TypeOf(genericList[any]{}), "genericList[interface {}]", "reflect_test.genericList[interface {}]"},
+		{TypeOf(genericList[struct {
+			X int `json:"x"`
+			myError
+		}]{}), `genericList[struct { X int "json:\"x\""; reflect_test.myError }]`, `reflect_test.genericList[struct { X int "json:\"x\""; reflect_test.myError }]`},
+		{TypeOf(genericList[func(int, ...string) (bool, error)]{}), "genericList[func(int, ...string) (bool, error)]", "reflect_test.genericList[func(int, ...string) (bool, error)]"},
+		{TypeOf(genericList[chan (<-chan int)]{}), "genericList[chan (<-chan int)]", "reflect_test.genericList[chan (<-chan int)]"},
+		{TypeOf(genericList[interface{ Error() string }]{}), "genericList[interface { Error() string }]", "reflect_test.genericList[interface { Error() string }]"},
+	}
+	for _, tt := range tests {
+		if name := tt.typ.Name(); name != tt.name {
+			t.Errorf("Name() = %q, want %q", name, tt.name)
+		}
+		if str := tt.typ.String(); str != tt.str {
+			t.Errorf("String() = %q, want %q", str, tt.str)
+		}
+	}
+	if path := TypeOf(genericList[int]{}).PkgPath(); path != "reflect_test" {
+		t.Errorf("PkgPath() = %q, want %q", path, "reflect_test")
+	}
 }
 
 func TestAssignableTo(t *testing.T) {
@@ -572,6 +1001,195 @@ func TestAssignableTo(t *testing.T) {
 	}
 }
 
+func TestTinyAssignableToInterface(t *testing.T) {
+	type namedChan chan int
+	errType := TypeOf((*error)(nil)).Elem()
+	tests := []struct {
+		from, to Type
+		ok       bool
+	}{
+		{TypeOf(myError("")), errType, true},
+		{TypeOf(0), errType, false},
+		{errType, TypeOf((*any)(nil)).Elem(), true},
+		{TypeOf((*any)(nil)).Elem(), errType, false},
+		{TypeOf(make(chan int)), TypeOf(make(<-chan int)), true},
+		{TypeOf(make(<-chan int)), TypeOf(make(chan int)), false},
+		{TypeOf(make(chan int)), TypeOf(make(namedChan)), true},
+		{TypeOf(struct{ A int }{}), TypeOf(struct {
+			A int `json:"a"`
+		}{}), false},
+	}
+	for _, tt := range tests {
+		if ok := tt.from.AssignableTo(tt.to); ok != tt.ok {
+			t.Errorf("(%s).AssignableTo(%s) = %v, want %v", tt.from, tt.to, ok, tt.ok)
+		}
+	}
+
+	var err error
+	ValueOf(&err).Elem().Set(ValueOf(myError("x")))
+	if err == nil || err.Error() != "x" {
+		t.Errorf("Set of error variable failed: %v", err)
+	}
+}
+
+func TestTinyOverflowComplex(t *testing.T) {
+	if ValueOf(complex128(0)).OverflowComplex(complex(1e300, 1e300)) {
+		t.Errorf("complex128 overflows with 1e300")
+	}
+	v := ValueOf(complex64(0))
+	if v.OverflowComplex(complex(1e30, -1e30)) {
+		t.Errorf("complex64 overflows with 1e30")
+	}
+	if !v.OverflowComplex(complex(1e300, 0)) || !v.OverflowComplex(complex(0, -1e300)) {
+		t.Errorf("complex64 doesn't overflow with 1e300")
+	}
+}
+
+func TestTinyAppend(t *testing.T) {
+	type big struct {
+		A, B, C int64
+	}
+	src := []big{{1, 2, 3}, {4, 5, 6}}
+	v := Append(ValueOf([]big{{7, 8, 9}}), ValueOf(src).Index(0), ValueOf(src[1]))
+	if got := v.Interface().([]big); len(got) != 3 || got[0].A != 7 || got[1] != src[0] || got[2] != src[1] {
+		t.Errorf("Append of structs = %v", got)
+	}
+
+	// Values stored indirectly, and values that need to be converted.
+	s := struct{ N int16 }{5}
+	var iface error = myError("x")
+	v = Append(ValueOf([]any(nil)), ValueOf(&s).Elem().Field(0), ValueOf(3), ValueOf(&iface).Elem())
+	if got := v.Interface().([]any); len(got) != 3 || got[0] != int16(5) || got[1] != 3 || got[2] != myError("x") {
+		t.Errorf("Append to []any = %v", got)
+	}
+	v = Append(ValueOf([]int16(nil)), ValueOf(&s).Elem().Field(0), ValueOf(int16(-1)))
+	if got := v.Interface().([]int16); len(got) != 2 || got[0] != 5 || got[1] != -1 {
+		t.Errorf("Append to []int16 = %v", got)
+	}
+}
+
+func TestTinyZeroLarge(t *testing.T) {
+	type small [10]int64
+	type big [100]int64
+	for i := 0; i < 2; i++ {
+		// Zero values share memory, which must stay zero.
+		vs := Zero(TypeOf(small{}))
+		vb := Zero(TypeOf(big{}))
+		if vs.CanAddr() || vb.CanAddr() || vs.CanSet() || vb.CanSet() {
+			t.Errorf("Zero value is addressable")
+		}
+		if !vs.IsZero() || !vb.IsZero() {
+			t.Errorf("Zero value is not zero")
+		}
+		x := New(TypeOf(big{})).Elem()
+		x.Index(99).SetInt(1)
+		x.Set(vb)
+		if x.Index(99).Int() != 0 || vb.Index(99).Int() != 0 {
+			t.Errorf("Set from Zero value didn't clear the value")
+		}
+	}
+}
+
+func TestTinyStructFields(t *testing.T) {
+	type inner struct{ X int }
+	type S struct {
+		A int8 `json:"a"`
+		b string
+		inner
+		C []byte `json:"c,omitempty"`
+	}
+	typ := TypeOf(S{})
+	iter := StructFields(typ)
+	n := 0
+	for iter.Next() {
+		want := typ.Field(n)
+		if iter.Index() != n || iter.Name() != want.Name || iter.PkgPath() != want.PkgPath ||
+			iter.IsExported() != want.IsExported() || iter.Type() != want.Type ||
+			iter.Tag() != want.Tag || iter.Offset() != want.Offset || iter.Anonymous() != want.Anonymous {
+			t.Errorf("field %d: got %q %q %v %q %d %v, want %q %q %v %q %d %v", n,
+				iter.Name(), iter.PkgPath(), iter.Type(), iter.Tag(), iter.Offset(), iter.Anonymous(),
+				want.Name, want.PkgPath, want.Type, want.Tag, want.Offset, want.Anonymous)
+		}
+		if f := iter.Field(); f.Name != want.Name || len(f.Index) != 1 || f.Index[0] != n {
+			t.Errorf("field %d: Field() = %v, want %v", n, f, want)
+		}
+		n++
+	}
+	if n != typ.NumField() {
+		t.Errorf("iterated over %d fields, want %d", n, typ.NumField())
+	}
+	if iter.Next() {
+		t.Errorf("Next returned true after the last field")
+	}
+	shouldPanic("no current field", func() { iter.Name() })
+	shouldPanic("StructFields", func() { StructFields(TypeOf(0)) })
+
+	allocs := testing.AllocsPerRun(10, func() {
+		iter := StructFields(typ)
+		for iter.Next() {
+			_ = iter.Tag().Get("json")
+			_ = iter.Name()
+			_ = iter.Type()
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("StructFields iteration allocated %v times, want 0", allocs)
+	}
+}
+
+func TestTinyStructOfPointer(t *testing.T) {
+	fields := []StructField{
+		{Name: "A", Type: TypeOf(int16(0))},
+		{Name: "B", Type: TypeOf("")},
+	}
+	st := StructOf(fields)
+	if StructOf(fields) != st {
+		t.Errorf("StructOf returned a new type for the same fields")
+	}
+
+	// The pointer type is created together with the struct type.
+	pt := PointerTo(st)
+	if PointerTo(st) != pt || pt.Elem() != st {
+		t.Errorf("PointerTo(%v) returned a new type", st)
+	}
+	if typ := New(st).Type(); typ != pt {
+		t.Errorf("New(%v).Type() = %v, want %v", st, typ, pt)
+	}
+	if PointerTo(pt).Elem() != pt {
+		t.Errorf("PointerTo(%v).Elem() = %v", pt, PointerTo(pt).Elem())
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		_ = PointerTo(st)
+	})
+	if allocs != 0 {
+		t.Errorf("PointerTo allocated %v times, want 0", allocs)
+	}
+}
+
+func TestTinyValueAllocs(t *testing.T) {
+	type pair struct {
+		A int
+		B string
+	}
+	s := ValueOf(pair{A: 3, B: "three"})
+	a := ValueOf([]pair{{A: 1}, {A: 2}})
+	m := ValueOf(map[string]int{"one": 1, "two": 2})
+	key := ValueOf("two")
+
+	for _, tc := range []struct {
+		name string
+		fn   func()
+	}{
+		{"Field", func() { _ = s.Field(0).Int() + int64(s.Field(1).Len()) }},
+		{"Index", func() { _ = a.Index(1).Field(0).Int() }},
+		{"MapIndex", func() { _ = m.MapIndex(key).Int() }},
+	} {
+		if allocs := testing.AllocsPerRun(10, tc.fn); allocs != 0 {
+			t.Errorf("%s allocated %v times, want 0", tc.name, allocs)
+		}
+	}
+}
+
 func TestConvert(t *testing.T) {
 	v := ValueOf(int64(3))
 	c := v.Convert(TypeOf(byte(0)))
@@ -0,0 +1,47 @@
+//go:build reflect_debug
+
+package reflect
+
+// This file adds the type of the Value and the kinds that were expected to the
+// message of a ValueError, with -tags=reflect_debug. This makes it easier to
+// find reflection bugs from the panic message alone (for example, when the
+// only output is a serial port). It is not the default because these strings
+// increase the binary size.
+
+func (e *ValueError) details() string {
+	s := ""
+	if e.typ != nil {
+		s += "type " + e.typ.String()
+	}
+	if e.expected != 0 {
+		if s != "" {
+			s += ", "
+		}
+		s += "expected " + e.expected.String()
+	}
+	if s == "" {
+		return ""
+	}
+	return " (" + s + ")"
+}
+
+// String returns the kinds in this set, like "int, int8 or int16".
+func (set kindSet) String() string {
+	s := ""
+	remaining := set
+	for k := Kind(0); remaining != 0; k++ {
+		if remaining&(1<<k) == 0 {
+			continue
+		}
+		remaining &^= 1 << k
+		if s != "" {
+			if remaining == 0 {
+				s += " or "
+			} else {
+				s += ", "
+			}
+		}
+		s += k.String()
+	}
+	return s
+}
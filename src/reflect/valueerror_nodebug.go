@@ -0,0 +1,9 @@
+//go:build !reflect_debug
+
+package reflect
+
+// details returns nothing, so that no extra strings end up in the binary. See
+// valueerror_debug.go.
+func (e *ValueError) details() string {
+	return ""
+}
@@ -23,6 +23,28 @@ func fastrand() uint32 {
 
 var xorshift32State uint32 = 1
 
+// initRand seeds the random number generators of the runtime with entropy
+// from the system, if there is a source of entropy. This makes map hash seeds
+// and map iteration order unpredictable, so that an attacker can't easily
+// construct colliding map keys (HashDoS) and programs don't accidentally
+// depend on the order of maps.
+//
+// Maps created at compile time (by interp) keep the seed they got during
+// compilation.
+func initRand() {
+	r, ok := hardwareRand()
+	if !ok {
+		return
+	}
+	// Both generators get stuck at a zero state.
+	if s := uint32(r ^ r>>32); s != 0 {
+		xorshift32State = s
+	}
+	if r != 0 {
+		xorshift64State = r
+	}
+}
+
 func xorshift32(x uint32) uint32 {
 	// Algorithm "xor" from p. 4 of Marsaglia, "Xorshift RNGs".
 	// Improved sequence based on
@@ -0,0 +1,123 @@
+//go:build allocs_trace
+
+package runtime
+
+// This file implements heap allocation tracing, enabled with
+// -tags=allocs_trace. Every heap allocation is recorded together with the
+// address it was allocated from, so that the places that allocate the most can
+// be found without a profiler. Print them with debug.PrintAllocSites.
+
+import "unsafe"
+
+const allocsTrace = true
+
+// Number of allocations that are remembered. Older allocations are overwritten.
+const allocTraceSize = 256
+
+type allocRecord struct {
+	pc   uintptr
+	size uintptr
+}
+
+// allocSite is a summary of all recorded allocations from a single place.
+type allocSite struct {
+	pc    uintptr
+	count uintptr
+	size  uintptr
+}
+
+var (
+	allocTraceRecords [allocTraceSize]allocRecord
+	allocTraceNext    uintptr // index of the next record to overwrite
+	allocTraceCount   uint64  // total number of recorded allocations
+
+	// Scratch space for debug.PrintAllocSites, which must not allocate itself.
+	allocTraceSites [allocTraceSize]allocSite
+)
+
+// traceAlloc records an allocation of the given size. The pc is the return
+// address of the call to alloc.
+func traceAlloc(pc unsafe.Pointer, size uintptr) {
+	allocTraceRecords[allocTraceNext] = allocRecord{
+		pc:   uintptr(pc) - callInstSize,
+		size: size,
+	}
+	allocTraceNext++
+	if allocTraceNext == allocTraceSize {
+		allocTraceNext = 0
+	}
+	allocTraceCount++
+}
+
+// allocSites groups the recorded allocations by call site, sorted by the
+// number of allocated bytes. It doesn't allocate: the returned slice refers to
+// scratch space that is overwritten by the next call.
+func allocSites() []allocSite {
+	numSites := 0
+	for _, record := range allocTraceRecords {
+		if record.pc == 0 {
+			continue // not yet used
+		}
+		found := false
+		for i := 0; i < numSites; i++ {
+			if allocTraceSites[i].pc == record.pc {
+				allocTraceSites[i].count++
+				allocTraceSites[i].size += record.size
+				found = true
+				break
+			}
+		}
+		if !found {
+			allocTraceSites[numSites] = allocSite{pc: record.pc, count: 1, size: record.size}
+			numSites++
+		}
+	}
+
+	// Sort by the number of allocated bytes (insertion sort, as the list is
+	// small and this must not allocate).
+	for i := 1; i < numSites; i++ {
+		for j := i; j > 0 && allocTraceSites[j].size > allocTraceSites[j-1].size; j-- {
+			allocTraceSites[j], allocTraceSites[j-1] = allocTraceSites[j-1], allocTraceSites[j]
+		}
+	}
+	return allocTraceSites[:numSites]
+}
+
+//go:linkname debug_printAllocSites runtime/debug.printAllocSites
+func debug_printAllocSites(n int) {
+	sites := allocSites()
+	printstring("allocation sites (last ")
+	recorded := allocTraceCount
+	if recorded > allocTraceSize {
+		recorded = allocTraceSize
+	}
+	printuint64(recorded)
+	printstring(" of ")
+	printuint64(allocTraceCount)
+	printstring(" allocations):\n")
+	printstring("    bytes  count  pc\n")
+	if n > len(sites) || n < 0 {
+		n = len(sites)
+	}
+	for _, site := range sites[:n] {
+		printstring("  ")
+		printPadded(site.size, 7)
+		printstring("  ")
+		printPadded(site.count, 5)
+		printstring("  ")
+		printptr(site.pc)
+		printnl()
+	}
+}
+
+// printPadded prints n right-aligned in a field of the given width.
+func printPadded(n uintptr, width int) {
+	digits := 1
+	for v := n; v >= 10; v /= 10 {
+		digits++
+	}
+	for ; digits < width; digits++ {
+		printspace()
+	}
+	printuintptr(n)
+}
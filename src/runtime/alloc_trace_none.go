@@ -0,0 +1,15 @@
+//go:build !allocs_trace
+
+package runtime
+
+import "unsafe"
+
+const allocsTrace = false
+
+func traceAlloc(pc unsafe.Pointer, size uintptr) {
+}
+
+//go:linkname debug_printAllocSites runtime/debug.printAllocSites
+func debug_printAllocSites(n int) {
+	println("allocation tracing is not enabled, build with -tags=allocs_trace")
+}
@@ -0,0 +1,19 @@
+package runtime
+
+const GOARCH = "mips"
+
+// The bitness of the CPU (e.g. 8, 32, 64).
+const TargetBits = 32
+
+const deferExtraRegs = 0
+
+const callInstSize = 8 // "jal someFunction" is 4 bytes, plus a MIPS delay slot
+
+// It appears that MIPS has a maximum alignment of 8 bytes.
+func align(ptr uintptr) uintptr {
+	return (ptr + 7) &^ 7
+}
+
+func getCurrentStackPointer() uintptr {
+	return uintptr(stacksave())
+}
@@ -2,7 +2,10 @@
 
 package runtime
 
-import "unsafe"
+import (
+	"runtime/interrupt"
+	"unsafe"
+)
 
 // The below functions override the default allocator of wasi-libc. This ensures
 // code linked from other languages can allocate memory without colliding with
@@ -15,6 +18,11 @@ func libc_malloc(size uintptr) unsafe.Pointer {
 	if size == 0 {
 		return nil
 	}
+	if hasParallelism {
+		// C code on other threads may use the allocs map at the same time.
+		mask := interrupt.Disable()
+		defer interrupt.Restore(mask)
+	}
 	buf := make([]byte, size)
 	ptr := unsafe.Pointer(&buf[0])
 	allocs[uintptr(ptr)] = buf
@@ -26,6 +34,10 @@ func libc_free(ptr unsafe.Pointer) {
 	if ptr == nil {
 		return
 	}
+	if hasParallelism {
+		mask := interrupt.Disable()
+		defer interrupt.Restore(mask)
+	}
 	if _, ok := allocs[uintptr(ptr)]; ok {
 		delete(allocs, uintptr(ptr))
 	} else {
@@ -45,6 +57,10 @@ func libc_realloc(oldPtr unsafe.Pointer, size uintptr) unsafe.Pointer {
 		libc_free(oldPtr)
 		return nil
 	}
+	if hasParallelism {
+		mask := interrupt.Disable()
+		defer interrupt.Restore(mask)
+	}
 
 	// It's hard to optimize this to expand the current buffer with our GC, but
 	// it is theoretically possible. For now, just always allocate fresh.
@@ -2,33 +2,6 @@
 
 package runtime
 
-import (
-	"unsafe"
-)
-
-//go:extern _heap_start
-var heapStartSymbol [0]byte
-
-//go:extern _heap_end
-var heapEndSymbol [0]byte
-
-//go:extern _globals_start
-var globalsStartSymbol [0]byte
-
-//go:extern _globals_end
-var globalsEndSymbol [0]byte
-
-//go:extern _stack_top
-var stackTopSymbol [0]byte
-
-var (
-	heapStart    = uintptr(unsafe.Pointer(&heapStartSymbol))
-	heapEnd      = uintptr(unsafe.Pointer(&heapEndSymbol))
-	globalsStart = uintptr(unsafe.Pointer(&globalsStartSymbol))
-	globalsEnd   = uintptr(unsafe.Pointer(&globalsEndSymbol))
-	stackTop     = uintptr(unsafe.Pointer(&stackTopSymbol))
-)
-
 // growHeap tries to grow the heap size. It returns true if it succeeds, false
 // otherwise.
 func growHeap() bool {
@@ -36,22 +9,6 @@ func growHeap() bool {
 	return false
 }
 
-//export malloc
-func libc_malloc(size uintptr) unsafe.Pointer {
-	return alloc(size, nil)
-}
-
-//export calloc
-func libc_calloc(nmemb, size uintptr) unsafe.Pointer {
-	// No difference between calloc and malloc.
-	return libc_malloc(nmemb * size)
-}
-
-//export free
-func libc_free(ptr unsafe.Pointer) {
-	free(ptr)
-}
-
 //export runtime_putchar
 func runtime_putchar(c byte) {
 	putchar(c)
@@ -64,6 +21,23 @@ func syscall_Exit(code int) {
 
 const baremetal = true
 
+// The memory profile uses RAM, so it must be enabled explicitly by setting
+// MemProfileRate.
+const defaultMemProfileRate = 0
+
+// crashStackEnd returns the end of the stack that sp points into, so that the
+// crash handler doesn't read past it. Goroutine stacks are allocated on the
+// heap.
+func crashStackEnd(sp uintptr) uintptr {
+	if sp >= heapStart && sp < heapEnd {
+		return heapEnd
+	}
+	if sp < stackTop {
+		return stackTop
+	}
+	return 0
+}
+
 // timeOffset is how long the monotonic clock started after the Unix epoch. It
 // should be a positive integer under normal operation or zero when it has not
 // been set.
@@ -0,0 +1,51 @@
+//go:build baremetal && !tinygo.rtos
+
+package runtime
+
+// This file contains the parts of the baremetal runtime that assume TinyGo owns
+// the whole chip: the memory layout comes from the TinyGo linker script and the
+// C library used by TinyGo is the only one in the program. Neither is true when
+// running as a task in existing RTOS firmware (see runtime_cortexm_rtos.go).
+
+import (
+	"unsafe"
+)
+
+//go:extern _heap_start
+var heapStartSymbol [0]byte
+
+//go:extern _heap_end
+var heapEndSymbol [0]byte
+
+//go:extern _globals_start
+var globalsStartSymbol [0]byte
+
+//go:extern _globals_end
+var globalsEndSymbol [0]byte
+
+//go:extern _stack_top
+var stackTopSymbol [0]byte
+
+var (
+	heapStart    = uintptr(unsafe.Pointer(&heapStartSymbol))
+	heapEnd      = uintptr(unsafe.Pointer(&heapEndSymbol))
+	globalsStart = uintptr(unsafe.Pointer(&globalsStartSymbol))
+	globalsEnd   = uintptr(unsafe.Pointer(&globalsEndSymbol))
+	stackTop     = uintptr(unsafe.Pointer(&stackTopSymbol))
+)
+
+//export malloc
+func libc_malloc(size uintptr) unsafe.Pointer {
+	return alloc(size, nil)
+}
+
+//export calloc
+func libc_calloc(nmemb, size uintptr) unsafe.Pointer {
+	// No difference between calloc and malloc.
+	return libc_malloc(nmemb * size)
+}
+
+//export free
+func libc_free(ptr unsafe.Pointer) {
+	free(ptr)
+}
@@ -22,6 +22,17 @@ package runtime
 // the 'comma-ok' value to true.
 // A receive operation on a closed channel is completed by zeroing the data
 // element of the receiving task and setting the 'comma-ok' value to false.
+//
+// Channels may be used to pass values from an interrupt handler to a
+// goroutine, as long as the operation in the interrupt never waits: a send on
+// a buffered channel with room in the buffer (or with a waiting receiver), or
+// a select statement with a default case. All channel operations disable
+// interrupts while they update the channel, so an interrupt never sees a
+// channel in an inconsistent state and the interrupt itself never has to wait
+// for a lock. A goroutine that is woken up this way is added to the runqueue
+// and runs as soon as the interrupt returns to the scheduler. Operations that
+// would block panic when called inside an interrupt, as an interrupt can't be
+// paused.
 
 import (
 	"internal/task"
@@ -137,6 +148,21 @@ func chanMake(elementSize uintptr, bufSize uintptr) *channel {
 	}
 }
 
+// chanMakeStatic initializes a channel that was created in a package
+// initializer with a constant size. The compiler allocates the channel and its
+// buffer (if any) as globals instead of on the heap.
+func chanMakeStatic(ch *channel, elementSize uintptr, bufSize uintptr, buf unsafe.Pointer) *channel {
+	ch.elementSize = elementSize
+	ch.bufSize = bufSize
+	ch.buf = buf
+	return ch
+}
+
+// wrapper for use in reflect
+func chanMakeUnsafePointer(elementSize uintptr, bufSize uintptr) unsafe.Pointer {
+	return unsafe.Pointer(chanMake(elementSize, bufSize))
+}
+
 // Return the number of entries in this chan, called from the len builtin.
 // A nil chan is defined as having length 0.
 //
@@ -196,7 +222,7 @@ func (ch *channel) resumeRX(ok bool) unsafe.Pointer {
 	}
 
 	// push task onto runqueue
-	runqueue.Push(b.t)
+	runqueuePushBack(b.t)
 
 	return dst
 }
@@ -223,7 +249,7 @@ func (ch *channel) resumeTX() unsafe.Pointer {
 	}
 
 	// push task onto runqueue
-	runqueue.Push(b.t)
+	runqueuePushBack(b.t)
 
 	return src
 }
@@ -471,6 +497,11 @@ func chanSend(ch *channel, value unsafe.Pointer, blockedlist *channelBlockedList
 		return
 	}
 
+	if interrupt.In() {
+		interrupt.Restore(i)
+		runtimePanic("blocking channel send inside interrupt")
+	}
+
 	if ch == nil {
 		// A nil channel blocks forever. Do not schedule this goroutine again.
 		interrupt.Restore(i)
@@ -488,7 +519,9 @@ func chanSend(ch *channel, value unsafe.Pointer, blockedlist *channelBlockedList
 	ch.blocked = blockedlist
 	chanDebug(ch)
 	interrupt.Restore(i)
-	task.Pause()
+	start := blockEventStart()
+	task.Wait(task.WaitChanSend, unsafe.Pointer(ch))
+	blockEvent(start, returnAddress(0))
 	sender.Ptr = nil
 }
 
@@ -506,6 +539,11 @@ func chanRecv(ch *channel, value unsafe.Pointer, blockedlist *channelBlockedList
 		return ok
 	}
 
+	if interrupt.In() {
+		interrupt.Restore(i)
+		runtimePanic("blocking channel receive inside interrupt")
+	}
+
 	if ch == nil {
 		// A nil channel blocks forever. Do not schedule this goroutine again.
 		interrupt.Restore(i)
@@ -523,7 +561,9 @@ func chanRecv(ch *channel, value unsafe.Pointer, blockedlist *channelBlockedList
 	ch.blocked = blockedlist
 	chanDebug(ch)
 	interrupt.Restore(i)
-	task.Pause()
+	start := blockEventStart()
+	task.Wait(task.WaitChanReceive, unsafe.Pointer(ch))
+	blockEvent(start, returnAddress(0))
 	ok := receiver.Data == 1
 	receiver.Ptr, receiver.Data = nil, 0
 	return ok
@@ -563,12 +603,17 @@ func chanClose(ch *channel) {
 	chanDebug(ch)
 }
 
+// wrapper for use in reflect
+func chanCloseUnsafePointer(p unsafe.Pointer) {
+	chanClose((*channel)(p))
+}
+
 // chanSelect is the runtime implementation of the select statement. This is
 // perhaps the most complicated statement in the Go spec. It returns the
 // selected index and the 'comma-ok' value.
 //
-// TODO: do this in a round-robin fashion (as specified in the Go spec) instead
-// of picking the first one that can proceed.
+// If more than one case can proceed immediately, one is chosen pseudo-randomly
+// (see tryChanSelect). Otherwise the first case that becomes ready wins.
 func chanSelect(recvbuf unsafe.Pointer, states []chanSelectState, ops []channelBlockedList) (uintptr, bool) {
 	istate := interrupt.Disable()
 
@@ -578,12 +623,19 @@ func chanSelect(recvbuf unsafe.Pointer, states []chanSelectState, ops []channelB
 		return selected, ok
 	}
 
+	if interrupt.In() {
+		interrupt.Restore(istate)
+		runtimePanic("blocking select inside interrupt")
+	}
+
 	// construct blocked operations
 	for i, v := range states {
 		if v.ch == nil {
 			// A nil channel receive will never complete.
 			// A nil channel send would have panicked during tryChanSelect.
-			ops[i] = channelBlockedList{}
+			// The entry is still part of the select, so that all channels
+			// can be found from ops[0] (see dumpGoroutines).
+			ops[i] = channelBlockedList{allSelectOps: ops}
 			continue
 		}
 
@@ -629,31 +681,62 @@ func chanSelect(recvbuf unsafe.Pointer, states []chanSelectState, ops []channelB
 
 	// wait for one case to fire
 	interrupt.Restore(istate)
-	task.Pause()
+	start := blockEventStart()
+	task.Wait(task.WaitSelect, unsafe.Pointer(&ops[0]))
+	blockEvent(start, returnAddress(0))
 
 	// figure out which one fired and return the ok value
 	return (uintptr(t.Ptr) - uintptr(unsafe.Pointer(&states[0]))) / unsafe.Sizeof(chanSelectState{}), t.Data != 0
 }
 
+// chanSelectUnsafePointer is the n-way select entry point used by
+// reflect.Select, a wrapper around chanSelect and tryChanSelect. The states pointer points to an array of numStates
+// chanSelectState structs. When block is false and no case can proceed, the
+// returned index is ^uintptr(0).
+func chanSelectUnsafePointer(recvbuf, states unsafe.Pointer, numStates uintptr, block bool) (uintptr, bool) {
+	s := unsafe.Slice((*chanSelectState)(states), numStates)
+	if !block {
+		return tryChanSelect(recvbuf, s)
+	}
+	if numStates == 0 {
+		// Like select {}, this blocks forever.
+		deadlock()
+	}
+	return chanSelect(recvbuf, s, make([]channelBlockedList, numStates))
+}
+
 // tryChanSelect is like chanSelect, but it does a non-blocking select operation.
+//
+// The cases are tried starting at a pseudo-random index, so that when several
+// cases are ready at the same time, each of them has a chance to be chosen
+// (as required by the Go spec) and later cases are not starved.
 func tryChanSelect(recvbuf unsafe.Pointer, states []chanSelectState) (uintptr, bool) {
 	istate := interrupt.Disable()
 
 	// See whether we can receive from one of the channels.
-	for i, state := range states {
+	start := uintptr(0)
+	if len(states) > 1 {
+		start = uintptr(fastrand()) % uintptr(len(states))
+	}
+	for n := uintptr(0); n < uintptr(len(states)); n++ {
+		i := start + n
+		if i >= uintptr(len(states)) {
+			i -= uintptr(len(states))
+		}
+		state := states[i]
 		if state.value == nil {
 			// A receive operation.
 			if rx, ok := state.ch.tryRecv(recvbuf); rx {
 				chanDebug(state.ch)
 				interrupt.Restore(istate)
-				return uintptr(i), ok
+				return i, ok
 			}
 		} else {
 			// A send operation: state.value is not nil.
 			if state.ch.trySend(state.value) {
 				chanDebug(state.ch)
 				interrupt.Restore(istate)
-				return uintptr(i), true
+				return i, true
 			}
 		}
 	}
@@ -74,7 +74,7 @@ func (c *Cond) Wait() {
 			// Condition variable has not been notified.
 			// Block the current task on the condition variable.
 			if atomic.CompareAndSwapPointer((*unsafe.Pointer)(unsafe.Pointer(&c.t)), nil, unsafe.Pointer(cur)) {
-				task.Pause()
+				task.Wait(task.WaitCond, unsafe.Pointer(c))
 				return
 			}
 		case &notifiedPlaceholder:
@@ -0,0 +1,97 @@
+package runtime
+
+// This file implements the runtime side of CPU profiling. While profiling is
+// on, a timer interrupt (or a signal on hosted systems) records the PC it
+// interrupted in a ring buffer. The runtime/pprof package reads the samples
+// from this buffer and writes them out as a profile.
+
+import "runtime/volatile"
+
+var (
+	cpuProfileRate int      // samples per second, or 0 when profiling is off
+	cpuProfileBuf  []uint64 // ring buffer, allocated when profiling starts
+	cpuProfileHead uint32   // number of written samples, only modified by cpuProfileSample
+	cpuProfileTail uint32   // number of read samples, only modified by pprof_readProfile
+	cpuProfileLost uint32   // number of samples dropped because the buffer was full
+)
+
+// SetCPUProfileRate sets the CPU profiling rate to hz samples per second.
+// If hz <= 0, SetCPUProfileRate turns off profiling.
+// If the profiler is on, the rate cannot be changed without first turning it off.
+//
+// Most clients should use the runtime/pprof package instead of calling
+// SetCPUProfileRate directly. CPU profiling is supported on Linux, macOS and
+// FreeBSD, and on Cortex-M when building with -tags=cpuprofile. On other
+// systems no samples are recorded.
+func SetCPUProfileRate(hz int) {
+	if hz > 1000000 {
+		hz = 1000000
+	}
+	if hz > 0 {
+		if cpuProfileRate != 0 {
+			println("runtime: cannot set cpu profile rate until previous profile has finished.")
+			return
+		}
+		if !cpuProfileStart(hz) {
+			return
+		}
+		// Samples are ignored until cpuProfileRate is set below.
+		if cpuProfileBuf == nil {
+			// Microcontrollers have little RAM to spare.
+			size := 1024
+			if baremetal {
+				size = 256
+			}
+			cpuProfileBuf = make([]uint64, size)
+		}
+		cpuProfileHead = 0
+		cpuProfileTail = 0
+		cpuProfileLost = 0
+		cpuProfileRate = hz
+	} else if cpuProfileRate != 0 {
+		cpuProfileStop()
+		cpuProfileRate = 0
+	}
+}
+
+// cpuProfileSample records a single sample. It is called from an interrupt or
+// signal handler, so it must not allocate or block.
+func cpuProfileSample(pc uintptr) {
+	if cpuProfileRate == 0 {
+		// A late sample, after profiling was turned off.
+		return
+	}
+	head := cpuProfileHead
+	if head-volatile.LoadUint32(&cpuProfileTail) >= uint32(len(cpuProfileBuf)) {
+		// The buffer is full, runtime/pprof didn't keep up.
+		volatile.StoreUint32(&cpuProfileLost, cpuProfileLost+1)
+		return
+	}
+	volatile.StoreUint64(&cpuProfileBuf[head%uint32(len(cpuProfileBuf))], uint64(pc))
+	volatile.StoreUint32(&cpuProfileHead, head+1)
+}
+
+// pprof_readProfile copies the buffered samples into buf and returns the
+// number of copied samples, the number of samples that were dropped since
+// profiling started, and whether profiling is still on.
+//
+//go:linkname pprof_readProfile runtime/pprof.readProfile
+func pprof_readProfile(buf []uintptr) (n int, lost uint32, running bool) {
+	head := volatile.LoadUint32(&cpuProfileHead)
+	tail := cpuProfileTail
+	for tail != head && n < len(buf) {
+		buf[n] = uintptr(volatile.LoadUint64(&cpuProfileBuf[tail%uint32(len(cpuProfileBuf))]))
+		n++
+		tail++
+	}
+	volatile.StoreUint32(&cpuProfileTail, tail)
+	return n, volatile.LoadUint32(&cpuProfileLost), cpuProfileRate != 0
+}
+
+// pprof_hasScheduler returns whether goroutines can be started, so that
+// runtime/pprof can read samples in the background.
+//
+//go:linkname pprof_hasScheduler runtime/pprof.hasScheduler
+func pprof_hasScheduler() bool {
+	return hasScheduler
+}
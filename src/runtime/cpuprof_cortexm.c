@@ -0,0 +1,27 @@
+//go:build cortexm && cpuprofile && !tinygo.rtos && !(nxp && mk66f18) && !mimxrt1062
+
+// SysTick handler for CPU profiling. The processor pushed the interrupted PC
+// to the stack on exception entry: bit 2 of EXC_RETURN (in lr) tells whether
+// that was the process stack (used by goroutines) or the main stack.
+// The handler tail-calls tinygo_cpuProfileSignal, which returns directly from
+// the exception using the EXC_RETURN value still in lr.
+// Only Thumb-1 instructions are used, so that this also works on Cortex-M0.
+
+__attribute__((naked))
+void SysTick_Handler(void) {
+    __asm__ volatile(
+        "movs r0, #4\n"
+        "mov  r1, lr\n"
+        "tst  r0, r1\n"
+        "beq  1f\n"
+        "mrs  r0, psp\n"
+        "b    2f\n"
+        "1:\n"
+        "mrs  r0, msp\n"
+        "2:\n"
+        "ldr  r0, [r0, #24]\n" // stacked PC
+        "ldr  r1, =tinygo_cpuProfileSignal\n"
+        "bx   r1\n"
+        ".ltorg\n"
+    );
+}
@@ -0,0 +1,63 @@
+//go:build cortexm && cpuprofile && !tinygo.rtos && !(nxp && mk66f18) && !mimxrt1062
+
+package runtime
+
+// CPU profiling on Cortex-M, enabled with -tags=cpuprofile. The SysTick timer
+// interrupts the program hz times per second, and its handler (in
+// cpuprof_cortexm.c) records the PC from the exception stack frame. This
+// takes over SysTick, so it isn't available on chips where the runtime uses
+// SysTick for timekeeping.
+
+import (
+	"C" // dummy import so that cpuprof_cortexm.c works
+	"device/arm"
+)
+
+func cpuProfileStart(hz int) bool {
+	// SysTick counts processor clock cycles, but the runtime doesn't know the
+	// clock frequency of every chip. Measure it against ticks() instead, by
+	// letting SysTick count down for about a millisecond.
+	arm.SYST.SYST_CSR.Set(0)
+	arm.SYST.SYST_RVR.Set(arm.SYST_RVR_RELOAD_Msk)
+	arm.SYST.SYST_CVR.Set(0) // also clears COUNTFLAG
+	arm.SYST.SYST_CSR.Set(arm.SYST_CSR_CLKSOURCE | arm.SYST_CSR_ENABLE)
+	start := ticks()
+	wait := nanosecondsToTicks(1e6)
+	for {
+		current := arm.SYST.SYST_CVR.Get()
+		elapsed := ticks() - start
+		if elapsed >= wait {
+			cycles := arm.SYST_RVR_RELOAD_Msk - current
+			cyclesPerSecond := uint64(cycles) * 1e9 / uint64(ticksToNanoseconds(elapsed))
+			period := cyclesPerSecond / uint64(hz)
+			if period < 1000 {
+				// Leave some time for the program itself.
+				period = 1000
+			} else if period > arm.SYST_RVR_RELOAD_Msk+1 {
+				period = arm.SYST_RVR_RELOAD_Msk + 1
+			}
+			arm.SYST.SYST_CSR.Set(0)
+			arm.SYST.SYST_RVR.Set(uint32(period - 1))
+			arm.SYST.SYST_CVR.Set(0)
+			arm.SYST.SYST_CSR.Set(arm.SYST_CSR_CLKSOURCE | arm.SYST_CSR_TICKINT | arm.SYST_CSR_ENABLE)
+			return true
+		}
+		if arm.SYST.SYST_CSR.HasBits(arm.SYST_CSR_COUNTFLAG) {
+			// SysTick wrapped before a millisecond passed according to
+			// ticks(), so ticks() doesn't advance (as in some emulators).
+			arm.SYST.SYST_CSR.Set(0)
+			return false
+		}
+	}
+}
+
+func cpuProfileStop() {
+	arm.SYST.SYST_CSR.Set(0)
+}
+
+// Called from the SysTick handler with the interrupted PC.
+//
+//export tinygo_cpuProfileSignal
+func cpuProfileSignal(pc uintptr) {
+	cpuProfileSample(pc)
+}
@@ -0,0 +1,13 @@
+//go:build !((darwin || freebsd || (linux && !baremetal && !wasi)) && !nintendoswitch) && !(cortexm && cpuprofile && !tinygo.rtos && !(nxp && mk66f18) && !mimxrt1062)
+
+package runtime
+
+// CPU profiling is not supported on this system: there is no way to
+// periodically interrupt the program.
+
+func cpuProfileStart(hz int) bool {
+	return false
+}
+
+func cpuProfileStop() {
+}
@@ -0,0 +1,77 @@
+//go:build (darwin || freebsd || (linux && !baremetal && !wasi)) && !nintendoswitch
+
+// SIGPROF handler for CPU profiling. It records the PC at which the signal
+// interrupted the program.
+
+#define _GNU_SOURCE
+#include <signal.h>
+#include <stdint.h>
+#include <string.h>
+#include <sys/time.h>
+
+void tinygo_cpuProfileSignal(uintptr_t pc);
+
+// Return the PC stored in the signal context, or 0 if it is not known for this
+// system.
+static uintptr_t tinygo_signalPC(void *context) {
+    ucontext_t *uc = context;
+#if defined(__APPLE__) && defined(__x86_64__)
+    return uc->uc_mcontext->__ss.__rip;
+#elif defined(__APPLE__) && defined(__aarch64__)
+    return uc->uc_mcontext->__ss.__pc;
+#elif defined(__FreeBSD__) && defined(__x86_64__)
+    return uc->uc_mcontext.mc_rip;
+#elif defined(__FreeBSD__) && defined(__i386__)
+    return uc->uc_mcontext.mc_eip;
+#elif defined(__FreeBSD__) && defined(__aarch64__)
+    return uc->uc_mcontext.mc_gpregs.gp_elr;
+#elif defined(__FreeBSD__) && defined(__arm__)
+    return uc->uc_mcontext.__gregs[_REG_PC];
+#elif defined(__linux__) && defined(__x86_64__)
+    return uc->uc_mcontext.gregs[REG_RIP];
+#elif defined(__linux__) && defined(__i386__)
+    return uc->uc_mcontext.gregs[REG_EIP];
+#elif defined(__linux__) && defined(__aarch64__)
+    return uc->uc_mcontext.pc;
+#elif defined(__linux__) && defined(__arm__)
+    return uc->uc_mcontext.arm_pc;
+#elif defined(__linux__) && defined(__mips__)
+    return uc->uc_mcontext.pc;
+#else
+    (void)uc;
+    return 0;
+#endif
+}
+
+static void tinygo_profileSignalHandler(int sig, siginfo_t *info, void *context) {
+    (void)sig;
+    (void)info;
+    tinygo_cpuProfileSignal(tinygo_signalPC(context));
+}
+
+// Install the signal handler and start the timer. Returns 0 on success.
+int tinygo_startProfileTimer(int hz) {
+    struct sigaction act;
+    memset(&act, 0, sizeof(act));
+    act.sa_sigaction = tinygo_profileSignalHandler;
+    act.sa_flags = SA_SIGINFO | SA_RESTART;
+    sigemptyset(&act.sa_mask);
+    if (sigaction(SIGPROF, &act, NULL) != 0) {
+        return -1;
+    }
+
+    struct itimerval timer;
+    memset(&timer, 0, sizeof(timer));
+    timer.it_interval.tv_usec = 1000000 / hz;
+    timer.it_value = timer.it_interval;
+    return setitimer(ITIMER_PROF, &timer, NULL);
+}
+
+// Stop the timer. The signal is ignored from now on, in case one is still
+// pending.
+void tinygo_stopProfileTimer(void) {
+    struct itimerval timer;
+    memset(&timer, 0, sizeof(timer));
+    setitimer(ITIMER_PROF, &timer, NULL);
+    signal(SIGPROF, SIG_IGN);
+}
@@ -0,0 +1,30 @@
+//go:build (darwin || freebsd || (linux && !baremetal && !wasi)) && !nintendoswitch
+
+package runtime
+
+import "C" // dummy import so that cpuprof_unix.c works
+
+// CPU profiling on hosted systems uses a SIGPROF signal, sent by the kernel
+// for every 1/hz seconds of CPU time used by the process. The signal handler
+// is implemented in cpuprof_unix.c.
+
+//export tinygo_startProfileTimer
+func startProfileTimer(hz int32) int32
+
+//export tinygo_stopProfileTimer
+func stopProfileTimer()
+
+func cpuProfileStart(hz int) bool {
+	return startProfileTimer(int32(hz)) == 0
+}
+
+func cpuProfileStop() {
+	stopProfileTimer()
+}
+
+// Called from the SIGPROF signal handler with the interrupted PC.
+//
+//export tinygo_cpuProfileSignal
+func cpuProfileSignal(pc uintptr) {
+	cpuProfileSample(pc)
+}
@@ -0,0 +1,109 @@
+package runtime
+
+// This file implements debug.SetCrashHandler: a function that is called when
+// the program crashes (an unrecovered panic, a runtime error or a hardware
+// fault), right before the program is halted or the chip is reset. This can
+// be used to save information about the crash somewhere, like flash memory.
+
+import "unsafe"
+
+// Reasons for a crash, must match debug.CrashReason.
+const (
+	crashReasonNone = iota
+	crashReasonPanic
+	crashReasonFault
+)
+
+// crashReport has the same layout as debug.CrashReport.
+type crashReport struct {
+	reason       uint8
+	message      string
+	pc           uintptr
+	lr           uintptr
+	faultStatus  uint32
+	faultAddress uintptr
+	traceback    []uintptr
+	resetCause   uint32
+}
+
+// Maximum number of stack words passed to the crash handler.
+const crashStackWords = 32
+
+var (
+	crashHandler       func(report *crashReport, stack []uintptr)
+	crashHandlerCalled bool // to avoid calling the handler again when it crashes itself
+
+	// The crash handler can't rely on the heap, so the message and stack are
+	// copied into these buffers.
+	crashMessage [96]byte
+	crashStack   [crashStackWords]uintptr
+)
+
+//go:linkname debug_setCrashHandler runtime/debug.setCrashHandler
+func debug_setCrashHandler(handler func(report *crashReport, stack []uintptr)) {
+	crashHandler = handler
+}
+
+// callPanicHandler calls the crash handler (if there is one) for a panic at pc.
+// The sp is the stack pointer at the time of the panic.
+func callPanicHandler(pc uintptr, message interface{}, sp uintptr) {
+	if crashHandler == nil || crashHandlerCalled {
+		return
+	}
+	crashHandlerCalled = true
+	var msg string
+	switch message := message.(type) {
+	case string:
+		msg = message
+	case error:
+		msg = message.Error()
+	case stringer:
+		msg = message.String()
+	}
+	callCrashHandler(&crashReport{reason: crashReasonPanic, message: msg, pc: pc}, sp)
+}
+
+// callRuntimePanicHandler calls the crash handler (if there is one) for a
+// runtime error like a nil pointer dereference. The sp is the stack pointer at
+// the time of the panic, or 0 if the stack can't be trusted.
+func callRuntimePanicHandler(pc uintptr, msg string, sp uintptr) {
+	if crashHandler == nil || crashHandlerCalled {
+		return
+	}
+	crashHandlerCalled = true
+	n := copy(crashMessage[:], "runtime error: ")
+	n += copy(crashMessage[n:], msg)
+	report := crashReport{
+		reason:  crashReasonPanic,
+		message: unsafe.String(&crashMessage[0], n),
+		pc:      pc,
+	}
+	callCrashHandler(&report, sp)
+}
+
+// callFaultHandler calls the crash handler (if there is one) for a hardware
+// fault. See recordFault for the meaning of the parameters. The sp is the
+// stack pointer at the time of the fault, or 0 if it isn't valid.
+func callFaultHandler(pc, lr uintptr, faultStatus uint32, faultAddress uintptr, sp uintptr) {
+	if crashHandler == nil || crashHandlerCalled {
+		return
+	}
+	crashHandlerCalled = true
+	report := crashReport{
+		reason:       crashReasonFault,
+		pc:           pc,
+		lr:           lr,
+		faultStatus:  faultStatus,
+		faultAddress: faultAddress,
+	}
+	callCrashHandler(&report, sp)
+}
+
+func callCrashHandler(report *crashReport, sp uintptr) {
+	var stack []uintptr
+	if end := crashStackEnd(sp); sp != 0 && end > sp {
+		words := unsafe.Slice((*uintptr)(unsafe.Pointer(sp)), (end-sp)/unsafe.Sizeof(uintptr(0)))
+		stack = crashStack[:copy(crashStack[:], words)]
+	}
+	crashHandler(report, stack)
+}
@@ -0,0 +1,214 @@
+//go:build cortexm && crashreport && !tinygo.rtos
+
+package runtime
+
+// This file implements a crash reporter, enabled with -tags=crashreport. When
+// the program panics or causes a hardware fault, the panic message, the
+// addresses of the crash and a traceback are saved in RAM that is not cleared
+// at startup, and the chip is reset. The next run can retrieve the report with
+// debug.LastCrash, for example to send it to a server, and remove it with
+// debug.ClearCrash.
+//
+// There is no unwind information in the binary, so the traceback is made by
+// scanning the stack for words that look like return addresses: odd (Thumb)
+// addresses in the code section. This may include stale return addresses of
+// functions that already returned, but it is usually enough to see how the
+// crashing function was reached.
+
+import (
+	"runtime/volatile"
+	"unsafe"
+)
+
+const crashReporting = true
+
+// Maximum length of the saved panic message. Longer messages are truncated.
+const crashMessageSize = 96
+
+// Maximum number of return addresses saved in the traceback.
+const crashTracebackSize = 8
+
+// Number of stack words that are searched for return addresses.
+const crashTracebackScan = 256
+
+// Marks a valid crash record. RAM contents are random after power-on, so the
+// record is also protected by a checksum.
+const crashMagic = 0x43524153 // "CRAS"
+
+type crashRecord struct {
+	magic        uint32
+	reason       uint8
+	messageLen   uint8
+	pc           uintptr
+	lr           uintptr
+	faultStatus  uint32
+	faultAddress uintptr
+	traceback    [crashTracebackSize]uintptr
+	message      [crashMessageSize]byte
+	checksum     uint32
+}
+
+// Start and end of the code, set in the linker script.
+//
+//go:extern _stext
+var _stext [0]byte
+
+//go:extern _etext
+var _etext [0]byte
+
+// The crash record is stored in the .noinit section, which is neither loaded
+// nor zeroed at startup so it survives a reset.
+//
+//go:section .noinit
+var crashRecordSaved crashRecord
+
+// Set when a crash was recorded in this run, so that a second crash while
+// handling the first doesn't overwrite the report.
+var crashRecorded bool
+
+// computeChecksum returns a simple checksum (FNV-1a) over all fields but the checksum
+// itself.
+func (r *crashRecord) computeChecksum() uint32 {
+	hash := uint32(2166136261)
+	data := unsafe.Slice((*byte)(unsafe.Pointer(r)), unsafe.Offsetof(r.checksum))
+	for _, c := range data {
+		hash ^= uint32(c)
+		hash *= 16777619
+	}
+	return hash
+}
+
+// valid returns whether the record contains a crash report. Volatile accesses
+// are used for the magic value and checksum because the compiler would
+// otherwise assume the record is zero at startup, like other globals.
+func (r *crashRecord) valid() bool {
+	return volatile.LoadUint32(&r.magic) == crashMagic &&
+		r.reason != crashReasonNone &&
+		int(r.messageLen) <= len(r.message) &&
+		volatile.LoadUint32(&r.checksum) == r.computeChecksum()
+}
+
+// startCrashRecord prepares the crash record for a new crash. It returns nil
+// if this crash should not be recorded. The traceback is taken from the stack
+// starting at sp, if it isn't 0.
+func startCrashRecord(reason uint8, pc, sp uintptr) *crashRecord {
+	if crashRecorded {
+		return nil
+	}
+	crashRecorded = true
+	r := &crashRecordSaved
+	*r = crashRecord{
+		reason: reason,
+		pc:     pc,
+	}
+	r.saveTraceback(sp)
+	return r
+}
+
+// saveTraceback stores the return addresses found on the stack starting at sp.
+func (r *crashRecord) saveTraceback(sp uintptr) {
+	end := crashStackEnd(sp)
+	if sp == 0 || end <= sp {
+		return
+	}
+	if end-sp > crashTracebackScan*unsafe.Sizeof(uintptr(0)) {
+		end = sp + crashTracebackScan*unsafe.Sizeof(uintptr(0))
+	}
+	textStart := uintptr(unsafe.Pointer(&_stext))
+	textEnd := uintptr(unsafe.Pointer(&_etext))
+	n := 0
+	for addr := sp; addr < end && n < len(r.traceback); addr += unsafe.Sizeof(uintptr(0)) {
+		word := *(*uintptr)(unsafe.Pointer(addr))
+		if word&1 == 0 || word < textStart || word >= textEnd {
+			// Not a return address: those have the Thumb bit set and point
+			// into the code.
+			continue
+		}
+		// Point to the call instruction, like the pc.
+		r.traceback[n] = word&^1 - callInstSize
+		n++
+	}
+}
+
+// finish marks the crash record as valid.
+func (r *crashRecord) finish() {
+	volatile.StoreUint32(&r.checksum, r.computeChecksum())
+	volatile.StoreUint32(&r.magic, crashMagic)
+}
+
+func (r *crashRecord) setMessage(msg string) {
+	r.messageLen = uint8(copy(r.message[:], msg))
+}
+
+// recordPanic saves a panic in the crash record. The pc is the address of the
+// panic.
+func recordPanic(pc uintptr, message interface{}) {
+	r := startCrashRecord(crashReasonPanic, pc, getCurrentStackPointer())
+	if r == nil {
+		return
+	}
+	switch msg := message.(type) {
+	case string:
+		r.setMessage(msg)
+	case error:
+		r.setMessage(msg.Error())
+	case stringer:
+		r.setMessage(msg.String())
+	}
+	r.finish()
+}
+
+// recordRuntimePanic saves a runtime error (like a nil pointer dereference) in
+// the crash record.
+func recordRuntimePanic(pc uintptr, msg string) {
+	r := startCrashRecord(crashReasonPanic, pc, getCurrentStackPointer())
+	if r == nil {
+		return
+	}
+	r.setMessage("runtime error: ")
+	r.messageLen += uint8(copy(r.message[r.messageLen:], msg))
+	r.finish()
+}
+
+// recordFault saves a hardware fault in the crash record. The pc is the address
+// of the faulting instruction and lr the return address of the function that
+// faulted, if known. The fault status and address are architecture specific.
+// The sp is the stack pointer at the time of the fault, or 0 if it isn't valid.
+func recordFault(pc, lr uintptr, faultStatus uint32, faultAddress uintptr, sp uintptr) {
+	r := startCrashRecord(crashReasonFault, pc, sp)
+	if r == nil {
+		return
+	}
+	r.lr = lr
+	r.faultStatus = faultStatus
+	r.faultAddress = faultAddress
+	r.finish()
+}
+
+//go:linkname debug_lastCrash runtime/debug.lastCrash
+func debug_lastCrash(report *crashReport) bool {
+	r := &crashRecordSaved
+	if !r.valid() {
+		return false
+	}
+	n := 0
+	for n < len(r.traceback) && r.traceback[n] != 0 {
+		n++
+	}
+	*report = crashReport{
+		reason:       r.reason,
+		message:      string(r.message[:r.messageLen]),
+		pc:           r.pc,
+		lr:           r.lr,
+		faultStatus:  r.faultStatus,
+		faultAddress: r.faultAddress,
+		traceback:    append([]uintptr(nil), r.traceback[:n]...),
+		resetCause:   resetCause(),
+	}
+	return true
+}
+
+//go:linkname debug_clearCrash runtime/debug.clearCrash
+func debug_clearCrash() {
+	volatile.StoreUint32(&crashRecordSaved.magic, 0)
+}
@@ -0,0 +1,23 @@
+//go:build !(cortexm && crashreport && !tinygo.rtos)
+
+package runtime
+
+const crashReporting = false
+
+func recordPanic(pc uintptr, message interface{}) {
+}
+
+func recordRuntimePanic(pc uintptr, msg string) {
+}
+
+func recordFault(pc, lr uintptr, faultStatus uint32, faultAddress uintptr, sp uintptr) {
+}
+
+//go:linkname debug_lastCrash runtime/debug.lastCrash
+func debug_lastCrash(report *crashReport) bool {
+	return false
+}
+
+//go:linkname debug_clearCrash runtime/debug.clearCrash
+func debug_clearCrash() {
+}
@@ -0,0 +1,10 @@
+//go:build cortexm && crashreport && !tinygo.rtos && nrf
+
+package runtime
+
+import "device/nrf"
+
+// resetCause returns the reset reason register, for the crash report.
+func resetCause() uint32 {
+	return nrf.POWER.RESETREAS.Get()
+}
@@ -0,0 +1,8 @@
+//go:build cortexm && crashreport && !tinygo.rtos && !nrf && !rp2040
+
+package runtime
+
+// resetCause returns 0, as the reset cause register of this chip isn't known.
+func resetCause() uint32 {
+	return 0
+}
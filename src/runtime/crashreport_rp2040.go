@@ -0,0 +1,10 @@
+//go:build cortexm && crashreport && !tinygo.rtos && rp2040
+
+package runtime
+
+import "device/rp"
+
+// resetCause returns the chip reset register, for the crash report.
+func resetCause() uint32 {
+	return rp.VREG_AND_CHIP_RESET.CHIP_RESET.Get()
+}
@@ -1,12 +1,13 @@
 package runtime
 
+import "internal/task"
+
 // NumCPU returns the number of logical CPUs usable by the current process.
 //
-// The set of available CPUs is checked by querying the operating system
-// at process startup. Changes to operating system CPU allocation after
-// process startup are not reflected.
+// This is the number of cores that run goroutines, which is only more than one
+// with -scheduler=cores.
 func NumCPU() int {
-	return 1
+	return numCPU
 }
 
 // Stub for NumCgoCall, does not return the real value
@@ -14,7 +15,7 @@ func NumCgoCall() int {
 	return 0
 }
 
-// Stub for NumGoroutine, does not return the real value
+// NumGoroutine returns the number of goroutines that currently exist.
 func NumGoroutine() int {
-	return 1
+	return task.Count()
 }
@@ -0,0 +1,116 @@
+package debug
+
+// CrashReason is the kind of crash stored in a CrashReport.
+type CrashReason uint8
+
+const (
+	CrashNone  CrashReason = iota
+	CrashPanic             // panic that was not recovered
+	CrashFault             // hardware fault, like a HardFault on Cortex-M
+)
+
+func (r CrashReason) String() string {
+	switch r {
+	case CrashPanic:
+		return "panic"
+	case CrashFault:
+		return "fault"
+	default:
+		return "none"
+	}
+}
+
+// CrashReport describes a crash of a previous run of the program. The
+// addresses can be converted to source locations using the ELF file of the
+// program, for example with addr2line.
+//
+// The layout must match crashReport in the runtime.
+type CrashReport struct {
+	Reason CrashReason
+
+	// Message is the panic message, possibly truncated. It is empty for
+	// hardware faults.
+	Message string
+
+	// PC is the address of the panic, or the address of the faulting
+	// instruction. It is 0 if unknown.
+	PC uintptr
+
+	// LR is the return address of the function that caused the fault. It is 0
+	// for panics, or if unknown.
+	LR uintptr
+
+	// FaultStatus and FaultAddress are architecture specific details of a
+	// hardware fault. On Cortex-M, they are the CFSR register and the MMFAR or
+	// BFAR register (whichever is valid).
+	FaultStatus  uint32
+	FaultAddress uintptr
+
+	// Traceback contains return addresses found on the stack at the time of
+	// the crash, most recent call first. They are found by scanning the stack,
+	// so some of them may be left over from calls that already returned. It is
+	// only filled in for reports returned by LastCrash.
+	Traceback []uintptr
+
+	// ResetCause is the value of the reset cause register of the chip when
+	// the report was read (RESETREAS on nRF chips, CHIP_RESET on the RP2040),
+	// or 0 on other chips. It normally says the chip was reset by software,
+	// which the crash reporter does after saving the report. A different
+	// cause, like the watchdog or a brown-out, means the chip didn't get to
+	// reset itself normally. It is only filled in by LastCrash.
+	ResetCause uint32
+}
+
+// LastCrash returns the crash report saved by a previous run of the program,
+// if there is one. The report stays available until it is removed with
+// ClearCrash, or replaced by a new crash.
+//
+// This is a TinyGo extension. Crash reports are only saved when building for a
+// Cortex-M chip with -tags=crashreport. With this tag, the chip is reset after
+// a crash instead of halting, and the report is kept in RAM that is not cleared
+// at startup, so it is lost when the chip loses power.
+func LastCrash() (report CrashReport, ok bool) {
+	ok = lastCrash(&report)
+	return
+}
+
+// ClearCrash removes the saved crash report, for example after it was sent to
+// a server. This is a TinyGo extension, see LastCrash.
+func ClearCrash() {
+	clearCrash()
+}
+
+// SetCrashHandler sets a function that is called when the program crashes
+// because of an unrecovered panic, a runtime error or a hardware fault. It is
+// called after the crash was printed, right before the program is halted (or
+// the chip is reset, with -tags=crashreport). A nil handler removes the
+// current handler.
+//
+// The handler receives a description of the crash and, on baremetal targets, a
+// copy of the first words of the stack at the time of the crash (nil if the
+// stack couldn't be read). The program is in an unknown state when the handler
+// is called, possibly inside an interrupt, so the handler must not block or
+// allocate heap memory. It can for example write the report to flash, or
+// reset the chip itself. A crash inside the handler doesn't call it again.
+//
+// This is a TinyGo extension.
+func SetCrashHandler(handler func(report CrashReport, stack []uintptr)) {
+	crashHandler = handler
+	if handler == nil {
+		setCrashHandler(nil)
+	} else {
+		setCrashHandler(callCrashHandler)
+	}
+}
+
+var crashHandler func(report CrashReport, stack []uintptr)
+
+func callCrashHandler(report *CrashReport, stack []uintptr) {
+	crashHandler(*report, stack)
+}
+
+func lastCrash(report *CrashReport) bool // implemented in package runtime
+
+func setCrashHandler(handler func(report *CrashReport, stack []uintptr)) // implemented in package runtime
+
+func clearCrash() // implemented in package runtime
@@ -21,6 +21,19 @@ func Stack() []byte {
 	return nil
 }
 
+// PrintAllocSites prints the n places in the program that allocated the most
+// heap memory in the most recent allocations, or all of them if n is negative.
+// The addresses can be converted to source locations using the ELF file of
+// the program, for example with addr2line.
+//
+// This is a TinyGo extension. It is only available when building with
+// -tags=allocs_trace, otherwise it prints a message explaining how to enable it.
+func PrintAllocSites(n int) {
+	printAllocSites(n)
+}
+
+func printAllocSites(n int) // implemented in package runtime
+
 // ReadBuildInfo returns the build information embedded
 // in the running binary. The information is available only
 // in binaries built with module support.
@@ -54,7 +67,30 @@ type Module struct {
 	Replace *Module // replaced by this module
 }
 
-// Not implemented.
-func SetGCPercent(n int) int {
-	return n
+// SetGCPercent sets the garbage collection target percentage and returns the
+// previous setting. The initial setting is 100. In TinyGo, a collection cycle
+// is only started when the heap is full. Afterwards the heap is grown (if the
+// system allows it) until at least this percentage of the live heap size is
+// free, so a higher percentage means fewer collection cycles but a bigger
+// heap. A negative percentage disables garbage collection: the heap is grown
+// instead, and memory is only collected when the heap can't grow any further.
+func SetGCPercent(percent int) int {
+	return setGCPercent(percent)
 }
+
+func setGCPercent(percent int) int // implemented in package runtime
+
+// SetMemoryLimit sets a limit on the number of bytes used by heap objects, and
+// returns the previously set limit. A negative input does not change the
+// limit, and can be used to query it. The initial limit is math.MaxInt64.
+//
+// Unlike in upstream Go, this is a hard limit: the heap doesn't grow beyond
+// it, and an allocation that would go over the limit even after a collection
+// cycle fails with an out of memory error. This can be used to reserve part
+// of a large (for example external) RAM for other purposes. Only the
+// conservative and precise garbage collectors enforce the limit.
+func SetMemoryLimit(limit int64) int64 {
+	return setMemoryLimit(limit)
+}
+
+func setMemoryLimit(limit int64) int64 // implemented in package runtime
@@ -1,7 +1,17 @@
 package runtime
 
+// Callers fills the slice pc with the return program counters of function
+// invocations on the calling goroutine's stack. The argument skip is the number
+// of stack frames to skip before recording in pc, with 0 identifying the frame
+// for Callers itself and 1 identifying the caller of Callers. It returns the
+// number of entries written to pc.
+//
+// The stack can only be walked when building with -linetable, otherwise
+// Callers always returns 0.
+//
+//go:noinline
 func Callers(skip int, pc []uintptr) int {
-	return 0
+	return callers(skip+1, pc)
 }
 
 // buildVersion is the Tinygo tree's version string at build time.
@@ -0,0 +1,138 @@
+//go:build baremetal && tinygo.extram
+
+package runtime
+
+// This file implements a separate heap in external RAM (PSRAM or SDRAM), for
+// boards that have it. The location is set in the target JSON file using
+// "external-ram-start" and "external-ram-size".
+//
+// External RAM is usually a lot bigger but also a lot slower than internal
+// SRAM, so the GC heap stays in SRAM and the external RAM is only used for
+// memory that is explicitly allocated with extram.Alloc: typically large
+// buffers like framebuffers and audio buffers. This memory isn't managed by the
+// GC: it is never scanned for pointers and must be freed using extram.Free.
+//
+// The allocator is a simple first-fit allocator with a free list that is
+// sorted by address, so that adjacent free chunks can be merged when memory is
+// freed. This is slow when there are many chunks, but large buffers are usually
+// few and long-lived.
+//
+// External RAM often needs to be configured before it can be used (for example
+// the FMC on STM32 chips or the SPI RAM cache on the ESP32). This is not done
+// by the runtime, so it must be done before the first call to extram.Alloc.
+
+import (
+	"runtime/interrupt"
+	"unsafe"
+)
+
+//go:extern _extram_start
+var extramStartSymbol [0]byte
+
+//go:extern _extram_end
+var extramEndSymbol [0]byte
+
+// extramChunk is the header of every chunk of external RAM, both allocated and
+// free.
+type extramChunk struct {
+	size uintptr      // size of the chunk in bytes, including the header
+	next *extramChunk // next free chunk (only for free chunks)
+}
+
+var (
+	extramFree        *extramChunk // free chunks, sorted by address
+	extramInitialized bool
+)
+
+var extramHeaderSize = align(unsafe.Sizeof(extramChunk{}))
+
+// extram_Alloc allocates size bytes of zeroed memory in external RAM, or
+// returns nil if there is not enough external RAM left.
+//
+//go:linkname extram_Alloc runtime/extram.Alloc
+func extram_Alloc(size uintptr) unsafe.Pointer {
+	if size == 0 {
+		// Return a unique pointer, like a normal allocation.
+		size = 1
+	}
+	size = extramHeaderSize + align(size)
+
+	mask := interrupt.Disable()
+	if !extramInitialized {
+		// Initialize lazily, as external RAM may not be usable at startup.
+		extramInitialized = true
+		start := align(uintptr(unsafe.Pointer(&extramStartSymbol)))
+		end := uintptr(unsafe.Pointer(&extramEndSymbol))
+		if end > start+extramHeaderSize {
+			extramFree = (*extramChunk)(unsafe.Pointer(start))
+			extramFree.size = (end - start) &^ (extramHeaderSize - 1)
+			extramFree.next = nil
+		}
+	}
+
+	// Find the first free chunk that is big enough.
+	link := &extramFree
+	for *link != nil && (*link).size < size {
+		link = &(*link).next
+	}
+	chunk := *link
+	if chunk == nil {
+		interrupt.Restore(mask)
+		return nil
+	}
+	if chunk.size-size >= 2*extramHeaderSize {
+		// Split off the rest of the chunk, if it is big enough to be useful.
+		rest := (*extramChunk)(unsafe.Add(unsafe.Pointer(chunk), size))
+		rest.size = chunk.size - size
+		rest.next = chunk.next
+		chunk.size = size
+		*link = rest
+	} else {
+		*link = chunk.next
+	}
+	chunk.next = nil
+	interrupt.Restore(mask)
+
+	ptr := unsafe.Add(unsafe.Pointer(chunk), extramHeaderSize)
+	memzero(ptr, chunk.size-extramHeaderSize)
+	return ptr
+}
+
+// extram_Free frees memory that was allocated with extram_Alloc.
+//
+//go:linkname extram_Free runtime/extram.Free
+func extram_Free(ptr unsafe.Pointer) {
+	if ptr == nil {
+		return
+	}
+	chunk := (*extramChunk)(unsafe.Add(ptr, -int(extramHeaderSize)))
+
+	mask := interrupt.Disable()
+
+	// Find the free chunks right before and after this chunk.
+	var prev *extramChunk
+	next := extramFree
+	for next != nil && uintptr(unsafe.Pointer(next)) < uintptr(unsafe.Pointer(chunk)) {
+		prev, next = next, next.next
+	}
+
+	// Insert the chunk, merging it with the next free chunk if they're
+	// adjacent.
+	chunk.next = next
+	if next != nil && uintptr(unsafe.Pointer(chunk))+chunk.size == uintptr(unsafe.Pointer(next)) {
+		chunk.size += next.size
+		chunk.next = next.next
+	}
+
+	// Merge the chunk with the previous free chunk if they're adjacent.
+	if prev == nil {
+		extramFree = chunk
+	} else if uintptr(unsafe.Pointer(prev))+prev.size == uintptr(unsafe.Pointer(chunk)) {
+		prev.size += chunk.size
+		prev.next = chunk.next
+	} else {
+		prev.next = chunk
+	}
+
+	interrupt.Restore(mask)
+}
@@ -0,0 +1,27 @@
+// Package extram allocates memory in the external RAM (PSRAM or SDRAM) of
+// boards that have it. The location of the external RAM is set in the target
+// JSON file using "external-ram-start" and "external-ram-size".
+//
+// External RAM is usually a lot bigger but also a lot slower than internal
+// SRAM, so the GC heap stays in SRAM and only memory allocated with Alloc is
+// placed in external RAM: typically large buffers like framebuffers and audio
+// buffers. On targets without external RAM, Alloc and Free use the normal heap.
+//
+// External RAM often needs to be configured before it can be used (for example
+// the FMC on STM32 chips or the SPI RAM cache on the ESP32). This is not done
+// by TinyGo, so it must be done before the first call to Alloc.
+package extram
+
+import "unsafe"
+
+// Alloc allocates size bytes of zeroed memory in external RAM. It returns nil
+// if there is not enough external RAM left, so that the caller can fall back to
+// a normal allocation.
+//
+// The memory is not scanned by the GC, so it must not contain the only
+// reference to a heap object. It stays allocated until it is freed using Free.
+func Alloc(size uintptr) unsafe.Pointer // implemented in package runtime
+
+// Free frees memory that was allocated with Alloc. The memory must not be used
+// afterwards.
+func Free(ptr unsafe.Pointer) // implemented in package runtime
@@ -0,0 +1,21 @@
+//go:build !baremetal || !tinygo.extram
+
+package runtime
+
+import "unsafe"
+
+// extram_Alloc allocates size bytes of zeroed memory, see extram.go. This
+// target has no external RAM, so the memory is allocated on the normal heap
+// instead.
+//
+//go:linkname extram_Alloc runtime/extram.Alloc
+func extram_Alloc(size uintptr) unsafe.Pointer {
+	return alloc(size, nil)
+}
+
+// extram_Free frees memory that was allocated with extram_Alloc.
+//
+//go:linkname extram_Free runtime/extram.Free
+func extram_Free(ptr unsafe.Pointer) {
+	free(ptr)
+}
@@ -1,9 +1,10 @@
-//go:build gc.conservative || gc.precise
+//go:build gc.conservative || gc.precise || gc.incremental || gc.compacting
 
 package runtime
 
 // This memory manager is a textbook mark/sweep implementation, heavily inspired
-// by the MicroPython garbage collector.
+// by the MicroPython garbage collector. With -gc=incremental, the mark phase is
+// split into small slices (see gc_incremental.go).
 //
 // The memory manager internally uses blocks of 4 pointers big (see
 // bytesPerBlock). Every allocation first rounds up to this size to align every
@@ -12,6 +13,8 @@ package runtime
 // and the following ones (if any) as the "tail" (see below). If it cannot find
 // any free space, it will perform a garbage collection cycle and try again. If
 // it still cannot find any free space, it gives up.
+// With -allocator=tlsf, free blocks are found using segregated free lists
+// instead of a linear scan (see gc_tlsf.go).
 //
 // Every block has some metadata, which is stored at the end of the heap.
 // The four states are "free", "head", "tail", and "mark". During normal
@@ -55,6 +58,11 @@ var (
 	gcTotalAlloc  uint64         // total number of bytes allocated
 	gcMallocs     uint64         // total number of allocations
 	gcFrees       uint64         // total number of objects freed
+	gcPauseTotal  uint64         // total time spent in GC cycles, in nanoseconds
+	gcLastPause   uint64         // duration of the last GC pause, in nanoseconds
+	gcNumGC       uint32         // number of completed GC cycles
+	gcHeapInuse   uintptr        // number of bytes in blocks that are in use
+	gcHeapLive    uintptr        // number of bytes in use right after the last GC cycle
 )
 
 // zeroSizedAlloc is just a sentinel that gets returned when allocating 0 bytes.
@@ -201,11 +209,17 @@ func initHeap() {
 	// Set all block states to 'free'.
 	metadataSize := heapEnd - uintptr(metadataStart)
 	memzero(unsafe.Pointer(metadataStart), metadataSize)
+
+	if gcTLSF {
+		tlsfRebuild()
+	}
 }
 
 // setHeapEnd is called to expand the heap. The heap can only grow, not shrink.
 // Also, the heap should grow substantially each time otherwise growing the heap
 // will be expensive.
+//
+//go:nowritebarrier
 func setHeapEnd(newHeapEnd uintptr) {
 	if gcAsserts && newHeapEnd <= heapEnd {
 		runtimePanic("gc: setHeapEnd didn't grow the heap")
@@ -232,6 +246,12 @@ func setHeapEnd(newHeapEnd uintptr) {
 	if gcAsserts && uintptr(metadataStart) < uintptr(oldMetadataStart)+oldMetadataSize {
 		runtimePanic("gc: heap did not grow enough at once")
 	}
+
+	if gcTLSF {
+		// Add the new blocks to the free lists. This happens rarely, so
+		// simply rebuild them.
+		tlsfRebuild()
+	}
 }
 
 // calculateHeapAddresses initializes variables such as metadataStart and
@@ -268,6 +288,7 @@ func calculateHeapAddresses() {
 // collection cycle if needed. If no space is free, it panics.
 //
 //go:noinline
+//go:nowritebarrier
 func alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer {
 	if size == 0 {
 		return unsafe.Pointer(&zeroSizedAlloc)
@@ -276,109 +297,220 @@ func alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer {
 	if preciseHeap {
 		size += align(unsafe.Sizeof(layout))
 	}
+	if leakCheck {
+		// Reserve the last word to store the allocation site.
+		size += unsafe.Sizeof(uintptr(0))
+	}
 
 	if interrupt.In() {
 		runtimePanicAt(returnAddress(0), "heap alloc in interrupt")
 	}
 
+	if hasParallelism {
+		// Goroutines on other cores may allocate at the same time. Disabling
+		// interrupts also locks out the other cores.
+		mask := interrupt.Disable()
+		defer interrupt.Restore(mask)
+	}
+
+	if allocsTrace {
+		traceAlloc(returnAddress(0), size)
+	}
+	if MemProfileRate > 0 {
+		memProfileAlloc(returnAddress(0), size)
+	}
+
 	gcTotalAlloc += uint64(size)
 	gcMallocs++
 
 	neededBlocks := (size + (bytesPerBlock - 1)) / bytesPerBlock
 
-	// Continue looping until a run of free blocks has been found that fits the
-	// requested size.
-	index := nextAlloc
-	numFreeBlocks := uintptr(0)
-	heapScanCount := uint8(0)
-	for {
-		if index == nextAlloc {
-			if heapScanCount == 0 {
-				heapScanCount = 1
-			} else if heapScanCount == 1 {
-				// The entire heap has been searched for free memory, but none
-				// could be found. Run a garbage collection cycle to reclaim
-				// free memory and try again.
-				heapScanCount = 2
-				freeBytes := runGC()
-				heapSize := uintptr(metadataStart) - heapStart
-				if freeBytes < heapSize/3 {
-					// Ensure there is at least 33% headroom.
-					// This percentage was arbitrarily chosen, and may need to
-					// be tuned in the future.
-					growHeap()
-				}
-			} else {
-				// Even after garbage collection, no free memory could be found.
-				// Try to increase heap size.
-				if growHeap() {
-					// Success, the heap was increased in size. Try again with a
-					// larger heap.
-				} else {
-					// Unfortunately the heap could not be increased. This
-					// happens on baremetal systems for example (where all
-					// available RAM has already been dedicated to the heap).
+	if gcIncremental {
+		// Start a new GC cycle if the heap is filling up, or do some marking
+		// work if a cycle is already running.
+		gcAllocStep(neededBlocks * bytesPerBlock)
+	}
+
+	if overMemoryLimit(gcHeapInuse + neededBlocks*bytesPerBlock) {
+		// This allocation would go over the limit set with
+		// debug.SetMemoryLimit. Try to free some memory first.
+		runGC()
+		if overMemoryLimit(gcHeapInuse + neededBlocks*bytesPerBlock) {
+			runtimePanicAt(returnAddress(0), "out of memory")
+		}
+	}
+
+	var thisAlloc gcBlock
+	if gcTLSF {
+		// Take a free range from the segregated free lists (see gc_tlsf.go).
+		// A failed lookup in the free lists counts as a search of the entire
+		// heap.
+		heapScanCount := uint8(1)
+		ok := true
+		for {
+			if block, found := tlsfAlloc(neededBlocks); found {
+				thisAlloc = block
+				break
+			}
+			if heapScanCount, ok = allocRetry(heapScanCount, neededBlocks); !ok {
+				runtimePanicAt(returnAddress(0), "out of memory")
+			}
+		}
+	} else {
+		// Continue looping until a run of free blocks has been found that fits
+		// the requested size.
+		index := nextAlloc
+		numFreeBlocks := uintptr(0)
+		heapScanCount := uint8(0)
+		ok := true
+		for {
+			if index == nextAlloc {
+				if heapScanCount, ok = allocRetry(heapScanCount, neededBlocks); !ok {
 					runtimePanicAt(returnAddress(0), "out of memory")
 				}
+				// The heap may have been changed by a GC cycle or by
+				// compaction, so start counting free blocks anew.
+				numFreeBlocks = 0
 			}
-		}
 
-		// Wrap around the end of the heap.
-		if index == endBlock {
-			index = 0
-			// Reset numFreeBlocks as allocations cannot wrap.
-			numFreeBlocks = 0
-			// In rare cases, the initial heap might be so small that there are
-			// no blocks at all. In this case, it's better to jump back to the
-			// start of the loop and try again, until the GC realizes there is
-			// no memory and grows the heap.
-			// This can sometimes happen on WebAssembly, where the initial heap
-			// is created by whatever is left on the last memory page.
-			continue
-		}
+			// Wrap around the end of the heap.
+			if index == endBlock {
+				index = 0
+				// Reset numFreeBlocks as allocations cannot wrap.
+				numFreeBlocks = 0
+				// In rare cases, the initial heap might be so small that there
+				// are no blocks at all. In this case, it's better to jump back
+				// to the start of the loop and try again, until the GC realizes
+				// there is no memory and grows the heap.
+				// This can sometimes happen on WebAssembly, where the initial
+				// heap is created by whatever is left on the last memory page.
+				continue
+			}
 
-		// Is the block we're looking at free?
-		if index.state() != blockStateFree {
-			// This block is in use. Try again from this point.
-			numFreeBlocks = 0
-			index++
-			continue
-		}
-		numFreeBlocks++
-		index++
-
-		// Are we finished?
-		if numFreeBlocks == neededBlocks {
-			// Found a big enough range of free blocks!
-			nextAlloc = index
-			thisAlloc := index - gcBlock(neededBlocks)
-			if gcDebug {
-				println("found memory:", thisAlloc.pointer(), int(size))
+			// Is the block we're looking at free?
+			if index.state() != blockStateFree {
+				// This block is in use. Try again from this point.
+				numFreeBlocks = 0
+				index++
+				continue
 			}
+			numFreeBlocks++
+			index++
 
-			// Set the following blocks as being allocated.
-			thisAlloc.setState(blockStateHead)
-			for i := thisAlloc + 1; i != nextAlloc; i++ {
-				i.setState(blockStateTail)
+			// Are we finished?
+			if numFreeBlocks == neededBlocks {
+				// Found a big enough range of free blocks!
+				nextAlloc = index
+				thisAlloc = index - gcBlock(neededBlocks)
+				break
 			}
+		}
+	}
+	if gcDebug {
+		println("found memory:", thisAlloc.pointer(), int(size))
+	}
+
+	// Set the following blocks as being allocated.
+	gcHeapInuse += neededBlocks * bytesPerBlock
+	if gcMarking {
+		// Objects allocated while an incremental GC cycle is running are
+		// considered live for this cycle.
+		thisAlloc.setState(blockStateMark)
+	} else {
+		thisAlloc.setState(blockStateHead)
+	}
+	for i := thisAlloc + 1; i != thisAlloc+gcBlock(neededBlocks); i++ {
+		i.setState(blockStateTail)
+	}
 
-			// Return a pointer to this allocation.
-			pointer := thisAlloc.pointer()
-			if preciseHeap {
-				// Store the object layout at the start of the object.
-				// TODO: this wastes a little bit of space on systems with
-				// larger-than-pointer alignment requirements.
-				*(*unsafe.Pointer)(pointer) = layout
-				add := align(unsafe.Sizeof(layout))
-				pointer = unsafe.Add(pointer, add)
-				size -= add
+	// Return a pointer to this allocation.
+	pointer := thisAlloc.pointer()
+	if preciseHeap {
+		// Store the object layout at the start of the object.
+		// TODO: this wastes a little bit of space on systems with
+		// larger-than-pointer alignment requirements.
+		*(*unsafe.Pointer)(pointer) = layout
+		add := align(unsafe.Sizeof(layout))
+		pointer = unsafe.Add(pointer, add)
+		size -= add
+	}
+	memzero(pointer, size)
+	if leakCheck {
+		leakCheckSetSite((thisAlloc + gcBlock(neededBlocks)).address(), returnAddress(0))
+	}
+	return pointer
+}
+
+// allocRetry is called by alloc every time the entire heap has been searched
+// for neededBlocks free blocks without success. The first time, it does
+// nothing so that the search can start. After that it runs a GC cycle, then
+// compacts the heap (if supported), and then grows the heap, until it panics
+// when there is nothing left to try. It returns the new value of scanCount, or
+// false if the heap is out of memory.
+func allocRetry(scanCount uint8, neededBlocks uintptr) (uint8, bool) {
+	if scanCount == 0 {
+		return 1, true
+	} else if scanCount == 1 && gcPercent < 0 && gcGrowHeap() {
+		// The GC was turned off with debug.SetGCPercent(-1), and the heap
+		// could be grown instead. Continue searching in the new part of the
+		// heap.
+		return 1, true
+	} else if scanCount == 1 {
+		// The entire heap has been searched for free memory, but none could
+		// be found. Run a garbage collection cycle to reclaim free memory and
+		// try again.
+		freeBytes := runGC()
+		heapSize := uintptr(metadataStart) - heapStart
+		liveBytes := heapSize - freeBytes
+		if gcPercent >= 0 && uint64(freeBytes)*100 < uint64(liveBytes)*uint64(gcPercent) {
+			// Ensure there is enough headroom, as set with
+			// debug.SetGCPercent (100% by default).
+			gcGrowHeap()
+		}
+		return 2, true
+	} else if scanCount == 2 && gcCompacting && uintptr(endBlock)*bytesPerBlock-gcHeapInuse >= neededBlocks*bytesPerBlock {
+		// There is enough free memory, it just isn't in one piece. Move
+		// objects around to create more contiguous free memory, and search
+		// the heap once more.
+		guard := stackGuardSuspend()
+		compactHeap()
+		stackGuardRestore(guard)
+		if gcTLSF {
+			tlsfRebuild()
+		}
+		return 3, true
+	}
+
+	// Even after garbage collection, no free memory could be found. Try to
+	// increase heap size.
+	if !gcGrowHeap() {
+		if heapPoison && poisonFlush() {
+			// Free the objects that were kept in quarantine, and search the
+			// heap once more.
+			if gcTLSF {
+				tlsfRebuild()
 			}
-			memzero(pointer, size)
-			return pointer
+			return scanCount, true
 		}
+		// Unfortunately the heap could not be increased. This happens on
+		// baremetal systems for example (where all available RAM has
+		// already been dedicated to the heap).
+		return scanCount, false
+	}
+	// Success, the heap was increased in size. Try again with a larger heap.
+	return scanCount, true
+}
+
+// gcGrowHeap tries to grow the heap, like growHeap, unless the heap is already
+// as big as allowed by the memory limit.
+func gcGrowHeap() bool {
+	if uint64(uintptr(metadataStart)-heapStart) >= uint64(gcMemoryLimit) {
+		return false
 	}
+	return growHeap()
 }
 
+//go:nowritebarrier
 func realloc(ptr unsafe.Pointer, size uintptr) unsafe.Pointer {
 	if ptr == nil {
 		return alloc(size, nil)
@@ -390,6 +522,10 @@ func realloc(ptr unsafe.Pointer, size uintptr) unsafe.Pointer {
 	// this might be a few bytes longer than the original size of
 	// ptr, because we align to full blocks of size bytesPerBlock
 	oldSize := endOfTailAddress - ptrAddress
+	if leakCheck {
+		// The last word is the allocation site.
+		oldSize -= unsafe.Sizeof(uintptr(0))
+	}
 	if size <= oldSize {
 		return ptr
 	}
@@ -408,6 +544,10 @@ func free(ptr unsafe.Pointer) {
 // GC performs a garbage collection cycle.
 func GC() {
 	runGC()
+	if !hasScheduler {
+		// There is no finalizer goroutine.
+		runFinalizers()
+	}
 }
 
 // runGC performs a garbage colleciton cycle. It is the internal implementation
@@ -417,51 +557,109 @@ func runGC() (freeBytes uintptr) {
 	if gcDebug {
 		println("running collection cycle...")
 	}
+	if hasParallelism {
+		// Stop the other cores, so that they can't change pointers while
+		// the heap is being marked.
+		mask := interrupt.Disable()
+		defer interrupt.Restore(mask)
+		gcStopOtherCores()
+		defer gcResumeOtherCores()
+	}
 
-	// Mark phase: mark all reachable objects, recursively.
-	markStack()
-	markGlobals()
-
-	if baremetal && hasScheduler {
-		// Channel operations in interrupts may move task pointers around while we are marking.
-		// Therefore we need to scan the runqueue seperately.
-		var markedTaskQueue task.Queue
-	runqueueScan:
-		for !runqueue.Empty() {
-			// Pop the next task off of the runqueue.
-			t := runqueue.Pop()
+	// The stack of the current goroutine is scanned as a heap object, which
+	// isn't possible while the stack guard is active.
+	guard := stackGuardSuspend()
 
-			// Mark the task if it has not already been marked.
-			markRoot(uintptr(unsafe.Pointer(&runqueue)), uintptr(unsafe.Pointer(t)))
+	traceGCStart()
+	start := nanotime()
 
-			// Push the task onto our temporary queue.
-			markedTaskQueue.Push(t)
+	if gcIncremental {
+		// Finish the incremental GC cycle that is currently running (or do a
+		// new one) without interruption.
+		if !gcMarking {
+			gcStartMark()
 		}
+		gcMarkSlice(^uintptr(0))
+	} else {
+		clearPools()
+
+		// Mark phase: mark all reachable objects, recursively.
+		markStack()
+		markGlobals()
+
+		if baremetal && hasScheduler && !hasParallelism {
+			// Channel operations in interrupts may move task pointers around while we are marking.
+			// Therefore we need to scan the runqueue seperately.
+			// (With multiple cores, interrupts are disabled while the GC runs.)
+			var markedTaskQueue task.Queue
+		runqueueScan:
+			for !runqueue.Empty() {
+				// Pop the next task off of the runqueue.
+				t := runqueue.Pop()
+
+				// Mark the task if it has not already been marked.
+				markRoot(uintptr(unsafe.Pointer(&runqueue)), uintptr(unsafe.Pointer(t)))
+
+				// Push the task onto our temporary queue.
+				markedTaskQueue.Push(t)
+			}
 
-		finishMark()
+			finishMark()
 
-		// Restore the runqueue.
-		i := interrupt.Disable()
-		if !runqueue.Empty() {
-			// Something new came in while finishing the mark.
+			// Restore the runqueue.
+			i := interrupt.Disable()
+			if !runqueue.Empty() {
+				// Something new came in while finishing the mark.
+				interrupt.Restore(i)
+				goto runqueueScan
+			}
+			runqueue = markedTaskQueue
 			interrupt.Restore(i)
-			goto runqueueScan
+		} else {
+			finishMark()
 		}
-		runqueue = markedTaskQueue
-		interrupt.Restore(i)
-	} else {
-		finishMark()
 	}
 
-	// Sweep phase: free all non-marked objects and unmark marked objects for
-	// the next collection cycle.
+	freeBytes = finishGC()
+
+	gcAddPause(start)
+	traceGCDone(gcHeapInuse)
+	stackGuardRestore(guard)
+
+	return
+}
+
+// gcAddPause records a GC pause (a whole cycle, or a slice of an incremental
+// cycle) that started at the given time.
+func gcAddPause(start int64) {
+	gcLastPause = uint64(nanotime() - start)
+	gcPauseTotal += gcLastPause
+}
+
+// finishGC runs the sweep phase after all reachable objects have been marked:
+// it frees all non-marked objects and unmarks marked objects for the next
+// collection cycle. It returns the number of free bytes in the heap.
+func finishGC() (freeBytes uintptr) {
+	if finalizers != nil {
+		// Keep unreachable objects with a finalizer alive until their
+		// finalizer has run.
+		queueFinalizers()
+	}
 	freeBytes = sweep()
+	gcHeapInuse = uintptr(endBlock)*bytesPerBlock - freeBytes
+	gcHeapLive = gcHeapInuse
+	if gcTLSF {
+		// Put the freed (and coalesced) ranges in the free lists.
+		tlsfRebuild()
+	}
 
 	// Show how much has been sweeped, for debugging.
 	if gcDebug {
 		dumpHeap()
 	}
 
+	gcNumGC++
+
 	return
 }
 
@@ -597,6 +795,18 @@ func finishMark() {
 
 // mark a GC root at the address addr.
 func markRoot(addr, root uintptr) {
+	if gcPinning {
+		// The heap is being compacted, only pin the object so that it won't be
+		// moved.
+		pinObject(root)
+		return
+	}
+	if gcIncremental {
+		// Only mark the object here. Its contents are scanned in a later mark
+		// slice.
+		gcShade(root)
+		return
+	}
 	if isOnHeap(root) {
 		block := blockFromAddr(root)
 		if block.state() == blockStateFree {
@@ -618,10 +828,21 @@ func markRoot(addr, root uintptr) {
 // Sweep goes through all memory and frees unmarked memory.
 // It returns how many bytes are free in the heap after the sweep.
 func sweep() (freeBytes uintptr) {
+	if heapPoison {
+		// Keep quarantined objects (see gc_poison.go).
+		poisonMarkQuarantine()
+	}
 	freeCurrentObject := false
 	for block := gcBlock(0); block < endBlock; block++ {
 		switch block.state() {
 		case blockStateHead:
+			if heapPoison {
+				// Put the object in quarantine instead of freeing it.
+				freeBytes += poisonQuarantine(block)
+				freeCurrentObject = false
+				gcFrees++
+				continue
+			}
 			// Unmarked head. Free it, including all tail blocks following it.
 			block.markFree()
 			freeCurrentObject = true
@@ -675,23 +896,63 @@ func dumpHeap() {
 func ReadMemStats(m *MemStats) {
 	m.HeapIdle = 0
 	m.HeapInuse = 0
+	m.HeapObjects = 0
+	m.HeapFreeRanges = 0
+	m.HeapLargestFree = 0
+	var freeRange uint64 // size of the current range of free blocks
 	for block := gcBlock(0); block < endBlock; block++ {
 		bstate := block.state()
 		if bstate == blockStateFree {
 			m.HeapIdle += uint64(bytesPerBlock)
+			if freeRange == 0 {
+				m.HeapFreeRanges++
+			}
+			freeRange += uint64(bytesPerBlock)
+			if freeRange > m.HeapLargestFree {
+				m.HeapLargestFree = freeRange
+			}
 		} else {
+			freeRange = 0
 			m.HeapInuse += uint64(bytesPerBlock)
+			if bstate != blockStateTail {
+				// Every object starts with a head block.
+				m.HeapObjects++
+			}
 		}
 	}
 	m.HeapReleased = 0 // always 0, we don't currently release memory back to the OS.
 	m.HeapSys = m.HeapInuse + m.HeapIdle
+	m.HeapAlloc = m.HeapInuse
+	m.Alloc = m.HeapAlloc
 	m.GCSys = uint64(heapEnd - uintptr(metadataStart))
 	m.TotalAlloc = gcTotalAlloc
 	m.Mallocs = gcMallocs
 	m.Frees = gcFrees
 	m.Sys = uint64(heapEnd - heapStart)
+	m.PauseTotalNs = gcPauseTotal
+	m.NumGC = gcNumGC
+}
+
+// gcHeapGoal returns the number of bytes in use at which the next GC cycle is
+// started.
+func gcHeapGoal() uintptr {
+	if gcIncremental {
+		return gcTriggerGoal()
+	}
+	// A GC cycle is only run when the heap is full.
+	return uintptr(endBlock) * bytesPerBlock
 }
 
-func SetFinalizer(obj interface{}, finalizer interface{}) {
-	// Unimplemented.
+// gcReadMetrics fills in the GC statistics for runtime/metrics.
+func gcReadMetrics(m *gcMetrics) {
+	m.numGC = uint64(gcNumGC)
+	m.totalAlloc = gcTotalAlloc
+	m.mallocs = gcMallocs
+	m.frees = gcFrees
+	m.heapInuse = uint64(gcHeapInuse)
+	m.heapLive = uint64(gcHeapLive)
+	m.heapGoal = uint64(gcHeapGoal())
+	m.heapSys = uint64(heapEnd - heapStart)
+	m.lastPauseNs = gcLastPause
+	m.pauseTotalNs = gcPauseTotal
 }
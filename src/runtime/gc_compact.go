@@ -0,0 +1,210 @@
+//go:build gc.compacting
+
+package runtime
+
+// This file adds heap compaction to the precise GC (gc_precise.go), for
+// programs that run for a long time. Even when there is little live data, the
+// heap may get so fragmented over time that a large allocation can't be
+// satisfied anymore. When that happens (after a GC cycle), objects are moved
+// to the start of the heap to create one large free area at the end.
+//
+// Stacks and globals are scanned conservatively, so pointers found there can't
+// be updated: the objects they point to are "pinned" and are never moved. The
+// same goes for objects without a known layout (such as goroutine stacks and
+// hashmap groups) and the objects they point to. All other objects are only
+// referenced from pointer fields in heap objects with a known layout, which can
+// be updated when the object is moved.
+//
+// Compaction is done in four steps:
+//  1. Pin all objects that can't be moved, using the mark state of the block.
+//     There are no other marked blocks after a GC cycle.
+//  2. Move every object that isn't pinned, starting at the end of the heap,
+//     into the lowest free area that fits. The old copy stays allocated and
+//     stores the address of the new copy in place of its layout. New copies
+//     are pinned so that they don't get moved again.
+//  3. Update all pointers in heap objects that point to a moved object.
+//  4. Free all old copies and unpin all objects.
+//
+// Note that this means that pointers to heap objects must never be stored in a
+// uintptr, even temporarily: unlike with the other GCs, the object may not
+// just be freed but may also move.
+
+import (
+	"runtime/interrupt"
+	"unsafe"
+)
+
+const gcCompacting = true
+
+// gcPinning is set while the stacks and globals are being scanned to pin the
+// objects they refer to.
+var gcPinning bool
+
+// compactHeap moves objects to the start of the heap to reduce fragmentation.
+// It must only be called right after a GC cycle. It returns whether any object
+// was moved.
+func compactHeap() bool {
+	if gcDebug {
+		println("compacting heap...")
+	}
+
+	// Interrupts might modify the heap while objects are being moved.
+	mask := interrupt.Disable()
+
+	// Pin all objects referenced from stacks and globals, all objects with a
+	// finalizer and all quarantined objects.
+	gcPinning = true
+	markStack()
+	markGlobals()
+	gcPinning = false
+	pinFinalizers()
+	poisonPinQuarantine()
+
+	// Pin all objects with an unknown layout, and all objects referenced from
+	// them.
+	for block := gcBlock(0); block < endBlock; block++ {
+		state := block.state()
+		if state != blockStateHead && state != blockStateMark {
+			continue
+		}
+		if *(*uintptr)(block.pointer()) != 0 {
+			// Known layout.
+			continue
+		}
+		if state == blockStateHead {
+			block.setState(blockStateMark)
+		}
+		start, end := block.address()+align(unsafe.Sizeof(uintptr(0))), block.findNext().address()
+		for addr := start; addr != end; addr += unsafe.Alignof(addr) {
+			pinObject(*(*uintptr)(unsafe.Pointer(addr)))
+		}
+	}
+
+	// Move objects from the end of the heap into free space at the start.
+	moved := false
+	firstFree := gcBlock(0) // all blocks below this one are in use
+	for block := endBlock; block > firstFree; {
+		block--
+		if block.state() != blockStateHead {
+			// Free, pinned, or not the start of an object.
+			continue
+		}
+		numBlocks := block.findNext() - block
+		dest, ok := findFreeBlocks(&firstFree, block, numBlocks)
+		if !ok {
+			continue
+		}
+		memcpy(dest.pointer(), block.pointer(), uintptr(numBlocks)*bytesPerBlock)
+		dest.setState(blockStateMark)
+		for i := dest + 1; i != dest+numBlocks; i++ {
+			i.setState(blockStateTail)
+		}
+		*(*uintptr)(block.pointer()) = dest.address()
+		moved = true
+	}
+
+	if moved {
+		// Update all pointers to moved objects.
+		for block := gcBlock(0); block < endBlock; block++ {
+			state := block.state()
+			if state != blockStateHead && state != blockStateMark {
+				continue
+			}
+			layout := *(*uintptr)(block.pointer())
+			if layout == 0 || isForwardPointer(layout) {
+				// Either the layout is unknown (and everything it refers to is
+				// pinned), or this is the old copy of a moved object.
+				continue
+			}
+			scanner := newGCObjectScanner(block)
+			if scanner.pointerFree() {
+				continue
+			}
+			start, end := block.address()+align(unsafe.Sizeof(uintptr(0))), block.findNext().address()
+			for addr := start; addr != end; addr += unsafe.Alignof(addr) {
+				word := *(*uintptr)(unsafe.Pointer(addr))
+				if !scanner.nextIsPointer(word, block.address(), addr) {
+					continue
+				}
+				target := blockFromAddr(word)
+				if target.state() == blockStateFree {
+					continue
+				}
+				target = target.findHead()
+				if forward := *(*uintptr)(target.pointer()); isForwardPointer(forward) {
+					// The object was moved. The pointer may point inside the
+					// object, so keep the offset.
+					*(*uintptr)(unsafe.Pointer(addr)) = forward + (word - target.address())
+				}
+			}
+		}
+	}
+
+	// Free the old copies of moved objects, and unpin all objects.
+	for block := gcBlock(0); block < endBlock; block++ {
+		switch block.state() {
+		case blockStateMark:
+			block.unmark()
+		case blockStateHead:
+			if isForwardPointer(*(*uintptr)(block.pointer())) {
+				block.markFree()
+				for block+1 < endBlock && (block+1).state() == blockStateTail {
+					block++
+					block.markFree()
+				}
+			}
+		}
+	}
+
+	interrupt.Restore(mask)
+
+	if gcDebug {
+		dumpHeap()
+	}
+
+	return moved
+}
+
+// isForwardPointer returns whether the first word of an object (normally its
+// layout) is the address of the new copy of a moved object. Inline layouts have
+// the lowest bit set, which is needed to tell them apart: on microcontrollers
+// an inline layout with high bitmap bits can easily look like an address in RAM.
+func isForwardPointer(word uintptr) bool {
+	return word&1 == 0 && isOnHeap(word)
+}
+
+// findFreeBlocks returns the lowest range of numBlocks free blocks below limit.
+// The firstFree block is updated to the lowest free block in the heap.
+func findFreeBlocks(firstFree *gcBlock, limit gcBlock, numBlocks gcBlock) (gcBlock, bool) {
+	for *firstFree < limit && firstFree.state() != blockStateFree {
+		*firstFree++
+	}
+	found := gcBlock(0)
+	for block := *firstFree; block < limit; block++ {
+		if block.state() != blockStateFree {
+			found = 0
+			continue
+		}
+		found++
+		if found == numBlocks {
+			return block + 1 - numBlocks, true
+		}
+	}
+	return 0, false
+}
+
+// pinObject marks the heap object that ptr points to (if any), so that it won't
+// be moved.
+func pinObject(ptr uintptr) {
+	if !isOnHeap(ptr) {
+		return
+	}
+	block := blockFromAddr(ptr)
+	if block.state() == blockStateFree {
+		return
+	}
+	head := block.findHead()
+	if head.state() == blockStateHead {
+		head.setState(blockStateMark)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !gc.compacting
+
+package runtime
+
+// Stubs for heap compaction, see gc_compact.go.
+
+const gcCompacting = false
+
+// The heap is never compacted, so objects never need to be pinned.
+const gcPinning = false
+
+func compactHeap() bool {
+	return false
+}
+
+func pinObject(ptr uintptr) {}
@@ -1,4 +1,4 @@
-//go:build gc.conservative
+//go:build gc.conservative || gc.incremental
 
 // This implements the block-based heap as a fully conservative GC. No tracking
 // of pointers is done, every word in an object is considered live if it looks
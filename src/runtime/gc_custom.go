@@ -58,6 +58,20 @@ func SetFinalizer(obj interface{}, finalizer interface{})
 // ReadMemStats populates m with memory statistics.
 func ReadMemStats(ms *MemStats)
 
+// gcReadMetrics fills in the GC statistics for runtime/metrics, from the
+// statistics the custom GC provides.
+func gcReadMetrics(m *gcMetrics) {
+	var ms MemStats
+	ReadMemStats(&ms)
+	m.numGC = uint64(ms.NumGC)
+	m.totalAlloc = ms.TotalAlloc
+	m.mallocs = ms.Mallocs
+	m.frees = ms.Frees
+	m.heapInuse = ms.HeapInuse
+	m.heapSys = ms.HeapSys
+	m.pauseTotalNs = ms.PauseTotalNs
+}
+
 func setHeapEnd(newHeapEnd uintptr) {
 	// Heap is in custom GC so ignore for when called from wasm initialization.
 }
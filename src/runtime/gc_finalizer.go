@@ -0,0 +1,202 @@
+//go:build gc.conservative || gc.precise || gc.incremental || gc.compacting
+
+package runtime
+
+// This file implements finalizers (runtime.SetFinalizer) for the block-based
+// GCs.
+//
+// Objects with a finalizer are kept in a list, with their address hidden from
+// the GC. When the GC has marked all reachable objects, every object in this
+// list that wasn't marked is unreachable. Such an object is marked after all
+// (together with everything it references) so that it survives until its
+// finalizer has run, and is moved to a second list. The finalizers in that list
+// are run one after another by a separate goroutine, or when runtime.GC is
+// called if there is no scheduler. The object is freed in a later cycle if the
+// finalizer didn't make it reachable again.
+//
+// Like in upstream Go, an object that is only reachable from another object
+// with a finalizer is finalized in a later cycle, and objects in a cycle of
+// finalizers are never finalized.
+
+import (
+	"internal/task"
+	"runtime/interrupt"
+	"unsafe"
+)
+
+type gcFinalizer struct {
+	next *gcFinalizer
+	obj  uintptr              // address of the object, inverted so that the GC won't find it
+	arg  unsafe.Pointer       // the object, once it is ready to be finalized
+	fn   func(unsafe.Pointer) // the finalizer
+}
+
+var (
+	finalizers      *gcFinalizer // objects with a finalizer
+	finalizersReady *gcFinalizer // unreachable objects that need their finalizer to be run
+	finalizerTask   *task.Task   // finalizer goroutine, when it waits for finalizers
+	finalizerLoopOn bool         // whether the finalizer goroutine was started
+)
+
+// SetFinalizer sets the finalizer associated with obj to the provided finalizer
+// function. When the garbage collector finds an unreachable block with an
+// associated finalizer, it clears the association and runs finalizer(obj) in a
+// separate goroutine. This makes obj reachable again, but now without an
+// associated finalizer. Assuming that SetFinalizer is not called again, the
+// next time the garbage collector sees that obj is unreachable, it will free
+// obj. SetFinalizer(obj, nil) clears any finalizer associated with obj.
+//
+// The argument obj must be a pointer to an object allocated by calling new, by
+// taking the address of a composite literal, or by taking the address of a
+// local variable, otherwise it is ignored. Unlike in upstream Go, the finalizer
+// must be a func(*T) where obj is a *T, and can't have results.
+//
+// Without a scheduler (-scheduler=none), finalizers are only run when
+// runtime.GC is called.
+//
+// Note that because the stack and (with the conservative GC) all objects are
+// scanned conservatively, an object may stay reachable and thus not be
+// finalized for a long time. Finalizers should only be used to release
+// resources that are not managed by the GC, as a safety net. Use KeepAlive to
+// make sure the finalizer doesn't run while the object is still in use.
+func SetFinalizer(obj interface{}, finalizer interface{}) {
+	_, ptr := decomposeInterface(obj)
+	if !isOnHeap(uintptr(ptr)) || blockFromAddr(uintptr(ptr)).state() == blockStateFree {
+		// Not a heap object, so it is never freed.
+		return
+	}
+
+	mask := interrupt.Disable()
+	removeFinalizer(uintptr(ptr))
+	interrupt.Restore(mask)
+	if finalizer == nil {
+		return
+	}
+
+	// Func values don't fit in an interface, so the interface contains a
+	// pointer to the func value.
+	_, fn := decomposeInterface(finalizer)
+	f := &gcFinalizer{
+		obj: ^uintptr(ptr),
+		fn:  *(*func(unsafe.Pointer))(fn),
+	}
+	mask = interrupt.Disable()
+	f.next = finalizers
+	finalizers = f
+	interrupt.Restore(mask)
+
+	if !finalizerLoopOn {
+		finalizerLoopOn = true
+		startFinalizerGoroutine(finalizerLoop)
+	}
+}
+
+// removeFinalizer removes the finalizer for the object at ptr, if there is one.
+// It must be called in a critical section.
+func removeFinalizer(ptr uintptr) {
+	for prev := &finalizers; *prev != nil; prev = &(*prev).next {
+		if (*prev).obj == ^ptr {
+			*prev = (*prev).next
+			return
+		}
+	}
+}
+
+// queueFinalizers is called after all reachable objects have been marked and
+// before the heap is swept. It moves the finalizers of all unreachable objects
+// to finalizersReady, and marks these objects so that they won't be freed.
+//
+//go:nowritebarrier
+func queueFinalizers() {
+	// Find all unreachable objects with a finalizer.
+	var unreachable *gcFinalizer
+	for prev := &finalizers; *prev != nil; {
+		f := *prev
+		if blockFromAddr(^f.obj).findHead().state() == blockStateMark {
+			prev = &f.next
+			continue
+		}
+		*prev = f.next
+		f.next = unreachable
+		unreachable = f
+	}
+	if unreachable == nil {
+		return
+	}
+
+	// Mark everything they reference, but not the objects themselves.
+	for f := unreachable; f != nil; f = f.next {
+		head := blockFromAddr(^f.obj).findHead()
+		start, end := head.address(), head.findNext().address()
+		for addr := start; addr+unsafe.Sizeof(addr) <= end; addr += unsafe.Alignof(addr) {
+			markRoot(addr, *(*uintptr)(unsafe.Pointer(addr)))
+		}
+	}
+	if gcIncremental {
+		gcMarkSlice(^uintptr(0))
+	} else {
+		finishMark()
+	}
+
+	// Objects that are referenced by another unreachable object will be
+	// finalized in a later cycle. The others can be finalized now.
+	for unreachable != nil {
+		f := unreachable
+		unreachable = f.next
+		head := blockFromAddr(^f.obj).findHead()
+		if head.state() == blockStateMark {
+			f.next = finalizers
+			finalizers = f
+			continue
+		}
+		head.setState(blockStateMark)
+		f.arg = unsafe.Pointer(^f.obj)
+		f.next = finalizersReady
+		finalizersReady = f
+	}
+
+	// Wake up the finalizer goroutine.
+	if finalizerTask != nil {
+		t := finalizerTask
+		finalizerTask = nil
+		runqueuePushBack(t)
+	}
+}
+
+// pinFinalizers pins all objects with a finalizer while the heap is compacted,
+// as their addresses can't be updated.
+func pinFinalizers() {
+	for f := finalizers; f != nil; f = f.next {
+		pinObject(^f.obj)
+	}
+}
+
+// runFinalizers runs all finalizers that are ready to be run.
+func runFinalizers() {
+	for {
+		mask := interrupt.Disable()
+		f := finalizersReady
+		if f == nil {
+			interrupt.Restore(mask)
+			return
+		}
+		finalizersReady = f.next
+		interrupt.Restore(mask)
+		f.fn(f.arg)
+	}
+}
+
+// finalizerLoop is the finalizer goroutine, when there is a scheduler.
+func finalizerLoop() {
+	for {
+		runFinalizers()
+		mask := interrupt.Disable()
+		if finalizersReady != nil {
+			interrupt.Restore(mask)
+			continue
+		}
+		finalizerTask = task.Current()
+		interrupt.Restore(mask)
+		task.Wait(task.WaitFinalizer, nil)
+	}
+}
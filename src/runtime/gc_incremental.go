@@ -0,0 +1,284 @@
+//go:build gc.incremental
+
+package runtime
+
+// This file implements incremental marking for the block-based GC in
+// gc_blocks.go, to avoid long pauses in programs that need to respond quickly
+// (motor control, audio, etc).
+//
+// Instead of marking the whole heap at once, marking is done in small slices:
+// the allocator does a bit of marking work for every allocation, and the
+// scheduler does a slice of marking work every time it switches to a
+// goroutine. Only the start of a cycle (scanning globals and the current stack)
+// and the sweep phase at the end are done in one go.
+//
+// The algorithm is snapshot-at-the-beginning: every object that was reachable
+// when the cycle started is marked, as is every object allocated during the
+// cycle. This is made possible by the following:
+//   - Before a pointer in memory is overwritten, the old value is marked. The
+//     compiler inserts a call to gcWriteBarrier for this purpose before every
+//     store that might overwrite a pointer (see transform.InsertWriteBarriers).
+//   - Stores to the stack do not have a write barrier. Therefore the current
+//     stack is scanned at the start of the cycle, and the stack of a goroutine
+//     is scanned right before it is resumed.
+//   - New objects are allocated in the marked state while marking.
+//
+// Objects that are marked but not yet scanned are kept on a small grey stack.
+// When it overflows, all marked objects are scanned again at the end of the
+// cycle, like in the non-incremental GC.
+//
+// The incremental GC is always conservative.
+//
+// Limitations: the write barrier makes every store of a pointer-sized value
+// slower and larger, and an object is always scanned at once so a very large
+// object still causes a long pause. When the heap runs out before marking is
+// finished, the rest of the cycle is completed without interruption.
+
+import (
+	"internal/task"
+	"runtime/interrupt"
+	"unsafe"
+)
+
+const gcIncremental = true
+
+const (
+	// Number of objects that can be marked but not yet scanned before the GC
+	// has to fall back to rescanning all marked objects.
+	gcGreyStackSize = 64
+
+	// A new cycle is started when less than 1/gcTriggerRatio of the heap is
+	// free. The remaining free memory is used for allocations while marking.
+	gcTriggerRatio = 4
+
+	// Number of bytes to scan for every byte allocated while marking. It is
+	// set so that the entire heap can be scanned before the free memory left
+	// at the start of the cycle runs out.
+	gcAssistRatio = gcTriggerRatio
+
+	// Number of bytes to scan every time the scheduler resumes a goroutine.
+	gcSliceBytes = 256 * unsafe.Sizeof(uintptr(0))
+)
+
+var (
+	gcMarking      bool                     // an incremental GC cycle is in progress
+	gcGreyStack    [gcGreyStackSize]gcBlock // marked objects that still need to be scanned
+	gcGreyLen      uintptr                  // number of objects on gcGreyStack
+	gcGreyOverflow bool                     // objects were marked that didn't fit on gcGreyStack
+	gcRescanning   bool                     // all marked objects are being scanned again
+	gcRescanBlock  gcBlock                  // next block to check while rescanning
+)
+
+// gcWriteBarrier is called before the memory at addr is overwritten. Calls are
+// inserted by the compiler, see the package comment.
+//
+//go:nowritebarrier
+func gcWriteBarrier(addr unsafe.Pointer, size uintptr) {
+	if gcMarking {
+		gcWriteBarrierSlow(addr, size)
+	}
+}
+
+// gcWriteBarrierSlow marks all objects referenced from the memory at addr. It
+// is kept separate from gcWriteBarrier so that the check whether the GC is
+// marking can be inlined.
+//
+//go:noinline
+//go:nowritebarrier
+func gcWriteBarrierSlow(addr unsafe.Pointer, size uintptr) {
+	// The write barrier may be called from an interrupt, while a mark slice
+	// is running.
+	mask := interrupt.Disable()
+	end := uintptr(addr) + size
+	for ptr := uintptr(addr) &^ (unsafe.Alignof(uintptr(0)) - 1); ptr < end; ptr += unsafe.Alignof(uintptr(0)) {
+		gcShade(*(*uintptr)(unsafe.Pointer(ptr)))
+	}
+	interrupt.Restore(mask)
+}
+
+// gcAllocStep is called by the allocator for every allocation of the given
+// size. It starts a new cycle when the heap is filling up, and does some
+// marking work proportional to the allocation while a cycle is running.
+//
+//go:nowritebarrier
+func gcAllocStep(size uintptr) {
+	if !gcMarking {
+		heapSize := uintptr(metadataStart) - heapStart
+		if gcPercent < 0 || gcHeapInuse+size+heapSize/gcTriggerRatio <= heapSize {
+			// Either the GC has been turned off using debug.SetGCPercent, or
+			// there is still enough free memory.
+			return
+		}
+	}
+	start := nanotime()
+	guard := stackGuardSuspend() // the current stack may be scanned
+	if !gcMarking {
+		gcStartMark()
+	}
+	if gcMarkSlice(size * gcAssistRatio) {
+		finishGC()
+	}
+	stackGuardRestore(guard)
+	gcAddPause(start)
+}
+
+// gcTriggerGoal returns the number of bytes in use at which gcAllocStep starts
+// a new GC cycle.
+func gcTriggerGoal() uintptr {
+	heapSize := uintptr(metadataStart) - heapStart
+	return heapSize - heapSize/gcTriggerRatio
+}
+
+// gcResumeTask is called by the scheduler right before a goroutine is resumed.
+// It runs a single slice of marking work, and scans the stack of the goroutine
+// as it is about to change.
+//
+//go:nowritebarrier
+func gcResumeTask(t *task.Task) {
+	if !gcMarking {
+		return
+	}
+	start := nanotime()
+	if gcMarkSlice(gcSliceBytes) {
+		finishGC()
+	} else if stack := t.StackBase(); stack != nil {
+		mask := interrupt.Disable()
+		gcScanObject(uintptr(stack))
+		interrupt.Restore(mask)
+	}
+	gcAddPause(start)
+}
+
+// gcStartMark starts a new incremental GC cycle by marking all roots: globals,
+// the current stack and the system stack.
+//
+//go:nowritebarrier
+func gcStartMark() {
+	if gcDebug {
+		println("starting incremental collection cycle...")
+	}
+	// This must happen in one go, otherwise an interrupt might move a
+	// pointer to a root that has already been scanned.
+	mask := interrupt.Disable()
+	clearPools()
+	gcMarking = true
+	markStack()
+	markGlobals()
+	interrupt.Restore(mask)
+}
+
+// gcMarkSlice scans marked objects until at least budget bytes have been
+// scanned or until marking is finished. It returns true if marking is finished,
+// after which the heap must be swept using finishGC.
+//
+//go:nowritebarrier
+func gcMarkSlice(budget uintptr) bool {
+	scanned := uintptr(0)
+	for scanned < budget {
+		if gcGreyLen != 0 {
+			// Scan the next marked object.
+			mask := interrupt.Disable()
+			gcGreyLen--
+			scanned += gcScanBlock(gcGreyStack[gcGreyLen])
+			interrupt.Restore(mask)
+			continue
+		}
+
+		if gcRescanning {
+			// Scan all marked objects again, to find objects that didn't fit
+			// on the grey stack.
+			if gcRescanBlock >= endBlock {
+				gcRescanning = false
+				continue
+			}
+			block := gcRescanBlock
+			gcRescanBlock++
+			if block.state() == blockStateMark {
+				mask := interrupt.Disable()
+				scanned += gcScanBlock(block)
+				interrupt.Restore(mask)
+			} else {
+				// Account for the cost of checking this block.
+				scanned += unsafe.Sizeof(uintptr(0))
+			}
+			continue
+		}
+
+		mask := interrupt.Disable()
+		if gcGreyOverflow {
+			// Start a new rescan of the heap.
+			gcGreyOverflow = false
+			gcRescanning = true
+			gcRescanBlock = 0
+			interrupt.Restore(mask)
+			continue
+		}
+		if gcGreyLen == 0 {
+			// Nothing left to scan, so all live objects have been marked.
+			gcMarking = false
+			interrupt.Restore(mask)
+			return true
+		}
+		// An interrupt marked a new object right before interrupts were
+		// disabled.
+		interrupt.Restore(mask)
+	}
+	return false
+}
+
+// gcScanBlock marks all objects referenced from the object that starts at the
+// given block. It returns the size of the object in bytes.
+//
+//go:nowritebarrier
+func gcScanBlock(block gcBlock) uintptr {
+	start, end := block.address(), block.findNext().address()
+	for addr := start; addr+unsafe.Sizeof(addr) <= end; addr += unsafe.Alignof(addr) {
+		gcShade(*(*uintptr)(unsafe.Pointer(addr)))
+	}
+	return end - start
+}
+
+// gcScanObject marks the heap object containing ptr and scans it right away.
+//
+//go:nowritebarrier
+func gcScanObject(ptr uintptr) {
+	if !isOnHeap(ptr) {
+		return
+	}
+	block := blockFromAddr(ptr)
+	if block.state() == blockStateFree {
+		return
+	}
+	gcShade(ptr)
+	gcScanBlock(block.findHead())
+}
+
+// gcShade marks the object that ptr points to (if any) and pushes it on the
+// grey stack to be scanned later.
+//
+//go:nowritebarrier
+func gcShade(ptr uintptr) {
+	if !isOnHeap(ptr) {
+		return
+	}
+	block := blockFromAddr(ptr)
+	if block.state() == blockStateFree {
+		// The to-be-marked object doesn't actually exist.
+		// This is probably a false positive.
+		return
+	}
+	head := block.findHead()
+	if head.state() == blockStateMark {
+		// Already marked.
+		return
+	}
+	head.setState(blockStateMark)
+	if gcGreyLen == gcGreyStackSize {
+		// The grey stack is full. The object will be scanned when all
+		// marked objects are scanned again.
+		gcGreyOverflow = true
+		return
+	}
+	gcGreyStack[gcGreyLen] = head
+	gcGreyLen++
+}
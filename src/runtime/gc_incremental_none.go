@@ -0,0 +1,30 @@
+//go:build !gc.incremental
+
+package runtime
+
+import "internal/task"
+
+// Stubs for the hooks of the incremental GC, see gc_incremental.go.
+
+const gcIncremental = false
+
+// No GC cycle can be in progress when the GC is not incremental.
+const gcMarking = false
+
+func gcAllocStep(size uintptr) {}
+
+func gcStartMark() {}
+
+func gcMarkSlice(budget uintptr) bool {
+	return true
+}
+
+func gcShade(ptr uintptr) {}
+
+func gcScanObject(ptr uintptr) {}
+
+func gcResumeTask(t *task.Task) {}
+
+func gcTriggerGoal() uintptr {
+	return 0
+}
@@ -0,0 +1,10 @@
+package runtime
+
+import "unsafe"
+
+// layoutNoPointers is the object layout (as passed to alloc) for objects that
+// don't contain any pointers, like the bytes of a string. It is the same value
+// as createObjectLayout in the compiler uses for such objects: a bitstring of
+// size 1 without any pointer bits. Only the precise GC looks at it, see
+// gc_precise.go for the format.
+var layoutNoPointers = unsafe.Pointer(uintptr(0b11))
@@ -0,0 +1,11 @@
+//go:build !gc.precise && !gc.compacting
+
+package runtime
+
+import "unsafe"
+
+// heapLayout returns nil (unknown layout): only the precise GC stores object
+// layouts.
+func heapLayout(ptr unsafe.Pointer, elemSize uintptr) unsafe.Pointer {
+	return nil
+}
@@ -31,6 +31,12 @@ func alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer {
 	// much. And by using platform-native data types (e.g. *uint8 for 8-bit
 	// systems).
 	size = align(size)
+	if allocsTrace {
+		traceAlloc(returnAddress(0), size)
+	}
+	if MemProfileRate > 0 {
+		memProfileAlloc(returnAddress(0), size)
+	}
 	addr := heapptr
 	gcTotalAlloc += uint64(size)
 	gcMallocs++
@@ -74,11 +80,34 @@ func ReadMemStats(m *MemStats) {
 	m.HeapReleased = 0 // always 0, we don't currently release memory back to the OS.
 
 	m.HeapSys = m.HeapInuse + m.HeapIdle
+	m.HeapAlloc = m.HeapInuse
+	m.Alloc = m.HeapAlloc
+	m.HeapObjects = gcMallocs // objects are never freed
 	m.GCSys = 0
 	m.TotalAlloc = gcTotalAlloc
 	m.Mallocs = gcMallocs
 	m.Frees = gcFrees
 	m.Sys = uint64(heapEnd - heapStart)
+	m.PauseTotalNs = 0 // there is no GC
+	m.NumGC = 0
+
+	// All memory after heapptr is free.
+	m.HeapFreeRanges = 0
+	m.HeapLargestFree = 0
+	if heapptr < heapEnd {
+		m.HeapFreeRanges = 1
+		m.HeapLargestFree = uint64(heapEnd - heapptr)
+	}
+}
+
+// gcReadMetrics fills in the GC statistics for runtime/metrics.
+func gcReadMetrics(m *gcMetrics) {
+	m.totalAlloc = gcTotalAlloc
+	m.mallocs = gcMallocs
+	m.heapInuse = gcTotalAlloc
+	m.heapLive = gcTotalAlloc
+	m.heapSys = uint64(heapEnd - heapStart)
+	m.heapGoal = m.heapSys // there is no GC, the heap is simply full
 }
 
 func GC() {
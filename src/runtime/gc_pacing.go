@@ -0,0 +1,47 @@
+package runtime
+
+// GC pacing settings, changed through runtime/debug. They are only used by the
+// block-based GCs (conservative and precise): other GCs store the values but
+// otherwise ignore them.
+
+var (
+	// gcPercent controls how much the heap is grown, like the GOGC environment
+	// variable in upstream Go. When a collection cycle leaves less than
+	// gcPercent% of the live heap size free, the heap is grown (if the system
+	// allows it). A negative value turns off the GC: the heap is grown instead
+	// of running a collection cycle, and memory is only collected when the
+	// heap can't grow any further.
+	gcPercent = 100
+
+	// gcMemoryLimit is the maximum number of bytes that may be in use by heap
+	// objects. The heap won't grow beyond this size, and allocations that
+	// would go over the limit fail with an out of memory error if a
+	// collection cycle can't free enough memory. Unlike in upstream Go, this
+	// is a hard limit.
+	gcMemoryLimit int64 = 1<<63 - 1
+)
+
+//go:linkname debug_setGCPercent runtime/debug.setGCPercent
+func debug_setGCPercent(percent int) int {
+	old := gcPercent
+	if percent < 0 {
+		percent = -1
+	}
+	gcPercent = percent
+	return old
+}
+
+//go:linkname debug_setMemoryLimit runtime/debug.setMemoryLimit
+func debug_setMemoryLimit(limit int64) int64 {
+	old := gcMemoryLimit
+	if limit >= 0 {
+		gcMemoryLimit = limit
+	}
+	return old
+}
+
+// overMemoryLimit returns whether the heap would use more memory than allowed
+// by gcMemoryLimit when it has the given number of bytes in use.
+func overMemoryLimit(inuse uintptr) bool {
+	return uint64(inuse) > uint64(gcMemoryLimit)
+}
@@ -0,0 +1,136 @@
+//go:build heappoison && (gc.conservative || gc.precise || gc.incremental || gc.compacting)
+
+package runtime
+
+// This file implements heap poisoning, enabled with -tags=heappoison. It is
+// meant to find bugs where unsafe code or C code keeps using a heap object
+// after the GC has freed it, for example because the only pointer to it was
+// stored in a uintptr or in C memory.
+//
+// Objects that the GC finds to be unreachable are not freed right away.
+// Instead, they are filled with a poison pattern and kept in a quarantine for
+// the next poisonQuarantineSize objects that become unreachable, so that their
+// memory isn't reused in the meantime. Every GC cycle, and when an object
+// leaves the quarantine to be freed, the poison pattern is checked: if it was
+// overwritten, the program panics with the address that was written to. Reading
+// a freed object returns the poison pattern, which is not a valid pointer.
+//
+// Quarantined objects still count as in use in MemStats. They are all freed
+// when the heap would run out of memory otherwise.
+
+import "unsafe"
+
+const heapPoison = true
+
+const (
+	// Number of unreachable objects that are kept in quarantine.
+	poisonQuarantineSize = 64
+
+	// Pattern that freed objects are filled with.
+	poisonWord = uintptr(0xa5a5a5a5a5a5a5a5 & uint64(^uintptr(0)))
+)
+
+var (
+	poisonQueue [poisonQuarantineSize]gcBlock // head blocks of quarantined objects
+	poisonStart int                           // index of the oldest object in poisonQueue
+	poisonLen   int                           // number of objects in poisonQueue
+)
+
+// poisonRange returns the part of the object that starts at head that is
+// poisoned. The layout (with the precise GC) and the allocation site (with
+// -tags=leakcheck) are kept.
+func poisonRange(head gcBlock) (start, end uintptr) {
+	start = head.address()
+	end = head.findNext().address()
+	if preciseHeap {
+		start += align(unsafe.Sizeof(unsafe.Pointer(nil)))
+	}
+	if leakCheck {
+		end -= unsafe.Sizeof(uintptr(0))
+	}
+	return
+}
+
+// poisonCheck panics if the poison pattern of a quarantined object was
+// overwritten.
+func poisonCheck(head gcBlock) {
+	start, end := poisonRange(head)
+	for addr := start; addr < end; addr += unsafe.Sizeof(uintptr(0)) {
+		if *(*uintptr)(unsafe.Pointer(addr)) == poisonWord {
+			continue
+		}
+		print("freed heap object ", unsafe.Pointer(head.address()), " was written to at ", unsafe.Pointer(addr))
+		if pc := leakCheckObjectSite(head.address()); pc != 0 {
+			print(", allocated at ", unsafe.Pointer(pc))
+		}
+		println()
+		runtimePanic("write to freed heap memory")
+	}
+}
+
+// poisonMarkQuarantine is called right before the heap is swept. It checks all
+// quarantined objects and marks them, so that they are not freed by the sweep.
+func poisonMarkQuarantine() {
+	for i := 0; i < poisonLen; i++ {
+		head := poisonQueue[(poisonStart+i)%poisonQuarantineSize]
+		poisonCheck(head)
+		if head.state() == blockStateHead {
+			head.setState(blockStateMark)
+		}
+	}
+}
+
+// poisonQuarantine is called by the sweep for every unreachable object instead
+// of freeing it. It poisons the object and puts it in quarantine, which may
+// free the oldest quarantined object. It returns the number of bytes freed
+// before head, in the part of the heap that was already swept.
+func poisonQuarantine(head gcBlock) (freeBytes uintptr) {
+	if poisonLen == poisonQuarantineSize {
+		oldest := poisonQueue[poisonStart]
+		poisonStart = (poisonStart + 1) % poisonQuarantineSize
+		poisonLen--
+		size := poisonFree(oldest)
+		if oldest < head {
+			freeBytes = size
+		}
+	}
+	start, end := poisonRange(head)
+	for addr := start; addr < end; addr += unsafe.Sizeof(uintptr(0)) {
+		*(*uintptr)(unsafe.Pointer(addr)) = poisonWord
+	}
+	poisonQueue[(poisonStart+poisonLen)%poisonQuarantineSize] = head
+	poisonLen++
+	return
+}
+
+// poisonFree checks a quarantined object one last time and frees it. It
+// returns the number of bytes that were freed.
+func poisonFree(head gcBlock) uintptr {
+	poisonCheck(head)
+	next := head.findNext()
+	for block := head; block != next; block++ {
+		block.markFree()
+	}
+	return uintptr(next-head) * bytesPerBlock
+}
+
+// poisonFlush frees all quarantined objects, when the heap is out of memory.
+// It returns whether any memory was freed.
+func poisonFlush() bool {
+	if poisonLen == 0 {
+		return false
+	}
+	for ; poisonLen > 0; poisonLen-- {
+		gcHeapInuse -= poisonFree(poisonQueue[poisonStart])
+		poisonStart = (poisonStart + 1) % poisonQuarantineSize
+	}
+	return true
+}
+
+// poisonPinQuarantine pins all quarantined objects while the heap is
+// compacted, as their addresses are stored in the quarantine.
+func poisonPinQuarantine() {
+	for i := 0; i < poisonLen; i++ {
+		pinObject(poisonQueue[(poisonStart+i)%poisonQuarantineSize].address())
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !heappoison && (gc.conservative || gc.precise || gc.incremental || gc.compacting)
+
+package runtime
+
+// Stubs for heap poisoning, see gc_poison.go.
+
+const heapPoison = false
+
+func poisonMarkQuarantine() {}
+
+func poisonQuarantine(head gcBlock) uintptr {
+	return 0
+}
+
+func poisonFlush() bool {
+	return false
+}
+
+func poisonPinQuarantine() {}
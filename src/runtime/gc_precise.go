@@ -1,4 +1,4 @@
-//go:build gc.precise
+//go:build gc.precise || gc.compacting
 
 // This implements the block-based GC as a partially precise GC. This means that
 // for most heap allocations it is known which words contain a pointer and which
@@ -145,3 +145,35 @@ func (scanner *gcObjectScanner) nextIsPointer(word, parent, addrOfWord uintptr)
 	// Probably a pointer.
 	return true
 }
+
+// heapLayout returns the layout of the heap object that ptr points into, for
+// use by a new object that will contain a copy of the elements at ptr, like
+// when growing a slice. The pointer must point to at least one element of the
+// given size. It returns nil (unknown layout) if the layout can't be reused.
+func heapLayout(ptr unsafe.Pointer, elemSize uintptr) unsafe.Pointer {
+	if !isOnHeap(uintptr(ptr)) {
+		return nil
+	}
+	head := blockFromAddr(uintptr(ptr)).findHead()
+	layout := *(*unsafe.Pointer)(unsafe.Pointer(head.address()))
+	if layout == nil {
+		return nil
+	}
+	scanner := newGCObjectScanner(head)
+	if scanner.pointerFree() {
+		// No pointers anywhere, so there is nothing to get wrong.
+		return layout
+	}
+
+	// The bitstring repeats every scanner.size words starting at the object
+	// data (after the layout word). The new object starts with the data at
+	// ptr, so ptr must be at the start of a repetition for the bitstring to
+	// stay valid. Also check that the elements line up with the bitstring, to
+	// be sure this is really an object of this element type.
+	period := scanner.size * unsafe.Sizeof(uintptr(0))
+	offset := uintptr(ptr) - (head.address() + align(unsafe.Sizeof(uintptr(0))))
+	if offset%period != 0 || elemSize%period != 0 {
+		return nil
+	}
+	return layout
+}
@@ -0,0 +1,48 @@
+//go:build gc.conservative && scheduler.cores && !tinygo.wasm
+
+package runtime
+
+import "internal/task"
+
+// markStack marks all root pointers found on the stacks of all cores.
+//
+// Like gc_stack_raw.go, this relies on getting the current stack pointer from
+// a register and assumes a descending stack. The other cores have been stopped
+// by gcStopOtherCores and have pushed their registers to their stack.
+func markStack() {
+	// Scan the current stack, and all current registers.
+	scanCurrentStack()
+
+	self := currentCPU()
+	if !task.OnSystemStack() {
+		// Mark system stack.
+		markRoots(getSystemStackPointer(), coreStackTop(self))
+	}
+
+	// Mark the system stacks of the other cores. Their goroutine stacks are
+	// heap allocations and are found from allTasks.
+	for i := uint32(0); i < numCPU; i++ {
+		if i != self && coreRunning[i] != 0 {
+			markRoots(corePausedSP[i], coreStackTop(i))
+		}
+	}
+}
+
+//go:export tinygo_scanCurrentStack
+func scanCurrentStack()
+
+//go:export tinygo_scanstack
+func scanstack(sp uintptr) {
+	// Mark current stack.
+	// This function is called by scanCurrentStack, after pushing all registers onto the stack.
+	// Callee-saved registers have been pushed onto stack by tinygo_localscan, so this will scan them too.
+	if task.OnSystemStack() {
+		// This is the system stack.
+		// Scan all words on the stack.
+		markRoots(sp, coreStackTop(currentCPU()))
+	} else {
+		// This is a goroutine stack.
+		// It is an allocation, so scan it as if it were a value in a global.
+		markRoot(0, sp)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build (gc.conservative || gc.precise || gc.incremental || gc.compacting) && tinygo.library
+
+package runtime
+
+// markStack marks all root pointers found on the stack.
+//
+// This is a variant of the implementation in gc_stack_raw.go for libraries.
+// Exported functions may be called from any thread of the host process, so the
+// top of the stack can't be determined once at startup like in an executable.
+// Instead, it is looked up for the current thread in every GC cycle.
+// Libraries don't support goroutines (-scheduler=none), so there is only ever
+// the system stack to scan.
+func markStack() {
+	stackTop = threadStackTop()
+	scanCurrentStack()
+}
+
+//go:export tinygo_scanCurrentStack
+func scanCurrentStack()
+
+//go:export tinygo_scanstack
+func scanstack(sp uintptr) {
+	// Mark current stack.
+	// This function is called by scanCurrentStack, after pushing all registers
+	// onto the stack.
+	markRoots(sp, stackTop)
+}
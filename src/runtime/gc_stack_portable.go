@@ -24,7 +24,8 @@ type stackChainObject struct {
 //   - The system stack (aka startup stack) is not heap allocated, so even
 //     though it may be referenced it will not be scanned by default.
 //
-// Therefore, we only need to scan the system stack.
+// Therefore, we only need to scan the system stack. With -scheduler=cores,
+// that is the system stack of every thread.
 // It is relatively easy to scan the system stack while we're on it: we can
 // simply read __stack_pointer and __global_base and scan the area inbetween.
 // Unfortunately, it's hard to get the system stack pointer while we're on a
@@ -45,7 +46,9 @@ func markStack() {
 	// live.
 	volatile.LoadUint32((*uint32)(unsafe.Pointer(&stackChainStart)))
 
-	if task.OnSystemStack() {
+	if hasParallelism {
+		markCoreStacks()
+	} else if task.OnSystemStack() {
 		markRoots(getCurrentStackPointer(), stackTop)
 	}
 }
@@ -1,4 +1,4 @@
-//go:build (gc.conservative || gc.precise) && !tinygo.wasm
+//go:build (gc.conservative || gc.precise || gc.incremental || gc.compacting) && !tinygo.wasm && !tinygo.library && !scheduler.cores
 
 package runtime
 
@@ -35,5 +35,11 @@ func scanstack(sp uintptr) {
 		// This is a goroutine stack.
 		// It is an allocation, so scan it as if it were a value in a global.
 		markRoot(0, sp)
+		if gcIncremental {
+			// The stack will keep changing while the incremental GC is
+			// marking, so its contents are part of the snapshot that must
+			// be scanned right away.
+			gcScanObject(sp)
+		}
 	}
 }
@@ -0,0 +1,178 @@
+//go:build allocator.tlsf && (gc.conservative || gc.precise || gc.incremental || gc.compacting)
+
+package runtime
+
+// This file implements a TLSF-style (two-level segregated fit) allocator for
+// the block-based GC in gc_blocks.go, selected with -allocator=tlsf.
+//
+// The default allocator scans the heap linearly for a run of free blocks, which
+// gets slow on large heaps and tends to fragment the heap under mixed-size
+// allocation patterns. Instead, this allocator keeps every range of free blocks
+// in a free list, according to its size. Sizes are split into rows by their
+// highest bit, and every row is split into tlsfCols columns, so that the sizes
+// in a single list differ by at most 1/tlsfCols. Two levels of bitmaps record
+// which lists are non-empty, so that finding a free range that is big enough
+// takes a constant number of steps. The remainder of the range is put back in
+// the free lists.
+//
+// Free lists are stored in the free blocks themselves (see tlsfNode), so the
+// only memory overhead is the list heads. Memory is only freed by the GC, which
+// sweeps the entire heap anyway, so the free lists are simply rebuilt after
+// every GC cycle. This also merges adjacent free ranges.
+//
+// More information:
+// http://www.gii.upv.es/tlsf/files/papers/ecrts04_tlsf.pdf
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+const gcTLSF = true
+
+const (
+	tlsfColBits = 2
+	tlsfCols    = 1 << tlsfColBits
+
+	// There is a row for every bit of a block count, except for the lowest
+	// tlsfColBits bits which share row 0.
+	tlsfRows = unsafe.Sizeof(uintptr(0))*8 - tlsfColBits + 1
+
+	// Marks the end of a free list.
+	tlsfNoBlock = ^gcBlock(0)
+)
+
+var (
+	tlsfRowBitmap uintptr                     // rows with a non-empty free list
+	tlsfColBitmap [tlsfRows]uint8             // columns with a non-empty free list, per row
+	tlsfHeads     [tlsfRows][tlsfCols]gcBlock // first free range of each free list
+)
+
+// tlsfNode is stored at the start of every range of free blocks. It fits in a
+// single block.
+type tlsfNode struct {
+	size uintptr // number of free blocks in this range
+	next gcBlock
+	prev gcBlock
+}
+
+func (b gcBlock) tlsfNode() *tlsfNode {
+	return (*tlsfNode)(b.pointer())
+}
+
+// tlsfMapping returns the free list for free ranges of n blocks.
+func tlsfMapping(n uintptr) (row, col uintptr) {
+	if n < tlsfCols {
+		return 0, n
+	}
+	high := uintptr(bits.Len(uint(n))) - 1
+	return high - tlsfColBits + 1, n>>(high-tlsfColBits) - tlsfCols
+}
+
+// tlsfAlloc removes a range of n free blocks from the free lists and returns
+// the first block. It returns false if there is no free range big enough.
+func tlsfAlloc(n uintptr) (gcBlock, bool) {
+	// Round n up to the smallest size of the next free list, so that every
+	// range in the free list that is found is big enough.
+	rounded := n
+	if n >= tlsfCols {
+		rounded += 1<<(uintptr(bits.Len(uint(n)))-1-tlsfColBits) - 1
+	}
+	row, col := tlsfMapping(rounded)
+	block := tlsfNoBlock
+	if row < tlsfRows {
+		cols := tlsfColBitmap[row] & (^uint8(0) << col)
+		if cols == 0 {
+			// Look in the next row with a non-empty free list.
+			rows := tlsfRowBitmap & (^uintptr(0) << (row + 1))
+			if rows != 0 {
+				row = uintptr(bits.TrailingZeros(uint(rows)))
+				cols = tlsfColBitmap[row]
+			}
+		}
+		if cols != 0 {
+			col = uintptr(bits.TrailingZeros8(cols))
+			block = tlsfHeads[row][col]
+		}
+	}
+	if block == tlsfNoBlock && rounded != n {
+		// The free list that n itself maps to may still contain a range that
+		// is just big enough. Only check it when everything else failed, as
+		// the list might be long.
+		row, col = tlsfMapping(n)
+		if tlsfColBitmap[row]&(1<<col) != 0 {
+			for b := tlsfHeads[row][col]; b != tlsfNoBlock; b = b.tlsfNode().next {
+				if b.tlsfNode().size >= n {
+					block = b
+					break
+				}
+			}
+		}
+	}
+	if block == tlsfNoBlock {
+		return 0, false
+	}
+
+	// Split off the part of the range that isn't needed.
+	size := block.tlsfNode().size
+	tlsfRemove(block)
+	if size > n {
+		tlsfInsert(block+gcBlock(n), size-n)
+	}
+	return block, true
+}
+
+// tlsfInsert adds the range of n free blocks starting at block to the free
+// lists.
+func tlsfInsert(block gcBlock, n uintptr) {
+	row, col := tlsfMapping(n)
+	node := block.tlsfNode()
+	node.size = n
+	node.prev = tlsfNoBlock
+	node.next = tlsfNoBlock
+	if tlsfColBitmap[row]&(1<<col) != 0 {
+		node.next = tlsfHeads[row][col]
+		node.next.tlsfNode().prev = block
+	}
+	tlsfHeads[row][col] = block
+	tlsfColBitmap[row] |= 1 << col
+	tlsfRowBitmap |= 1 << row
+}
+
+// tlsfRemove removes the free range starting at block from its free list.
+func tlsfRemove(block gcBlock) {
+	node := block.tlsfNode()
+	if node.next != tlsfNoBlock {
+		node.next.tlsfNode().prev = node.prev
+	}
+	if node.prev != tlsfNoBlock {
+		node.prev.tlsfNode().next = node.next
+		return
+	}
+	row, col := tlsfMapping(node.size)
+	tlsfHeads[row][col] = node.next
+	if node.next == tlsfNoBlock {
+		tlsfColBitmap[row] &^= 1 << col
+		if tlsfColBitmap[row] == 0 {
+			tlsfRowBitmap &^= 1 << row
+		}
+	}
+}
+
+// tlsfRebuild recreates the free lists from the block states, merging adjacent
+// free blocks into a single range. It must be called whenever blocks are freed
+// or added to the heap.
+func tlsfRebuild() {
+	tlsfRowBitmap = 0
+	tlsfColBitmap = [tlsfRows]uint8{}
+	start := gcBlock(0)
+	for block := gcBlock(0); block <= endBlock; block++ {
+		if block < endBlock && block.state() == blockStateFree {
+			continue
+		}
+		if block > start {
+			tlsfInsert(start, uintptr(block-start))
+		}
+		start = block + 1
+	}
+}
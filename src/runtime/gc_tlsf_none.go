@@ -0,0 +1,13 @@
+//go:build !allocator.tlsf && (gc.conservative || gc.precise || gc.incremental || gc.compacting)
+
+package runtime
+
+// Stubs for the TLSF allocator, see gc_tlsf.go.
+
+const gcTLSF = false
+
+func tlsfAlloc(n uintptr) (gcBlock, bool) {
+	return 0, false
+}
+
+func tlsfRebuild() {}
@@ -1,9 +1,21 @@
 package runtime
 
-// This is a hashmap implementation for the map[T]T type.
-// It is very roughly based on the implementation of the Go hashmap:
+// This is a hashmap implementation for the map[T]T type. It is an open
+// addressing hash table loosely based on the "Swiss table" design:
 //
-//     https://golang.org/src/runtime/map.go
+//     https://abseil.io/about/design/swisstables
+//
+// The table is an array of groups. Each group holds 8 slots, and starts with a
+// control byte for every slot: either empty, deleted, or the lower 7 bits of
+// the hash of the key stored in the slot (with the top bit set). A lookup
+// starts at a group selected by the hash, compares the control bytes first and
+// only compares keys that have a matching control byte. If the key isn't in
+// the group and the group has an empty slot, the key isn't in the map.
+// Otherwise, the lookup continues at the next group in the probe sequence.
+//
+// Compared to chaining buckets, this needs no extra pointers or allocations
+// for overflowing buckets, and keeps small maps (up to 8 entries) in a single
+// group.
 
 import (
 	"reflect"
@@ -12,12 +24,13 @@ import (
 
 // The underlying hashmap structure for Go.
 type hashmap struct {
-	buckets    unsafe.Pointer // pointer to array of buckets
+	groups     unsafe.Pointer // pointer to array of groups, nil until the first insert
 	seed       uintptr
 	count      uintptr
 	keySize    uintptr // maybe this can store the key type as well? E.g. keysize == 5 means string?
 	valueSize  uintptr
-	bucketBits uint8
+	growthLeft uintptr // number of empty slots that can be used before the table must be rebuilt
+	groupBits  uint8
 	keyEqual   func(x, y unsafe.Pointer, n uintptr) bool
 	keyHash    func(key unsafe.Pointer, size, seed uintptr) uint32
 }
@@ -30,61 +43,69 @@ const (
 	hashmapAlgorithmInterface
 )
 
-// A hashmap bucket. A bucket is a container of 8 key/value pairs: first the
-// following two entries, then the 8 keys, then the 8 values. This somewhat odd
-// ordering is to make sure the keys and values are well aligned when one of
-// them is smaller than the system word size.
-type hashmapBucket struct {
-	tophash [8]uint8
-	next    *hashmapBucket // next bucket (if there are more than 8 in a chain)
+// Control byte values. Newly allocated memory is zero, so a new group starts
+// out with all slots empty.
+const (
+	hashmapCtrlEmpty   = 0x00
+	hashmapCtrlDeleted = 0x01
+	hashmapCtrlFull    = 0x80 // set for slots that are in use, combined with 7 bits of the hash
+)
+
+// A hashmap group. A group is a container of 8 key/value pairs: first the
+// control bytes, then the 8 keys, then the 8 values. This somewhat odd ordering
+// is to make sure the keys and values are well aligned when one of them is
+// smaller than the system word size.
+type hashmapGroup struct {
+	ctrl [8]uint8
 	// Followed by the actual keys, and then the actual values. These are
 	// allocated but as they're of variable size they can't be shown here.
 }
 
 type hashmapIterator struct {
-	buckets      unsafe.Pointer // pointer to array of hashapBuckets
-	numBuckets   uintptr        // length of buckets array
-	bucketNumber uintptr        // current index into buckets array
-	bucket       *hashmapBucket // current bucket in chain
-	bucketIndex  uint8          // current index into bucket
+	groups   unsafe.Pointer // groups of the map when the iteration started
+	numSlots uintptr        // number of slots in groups
+	offset   uintptr        // random slot where the iteration starts
+	slot     uintptr        // number of slots looked at so far
 }
 
 func hashmapNewIterator() unsafe.Pointer {
 	return unsafe.Pointer(new(hashmapIterator))
 }
 
-// Get the topmost 8 bits of the hash, without using a special value (like 0).
-func hashmapTopHash(hash uint32) uint8 {
-	tophash := uint8(hash >> 24)
-	if tophash < 1 {
-		// 0 means empty slot, so make it bigger.
-		tophash += 1
-	}
-	return tophash
+// Get the control byte for a key with the given hash.
+func hashmapCtrl(hash uint32) uint8 {
+	return uint8(hash>>25) | hashmapCtrlFull
 }
 
 // Create a new hashmap with the given keySize and valueSize.
+// The map is created with enough groups to hold sizeHint elements without
+// growing. Maps created without a size hint don't allocate anything until the
+// first element is inserted.
 func hashmapMake(keySize, valueSize uintptr, sizeHint uintptr, alg uint8) *hashmap {
-	bucketBits := uint8(0)
-	for hashmapHasSpaceToGrow(bucketBits) && hashmapOverLoadFactor(sizeHint, bucketBits) {
-		bucketBits++
+	m := &hashmap{
+		seed:      uintptr(fastrand()),
+		keySize:   keySize,
+		valueSize: valueSize,
+		keyEqual:  hashmapKeyEqualAlg(hashmapAlgorithm(alg)),
+		keyHash:   hashmapKeyHashAlg(hashmapAlgorithm(alg)),
+	}
+	if sizeHint == 0 {
+		return m
 	}
 
-	bucketBufSize := unsafe.Sizeof(hashmapBucket{}) + keySize*8 + valueSize*8
-	buckets := alloc(bucketBufSize*(1<<bucketBits), nil)
-
-	keyHash := hashmapKeyHashAlg(hashmapAlgorithm(alg))
-	keyEqual := hashmapKeyEqualAlg(hashmapAlgorithm(alg))
-
-	return &hashmap{
-		buckets:    buckets,
-		seed:       uintptr(fastrand()),
-		keySize:    keySize,
-		valueSize:  valueSize,
-		bucketBits: bucketBits,
-		keyEqual:   keyEqual,
-		keyHash:    keyHash,
+	groupBits := uint8(0)
+	for hashmapHasSpaceToGrow(groupBits) && hashmapMaxLoad(groupBits) < sizeHint {
+		groupBits++
+	}
+	if hashmapGroupSize(m) > (^uintptr(0)>>1)>>groupBits {
+		// The groups for this many elements wouldn't fit in memory, and the
+		// size calculation below would overflow. Ignore the hint, like the Go
+		// runtime does.
+		groupBits = 0
 	}
+	m.groupBits = groupBits
+	hashmapAllocGroups(m)
+	return m
 }
 
 func hashmapMakeUnsafePointer(keySize, valueSize uintptr, sizeHint uintptr, alg uint8) unsafe.Pointer {
@@ -119,23 +140,24 @@ func hashmapKeyHashAlg(alg hashmapAlgorithm) func(key unsafe.Pointer, n, seed ui
 	}
 }
 
-func hashmapHasSpaceToGrow(bucketBits uint8) bool {
+func hashmapHasSpaceToGrow(groupBits uint8) bool {
 	// Over this limit, we're likely to overflow uintptrs during calculations
 	// or numbers of hash elements.   Don't allow any more growth.
 	// With 29 bits, this is 2^32 elements anyway.
-	return bucketBits <= uint8((unsafe.Sizeof(uintptr(0))*8)-3)
+	return groupBits <= uint8((unsafe.Sizeof(uintptr(0))*8)-3)
 }
 
-func hashmapOverLoadFactor(n uintptr, bucketBits uint8) bool {
-	// "maximum" number of elements is 0.75 * buckets * elements per bucket
-	// to avoid overflow, this is calculated as
-	// max = 3 * (1/4 * buckets * elements per bucket)
-	//     = 3 * (buckets * (elements per bucket)/4)
-	//     = 3 * (buckets * (8/4)
-	//     = 3 * (buckets * 2)
-	//     = 6 * buckets
-	max := (uintptr(6) << bucketBits)
-	return n > max
+// hashmapMaxLoad returns the number of elements that fit in a table with the
+// given number of groups before it has to grow.
+func hashmapMaxLoad(groupBits uint8) uintptr {
+	if groupBits == 0 {
+		// A single group is always searched entirely, so it can be filled up
+		// completely.
+		return 8
+	}
+	// Keep at least 1/8th of the slots empty, so that most lookups for a key
+	// that isn't in the map stop at the first group.
+	return uintptr(7) << groupBits
 }
 
 // Return the number of entries in this hashmap, called from the len builtin.
@@ -154,123 +176,163 @@ func hashmapLenUnsafePointer(m unsafe.Pointer) int {
 }
 
 //go:inline
-func hashmapBucketSize(m *hashmap) uintptr {
-	return unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*8
+func hashmapGroupSize(m *hashmap) uintptr {
+	return unsafe.Sizeof(hashmapGroup{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*8
 }
 
 //go:inline
-func hashmapBucketAddr(m *hashmap, buckets unsafe.Pointer, n uintptr) *hashmapBucket {
-	bucketSize := hashmapBucketSize(m)
-	bucket := (*hashmapBucket)(unsafe.Add(buckets, bucketSize*n))
-	return bucket
+func hashmapGroupAddr(m *hashmap, groups unsafe.Pointer, n uintptr) *hashmapGroup {
+	groupSize := hashmapGroupSize(m)
+	group := (*hashmapGroup)(unsafe.Add(groups, groupSize*n))
+	return group
 }
 
 //go:inline
-func hashmapBucketAddrForHash(m *hashmap, hash uint32) *hashmapBucket {
-	numBuckets := uintptr(1) << m.bucketBits
-	bucketNumber := (uintptr(hash) & (numBuckets - 1))
-	return hashmapBucketAddr(m, m.buckets, bucketNumber)
-}
-
-//go:inline
-func hashmapSlotKey(m *hashmap, bucket *hashmapBucket, slot uint8) unsafe.Pointer {
-	slotKeyOffset := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*uintptr(slot)
-	slotKey := unsafe.Add(unsafe.Pointer(bucket), slotKeyOffset)
+func hashmapSlotKey(m *hashmap, group *hashmapGroup, slot uint8) unsafe.Pointer {
+	slotKeyOffset := unsafe.Sizeof(hashmapGroup{}) + uintptr(m.keySize)*uintptr(slot)
+	slotKey := unsafe.Add(unsafe.Pointer(group), slotKeyOffset)
 	return slotKey
 }
 
 //go:inline
-func hashmapSlotValue(m *hashmap, bucket *hashmapBucket, slot uint8) unsafe.Pointer {
-	slotValueOffset := unsafe.Sizeof(hashmapBucket{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*uintptr(slot)
-	slotValue := unsafe.Add(unsafe.Pointer(bucket), slotValueOffset)
+func hashmapSlotValue(m *hashmap, group *hashmapGroup, slot uint8) unsafe.Pointer {
+	slotValueOffset := unsafe.Sizeof(hashmapGroup{}) + uintptr(m.keySize)*8 + uintptr(m.valueSize)*uintptr(slot)
+	slotValue := unsafe.Add(unsafe.Pointer(group), slotValueOffset)
 	return slotValue
 }
 
-// Set a specified key to a given value. Grow the map if necessary.
+// hashmapGroupHasEmpty returns whether the group has at least one empty (not
+// deleted) slot.
 //
 //go:nobounds
-func hashmapSet(m *hashmap, key unsafe.Pointer, value unsafe.Pointer, hash uint32) {
-	if hashmapHasSpaceToGrow(m.bucketBits) && hashmapOverLoadFactor(m.count, m.bucketBits) {
-		hashmapGrow(m)
-		// seed changed when we grew; rehash key with new seed
-		hash = m.keyHash(key, m.keySize, m.seed)
+func hashmapGroupHasEmpty(group *hashmapGroup) bool {
+	for i := 0; i < 8; i++ {
+		if group.ctrl[i] == hashmapCtrlEmpty {
+			return true
+		}
 	}
+	return false
+}
 
-	tophash := hashmapTopHash(hash)
-	bucket := hashmapBucketAddrForHash(m, hash)
-	var lastBucket *hashmapBucket
-
-	// See whether the key already exists somewhere.
-	var emptySlotKey unsafe.Pointer
-	var emptySlotValue unsafe.Pointer
-	var emptySlotTophash *byte
-	for bucket != nil {
-		for i := uint8(0); i < 8; i++ {
-			slotKey := hashmapSlotKey(m, bucket, i)
-			slotValue := hashmapSlotValue(m, bucket, i)
-			if bucket.tophash[i] == 0 && emptySlotKey == nil {
-				// Found an empty slot, store it for if we couldn't find an
-				// existing slot.
-				emptySlotKey = slotKey
-				emptySlotValue = slotValue
-				emptySlotTophash = &bucket.tophash[i]
-			}
-			if bucket.tophash[i] == tophash {
-				// Could be an existing key that's the same.
+// hashmapAllocGroups allocates an empty table for m, with 1<<m.groupBits
+// groups.
+func hashmapAllocGroups(m *hashmap) {
+	m.groups = alloc(hashmapGroupSize(m)<<m.groupBits, nil)
+	m.growthLeft = hashmapMaxLoad(m.groupBits)
+}
+
+// hashmapFind returns the group and slot where the given key is stored, or a
+// nil group if the key is not in the map.
+//
+// Groups are probed in a triangular sequence (h, h+1, h+3, h+6, ...), which
+// visits every group exactly once as the number of groups is a power of two.
+//
+//go:nobounds
+func hashmapFind(m *hashmap, key unsafe.Pointer, hash uint32) (*hashmapGroup, uint8) {
+	if m.groups == nil {
+		return nil, 0
+	}
+	ctrl := hashmapCtrl(hash)
+	mask := uintptr(1)<<m.groupBits - 1
+	groupNumber := uintptr(hash) & mask
+	for i := uintptr(0); i <= mask; i++ {
+		group := hashmapGroupAddr(m, m.groups, groupNumber)
+		for slot := uint8(0); slot < 8; slot++ {
+			if group.ctrl[slot] == ctrl {
+				// This could be the key we're looking for.
+				slotKey := hashmapSlotKey(m, group, slot)
 				if m.keyEqual(key, slotKey, m.keySize) {
-					// found same key, replace it
-					memcpy(slotValue, value, m.valueSize)
-					return
+					return group, slot
 				}
 			}
 		}
-		lastBucket = bucket
-		bucket = bucket.next
+		if hashmapGroupHasEmpty(group) {
+			// The key would have been stored in this group.
+			break
+		}
+		groupNumber = (groupNumber + i + 1) & mask
 	}
-	if emptySlotKey == nil {
-		// Add a new bucket to the bucket chain.
-		// TODO: rebalance if necessary to avoid O(n) insert and lookup time.
-		lastBucket.next = (*hashmapBucket)(hashmapInsertIntoNewBucket(m, key, value, tophash))
+	return nil, 0
+}
+
+// Set a specified key to a given value. Grow the map if necessary.
+func hashmapSet(m *hashmap, key unsafe.Pointer, value unsafe.Pointer, hash uint32) {
+	if group, slot := hashmapFind(m, key, hash); group != nil {
+		// found same key, replace it
+		memcpy(hashmapSlotValue(m, group, slot), value, m.valueSize)
 		return
 	}
-	m.count++
-	memcpy(emptySlotKey, key, m.keySize)
-	memcpy(emptySlotValue, value, m.valueSize)
-	*emptySlotTophash = tophash
+	if m.growthLeft == 0 {
+		hashmapGrow(m)
+		// seed changed when we grew; rehash key with new seed
+		hash = m.keyHash(key, m.keySize, m.seed)
+	}
+	hashmapInsert(m, key, value, hash)
 }
 
 func hashmapSetUnsafePointer(m unsafe.Pointer, key unsafe.Pointer, value unsafe.Pointer, hash uint32) {
 	hashmapSet((*hashmap)(m), key, value, hash)
 }
 
-// hashmapInsertIntoNewBucket creates a new bucket, inserts the given key and
-// value into the bucket, and returns a pointer to this bucket.
-func hashmapInsertIntoNewBucket(m *hashmap, key, value unsafe.Pointer, tophash uint8) *hashmapBucket {
-	bucketBufSize := hashmapBucketSize(m)
-	bucketBuf := alloc(bucketBufSize, nil)
-	bucket := (*hashmapBucket)(bucketBuf)
-
-	// Insert into the first slot, which is empty as it has just been allocated.
-	slotKey := hashmapSlotKey(m, bucket, 0)
-	slotValue := hashmapSlotValue(m, bucket, 0)
-	m.count++
-	memcpy(slotKey, key, m.keySize)
-	memcpy(slotValue, value, m.valueSize)
-	bucket.tophash[0] = tophash
-	return bucket
+// hashmapInsert stores a key that is not in the map yet in the first free
+// slot of its probe sequence. There must be room for at least one more
+// element (m.growthLeft != 0).
+//
+//go:nobounds
+func hashmapInsert(m *hashmap, key, value unsafe.Pointer, hash uint32) {
+	mask := uintptr(1)<<m.groupBits - 1
+	groupNumber := uintptr(hash) & mask
+	for i := uintptr(0); ; i++ {
+		group := hashmapGroupAddr(m, m.groups, groupNumber)
+		for slot := uint8(0); slot < 8; slot++ {
+			if group.ctrl[slot]&hashmapCtrlFull != 0 {
+				continue
+			}
+			if group.ctrl[slot] == hashmapCtrlEmpty {
+				m.growthLeft--
+			}
+			group.ctrl[slot] = hashmapCtrl(hash)
+			memcpy(hashmapSlotKey(m, group, slot), key, m.keySize)
+			memcpy(hashmapSlotValue(m, group, slot), value, m.valueSize)
+			m.count++
+			return
+		}
+		groupNumber = (groupNumber + i + 1) & mask
+	}
 }
 
+// hashmapGrow rebuilds the table of the map when it has no empty slots left.
+// If many slots are only marked as deleted, the new table has the same size.
+// Otherwise it is twice as big.
 func hashmapGrow(m *hashmap) {
+	groupBits := m.groupBits
+	if m.groups != nil && m.count >= hashmapMaxLoad(m.groupBits)/2 && hashmapHasSpaceToGrow(m.groupBits) {
+		groupBits++
+	}
+	hashmapRehash(m, groupBits)
+}
+
+// hashmapShrink rebuilds the table of a mostly empty map at a smaller size, so
+// that a map that temporarily held many elements doesn't keep all that memory
+// alive. The new table is at most half full, so that it doesn't need to grow
+// again right away.
+func hashmapShrink(m *hashmap) {
+	groupBits := m.groupBits
+	for groupBits > 0 && m.count <= hashmapMaxLoad(groupBits-1)/2 {
+		groupBits--
+	}
+	hashmapRehash(m, groupBits)
+}
+
+// hashmapRehash moves all elements of the map to a new table with 1<<groupBits
+// groups, which also removes all deleted slots.
+func hashmapRehash(m *hashmap, groupBits uint8) {
 	// clone map as empty
 	n := *m
 	n.count = 0
 	n.seed = uintptr(fastrand())
-
-	// allocate our new buckets twice as big
-	n.bucketBits = m.bucketBits + 1
-	numBuckets := uintptr(1) << n.bucketBits
-	bucketBufSize := hashmapBucketSize(m)
-	n.buckets = alloc(bucketBufSize*numBuckets, nil)
+	n.groupBits = groupBits
+	hashmapAllocGroups(&n)
 
 	// use a hashmap iterator to go through the old map
 	var it hashmapIterator
@@ -280,15 +342,13 @@ func hashmapGrow(m *hashmap) {
 
 	for hashmapNext(m, &it, key, value) {
 		h := n.keyHash(key, uintptr(n.keySize), n.seed)
-		hashmapSet(&n, key, value, h)
+		hashmapInsert(&n, key, value, h)
 	}
 
 	*m = n
 }
 
 // Get the value of a specified key, or zero the value if not found.
-//
-//go:nobounds
 func hashmapGet(m *hashmap, key, value unsafe.Pointer, valueSize uintptr, hash uint32) bool {
 	if m == nil {
 		// Getting a value out of a nil map is valid. From the spec:
@@ -298,24 +358,10 @@ func hashmapGet(m *hashmap, key, value unsafe.Pointer, valueSize uintptr, hash u
 		return false
 	}
 
-	tophash := hashmapTopHash(hash)
-	bucket := hashmapBucketAddrForHash(m, hash)
-
-	// Try to find the key.
-	for bucket != nil {
-		for i := uint8(0); i < 8; i++ {
-			slotKey := hashmapSlotKey(m, bucket, i)
-			slotValue := hashmapSlotValue(m, bucket, i)
-			if bucket.tophash[i] == tophash {
-				// This could be the key we're looking for.
-				if m.keyEqual(key, slotKey, m.keySize) {
-					// Found the key, copy it.
-					memcpy(value, slotValue, m.valueSize)
-					return true
-				}
-			}
-		}
-		bucket = bucket.next
+	if group, slot := hashmapFind(m, key, hash); group != nil {
+		// Found the key, copy it.
+		memcpy(value, hashmapSlotValue(m, group, slot), m.valueSize)
+		return true
 	}
 
 	// Did not find the key.
@@ -329,8 +375,6 @@ func hashmapGetUnsafePointer(m unsafe.Pointer, key, value unsafe.Pointer, valueS
 
 // Delete a given key from the map. No-op when the key does not exist in the
 // map.
-//
-//go:nobounds
 func hashmapDelete(m *hashmap, key unsafe.Pointer, hash uint32) {
 	if m == nil {
 		// The delete builtin is defined even when the map is nil. From the spec:
@@ -339,28 +383,28 @@ func hashmapDelete(m *hashmap, key unsafe.Pointer, hash uint32) {
 		return
 	}
 
-	tophash := hashmapTopHash(hash)
-	bucket := hashmapBucketAddrForHash(m, hash)
+	group, slot := hashmapFind(m, key, hash)
+	if group == nil {
+		return
+	}
 
-	// Try to find the key.
-	for bucket != nil {
-		for i := uint8(0); i < 8; i++ {
-			slotKey := hashmapSlotKey(m, bucket, i)
-			if bucket.tophash[i] == tophash {
-				// This could be the key we're looking for.
-				if m.keyEqual(key, slotKey, m.keySize) {
-					// Found the key, delete it.
-					bucket.tophash[i] = 0
-					// Zero out the key and value so garbage collector doesn't pin the allocations.
-					memzero(slotKey, m.keySize)
-					slotValue := hashmapSlotValue(m, bucket, i)
-					memzero(slotValue, m.valueSize)
-					m.count--
-					return
-				}
-			}
-		}
-		bucket = bucket.next
+	// Zero out the key and value so garbage collector doesn't pin the allocations.
+	memzero(hashmapSlotKey(m, group, slot), m.keySize)
+	memzero(hashmapSlotValue(m, group, slot), m.valueSize)
+	if m.groupBits == 0 || hashmapGroupHasEmpty(group) {
+		// Lookups never continue past this group, so the slot can be reused
+		// like any other empty slot.
+		group.ctrl[slot] = hashmapCtrlEmpty
+		m.growthLeft++
+	} else {
+		// Lookups for other keys may need to continue past this slot.
+		group.ctrl[slot] = hashmapCtrlDeleted
+	}
+	m.count--
+
+	if m.groupBits != 0 && m.count <= hashmapMaxLoad(m.groupBits)/8 {
+		// The map is less than 1/8th full, so give back some memory.
+		hashmapShrink(m)
 	}
 }
 
@@ -373,46 +417,40 @@ func hashmapNext(m *hashmap, it *hashmapIterator, key, value unsafe.Pointer) boo
 		return false
 	}
 
-	if it.buckets == nil {
+	if it.groups == nil {
+		if m.groups == nil {
+			// Nothing was ever inserted in this map.
+			return false
+		}
 		// initialize iterator
-		it.buckets = m.buckets
-		it.numBuckets = uintptr(1) << m.bucketBits
+		it.groups = m.groups
+		it.numSlots = uintptr(8) << m.groupBits
+		// Start at a random slot, so that programs don't depend on the
+		// iteration order of maps. The number of slots is a power of two.
+		it.offset = uintptr(fastrand()) & (it.numSlots - 1)
 	}
 
-	for {
-		if it.bucketIndex >= 8 {
-			// end of bucket, move to the next in the chain
-			it.bucketIndex = 0
-			it.bucket = it.bucket.next
-		}
-		if it.bucket == nil {
-			if it.bucketNumber >= it.numBuckets {
-				// went through all buckets
-				return false
-			}
-			it.bucket = hashmapBucketAddr(m, it.buckets, it.bucketNumber)
-			it.bucketNumber++ // next bucket
-		}
-		if it.bucket.tophash[it.bucketIndex] == 0 {
-			// slot is empty - move on
-			it.bucketIndex++
+	for it.slot < it.numSlots {
+		index := (it.offset + it.slot) & (it.numSlots - 1)
+		group := hashmapGroupAddr(m, it.groups, index/8)
+		slot := uint8(index % 8)
+		it.slot++
+		if group.ctrl[slot]&hashmapCtrlFull == 0 {
+			// slot is empty or deleted - move on
 			continue
 		}
 
-		slotKey := hashmapSlotKey(m, it.bucket, it.bucketIndex)
+		slotKey := hashmapSlotKey(m, group, slot)
 		memcpy(key, slotKey, m.keySize)
 
-		if it.buckets == m.buckets {
-			// Our view of the buckets is the same as the parent map.
+		if it.groups == m.groups {
+			// Our view of the groups is the same as the parent map.
 			// Just copy the value we have
-			slotValue := hashmapSlotValue(m, it.bucket, it.bucketIndex)
+			slotValue := hashmapSlotValue(m, group, slot)
 			memcpy(value, slotValue, m.valueSize)
-			it.bucketIndex++
 		} else {
-			it.bucketIndex++
-
-			// Our view of the buckets doesn't match the parent map.
-			// Look up the key in the new buckets and return that value if it exists
+			// Our view of the groups doesn't match the parent map.
+			// Look up the key in the new groups and return that value if it exists
 			hash := m.keyHash(key, m.keySize, m.seed)
 			ok := hashmapGet(m, key, value, m.valueSize, hash)
 			if !ok {
@@ -425,6 +463,7 @@ func hashmapNext(m *hashmap, it *hashmapIterator, key, value unsafe.Pointer) boo
 
 		return true
 	}
+	return false
 }
 
 func hashmapNextUnsafePointer(m unsafe.Pointer, it unsafe.Pointer, key, value unsafe.Pointer) bool {
@@ -3,3 +3,13 @@
 package runtime
 
 const baremetal = false
+
+// Sample heap allocations by default, like the Go runtime.
+const defaultMemProfileRate = 512 * 1024
+
+// crashStackEnd returns the end of the stack that sp points into. It isn't
+// known on hosted systems, so the crash handler doesn't get a copy of the
+// stack.
+func crashStackEnd(sp uintptr) uintptr {
+	return 0
+}
@@ -0,0 +1,44 @@
+package runtime
+
+// This file implements the idle path of the scheduler: what happens when all
+// goroutines are blocked. By default the target-specific sleepTicks and
+// waitForEvents are used, but a board can install a hook that enters a
+// low-power mode instead.
+
+var sleepHook func(ns int64)
+
+// SetSleepHook sets the function that is called when all goroutines are
+// blocked, to put the chip into a low-power mode. It is called with the time
+// in nanoseconds until a goroutine or timer needs to run again, or with -1 if
+// goroutines are only waiting for an interrupt. A nil hook restores the
+// default sleep of the target.
+//
+// The hook is called from the scheduler, so it must not block on channels or
+// mutexes. It must return when an interrupt has happened (for example by
+// waiting with WFE or WFI), and may always return earlier than requested: the
+// scheduler will call it again if needed. If the tick counter of the chip stops
+// while sleeping, the hook must call ResyncClock after waking up.
+//
+// The hook is only used on baremetal targets.
+func SetSleepHook(hook func(ns int64)) {
+	sleepHook = hook
+}
+
+// idleSleep is called when no goroutine can run for the given number of ticks.
+// It may return early, for example when an interrupt happened.
+func idleSleep(d timeUnit) {
+	if baremetal && sleepHook != nil {
+		sleepHook(ticksToNanoseconds(d))
+		return
+	}
+	sleepTicks(d)
+}
+
+// idleWait is called when no goroutine can run until an interrupt happens.
+func idleWait() {
+	if baremetal && sleepHook != nil {
+		sleepHook(-1)
+		return
+	}
+	waitForEvents()
+}
@@ -1,5 +1,19 @@
 // Package interrupt provides access to hardware interrupts. It provides a way
 // to define interrupts and to enable/disable them.
+//
+// Interrupt handlers can't block. To hand data to a goroutine, send it on a
+// buffered channel, either with room to spare or using a select statement
+// with a default case so that the value is dropped when the buffer is full:
+//
+//	select {
+//	case events <- ev:
+//	default:
+//		// The goroutine didn't keep up.
+//	}
+//
+// Such a send never waits, and wakes up the goroutine if it is blocked
+// receiving from the channel. Channel operations that would block panic when
+// used inside an interrupt.
 package interrupt
 
 import "unsafe"
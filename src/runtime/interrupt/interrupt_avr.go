@@ -43,3 +43,10 @@ func Restore(state State) {
 func In() bool {
 	return false
 }
+
+// disabled returns whether interrupts are currently disabled on this core. The
+// runtime uses it to avoid preempting a goroutine inside a critical section.
+func disabled() bool {
+	// SREG is at I/O address 0x3f, the I bit is bit 7.
+	return device.AsmFull("in {}, 0x3f", nil)&0x80 == 0
+}
@@ -39,8 +39,13 @@ type State uintptr
 //
 // Critical sections can be nested. Make sure to call Restore in the same order
 // as you called Disable (this happens naturally with the pattern above).
+//
+// With -scheduler=cores, Disable also waits until no other core is inside a
+// critical section, so that critical sections are exclusive across cores.
 func Disable() (state State) {
-	return State(arm.DisableInterrupts())
+	state = State(arm.DisableInterrupts())
+	lockCores()
+	return state
 }
 
 // Restore restores interrupts to what they were before. Give the previous state
@@ -48,6 +53,7 @@ func Disable() (state State) {
 // calling Disable, this will not re-enable interrupts, allowing for nested
 // cricital sections.
 func Restore(state State) {
+	unlockCores()
 	arm.EnableInterrupts(uintptr(state))
 }
 
@@ -60,3 +66,9 @@ func In() bool {
 	vectactive := uint8(arm.SCB.ICSR.Get())
 	return vectactive != 0
 }
+
+// disabled returns whether interrupts are currently disabled on this core. The
+// runtime uses it to avoid preempting a goroutine inside a critical section.
+func disabled() bool {
+	return arm.AsmFull("mrs {}, PRIMASK", nil) != 0
+}
@@ -0,0 +1,13 @@
+//go:build cortexm && scheduler.cores
+
+package interrupt
+
+// Critical sections are shared by all cores, using a lock implemented in the
+// runtime (see src/runtime/scheduler_cores.go). The lock can be taken
+// recursively by the core that holds it.
+
+//go:linkname lockCores runtime.lockCores
+func lockCores()
+
+//go:linkname unlockCores runtime.unlockCores
+func unlockCores()
@@ -0,0 +1,10 @@
+//go:build cortexm && !scheduler.cores
+
+package interrupt
+
+// Only one core runs Go code, so disabling interrupts is enough to protect a
+// critical section.
+
+func lockCores() {}
+
+func unlockCores() {}
@@ -102,3 +102,9 @@ func Restore(state State) {
 func In() bool {
 	return inInterrupt
 }
+
+// disabled returns whether interrupts are currently disabled on this core. The
+// runtime uses it to avoid preempting a goroutine inside a critical section.
+func disabled() bool {
+	return gba.INTERRUPT.PAUSE.Get() == 0
+}
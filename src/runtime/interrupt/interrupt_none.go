@@ -1,4 +1,4 @@
-//go:build !baremetal
+//go:build !baremetal && !(tinygo.wasm && scheduler.cores)
 
 package interrupt
 
@@ -29,3 +29,10 @@ func In() bool {
 	// There are no interrupts, so it can't be in one.
 	return false
 }
+
+// disabled returns whether interrupts are currently disabled on this core. The
+// runtime uses it to avoid preempting a goroutine inside a critical section.
+func disabled() bool {
+	// There are no interrupts, so they can't be disabled.
+	return false
+}
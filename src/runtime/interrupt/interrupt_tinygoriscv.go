@@ -36,3 +36,9 @@ func In() bool {
 	// to ignore it. It's handled specially (in handleException).
 	return riscv.MCAUSE.Get() != 0
 }
+
+// disabled returns whether interrupts are currently disabled on this core. The
+// runtime uses it to avoid preempting a goroutine inside a critical section.
+func disabled() bool {
+	return riscv.MSTATUS.Get()&(1<<3) == 0 // MIE bit
+}
@@ -0,0 +1,50 @@
+//go:build tinygo.wasm && scheduler.cores
+
+package interrupt
+
+// There are no interrupts on WebAssembly, but with -scheduler=cores goroutines
+// run on more than one thread. Critical sections are shared by all threads,
+// using a lock implemented in the runtime (see src/runtime/scheduler_cores.go).
+// The lock can be taken recursively by the thread that holds it.
+
+// State represents the previous global interrupt state.
+type State uintptr
+
+// Disable waits until no other thread is inside a critical section, and
+// returns a value that must be passed to Restore at the end of the critical
+// section:
+//
+//	state := interrupt.Disable()
+//	// critical section
+//	interrupt.Restore(state)
+//
+// Critical sections can be nested. Make sure to call Restore in the same order
+// as you called Disable (this happens naturally with the pattern above).
+func Disable() (state State) {
+	lockCores()
+	return 0
+}
+
+// Restore ends a critical section started with Disable.
+func Restore(state State) {
+	unlockCores()
+}
+
+// In returns whether the system is currently in an interrupt.
+func In() bool {
+	// There are no interrupts, so it can't be in one.
+	return false
+}
+
+// disabled returns whether interrupts are currently disabled on this core. The
+// runtime uses it to avoid preempting a goroutine inside a critical section.
+func disabled() bool {
+	// There are no interrupts, so they can't be disabled.
+	return false
+}
+
+//go:linkname lockCores runtime.lockCores
+func lockCores()
+
+//go:linkname unlockCores runtime.unlockCores
+func unlockCores()
@@ -37,3 +37,12 @@ func Restore(state State) {
 func In() bool {
 	return false
 }
+
+// disabled returns whether interrupts are currently disabled on this core. The
+// runtime uses it to avoid preempting a goroutine inside a critical section.
+//
+// Interrupts are never enabled on Xtensa yet (PS.INTLEVEL stays at its reset
+// value), so this currently always returns true.
+func disabled() bool {
+	return device.AsmFull("rsr {}, PS", nil)&0xf != 0 // PS.INTLEVEL
+}
@@ -0,0 +1,202 @@
+//go:build leakcheck && (gc.conservative || gc.precise || gc.incremental || gc.compacting)
+
+package runtime
+
+// This file implements the leak check mode, enabled with -tags=leakcheck.
+// Every heap object gets one extra word at the end of its last block, which
+// holds the address it was allocated from. DumpAllocs walks the heap and
+// reports all objects grouped by that address. Calling it every now and then
+// and looking for a site that keeps growing is a way to find slow leaks on
+// devices that run for a long time. The goroutine dump that is printed on a
+// fatal panic or deadlock also shows where the channels and mutexes that
+// goroutines are blocked on were allocated.
+//
+// A code address never points into the heap, so the extra word doesn't keep
+// anything alive. It is copied together with the object when the heap is
+// compacted.
+
+import (
+	"runtime/interrupt"
+	"unsafe"
+)
+
+const leakCheck = true
+
+// Number of allocation sites that DumpAllocs can tell apart. Objects allocated
+// from any other site are reported together.
+const leakCheckMaxSites = 128
+
+type leakSite struct {
+	pc    uintptr
+	count uintptr
+	size  uintptr
+}
+
+var (
+	// Scratch space for DumpAllocs, which must not allocate while it walks
+	// the heap.
+	leakCheckSites [leakCheckMaxSites]leakSite
+	leakCheckOther leakSite
+	leakCheckLine  [128]byte
+)
+
+// leakCheckSetSite stores the return address of the call to alloc in the last
+// word of an object, which ends at the given address.
+//
+//go:nowritebarrier
+func leakCheckSetSite(end uintptr, pc unsafe.Pointer) {
+	*(*uintptr)(unsafe.Pointer(end - unsafe.Sizeof(uintptr(0)))) = uintptr(pc)
+}
+
+// leakCheckSite returns the allocation site of the object that ends right
+// before the given block, or 0 if it is unknown.
+func leakCheckSite(next gcBlock) uintptr {
+	pc := *(*uintptr)(unsafe.Pointer(next.address() - unsafe.Sizeof(uintptr(0))))
+	if pc != 0 {
+		pc -= callInstSize
+	}
+	return pc
+}
+
+// leakCheckObjectSite returns the allocation site of the heap object that
+// contains ptr, or 0 if ptr doesn't point into a heap object.
+func leakCheckObjectSite(ptr uintptr) uintptr {
+	if !isOnHeap(ptr) {
+		return 0
+	}
+	head := blockFromAddr(ptr).findHead()
+	if head.state() == blockStateFree {
+		return 0
+	}
+	return leakCheckSite(head.findNext())
+}
+
+// leakCheckCollect groups all objects on the heap by allocation site, sorted by
+// the number of bytes they use. It doesn't allocate: the returned slice refers
+// to scratch space that is overwritten by the next call.
+func leakCheckCollect() []leakSite {
+	mask := interrupt.Disable()
+	numSites := 0
+	leakCheckOther = leakSite{}
+	for block := gcBlock(0); block < endBlock; {
+		state := block.state()
+		if state != blockStateHead && state != blockStateMark {
+			block++
+			continue
+		}
+		next := block.findNext()
+		pc := leakCheckSite(next)
+		size := uintptr(next-block) * bytesPerBlock
+		block = next
+
+		site := &leakCheckOther
+		for i := 0; i < numSites; i++ {
+			if leakCheckSites[i].pc == pc {
+				site = &leakCheckSites[i]
+				break
+			}
+		}
+		if site == &leakCheckOther && numSites < len(leakCheckSites) {
+			site = &leakCheckSites[numSites]
+			*site = leakSite{pc: pc}
+			numSites++
+		}
+		site.count++
+		site.size += size
+	}
+	interrupt.Restore(mask)
+
+	// Sort by the number of bytes (insertion sort, as this must not allocate).
+	for i := 1; i < numSites; i++ {
+		for j := i; j > 0 && leakCheckSites[j].size > leakCheckSites[j-1].size; j-- {
+			leakCheckSites[j], leakCheckSites[j-1] = leakCheckSites[j-1], leakCheckSites[j]
+		}
+	}
+	return leakCheckSites[:numSites]
+}
+
+// DumpAllocs writes all objects on the heap to w, grouped by the place they
+// were allocated from and sorted by the number of bytes they use. Each line
+// contains the number of bytes, the number of objects, the address of the
+// allocation (which can be converted to a source location using the ELF file
+// of the program, for example with addr2line) and the name of the function it
+// is in when building with -linetable. The address is 0 if it is unknown, like
+// on WebAssembly.
+//
+// Objects that are unreachable but were not yet freed by the GC are included,
+// so call GC first to only see live objects.
+//
+// This is a TinyGo extension. It is only available when building with
+// -tags=leakcheck and one of the conservative, precise, incremental or
+// compacting GCs, otherwise it returns an error. In this mode, every heap
+// object uses one word of memory more.
+func DumpAllocs(w interface{ Write([]byte) (int, error) }) error {
+	sites := leakCheckCollect()
+	other := leakCheckOther
+	var count, size uintptr
+	for _, site := range sites {
+		count += site.count
+		size += site.size
+	}
+	count += other.count
+	size += other.size
+
+	line := append(leakCheckLine[:0], "heap objects by allocation site ("...)
+	line = appendLeakUint(line, count, 0)
+	line = append(line, " objects, "...)
+	line = appendLeakUint(line, size, 0)
+	line = append(line, " bytes):\n      bytes   count  pc\n"...)
+	if _, err := w.Write(line); err != nil {
+		return err
+	}
+	for _, site := range sites {
+		line = appendLeakSite(leakCheckLine[:0], site)
+		if name, _, ok := lineTableFunction(site.pc); ok {
+			line = append(line, ' ')
+			line = append(line, name...)
+		}
+		line = append(line, '\n')
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	if other.count != 0 {
+		line = appendLeakSite(leakCheckLine[:0], other)
+		line = append(line, " (other sites)\n"...)
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendLeakSite formats the size, count and address of a site, aligned with
+// the header printed by DumpAllocs.
+func appendLeakSite(line []byte, site leakSite) []byte {
+	line = appendLeakUint(line, site.size, 11)
+	line = appendLeakUint(line, site.count, 8)
+	line = append(line, "  0x"...)
+	for shift := int(unsafe.Sizeof(site.pc)*8) - 4; shift >= 0; shift -= 4 {
+		line = append(line, "0123456789abcdef"[(site.pc>>shift)&0xf])
+	}
+	return line
+}
+
+// appendLeakUint appends n in decimal, right-aligned in a field of the given
+// width.
+func appendLeakUint(line []byte, n uintptr, width int) []byte {
+	var digits [20]byte
+	i := len(digits)
+	for {
+		i--
+		digits[i] = byte('0' + n%10)
+		n /= 10
+		if n == 0 {
+			break
+		}
+	}
+	for ; len(digits)-i < width; width-- {
+		line = append(line, ' ')
+	}
+	return append(line, digits[i:]...)
+}
@@ -0,0 +1,32 @@
+//go:build !(leakcheck && (gc.conservative || gc.precise || gc.incremental || gc.compacting))
+
+package runtime
+
+import "unsafe"
+
+const leakCheck = false
+
+func leakCheckSetSite(end uintptr, pc unsafe.Pointer) {
+}
+
+func leakCheckObjectSite(ptr uintptr) uintptr {
+	return 0
+}
+
+// DumpAllocs writes all objects on the heap to w, grouped by the place they
+// were allocated from.
+//
+// This is a TinyGo extension. It is only available when building with
+// -tags=leakcheck and one of the conservative, precise, incremental or
+// compacting GCs, so it always returns an error.
+func DumpAllocs(w interface{ Write([]byte) (int, error) }) error {
+	return errLeakCheck
+}
+
+var errLeakCheck = leakCheckError{}
+
+type leakCheckError struct{}
+
+func (leakCheckError) Error() string {
+	return "heap allocation sites are not recorded, build with -tags=leakcheck"
+}
@@ -0,0 +1,187 @@
+//go:build tinygo.linetable
+
+package runtime
+
+// This file implements the lookups behind runtime.Callers, runtime.Caller and
+// runtime.FuncForPC with -linetable. The stack is walked using frame pointers,
+// which are kept by the compiler in this mode, and PCs are resolved using a
+// table that is created by the linker (see builder/linetable.go for the
+// format).
+//
+// Inlined functions don't have a stack frame of their own, so they don't show
+// up in stack traces. The source location of a PC is still exact though, so a
+// call inside an inlined function will report the file and line of that call.
+
+import "unsafe"
+
+//go:extern tinygo_pcln
+var lineTable [0]byte
+
+// frameAddress returns the frame pointer of its own stack frame, so that the
+// frame record it points to contains the return address into the caller.
+// It is implemented by the compiler.
+func frameAddress() unsafe.Pointer
+
+// Largest stack frame that is expected while walking the stack. This is used
+// to detect the end of the stack, where the frame pointer contains garbage.
+const maxFrameSize = 1 << 20
+
+type lineTableHeader struct {
+	tableAddr uintptr // link-time address of the table
+	base      uintptr // link-time address of the first function
+	numFuncs  uint32
+	numFiles  uint32
+}
+
+type lineTableFunc struct {
+	start uint32 // offset from the first function
+	size  uint32
+	name  uint32 // offset of the name in the table
+	lines uint32 // offset of the line rows in the table
+}
+
+// callers stores the return addresses of the functions on the stack in pc,
+// starting at the function that called callers (skip=1). It stops at the first
+// return address that isn't part of a known function. It returns the number of
+// return addresses stored in pc.
+//
+//go:noinline
+func callers(skip int, pc []uintptr) int {
+	fp := uintptr(frameAddress())
+	n := 0
+	for i := 0; n < len(pc); i++ {
+		ret := *(*uintptr)(unsafe.Add(unsafe.Pointer(fp), frameReturnOffset))
+		next := *(*uintptr)(unsafe.Add(unsafe.Pointer(fp), frameParentOffset))
+		if GOARCH == "arm" {
+			// Clear the Thumb bit.
+			ret &^= 1
+		}
+		if fn, _ := lineTableFind(ret - 1); fn == nil {
+			// Reached the start of the goroutine, or C code that doesn't
+			// keep frame pointers.
+			break
+		}
+		if i >= skip {
+			pc[n] = ret
+			n++
+		}
+		if next <= fp || next-fp > maxFrameSize || next%unsafe.Alignof(next) != 0 {
+			break
+		}
+		fp = next
+	}
+	return n
+}
+
+// lineTableFind returns the function that contains the given PC, and the
+// offset of the PC into the function. It returns nil if the function is not
+// found.
+func lineTableFind(pc uintptr) (*lineTableFunc, uintptr) {
+	header := (*lineTableHeader)(unsafe.Pointer(&lineTable))
+	if header.numFuncs == 0 {
+		return nil, 0
+	}
+	// The executable may have been loaded at a different address than the
+	// one it was linked at (PIE).
+	bias := uintptr(unsafe.Pointer(&lineTable)) - header.tableAddr
+	addr := pc - bias - header.base
+	funcs := unsafe.Slice((*lineTableFunc)(unsafe.Add(unsafe.Pointer(header), unsafe.Sizeof(lineTableHeader{}))), header.numFuncs)
+
+	// Find the last function that starts at or before addr.
+	low, high := 0, len(funcs)
+	for low < high {
+		mid := int(uint(low+high) >> 1)
+		if uintptr(funcs[mid].start) <= addr {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	if low == 0 {
+		return nil, 0
+	}
+	fn := &funcs[low-1]
+	offset := addr - uintptr(fn.start)
+	if offset >= uintptr(fn.size) {
+		// In between two functions, or past the last function.
+		return nil, 0
+	}
+	return fn, offset
+}
+
+// lineTableFunction returns the name and entry address of the function that
+// contains the given PC.
+func lineTableFunction(pc uintptr) (name string, entry uintptr, ok bool) {
+	fn, offset := lineTableFind(pc)
+	if fn == nil {
+		return "", 0, false
+	}
+	return lineTableString(fn.name), pc - offset, true
+}
+
+// lineTableLine returns the source location of the given PC.
+func lineTableLine(pc uintptr) (file string, line int) {
+	fn, offset := lineTableFind(pc)
+	if fn == nil {
+		return "", 0
+	}
+	header := (*lineTableHeader)(unsafe.Pointer(&lineTable))
+	p := unsafe.Add(unsafe.Pointer(&lineTable), fn.lines)
+	numRows, p := lineTableUvarint(p)
+	address := uintptr(0)
+	fileIndex := ^uintptr(0)
+	for i := uintptr(0); i < numRows; i++ {
+		var delta, index uintptr
+		var lineDelta int
+		delta, p = lineTableUvarint(p)
+		index, p = lineTableUvarint(p)
+		lineDelta, p = lineTableVarint(p)
+		if address+delta > offset {
+			break
+		}
+		address += delta
+		fileIndex = index
+		line += lineDelta
+	}
+	if fileIndex >= uintptr(header.numFiles) {
+		return "", 0
+	}
+	files := unsafe.Add(unsafe.Pointer(header), unsafe.Sizeof(lineTableHeader{})+uintptr(header.numFuncs)*unsafe.Sizeof(lineTableFunc{}))
+	return lineTableString(*(*uint32)(unsafe.Add(files, fileIndex*4))), line
+}
+
+// lineTableString returns the string stored at the given offset in the table.
+func lineTableString(offset uint32) string {
+	length, p := lineTableUvarint(unsafe.Add(unsafe.Pointer(&lineTable), offset))
+	s := _string{
+		ptr:    (*byte)(p),
+		length: length,
+	}
+	return *(*string)(unsafe.Pointer(&s))
+}
+
+// lineTableUvarint decodes an unsigned varint (like binary.Uvarint) and
+// returns it with a pointer to the byte after it.
+func lineTableUvarint(p unsafe.Pointer) (uintptr, unsafe.Pointer) {
+	var n uintptr
+	shift := 0
+	for {
+		b := *(*byte)(p)
+		p = unsafe.Add(p, 1)
+		n |= uintptr(b&0x7f) << shift
+		if b < 0x80 {
+			return n, p
+		}
+		shift += 7
+	}
+}
+
+// lineTableVarint decodes a signed varint (like binary.Varint).
+func lineTableVarint(p unsafe.Pointer) (int, unsafe.Pointer) {
+	u, p := lineTableUvarint(p)
+	n := int(u >> 1)
+	if u&1 != 0 {
+		n = ^n
+	}
+	return n, p
+}
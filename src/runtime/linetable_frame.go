@@ -0,0 +1,13 @@
+//go:build tinygo.linetable && !tinygo.riscv
+
+package runtime
+
+import "unsafe"
+
+// Location of the frame record, relative to the frame pointer. On most
+// architectures the frame pointer points to the saved frame pointer of the
+// parent, followed by the return address.
+const (
+	frameParentOffset = 0
+	frameReturnOffset = int(unsafe.Sizeof(uintptr(0)))
+)
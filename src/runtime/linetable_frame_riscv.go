@@ -0,0 +1,13 @@
+//go:build tinygo.linetable && tinygo.riscv
+
+package runtime
+
+import "unsafe"
+
+// Location of the frame record, relative to the frame pointer. On RISC-V the
+// frame pointer points right past the frame record, to the stack pointer of
+// the parent.
+const (
+	frameParentOffset = -2 * int(unsafe.Sizeof(uintptr(0)))
+	frameReturnOffset = -int(unsafe.Sizeof(uintptr(0)))
+)
@@ -0,0 +1,18 @@
+//go:build !tinygo.linetable
+
+package runtime
+
+// Stubs for when the program is built without -linetable: PCs can't be
+// resolved, and the stack can't be walked.
+
+func callers(skip int, pc []uintptr) int {
+	return 0
+}
+
+func lineTableFunction(pc uintptr) (name string, entry uintptr, ok bool) {
+	return "", 0, false
+}
+
+func lineTableLine(pc uintptr) (file string, line int) {
+	return "", 0
+}
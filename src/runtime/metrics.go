@@ -0,0 +1,27 @@
+package runtime
+
+// gcMetrics has the same layout as gcStats in runtime/metrics. It is filled in
+// by the GC, see gcReadMetrics.
+type gcMetrics struct {
+	numGC        uint64 // number of completed GC cycles
+	totalAlloc   uint64 // total number of bytes allocated
+	mallocs      uint64 // total number of allocations
+	frees        uint64 // total number of objects freed
+	heapInuse    uint64 // bytes in use by heap objects (including unreachable objects that weren't collected yet)
+	heapLive     uint64 // bytes in use right after the last GC cycle
+	heapGoal     uint64 // bytes in use at which the next GC cycle will be started
+	heapSys      uint64 // size of the heap
+	lastPauseNs  uint64 // duration of the last GC pause
+	pauseTotalNs uint64 // total time spent in the GC
+	gcPercent    int64  // as set with debug.SetGCPercent
+	memoryLimit  int64  // as set with debug.SetMemoryLimit
+}
+
+//go:linkname metrics_readGCStats runtime/metrics.readGCStats
+func metrics_readGCStats(m *gcMetrics) {
+	*m = gcMetrics{
+		gcPercent:   int64(gcPercent),
+		memoryLimit: gcMemoryLimit,
+	}
+	gcReadMetrics(m)
+}
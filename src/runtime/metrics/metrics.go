@@ -1,32 +1,264 @@
-// Package metrics is a dummy package that is not yet implemented.
-
+// Package metrics provides a stable interface to access implementation-defined
+// metrics exported by the Go runtime.
+//
+// This is a subset of the upstream Go package: only metrics about the heap and
+// the garbage collector are supported, see All for the list. They are updated
+// by every allocation and GC cycle, so they can be read often, for example to
+// log GC behavior or to back off when memory is running low. Reading them does
+// not allocate memory.
 package metrics
 
-type Description struct{}
+import (
+	"math"
+	"unsafe"
+)
+
+// Description describes a runtime metric.
+type Description struct {
+	// Name is the full name of the metric which includes the unit.
+	Name string
+
+	// Description is an English language sentence describing the metric.
+	Description string
+
+	// Kind is the kind of value for this metric.
+	Kind ValueKind
+
+	// Cumulative is whether or not the metric is cumulative. If a cumulative
+	// metric is just a single number, then it increases monotonically.
+	Cumulative bool
+}
+
+// The metrics supported by TinyGo. All of them are also available in upstream
+// Go, except for /gc/pauses/last:seconds.
+var allDesc = []Description{
+	{
+		Name:        "/cpu/classes/gc/total:cpu-seconds",
+		Description: "Total time spent performing GC tasks, including pauses for marking and sweeping.",
+		Kind:        KindFloat64,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/gc/cycles/total:gc-cycles",
+		Description: "Count of all completed GC cycles.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/gc/gogc:percent",
+		Description: "Heap size target percentage configured by the user, otherwise 100. This value is set by the GOGC environment variable, and the runtime/debug.SetGCPercent function.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/gc/gomemlimit:bytes",
+		Description: "Go runtime memory limit configured by the user, otherwise math.MaxInt64. This value is set by the runtime/debug.SetMemoryLimit function.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/gc/heap/allocs:bytes",
+		Description: "Cumulative sum of memory allocated to the heap by the application.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/gc/heap/allocs:objects",
+		Description: "Cumulative count of heap allocations triggered by the application.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/gc/heap/frees:objects",
+		Description: "Cumulative count of heap allocations whose storage was freed by the garbage collector.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/gc/heap/goal:bytes",
+		Description: "Heap size target for the end of the GC cycle. A GC cycle is started when this many bytes are in use.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/gc/heap/live:bytes",
+		Description: "Heap memory occupied by live objects that were marked by the previous GC.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/gc/pauses/last:seconds",
+		Description: "Duration of the last GC pause: a whole GC cycle, or a slice of marking work with the incremental GC. This metric is specific to TinyGo.",
+		Kind:        KindFloat64,
+	},
+	{
+		Name:        "/memory/classes/heap/objects:bytes",
+		Description: "Memory occupied by live objects and dead objects that have not yet been freed by the garbage collector.",
+		Kind:        KindUint64,
+	},
+	{
+		Name:        "/memory/classes/total:bytes",
+		Description: "All memory that can be used by the heap.",
+		Kind:        KindUint64,
+	},
+}
 
+// All returns a slice of containing metric descriptions for all supported
+// metrics.
 func All() []Description {
-	return nil
+	return append([]Description(nil), allDesc...)
 }
 
-type Float64Histogram struct{}
+// ValueKind is a tag for a metric Value which indicates its type.
+type ValueKind int
 
-type Sample struct{}
+const (
+	// KindBad indicates that the Value has no type and should not be used.
+	KindBad ValueKind = iota
 
-func Read(m []Sample) {}
+	// KindUint64 indicates that the type of the Value is a uint64.
+	KindUint64
 
-type Value struct{}
+	// KindFloat64 indicates that the type of the Value is a float64.
+	KindFloat64
 
-func (v Value) Float64() float64 {
-	return 0
+	// KindFloat64Histogram indicates that the type of the Value is a
+	// *Float64Histogram.
+	KindFloat64Histogram
+)
+
+// Float64Histogram represents a distribution of float64 values. No metric of
+// this kind is currently supported by TinyGo.
+type Float64Histogram struct {
+	// Counts contains the weights for each histogram bucket.
+	Counts []uint64
+
+	// Buckets contains the boundaries of the histogram buckets, in increasing
+	// order.
+	Buckets []float64
 }
-func (v Value) Float64Histogram() *Float64Histogram {
-	return nil
+
+// Value represents a metric value returned by the runtime.
+type Value struct {
+	kind    ValueKind
+	scalar  uint64         // contains scalar values for scalar Kinds.
+	pointer unsafe.Pointer // contains non-scalar values.
 }
+
+// Kind returns the tag representing the kind of value this is.
 func (v Value) Kind() ValueKind {
-	return ValueKind{}
+	return v.kind
 }
+
+// Uint64 returns the internal uint64 value for the metric.
+//
+// If v.Kind() != KindUint64, this method panics.
 func (v Value) Uint64() uint64 {
-	return 0
+	if v.kind != KindUint64 {
+		panic("called Uint64 on non-uint64 metric value")
+	}
+	return v.scalar
 }
 
-type ValueKind struct{}
+// Float64 returns the internal float64 value for the metric.
+//
+// If v.Kind() != KindFloat64, this method panics.
+func (v Value) Float64() float64 {
+	if v.kind != KindFloat64 {
+		panic("called Float64 on non-float64 metric value")
+	}
+	return math.Float64frombits(v.scalar)
+}
+
+// Float64Histogram returns the internal *Float64Histogram value for the metric.
+//
+// If v.Kind() != KindFloat64Histogram, this method panics.
+func (v Value) Float64Histogram() *Float64Histogram {
+	if v.kind != KindFloat64Histogram {
+		panic("called Float64Histogram on non-Float64Histogram metric value")
+	}
+	return (*Float64Histogram)(v.pointer)
+}
+
+// Sample captures a single metric sample.
+type Sample struct {
+	// Name is the name of the metric sampled.
+	//
+	// It must correspond to a name in one of the metric descriptions
+	// returned by All.
+	Name string
+
+	// Value is the value of the metric sample.
+	Value Value
+}
+
+// gcStats has the same layout as gcMetrics in the runtime.
+type gcStats struct {
+	numGC        uint64
+	totalAlloc   uint64
+	mallocs      uint64
+	frees        uint64
+	heapInuse    uint64
+	heapLive     uint64
+	heapGoal     uint64
+	heapSys      uint64
+	lastPauseNs  uint64
+	pauseTotalNs uint64
+	gcPercent    int64
+	memoryLimit  int64
+}
+
+func readGCStats(m *gcStats) // implemented in package runtime
+
+// Read populates each Value field in the given slice of metric samples.
+//
+// Desired metrics should be present in the slice with the appropriate name.
+// If a name is not known to this implementation, the Value of the sample will
+// have kind KindBad.
+//
+// All samples are taken at the same time, so they are consistent with each
+// other.
+func Read(m []Sample) {
+	var stats gcStats
+	readGCStats(&stats)
+	for i := range m {
+		v := &m[i].Value
+		v.kind = KindUint64
+		v.pointer = nil
+		switch m[i].Name {
+		case "/cpu/classes/gc/total:cpu-seconds":
+			v.setSeconds(stats.pauseTotalNs)
+		case "/gc/cycles/total:gc-cycles":
+			v.scalar = stats.numGC
+		case "/gc/gogc:percent":
+			v.scalar = uint64(stats.gcPercent)
+			if stats.gcPercent < 0 {
+				// The GC is turned off with a negative percentage.
+				v.scalar = 0
+			}
+		case "/gc/gomemlimit:bytes":
+			v.scalar = uint64(stats.memoryLimit)
+		case "/gc/heap/allocs:bytes":
+			v.scalar = stats.totalAlloc
+		case "/gc/heap/allocs:objects":
+			v.scalar = stats.mallocs
+		case "/gc/heap/frees:objects":
+			v.scalar = stats.frees
+		case "/gc/heap/goal:bytes":
+			v.scalar = stats.heapGoal
+		case "/gc/heap/live:bytes":
+			v.scalar = stats.heapLive
+		case "/gc/pauses/last:seconds":
+			v.setSeconds(stats.lastPauseNs)
+		case "/memory/classes/heap/objects:bytes":
+			v.scalar = stats.heapInuse
+		case "/memory/classes/total:bytes":
+			v.scalar = stats.heapSys
+		default:
+			v.kind = KindBad
+			v.scalar = 0
+		}
+	}
+}
+
+// setSeconds sets v to a duration in nanoseconds, as a float64 in seconds.
+func (v *Value) setSeconds(ns uint64) {
+	v.kind = KindFloat64
+	v.scalar = math.Float64bits(float64(ns) / 1e9)
+}
@@ -0,0 +1,96 @@
+package metrics_test
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"testing"
+)
+
+// The metrics supported by TinyGo, with their kind.
+var supported = map[string]metrics.ValueKind{
+	"/cpu/classes/gc/total:cpu-seconds":  metrics.KindFloat64,
+	"/gc/cycles/total:gc-cycles":         metrics.KindUint64,
+	"/gc/gogc:percent":                   metrics.KindUint64,
+	"/gc/gomemlimit:bytes":               metrics.KindUint64,
+	"/gc/heap/allocs:bytes":              metrics.KindUint64,
+	"/gc/heap/allocs:objects":            metrics.KindUint64,
+	"/gc/heap/frees:objects":             metrics.KindUint64,
+	"/gc/heap/goal:bytes":                metrics.KindUint64,
+	"/gc/heap/live:bytes":                metrics.KindUint64,
+	"/gc/pauses/last:seconds":            metrics.KindFloat64,
+	"/memory/classes/heap/objects:bytes": metrics.KindUint64,
+	"/memory/classes/total:bytes":        metrics.KindUint64,
+}
+
+// TestAll checks the names and kinds of all supported metrics.
+func TestAll(t *testing.T) {
+	all := metrics.All()
+	if len(all) != len(supported) {
+		t.Errorf("expected %d metrics, got %d", len(supported), len(all))
+	}
+	for _, desc := range all {
+		kind, ok := supported[desc.Name]
+		if !ok {
+			t.Errorf("unexpected metric %s", desc.Name)
+			continue
+		}
+		if desc.Kind != kind {
+			t.Errorf("metric %s: expected kind %d, got %d", desc.Name, kind, desc.Kind)
+		}
+		if desc.Description == "" {
+			t.Errorf("metric %s has no description", desc.Name)
+		}
+	}
+
+	// All returns a copy, so changing it must not change the next result.
+	all[0].Name = "changed"
+	if metrics.All()[0].Name == "changed" {
+		t.Error("All returned the internal slice")
+	}
+}
+
+// TestRead checks that Read returns every supported metric with the right
+// kind, and KindBad for unknown metrics.
+func TestRead(t *testing.T) {
+	all := metrics.All()
+	samples := make([]metrics.Sample, len(all)+1)
+	for i, desc := range all {
+		samples[i].Name = desc.Name
+	}
+	samples[len(all)].Name = "/unknown:bytes"
+	metrics.Read(samples)
+
+	for i, desc := range all {
+		if kind := samples[i].Value.Kind(); kind != desc.Kind {
+			t.Errorf("metric %s: expected kind %d, got %d", desc.Name, desc.Kind, kind)
+		}
+	}
+	if kind := samples[len(all)].Value.Kind(); kind != metrics.KindBad {
+		t.Errorf("unknown metric: expected KindBad, got %d", kind)
+	}
+}
+
+var sink []byte
+
+// TestReadChanges checks that cumulative metrics increase after allocating
+// memory and running the GC.
+func TestReadChanges(t *testing.T) {
+	samples := []metrics.Sample{
+		{Name: "/gc/cycles/total:gc-cycles"},
+		{Name: "/gc/heap/allocs:objects"},
+	}
+	metrics.Read(samples)
+	cycles := samples[0].Value.Uint64()
+	allocs := samples[1].Value.Uint64()
+
+	sink = make([]byte, 100)
+	runtime.GC()
+
+	metrics.Read(samples)
+	if samples[0].Value.Uint64() <= cycles {
+		t.Errorf("GC cycles did not increase: %d -> %d", cycles, samples[0].Value.Uint64())
+	}
+	if samples[1].Value.Uint64() <= allocs {
+		t.Errorf("allocated objects did not increase: %d -> %d", allocs, samples[1].Value.Uint64())
+	}
+}
@@ -0,0 +1,49 @@
+//go:build baremetal
+
+package runtime
+
+import "runtime/interrupt"
+
+// The monotonic clock on baremetal targets. Every target provides
+// hardwareTicks, which reads a hardware counter (extended to 64 bits by the
+// target where needed). This file makes sure that the clock seen by the rest
+// of the runtime never goes backwards, and keeps counting across sleep modes
+// in which the hardware counter stops or is reset.
+
+var (
+	ticksOffset timeUnit // added to hardwareTicks, see ResyncClock
+	ticksLast   timeUnit // the last value returned by ticks
+)
+
+// ticks returns the number of ticks since the program started.
+func ticks() timeUnit {
+	// Read the counter before disabling interrupts: some targets need the
+	// counter overflow interrupt to run while reading it.
+	hw := hardwareTicks()
+	mask := interrupt.Disable()
+	t := hw + ticksOffset
+	if t < ticksLast {
+		// Another caller read the counter later but returned first, or the
+		// hardware counter was glitched. Don't let the clock go backwards.
+		t = ticksLast
+	}
+	ticksLast = t
+	interrupt.Restore(mask)
+	return t
+}
+
+// ResyncClock must be called by code that puts the chip in a sleep mode in
+// which the hardware tick counter stops or is reset, after waking up. It is
+// passed the time in nanoseconds that the chip slept, as measured by a clock
+// that kept running (such as an RTC). The monotonic clock then continues from
+// where it was before sleeping, plus the given duration, so that timeouts and
+// time.Since stay correct.
+//
+// It must not be called after sleep modes in which the tick counter keeps
+// running: that would count the sleep time twice.
+func ResyncClock(slept int64) {
+	hw := hardwareTicks()
+	mask := interrupt.Disable()
+	ticksOffset = ticksLast + nanosecondsToTicks(slept) - hw
+	interrupt.Restore(mask)
+}
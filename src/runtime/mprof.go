@@ -0,0 +1,374 @@
+package runtime
+
+import (
+	"runtime/interrupt"
+	"unsafe"
+)
+
+// MemProfileRate controls the fraction of memory allocations that are recorded
+// and reported in the memory profile. The profiler aims to sample an average
+// of one allocation per MemProfileRate bytes allocated.
+//
+// To include every allocated block in the profile, set MemProfileRate to 1.
+// To turn off profiling entirely, set MemProfileRate to 0.
+//
+// The default is 512 KiB on hosted systems, and 0 on microcontrollers where
+// the profile would take up scarce RAM. Programs that change it should do so
+// just once, as early as possible in their execution (e.g., at the beginning
+// of main).
+var MemProfileRate int = defaultMemProfileRate
+
+// Maximum number of return addresses that are recorded for each sampled
+// allocation. Only the first (the function that allocated) is known, unless
+// the program is built with -linetable.
+const memProfileStackDepth = 8
+
+// Maximum number of different stacks in the memory profile. Samples from other
+// stacks are dropped once the profile is full.
+const memProfileBuckets = 64
+
+type memProfileBucket struct {
+	stack        [memProfileStackDepth]uintptr
+	allocBytes   int64
+	allocObjects int64
+}
+
+var (
+	memProfileTable    *[memProfileBuckets]memProfileBucket // allocated at the first sample
+	memProfileUsed     int                                  // number of used buckets
+	memProfileNext     uintptr                              // bytes left to allocate until the next sample
+	memProfileSampling bool                                 // true while memProfileTable is allocated
+)
+
+// memProfileAlloc is called for every heap allocation while MemProfileRate is
+// set. It samples about one allocation per MemProfileRate bytes, so that an
+// allocation of size bytes is recorded with a probability of
+// size/MemProfileRate. The pc is the return address of the call to alloc.
+//
+//go:noinline
+func memProfileAlloc(pc unsafe.Pointer, size uintptr) {
+	if size < memProfileNext {
+		memProfileNext -= size
+		return
+	}
+	if memProfileSampling {
+		// This is the allocation of memProfileTable itself.
+		return
+	}
+	memProfileNext = uintptr(MemProfileRate)
+
+	var stack [memProfileStackDepth]uintptr
+	stack[0] = uintptr(pc)
+	if stack[0] != 0 {
+		// Skip memProfileAlloc and alloc.
+		callers(3, stack[1:])
+	}
+
+	if memProfileTable == nil {
+		memProfileSampling = true
+		memProfileTable = new([memProfileBuckets]memProfileBucket)
+		memProfileSampling = false
+	}
+	for i := 0; i < memProfileBuckets; i++ {
+		bucket := &memProfileTable[i]
+		if i == memProfileUsed {
+			bucket.stack = stack
+			memProfileUsed++
+		} else if bucket.stack != stack {
+			continue
+		}
+		bucket.allocBytes += int64(size)
+		bucket.allocObjects++
+		return
+	}
+}
+
+// MemProfile returns a profile of memory allocated and freed per allocation
+// site.
+//
+// MemProfile returns n, the number of records in the current memory profile.
+// If len(p) >= n, MemProfile copies the profile into p and returns n, true.
+// If len(p) < n, MemProfile does not change p and returns n, false.
+//
+// The allocations are sampled according to MemProfileRate. Frees are not
+// recorded, so all sampled allocations are reported as in use and inuseZero
+// is ignored.
+func MemProfile(p []MemProfileRecord, inuseZero bool) (n int, ok bool) {
+	n = memProfileUsed
+	if n > len(p) {
+		return n, false
+	}
+	for i := 0; i < n; i++ {
+		bucket := &memProfileTable[i]
+		p[i] = MemProfileRecord{
+			AllocBytes:   bucket.allocBytes,
+			AllocObjects: bucket.allocObjects,
+		}
+		copy(p[i].Stack0[:], bucket.stack[:])
+	}
+	return n, true
+}
+
+// A MemProfileRecord describes the live objects allocated by a particular call
+// sequence (stack trace).
+type MemProfileRecord struct {
+	AllocBytes, FreeBytes     int64       // number of bytes allocated, freed
+	AllocObjects, FreeObjects int64       // number of objects allocated, freed
+	Stack0                    [32]uintptr // stack trace for this record; ends at first 0 entry
+}
+
+// InUseBytes returns the number of bytes in use (AllocBytes - FreeBytes).
+func (r *MemProfileRecord) InUseBytes() int64 { return r.AllocBytes - r.FreeBytes }
+
+// InUseObjects returns the number of objects in use (AllocObjects - FreeObjects).
+func (r *MemProfileRecord) InUseObjects() int64 {
+	return r.AllocObjects - r.FreeObjects
+}
+
+// Stack returns the stack trace associated with the record, a prefix of
+// r.Stack0.
+func (r *MemProfileRecord) Stack() []uintptr {
+	for i, v := range r.Stack0 {
+		if v == 0 {
+			return r.Stack0[0:i]
+		}
+	}
+	return r.Stack0[0:]
+}
+
+// Profiles of blocking events and mutex contention. Like the memory profile,
+// they only contain the function that blocked unless the program is built with
+// -linetable. On WebAssembly, where return addresses are unknown, all events
+// are recorded with an empty stack.
+
+// Maximum number of return addresses that are recorded for each blocking event.
+const blockProfileStackDepth = 8
+
+// Maximum number of different stacks in the block and mutex profiles. Events
+// from other stacks are dropped once a profile is full.
+const blockProfileBuckets = 64
+
+type blockProfileBucket struct {
+	stack [blockProfileStackDepth]uintptr
+	count int64
+	delay int64 // nanoseconds
+}
+
+type blockProfileTable struct {
+	buckets [blockProfileBuckets]blockProfileBucket
+	used    int
+}
+
+var (
+	blockProfileRate     int64              // nanoseconds per sampled event, or 0 if disabled
+	blockProfile         *blockProfileTable // allocated when the block profile is enabled
+	mutexProfileFraction int64              // 1/mutexProfileFraction events are sampled, or 0 if disabled
+	mutexProfile         *blockProfileTable // allocated when the mutex profile is enabled
+)
+
+// SetBlockProfileRate controls the fraction of goroutine blocking events that
+// are reported in the blocking profile. The profiler aims to sample an average
+// of one blocking event per rate nanoseconds spent blocked.
+//
+// To include every blocking event in the profile, pass rate = 1. To turn off
+// profiling entirely, pass rate <= 0.
+//
+// Blocking events are channel operations, select statements, and locking a
+// sync.Mutex or sync.RWMutex.
+func SetBlockProfileRate(rate int) {
+	if rate > 0 && blockProfile == nil {
+		blockProfile = new(blockProfileTable)
+	}
+	if rate < 0 {
+		rate = 0
+	}
+	blockProfileRate = int64(rate)
+}
+
+// SetMutexProfileFraction controls the fraction of mutex contention events
+// that are reported in the mutex profile. On average 1/rate events are
+// reported. The previous rate is returned.
+//
+// To turn off profiling entirely, pass rate 0. To just read the current rate,
+// pass rate < 0. (For n>1 the details of sampling may change.)
+//
+// Unlike in upstream Go, the event is recorded with the stack of the goroutine
+// that waited for the mutex instead of the one that unlocked it.
+func SetMutexProfileFraction(rate int) int {
+	if rate < 0 {
+		return int(mutexProfileFraction)
+	}
+	if rate > 0 && mutexProfile == nil {
+		mutexProfile = new(blockProfileTable)
+	}
+	old := mutexProfileFraction
+	mutexProfileFraction = int64(rate)
+	return int(old)
+}
+
+// blockEventStart returns the time at which the current goroutine starts to
+// block, or 0 if blocking events are not profiled.
+func blockEventStart() int64 {
+	if blockProfileRate == 0 {
+		return 0
+	}
+	return nanotime()
+}
+
+// blockEvent records a blocking event that started at start, unless start is
+// 0. The pc is the return address of the channel operation that blocked.
+//
+//go:noinline
+func blockEvent(start int64, pc unsafe.Pointer) {
+	if start == 0 {
+		return
+	}
+	delay := nanotime() - start
+	if !blockEventSampled(delay) {
+		return
+	}
+	var stack [blockProfileStackDepth]uintptr
+	stack[0] = uintptr(pc)
+	if stack[0] != 0 {
+		// Skip blockEvent and the channel operation.
+		callers(3, stack[1:])
+	}
+	blockProfile.add(&stack, delay)
+}
+
+// blockEventSampled returns whether a blocking event of the given duration
+// should be recorded. Events longer than the rate are always recorded, shorter
+// events with a probability of delay/rate.
+func blockEventSampled(delay int64) bool {
+	rate := blockProfileRate
+	if rate <= 0 {
+		return false
+	}
+	return delay >= rate || int64(fastrand64()%uint64(rate)) < delay
+}
+
+// mutexWaitStart returns the time at which the current goroutine starts to
+// wait for a mutex, or 0 if neither blocking events nor mutex contention are
+// profiled.
+func mutexWaitStart() int64 {
+	if blockProfileRate == 0 && mutexProfileFraction == 0 {
+		return 0
+	}
+	return nanotime()
+}
+
+// mutexWaitEnd records that the current goroutine waited for a mutex since
+// start, unless start is 0. It is called by the Lock methods in package sync.
+//
+//go:noinline
+func mutexWaitEnd(start int64) {
+	if start == 0 {
+		return
+	}
+	delay := nanotime() - start
+	blockSampled := blockEventSampled(delay)
+	rate := mutexProfileFraction
+	mutexSampled := rate > 0 && fastrand64()%uint64(rate) == 0
+	if !blockSampled && !mutexSampled {
+		return
+	}
+	var stack [blockProfileStackDepth]uintptr
+	stack[0] = uintptr(returnAddress(0))
+	if stack[0] != 0 {
+		// Skip mutexWaitEnd and the Lock method.
+		callers(2, stack[1:])
+	}
+	if blockSampled {
+		blockProfile.add(&stack, delay)
+	}
+	if mutexSampled {
+		mutexProfile.add(&stack, delay)
+	}
+}
+
+// add records an event with the given stack and delay in the profile.
+func (t *blockProfileTable) add(stack *[blockProfileStackDepth]uintptr, delay int64) {
+	mask := interrupt.Disable()
+	for i := 0; i < blockProfileBuckets; i++ {
+		bucket := &t.buckets[i]
+		if i == t.used {
+			bucket.stack = *stack
+			t.used++
+		} else if bucket.stack != *stack {
+			continue
+		}
+		bucket.count++
+		bucket.delay += delay
+		break
+	}
+	interrupt.Restore(mask)
+}
+
+// read copies the profile into p, see BlockProfile.
+func (t *blockProfileTable) read(p []BlockProfileRecord) (n int, ok bool) {
+	if t == nil {
+		return 0, true
+	}
+	mask := interrupt.Disable()
+	defer interrupt.Restore(mask)
+	n = t.used
+	if n > len(p) {
+		return n, false
+	}
+	for i := 0; i < n; i++ {
+		bucket := &t.buckets[i]
+		p[i] = BlockProfileRecord{
+			Count:  bucket.count,
+			Cycles: bucket.delay,
+		}
+		copy(p[i].Stack0[:], bucket.stack[:])
+	}
+	return n, true
+}
+
+// BlockProfile returns n, the number of records in the current blocking
+// profile. If len(p) >= n, BlockProfile copies the profile into p and returns
+// n, true. If len(p) < n, BlockProfile does not change p and returns n, false.
+//
+// Most clients should use the runtime/pprof package instead of calling
+// BlockProfile directly.
+func BlockProfile(p []BlockProfileRecord) (n int, ok bool) {
+	return blockProfile.read(p)
+}
+
+// MutexProfile returns n, the number of records in the current mutex profile.
+// If len(p) >= n, MutexProfile copies the profile into p and returns n, true.
+// Otherwise, MutexProfile does not change p, and returns n, false.
+//
+// Most clients should use the runtime/pprof package instead of calling
+// MutexProfile directly.
+func MutexProfile(p []BlockProfileRecord) (n int, ok bool) {
+	return mutexProfile.read(p)
+}
+
+// BlockProfileRecord describes blocking events originated at a particular call
+// sequence (stack trace).
+//
+// Unlike in upstream Go, Cycles is the total time spent blocked in
+// nanoseconds, not in CPU ticks.
+type BlockProfileRecord struct {
+	Count  int64
+	Cycles int64
+	StackRecord
+}
+
+// A StackRecord describes a single execution stack.
+type StackRecord struct {
+	Stack0 [32]uintptr // stack trace for this record; ends at first 0 entry
+}
+
+// Stack returns the stack trace associated with the record, a prefix of
+// r.Stack0.
+func (r *StackRecord) Stack() []uintptr {
+	for i, v := range r.Stack0 {
+		if v == 0 {
+			return r.Stack0[0:i]
+		}
+	}
+	return r.Stack0[0:]
+}
@@ -3,12 +3,19 @@ package runtime
 // Memory statistics
 
 // Subset of memory statistics from upstream Go.
-// Works with conservative gc only.
+// Only the conservative and precise GCs fill in all fields. The leaking GC
+// never frees memory and never runs a GC cycle.
+// HeapFreeRanges and HeapLargestFree are TinyGo extensions.
 
 // A MemStats records statistics about the memory allocator.
 type MemStats struct {
 	// General statistics.
 
+	// Alloc is bytes of allocated heap objects.
+	//
+	// This is the same as HeapAlloc (see below).
+	Alloc uint64
+
 	// Sys is the total bytes of memory obtained from the OS.
 	//
 	// Sys is the sum of the XSys fields below. Sys measures the
@@ -18,6 +25,12 @@ type MemStats struct {
 
 	// Heap memory statistics.
 
+	// HeapAlloc is bytes of allocated heap objects.
+	//
+	// In TinyGo this is the same as HeapInuse, as the heap is made up of
+	// blocks and every in-use block belongs to an allocated object.
+	HeapAlloc uint64
+
 	// HeapSys is bytes of heap memory, total.
 	//
 	// In TinyGo unlike upstream Go, we make no distinction between
@@ -35,6 +48,9 @@ type MemStats struct {
 	// HeapReleased is bytes of physical memory returned to the OS.
 	HeapReleased uint64
 
+	// HeapObjects is the number of allocated heap objects.
+	HeapObjects uint64
+
 	// TotalAlloc is cumulative bytes allocated for heap objects.
 	//
 	// TotalAlloc increases as heap objects are allocated, but
@@ -57,4 +73,27 @@ type MemStats struct {
 
 	// GCSys is bytes of memory in garbage collection metadata.
 	GCSys uint64
+
+	// Garbage collector statistics.
+
+	// PauseTotalNs is the cumulative nanoseconds spent in GC since the
+	// program started. The world is stopped during the entire collection.
+	PauseTotalNs uint64
+
+	// NumGC is the number of completed GC cycles.
+	NumGC uint32
+
+	// Heap fragmentation statistics. These are specific to TinyGo.
+
+	// HeapFreeRanges is the number of contiguous ranges of free heap memory.
+	// When a lot of memory is free but it is split over many ranges, the heap
+	// is fragmented.
+	HeapFreeRanges uint64
+
+	// HeapLargestFree is the size in bytes of the largest contiguous range of
+	// free heap memory. An allocation that is bigger than this (plus a few
+	// bytes of overhead) only succeeds if the GC frees enough memory next to
+	// it or if the heap can grow, so firmware can check it to free memory or
+	// restart at a safe point before an allocation fails.
+	HeapLargestFree uint64
 }
@@ -0,0 +1,109 @@
+//go:build (linux && !baremetal && !nintendoswitch && !wasi) || freebsd
+
+package runtime
+
+// This file is for hosted operating systems that use ELF binaries.
+
+import "unsafe"
+
+// For the definition of the various header structs, see:
+// https://refspecs.linuxfoundation.org/elf/elf.pdf
+// Also useful:
+// https://en.wikipedia.org/wiki/Executable_and_Linkable_Format
+type elfHeader struct {
+	ident_magic      uint32
+	ident_class      uint8
+	ident_data       uint8
+	ident_version    uint8
+	ident_osabi      uint8
+	ident_abiversion uint8
+	_                [7]byte // reserved
+	filetype         uint16
+	machine          uint16
+	version          uint32
+	entry            uintptr
+	phoff            uintptr
+	shoff            uintptr
+	flags            uint32
+	ehsize           uint16
+	phentsize        uint16
+	phnum            uint16
+	shentsize        uint16
+	shnum            uint16
+	shstrndx         uint16
+}
+
+type elfProgramHeader64 struct {
+	_type  uint32
+	flags  uint32
+	offset uintptr
+	vaddr  uintptr
+	paddr  uintptr
+	filesz uintptr
+	memsz  uintptr
+	align  uintptr
+}
+
+type elfProgramHeader32 struct {
+	_type  uint32
+	offset uintptr
+	vaddr  uintptr
+	paddr  uintptr
+	filesz uintptr
+	memsz  uintptr
+	flags  uint32
+	align  uintptr
+}
+
+// ELF header of the currently running process.
+//
+//go:extern __ehdr_start
+var ehdr_start elfHeader
+
+// markGlobals marks all globals, which are reachable by definition.
+// It parses the ELF program header to find writable segments.
+func markGlobals() {
+	// Relevant constants from the ELF specification.
+	// See: https://refspecs.linuxfoundation.org/elf/elf.pdf
+	const (
+		PT_LOAD = 1
+		PF_W    = 0x2 // program flag: write access
+	)
+
+	// Position independent binaries (like shared libraries) may be loaded at
+	// a different address than the one they were linked at. The first
+	// loadable segment starts with the ELF header, so use it to calculate the
+	// offset that has to be added to all virtual addresses.
+	var loadBias uintptr
+
+	headerPtr := unsafe.Pointer(uintptr(unsafe.Pointer(&ehdr_start)) + ehdr_start.phoff)
+	for i := 0; i < int(ehdr_start.phnum); i++ {
+		// Look for a writable segment and scan its contents.
+		// There is a little bit of duplication here, which is unfortunate. But
+		// the alternative would be to put elfProgramHeader in separate files
+		// which is IMHO a lot uglier. If only the ELF spec was consistent
+		// between 32-bit and 64-bit...
+		if TargetBits == 64 {
+			header := (*elfProgramHeader64)(headerPtr)
+			if header._type == PT_LOAD && header.offset == 0 {
+				loadBias = uintptr(unsafe.Pointer(&ehdr_start)) - header.vaddr
+			}
+			if header._type == PT_LOAD && header.flags&PF_W != 0 {
+				start := header.vaddr + loadBias
+				end := start + header.memsz
+				markRoots(start, end)
+			}
+		} else {
+			header := (*elfProgramHeader32)(headerPtr)
+			if header._type == PT_LOAD && header.offset == 0 {
+				loadBias = uintptr(unsafe.Pointer(&ehdr_start)) - header.vaddr
+			}
+			if header._type == PT_LOAD && header.flags&PF_W != 0 {
+				start := header.vaddr + loadBias
+				end := start + header.memsz
+				markRoots(start, end)
+			}
+		}
+		headerPtr = unsafe.Add(headerPtr, ehdr_start.phentsize)
+	}
+}
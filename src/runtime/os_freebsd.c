@@ -0,0 +1,52 @@
+// Program entry point for FreeBSD. This replaces crt1.o, which is normally
+// provided by the system. The kernel (or the runtime linker, for dynamically
+// linked binaries) passes a pointer to the initial stack contents and a cleanup
+// function that must be registered with atexit.
+//
+// Based on lib/csu/common/crt1_c.c from the FreeBSD source tree.
+
+int main(int argc, char **argv, char **env);
+int atexit(void (*function)(void));
+void exit(int status) __attribute__((noreturn));
+
+// ELF note that marks this binary as a FreeBSD binary. It is normally provided
+// by crtbrand.c. The value is the minimum supported __FreeBSD_version (12.0).
+__attribute__((section(".note.tag"), aligned(4), used))
+static const struct {
+    int namesz;
+    int descsz;
+    int type;
+    char name[8];
+    int desc;
+} abitag = {8, 4, 1, "FreeBSD", 1200000};
+
+// These globals are normally defined in crt1.o and used by libc.
+char **environ;
+const char *__progname = "";
+
+#if defined(__x86_64__)
+// The stack is only 8-byte aligned on entry.
+__attribute__((force_align_arg_pointer))
+#endif
+void _start(char **ap, void (*cleanup)(void)) {
+    int argc = *(long *)(void *)ap;
+    char **argv = ap + 1;
+    environ = ap + 2 + argc;
+
+    if (argc > 0 && argv[0] != 0) {
+        // Set __progname to the base name of the executable.
+        __progname = argv[0];
+        for (const char *s = argv[0]; *s != '\0'; s++) {
+            if (*s == '/') {
+                __progname = s + 1;
+            }
+        }
+    }
+
+    if (cleanup != 0) {
+        // Cleanup function provided by the runtime linker (rtld).
+        atexit(cleanup);
+    }
+
+    exit(main(argc, argv, environ));
+}
@@ -0,0 +1,23 @@
+//go:build freebsd
+
+package runtime
+
+import "C" // dummy import so that os_freebsd.c works
+
+const GOOS = "freebsd"
+
+const (
+	// See https://cgit.freebsd.org/src/tree/sys/sys/mman.h
+	flag_PROT_READ     = 0x1
+	flag_PROT_WRITE    = 0x2
+	flag_MAP_PRIVATE   = 0x2
+	flag_MAP_ANONYMOUS = 0x1000 // MAP_ANON
+)
+
+// Source: https://cgit.freebsd.org/src/tree/sys/sys/_clock_id.h
+// FreeBSD doesn't have CLOCK_MONOTONIC_RAW, but its CLOCK_MONOTONIC isn't
+// affected by adjtime(2) either so it can be used instead.
+const (
+	clock_REALTIME      = 0
+	clock_MONOTONIC_RAW = 4 // CLOCK_MONOTONIC
+)
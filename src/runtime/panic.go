@@ -46,9 +46,14 @@ func _panic(message interface{}) {
 			// unreachable
 		}
 	}
+	if crashReporting {
+		recordPanic(uintptr(returnAddress(0))-callInstSize, message)
+	}
 	printstring("panic: ")
 	printitf(message)
 	printnl()
+	dumpGoroutines()
+	callPanicHandler(uintptr(returnAddress(0))-callInstSize, message, getCurrentStackPointer())
 	abort()
 }
 
@@ -60,6 +65,9 @@ func runtimePanic(msg string) {
 }
 
 func runtimePanicAt(addr unsafe.Pointer, msg string) {
+	if crashReporting {
+		recordRuntimePanic(uintptr(addr)-callInstSize, msg)
+	}
 	if hasReturnAddr {
 		printstring("panic: runtime error at ")
 		printptr(uintptr(addr) - callInstSize)
@@ -68,6 +76,28 @@ func runtimePanicAt(addr unsafe.Pointer, msg string) {
 		printstring("panic: runtime error: ")
 	}
 	println(msg)
+	dumpGoroutines()
+	callRuntimePanicHandler(uintptr(addr)-callInstSize, msg, getCurrentStackPointer())
+	abort()
+}
+
+// stackOverflow is called when a goroutine has overflowed its stack. The stack
+// can't be trusted anymore, so the function the goroutine was started with is
+// printed instead to find out which goroutine it was.
+func stackOverflow(entry uintptr) {
+	if crashReporting {
+		recordRuntimePanic(entry, "goroutine stack overflow")
+	}
+	printstring("panic: runtime error: goroutine stack overflow (goroutine ")
+	if name, _, ok := lineTableFunction(entry); ok {
+		printstring(name)
+	} else {
+		printptr(entry)
+	}
+	printstring(")")
+	printnl()
+	dumpGoroutines()
+	callRuntimePanicHandler(entry, "goroutine stack overflow", 0)
 	abort()
 }
 
@@ -0,0 +1,21 @@
+package runtime
+
+// Support for sync.Pool: pools are cleared by the GC, like in upstream Go.
+
+// poolCleanup is the function set by package sync, or nil.
+var poolCleanup func()
+
+// registerPoolCleanup is called by package sync before the first item is put
+// in a sync.Pool. The cleanup function is then called at the start of every GC
+// cycle, so that pools don't keep memory alive forever.
+func registerPoolCleanup(cleanup func()) {
+	poolCleanup = cleanup
+}
+
+// clearPools is called at the start of a GC cycle, before marking. The cleanup
+// function must not allocate.
+func clearPools() {
+	if poolCleanup != nil {
+		poolCleanup()
+	}
+}
@@ -1,44 +1,568 @@
+// Package pprof writes runtime profiling data in the format expected by the
+// pprof visualization tool.
+//
+// TinyGo supports a subset of the profiles of the Go runtime:
+//
+//   - goroutine: the number of goroutines, without stack traces.
+//   - heap and allocs: sampled heap allocations (see runtime.MemProfileRate),
+//     grouped by the place they were allocated from. The stack only contains
+//     the allocating function, unless the program is built with -linetable.
+//     Frees are not recorded, so all allocations are reported as in use.
+//   - block and mutex: blocking channel operations and waits for a
+//     sync.Mutex or sync.RWMutex (see runtime.SetBlockProfileRate and
+//     runtime.SetMutexProfileFraction), grouped like the heap profile. The
+//     mutex profile contains the goroutine that waited, not the one that
+//     held the lock.
+//   - threadcreate: always empty.
+//
+// CPU profiles only contain the PC that was interrupted by the profiling timer
+// for every sample, not the full stack. They are supported on Linux, macOS and
+// FreeBSD, and on Cortex-M when building with -tags=cpuprofile (which uses the
+// SysTick timer). On other systems, StartCPUProfile returns an error.
+//
+// Profiles only contain addresses, which can be converted to function names
+// and source locations by passing the program binary to the pprof tool.
 package pprof
 
-// TinyGo does not implement pprof. However, a dummy shell is needed for the
-// testing package (and testing/internal/pprof).
-
 import (
+	"bufio"
 	"errors"
+	"fmt"
 	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
 )
 
 var ErrUnimplemented = errors.New("runtime/pprof: unimplemented")
 
-type Profile struct{}
+// A Profile is a collection of stack traces showing the call sequences that
+// led to instances of a particular event, such as allocation.
+type Profile struct {
+	name  string
+	mu    sync.Mutex
+	m     map[interface{}][]uintptr
+	count func() int
+	write func(io.Writer, int) error
+}
 
-func StartCPUProfile(w io.Writer) error {
-	return nil
+var profiles struct {
+	mu sync.Mutex
+	m  map[string]*Profile
 }
 
-func StopCPUProfile() {
+var goroutineProfile = &Profile{
+	name:  "goroutine",
+	count: runtime.NumGoroutine,
+	write: writeGoroutine,
 }
 
-func WriteHeapProfile(w io.Writer) error {
-	return nil
+var threadcreateProfile = &Profile{
+	name:  "threadcreate",
+	count: func() int { return 0 },
+	write: writeEmpty("threadcreate", valueType{"threadcreate", "count"}),
+}
+
+var heapProfile = &Profile{
+	name:  "heap",
+	count: countHeap,
+	write: writeHeap,
+}
+
+var allocsProfile = &Profile{
+	name:  "allocs",
+	count: countHeap,
+	write: writeAlloc,
 }
 
+var blockProfile = &Profile{
+	name:  "block",
+	count: countBlock,
+	write: writeBlock,
+}
+
+var mutexProfile = &Profile{
+	name:  "mutex",
+	count: countMutex,
+	write: writeMutex,
+}
+
+func lockProfiles() {
+	profiles.mu.Lock()
+	if profiles.m == nil {
+		// Initial built-in profiles.
+		profiles.m = map[string]*Profile{
+			"goroutine":    goroutineProfile,
+			"threadcreate": threadcreateProfile,
+			"heap":         heapProfile,
+			"allocs":       allocsProfile,
+			"block":        blockProfile,
+			"mutex":        mutexProfile,
+		}
+	}
+}
+
+func unlockProfiles() {
+	profiles.mu.Unlock()
+}
+
+// NewProfile creates a new profile with the given name. If a profile with that
+// name already exists, NewProfile panics.
+//
+// Stack traces are not available in TinyGo, so the profile only records the
+// number of values that were added.
+func NewProfile(name string) *Profile {
+	lockProfiles()
+	defer unlockProfiles()
+	if name == "" {
+		panic("pprof: NewProfile with empty name")
+	}
+	if profiles.m[name] != nil {
+		panic("pprof: NewProfile name already in use: " + name)
+	}
+	p := &Profile{
+		name: name,
+		m:    map[interface{}][]uintptr{},
+	}
+	profiles.m[name] = p
+	return p
+}
+
+// Lookup returns the profile with the given name, or nil if no such profile
+// exists.
 func Lookup(name string) *Profile {
-	return nil
+	lockProfiles()
+	defer unlockProfiles()
+	return profiles.m[name]
 }
 
+// Profiles returns a slice of all the known profiles, sorted by name.
+func Profiles() []*Profile {
+	lockProfiles()
+	defer unlockProfiles()
+
+	all := make([]*Profile, 0, len(profiles.m))
+	for _, p := range profiles.m {
+		all = append(all, p)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].name < all[j].name })
+	return all
+}
+
+// Name returns this profile's name, which can be passed to Lookup to reobtain
+// the profile.
 func (p *Profile) Name() string {
-	return ""
+	return p.name
 }
 
+// Count returns the number of execution stacks currently in the profile.
 func (p *Profile) Count() int {
-	return 0
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.count != nil {
+		return p.count()
+	}
+	return len(p.m)
 }
 
+// Add adds the current execution stack to the profile, associated with value.
+// Add panics if the profile already contains a stack for value.
+func (p *Profile) Add(value interface{}, skip int) {
+	if p.name == "" {
+		panic("pprof: use of uninitialized Profile")
+	}
+	if p.write != nil {
+		panic("pprof: Add called on built-in Profile " + p.name)
+	}
+
+	stk := make([]uintptr, 32)
+	n := runtime.Callers(skip+1, stk[:])
+	stk = stk[:n]
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.m[value] != nil {
+		panic("pprof: Profile.Add of duplicate value")
+	}
+	p.m[value] = stk
+}
+
+// Remove removes the execution stack associated with value from the profile.
+// It is a no-op if the value is not in the profile.
+func (p *Profile) Remove(value interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.m, value)
+}
+
+// WriteTo writes a pprof-formatted snapshot of the profile to w. If a write to
+// w returns an error, WriteTo returns that error. Otherwise, WriteTo returns
+// nil.
+//
+// The debug parameter enables additional output. Passing debug=0 writes the
+// gzip-compressed protocol buffer described in
+// https://github.com/google/pprof/tree/main/proto#overview. Passing debug=1
+// writes the legacy text format with comments.
 func (p *Profile) WriteTo(w io.Writer, debug int) error {
-	return ErrUnimplemented
+	if p.name == "" {
+		panic("pprof: use of zero Profile")
+	}
+	if p.write != nil {
+		return p.write(w, debug)
+	}
+
+	// Custom profile: group the values by stack.
+	p.mu.Lock()
+	counts := map[string]int64{}
+	stacks := map[string][]uintptr{}
+	for _, stk := range p.m {
+		key := fmt.Sprint(stk)
+		counts[key]++
+		stacks[key] = stk
+	}
+	p.mu.Unlock()
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if debug > 0 {
+		tw := bufio.NewWriter(w)
+		fmt.Fprintf(tw, "%s profile: total %d\n", p.name, len(p.m))
+		for _, key := range keys {
+			fmt.Fprintf(tw, "%d @", counts[key])
+			printStack(tw, stacks[key])
+		}
+		return tw.Flush()
+	}
+	b := newProfileBuilder(valueType{p.name, "count"})
+	b.periodType = valueType{p.name, "count"}
+	b.period = 1
+	for _, key := range keys {
+		b.addSample(stacks[key], counts[key])
+	}
+	return b.write(w)
 }
 
-func Profiles() []*Profile {
+func printStack(w io.Writer, stk []uintptr) {
+	for _, pc := range stk {
+		fmt.Fprintf(w, " %#x", pc)
+	}
+	fmt.Fprintln(w)
+}
+
+// writeEmpty returns a function that writes a profile without any samples.
+func writeEmpty(name string, sampleTypes ...valueType) func(io.Writer, int) error {
+	return func(w io.Writer, debug int) error {
+		if debug > 0 {
+			_, err := fmt.Fprintf(w, "%s profile: total 0\n", name)
+			return err
+		}
+		return newProfileBuilder(sampleTypes...).write(w)
+	}
+}
+
+func writeGoroutine(w io.Writer, debug int) error {
+	n := runtime.NumGoroutine()
+	if debug > 0 {
+		_, err := fmt.Fprintf(w, "goroutine profile: total %d\n", n)
+		return err
+	}
+	b := newProfileBuilder(valueType{"goroutine", "count"})
+	b.periodType = valueType{"goroutine", "count"}
+	b.period = 1
+	b.addSample(nil, int64(n))
+	return b.write(w)
+}
+
+// readMemProfile returns the records of runtime.MemProfile.
+func readMemProfile() []runtime.MemProfileRecord {
+	var p []runtime.MemProfileRecord
+	n, ok := runtime.MemProfile(nil, true)
+	for {
+		// Allocate room for a slightly bigger profile, in case a few more
+		// entries have been added since the call to MemProfile.
+		p = make([]runtime.MemProfileRecord, n+50)
+		n, ok = runtime.MemProfile(p, true)
+		if ok {
+			return p[:n]
+		}
+	}
+}
+
+func countHeap() int {
+	n, _ := runtime.MemProfile(nil, true)
+	return n
+}
+
+func writeHeap(w io.Writer, debug int) error {
+	return writeHeapInternal(w, debug, "")
+}
+
+func writeAlloc(w io.Writer, debug int) error {
+	return writeHeapInternal(w, debug, "alloc_space")
+}
+
+func writeHeapInternal(w io.Writer, debug int, defaultSampleType string) error {
+	records := readMemProfile()
+	sort.Slice(records, func(i, j int) bool { return records[i].InUseBytes() > records[j].InUseBytes() })
+
+	if debug > 0 {
+		// Legacy text format, as written by the Go runtime.
+		var total runtime.MemProfileRecord
+		for i := range records {
+			r := &records[i]
+			total.AllocBytes += r.AllocBytes
+			total.AllocObjects += r.AllocObjects
+			total.FreeBytes += r.FreeBytes
+			total.FreeObjects += r.FreeObjects
+		}
+		tw := bufio.NewWriter(w)
+		fmt.Fprintf(tw, "heap profile: %d: %d [%d: %d] @ heap/%d\n",
+			total.InUseObjects(), total.InUseBytes(),
+			total.AllocObjects, total.AllocBytes,
+			2*runtime.MemProfileRate)
+		for i := range records {
+			r := &records[i]
+			fmt.Fprintf(tw, "%d: %d [%d: %d] @",
+				r.InUseObjects(), r.InUseBytes(),
+				r.AllocObjects, r.AllocBytes)
+			printStack(tw, r.Stack())
+		}
+		return tw.Flush()
+	}
+
+	b := newProfileBuilder(
+		valueType{"alloc_objects", "count"},
+		valueType{"alloc_space", "bytes"},
+		valueType{"inuse_objects", "count"},
+		valueType{"inuse_space", "bytes"},
+	)
+	rate := int64(runtime.MemProfileRate)
+	b.defaultSampleType = defaultSampleType
+	b.periodType = valueType{"space", "bytes"}
+	b.period = rate
+	for i := range records {
+		r := &records[i]
+		allocObjects, allocBytes := scaleHeapSample(r.AllocObjects, r.AllocBytes, rate)
+		inUseObjects, inUseBytes := scaleHeapSample(r.InUseObjects(), r.InUseBytes(), rate)
+		b.addSample(r.Stack(), allocObjects, allocBytes, inUseObjects, inUseBytes)
+	}
+	return b.write(w)
+}
+
+// scaleHeapSample estimates the number of allocations and bytes that the
+// sampled allocations represent. The runtime samples one allocation per rate
+// bytes, so an allocation of size bytes is sampled with a probability of
+// size/rate (or always, if it is bigger than rate).
+func scaleHeapSample(count, size, rate int64) (int64, int64) {
+	if count == 0 || size == 0 || rate <= 1 {
+		return count, size
+	}
+	avgSize := size / count
+	if avgSize >= rate {
+		return count, size
+	}
+	return count * rate / avgSize, size * rate / avgSize
+}
+
+// readBlockProfile returns the records of runtime.BlockProfile or
+// runtime.MutexProfile.
+func readBlockProfile(profile func([]runtime.BlockProfileRecord) (int, bool)) []runtime.BlockProfileRecord {
+	var p []runtime.BlockProfileRecord
+	n, ok := profile(nil)
+	for {
+		p = make([]runtime.BlockProfileRecord, n+50)
+		n, ok = profile(p)
+		if ok {
+			return p[:n]
+		}
+	}
+}
+
+func countBlock() int {
+	n, _ := runtime.BlockProfile(nil)
+	return n
+}
+
+func countMutex() int {
+	n, _ := runtime.MutexProfile(nil)
+	return n
+}
+
+func writeBlock(w io.Writer, debug int) error {
+	return writeProfileInternal(w, debug, "contention", 0, runtime.BlockProfile)
+}
+
+func writeMutex(w io.Writer, debug int) error {
+	return writeProfileInternal(w, debug, "mutex", int64(runtime.SetMutexProfileFraction(-1)), runtime.MutexProfile)
+}
+
+// writeProfileInternal writes the block or mutex profile. The delays in the
+// records are in nanoseconds, so they don't need to be converted from CPU
+// ticks like in upstream Go.
+func writeProfileInternal(w io.Writer, debug int, name string, period int64, profile func([]runtime.BlockProfileRecord) (int, bool)) error {
+	records := readBlockProfile(profile)
+	sort.Slice(records, func(i, j int) bool { return records[i].Cycles > records[j].Cycles })
+
+	if debug > 0 {
+		// Legacy text format, as written by the Go runtime.
+		tw := bufio.NewWriter(w)
+		fmt.Fprintf(tw, "--- %s:\n", name)
+		fmt.Fprintf(tw, "cycles/second=%v\n", int64(time.Second))
+		if name == "mutex" {
+			fmt.Fprintf(tw, "sampling period=%d\n", period)
+		}
+		for i := range records {
+			r := &records[i]
+			fmt.Fprintf(tw, "%v %v @", r.Cycles, r.Count)
+			printStack(tw, r.Stack())
+		}
+		return tw.Flush()
+	}
+
+	b := newProfileBuilder(valueType{"contentions", "count"}, valueType{"delay", "nanoseconds"})
+	b.periodType = valueType{"contentions", "count"}
+	b.period = 1
+	if period > 0 {
+		b.period = period
+	}
+	for i := range records {
+		r := &records[i]
+		count, delay := r.Count, r.Cycles
+		if period > 1 {
+			// Only one in period events was sampled.
+			count, delay = count*period, delay*period
+		}
+		b.addSample(r.Stack(), count, delay)
+	}
+	return b.write(w)
+}
+
+// WriteHeapProfile is shorthand for Lookup("heap").WriteTo(w, 0).
+func WriteHeapProfile(w io.Writer) error {
+	return writeHeap(w, 0)
+}
+
+// The rate at which CPU profiles are sampled, in samples per second.
+const cpuProfileRate = 100
+
+var cpu struct {
+	sync.Mutex
+	profiling bool
+	w         io.Writer
+	start     time.Time
+	counts    map[uintptr]int64 // number of samples for each PC
+	profile   int               // incremented for every profile
+}
+
+func readProfile(buf []uintptr) (n int, lost uint32, running bool) // implemented in package runtime
+
+func hasScheduler() bool // implemented in package runtime
+
+// StartCPUProfile enables CPU profiling for the current process.
+// While profiling, the profile will be buffered and written to w.
+// StartCPUProfile returns an error if profiling is already enabled.
+//
+// The samples are taken by a timer interrupt and only contain the interrupted
+// PC, not the full stack. CPU profiling is supported on Linux, macOS and
+// FreeBSD, and on Cortex-M when building with -tags=cpuprofile. On other
+// systems StartCPUProfile returns an error.
+func StartCPUProfile(w io.Writer) error {
+	cpu.Lock()
+	defer cpu.Unlock()
+	if cpu.profiling {
+		return errors.New("cpu profiling already in use")
+	}
+	runtime.SetCPUProfileRate(cpuProfileRate)
+	if _, _, running := readProfile(nil); !running {
+		return errors.New("cpu profiling is not supported on this system")
+	}
+	cpu.profiling = true
+	cpu.w = w
+	cpu.start = time.Now()
+	cpu.counts = make(map[uintptr]int64)
+	cpu.profile++
+	if hasScheduler() {
+		// Read the samples in the background, as the runtime can only
+		// buffer a limited number of them.
+		go readCPUProfile(cpu.profile)
+	}
 	return nil
 }
+
+// readCPUProfile periodically moves the samples buffered by the runtime into
+// cpu.counts, until the given profile is stopped.
+func readCPUProfile(profile int) {
+	for {
+		time.Sleep(100 * time.Millisecond)
+		cpu.Lock()
+		if !cpu.profiling || cpu.profile != profile {
+			cpu.Unlock()
+			return
+		}
+		readCPUSamples()
+		cpu.Unlock()
+	}
+}
+
+// readCPUSamples moves all samples buffered by the runtime into cpu.counts
+// and returns the number of samples the runtime had to drop. It must be called
+// with cpu locked.
+func readCPUSamples() uint32 {
+	var buf [64]uintptr
+	for {
+		n, lost, _ := readProfile(buf[:])
+		for _, pc := range buf[:n] {
+			cpu.counts[pc]++
+		}
+		if n < len(buf) {
+			return lost
+		}
+	}
+}
+
+// StopCPUProfile stops the current CPU profile, if any.
+// StopCPUProfile only returns after all the writes for the
+// profile have completed.
+func StopCPUProfile() {
+	cpu.Lock()
+	defer cpu.Unlock()
+	if !cpu.profiling {
+		return
+	}
+	cpu.profiling = false
+	runtime.SetCPUProfileRate(0)
+	lost := readCPUSamples()
+
+	pcs := make([]uintptr, 0, len(cpu.counts))
+	for pc := range cpu.counts {
+		pcs = append(pcs, pc)
+	}
+	sort.Slice(pcs, func(i, j int) bool { return cpu.counts[pcs[i]] > cpu.counts[pcs[j]] })
+
+	const period = int64(time.Second / cpuProfileRate)
+	b := newProfileBuilder(valueType{"samples", "count"}, valueType{"cpu", "nanoseconds"})
+	b.periodType = valueType{"cpu", "nanoseconds"}
+	b.period = period
+	b.duration = time.Since(cpu.start)
+	for _, pc := range pcs {
+		count := cpu.counts[pc]
+		b.addSample([]uintptr{pc}, count, count*period)
+	}
+	if lost != 0 {
+		// Like upstream Go, count the samples the runtime had to drop in a
+		// pseudo-function, so that the loss is visible in the profile.
+		b.addFunctionSample("runtime/pprof.lostProfileEvent", int64(lost), int64(lost)*period)
+	}
+	err := b.write(cpu.w)
+	cpu.w = nil
+	cpu.counts = nil
+	if err != nil {
+		// StopCPUProfile has no way to return the error, so this panics like
+		// the upstream profile writer does.
+		panic("runtime/pprof: converting profile: " + err.Error())
+	}
+}
@@ -0,0 +1,261 @@
+package pprof
+
+// This file writes profiles in the profile.proto format used by the pprof
+// tool, see https://github.com/google/pprof/blob/main/proto/profile.proto.
+// Only the parts that TinyGo can fill in are written: there is no symbol
+// information in a TinyGo binary, so locations only contain an address. The
+// pprof tool can symbolize them using the ELF file of the program. The only
+// exception are pseudo-functions like lostProfileEvent, which have a name but
+// no address.
+
+import (
+	"compress/gzip"
+	"io"
+	"time"
+)
+
+// profileBuilder collects the samples of a profile and writes it.
+type profileBuilder struct {
+	sampleTypes       []valueType
+	defaultSampleType string
+	periodType        valueType
+	period            int64
+	duration          time.Duration
+	samples           []profileSample
+
+	strings     []string
+	stringIndex map[string]int64
+	locations   []profileLocation
+	locationIDs map[uintptr]uint64
+	functions   []string
+	functionIDs map[string]uint64 // location ID of each function
+}
+
+type valueType struct {
+	typ, unit string
+}
+
+// profileLocation is either an address or (if function is non-zero) a
+// pseudo-function without an address.
+type profileLocation struct {
+	address  uintptr
+	function uint64
+}
+
+type profileSample struct {
+	locations []uint64
+	values    []int64
+}
+
+func newProfileBuilder(sampleTypes ...valueType) *profileBuilder {
+	return &profileBuilder{
+		sampleTypes: sampleTypes,
+		strings:     []string{""}, // the first string must be the empty string
+		stringIndex: map[string]int64{"": 0},
+		locationIDs: map[uintptr]uint64{},
+		functionIDs: map[string]uint64{},
+	}
+}
+
+// addSample adds a sample with the given stack (return addresses, innermost
+// first) and values, one for each sample type.
+func (b *profileBuilder) addSample(stack []uintptr, values ...int64) {
+	locations := make([]uint64, len(stack))
+	for i, pc := range stack {
+		id, ok := b.locationIDs[pc]
+		if !ok {
+			b.locations = append(b.locations, profileLocation{address: pc})
+			id = uint64(len(b.locations))
+			b.locationIDs[pc] = id
+		}
+		locations[i] = id
+	}
+	b.samples = append(b.samples, profileSample{locations: locations, values: values})
+}
+
+// addFunctionSample adds a sample with a single location in the named
+// function, which doesn't need to exist in the program.
+func (b *profileBuilder) addFunctionSample(name string, values ...int64) {
+	id, ok := b.functionIDs[name]
+	if !ok {
+		b.functions = append(b.functions, name)
+		b.locations = append(b.locations, profileLocation{function: uint64(len(b.functions))})
+		id = uint64(len(b.locations))
+		b.functionIDs[name] = id
+	}
+	b.samples = append(b.samples, profileSample{locations: []uint64{id}, values: values})
+}
+
+func (b *profileBuilder) stringID(s string) int64 {
+	id, ok := b.stringIndex[s]
+	if !ok {
+		id = int64(len(b.strings))
+		b.strings = append(b.strings, s)
+		b.stringIndex[s] = id
+	}
+	return id
+}
+
+// Field numbers of the Profile message and its submessages.
+const (
+	tagProfileSampleType        = 1
+	tagProfileSample            = 2
+	tagProfileMapping           = 3
+	tagProfileLocation          = 4
+	tagProfileFunction          = 5
+	tagProfileStringTable       = 6
+	tagProfileTimeNanos         = 9
+	tagProfileDurationNanos     = 10
+	tagProfilePeriodType        = 11
+	tagProfilePeriod            = 12
+	tagProfileDefaultSampleType = 14
+
+	tagValueTypeType = 1
+	tagValueTypeUnit = 2
+
+	tagSampleLocation = 1
+	tagSampleValue    = 2
+
+	tagMappingID     = 1
+	tagMappingStart  = 2
+	tagMappingLimit  = 3
+	tagMappingOffset = 4
+
+	tagLocationID        = 1
+	tagLocationMappingID = 2
+	tagLocationAddress   = 3
+	tagLocationLine      = 4
+
+	tagLineFunctionID = 1
+
+	tagFunctionID         = 1
+	tagFunctionName       = 2
+	tagFunctionSystemName = 3
+)
+
+// write writes the profile as a gzip-compressed protobuf.
+func (b *profileBuilder) write(w io.Writer) error {
+	var pb protobuf
+	for _, typ := range b.sampleTypes {
+		b.writeValueType(&pb, tagProfileSampleType, typ)
+	}
+	for _, sample := range b.samples {
+		pb.message(tagProfileSample, func(pb *protobuf) {
+			pb.uint64s(tagSampleLocation, sample.locations)
+			pb.int64s(tagSampleValue, sample.values)
+		})
+	}
+	if len(b.locations) != 0 {
+		// A single mapping that covers the whole address space, so that the
+		// addresses can be looked up in the program binary.
+		pb.message(tagProfileMapping, func(pb *protobuf) {
+			pb.uint64(tagMappingID, 1)
+			pb.uint64(tagMappingStart, 0)
+			pb.uint64(tagMappingLimit, uint64(^uintptr(0)))
+			pb.uint64(tagMappingOffset, 0)
+		})
+	}
+	for i, loc := range b.locations {
+		pb.message(tagProfileLocation, func(pb *protobuf) {
+			pb.uint64(tagLocationID, uint64(i+1))
+			if loc.function != 0 {
+				pb.message(tagLocationLine, func(pb *protobuf) {
+					pb.uint64(tagLineFunctionID, loc.function)
+				})
+				return
+			}
+			pb.uint64(tagLocationMappingID, 1)
+			pb.uint64(tagLocationAddress, uint64(loc.address))
+		})
+	}
+	for i, name := range b.functions {
+		pb.message(tagProfileFunction, func(pb *protobuf) {
+			pb.uint64(tagFunctionID, uint64(i+1))
+			pb.int64(tagFunctionName, b.stringID(name))
+			pb.int64(tagFunctionSystemName, b.stringID(name))
+		})
+	}
+	pb.int64(tagProfileTimeNanos, time.Now().UnixNano())
+	pb.int64(tagProfileDurationNanos, int64(b.duration))
+	if b.periodType.typ != "" {
+		b.writeValueType(&pb, tagProfilePeriodType, b.periodType)
+		pb.int64(tagProfilePeriod, b.period)
+	}
+	if b.defaultSampleType != "" {
+		pb.int64(tagProfileDefaultSampleType, b.stringID(b.defaultSampleType))
+	}
+	// The string table must be written last, after all strings are known.
+	for _, s := range b.strings {
+		pb.string(tagProfileStringTable, s)
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(pb.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func (b *profileBuilder) writeValueType(pb *protobuf, tag int, typ valueType) {
+	pb.message(tag, func(pb *protobuf) {
+		pb.int64(tagValueTypeType, b.stringID(typ.typ))
+		pb.int64(tagValueTypeUnit, b.stringID(typ.unit))
+	})
+}
+
+// protobuf is a minimal protocol buffer encoder.
+type protobuf struct {
+	data []byte
+}
+
+func (pb *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		pb.data = append(pb.data, byte(x)|0x80)
+		x >>= 7
+	}
+	pb.data = append(pb.data, byte(x))
+}
+
+func (pb *protobuf) length(tag int, n int) {
+	pb.varint(uint64(tag)<<3 | 2)
+	pb.varint(uint64(n))
+}
+
+func (pb *protobuf) uint64(tag int, x uint64) {
+	pb.varint(uint64(tag) << 3)
+	pb.varint(x)
+}
+
+func (pb *protobuf) int64(tag int, x int64) {
+	pb.uint64(tag, uint64(x))
+}
+
+func (pb *protobuf) uint64s(tag int, xs []uint64) {
+	var packed protobuf
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	pb.length(tag, len(packed.data))
+	pb.data = append(pb.data, packed.data...)
+}
+
+func (pb *protobuf) int64s(tag int, xs []int64) {
+	var packed protobuf
+	for _, x := range xs {
+		packed.varint(uint64(x))
+	}
+	pb.length(tag, len(packed.data))
+	pb.data = append(pb.data, packed.data...)
+}
+
+func (pb *protobuf) string(tag int, s string) {
+	pb.length(tag, len(s))
+	pb.data = append(pb.data, s...)
+}
+
+func (pb *protobuf) message(tag int, f func(pb *protobuf)) {
+	var msg protobuf
+	f(&msg)
+	pb.length(tag, len(msg.data))
+	pb.data = append(pb.data, msg.data...)
+}
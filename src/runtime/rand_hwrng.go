@@ -0,0 +1,19 @@
+//go:build nrf || (stm32 && !(stm32f103 || stm32l0x1)) || (sam && atsamd51) || (sam && atsame5x) || rp2040
+
+package runtime
+
+import "machine"
+
+// hardwareRand returns a random number from the random number generator of
+// the chip.
+func hardwareRand() (n uint64, ok bool) {
+	hi, err := machine.GetRNG()
+	if err != nil {
+		return 0, false
+	}
+	lo, err := machine.GetRNG()
+	if err != nil {
+		return 0, false
+	}
+	return uint64(hi)<<32 | uint64(lo), true
+}
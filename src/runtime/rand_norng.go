@@ -0,0 +1,9 @@
+//go:build !(darwin || freebsd || (linux && !baremetal && !wasi) || windows || tinygo.wasm || nrf || (stm32 && !(stm32f103 || stm32l0x1)) || (sam && atsamd51) || (sam && atsame5x) || rp2040) || nintendoswitch
+
+package runtime
+
+// hardwareRand is not implemented on this system. The runtime keeps using a
+// fixed seed.
+func hardwareRand() (n uint64, ok bool) {
+	return 0, false
+}
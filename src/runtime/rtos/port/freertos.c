@@ -0,0 +1,90 @@
+// FreeRTOS port of the TinyGo RTOS interface, see tinygo_rtos.h.
+//
+// Example use, with the heap in a static buffer and the linker symbols of a
+// typical GCC linker script for the globals range:
+//
+//     static uint8_t go_heap[32 * 1024] __attribute__((aligned(8)));
+//     extern char __data_start__[], __bss_end__[];
+//
+//     static void go_task(void *arg) {
+//         tinygo_rtos_main(go_heap, sizeof(go_heap), __data_start__, __bss_end__);
+//         vTaskDelete(NULL);
+//     }
+//
+//     xTaskCreate(go_task, "go", 1024, NULL, tskIDLE_PRIORITY + 1, NULL);
+
+#include "FreeRTOS.h"
+#include "task.h"
+#include "queue.h"
+#include "semphr.h"
+#include <stdio.h>
+#include "tinygo_rtos.h"
+
+static TaskHandle_t tinygo_task;
+
+uint64_t tinygo_rtos_now_us(void) {
+    // The tick count is only 32 bits wide (usually), so keep track of
+    // overflows to get a monotonic 64-bit timestamp. This is only called from
+    // the Go task so doesn't need locking.
+    static TickType_t last;
+    static uint64_t high;
+    TickType_t now = xTaskGetTickCount();
+    if (now < last) {
+        high += (uint64_t)(TickType_t)-1 + 1;
+    }
+    last = now;
+    return (high + now) * portTICK_PERIOD_MS * 1000;
+}
+
+void tinygo_rtos_wait(uint64_t timeout_us) {
+    tinygo_task = xTaskGetCurrentTaskHandle();
+    TickType_t ticks = portMAX_DELAY;
+    if (timeout_us != UINT64_MAX) {
+        // Round up, so that the Go task doesn't wake up too early (and wait
+        // again).
+        uint64_t us_per_tick = (uint64_t)portTICK_PERIOD_MS * 1000;
+        uint64_t n = (timeout_us + us_per_tick - 1) / us_per_tick;
+        ticks = n < portMAX_DELAY ? (TickType_t)n : portMAX_DELAY - 1;
+    }
+    ulTaskNotifyTake(pdTRUE, ticks);
+}
+
+void tinygo_rtos_notify(void) {
+    if (tinygo_task == NULL) {
+        return;
+    }
+    if (xPortIsInsideInterrupt()) {
+        BaseType_t woken = pdFALSE;
+        vTaskNotifyGiveFromISR(tinygo_task, &woken);
+        portYIELD_FROM_ISR(woken);
+    } else {
+        xTaskNotifyGive(tinygo_task);
+    }
+}
+
+void tinygo_rtos_putchar(char c) {
+    putchar(c);
+}
+
+void tinygo_rtos_exit(int code) {
+    (void)code;
+    vTaskDelete(NULL);
+    for (;;) {
+    }
+}
+
+int tinygo_rtos_queue_send(void *queue, const void *item) {
+    return xQueueSendToBack((QueueHandle_t)queue, item, 0) == pdPASS;
+}
+
+int tinygo_rtos_queue_receive(void *queue, void *item) {
+    return xQueueReceive((QueueHandle_t)queue, item, 0) == pdPASS;
+}
+
+int tinygo_rtos_sem_take(void *sem) {
+    return xSemaphoreTake((SemaphoreHandle_t)sem, 0) == pdPASS;
+}
+
+int tinygo_rtos_sem_give(void *sem) {
+    return xSemaphoreGive((SemaphoreHandle_t)sem) == pdPASS;
+}
@@ -0,0 +1,58 @@
+// Interface between TinyGo and RTOS firmware, for programs built with
+// -buildmode=c-archive for a generic Cortex-M target (like -target=cortex-m4).
+//
+// The firmware links the resulting archive and compiles one of the port
+// implementations in this directory (freertos.c or zephyr.c), or its own
+// implementation of the functions below.
+
+#pragma once
+
+#include <stddef.h>
+#include <stdint.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// Implemented by TinyGo.
+
+// Run the Go program in the current RTOS task. It returns when the main
+// function of the Go program returns. All goroutines run inside this task.
+//
+// The heap is used for all Go allocations, including goroutine stacks. The
+// globals range is scanned by the garbage collector and must contain all Go
+// global variables: usually it is the start of .data to the end of .bss.
+// Only pointers stored in the heap, the globals range or on the stack of this
+// task keep Go objects alive.
+void tinygo_rtos_main(void *heap, size_t heap_size, void *globals_start, void *globals_end);
+
+// Implemented by the port.
+
+// Return a monotonic timestamp in microseconds.
+uint64_t tinygo_rtos_now_us(void);
+
+// Block the Go task until tinygo_rtos_notify is called or the timeout (in
+// microseconds) expires. A timeout of UINT64_MAX means to wait forever.
+void tinygo_rtos_wait(uint64_t timeout_us);
+
+// Wake up the Go task if it is blocked in tinygo_rtos_wait. This may be called
+// from other tasks and from interrupts, for example after sending to a queue
+// that is read by Go code.
+void tinygo_rtos_notify(void);
+
+// Write a character to the console (used by print, println and os.Stdout).
+void tinygo_rtos_putchar(char c);
+
+// Stop the Go task, after os.Exit or a panic. This function must not return.
+void tinygo_rtos_exit(int code);
+
+// Non-blocking queue and semaphore operations. They return non-zero on
+// success.
+int tinygo_rtos_queue_send(void *queue, const void *item);
+int tinygo_rtos_queue_receive(void *queue, void *item);
+int tinygo_rtos_sem_take(void *sem);
+int tinygo_rtos_sem_give(void *sem);
+
+#ifdef __cplusplus
+}
+#endif
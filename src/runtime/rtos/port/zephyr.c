@@ -0,0 +1,66 @@
+// Zephyr port of the TinyGo RTOS interface, see tinygo_rtos.h.
+//
+// Queues are message queues (struct k_msgq) and semaphores are struct k_sem.
+//
+// Example use, with the heap in a static buffer and the Zephyr linker symbols
+// (from <zephyr/linker/linker-defs.h>) for the globals range:
+//
+//     static uint8_t go_heap[32 * 1024] __aligned(8);
+//
+//     static void go_task(void *p1, void *p2, void *p3) {
+//         tinygo_rtos_main(go_heap, sizeof(go_heap), __data_region_start, __bss_end);
+//     }
+//
+//     K_THREAD_DEFINE(go_tid, 4096, go_task, NULL, NULL, NULL, 5, 0, 0);
+
+#include <zephyr/kernel.h>
+#include <zephyr/sys/printk.h>
+#include "tinygo_rtos.h"
+
+// Given by tinygo_rtos_notify and taken by tinygo_rtos_wait.
+static K_SEM_DEFINE(tinygo_notify_sem, 0, 1);
+
+uint64_t tinygo_rtos_now_us(void) {
+    return k_ticks_to_us_floor64(k_uptime_ticks());
+}
+
+void tinygo_rtos_wait(uint64_t timeout_us) {
+    k_timeout_t timeout = K_FOREVER;
+    if (timeout_us != UINT64_MAX) {
+        timeout = K_USEC(timeout_us);
+    }
+    k_sem_take(&tinygo_notify_sem, timeout);
+}
+
+void tinygo_rtos_notify(void) {
+    // k_sem_give may be called from interrupts.
+    k_sem_give(&tinygo_notify_sem);
+}
+
+void tinygo_rtos_putchar(char c) {
+    printk("%c", c);
+}
+
+void tinygo_rtos_exit(int code) {
+    ARG_UNUSED(code);
+    k_thread_abort(k_current_get());
+    CODE_UNREACHABLE;
+}
+
+int tinygo_rtos_queue_send(void *queue, const void *item) {
+    return k_msgq_put((struct k_msgq *)queue, item, K_NO_WAIT) == 0;
+}
+
+int tinygo_rtos_queue_receive(void *queue, void *item) {
+    return k_msgq_get((struct k_msgq *)queue, item, K_NO_WAIT) == 0;
+}
+
+int tinygo_rtos_sem_take(void *sem) {
+    return k_sem_take((struct k_sem *)sem, K_NO_WAIT) == 0;
+}
+
+int tinygo_rtos_sem_give(void *sem) {
+    // k_sem_give can't fail: it saturates at the semaphore limit.
+    k_sem_give((struct k_sem *)sem);
+    return 1;
+}
@@ -0,0 +1,108 @@
+//go:build tinygo.rtos
+
+// Package rtos provides access to the RTOS that the program runs under. This is
+// the case for programs built with -buildmode=c-archive for a generic Cortex-M
+// target (like -target=cortex-m4), which are linked into existing C firmware
+// and started from an RTOS task by calling tinygo_rtos_main.
+//
+// Queues and semaphores are created in C using the native RTOS API and passed
+// to Go as opaque handles. Operations on them never block the RTOS task that
+// runs the Go code, as that would block all goroutines. Instead, a goroutine
+// that has to wait polls the queue or semaphore and sleeps in between, so that
+// other goroutines (and other RTOS tasks) can run in the meantime.
+//
+// The RTOS specific parts are implemented in C by a port, see the port
+// directory for the API and the implementations for FreeRTOS and Zephyr.
+package rtos
+
+import (
+	"time"
+	"unsafe"
+)
+
+// pollInterval is how long a goroutine sleeps before trying a queue or
+// semaphore operation again.
+const pollInterval = time.Millisecond
+
+//export tinygo_rtos_queue_send
+func queueSend(queue, item unsafe.Pointer) int32
+
+//export tinygo_rtos_queue_receive
+func queueReceive(queue, item unsafe.Pointer) int32
+
+//export tinygo_rtos_sem_take
+func semTake(sem unsafe.Pointer) int32
+
+//export tinygo_rtos_sem_give
+func semGive(sem unsafe.Pointer) int32
+
+// Queue is a message queue of the RTOS, for communication with C code running
+// in other RTOS tasks or in interrupts.
+type Queue struct {
+	handle unsafe.Pointer
+}
+
+// NewQueue returns a Queue for the given RTOS queue handle, for example a
+// FreeRTOS QueueHandle_t or a Zephyr struct k_msgq pointer.
+func NewQueue(handle unsafe.Pointer) Queue {
+	return Queue{handle}
+}
+
+// Send copies the item to the back of the queue, waiting until there is space
+// in the queue. The item must point to a value of the item size that the queue
+// was created with.
+func (q Queue) Send(item unsafe.Pointer) {
+	for !q.TrySend(item) {
+		time.Sleep(pollInterval)
+	}
+}
+
+// TrySend copies the item to the back of the queue if there is space in the
+// queue, and reports whether it did.
+func (q Queue) TrySend(item unsafe.Pointer) bool {
+	return queueSend(q.handle, item) != 0
+}
+
+// Receive copies the item at the front of the queue to the memory that item
+// points to and removes it from the queue, waiting until there is an item in
+// the queue.
+func (q Queue) Receive(item unsafe.Pointer) {
+	for !q.TryReceive(item) {
+		time.Sleep(pollInterval)
+	}
+}
+
+// TryReceive receives an item from the queue if it is not empty, and reports
+// whether it did.
+func (q Queue) TryReceive(item unsafe.Pointer) bool {
+	return queueReceive(q.handle, item) != 0
+}
+
+// Semaphore is a counting (or binary) semaphore of the RTOS.
+type Semaphore struct {
+	handle unsafe.Pointer
+}
+
+// NewSemaphore returns a Semaphore for the given RTOS semaphore handle, for
+// example a FreeRTOS SemaphoreHandle_t or a Zephyr struct k_sem pointer.
+func NewSemaphore(handle unsafe.Pointer) Semaphore {
+	return Semaphore{handle}
+}
+
+// Take takes the semaphore, waiting until it is available.
+func (s Semaphore) Take() {
+	for !s.TryTake() {
+		time.Sleep(pollInterval)
+	}
+}
+
+// TryTake takes the semaphore if it is available, and reports whether it did.
+func (s Semaphore) TryTake() bool {
+	return semTake(s.handle) != 0
+}
+
+// Give gives (releases) the semaphore. It returns false if the semaphore could
+// not be given, for example because a binary semaphore was already given.
+func (s Semaphore) Give() bool {
+	return semGive(s.handle) != 0
+}
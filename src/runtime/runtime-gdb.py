@@ -0,0 +1,130 @@
+# GDB extension for TinyGo programs, loaded by `tinygo gdb`. It can also be
+# loaded manually with:
+#
+#     source /path/to/tinygo/src/runtime/runtime-gdb.py
+#
+# It adds the following commands, similar to those of the Go runtime-gdb.py:
+#
+#     info goroutines      list all goroutines with their current location
+#     goroutine <n> <cmd>  run a GDB command (like bt) on goroutine n
+#
+# This only works with -scheduler=tasks (the default on most microcontrollers),
+# where each goroutine has its own stack. Paused goroutines store their
+# callee-saved registers at the top of their stack, see calleeSavedRegs in
+# src/internal/task. To inspect a paused goroutine, the registers of the CPU are
+# temporarily replaced with the saved registers of that goroutine.
+
+import gdb
+
+
+def _lookup(name):
+    try:
+        return gdb.parse_and_eval("'%s'" % name)
+    except gdb.error:
+        raise gdb.GdbError(
+            "%s not found: goroutines are only available with -scheduler=tasks" % name)
+
+
+def _goroutines():
+    """Return a list of (index, task pointer) of all goroutines, oldest first."""
+    tasks = []
+    t = _lookup("internal/task.allTasks")
+    while int(t) != 0:
+        tasks.append(t)
+        t = t["state"]["allNext"]
+    tasks.reverse()
+    return list(enumerate(tasks, 1))
+
+
+def _saved_registers(task):
+    """Return the registers of a paused goroutine, as (name, value) pairs."""
+    sp = int(task["state"]["sp"])
+    regs_type = gdb.lookup_type("internal/task.calleeSavedRegs")
+    regs = gdb.Value(sp).cast(regs_type.pointer()).dereference()
+    result = []
+    for field in regs_type.fields():
+        name = field.name
+        if name in ("pc", "ra"):
+            # Return address of the stack switch, which is where the goroutine
+            # continues when it is resumed.
+            name = "pc"
+        if field.type.strip_typedefs().code != gdb.TYPE_CODE_INT:
+            # Padding or vector registers.
+            continue
+        try:
+            reg = gdb.parse_and_eval("$" + name)
+        except gdb.error:
+            continue
+        if reg.type.strip_typedefs().code not in (gdb.TYPE_CODE_INT, gdb.TYPE_CODE_PTR):
+            # Only general purpose registers are restored (for example, d8 on
+            # arm64 is a vector register in GDB).
+            continue
+        result.append((name, int(regs[field.name])))
+    # The stack pointer after the registers have been popped off the stack.
+    result.append(("sp", sp + regs_type.sizeof))
+    return result
+
+
+def _location(pc):
+    block = gdb.block_for_pc(pc)
+    while block is not None and block.function is None:
+        block = block.superblock
+    name = block.function.print_name if block is not None else "??"
+    sal = gdb.find_pc_line(pc)
+    if sal.symtab is not None:
+        return "%s at %s:%d" % (name, sal.symtab.filename, sal.line)
+    return name
+
+
+class InfoGoroutines(gdb.Command):
+    """List all goroutines. The running goroutine is marked with a '*'."""
+
+    def __init__(self):
+        gdb.Command.__init__(self, "info goroutines", gdb.COMMAND_STACK, gdb.COMPLETE_NONE)
+
+    def invoke(self, arg, from_tty):
+        current = int(_lookup("internal/task.currentTask"))
+        for index, task in _goroutines():
+            if int(task) == current:
+                pc = int(gdb.parse_and_eval("$pc"))
+                print("* %-3d %s running %s" % (index, task, _location(pc)))
+            else:
+                pc = dict(_saved_registers(task))["pc"]
+                print("  %-3d %s waiting %s" % (index, task, _location(pc)))
+
+
+class GoroutineCmd(gdb.Command):
+    """Run a GDB command on a goroutine, for example: goroutine 2 bt
+
+The goroutine number is the one shown by info goroutines."""
+
+    def __init__(self):
+        gdb.Command.__init__(self, "goroutine", gdb.COMMAND_STACK, gdb.COMPLETE_NONE)
+
+    def invoke(self, arg, from_tty):
+        index, _, cmd = arg.strip().partition(" ")
+        if not index.isdigit() or not cmd:
+            raise gdb.GdbError("usage: goroutine <n> <command>")
+        tasks = dict(_goroutines())
+        task = tasks.get(int(index))
+        if task is None:
+            raise gdb.GdbError("no goroutine %s" % index)
+        if int(task) == int(_lookup("internal/task.currentTask")):
+            gdb.execute(cmd, from_tty)
+            return
+
+        # Swap in the saved registers of the goroutine, run the command, and
+        # restore the registers of the CPU.
+        saved = _saved_registers(task)
+        original = [(name, int(gdb.parse_and_eval("$" + name))) for name, _ in saved]
+        try:
+            for name, value in saved:
+                gdb.execute("set $%s = %d" % (name, value), to_string=True)
+            gdb.execute(cmd, from_tty)
+        finally:
+            for name, value in original:
+                gdb.execute("set $%s = %d" % (name, value), to_string=True)
+
+
+InfoGoroutines()
+GoroutineCmd()
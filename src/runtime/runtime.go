@@ -1,6 +1,7 @@
 package runtime
 
 import (
+	"runtime/interrupt"
 	"unsafe"
 )
 
@@ -17,7 +18,7 @@ func callMain()
 
 func GOMAXPROCS(n int) int {
 	// Note: setting GOMAXPROCS is ignored.
-	return 1
+	return numCPU
 }
 
 func GOROOT() string {
@@ -77,19 +78,30 @@ func os_sigpipe() {
 	runtimePanic("too many writes on closed pipe")
 }
 
-// LockOSThread wires the calling goroutine to its current operating system thread.
-// Stub for now
-// Called by go1.18 standard library on windows, see https://github.com/golang/go/issues/49320
-func LockOSThread() {
+// InInterrupt returns whether the caller is running inside an interrupt
+// handler. Interrupt handlers must not block or allocate heap memory, so
+// drivers can use this to check that they are not called from one.
+func InInterrupt() bool {
+	return interrupt.In()
 }
 
-// UnlockOSThread undoes an earlier call to LockOSThread.
-// Stub for now
-func UnlockOSThread() {
-}
-
-// KeepAlive makes sure the value in the interface is alive until at least the
-// point of the call.
+// KeepAlive marks its argument as currently reachable. This ensures that the
+// object is not freed, and its finalizer (see SetFinalizer) is not run, before
+// the point in the program where KeepAlive is called. It also acts as a
+// compiler barrier: all memory writes before the call are done before the
+// call, and no memory reads after the call are moved before it.
+//
+// This is needed when memory is used in a way the compiler can't see, for
+// example when a buffer is handed to a DMA peripheral or a C function that
+// keeps using it after the call returns:
+//
+//	buf := make([]byte, 64)
+//	startDMA(unsafe.Pointer(&buf[0]), len(buf))
+//	waitDMA()
+//	runtime.KeepAlive(buf) // buf must not be freed while DMA is in progress
+//
+// Calls to KeepAlive are implemented directly by the compiler, and are never
+// removed by the optimizer.
 func KeepAlive(x interface{})
 
 var godebugUpdate func(string, string)
@@ -75,7 +75,7 @@ func nanosecondsToTicks(ns int64) timeUnit {
 	return timeUnit(ns)
 }
 
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	// TODO
 	return 0
 }
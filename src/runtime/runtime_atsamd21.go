@@ -283,8 +283,8 @@ func sleepTicks(d timeUnit) {
 	}
 }
 
-// ticks returns the elapsed time since reset.
-func ticks() timeUnit {
+// hardwareTicks returns the elapsed time since reset.
+func hardwareTicks() timeUnit {
 	// For some ways of capturing the time atomically, see this thread:
 	// https://www.eevblog.com/forum/microcontrollers/correct-timing-by-timer-overflow-count/msg749617/#msg749617
 	// Here, instead of re-reading the counter register if an overflow has been
@@ -59,7 +59,7 @@ func preinit() {
 func initHardware() {
 	initUART()
 	initMonotonicTimer()
-	nextTimerRecalibrate = ticks() + timerRecalibrateInterval
+	nextTimerRecalibrate = hardwareTicks() + timerRecalibrateInterval
 
 	// Enable interrupts after initialization.
 	avr.Asm("sei")
@@ -75,11 +75,11 @@ func nanosecondsToTicks(ns int64) timeUnit {
 
 // Sleep this number of ticks of nanoseconds.
 func sleepTicks(d timeUnit) {
-	waitTill := ticks() + d
+	waitTill := hardwareTicks() + d
 	for {
 		// wait for interrupt
 		avr.Asm("sleep")
-		if waitTill <= ticks() {
+		if waitTill <= hardwareTicks() {
 			// done waiting
 			return
 		}
@@ -90,7 +90,7 @@ func sleepTicks(d timeUnit) {
 	}
 }
 
-func ticks() (ticksReturn timeUnit) {
+func hardwareTicks() (ticksReturn timeUnit) {
 	state := interrupt.Disable()
 	// use volatile since ticksCount can be changed when running on multi-core boards.
 	ticksReturn = timeUnit(volatile.LoadUint64((*uint64)(unsafe.Pointer(&ticksCount))))
@@ -71,7 +71,7 @@ func nanosecondsToTicks(ns int64) timeUnit {
 
 // Sleep for the given number of timer ticks.
 func sleepTicks(d timeUnit) {
-	ticksStart := ticks()
+	ticksStart := hardwareTicks()
 	sleepUntil := ticksStart + d
 
 	// Sleep until we're in the right 2-second interval.
@@ -80,7 +80,7 @@ func sleepTicks(d timeUnit) {
 		overflows := rtcOverflows.Get()
 		if overflows >= uint32(sleepUntil>>16) {
 			// We're in the right 2-second interval.
-			// At this point we know that the difference between ticks() and
+			// At this point we know that the difference between hardwareTicks() and
 			// sleepUntil is ≤0xffff.
 			avr.Asm("sei")
 			break
@@ -137,7 +137,7 @@ var rtcOverflows volatile.Register32
 var cmpMatch volatile.Register8
 
 // Return the number of RTC ticks that happened since reset.
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	var ovf uint32
 	var count uint16
 	for {
@@ -37,6 +37,9 @@ func preinit() {
 		dst = unsafe.Add(dst, 4)
 		src = unsafe.Add(src, 4)
 	}
+
+	// Make nil pointer dereferences fault, if supported by the chip.
+	initNilGuard()
 }
 
 // The stack layout at the moment an interrupt occurs.
@@ -52,3 +55,35 @@ type interruptStack struct {
 	PC  uintptr
 	PSR uintptr
 }
+
+// print prints the stacked registers, two lines of four registers each. The
+// line with the pc is recognized by the monitor (tinygo monitor, or tinygo
+// flash -monitor), which prints the function and source location of the fault.
+func (sp *interruptStack) print() {
+	print(" ")
+	printFaultRegister("r0", sp.R0)
+	printFaultRegister("r1", sp.R1)
+	printFaultRegister("r2", sp.R2)
+	printFaultRegister("r3", sp.R3)
+	println()
+	print(" ")
+	printFaultRegister("r12", sp.R12)
+	printFaultRegister("lr", sp.LR)
+	printFaultRegister("pc", sp.PC)
+	printFaultRegister("psr", sp.PSR)
+	println()
+}
+
+// printFaultRegister prints a single register value as " name=0x12345678".
+// Unlike printptr, it always prints all digits so that registers line up.
+func printFaultRegister(name string, value uintptr) {
+	print(" ", name, "=0x")
+	for shift := int(unsafe.Sizeof(value))*8 - 4; shift >= 0; shift -= 4 {
+		nibble := byte(value>>shift) & 0xf
+		if nibble < 10 {
+			putchar(nibble + '0')
+		} else {
+			putchar(nibble - 10 + 'a')
+		}
+	}
+}
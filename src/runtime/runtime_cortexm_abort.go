@@ -1,4 +1,4 @@
-//go:build cortexm && !nxp && !qemu
+//go:build cortexm && !nxp && !qemu && !tinygo.rtos
 
 package runtime
 
@@ -11,6 +11,11 @@ func exit(code int) {
 }
 
 func abort() {
+	if crashReporting {
+		// Reset the chip, so that the next run can read the crash report.
+		arm.SystemReset()
+	}
+
 	// lock up forever
 	for {
 		arm.Asm("wfi")
@@ -19,22 +19,32 @@ import (
 //
 //export handleHardFault
 func handleHardFault(sp *interruptStack) {
+	var pc, lr, stackPointer uintptr
+	if uintptr(unsafe.Pointer(&sp.PC)) >= 0x20000000 {
+		pc, lr, stackPointer = sp.PC, sp.LR, uintptr(unsafe.Pointer(sp))
+	}
+	if crashReporting {
+		recordFault(pc, lr, 0, 0, stackPointer)
+	}
+
 	print("fatal error: ")
 	if uintptr(unsafe.Pointer(sp)) < 0x20000000 {
 		print("stack overflow")
 	} else {
-		// TODO: try to find the cause of the hard fault. Especially on
-		// Cortex-M3 and higher it is possible to find more detailed information
-		// in special status registers.
+		// Cortex-M0 has no fault status registers, so the cause of the fault
+		// is unknown.
 		print("HardFault")
 	}
-	print(" with sp=", sp)
+	println()
 	if uintptr(unsafe.Pointer(&sp.PC)) >= 0x20000000 {
-		// Only print the PC if it points into memory.
-		// It may not point into memory during a stack overflow, so check that
-		// first before accessing the stack.
-		print(" pc=", sp.PC)
+		// Only print the stacked registers if they are in memory.
+		// They may not be during a stack overflow, so check that first before
+		// accessing the stack.
+		sp.print()
 	}
+	print(" ")
+	printFaultRegister("sp", uintptr(unsafe.Pointer(sp)))
 	println()
+	callFaultHandler(pc, lr, 0, 0, stackPointer)
 	abort()
 }
@@ -18,12 +18,66 @@ const (
 
 // See runtime_cortexm_hardfault.go
 //
+// MemManage, BusFault and UsageFault exceptions use the same handler (see
+// cortexm.S), so this function also reports those faults. They are disabled by
+// default, in which case they're escalated to a HardFault.
+//
 //go:export handleHardFault
 func handleHardFault(sp *interruptStack) {
 	fault := GetFaultStatus()
+	hardFault := GetHardFaultStatus()
 	spValid := !fault.Bus().ImpreciseDataBusError()
 
+	if addr, ok := fault.Mem().Address(); spValid {
+		// Overflow into the stack guard of a goroutine, see
+		// runtime_cortexm_stackguard.go.
+		stackGuardFault(addr, ok && fault.Mem().DataAccessViolation(), fault.Mem().WileStackingException(), uintptr(unsafe.Pointer(sp)))
+	}
+
+	if addr, ok := fault.Mem().Address(); ok && addr < nilGuardSize && fault.Mem().DataAccessViolation() && spValid && uintptr(unsafe.Pointer(&sp.PC)) >= 0x20000000 {
+		// Access to the region at address 0 protected by the MPU, see
+		// runtime_cortexm_nilguard.go. Report it like the nil checks
+		// inserted by the compiler, so that the monitor can find the source
+		// location. The stacked PC points to the faulting instruction.
+		if crashReporting {
+			recordRuntimePanic(sp.PC, "nil pointer dereference")
+		}
+		printstring("panic: runtime error at ")
+		printptr(sp.PC)
+		printstring(": nil pointer dereference")
+		printnl()
+		callRuntimePanicHandler(sp.PC, "nil pointer dereference", uintptr(unsafe.Pointer(sp)))
+		abort()
+	}
+
+	// Registers and address of the fault, for the crash report and handler.
+	var pc, lr, stackPointer uintptr
+	if spValid && uintptr(unsafe.Pointer(&sp.PC)) >= 0x20000000 {
+		pc, lr, stackPointer = sp.PC, sp.LR, uintptr(unsafe.Pointer(sp))
+	}
+	faultAddr, ok := fault.Mem().Address()
+	if !ok {
+		faultAddr, _ = fault.Bus().Address()
+	}
+	if crashReporting {
+		recordFault(pc, lr, uint32(fault), faultAddr, stackPointer)
+	}
+
 	print("fatal error: ")
+	switch arm.AsmFull("mrs {}, IPSR", nil) & 0x1ff {
+	case 4:
+		print("MemManage: ")
+	case 5:
+		print("BusFault: ")
+	case 6:
+		print("UsageFault: ")
+	default:
+		print("HardFault")
+		if hardFault.Forced() {
+			print(" (escalated)")
+		}
+		print(": ")
+	}
 	if spValid && uintptr(unsafe.Pointer(sp)) < 0x20000000 {
 		print("stack overflow? ")
 	}
@@ -82,7 +136,14 @@ func handleHardFault(sp *interruptStack) {
 	}
 
 	if fault.Unknown() {
-		print("unknown hard fault")
+		switch {
+		case hardFault.VectorTableRead():
+			print("bus error on vector table read")
+		case hardFault.DebugEvent():
+			print("debug event")
+		default:
+			print("unknown hard fault")
+		}
 	}
 
 	if addr, ok := fault.Mem().Address(); ok {
@@ -92,19 +153,60 @@ func handleHardFault(sp *interruptStack) {
 	if addr, ok := fault.Bus().Address(); ok {
 		print(" with bus fault address ", addr)
 	}
-	if spValid {
-		print(" with sp=", sp)
-		if uintptr(unsafe.Pointer(&sp.PC)) >= 0x20000000 {
-			// Only print the PC if it points into memory.
-			// It may not point into memory during a stack overflow, so check that
-			// first before accessing the stack.
-			print(" pc=", sp.PC)
-		}
+	println()
+
+	// Print the fault status registers, for when the decoded cause above isn't
+	// enough.
+	print(" ")
+	printFaultRegister("cfsr", uintptr(fault))
+	printFaultRegister("hfsr", uintptr(hardFault))
+	printFaultRegister("mmfar", uintptr(arm.SCB.MMFAR.Get()))
+	printFaultRegister("bfar", uintptr(arm.SCB.BFAR.Get()))
+	println()
+
+	if spValid && uintptr(unsafe.Pointer(&sp.PC)) >= 0x20000000 {
+		// Only print the stacked registers if they are in memory.
+		// They may not be during a stack overflow, so check that first before
+		// accessing the stack.
+		sp.print()
 	}
+	print(" ")
+	printFaultRegister("sp", uintptr(unsafe.Pointer(sp)))
 	println()
+	callFaultHandler(pc, lr, uint32(fault), faultAddr, stackPointer)
 	abort()
 }
 
+// GetHardFaultStatus reads the System Control Block HardFault Status Register
+// and returns it as a HardFaultStatus.
+func GetHardFaultStatus() HardFaultStatus {
+	return HardFaultStatus(arm.SCB.HFSR.Get())
+}
+
+type HardFaultStatus uint32
+
+// Forced: the HardFault was escalated from a configurable fault (MemManage,
+// BusFault or UsageFault) because that fault is disabled or can't be handled
+// at the current priority. The cause can be found in the FaultStatus.
+func (fs HardFaultStatus) Forced() bool {
+	return fs&arm.SCB_HFSR_FORCED != 0
+}
+
+// VectorTableRead: a BusFault occurred on a vector table read during exception
+// processing
+//
+// "When this bit is set to 1, the PC value stacked for the exception return
+// points to the instruction that was preempted by the exception."
+func (fs HardFaultStatus) VectorTableRead() bool {
+	return fs&arm.SCB_HFSR_VECTTBL != 0
+}
+
+// DebugEvent: a debug event (such as a breakpoint instruction) occurred while
+// no debugger was attached
+func (fs HardFaultStatus) DebugEvent() bool {
+	return fs&arm.SCB_HFSR_DEBUGEVT != 0
+}
+
 // Descriptions are sourced from the K66 SVD and
 // http://infocenter.arm.com/help/index.jsp?topic=/com.arm.doc.dui0552a/Cihcfefj.html
 
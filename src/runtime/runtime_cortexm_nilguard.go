@@ -0,0 +1,84 @@
+//go:build cortexm && !mimxrt1062 && !nxpmk66f18 && !softdevice && !tinygo.rtos
+
+package runtime
+
+// On Cortex-M, address 0 is usually flash (with the vector table) that can be
+// read like any other memory. So reading through a nil pointer that isn't
+// checked by the compiler (for example one converted from an unsafe.Pointer)
+// silently returns garbage. To catch these, the MPU is used to make the start of
+// the address space inaccessible, so that such a read causes a fault that is
+// reported as a nil pointer dereference.
+//
+// This is only done on ARMv7-M (Cortex-M3/M4/M7). ARMv6-M chips don't report
+// the fault address so the fault can't be recognized as a nil dereference, and
+// ARMv8-M has a different MPU. Chips whose runtime configures the MPU itself or
+// shares it with other firmware are excluded using build tags.
+
+import (
+	"device/arm"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// Largest region that is protected.
+const nilGuardMaxSize = 1024
+
+// Size of the protected region starting at address 0, or 0 if there is none.
+var nilGuardSize uintptr
+
+//go:extern _evectors
+var _evectors [0]byte
+
+func initNilGuard() {
+	switch (arm.SCB.CPUID.Get() & arm.SCB_CPUID_PARTNO_Msk) >> arm.SCB_CPUID_PARTNO_Pos {
+	case 0xc23, 0xc24, 0xc27: // Cortex-M3, Cortex-M4, Cortex-M7
+	default:
+		return
+	}
+	regions := (arm.MPU.TYPE.Get() & arm.MPU_TYPE_DREGION_Msk) >> arm.MPU_TYPE_DREGION_Pos
+	if regions == 0 || arm.MPU.CTRL.Get()&arm.MPU_CTRL_ENABLE != 0 {
+		// There is no MPU, or it's already in use (for example by a
+		// bootloader).
+		return
+	}
+
+	// Instruction fetches aren't allowed in the protected region, so it must
+	// not contain any code. That includes code after our vector table (if it
+	// is at address 0), and the handlers of a bootloader vector table at
+	// address 0 that forwards interrupts to ours.
+	limit := uintptr(unsafe.Pointer(&_evectors))
+	// Entry 0 is the initial stack pointer, which is skipped. This also avoids
+	// a load from a nil pointer, which LLVM would consider undefined behavior.
+	for addr := uintptr(4); addr < 16*4; addr += 4 {
+		handler := uintptr(volatile.LoadUint32((*uint32)(unsafe.Pointer(addr)))) &^ 1
+		if handler != 0 && handler < limit {
+			limit = handler
+		}
+	}
+
+	// MPU regions must be a power of two in size, at least 32 bytes, and
+	// aligned to their size.
+	size := uintptr(nilGuardMaxSize)
+	sizeField := uint32(9) // size is 2^(sizeField+1)
+	for size > limit {
+		size >>= 1
+		sizeField--
+	}
+	if size < 32 {
+		return
+	}
+
+	// Use the highest numbered region, which has the highest priority.
+	region := regions - 1
+	arm.MPU.RBAR.Set(arm.MPU_RBAR_VALID | region) // base address 0
+	arm.MPU.RASR.Set(arm.MPU_RASR_XN | arm.MPU_RASR_AP_NONE<<arm.MPU_RASR_AP_Pos |
+		sizeField<<arm.MPU_RASR_SIZE_Pos | arm.MPU_RASR_ENABLE)
+
+	// Use the default memory map for everything else. The MPU is disabled
+	// while handling a HardFault, so the fault handler can still read the
+	// initial stack pointer from address 0.
+	arm.MPU.CTRL.Set(arm.MPU_CTRL_PRIVDEFENA | arm.MPU_CTRL_ENABLE)
+	arm.Asm("dsb")
+	arm.Asm("isb")
+	nilGuardSize = size
+}
@@ -0,0 +1,11 @@
+//go:build cortexm && (mimxrt1062 || nxpmk66f18 || softdevice || tinygo.rtos)
+
+package runtime
+
+// There is no MPU protection of address 0 on these chips, see
+// runtime_cortexm_nilguard.go.
+
+const nilGuardSize = 0
+
+func initNilGuard() {
+}
@@ -7,6 +7,7 @@ package runtime
 
 import (
 	"device/arm"
+	"internal/semihosting"
 	"runtime/volatile"
 	"unsafe"
 )
@@ -37,7 +38,7 @@ func sleepTicks(d timeUnit) {
 	timestamp += d
 }
 
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	return timestamp
 }
 
@@ -67,12 +68,8 @@ func abort() {
 }
 
 func exit(code int) {
-	// Exit QEMU.
-	if code == 0 {
-		arm.SemihostingCall(arm.SemihostingReportException, arm.SemihostingApplicationExit)
-	} else {
-		arm.SemihostingCall(arm.SemihostingReportException, arm.SemihostingRunTimeErrorUnknown)
-	}
+	// Exit QEMU, passing the exit code to the host.
+	semihosting.Exit(code)
 
 	// Lock up forever (should be unreachable).
 	for {
@@ -0,0 +1,106 @@
+//go:build cortexm && tinygo.rtos
+
+package runtime
+
+// This file implements the runtime for generic Cortex-M targets built with
+// -buildmode=c-archive, where the Go code runs as a task in existing RTOS
+// firmware (FreeRTOS, Zephyr, etc) instead of owning the whole chip.
+//
+// The firmware calls tinygo_rtos_main from an RTOS task. All goroutines are
+// run by the TinyGo scheduler inside that task, on stacks allocated from the
+// heap that is passed in by the firmware. When the scheduler has nothing to do,
+// it blocks the RTOS task so that other tasks can run.
+//
+// Everything that depends on the RTOS is implemented by a small port layer in
+// C, see src/runtime/rtos/port for the API and implementations.
+
+import (
+	"unsafe"
+)
+
+type timeUnit int64
+
+var (
+	heapStart    uintptr
+	heapEnd      uintptr
+	globalsStart uintptr
+	globalsEnd   uintptr
+	stackTop     uintptr
+)
+
+//export tinygo_rtos_now_us
+func rtos_now_us() uint64
+
+//export tinygo_rtos_wait
+func rtos_wait(timeout uint64)
+
+//export tinygo_rtos_putchar
+func rtos_putchar(c byte)
+
+//export tinygo_rtos_exit
+func rtos_exit(code int32)
+
+// Run the Go program inside the current RTOS task. The heap is used for all Go
+// allocations (including goroutine stacks) and must not be used by C code.
+// The globals range is scanned by the garbage collector. It must include all
+// Go global variables, which are in the .data and .bss sections of the
+// firmware, so it is usually set to the start of .data and the end of .bss.
+//
+// This function returns when the main function returns.
+//
+//export tinygo_rtos_main
+func rtosMain(heap unsafe.Pointer, heapSize uintptr, globalsStartPtr, globalsEndPtr unsafe.Pointer) {
+	heapStart = align(uintptr(heap))
+	heapEnd = (uintptr(heap) + heapSize) &^ 7
+	globalsStart = uintptr(globalsStartPtr)
+	globalsEnd = uintptr(globalsEndPtr)
+	stackTop = getCurrentStackPointer()
+	run()
+}
+
+func ticksToNanoseconds(ticks timeUnit) int64 {
+	return int64(ticks) * 1000
+}
+
+func nanosecondsToTicks(ns int64) timeUnit {
+	return timeUnit(ns / 1000)
+}
+
+func hardwareTicks() timeUnit {
+	return timeUnit(rtos_now_us())
+}
+
+func sleepTicks(d timeUnit) {
+	if d <= 0 {
+		return
+	}
+	// This may return early when the RTOS task is notified, which is fine: the
+	// scheduler will check the time again.
+	rtos_wait(uint64(d))
+}
+
+func waitForEvents() {
+	// Block until another task or an interrupt calls tinygo_rtos_notify.
+	rtos_wait(^uint64(0))
+}
+
+func putchar(c byte) {
+	rtos_putchar(c)
+}
+
+func getchar() byte {
+	// Input is not supported: the firmware owns the UART.
+	return 0
+}
+
+func buffered() int {
+	return 0
+}
+
+func exit(code int) {
+	rtos_exit(int32(code))
+}
+
+func abort() {
+	exit(2)
+}
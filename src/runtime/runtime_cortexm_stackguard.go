@@ -0,0 +1,117 @@
+//go:build cortexm && scheduler.tasks && !mimxrt1062 && !nxpmk66f18 && !softdevice && !tinygo.rtos
+
+package runtime
+
+// Goroutine stacks are checked for overflow using a canary at the bottom of
+// the stack, but that only detects an overflow after the fact (and only if the
+// overflow happened to overwrite the canary). When the MPU is used to protect
+// address 0 (see runtime_cortexm_nilguard.go), a second MPU region can be used
+// to make a small area right above the canary inaccessible while the goroutine
+// runs, so that a stack overflow causes a fault right away.
+//
+// The GC scans goroutine stacks as regular heap objects, which would also
+// cause a fault. Therefore the guard is disabled while the GC runs.
+
+import (
+	"device/arm"
+	"internal/task"
+	"unsafe"
+)
+
+// Size of the inaccessible area. This is the smallest MPU region.
+const stackGuardSize = 32
+
+var (
+	stackGuardEnabled bool
+	stackGuardStart   uintptr // start of the current guard region, or 0 if there is none
+)
+
+// EnableStackGuard makes the runtime use the MPU to detect goroutine stack
+// overflows as soon as they happen, instead of only when the goroutine is
+// paused. It returns false if this isn't supported on this chip.
+//
+// This costs a few instructions for every goroutine switch, and up to 32 bytes
+// of every goroutine stack.
+func EnableStackGuard() bool {
+	regions := (arm.MPU.TYPE.Get() & arm.MPU_TYPE_DREGION_Msk) >> arm.MPU_TYPE_DREGION_Pos
+	if nilGuardSize == 0 || regions < 2 {
+		// The MPU isn't available or isn't managed by the runtime.
+		return false
+	}
+	stackGuardEnabled = true
+	return true
+}
+
+// stackGuardRegion returns the MPU region used for the stack guard, just below
+// the one used for the nil guard.
+func stackGuardRegion() uint32 {
+	return (arm.MPU.TYPE.Get()&arm.MPU_TYPE_DREGION_Msk)>>arm.MPU_TYPE_DREGION_Pos - 2
+}
+
+// stackGuardSet is called by the scheduler right before t is resumed, to
+// protect the bottom of its stack. It is called with nil when t is paused.
+func stackGuardSet(t *task.Task) {
+	if !stackGuardEnabled {
+		return
+	}
+	stackGuardStart = 0
+	if t != nil {
+		// MPU regions must be aligned to their size.
+		stackGuardStart = (t.StackGuard() + stackGuardSize - 1) &^ (stackGuardSize - 1)
+	}
+	stackGuardApply()
+}
+
+// stackGuardSuspend disables the stack guard until stackGuardRestore is
+// called. It returns whether the guard was active.
+func stackGuardSuspend() bool {
+	if stackGuardStart == 0 {
+		return false
+	}
+	arm.MPU.RBAR.Set(arm.MPU_RBAR_VALID | stackGuardRegion())
+	arm.MPU.RASR.Set(0)
+	arm.Asm("dsb")
+	arm.Asm("isb")
+	return true
+}
+
+// stackGuardRestore enables the stack guard again after stackGuardSuspend.
+func stackGuardRestore(active bool) {
+	if active {
+		stackGuardApply()
+	}
+}
+
+func stackGuardApply() {
+	region := stackGuardRegion()
+	if stackGuardStart == 0 {
+		arm.MPU.RBAR.Set(arm.MPU_RBAR_VALID | region)
+		arm.MPU.RASR.Set(0)
+	} else {
+		const sizeField = 4 // size is 2^(sizeField+1)
+		arm.MPU.RBAR.Set(uint32(stackGuardStart) | arm.MPU_RBAR_VALID | region)
+		arm.MPU.RASR.Set(arm.MPU_RASR_XN | arm.MPU_RASR_AP_NONE<<arm.MPU_RASR_AP_Pos |
+			sizeField<<arm.MPU_RASR_SIZE_Pos | arm.MPU_RASR_ENABLE)
+	}
+	arm.Asm("dsb")
+	arm.Asm("isb")
+}
+
+// stackGuardFault is called from the fault handler. If the fault was caused by
+// the current goroutine overflowing into the stack guard, it reports a stack
+// overflow and doesn't return. That is the case if the fault was a data access
+// in the guard region (addr), or if an exception couldn't be stacked because
+// the stack pointer (sp) was in the guard region.
+func stackGuardFault(addr uintptr, addrValid, stacking bool, sp uintptr) {
+	if stackGuardStart == 0 {
+		return
+	}
+	// When stacking fails, sp has already been decremented for the exception
+	// frame.
+	frameSize := unsafe.Sizeof(interruptStack{})
+	end := stackGuardStart + stackGuardSize
+	if (addrValid && addr >= stackGuardStart && addr < end) ||
+		(stacking && sp+frameSize > stackGuardStart && sp < end) {
+		stackOverflow(task.Current().Entry())
+	}
+}
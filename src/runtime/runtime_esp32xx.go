@@ -53,7 +53,7 @@ func initTimer() {
 	esp.TIMG0.T0LOAD.Set(0) // value doesn't matter.
 }
 
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	// First, update the LO and HI register pair by writing any value to the
 	// register. This allows reading the pair atomically.
 	esp.TIMG0.T0UPDATE.Set(0)
@@ -75,8 +75,8 @@ func ticksToNanoseconds(ticks timeUnit) int64 {
 
 // sleepTicks busy-waits until the given number of ticks have passed.
 func sleepTicks(d timeUnit) {
-	sleepUntil := ticks() + d
-	for ticks() < sleepUntil {
+	sleepUntil := hardwareTicks() + d
+	for hardwareTicks() < sleepUntil {
 		// TODO: suspend the CPU to not burn power here unnecessarily.
 	}
 }
@@ -80,7 +80,7 @@ func preinit() {
 	}
 }
 
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	// Get the counter value of the timer. It is 22 bits and starts with all
 	// ones (0x3fffff). To make it easier to work with, let it count upwards.
 	count := 0x3fffff - esp.TIMER.FRC1_COUNT.Get()
@@ -94,7 +94,7 @@ func ticks() timeUnit {
 		newTime += 0x400000
 	}
 
-	// Update the timestamp for the next call to ticks().
+	// Update the timestamp for the next call to hardwareTicks().
 	currentTime = newTime
 
 	return currentTime
@@ -112,8 +112,8 @@ func nanosecondsToTicks(ns int64) timeUnit {
 
 // sleepTicks busy-waits until the given number of ticks have passed.
 func sleepTicks(d timeUnit) {
-	sleepUntil := ticks() + d
-	for ticks() < sleepUntil {
+	sleepUntil := hardwareTicks() + d
+	for hardwareTicks() < sleepUntil {
 	}
 }
 
@@ -122,7 +122,7 @@ func buffered() int {
 
 var timerWakeup volatile.Register8
 
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	// Combining the low bits and the high bits yields a time span of over 270
 	// years without counter rollover.
 	highBits := sifive.CLINT.MTIMEH.Get()
@@ -140,7 +140,7 @@ func ticks() timeUnit {
 }
 
 func sleepTicks(d timeUnit) {
-	target := uint64(ticks() + d)
+	target := uint64(hardwareTicks() + d)
 	sifive.CLINT.MTIMECMPH.Set(uint32(target >> 32))
 	sifive.CLINT.MTIMECMP.Set(uint32(target))
 	riscv.MIE.SetBits(1 << 7) // MTIE
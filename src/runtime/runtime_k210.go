@@ -134,7 +134,7 @@ func buffered() int {
 
 var timerWakeup volatile.Register8
 
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	highBits := uint32(kendryte.CLINT.MTIME.Get() >> 32)
 	for {
 		lowBits := uint32(kendryte.CLINT.MTIME.Get() & 0xffffffff)
@@ -147,7 +147,7 @@ func ticks() timeUnit {
 }
 
 func sleepTicks(d timeUnit) {
-	target := uint64(ticks() + d)
+	target := uint64(hardwareTicks() + d)
 	kendryte.CLINT.MTIMECMP[0].Set(target)
 	riscv.MIE.SetBits(1 << 7) // MTIE
 	for {
@@ -0,0 +1,11 @@
+//go:build tinygo.library
+
+// Initialize the Go runtime when the library is loaded, before the host
+// program (or JNI_OnLoad) can call any exported function.
+
+void tinygo_initializeLibrary(void);
+
+__attribute__((constructor))
+static void tinygo_libraryConstructor(void) {
+    tinygo_initializeLibrary();
+}
@@ -0,0 +1,15 @@
+//go:build tinygo.library
+
+package runtime
+
+//export pthread_self
+func pthread_self() uintptr
+
+//export pthread_get_stackaddr_np
+func pthread_get_stackaddr_np(thread uintptr) uintptr
+
+// Return the top of the stack of the current thread.
+func threadStackTop() uintptr {
+	// On Darwin, the "stack address" is the highest address of the stack.
+	return pthread_get_stackaddr_np(pthread_self())
+}
@@ -0,0 +1,29 @@
+//go:build !baremetal && !nintendoswitch && !wasi && tinygo.library
+
+package runtime
+
+//export pthread_self
+func pthread_self() uintptr
+
+//export pthread_getattr_np
+func pthread_getattr_np(thread uintptr, attr *pthreadAttr) int32
+
+//export pthread_attr_getstack
+func pthread_attr_getstack(attr *pthreadAttr, stackaddr *uintptr, stacksize *uintptr) int32
+
+//export pthread_attr_destroy
+func pthread_attr_destroy(attr *pthreadAttr) int32
+
+// Opaque pthread_attr_t, large enough for all supported libcs (56 bytes on
+// 64-bit Android, 64 bytes on 64-bit glibc).
+type pthreadAttr [8]uint64
+
+// Return the top of the stack of the current thread.
+func threadStackTop() uintptr {
+	var attr pthreadAttr
+	var stackaddr, stacksize uintptr
+	pthread_getattr_np(pthread_self(), &attr)
+	pthread_attr_getstack(&attr, &stackaddr, &stacksize)
+	pthread_attr_destroy(&attr)
+	return stackaddr + stacksize
+}
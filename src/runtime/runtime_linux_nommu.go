@@ -0,0 +1,7 @@
+//go:build linux && nommu
+
+package runtime
+
+// Without a MMU, mmap allocates (physically contiguous) memory right away, so
+// only reserve as much as a small Linux system can spare for the heap.
+const heapMaxReservation = 1 * 1024 * 1024 // 1MB for the entire heap
@@ -99,20 +99,18 @@ func tick() {
 	cycleCount.Set(DWT_CYCCNT.Get())
 }
 
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	mask := arm.DisableInterrupts()
 	tick := tickCount.Get()
 	cycs := cycleCount.Get()
 	curr := DWT_CYCCNT.Get()
 	arm.EnableInterrupts(mask)
-	var diff uint32
-	if curr < cycs { // cycle counter overflow/rollover occurred
-		diff = (0xFFFFFFFF - cycs) + curr
-	} else {
-		diff = curr - cycs
-	}
-	frac := uint64(diff*0xFFFFFFFF/cyclesPerMicro) >> 32
+	// Unsigned subtraction also works when the cycle counter wrapped around.
+	diff := curr - cycs
+	frac := uint64(diff / cyclesPerMicro)
 	if frac > 1000 {
+		// The SysTick interrupt is pending, but hasn't incremented tickCount
+		// yet.
 		frac = 1000
 	}
 	return timeUnit(1000*tick + frac)
@@ -120,7 +118,7 @@ func ticks() timeUnit {
 
 func sleepTicks(duration timeUnit) {
 	if duration >= 0 {
-		curr := ticks()
+		curr := hardwareTicks()
 		last := curr + duration // 64-bit overflow unlikely
 		for curr < last {
 			cycles := timeUnit((last - curr) / pitCyclesPerMicro)
@@ -130,7 +128,7 @@ func sleepTicks(duration timeUnit) {
 			if !timerSleep(uint32(cycles)) {
 				return // return early due to interrupt
 			}
-			curr = ticks()
+			curr = hardwareTicks()
 		}
 	}
 }
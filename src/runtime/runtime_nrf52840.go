@@ -83,7 +83,9 @@ func buffered() int {
 func sleepTicks(d timeUnit) {
 	for d != 0 {
 		ticks := uint32(d) & 0x7fffff // 23 bits (to be on the safe side)
-		rtc_sleep(ticks)
+		if !rtc_sleep(ticks) {
+			return
+		}
 		d -= timeUnit(ticks)
 	}
 }
@@ -107,7 +109,7 @@ func nanosecondsToTicks(ns int64) timeUnit {
 }
 
 // Monotonically increasing numer of ticks since start.
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	// For some ways of capturing the time atomically, see this thread:
 	// https://www.eevblog.com/forum/microcontrollers/correct-timing-by-timer-overflow-count/msg749617/#msg749617
 	// Here, instead of re-reading the counter register if an overflow has been
@@ -134,7 +136,9 @@ func ticks() timeUnit {
 
 var rtc_wakeup volatile.Register8
 
-func rtc_sleep(ticks uint32) {
+// rtc_sleep sleeps for the given number of ticks. It returns false if it
+// returned early because an interrupt woke up a goroutine.
+func rtc_sleep(ticks uint32) bool {
 	nrf.RTC1.INTENSET.Set(nrf.RTC_INTENSET_COMPARE0)
 	rtc_wakeup.Set(0)
 	if ticks == 1 {
@@ -146,5 +150,12 @@ func rtc_sleep(ticks uint32) {
 	nrf.RTC1.CC[0].Set((nrf.RTC1.COUNTER.Get() + ticks) & 0x00ffffff)
 	for rtc_wakeup.Get() == 0 {
 		waitForEvents()
+		if hasScheduler && !runqueue.Empty() {
+			// The interrupt may have awoken a goroutine (for example by
+			// sending on a channel), so bail out early.
+			nrf.RTC1.INTENCLR.Set(nrf.RTC_INTENSET_COMPARE0)
+			return false
+		}
 	}
+	return true
 }
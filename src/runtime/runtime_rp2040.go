@@ -16,8 +16,8 @@ func machineLightSleep(uint64)
 
 type timeUnit uint64
 
-// ticks returns the number of ticks (microseconds) elapsed since power up.
-func ticks() timeUnit {
+// hardwareTicks returns the number of ticks (microseconds) elapsed since power up.
+func hardwareTicks() timeUnit {
 	t := machineTicks()
 	return timeUnit(t)
 }
@@ -44,8 +44,8 @@ func sleepTicks(d timeUnit) {
 	}
 
 	// Busy loop
-	sleepUntil := ticks() + d
-	for ticks() < sleepUntil {
+	sleepUntil := hardwareTicks() + d
+	for hardwareTicks() < sleepUntil {
 	}
 }
 
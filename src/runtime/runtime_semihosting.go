@@ -0,0 +1,30 @@
+//go:build baremetal && semihosting
+
+package runtime
+
+import (
+	"internal/semihosting"
+)
+
+// Use the command line of the semihosting host (for example the -append flag
+// of QEMU) as os.Args, unless the arguments were already set by the compiler
+// in nonhosted.go (as is done by tinygo test).
+func init() {
+	if osArgs != "" {
+		return
+	}
+	cmdline := semihosting.CommandLine()
+	if cmdline == "" {
+		return
+	}
+	args = args[:0]
+	start := 0
+	for i := 0; i <= len(cmdline); i++ {
+		if i == len(cmdline) || cmdline[i] == ' ' {
+			if i > start {
+				args = append(args, cmdline[start:i])
+			}
+			start = i + 1
+		}
+	}
+}
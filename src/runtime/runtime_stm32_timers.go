@@ -61,9 +61,7 @@ func nanosecondsToTicks(ns int64) timeUnit {
 }
 
 // number of ticks since start.
-//
-//go:linkname ticks runtime.ticks
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	// For some ways of capturing the time atomically, see this thread:
 	// https://www.eevblog.com/forum/microcontrollers/correct-timing-by-timer-overflow-count/msg749617/#msg749617
 	// Here, instead of re-reading the counter register if an overflow has been
@@ -126,8 +124,8 @@ func sleepTicks(d timeUnit) {
 	// There's no scheduler, so we sleep until at least the requested number
 	// of ticks has passed.  For short sleeps, this forms a busy loop since
 	// timerSleep will return immediately.
-	end := ticks() + d
-	for ticks() < end {
+	end := hardwareTicks() + d
+	for hardwareTicks() < end {
 		timerSleep(uint64(d))
 	}
 }
@@ -35,7 +35,7 @@ func sleepTicks(d timeUnit) {
 	timestamp += d
 }
 
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	return timestamp
 }
 
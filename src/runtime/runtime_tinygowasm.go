@@ -3,17 +3,24 @@
 package runtime
 
 import (
+	"runtime/interrupt"
 	"unsafe"
 )
 
+// wasmNested is used to detect scheduler nesting (WASM calls into JS calls back into WASM).
+// When this happens, we need to use a reduced version of the scheduler.
+var wasmNested bool
+
 // Implements __wasi_iovec_t.
 type __wasi_iovec_t struct {
 	buf    unsafe.Pointer
 	bufLen uint
 }
 
+// The errno result is a 32-bit value in WebAssembly, also with 64-bit memory.
+//
 //go:wasmimport wasi_snapshot_preview1 fd_write
-func fd_write(id uint32, iovs *__wasi_iovec_t, iovs_len uint, nwritten *uint) (errno uint)
+func fd_write(id uint32, iovs *__wasi_iovec_t, iovs_len uint, nwritten *uint) (errno uint16)
 
 // See:
 // https://github.com/WebAssembly/WASI/blob/main/phases/snapshot/docs.md#-proc_exitrval-exitcode
@@ -21,6 +28,12 @@ func fd_write(id uint32, iovs *__wasi_iovec_t, iovs_len uint, nwritten *uint) (e
 //go:wasmimport wasi_snapshot_preview1 proc_exit
 func proc_exit(exitcode uint32)
 
+// See:
+// https://github.com/WebAssembly/WASI/blob/main/phases/snapshot/docs.md#-random_getbuf-pointeru8-buf_len-size---errno
+//
+//go:wasmimport wasi_snapshot_preview1 random_get
+func random_get(buf unsafe.Pointer, bufLen uint) (errno uint16)
+
 const (
 	putcharBufferSize = 120
 	stdout            = 1
@@ -37,6 +50,12 @@ var (
 )
 
 func putchar(c byte) {
+	if hasParallelism {
+		// Other threads may print at the same time.
+		mask := interrupt.Disable()
+		defer interrupt.Restore(mask)
+	}
+
 	putcharBuffer[putcharPosition] = c
 	putcharPosition++
 
@@ -85,3 +104,9 @@ func procPin() {
 //go:linkname procUnpin sync/atomic.runtime_procUnpin
 func procUnpin() {
 }
+
+// hardwareRand returns a random number from the host.
+func hardwareRand() (n uint64, ok bool) {
+	ok = random_get(unsafe.Pointer(&n), 8) == 0
+	return
+}
@@ -1,4 +1,4 @@
-//go:build (darwin || (linux && !baremetal && !wasi)) && !nintendoswitch
+//go:build (darwin || freebsd || (linux && !baremetal && !wasi)) && !nintendoswitch
 
 package runtime
 
@@ -16,6 +16,7 @@ func usleep(usec uint) int
 // Note: off_t is defined as int64 because:
 //   - musl (used on Linux) always defines it as int64
 //   - darwin is practically always 64-bit anyway
+//   - FreeBSD defines it as int64 on all architectures
 //
 //export mmap
 func mmap(addr unsafe.Pointer, length uintptr, prot, flags, fd int, offset int64) unsafe.Pointer
@@ -26,6 +27,11 @@ func abort()
 //export exit
 func exit(code int)
 
+// int getentropy(void *buffer, size_t length);
+//
+//export getentropy
+func libc_getentropy(buf unsafe.Pointer, length uint) int32
+
 //export clock_gettime
 func libc_clock_gettime(clk_id int32, ts *timespec)
 
@@ -55,8 +61,10 @@ type timeUnit int64
 // Note: tv_sec and tv_nsec normally vary in size by platform. However, we're
 // using the time64 variant (see clock_gettime above), so the formats are the
 // same between 32-bit and 64-bit architectures.
-// There is one issue though: on big-endian systems, tv_nsec would be incorrect.
-// But we don't support big-endian systems yet (as of 2021) so this is fine.
+// On 32-bit systems, tv_nsec is a 32-bit long with 32 bits of padding either
+// before it (big-endian) or after it (little-endian). Because the padding is
+// never written, tv_nsec reads correctly as an int64 as long as the struct is
+// zero-initialized before the call.
 type timespec struct {
 	tv_sec  int64 // time_t with time64 support (always 64-bit)
 	tv_nsec int64 // unsigned 64-bit integer on all time64 platforms
@@ -64,26 +72,6 @@ type timespec struct {
 
 var stackTop uintptr
 
-// Entry point for Go. Initialize all packages and call main.main().
-//
-//export main
-func main(argc int32, argv *unsafe.Pointer) int {
-	preinit()
-
-	// Store argc and argv for later use.
-	main_argc = argc
-	main_argv = argv
-
-	// Obtain the initial stack pointer right before calling the run() function.
-	// The run function has been moved to a separate (non-inlined) function so
-	// that the correct stack pointer is read.
-	stackTop = getCurrentStackPointer()
-	runMain()
-
-	// For libc compatibility.
-	return 0
-}
-
 var (
 	main_argc int32
 	main_argv *unsafe.Pointer
@@ -112,13 +100,6 @@ func os_runtime_args() []string {
 	return args
 }
 
-// Must be a separate function to get the correct stack pointer.
-//
-//go:noinline
-func runMain() {
-	run()
-}
-
 //go:extern environ
 var environ *unsafe.Pointer
 
@@ -217,10 +198,8 @@ var heapMaxSize uintptr
 var heapStart, heapEnd uintptr
 
 func preinit() {
-	// Allocate a large chunk of virtual memory. Because it is virtual, it won't
-	// really be allocated in RAM. Memory will only be allocated when it is
-	// first touched.
-	heapMaxSize = 1 * 1024 * 1024 * 1024 // 1GB for the entire heap
+	// Allocate a chunk of memory for the heap, see heapMaxReservation.
+	heapMaxSize = heapMaxReservation
 	for {
 		addr := mmap(nil, heapMaxSize, flag_PROT_READ|flag_PROT_WRITE, flag_MAP_PRIVATE|flag_MAP_ANONYMOUS, -1, 0)
 		if addr == unsafe.Pointer(^uintptr(0)) {
@@ -253,3 +232,9 @@ func growHeap() bool {
 	setHeapEnd(heapStart + heapSize)
 	return true
 }
+
+// hardwareRand returns a random number from the operating system.
+func hardwareRand() (n uint64, ok bool) {
+	ok = libc_getentropy(unsafe.Pointer(&n), 8) == 0
+	return
+}
@@ -0,0 +1,17 @@
+//go:build (darwin || (linux && !baremetal && !wasi)) && !nintendoswitch && tinygo.library
+
+package runtime
+
+import "C" // dummy import so that runtime_library.c works
+
+// Initialize the runtime and all packages when the library is loaded. This is
+// called from a constructor in runtime_library.c, so that exported functions
+// can be called right after loading the library (for example from JNI_OnLoad).
+//
+//export tinygo_initializeLibrary
+func initializeLibrary() {
+	preinit()
+	initHeap()
+	initRand()
+	initAll()
+}
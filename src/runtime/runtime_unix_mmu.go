@@ -0,0 +1,8 @@
+//go:build (darwin || freebsd || (linux && !baremetal && !wasi)) && !nintendoswitch && !nommu
+
+package runtime
+
+// Reserve a large chunk of virtual memory for the heap. Because it is virtual,
+// it won't really be allocated in RAM. Memory will only be allocated when it is
+// first touched.
+const heapMaxReservation = 1 * 1024 * 1024 * 1024 // 1GB for the entire heap
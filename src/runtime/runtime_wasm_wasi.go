@@ -17,8 +17,12 @@ func __wasm_call_ctors()
 func _start() {
 	// These need to be initialized early so that the heap can be initialized.
 	heapStart = uintptr(unsafe.Pointer(&heapStartSymbol))
-	heapEnd = uintptr(wasm_memory_size(0) * wasmPageSize)
+	heapEnd = wasm_memory_size(0) * wasmPageSize
 	run()
+	if hasParallelism {
+		// Stop the other threads too.
+		proc_exit(0)
+	}
 }
 
 // Read the command line arguments from WASI.
@@ -89,6 +93,12 @@ var (
 )
 
 func sleepTicks(d timeUnit) {
+	if hasParallelism {
+		// Wait in a way that lets other threads wake up this one, for example
+		// to run a goroutine or to stop it for the GC.
+		waitForEventsTimeout(int64(d))
+		return
+	}
 	sleepTicksSubscription.u.u.timeout = uint64(d)
 	poll_oneoff(&sleepTicksSubscription, &sleepTicksResult, 1, &sleepTicksNEvents)
 }
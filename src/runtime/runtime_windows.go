@@ -14,6 +14,11 @@ func libc_putchar(c int) int
 //export VirtualAlloc
 func _VirtualAlloc(lpAddress unsafe.Pointer, dwSize uintptr, flAllocationType, flProtect uint32) unsafe.Pointer
 
+// errno_t rand_s(unsigned int* randomValue);
+//
+//export rand_s
+func _rand_s(randomValue *uint32) int32
+
 //export QueryUnbiasedInterruptTime
 func _QueryUnbiasedInterruptTime(UnbiasedTime *uint64) bool
 
@@ -228,3 +233,10 @@ func procPin() {
 //go:linkname procUnpin sync/atomic.runtime_procUnpin
 func procUnpin() {
 }
+
+// hardwareRand returns a random number from the operating system.
+func hardwareRand() (n uint64, ok bool) {
+	var hi, lo uint32
+	ok = _rand_s(&hi) == 0 && _rand_s(&lo) == 0
+	return uint64(hi)<<32 | uint64(lo), ok
+}
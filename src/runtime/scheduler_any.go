@@ -2,7 +2,11 @@
 
 package runtime
 
-import "internal/task"
+import (
+	"internal/task"
+	"runtime/interrupt"
+	"unsafe"
+)
 
 // Pause the current task for a given time.
 //
@@ -11,21 +15,108 @@ func sleep(duration int64) {
 	if duration <= 0 {
 		return
 	}
+	if interrupt.In() {
+		// The interrupted goroutine would be put to sleep instead.
+		runtimePanic("sleep inside interrupt")
+	}
+	if duration < shortSleepLimit && sleepShort(duration) {
+		return
+	}
 
+	mask := interrupt.Disable()
 	addSleepTask(task.Current(), nanosecondsToTicks(duration))
-	task.Pause()
+	interrupt.Restore(mask)
+	task.Wait(task.WaitSleep, nil)
 }
 
 // run is called by the program entry point to execute the go program.
 // With a scheduler, init and the main function are invoked in a goroutine before starting the scheduler.
 func run() {
 	initHeap()
+	initRand()
 	go func() {
 		initAll()
+		startSecondaryCores()
 		callMain()
 		schedulerDone = true
+		// Other cores may be waiting for events, while they should exit
+		// their scheduler.
+		wakeCores()
 	}()
 	scheduler()
 }
 
 const hasScheduler = true
+
+// startFinalizerGoroutine starts the goroutine that runs finalizers, see
+// gc_finalizer.go.
+func startFinalizerGoroutine(loop func()) {
+	go loop()
+}
+
+// dumpGoroutines prints all goroutines together with what they are blocked on
+// (or were last blocked on, if they have been woken up but didn't run yet). It
+// is called on a fatal panic, to make it possible to debug hangs and crashes
+// with nothing but a serial console.
+func dumpGoroutines() {
+	printstring("\ngoroutines:\n")
+	current := task.Current()
+	task.ForEach(func(t *task.Task) {
+		printstring("  ")
+		printptr(uintptr(unsafe.Pointer(t)))
+		printstring(" [")
+		if t == current {
+			printstring("running")
+		} else {
+			printstring(t.WaitReason.String())
+		}
+		printstring("]")
+		if t != current && t.WaitObject != nil {
+			printstring(" on ")
+			if t.WaitReason == task.WaitSelect {
+				// The wait object is the first operation of the select,
+				// which refers to all other operations.
+				first := true
+				for _, op := range (*channelBlockedList)(t.WaitObject).allSelectOps {
+					if op.t == nil {
+						continue // nil channel
+					}
+					if !first {
+						printstring(", ")
+					}
+					first = false
+					printWaitObject(uintptr(unsafe.Pointer(op.s.ch)))
+				}
+			} else {
+				printWaitObject(uintptr(t.WaitObject))
+			}
+		}
+		printnl()
+	})
+}
+
+// printWaitObject prints the address of an object that a goroutine is blocked
+// on, like a channel or a mutex. If it is known, it also prints where the
+// object (or the heap object that contains it) was allocated, which is the case
+// when building with -tags=leakcheck. The allocation site is resolved to a
+// function and source location when building with -linetable.
+func printWaitObject(ptr uintptr) {
+	printptr(ptr)
+	pc := leakCheckObjectSite(ptr)
+	if pc == 0 {
+		return
+	}
+	printstring(" allocated at ")
+	printptr(pc)
+	if name, _, ok := lineTableFunction(pc); ok {
+		printstring(" (")
+		printstring(name)
+		if file, line := lineTableLine(pc); file != "" {
+			printspace()
+			printstring(file)
+			printstring(":")
+			printint32(int32(line))
+		}
+		printstring(")")
+	}
+}
@@ -0,0 +1,188 @@
+//go:build !scheduler.cores
+
+package runtime
+
+// This file implements the scheduler loop for a single core: one run queue
+// from which goroutines are run one after another. See scheduler_cores.go for
+// the scheduler that runs goroutines on multiple cores.
+
+import "internal/task"
+
+const hasParallelism = false
+
+// Number of cores that run goroutines.
+const numCPU = 1
+
+// currentCPU returns the core the current code is running on, which is always
+// the first core.
+func currentCPU() uint32 {
+	return 0
+}
+
+// The queue of goroutines that are ready to run.
+var runqueue task.Queue
+
+// Add this task to the end of the run queue.
+func runqueuePushBack(t *task.Task) {
+	if traceEnabled && t.WaitReason != task.WaitNone {
+		traceEvent(traceEvGoUnblock, t, 0)
+	}
+	runqueue.Push(t)
+}
+
+// Run the scheduler until all tasks have finished.
+func scheduler() {
+	// Main scheduler loop.
+	var now timeUnit
+	for !schedulerDone {
+		scheduleLog("")
+		scheduleLog("  schedule")
+		if sleepQueue != nil || len(timerQueue) != 0 {
+			now = ticks()
+		}
+
+		// Add tasks that are done sleeping to the end of the runqueue so they
+		// will be executed soon.
+		if sleepQueue != nil && now-sleepQueueBaseTime >= timeUnit(sleepQueue.Data) {
+			t := sleepQueue
+			scheduleLogTask("  awake:", t)
+			sleepQueueBaseTime += timeUnit(t.Data)
+			sleepQueue = t.Next
+			t.Next = nil
+			runqueuePushBack(t)
+		}
+
+		// Check for expired timers to trigger.
+		if len(timerQueue) != 0 && now >= timerQueue[0].whenTicks() {
+			scheduleLog("--- timer awoke")
+			// Pop timer from queue.
+			tn := timerQueuePop()
+			// Run the callback stored in this timer node.
+			tn.callback(tn)
+		}
+
+		t := runqueue.Pop()
+		if t == nil {
+			limit := watchdogIdle()
+			if sleepQueue == nil && len(timerQueue) == 0 {
+				if asyncScheduler {
+					// JavaScript is treated specially, see below.
+					return
+				}
+				if limit >= 0 {
+					// Wake up in time to pet the watchdog.
+					idleSleep(limit)
+					continue
+				}
+				idleWait()
+				continue
+			}
+
+			var timeLeft timeUnit
+			if sleepQueue != nil {
+				timeLeft = timeUnit(sleepQueue.Data) - (now - sleepQueueBaseTime)
+			}
+			if len(timerQueue) != 0 {
+				timeLeftForTimer := timerQueue[0].whenTicks() - now
+				if sleepQueue == nil || timeLeftForTimer < timeLeft {
+					timeLeft = timeLeftForTimer
+				}
+			}
+			if limit >= 0 && timeLeft > limit {
+				timeLeft = limit
+			}
+
+			if schedulerDebug {
+				println("  sleeping...", sleepQueue, uint(timeLeft))
+				for t := sleepQueue; t != nil; t = t.Next {
+					println("    task sleeping:", t, timeUnit(t.Data))
+				}
+				for _, tim := range timerQueue {
+					println("---   timer waiting:", tim, tim.whenTicks())
+				}
+			}
+			idleSleep(timeLeft)
+			if asyncScheduler {
+				// The idleSleep function above only sets a timeout at which
+				// point the scheduler will be called again. It does not really
+				// sleep. So instead of sleeping, we return and expect to be
+				// called again.
+				break
+			}
+			continue
+		}
+
+		// Run the given task.
+		scheduleLogTask("  run:", t)
+		watchdogRun(t)
+		gcResumeTask(t)
+		preemptResumeTask()
+		if traceEnabled {
+			traceEvent(traceEvGoStart, t, 0)
+		}
+		stackGuardSet(t)
+		t.Resume()
+		stackGuardSet(nil)
+		traceGoStop(t)
+	}
+}
+
+// This horrible hack exists to make WASM work properly.
+// When a WASM program calls into JS which calls back into WASM, the event with which we called back in needs to be handled before returning.
+// Thus there are two copies of the scheduler running at once.
+// This is a reduced version of the scheduler which does not deal with the timer queue (that is a problem for the outer scheduler).
+func minSched() {
+	scheduleLog("start nested scheduler")
+	for !schedulerDone {
+		t := runqueue.Pop()
+		if t == nil {
+			break
+		}
+
+		scheduleLogTask("  run:", t)
+		watchdogRun(t)
+		gcResumeTask(t)
+		preemptResumeTask()
+		if traceEnabled {
+			traceEvent(traceEvGoStart, t, 0)
+		}
+		stackGuardSet(t)
+		t.Resume()
+		stackGuardSet(nil)
+		traceGoStop(t)
+	}
+	scheduleLog("stop nested scheduler")
+}
+
+func Gosched() {
+	traceYield = true
+	runqueue.Push(task.Current())
+	task.Pause()
+}
+
+// LockOSThread wires the calling goroutine to its current operating system
+// thread. Goroutines only run on a single core (or thread) here, so this does
+// nothing.
+// Called by go1.18 standard library on windows, see https://github.com/golang/go/issues/49320
+func LockOSThread() {
+}
+
+// UnlockOSThread undoes an earlier call to LockOSThread.
+func UnlockOSThread() {
+}
+
+// Stubs for the cores scheduler, see scheduler_cores.go.
+
+func startSecondaryCores() {}
+
+func gcStopOtherCores() {}
+
+func gcResumeOtherCores() {}
+
+func wakeCores() {}
+
+func waitForEventsTimeout(timeout int64) {}
+
+func markCoreStacks() {}
+
+func coreLockHeld() bool { return false }
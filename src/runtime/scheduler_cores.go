@@ -0,0 +1,349 @@
+//go:build scheduler.cores
+
+package runtime
+
+// This file implements a scheduler that runs goroutines on all cores of the
+// chip at the same time (-scheduler=cores).
+//
+// Every core runs its own scheduler loop with its own run queue. A goroutine
+// that becomes runnable is added to the run queue of the core that woke it up,
+// except when it was woken up by an interrupt: those goroutines are added to a
+// shared run queue so that whichever core is free first can run them. A core
+// that has nothing left to run steals goroutines from the other cores. A
+// goroutine wired to a core with LockOSThread is always added to the run queue
+// of that core, and is never stolen. Only the first core handles sleeping
+// goroutines and timers.
+//
+// Runtime data structures (run queues, channels, the heap, etc.) are protected
+// with interrupt.Disable like on a single core. With this scheduler, disabling
+// interrupts also takes a spinlock shared by all cores, see lockCores.
+//
+// The GC stops all other cores while it is running, see gcStopOtherCores.
+//
+// The chip specific parts (starting the other cores, the spinlock, waking up
+// and signalling cores) are only implemented for the RP2040 and for
+// WebAssembly threads. Other multicore chips such as the RP2350 and the ESP32
+// need their own implementation before they can use this scheduler.
+//
+// Note that all chips supported by this scheduler are 32-bit, which is
+// assumed when accessing pointers shared between cores.
+
+import (
+	"internal/task"
+	"runtime/interrupt"
+	"runtime/volatile"
+	"unsafe"
+)
+
+const hasParallelism = true
+
+// Per-core scheduler state.
+type cpuState struct {
+	current  *task.Task // goroutine running on this core, or nil in the scheduler
+	runqueue task.Queue // goroutines to run next on this core
+}
+
+var cpus [numCPU]cpuState
+
+// Goroutines woken up by an interrupt, which can run on any core.
+var runqueue task.Queue
+
+// currentTask returns the goroutine running on the current core. It is the
+// implementation of task.Current.
+func currentTask() *task.Task {
+	return loadTask(&cpus[currentCPU()].current)
+}
+
+// setCurrentTask is called by internal/task when it switches to a goroutine
+// (and back to the scheduler).
+func setCurrentTask(t *task.Task) {
+	storeTask(&cpus[currentCPU()].current, t)
+}
+
+// loadTask and storeTask access a task pointer that may be used by other cores
+// at the same time.
+func loadTask(p **task.Task) *task.Task {
+	return (*task.Task)(unsafe.Pointer(uintptr(volatile.LoadUint32((*uint32)(unsafe.Pointer(p))))))
+}
+
+func storeTask(p **task.Task, t *task.Task) {
+	volatile.StoreUint32((*uint32)(unsafe.Pointer(p)), uint32(uintptr(unsafe.Pointer(t))))
+}
+
+// Add this task to the end of the run queue.
+func runqueuePushBack(t *task.Task) {
+	if traceEnabled && t.WaitReason != task.WaitNone {
+		traceEvent(traceEvGoUnblock, t, 0)
+	}
+	if t.LockedCore != 0 {
+		// Wired to a core using LockOSThread.
+		cpus[t.LockedCore-1].runqueue.Push(t)
+	} else if interrupt.In() {
+		runqueue.Push(t)
+	} else {
+		cpus[currentCPU()].runqueue.Push(t)
+	}
+	// Wake up idle cores, so that they can steal this goroutine.
+	wakeCores()
+}
+
+// runqueuePop returns the next goroutine to run on the given core, or nil if
+// there is none. It must be called inside a critical section.
+func runqueuePop(core uint32) *task.Task {
+	if t := cpus[core].runqueue.Pop(); t != nil {
+		return t
+	}
+	if t := runqueue.Pop(); t != nil {
+		return t
+	}
+	// Steal a goroutine from another core.
+	for i := range cpus {
+		if uint32(i) == core {
+			continue
+		}
+		if t := cpus[i].runqueue.Pop(); t != nil {
+			if t.LockedCore == 0 {
+				return t
+			}
+			// This goroutine must stay on that core, put it back.
+			cpus[i].runqueue.Push(t)
+		}
+	}
+	return nil
+}
+
+// Run the scheduler on the current core until all tasks have finished.
+func scheduler() {
+	core := currentCPU()
+	var now timeUnit
+	for !schedulerDone {
+		scheduleLog("")
+		scheduleLog("  schedule")
+
+		mask := interrupt.Disable()
+		if core == 0 {
+			if sleepQueue != nil || len(timerQueue) != 0 {
+				now = ticks()
+			}
+
+			// Add tasks that are done sleeping to the end of the runqueue so
+			// they will be executed soon.
+			if sleepQueue != nil && now-sleepQueueBaseTime >= timeUnit(sleepQueue.Data) {
+				t := sleepQueue
+				scheduleLogTask("  awake:", t)
+				sleepQueueBaseTime += timeUnit(t.Data)
+				sleepQueue = t.Next
+				t.Next = nil
+				runqueuePushBack(t)
+			}
+
+			// Check for expired timers to trigger.
+			if len(timerQueue) != 0 && now >= timerQueue[0].whenTicks() {
+				scheduleLog("--- timer awoke")
+				// Pop timer from queue.
+				tn := timerQueuePop()
+				interrupt.Restore(mask)
+				// Run the callback stored in this timer node.
+				tn.callback(tn)
+				continue
+			}
+		}
+
+		t := runqueuePop(core)
+		if t == nil {
+			if core != 0 {
+				// Only the first core puts the chip in a low-power mode.
+				interrupt.Restore(mask)
+				waitForEvents()
+				continue
+			}
+			if sleepQueue == nil && len(timerQueue) == 0 {
+				interrupt.Restore(mask)
+				idleWait()
+				continue
+			}
+
+			var timeLeft timeUnit
+			if sleepQueue != nil {
+				timeLeft = timeUnit(sleepQueue.Data) - (now - sleepQueueBaseTime)
+			}
+			if len(timerQueue) != 0 {
+				timeLeftForTimer := timerQueue[0].whenTicks() - now
+				if sleepQueue == nil || timeLeftForTimer < timeLeft {
+					timeLeft = timeLeftForTimer
+				}
+			}
+			interrupt.Restore(mask)
+			idleSleep(timeLeft)
+			continue
+		}
+		interrupt.Restore(mask)
+
+		// The goroutine may have been made runnable by another core right
+		// before it paused there. Wait until that core has switched away from
+		// it.
+		for i := range cpus {
+			for uint32(i) != core && loadTask(&cpus[i].current) == t {
+			}
+		}
+
+		// Run the given task.
+		scheduleLogTask("  run:", t)
+		gcResumeTask(t)
+		preemptResumeTask()
+		if traceEnabled {
+			traceEvent(traceEvGoStart, t, 0)
+		}
+		stackGuardSet(t)
+		t.Resume()
+		stackGuardSet(nil)
+		traceGoStop(t)
+	}
+}
+
+func Gosched() {
+	traceYield = true
+	cpus[currentCPU()].runqueue.Push(task.Current())
+	task.Pause()
+}
+
+// LockOSThread wires the calling goroutine to the core it is currently running
+// on: until the goroutine calls UnlockOSThread as many times as it called
+// LockOSThread, it will only run on this core. Other goroutines may still run
+// on the core when this goroutine is paused.
+//
+// This is useful for code that accesses per-core hardware, such as the SIO
+// FIFO or interpolators on the RP2040.
+func LockOSThread() {
+	t := task.Current()
+	if t.LockCount == 255 {
+		runtimePanic("LockOSThread nesting overflow")
+	}
+	if t.LockCount == 0 {
+		t.LockedCore = uint8(currentCPU()) + 1
+	}
+	t.LockCount++
+}
+
+// UnlockOSThread undoes an earlier call to LockOSThread. If this drops the
+// number of active LockOSThread calls on the calling goroutine to zero, the
+// goroutine can run on any core again. If there are no active LockOSThread
+// calls, this is a no-op.
+func UnlockOSThread() {
+	t := task.Current()
+	if t.LockCount == 0 {
+		return
+	}
+	t.LockCount--
+	if t.LockCount == 0 {
+		t.LockedCore = 0
+	}
+}
+
+// The lock taken by interrupt.Disable, so that only one core at a time can be
+// inside a critical section.
+var (
+	coreLockOwner uint32 // number of the core holding the lock plus one, or 0
+	coreLockDepth uint32 // number of nested critical sections on that core
+)
+
+// lockCores is called by interrupt.Disable after interrupts have been disabled
+// on the current core. It waits until no other core is inside a critical
+// section.
+func lockCores() {
+	self := currentCPU() + 1
+	if volatile.LoadUint32(&coreLockOwner) == self {
+		// Nested critical section.
+		coreLockDepth++
+		return
+	}
+	for !coreLockTryAcquire() {
+		// The core holding the lock may be waiting for this core to stop for
+		// the GC, and this core won't take the interrupt that does this
+		// while interrupts are disabled.
+		if volatile.LoadUint8(&gcStopRequest) != 0 {
+			gcPauseCore()
+		}
+	}
+	volatile.StoreUint32(&coreLockOwner, self)
+	coreLockDepth = 1
+}
+
+// unlockCores is called by interrupt.Restore before interrupts are enabled
+// again.
+func unlockCores() {
+	coreLockDepth--
+	if coreLockDepth == 0 {
+		volatile.StoreUint32(&coreLockOwner, 0)
+		coreLockRelease()
+	}
+}
+
+// coreLockHeld returns whether the current core holds the lock taken by
+// interrupt.Disable, in other words whether it is inside a critical section.
+func coreLockHeld() bool {
+	return volatile.LoadUint32(&coreLockOwner) == currentCPU()+1
+}
+
+var (
+	gcStopRequest uint8              // set while the GC needs the other cores to be stopped
+	coreRunning   = [numCPU]uint8{1} // whether a core has started running goroutines (core 0 always has)
+	corePaused    [numCPU]uint8      // whether a core is stopped for the GC
+	corePausedSP  [numCPU]uintptr    // lowest address of the system stack to scan of a stopped core
+)
+
+// gcStopOtherCores stops all other cores until gcResumeOtherCores is called.
+// It must be called inside a critical section.
+func gcStopOtherCores() {
+	self := currentCPU()
+	volatile.StoreUint8(&gcStopRequest, 1)
+	for i := uint32(0); i < numCPU; i++ {
+		if i != self && volatile.LoadUint8(&coreRunning[i]) != 0 {
+			signalCore(i)
+		}
+	}
+	for i := uint32(0); i < numCPU; i++ {
+		for i != self && volatile.LoadUint8(&coreRunning[i]) != 0 && volatile.LoadUint8(&corePaused[i]) == 0 {
+		}
+	}
+}
+
+// gcResumeOtherCores lets the cores stopped by gcStopOtherCores continue.
+func gcResumeOtherCores() {
+	volatile.StoreUint8(&gcStopRequest, 0)
+	wakeCores()
+	for i := range corePaused {
+		for volatile.LoadUint8(&corePaused[i]) != 0 {
+		}
+	}
+}
+
+// gcPausedCore is called by gcPauseCore after it has pushed all registers to
+// the stack. It waits until the GC running on another core has finished.
+//
+//export tinygo_gcPausedCore
+func gcPausedCore(sp uintptr) {
+	core := currentCPU()
+	if !interrupt.In() && !task.OnSystemStack() {
+		// The registers were pushed to a goroutine stack, which is scanned as
+		// part of the heap. The system stack of this core is still in use by
+		// the scheduler though.
+		sp = getSystemStackPointer()
+	}
+	corePausedSP[core] = sp
+	volatile.StoreUint8(&corePaused[core], 1)
+	for volatile.LoadUint8(&gcStopRequest) != 0 {
+		waitForEvents()
+	}
+	volatile.StoreUint8(&corePaused[core], 0)
+}
+
+// runSecondaryCore is the scheduler entry point for all cores except the
+// first.
+func runSecondaryCore() {
+	volatile.StoreUint8(&coreRunning[currentCPU()], 1)
+	scheduler()
+	for {
+		waitForEvents()
+	}
+}
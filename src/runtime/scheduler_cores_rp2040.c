@@ -0,0 +1,34 @@
+//go:build scheduler.cores && rp2040
+
+#include <stdint.h>
+
+void tinygo_runCore1(void);
+
+uintptr_t tinygo_core1EntryPoint(void) {
+    return (uintptr_t)&tinygo_runCore1;
+}
+
+// Push all callee-saved registers to the stack so that the GC running on the
+// other core can find pointers in them, and wait in tinygo_gcPausedCore until
+// the GC has finished. The stack pointer after pushing is passed as the first
+// parameter. r3 is pushed too, to keep the stack aligned to 8 bytes.
+// Only Thumb-1 instructions are used, as the RP2040 has Cortex-M0+ cores.
+__attribute__((naked))
+void tinygo_gcPauseCore(void) {
+    __asm__ volatile(
+        "push {r3-r7, lr}\n"
+        "mov  r0, r8\n"
+        "mov  r1, r9\n"
+        "mov  r2, r10\n"
+        "mov  r3, r11\n"
+        "push {r0-r3}\n"
+        "mov  r0, sp\n"
+        "bl   tinygo_gcPausedCore\n"
+        "pop  {r0-r3}\n"
+        "mov  r8, r0\n"
+        "mov  r9, r1\n"
+        "mov  r10, r2\n"
+        "mov  r11, r3\n"
+        "pop  {r3-r7, pc}\n"
+    );
+}
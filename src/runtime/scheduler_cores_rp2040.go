@@ -0,0 +1,153 @@
+//go:build scheduler.cores && rp2040
+
+package runtime
+
+// Support for -scheduler=cores on the RP2040. Core 1 is started by the bootrom
+// using the handshake over the inter-core FIFO that is described in section
+// 2.8.2 of the RP2040 datasheet. After that, the FIFO is only used to
+// interrupt the other core when the GC needs to stop it.
+
+import (
+	"C" // dummy import so that scheduler_cores_rp2040.c works
+	"device/arm"
+	"device/rp"
+	"runtime/interrupt"
+	"runtime/volatile"
+	"unsafe"
+)
+
+const numCPU = 2
+
+// The hardware spinlock used by lockCores. The Pico SDK reserves spinlocks 14
+// and 15 for use by an operating system, so this doesn't conflict with
+// spinlocks used by C libraries.
+var coreLock = &rp.SIO.SPINLOCK14
+
+func coreLockTryAcquire() bool {
+	// Reading a spinlock claims it, if it was free.
+	return coreLock.Get() != 0
+}
+
+func coreLockRelease() {
+	coreLock.Set(0)
+}
+
+func currentCPU() uint32 {
+	return rp.SIO.CPUID.Get()
+}
+
+// The system stack of core 1. It is the same size as the default core 1 stack
+// in the Pico SDK.
+var core1Stack [2048 / 8]uint64
+
+// coreStackTop returns the highest address of the system stack of a core.
+func coreStackTop(core uint32) uintptr {
+	if core == 0 {
+		return stackTop
+	}
+	return uintptr(unsafe.Pointer(&core1Stack)) + unsafe.Sizeof(core1Stack)
+}
+
+// wakeCores wakes up cores waiting in waitForEvents.
+func wakeCores() {
+	arm.Asm("sev")
+}
+
+// signalCore interrupts the other core. It only has one FIFO to send values
+// to, so the core number is not used.
+func signalCore(core uint32) {
+	if rp.SIO.FIFO_ST.HasBits(rp.SIO_FIFO_ST_RDY) {
+		rp.SIO.FIFO_WR.Set(0)
+	}
+	wakeCores()
+}
+
+// coreSignalHandler is called when the other core wrote to the FIFO of this
+// core.
+func coreSignalHandler(interrupt.Interrupt) {
+	fifoDrain()
+	// Clear the sticky error flags, which also clears the interrupt.
+	rp.SIO.FIFO_ST.Set(0xff)
+	if volatile.LoadUint8(&gcStopRequest) != 0 {
+		gcPauseCore()
+	}
+}
+
+// enableCoreSignal enables the FIFO interrupt of the current core.
+func enableCoreSignal() {
+	if currentCPU() == 0 {
+		interrupt.New(rp.IRQ_SIO_IRQ_PROC0, coreSignalHandler).Enable()
+	} else {
+		interrupt.New(rp.IRQ_SIO_IRQ_PROC1, coreSignalHandler).Enable()
+	}
+}
+
+func fifoDrain() {
+	for rp.SIO.FIFO_ST.HasBits(rp.SIO_FIFO_ST_VLD) {
+		rp.SIO.FIFO_RD.Get()
+	}
+}
+
+func fifoPush(value uint32) {
+	for !rp.SIO.FIFO_ST.HasBits(rp.SIO_FIFO_ST_RDY) {
+	}
+	rp.SIO.FIFO_WR.Set(value)
+	wakeCores()
+}
+
+func fifoPop() uint32 {
+	for !rp.SIO.FIFO_ST.HasBits(rp.SIO_FIFO_ST_VLD) {
+		waitForEvents()
+	}
+	return rp.SIO.FIFO_RD.Get()
+}
+
+// startSecondaryCores starts core 1, which then runs the scheduler until the
+// program exits.
+func startSecondaryCores() {
+	// Reset core 1, in case it was left running by a previous program.
+	rp.PSM.FRCE_OFF.SetBits(rp.PSM_FRCE_OFF_PROC1)
+	for !rp.PSM.FRCE_OFF.HasBits(rp.PSM_FRCE_OFF_PROC1) {
+	}
+	rp.PSM.FRCE_OFF.ClearBits(rp.PSM_FRCE_OFF_PROC1)
+
+	// Send the vector table, stack pointer and entry point to the bootrom.
+	// Every value is echoed back by core 1. If it isn't, the sequence starts
+	// over.
+	cmds := [...]uint32{0, 0, 1, arm.SCB.VTOR.Get(), uint32(coreStackTop(1)), uint32(core1EntryPoint())}
+	for i := 0; i < len(cmds); {
+		cmd := cmds[i]
+		if cmd == 0 {
+			// Core 1 may be waiting for space in the FIFO.
+			fifoDrain()
+			wakeCores()
+		}
+		fifoPush(cmd)
+		if fifoPop() == cmd {
+			i++
+		} else {
+			i = 0
+		}
+	}
+
+	enableCoreSignal()
+}
+
+// runCore1 is the first function that runs on core 1, on core1Stack.
+//
+//export tinygo_runCore1
+func runCore1() {
+	enableCoreSignal()
+	runSecondaryCore()
+}
+
+// core1EntryPoint returns the address of runCore1.
+//
+//export tinygo_core1EntryPoint
+func core1EntryPoint() uintptr
+
+// gcPauseCore pushes all callee-saved registers to the stack and calls
+// gcPausedCore. It is implemented in scheduler_cores_rp2040.c.
+//
+//export tinygo_gcPauseCore
+func gcPauseCore()
@@ -0,0 +1,45 @@
+//go:build scheduler.cores && tinygo.wasm
+
+#include <pthread.h>
+#include <stdint.h>
+#include <stdlib.h>
+
+void tinygo_runThread(uint32_t core);
+
+// The number of the thread, as used by the scheduler. The first thread is 0.
+static _Thread_local uint32_t currentCore;
+
+uint32_t tinygo_currentCPU(void) {
+    return currentCore;
+}
+
+static void *threadStart(void *arg) {
+    currentCore = (uint32_t)(uintptr_t)arg;
+    tinygo_runThread(currentCore);
+    return NULL;
+}
+
+// Return the GOMAXPROCS environment variable, or 0 if it isn't set to a
+// positive number.
+uint32_t tinygo_maxThreads(void) {
+    const char *value = getenv("GOMAXPROCS");
+    if (value == NULL) {
+        return 0;
+    }
+    long n = strtol(value, NULL, 10);
+    if (n <= 0) {
+        return 0;
+    }
+    return n;
+}
+
+// Start a thread that runs the scheduler as the given core. Return 0 on
+// success, or an error number.
+int tinygo_startThread(uint32_t core) {
+    pthread_t thread;
+    int err = pthread_create(&thread, NULL, threadStart, (void *)(uintptr_t)core);
+    if (err == 0) {
+        pthread_detach(thread);
+    }
+    return err;
+}
@@ -0,0 +1,178 @@
+//go:build scheduler.cores && tinygo.wasm
+
+package runtime
+
+// Support for -scheduler=cores on WebAssembly, using shared linear memory and
+// atomic instructions (the threads proposal). The first thread is the one that
+// called _start. The other threads are started with pthread_create from
+// wasi-libc, which uses the thread-spawn function of wasi-threads. Every
+// thread switches between goroutines using asyncify, just like a program
+// without threads does.
+//
+// There are no interrupts, so a thread can't be stopped from the outside when
+// the GC needs to run. Instead, a thread stops the next time it tries to enter
+// a critical section (which it does when it allocates memory, uses a channel,
+// etc.) or waits for events. Therefore, a goroutine that runs a long loop
+// without doing any of that delays the GC on the other threads until it does.
+
+import (
+	"C" // dummy import so that scheduler_cores_tinygowasm.c works
+	"internal/task"
+	"runtime/volatile"
+	"sync/atomic"
+)
+
+// Maximum number of threads that run goroutines, including the first thread.
+// WebAssembly has no way to find out how many cores there are, so this is a
+// fixed number. Fewer threads are started when the GOMAXPROCS environment
+// variable is set to a lower number, see startSecondaryCores.
+const numCPU = 4
+
+// The lock used by lockCores.
+var coreLock uint32
+
+func coreLockTryAcquire() bool {
+	return atomic.CompareAndSwapUint32(&coreLock, 0, 1)
+}
+
+func coreLockRelease() {
+	atomic.StoreUint32(&coreLock, 0)
+}
+
+// currentCPU returns the number of the current thread, which is stored in a
+// thread-local variable. It is implemented in scheduler_cores_tinygowasm.c.
+//
+//export tinygo_currentCPU
+func currentCPU() uint32
+
+// The highest address of the system stack of all threads but the first, which
+// is stored when the thread starts.
+var coreStackTops [numCPU]uintptr
+
+// coreStackTop returns the highest address of the system stack of a thread.
+func coreStackTop(core uint32) uintptr {
+	if core == 0 {
+		return stackTop
+	}
+	return coreStackTops[core]
+}
+
+var (
+	coreEvents     uint32         // incremented by wakeCores
+	coreEventsSeen [numCPU]uint32 // value of coreEvents when waitForEvents last returned on a thread
+)
+
+// wakeCores wakes up threads waiting in waitForEvents.
+func wakeCores() {
+	atomic.AddUint32(&coreEvents, 1)
+	wasm_memory_atomic_notify(&coreEvents, ^uint32(0))
+}
+
+// signalCore asks a thread to stop for the GC. This only wakes it up if it is
+// waiting for events; otherwise it stops when it tries to enter a critical
+// section.
+func signalCore(core uint32) {
+	wakeCores()
+}
+
+// waitForEvents waits until wakeCores is called. Like the WFE instruction on
+// ARM, it returns right away if wakeCores was called since it last returned on
+// this thread.
+func waitForEvents() {
+	waitForEventsTimeout(-1)
+}
+
+// waitForEventsTimeout is like waitForEvents, but it also returns after the
+// given number of nanoseconds. With a negative timeout, it waits forever.
+func waitForEventsTimeout(timeout int64) {
+	core := currentCPU()
+	wasm_memory_atomic_wait32(&coreEvents, coreEventsSeen[core], timeout)
+	coreEventsSeen[core] = atomic.LoadUint32(&coreEvents)
+}
+
+// wasm_memory_atomic_wait32 waits until the value at ptr is not expected, until
+// it is woken up by wasm_memory_atomic_notify, or until the timeout (in
+// nanoseconds, or negative to wait forever) has passed.
+//
+//export llvm.wasm.memory.atomic.wait32
+func wasm_memory_atomic_wait32(ptr *uint32, expected uint32, timeout int64) int32
+
+// wasm_memory_atomic_notify wakes up at most count threads waiting on ptr.
+//
+//export llvm.wasm.memory.atomic.notify
+func wasm_memory_atomic_notify(ptr *uint32, count uint32) uint32
+
+// gcPauseCore waits in gcPausedCore until the GC running on another thread
+// has finished. Unlike on a chip, there are no registers to push to the stack:
+// pointers in local variables are stored in the stack chain when a function is
+// called (see gc_stack_portable.go).
+func gcPauseCore() {
+	gcPausedCore(getCurrentStackPointer())
+}
+
+// getSystemStackPointer returns the lowest address of the system stack of the
+// current thread that must be scanned by the GC. While a goroutine is running,
+// the system stack is only used by the scheduler, which doesn't keep pointers
+// there that can't be found elsewhere (see markStack in gc_stack_portable.go),
+// so nothing needs to be scanned.
+func getSystemStackPointer() uintptr {
+	if task.OnSystemStack() {
+		return getCurrentStackPointer()
+	}
+	return coreStackTop(currentCPU())
+}
+
+// markCoreStacks marks the system stacks of all threads. The other threads
+// have been stopped by gcStopOtherCores. Goroutine stacks are heap allocations,
+// which are found from allTasks and the current task of every thread.
+func markCoreStacks() {
+	self := currentCPU()
+	if task.OnSystemStack() {
+		markRoots(getCurrentStackPointer(), coreStackTop(self))
+	}
+	for i := uint32(0); i < numCPU; i++ {
+		if i == self || volatile.LoadUint8(&coreRunning[i]) == 0 {
+			continue
+		}
+		if sp := corePausedSP[i]; sp < coreStackTop(i) {
+			markRoots(sp, coreStackTop(i))
+		}
+	}
+}
+
+// startSecondaryCores starts a thread for every core but the first, or as many
+// as the GOMAXPROCS environment variable allows. If the host doesn't allow
+// starting that many threads (or none at all), goroutines run on the threads
+// that could be started.
+func startSecondaryCores() {
+	threads := uint32(numCPU)
+	if n := maxThreads(); n != 0 && n < threads {
+		threads = n
+	}
+	for core := uint32(1); core < threads; core++ {
+		if startThread(core) != 0 {
+			break
+		}
+	}
+}
+
+// maxThreads returns the value of the GOMAXPROCS environment variable, or 0
+// if it isn't set to a positive number. It is implemented in
+// scheduler_cores_tinygowasm.c.
+//
+//export tinygo_maxThreads
+func maxThreads() uint32
+
+// startThread starts a thread that calls runThread. It returns 0 on success, or
+// an error number. It is implemented in scheduler_cores_tinygowasm.c.
+//
+//export tinygo_startThread
+func startThread(core uint32) int32
+
+// runThread is the first Go function that runs on a new thread.
+//
+//export tinygo_runThread
+func runThread(core uint32) {
+	coreStackTops[core] = getCurrentStackPointer()
+	runSecondaryCore()
+}
@@ -7,7 +7,19 @@ func sleep(duration int64) {
 	if duration <= 0 {
 		return
 	}
+	if duration < shortSleepLimit && sleepShort(duration) {
+		return
+	}
 
+	if baremetal && sleepHook != nil {
+		// The sleep hook may return early, so call it until the deadline has
+		// been reached.
+		deadline := ticks() + nanosecondsToTicks(duration)
+		for now := ticks(); now < deadline; now = ticks() {
+			idleSleep(deadline - now)
+		}
+		return
+	}
 	sleepTicks(nanosecondsToTicks(duration))
 }
 
@@ -21,8 +33,16 @@ func getSystemStackPointer() uintptr {
 // With the "none" scheduler, init and the main function are invoked directly.
 func run() {
 	initHeap()
+	initRand()
 	initAll()
 	callMain()
 }
 
 const hasScheduler = false
+
+// startFinalizerGoroutine does nothing, as goroutines can't be started.
+// Finalizers are run by runtime.GC instead.
+func startFinalizerGoroutine(loop func()) {}
+
+// dumpGoroutines does nothing, as there is only one goroutine.
+func dumpGoroutines() {}
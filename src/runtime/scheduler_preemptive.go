@@ -0,0 +1,94 @@
+//go:build tinygo.autoyield
+
+package runtime
+
+// This file implements preemption at safepoints for the tasks and cores
+// schedulers with -autoyield. The compiler inserts a call to safepoint in every
+// loop outside of the runtime (see transform.InsertSafepoints), except in
+// functions marked //go:noyield. Every so often, a safepoint checks how long
+// the current goroutine has been running according to the system timer, and
+// switches to another goroutine when it has used up its time slice.
+//
+// This is not fully preemptive scheduling: goroutines are never switched from
+// the timer interrupt itself, because the runtime (the heap, the run queue,
+// channels) isn't safe against a goroutine switch at any instruction. So the
+// runtime itself and code that is called from it never gets interrupted by
+// another goroutine, but a loop without safepoints (in a //go:noyield
+// function, the runtime, C code or assembly) is never preempted either.
+//
+// A goroutine is not preempted while it has interrupts disabled or (with the
+// cores scheduler) holds the lock taken by interrupt.Disable, so a long running
+// loop inside a critical section is not preempted either.
+
+import (
+	"internal/task"
+	"runtime/interrupt"
+)
+
+const (
+	// Number of safepoints between two checks of the time. Reading the
+	// current time is relatively expensive on most chips.
+	preemptCheckInterval = 1024
+
+	// Time a goroutine may run before it is preempted, in nanoseconds.
+	preemptTimeSlice = 10_000_000 // 10ms
+)
+
+// Preemption state of a single core. With the cores scheduler, every core
+// runs its own goroutine and therefore has its own time slice.
+type preemptState struct {
+	count uint32   // safepoints since the last check of the time
+	start timeUnit // time the current goroutine was resumed
+}
+
+var preemptStates [numCPU]preemptState
+
+// safepoint is called in every loop of a Go program. It must be very small, so
+// that it is inlined in every loop.
+func safepoint() {
+	state := &preemptStates[currentCPU()]
+	state.count++
+	if state.count == preemptCheckInterval {
+		preemptCheck()
+	}
+}
+
+// preemptCheck switches to another goroutine if the current goroutine has used
+// up its time slice.
+//
+//go:noinline
+func preemptCheck() {
+	state := &preemptStates[currentCPU()]
+	state.count = 0
+	// This is also a good place to check for stack overflows, well before
+	// the goroutine blocks.
+	task.CheckStack()
+	if interrupt.In() || task.OnSystemStack() {
+		// Only goroutines can be preempted.
+		return
+	}
+	if interruptsDisabled() || coreLockHeld() {
+		// Don't switch goroutines inside a critical section. The next
+		// goroutine would run with interrupts disabled, and with the cores
+		// scheduler this goroutine might be resumed on another core while
+		// this core still holds the lock.
+		return
+	}
+	if ticks()-state.start < nanosecondsToTicks(preemptTimeSlice) {
+		return
+	}
+	scheduleLogTask("  preempt:", task.Current())
+	Gosched()
+}
+
+// preemptResumeTask is called by the scheduler right before a goroutine is
+// resumed, to start a new time slice.
+func preemptResumeTask() {
+	preemptStates[currentCPU()].start = ticks()
+}
+
+// interruptsDisabled returns whether interrupts are disabled on the current
+// core.
+//
+//go:linkname interruptsDisabled runtime/interrupt.disabled
+func interruptsDisabled() bool
@@ -0,0 +1,7 @@
+//go:build !tinygo.autoyield
+
+package runtime
+
+// Stub for when there are no safepoints, see scheduler_preemptive.go.
+
+func preemptResumeTask() {}
@@ -0,0 +1,78 @@
+//go:build cortexm && (nrf52 || nrf52833 || nrf52840 || atsamd51 || atsame5x)
+
+package runtime
+
+// These chips keep time using a 32768Hz RTC, which keeps running while the
+// chip sleeps but only has a resolution of about 30µs. Short sleeps are
+// therefore done by spinning on the DWT cycle counter instead, which counts
+// processor clock cycles. Longer sleeps still use the RTC, so that the chip
+// can sleep and other goroutines can run in the meantime.
+
+import "device/arm"
+
+// Sleeps shorter than this many nanoseconds (about 8 RTC ticks) spin on the
+// cycle counter.
+const shortSleepLimit = 250_000
+
+var (
+	cyclesPerSecond    uint64 // measured processor clock frequency, or 0 if not yet known
+	cycleCounterBroken bool   // whether the cycle counter turned out to be unusable
+)
+
+// sleepShort sleeps for the given number of nanoseconds by spinning on the
+// cycle counter, without letting other goroutines run. It returns false if the
+// cycle counter can't be used, in which case the caller should use the RTC.
+//
+// The first call measures the clock frequency, so it takes a few hundred
+// microseconds longer.
+func sleepShort(duration int64) bool {
+	if cyclesPerSecond == 0 {
+		if cycleCounterBroken || !calibrateCycleCounter() {
+			cycleCounterBroken = true
+			return false
+		}
+	}
+	cycles := uint32(uint64(duration) * cyclesPerSecond / 1e9)
+	start := arm.DWT.CYCCNT.Get()
+	for arm.DWT.CYCCNT.Get()-start < cycles {
+	}
+	return true
+}
+
+// calibrateCycleCounter enables the cycle counter and measures the processor
+// clock frequency against the RTC, as the runtime doesn't know the clock
+// frequency of every chip.
+func calibrateCycleCounter() bool {
+	if arm.DWT.CTRL.HasBits(arm.DWT_CTRL_NOCYCCNT) {
+		return false
+	}
+	arm.DEMCR.SetBits(arm.DEMCR_TRCENA)
+	arm.DWT.CTRL.SetBits(arm.DWT_CTRL_CYCCNTENA)
+
+	// Count the cycles between two RTC tick edges, so that the partial tick
+	// at the start isn't included.
+	startTicks, startCycles, ok := waitTickEdge(ticks())
+	if !ok {
+		return false
+	}
+	endTicks, endCycles, ok := waitTickEdge(startTicks + 7)
+	if !ok {
+		return false
+	}
+	cycles := uint64(endCycles - startCycles)
+	cyclesPerSecond = cycles * 1e9 / uint64(ticksToNanoseconds(endTicks-startTicks))
+	return cyclesPerSecond != 0
+}
+
+// waitTickEdge waits until ticks() is past the given value, and returns the
+// new value together with the cycle counter right before it changed. It
+// returns false if the RTC doesn't seem to be running (as in some emulators).
+func waitTickEdge(after timeUnit) (timeUnit, uint32, bool) {
+	for i := 0; i < 1000000; i++ {
+		cycles := arm.DWT.CYCCNT.Get()
+		if now := ticks(); now > after {
+			return now, cycles, true
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,12 @@
+//go:build !(cortexm && (nrf52 || nrf52833 || nrf52840 || atsamd51 || atsame5x))
+
+package runtime
+
+// The clock used for sleeping is precise enough on this target, or there is
+// no faster clock that the runtime knows about, see sleep_cyccnt.go.
+
+const shortSleepLimit = 0
+
+func sleepShort(duration int64) bool {
+	return false
+}
@@ -27,7 +27,13 @@ func sliceAppend(srcBuf, elemsBuf unsafe.Pointer, srcLen, srcCap, elemsLen uintp
 			// programs).
 			srcCap *= 2
 		}
-		buf := alloc(srcCap*elemSize, nil)
+		var layout unsafe.Pointer
+		if srcLen != 0 {
+			// The new buffer holds the same kind of elements as the old
+			// buffer, so it can usually use the same object layout.
+			layout = heapLayout(srcBuf, elemSize)
+		}
+		buf := alloc(srcCap*elemSize, layout)
 
 		// Copy the old slice to the new slice.
 		if srcLen != 0 {
@@ -72,7 +78,13 @@ func sliceGrow(oldBuf unsafe.Pointer, oldLen, oldCap, newCap, elemSize uintptr)
 		oldCap *= 2
 	}
 
-	buf := alloc(oldCap*elemSize, nil)
+	var layout unsafe.Pointer
+	if oldLen > 0 {
+		// Reuse the object layout of the old buffer if possible, like
+		// sliceAppend.
+		layout = heapLayout(oldBuf, elemSize)
+	}
+	buf := alloc(oldCap*elemSize, layout)
 	if oldLen > 0 {
 		// copy any data to new slice
 		memmove(buf, oldBuf, oldLen*elemSize)
@@ -1,22 +1,58 @@
 package runtime
 
+// A Func represents a Go function in the running binary.
 type Func struct {
+	name  string
+	entry uintptr
 }
 
+// FuncForPC returns a *Func describing the function that contains the given
+// program counter address, or else nil. It only returns a function when
+// building with -linetable.
 func FuncForPC(pc uintptr) *Func {
-	return nil
+	name, entry, ok := lineTableFunction(pc)
+	if !ok {
+		return nil
+	}
+	return &Func{name: name, entry: entry}
 }
 
+// Name returns the name of the function.
 func (f *Func) Name() string {
-	return ""
+	if f == nil {
+		return ""
+	}
+	return f.name
 }
 
+// Entry returns the entry address of the function.
+func (f *Func) Entry() uintptr {
+	if f == nil {
+		return 0
+	}
+	return f.entry
+}
+
+// FileLine returns the file name and line number of the source code
+// corresponding to the program counter pc.
 func (f *Func) FileLine(pc uintptr) (file string, line int) {
-	return "", 0
+	return lineTableLine(pc)
 }
 
+// Caller reports file and line number information about function invocations
+// on the calling goroutine's stack. The argument skip is the number of stack
+// frames to ascend, with 0 identifying the caller of Caller. The information is
+// only available when building with -linetable, otherwise ok is false.
+//
+//go:noinline
 func Caller(skip int) (pc uintptr, file string, line int, ok bool) {
-	return 0, "", 0, false
+	var pcs [1]uintptr
+	if callers(skip+2, pcs[:]) == 0 {
+		return 0, "", 0, false
+	}
+	pc = pcs[0]
+	file, line = lineTableLine(pc - 1)
+	return pc, file, line, true
 }
 
 func Stack(buf []byte, all bool) int {
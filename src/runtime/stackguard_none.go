@@ -0,0 +1,26 @@
+//go:build !(cortexm && scheduler.tasks && !mimxrt1062 && !nxpmk66f18 && !softdevice && !tinygo.rtos)
+
+package runtime
+
+// There is no hardware stack guard on this target, see
+// runtime_cortexm_stackguard.go. Stack overflows are only detected using the
+// stack canary.
+
+import "internal/task"
+
+// EnableStackGuard makes the runtime use the MPU to detect goroutine stack
+// overflows as soon as they happen. It is not supported on this target, so it
+// always returns false.
+func EnableStackGuard() bool {
+	return false
+}
+
+func stackGuardSet(t *task.Task) {}
+
+func stackGuardSuspend() bool {
+	return false
+}
+
+func stackGuardRestore(active bool) {}
+
+func stackGuardFault(addr uintptr, addrValid, stacking bool, sp uintptr) {}
@@ -1,21 +1,49 @@
 package runtime
 
+// Frames may be used to get function/file/line information for a slice of PC
+// values returned by Callers.
 type Frames struct {
-	//
+	callers []uintptr
 }
 
+// Frame is the information returned by Frames for each call frame.
 type Frame struct {
+	// PC is the program counter for the location in this frame.
+	PC uintptr
+
+	// Function is the package path-qualified function name of this call
+	// frame.
 	Function string
 
+	// File and Line are the file name and line number of the location in
+	// this frame.
 	File string
 	Line int
-	PC   uintptr
+
+	// Entry point program counter for the function.
+	Entry uintptr
 }
 
+// CallersFrames takes a slice of PCs returned by Callers and prepares to
+// return function/file/line information. Do not change the slice until you are
+// done with the Frames.
+//
+// The information is only available when building with -linetable.
 func CallersFrames(callers []uintptr) *Frames {
-	return nil
+	return &Frames{callers: callers}
 }
 
+// Next returns a Frame representing the next call frame in the slice of PC
+// values, and whether there are more frames after it.
 func (ci *Frames) Next() (frame Frame, more bool) {
-	return Frame{}, false
+	if ci == nil || len(ci.callers) == 0 {
+		return Frame{}, false
+	}
+	frame.PC = ci.callers[0]
+	ci.callers = ci.callers[1:]
+
+	// The PC is a return address, so look up the call instruction before it.
+	frame.Function, frame.Entry, _ = lineTableFunction(frame.PC - 1)
+	frame.File, frame.Line = lineTableLine(frame.PC - 1)
+	return frame, len(ci.callers) != 0
 }
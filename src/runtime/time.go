@@ -1,8 +1,11 @@
 package runtime
 
-// timerNode is an element in a linked list of timers.
+import "runtime/interrupt"
+
+// timerNode is an element in the timer queue. A timer keeps the same node
+// while it is pending, including when it is reset or when it is a ticker that
+// fires repeatedly.
 type timerNode struct {
-	next     *timerNode
 	timer    *timer
 	callback func(*timerNode)
 }
@@ -12,14 +15,47 @@ func (t *timerNode) whenTicks() timeUnit {
 	return nanosecondsToTicks(t.timer.when)
 }
 
+// The value of timer.when for timers that would otherwise overflow.
+const maxWhen = 1<<63 - 1
+
+// timerQueue is a 4-ary min-heap of timer nodes, ordered by when they expire,
+// like the timer heap in the upstream Go runtime. This makes adding and
+// removing a timer O(log n) instead of O(n) with many active timers.
+//
+// The timer.pp field (unused otherwise) holds the position of a timer in the
+// queue plus one, or one of the values below. It is not a pointer to the node,
+// because the time package declares it as a uintptr so a precise GC wouldn't
+// see the pointer.
+var timerQueue []*timerNode
+
+const (
+	timerStopped puintptr = 0            // not started, or stopped
+	timerFiring  puintptr = ^puintptr(0) // removed from the queue, callback about to run
+)
+
+// timerIndex returns the position of the given timer in the timer queue, or -1
+// if it isn't in the queue.
+func timerIndex(tim *timer) int {
+	if tim.pp == timerStopped || tim.pp == timerFiring {
+		return -1
+	}
+	return int(tim.pp) - 1
+}
+
 // Defined in the time package, implemented here in the runtime.
 //
 //go:linkname startTimer time.startTimer
 func startTimer(tim *timer) {
-	addTimer(&timerNode{
+	if tim.when < 0 {
+		tim.when = maxWhen
+	}
+	mask := interrupt.Disable()
+	tn := &timerNode{
 		timer:    tim,
 		callback: timerCallback,
-	})
+	}
+	addTimer(tn)
+	interrupt.Restore(mask)
 	scheduleLog("adding timer")
 }
 
@@ -33,16 +69,30 @@ func startTimer(tim *timer) {
 // If timerQueue doesn't get optimized away, small programs (that don't call
 // time.NewTimer etc) would still pay the cost of these timers.
 func timerCallback(tn *timerNode) {
-	// Run timer function (implemented in the time package).
-	// The seq parameter to the f function is not used in the time
-	// package so is left zero.
-	tn.timer.f(tn.timer.arg, 0)
-
-	// If this is a periodic timer (a ticker), re-add it to the queue.
-	if tn.timer.period != 0 {
-		tn.timer.when += tn.timer.period
-		addTimer(tn)
+	tim := tn.timer
+	mask := interrupt.Disable()
+	// The timer may have been stopped or reset (with -scheduler=cores, from
+	// another core) since it was removed from the queue. The callback still
+	// runs in that case, like in upstream Go.
+	if tim.pp == timerFiring {
+		if tim.period != 0 {
+			// This is a periodic timer (a ticker). Re-add it to the queue
+			// before running the callback, skipping the ticks that were
+			// missed if it fired late.
+			delta := tim.when - nanotime()
+			tim.when += tim.period * (1 + -delta/tim.period)
+			if tim.when < 0 {
+				tim.when = maxWhen
+			}
+			addTimer(tn)
+		} else {
+			tim.pp = timerStopped
+		}
 	}
+	interrupt.Restore(mask)
+
+	// Run timer function (implemented in the time package).
+	tim.f(tim.arg, tim.seq)
 }
 
 //go:linkname stopTimer time.stopTimer
@@ -52,8 +102,139 @@ func stopTimer(tim *timer) bool {
 
 //go:linkname resetTimer time.resetTimer
 func resetTimer(tim *timer, when int64) bool {
+	return modTimer(tim, when, tim.period, tim.f, tim.arg, tim.seq)
+}
+
+// modTimer changes all fields of a timer and (re)starts it. It returns whether
+// the timer was still in the queue. It is used by time.Ticker.Reset.
+//
+//go:linkname modTimer time.modTimer
+func modTimer(tim *timer, when, period int64, f func(any, uintptr), arg any, seq uintptr) bool {
+	if when < 0 {
+		when = maxWhen
+	}
+	mask := interrupt.Disable()
+	var tn *timerNode
+	i := timerIndex(tim)
+	pending := i >= 0
+	if pending {
+		tn = timerQueue[i]
+		timerQueueRemove(i)
+	}
 	tim.when = when
-	removed := removeTimer(tim)
-	startTimer(tim)
-	return removed
+	tim.period = period
+	tim.f = f
+	tim.arg = arg
+	tim.seq = seq
+	if tn == nil {
+		tn = &timerNode{
+			timer:    tim,
+			callback: timerCallback,
+		}
+	}
+	addTimer(tn)
+	interrupt.Restore(mask)
+	return pending
+}
+
+// addTimer adds the given timer node to the timer queue. It must not be in the
+// queue already.
+func addTimer(tn *timerNode) {
+	mask := interrupt.Disable()
+	timerQueue = append(timerQueue, tn)
+	timerQueueSiftUp(len(timerQueue) - 1)
+	interrupt.Restore(mask)
+	wakeCores()
+}
+
+// removeTimer is the implementation of time.stopTimer. It removes a timer from
+// the timer queue, returning true if the timer is present in the timer queue.
+func removeTimer(tim *timer) bool {
+	mask := interrupt.Disable()
+	i := timerIndex(tim)
+	removedTimer := i >= 0
+	if removedTimer {
+		scheduleLog("removed timer")
+		timerQueueRemove(i)
+	} else {
+		scheduleLog("did not remove timer")
+	}
+	tim.pp = timerStopped
+	interrupt.Restore(mask)
+	return removedTimer
+}
+
+// timerQueuePop removes the first timer node from the timer queue, so that the
+// scheduler can run its callback.
+func timerQueuePop() *timerNode {
+	mask := interrupt.Disable()
+	tn := timerQueue[0]
+	timerQueueRemove(0)
+	tn.timer.pp = timerFiring
+	interrupt.Restore(mask)
+	return tn
+}
+
+// timerQueueRemove removes the timer node at index i from the timer queue.
+func timerQueueRemove(i int) {
+	tn := timerQueue[i]
+	last := len(timerQueue) - 1
+	moved := timerQueue[last]
+	timerQueue[last] = nil
+	timerQueue = timerQueue[:last]
+	if i != last {
+		// Move the last node into the hole. It may need to go either up or
+		// down from there.
+		timerQueue[i] = moved
+		timerQueueSiftUp(i)
+		timerQueueSiftDown(timerIndex(moved.timer))
+	}
+	tn.timer.pp = timerStopped
+}
+
+// timerQueueSiftUp moves the node at index i up in the heap until its parent
+// doesn't expire later.
+func timerQueueSiftUp(i int) {
+	tn := timerQueue[i]
+	when := tn.timer.when
+	for i > 0 {
+		p := (i - 1) / 4 // parent
+		if when >= timerQueue[p].timer.when {
+			break
+		}
+		timerQueue[i] = timerQueue[p]
+		timerQueue[i].timer.pp = puintptr(i + 1)
+		i = p
+	}
+	timerQueue[i] = tn
+	tn.timer.pp = puintptr(i + 1)
+}
+
+// timerQueueSiftDown moves the node at index i down in the heap until none of
+// its children expire earlier.
+func timerQueueSiftDown(i int) {
+	n := len(timerQueue)
+	tn := timerQueue[i]
+	when := tn.timer.when
+	for {
+		// Find the child that expires first.
+		c := i*4 + 1
+		if c >= n {
+			break
+		}
+		first := c
+		for j := c + 1; j < c+4 && j < n; j++ {
+			if timerQueue[j].timer.when < timerQueue[first].timer.when {
+				first = j
+			}
+		}
+		if when <= timerQueue[first].timer.when {
+			break
+		}
+		timerQueue[i] = timerQueue[first]
+		timerQueue[i].timer.pp = puintptr(i + 1)
+		i = first
+	}
+	timerQueue[i] = tn
+	tn.timer.pp = puintptr(i + 1)
 }
@@ -83,7 +83,7 @@ func tick() {
 }
 
 // ticks are in microseconds
-func ticks() timeUnit {
+func hardwareTicks() timeUnit {
 	mask := arm.DisableInterrupts()
 	current := nxp.SysTick.CVR.Get()        // current value of the systick counter
 	count := millisSinceBoot()              // number of milliseconds since boot
@@ -106,7 +106,7 @@ func ticks() timeUnit {
 
 // sleepTicks spins for a number of microseconds
 func sleepTicks(duration timeUnit) {
-	now := ticks()
+	now := hardwareTicks()
 	end := duration + now
 	cyclesPerMicro := machine.ClockFrequency() / 1000000
 
@@ -127,7 +127,7 @@ func sleepTicks(duration timeUnit) {
 			return
 		}
 
-		now = ticks()
+		now = hardwareTicks()
 	}
 }
 
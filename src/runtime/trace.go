@@ -0,0 +1,187 @@
+package runtime
+
+// This file implements the runtime side of execution tracing. While tracing
+// is on, the scheduler, channels and the GC record scheduling events in a ring
+// buffer. The runtime/trace package reads the events from this buffer and
+// converts them to the trace format understood by `go tool trace`.
+
+import (
+	"internal/task"
+	"runtime/interrupt"
+	"unsafe"
+)
+
+// Event types. They have the same values as the equivalent events in the
+// trace format, but the arguments are different: see traceRecord.
+const (
+	traceEvGCStart       = 7
+	traceEvGCDone        = 8
+	traceEvGoCreate      = 13
+	traceEvGoStart       = 14
+	traceEvGoEnd         = 15
+	traceEvGoStop        = 16
+	traceEvGoSched       = 17
+	traceEvGoSleep       = 19
+	traceEvGoUnblock     = 21
+	traceEvGoBlockSend   = 22
+	traceEvGoBlockRecv   = 23
+	traceEvGoBlockSelect = 24
+	traceEvGoBlockSync   = 25
+	traceEvGoBlockCond   = 26
+	traceEvGoWaiting     = 31
+	traceEvHeapAlloc     = 33
+)
+
+// traceRecord is a single buffered event. The runtime/trace package has an
+// identical struct.
+type traceRecord struct {
+	ts  int64          // nanotime() when the event happened
+	arg uint64         // heap size for traceEvHeapAlloc
+	g   unsafe.Pointer // goroutine the event is about, if any
+	typ uint8
+}
+
+var (
+	traceEnabled bool
+	traceBuf     []traceRecord // ring buffer, allocated when tracing starts
+	traceHead    uint32        // number of written events
+	traceTail    uint32        // number of read events
+	traceLost    uint32        // number of events dropped because the buffer was full
+	traceYield   bool          // set by Gosched, so that the scheduler can tell it apart from an exit
+)
+
+// traceEvent records a single event. It may be called from an interrupt, so
+// it must not allocate or block.
+func traceEvent(typ uint8, t *task.Task, arg uint64) {
+	mask := interrupt.Disable()
+	if traceEnabled {
+		if traceHead-traceTail >= uint32(len(traceBuf)) {
+			// The buffer is full, runtime/trace didn't keep up.
+			traceLost++
+		} else {
+			traceBuf[traceHead%uint32(len(traceBuf))] = traceRecord{
+				ts:  nanotime(),
+				arg: arg,
+				g:   unsafe.Pointer(t),
+				typ: typ,
+			}
+			traceHead++
+		}
+	}
+	interrupt.Restore(mask)
+}
+
+// traceGoCreate is called by the internal/task package when a new goroutine
+// is started.
+func traceGoCreate(t *task.Task) {
+	if traceEnabled {
+		traceEvent(traceEvGoCreate, t, 0)
+	}
+}
+
+// traceGoStop records why the goroutine t returned to the scheduler: it
+// blocked, yielded or exited.
+func traceGoStop(t *task.Task) {
+	if !traceEnabled {
+		traceYield = false
+		return
+	}
+	var typ uint8
+	switch t.WaitReason {
+	case task.WaitNone:
+		typ = traceEvGoEnd
+		if traceYield {
+			typ = traceEvGoSched
+		}
+	case task.WaitChanSend:
+		typ = traceEvGoBlockSend
+	case task.WaitChanReceive:
+		typ = traceEvGoBlockRecv
+	case task.WaitSelect:
+		typ = traceEvGoBlockSelect
+	case task.WaitForever:
+		typ = traceEvGoStop
+	case task.WaitSleep:
+		typ = traceEvGoSleep
+	case task.WaitCond, task.WaitSyncCond:
+		typ = traceEvGoBlockCond
+	default:
+		typ = traceEvGoBlockSync
+	}
+	traceYield = false
+	traceEvent(typ, t, 0)
+}
+
+// traceGCStart and traceGCDone are called at the start and end of a GC cycle.
+func traceGCStart() {
+	if traceEnabled {
+		traceEvent(traceEvGCStart, nil, 0)
+	}
+}
+
+func traceGCDone(heapAlloc uintptr) {
+	if traceEnabled {
+		traceEvent(traceEvGCDone, nil, 0)
+		traceEvent(traceEvHeapAlloc, nil, uint64(heapAlloc))
+	}
+}
+
+// trace_start turns on tracing and records the goroutines that already
+// exist.
+//
+//go:linkname trace_start runtime/trace.startTrace
+func trace_start() {
+	if traceBuf == nil {
+		// Microcontrollers have little RAM to spare.
+		size := 4096
+		if baremetal {
+			size = 256
+		}
+		traceBuf = make([]traceRecord, size)
+	}
+	traceHead = 0
+	traceTail = 0
+	traceLost = 0
+	traceEnabled = true
+
+	current := task.Current()
+	task.ForEach(func(t *task.Task) {
+		traceEvent(traceEvGoCreate, t, 0)
+		if t != current && t.WaitReason != task.WaitNone {
+			traceEvent(traceEvGoWaiting, t, 0)
+		}
+	})
+	traceEvent(traceEvGoStart, current, 0)
+}
+
+// trace_stop turns off tracing.
+//
+//go:linkname trace_stop runtime/trace.stopTrace
+func trace_stop() {
+	traceEnabled = false
+}
+
+// trace_readEvents copies the buffered events into buf and returns the number
+// of copied events and the number of events that were dropped since tracing
+// started.
+//
+//go:linkname trace_readEvents runtime/trace.readEvents
+func trace_readEvents(buf []traceRecord) (n int, lost uint32) {
+	mask := interrupt.Disable()
+	for traceTail != traceHead && n < len(buf) {
+		buf[n] = traceBuf[traceTail%uint32(len(traceBuf))]
+		n++
+		traceTail++
+	}
+	lost = traceLost
+	interrupt.Restore(mask)
+	return n, lost
+}
+
+// trace_hasScheduler returns whether goroutines can be started, so that
+// runtime/trace can read events in the background.
+//
+//go:linkname trace_hasScheduler runtime/trace.hasScheduler
+func trace_hasScheduler() bool {
+	return hasScheduler
+}
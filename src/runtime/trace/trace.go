@@ -1,13 +1,358 @@
-// Stubs for the runtime/trace package
+// Package trace contains facilities for programs to generate traces for the
+// Go execution tracer.
+//
+// TinyGo records a subset of the events of the Go runtime: goroutine
+// creation, scheduling and blocking (on channels, select, sleep and the sync
+// package), and GC cycles together with the heap size after each cycle. The
+// trace doesn't contain stack traces, and user annotations (tasks, regions and
+// logs) are not supported. The trace can be viewed with `go tool trace`.
+//
+// The runtime can only buffer a limited number of events. If the program
+// generates events faster than they can be written out, events are dropped
+// and the trace may be incomplete. The number of dropped events is then logged
+// in the trace itself, as a log message in the "runtime/trace" category that
+// `go tool trace` shows with the other user logs.
 package trace
 
 import (
 	"errors"
 	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unsafe"
 )
 
+// Event types of the trace format (version 1.21), see
+// src/internal/trace/internal/tracev1/parser.go in the Go source tree.
+const (
+	evBatch          = 1  // [pid, timestamp]
+	evFrequency      = 2  // [ticks per second]
+	evGomaxprocs     = 4  // [timestamp, GOMAXPROCS, stack id]
+	evProcStart      = 5  // [timestamp, thread id]
+	evGCStart        = 7  // [timestamp, seq, stack id]
+	evGCDone         = 8  // [timestamp]
+	evGoCreate       = 13 // [timestamp, new goroutine id, new stack id, stack id]
+	evGoStart        = 14 // [timestamp, goroutine id, seq]
+	evGoEnd          = 15 // [timestamp]
+	evGoStop         = 16 // [timestamp, stack]
+	evGoSched        = 17 // [timestamp, stack]
+	evGoSleep        = 19 // [timestamp, stack]
+	evGoUnblock      = 21 // [timestamp, goroutine id, seq, stack]
+	evGoBlockSend    = 22 // [timestamp, stack]
+	evGoBlockRecv    = 23 // [timestamp, stack]
+	evGoBlockSelect  = 24 // [timestamp, stack]
+	evGoBlockSync    = 25 // [timestamp, stack]
+	evGoBlockCond    = 26 // [timestamp, stack]
+	evGoWaiting      = 31 // [timestamp, goroutine id]
+	evHeapAlloc      = 33 // [timestamp, heap_alloc]
+	evString         = 37 // [string id, value string]
+	evGoStartLocal   = 38 // [timestamp, goroutine id]
+	evGoUnblockLocal = 39 // [timestamp, goroutine id, stack]
+	evUserLog        = 48 // [timestamp, task id, key string id, stack, value string]
+)
+
+// event is an event as buffered by the runtime. It must match
+// runtime.traceRecord.
+type event struct {
+	ts  int64
+	arg uint64
+	g   unsafe.Pointer
+	typ uint8
+}
+
+func startTrace() // implemented in package runtime
+
+func stopTrace() // implemented in package runtime
+
+func readEvents(buf []event) (n int, lost uint32) // implemented in package runtime
+
+func hasScheduler() bool // implemented in package runtime
+
+type goroutineState uint8
+
+const (
+	goroutineRunnable goroutineState = iota
+	goroutineRunning
+	goroutineWaiting
+)
+
+type goroutine struct {
+	id    uint64
+	state goroutineState
+}
+
+var tracing struct {
+	sync.Mutex
+	enabled    bool
+	w          io.Writer
+	err        error  // first error returned by w
+	buf        []byte // encoded events that haven't been written yet
+	lastTs     int64  // timestamp of the previous event in the batch
+	started    bool   // whether the first batch has been written
+	goroutines map[unsafe.Pointer]*goroutine
+	running    *goroutine // goroutine running on the (only) P, or nil
+	nextID     uint64
+	gcRunning  bool
+	gcSeq      uint64
+	session    int // incremented for every trace
+}
+
+// Start enables tracing for the current program. While tracing, the trace
+// will be buffered and written to w. Start returns an error if tracing is
+// already enabled.
 func Start(w io.Writer) error {
-	return errors.New("not implemented")
+	tracing.Lock()
+	defer tracing.Unlock()
+	if tracing.enabled {
+		return errors.New("tracing is already enabled")
+	}
+	tracing.enabled = true
+	tracing.w = w
+	tracing.err = nil
+	tracing.started = false
+	tracing.goroutines = make(map[unsafe.Pointer]*goroutine)
+	tracing.running = nil
+	tracing.nextID = 1 // goroutine 0 means "no goroutine"
+	tracing.gcRunning = false
+	tracing.gcSeq = 0
+	tracing.session++
+	startTrace()
+	tracing.buf = append(tracing.buf[:0], "go 1.21 trace\x00\x00\x00"...)
+	flushEvents()
+
+	if hasScheduler() {
+		// Read the events in the background, as the runtime can only buffer
+		// a limited number of them.
+		go readTrace(tracing.session)
+	}
+	return nil
+}
+
+// Stop stops the current tracing, if any. Stop only returns after all the
+// writes for the trace have completed.
+func Stop() {
+	tracing.Lock()
+	defer tracing.Unlock()
+	if !tracing.enabled {
+		return
+	}
+	stopTrace()
+	lost := flushEvents()
+	if lost != 0 && tracing.started {
+		// The trace format has no event for lost events, so log them like
+		// trace.Log would.
+		appendLog("runtime/trace", "dropped "+strconv.FormatUint(uint64(lost), 10)+" events")
+		writeEvents()
+	}
+	tracing.enabled = false
+	tracing.w = nil
+	tracing.goroutines = nil
+	tracing.running = nil
 }
 
-func Stop() {}
+// IsEnabled reports whether tracing is enabled.
+func IsEnabled() bool {
+	tracing.Lock()
+	defer tracing.Unlock()
+	return tracing.enabled
+}
+
+// readTrace periodically writes out the events buffered by the runtime, until
+// the given trace is stopped.
+func readTrace(session int) {
+	for {
+		time.Sleep(20 * time.Millisecond)
+		tracing.Lock()
+		if !tracing.enabled || tracing.session != session {
+			tracing.Unlock()
+			return
+		}
+		flushEvents()
+		tracing.Unlock()
+	}
+}
+
+// flushEvents converts all events buffered by the runtime to a new batch and
+// writes it out. It returns the number of events that the runtime had to drop.
+// It must be called with tracing locked.
+func flushEvents() uint32 {
+	var buf [32]event
+	first := true
+	for {
+		n, lost := readEvents(buf[:])
+		for i := range buf[:n] {
+			ev := &buf[i]
+			if first {
+				first = false
+				tracing.buf = append(tracing.buf, evBatch|1<<6)
+				tracing.buf = appendVarint(tracing.buf, 0)
+				tracing.buf = appendVarint(tracing.buf, uint64(ev.ts))
+				tracing.lastTs = ev.ts
+				if !tracing.started {
+					// Timestamps are in nanoseconds.
+					tracing.started = true
+					tracing.buf = append(tracing.buf, evFrequency)
+					tracing.buf = appendVarint(tracing.buf, uint64(time.Second))
+					appendEvent(evGomaxprocs, ev.ts, 1, 0)
+					appendEvent(evProcStart, ev.ts, 0)
+				}
+			}
+			convertEvent(ev)
+		}
+		if n < len(buf) {
+			writeEvents()
+			return lost
+		}
+	}
+}
+
+// writeEvents writes out the events encoded so far. It must be called with
+// tracing locked.
+func writeEvents() {
+	if tracing.err == nil && len(tracing.buf) != 0 {
+		_, tracing.err = tracing.w.Write(tracing.buf)
+	}
+	tracing.buf = tracing.buf[:0]
+}
+
+// convertEvent encodes a single runtime event. The runtime doesn't record all
+// the state transitions the trace format needs (for example, when an
+// interrupt wakes up a goroutine that is about to block), so they are added
+// here when needed.
+func convertEvent(ev *event) {
+	switch ev.typ {
+	case evGoCreate:
+		if tracing.goroutines[ev.g] == nil {
+			createGoroutine(ev)
+		}
+	case evGoWaiting:
+		g := tracing.goroutines[ev.g]
+		if g != nil && g.state == goroutineRunnable {
+			g.state = goroutineWaiting
+			appendEvent(evGoWaiting, ev.ts, g.id)
+		}
+	case evGoStart:
+		if ev.g == nil {
+			// Started tracing from the scheduler.
+			return
+		}
+		if tracing.running != nil {
+			// The event that stopped this goroutine was dropped.
+			tracing.running.state = goroutineRunnable
+			tracing.running = nil
+			appendEvent(evGoSched, ev.ts, 0)
+		}
+		g := tracing.goroutines[ev.g]
+		if g == nil {
+			g = createGoroutine(ev)
+		}
+		if g.state == goroutineWaiting {
+			appendEvent(evGoUnblockLocal, ev.ts, g.id, 0)
+		}
+		g.state = goroutineRunning
+		tracing.running = g
+		appendEvent(evGoStartLocal, ev.ts, g.id)
+	case evGoUnblock:
+		g := tracing.goroutines[ev.g]
+		if g != nil && g.state == goroutineWaiting {
+			g.state = goroutineRunnable
+			appendEvent(evGoUnblockLocal, ev.ts, g.id, 0)
+		}
+	case evGoEnd, evGoStop:
+		g := tracing.goroutines[ev.g]
+		if g == nil || g != tracing.running {
+			return
+		}
+		tracing.running = nil
+		delete(tracing.goroutines, ev.g)
+		if ev.typ == evGoEnd {
+			appendEvent(evGoEnd, ev.ts)
+		} else {
+			appendEvent(evGoStop, ev.ts, 0)
+		}
+	case evGoSched, evGoSleep, evGoBlockSend, evGoBlockRecv, evGoBlockSelect, evGoBlockSync, evGoBlockCond:
+		g := tracing.goroutines[ev.g]
+		if g == nil || g != tracing.running {
+			return
+		}
+		tracing.running = nil
+		g.state = goroutineWaiting
+		if ev.typ == evGoSched {
+			g.state = goroutineRunnable
+		}
+		appendEvent(ev.typ, ev.ts, 0)
+	case evGCStart:
+		if !tracing.gcRunning {
+			tracing.gcRunning = true
+			appendEvent(evGCStart, ev.ts, tracing.gcSeq, 0)
+			tracing.gcSeq++
+		}
+	case evGCDone:
+		if tracing.gcRunning {
+			tracing.gcRunning = false
+			appendEvent(evGCDone, ev.ts)
+		}
+	case evHeapAlloc:
+		appendEvent(evHeapAlloc, ev.ts, ev.arg)
+	}
+}
+
+// createGoroutine assigns an ID to the goroutine of the given event and
+// writes an evGoCreate event for it.
+func createGoroutine(ev *event) *goroutine {
+	g := &goroutine{id: tracing.nextID}
+	tracing.nextID++
+	tracing.goroutines[ev.g] = g
+	appendEvent(evGoCreate, ev.ts, g.id, 0, 0)
+	return g
+}
+
+// appendEvent appends an event with the given timestamp and arguments to the
+// current batch.
+func appendEvent(typ byte, ts int64, args ...uint64) {
+	buf := tracing.buf
+	delta := uint64(ts - tracing.lastTs)
+	tracing.lastTs = ts
+	if len(args) < 3 {
+		buf = append(buf, typ|byte(len(args))<<6)
+		buf = appendVarint(buf, delta)
+		for _, arg := range args {
+			buf = appendVarint(buf, arg)
+		}
+	} else {
+		// Events with 3 or more arguments are prefixed with their length in
+		// bytes. Our events are always shorter than 128 bytes, so the length
+		// fits in a single byte.
+		buf = append(buf, typ|3<<6, 0)
+		start := len(buf)
+		buf = appendVarint(buf, delta)
+		for _, arg := range args {
+			buf = appendVarint(buf, arg)
+		}
+		buf[start-1] = byte(len(buf) - start)
+	}
+	tracing.buf = buf
+}
+
+// appendLog appends a log message in the background task to the current batch,
+// at the time of the previous event. The category is the only string in the
+// trace, so it always has string ID 1.
+func appendLog(category, message string) {
+	const categoryID = 1
+	tracing.buf = append(tracing.buf, evString)
+	tracing.buf = appendVarint(tracing.buf, categoryID)
+	tracing.buf = appendVarint(tracing.buf, uint64(len(category)))
+	tracing.buf = append(tracing.buf, category...)
+	// The message follows the (length-prefixed) arguments of the event.
+	appendEvent(evUserLog, tracing.lastTs, 0, categoryID, 0)
+	tracing.buf = appendVarint(tracing.buf, uint64(len(message)))
+	tracing.buf = append(tracing.buf, message...)
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for ; v >= 0x80; v >>= 7 {
+		buf = append(buf, 0x80|byte(v))
+	}
+	return append(buf, byte(v))
+}
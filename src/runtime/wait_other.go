@@ -1,7 +1,9 @@
-//go:build !tinygo.riscv && !cortexm
+//go:build !tinygo.riscv && !cortexm && !(tinygo.wasm && scheduler.cores)
 
 package runtime
 
 func waitForEvents() {
+	// All goroutines are blocked and there is nothing that can wake them up.
+	// The goroutine dump printed by runtimePanic shows where they're blocked.
 	runtimePanic("deadlocked: no event source")
 }
@@ -0,0 +1,33 @@
+//go:build tinygo.wasm && !scheduler.none && !scheduler.cores
+
+package runtime
+
+// wasmExportRun is called by the wrapper that the compiler creates for every
+// //go:wasmexport function, with a func value that calls the exported function.
+// It is called in a new goroutine so that it can block on channels, mutexes and
+// such, and goroutines are run until it has returned. This works like resume
+// and go_scheduler for JavaScript callbacks: when the host called the function
+// while Go code was already running (Go called an imported function, which
+// called back into Go), it is nested in the scheduler that is already running.
+//
+// Goroutines that wait for the host (like a timer with GOOS=js, or a sleeping
+// goroutine) can't run before control returns to the host. Therefore, the
+// program panics if the exported function blocks until one of them runs.
+func wasmExportRun(fn func()) {
+	done := false
+	go func() {
+		fn()
+		done = true
+	}()
+
+	nested := wasmNested
+	wasmNested = true
+	minSched()
+	wasmNested = nested
+	if !done {
+		if schedulerDone {
+			runtimePanic("//go:wasmexport function called after main returned")
+		}
+		runtimePanic("//go:wasmexport function is blocked")
+	}
+}
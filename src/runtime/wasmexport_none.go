@@ -0,0 +1,11 @@
+//go:build tinygo.wasm && (scheduler.none || scheduler.cores)
+
+package runtime
+
+// wasmExportRun is called by the wrapper of a //go:wasmexport function. The
+// function is simply called directly: there are no goroutines, or (with
+// -scheduler=cores) the scheduler runs on other threads and can't be nested.
+// In the latter case, the function must not block.
+func wasmExportRun(fn func()) {
+	fn()
+}
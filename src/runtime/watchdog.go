@@ -0,0 +1,77 @@
+//go:build !scheduler.cores
+
+package runtime
+
+// This file implements a software watchdog in the scheduler, on top of a
+// hardware watchdog. Petting the hardware watchdog from a timer interrupt would
+// keep the chip running even when a goroutine never gives up the CPU. Instead,
+// the scheduler pets it every time it has run all goroutines that were
+// runnable at the end of the previous round. A round ends when the scheduler
+// starts to run the last goroutine that was in the run queue at the start of
+// the round, or when there is nothing left to run.
+
+import "internal/task"
+
+var (
+	watchdogPet      func()
+	watchdogDeadline timeUnit
+	watchdogStart    timeUnit   // start of the current round
+	watchdogLast     *task.Task // last runnable goroutine at the start of the round
+	watchdogExpired  bool       // a round took longer than the deadline
+)
+
+// SetWatchdog makes the scheduler call pet, which should reload the hardware
+// watchdog, as long as all runnable goroutines get to run within the deadline
+// (in nanoseconds). Once a goroutine has kept the others from running for
+// longer than the deadline, pet is never called again so that the hardware
+// watchdog resets the chip. The hardware watchdog timeout should therefore be
+// somewhat longer than the deadline. A nil pet function turns the watchdog off.
+//
+// The scheduler wakes up at least twice per deadline to call pet, even if all
+// goroutines are blocked. Like the sleep hook, pet is called from the
+// scheduler, so it must not block on channels or mutexes.
+//
+// It returns false if this isn't supported, which is the case without a
+// scheduler and with -scheduler=cores.
+func SetWatchdog(deadline int64, pet func()) bool {
+	if !hasScheduler {
+		return false
+	}
+	watchdogDeadline = nanosecondsToTicks(deadline)
+	watchdogStart = ticks()
+	watchdogLast = nil
+	watchdogExpired = false
+	watchdogPet = pet
+	return true
+}
+
+// watchdogRun is called by the scheduler right before it runs t.
+func watchdogRun(t *task.Task) {
+	if watchdogPet != nil && (watchdogLast == nil || t == watchdogLast) {
+		watchdogRoundDone()
+	}
+}
+
+// watchdogIdle is called by the scheduler when no goroutine is runnable. It
+// returns how long the scheduler may sleep at most, or -1 if there is no
+// limit.
+func watchdogIdle() timeUnit {
+	if watchdogPet == nil {
+		return -1
+	}
+	watchdogRoundDone()
+	return watchdogDeadline / 2
+}
+
+// watchdogRoundDone ends the current round and starts the next one.
+func watchdogRoundDone() {
+	now := ticks()
+	if now-watchdogStart > watchdogDeadline {
+		watchdogExpired = true
+	}
+	if !watchdogExpired {
+		watchdogPet()
+	}
+	watchdogStart = now
+	watchdogLast = runqueue.Tail()
+}
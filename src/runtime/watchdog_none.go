@@ -0,0 +1,10 @@
+//go:build scheduler.cores
+
+package runtime
+
+// SetWatchdog makes the scheduler call pet to reload the hardware watchdog,
+// see watchdog.go. It is not supported with -scheduler=cores, so it always
+// returns false.
+func SetWatchdog(deadline int64, pet func()) bool {
+	return false
+}
@@ -1,84 +1,121 @@
 package sync
 
-import "internal/task"
-
+import (
+	"internal/task"
+	"runtime/interrupt"
+	"unsafe"
+)
+
+// Cond implements a condition variable. It works like the notifyList in the
+// upstream Go runtime: every call to Wait takes a ticket before unlocking L,
+// and Signal and Broadcast notify tickets in the order they were taken. This
+// way a signal sent while a waiter is still unlocking L isn't lost, and
+// waiters are woken up in FIFO order.
+//
+// The state is protected by disabling interrupts, so Signal and Broadcast may
+// also be called from an interrupt.
 type Cond struct {
 	L Locker
 
-	unlocking *earlySignal
-	blocked   task.Stack
-}
-
-// earlySignal is a type used to implement a stack for signalling waiters while they are unlocking.
-type earlySignal struct {
-	next *earlySignal
+	wait   uint32 // ticket of the next call to Wait
+	notify uint32 // ticket of the next waiter to notify
 
-	signaled bool
+	// Waiting goroutines, linked through task.Next. The ticket of each
+	// goroutine is stored in task.Data.
+	head *task.Task
+	tail *task.Task
 }
 
 func NewCond(l Locker) *Cond {
 	return &Cond{L: l}
 }
 
-func (c *Cond) trySignal() bool {
-	// Pop a blocked task off of the stack, and schedule it if applicable.
-	t := c.blocked.Pop()
-	if t != nil {
-		scheduleTask(t)
-		return true
-	}
-
-	// If there any tasks which are currently unlocking, signal one.
-	if c.unlocking != nil {
-		c.unlocking.signaled = true
-		c.unlocking = c.unlocking.next
-		return true
-	}
-
-	// There was nothing to signal.
-	return false
+// ticketNotified returns whether the given ticket was already notified.
+func (c *Cond) ticketNotified(ticket uint32) bool {
+	// Tickets may wrap around.
+	return int32(ticket-c.notify) < 0
 }
 
+// Signal wakes the goroutine that has been waiting the longest, if any.
 func (c *Cond) Signal() {
-	c.trySignal()
+	mask := interrupt.Disable()
+	if c.wait != c.notify {
+		ticket := c.notify
+		c.notify++
+
+		// Find the waiter with this ticket. It may not be in the list yet if it
+		// is still unlocking L, in which case it will see that its ticket was
+		// notified and not wait at all.
+		var prev *task.Task
+		for t := c.head; t != nil; prev, t = t, t.Next {
+			if uint32(t.Data) != ticket {
+				continue
+			}
+			if prev == nil {
+				c.head = t.Next
+			} else {
+				prev.Next = t.Next
+			}
+			if c.tail == t {
+				c.tail = prev
+			}
+			t.Next = nil
+			scheduleTask(t)
+			break
+		}
+	}
+	interrupt.Restore(mask)
 }
 
+// Broadcast wakes all goroutines waiting on c.
 func (c *Cond) Broadcast() {
-	// Signal everything.
-	for c.trySignal() {
+	mask := interrupt.Disable()
+	c.notify = c.wait
+	t := c.head
+	c.head = nil
+	c.tail = nil
+	for t != nil {
+		next := t.Next
+		t.Next = nil
+		scheduleTask(t)
+		t = next
 	}
+	interrupt.Restore(mask)
 }
 
+// Wait atomically unlocks c.L and suspends execution of the calling goroutine.
+// After later resuming execution, Wait locks c.L before returning.
 func (c *Cond) Wait() {
-	// Add an earlySignal frame to the stack so we can be signalled while unlocking.
-	early := earlySignal{
-		next: c.unlocking,
-	}
-	c.unlocking = &early
+	// Take a ticket before unlocking, so that a signal sent while unlocking
+	// is not missed.
+	mask := interrupt.Disable()
+	ticket := c.wait
+	c.wait++
+	interrupt.Restore(mask)
 
 	// Temporarily unlock L.
 	c.L.Unlock()
 
-	// Re-acquire the lock before returning.
-	defer c.L.Lock()
-
-	// If we were signaled while unlocking, immediately complete.
-	if early.signaled {
+	mask = interrupt.Disable()
+	if c.ticketNotified(ticket) {
+		// We were signaled while unlocking.
+		interrupt.Restore(mask)
+		c.L.Lock()
 		return
 	}
 
-	// Remove the earlySignal frame.
-	prev := c.unlocking
-	for prev != nil && prev.next != &early {
-		prev = prev.next
-	}
-	if prev != nil {
-		prev.next = early.next
+	// Add ourselves to the end of the list of waiters, and wait for a signal.
+	t := task.Current()
+	t.Data = uint64(ticket)
+	if c.tail == nil {
+		c.head = t
 	} else {
-		c.unlocking = early.next
+		c.tail.Next = t
 	}
+	c.tail = t
+	interrupt.Restore(mask)
+	task.Wait(task.WaitSyncCond, unsafe.Pointer(c))
 
-	// Wait for a signal.
-	c.blocked.Push(task.Current())
-	task.Pause()
+	// Re-acquire the lock before returning.
+	c.L.Lock()
 }
@@ -1,6 +1,7 @@
 package sync_test
 
 import (
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -81,3 +82,65 @@ type fakeLocker struct {
 
 func (l fakeLocker) Lock()   {}
 func (l fakeLocker) Unlock() { l.unlock() }
+
+// TestCondFIFO verifies that Signal wakes up waiters in the order they started
+// waiting.
+func TestCondFIFO(t *testing.T) {
+	var mu sync.Mutex
+	cond := sync.NewCond(&mu)
+
+	const n = 5
+	var order []int
+	var wg sync.WaitGroup
+	waiting := 0
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mu.Lock()
+			waiting++
+			cond.Wait()
+			order = append(order, i)
+			mu.Unlock()
+		}(i)
+
+		// Wait until this goroutine is waiting, so that the goroutines
+		// wait in order.
+		for {
+			mu.Lock()
+			w := waiting
+			mu.Unlock()
+			if w == i+1 {
+				break
+			}
+			runtime.Gosched()
+		}
+	}
+
+	// Wake up one waiter at a time, and wait until it has recorded itself
+	// before waking up the next one. This way the recorded order is the order
+	// in which Signal woke them up, and doesn't depend on the order in which
+	// woken goroutines acquire mu.
+	for i := 0; i < n; i++ {
+		mu.Lock()
+		cond.Signal()
+		mu.Unlock()
+		for {
+			mu.Lock()
+			woken := len(order)
+			mu.Unlock()
+			if woken == i+1 {
+				break
+			}
+			runtime.Gosched()
+		}
+	}
+	wg.Wait()
+
+	for i, got := range order {
+		if got != i {
+			t.Errorf("waiters woken up in order %v, want in order of waiting", order)
+			break
+		}
+	}
+}
@@ -1,70 +1,125 @@
 package sync
 
-// This file implements just enough of sync.Map to get packages to compile. It
-// is no more efficient than a map with a lock.
+// This file implements sync.Map as a regular map protected by a mutex. Unlike
+// the upstream implementation (which uses a read-only map with atomic loads
+// and a separate dirty map), this keeps every entry only once and is small in
+// code size, which matters more on microcontrollers. Load doesn't allocate.
+//
+// No method holds the lock while calling into user code, so the function
+// passed to Range may call any other method on the map.
 
 type Map struct {
 	lock Mutex
 	m    map[interface{}]interface{}
 }
 
-func (m *Map) Delete(key interface{}) {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-	delete(m.m, key)
-}
-
+// Load returns the value stored in the map for a key, or nil if no value is
+// present. The ok result indicates whether value was found in the map.
 func (m *Map) Load(key interface{}) (value interface{}, ok bool) {
 	m.lock.Lock()
-	defer m.lock.Unlock()
 	value, ok = m.m[key]
+	m.lock.Unlock()
 	return
 }
 
-func (m *Map) LoadOrStore(key, value interface{}) (actual interface{}, loaded bool) {
+// Store sets the value for a key.
+func (m *Map) Store(key, value interface{}) {
 	m.lock.Lock()
-	defer m.lock.Unlock()
 	if m.m == nil {
 		m.m = make(map[interface{}]interface{})
 	}
-	if existing, ok := m.m[key]; ok {
-		return existing, true
-	}
 	m.m[key] = value
-	return value, false
+	m.lock.Unlock()
 }
 
+// LoadOrStore returns the existing value for the key if present. Otherwise, it
+// stores and returns the given value. The loaded result is true if the value
+// was loaded, false if stored.
+func (m *Map) LoadOrStore(key, value interface{}) (actual interface{}, loaded bool) {
+	m.lock.Lock()
+	actual, loaded = m.m[key]
+	if !loaded {
+		if m.m == nil {
+			m.m = make(map[interface{}]interface{})
+		}
+		m.m[key] = value
+		actual = value
+	}
+	m.lock.Unlock()
+	return
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if
+// any. The loaded result reports whether the key was present.
 func (m *Map) LoadAndDelete(key interface{}) (value interface{}, loaded bool) {
 	m.lock.Lock()
-	defer m.lock.Unlock()
-	value, ok := m.m[key]
-	if !ok {
-		return nil, false
+	value, loaded = m.m[key]
+	if loaded {
+		delete(m.m, key)
 	}
+	m.lock.Unlock()
+	return
+}
+
+// Delete deletes the value for a key.
+func (m *Map) Delete(key interface{}) {
+	m.lock.Lock()
 	delete(m.m, key)
-	return value, true
+	m.lock.Unlock()
 }
 
-func (m *Map) Store(key, value interface{}) {
+// Swap swaps the value for a key and returns the previous value if any. The
+// loaded result reports whether the key was present.
+func (m *Map) Swap(key, value interface{}) (previous interface{}, loaded bool) {
 	m.lock.Lock()
-	defer m.lock.Unlock()
 	if m.m == nil {
 		m.m = make(map[interface{}]interface{})
 	}
+	previous, loaded = m.m[key]
 	m.m[key] = value
+	m.lock.Unlock()
+	return
 }
 
-func (m *Map) Range(f func(key, value interface{}) bool) {
+// CompareAndSwap swaps the old and new values for key if the value stored in
+// the map is equal to old. The old value must be of a comparable type.
+func (m *Map) CompareAndSwap(key, old, new interface{}) (swapped bool) {
 	m.lock.Lock()
-	defer m.lock.Unlock()
+	if value, ok := m.m[key]; ok && value == old {
+		m.m[key] = new
+		swapped = true
+	}
+	m.lock.Unlock()
+	return
+}
 
-	if m.m == nil {
-		return
+// CompareAndDelete deletes the entry for key if its value is equal to old. The
+// old value must be of a comparable type.
+func (m *Map) CompareAndDelete(key, old interface{}) (deleted bool) {
+	m.lock.Lock()
+	if value, ok := m.m[key]; ok && value == old {
+		delete(m.m, key)
+		deleted = true
 	}
+	m.lock.Unlock()
+	return
+}
 
+// Range calls f sequentially for each key and value present in the map. If f
+// returns false, Range stops the iteration.
+//
+// Like upstream, Range does not correspond to a consistent snapshot of the
+// map: entries stored or deleted while Range is running may or may not be
+// visited.
+func (m *Map) Range(f func(key, value interface{}) bool) {
+	m.lock.Lock()
+	// The lock is held while stepping the iterator, but not while calling f.
 	for k, v := range m.m {
+		m.lock.Unlock()
 		if !f(k, v) {
-			break
+			return
 		}
+		m.lock.Lock()
 	}
+	m.lock.Unlock()
 }
@@ -17,3 +17,54 @@ func TestMapLoadAndDelete(t *testing.T) {
 		t.Errorf("LoadAndDelete returned %v, %v, want nil, false", v, ok)
 	}
 }
+
+func TestMapSwap(t *testing.T) {
+	var sm sync.Map
+
+	if v, ok := sm.Swap("key", 1); ok || v != nil {
+		t.Errorf("Swap returned %v, %v, want nil, false", v, ok)
+	}
+	if v, ok := sm.Swap("key", 2); !ok || v != 1 {
+		t.Errorf("Swap returned %v, %v, want 1, true", v, ok)
+	}
+	if sm.CompareAndSwap("key", 1, 3) {
+		t.Error("CompareAndSwap with wrong old value succeeded")
+	}
+	if !sm.CompareAndSwap("key", 2, 3) {
+		t.Error("CompareAndSwap with right old value failed")
+	}
+	if sm.CompareAndDelete("key", 2) {
+		t.Error("CompareAndDelete with wrong old value succeeded")
+	}
+	if !sm.CompareAndDelete("key", 3) {
+		t.Error("CompareAndDelete with right old value failed")
+	}
+	if v, ok := sm.Load("key"); ok {
+		t.Errorf("Load after CompareAndDelete returned %v, true", v)
+	}
+}
+
+func TestMapRangeDelete(t *testing.T) {
+	var sm sync.Map
+	for i := 0; i < 10; i++ {
+		sm.Store(i, i*i)
+	}
+
+	// Deleting entries while ranging over the map must not deadlock.
+	n := 0
+	sm.Range(func(key, value interface{}) bool {
+		if value != key.(int)*key.(int) {
+			t.Errorf("Range: got value %v for key %v", value, key)
+		}
+		sm.Delete(key)
+		n++
+		return true
+	})
+	if n != 10 {
+		t.Errorf("Range visited %d entries, want 10", n)
+	}
+	sm.Range(func(key, value interface{}) bool {
+		t.Errorf("Range after deleting everything visited %v", key)
+		return true
+	})
+}
@@ -2,7 +2,8 @@ package sync
 
 import (
 	"internal/task"
-	_ "unsafe"
+	"runtime/interrupt"
+	"unsafe"
 )
 
 type Mutex struct {
@@ -13,19 +14,37 @@ type Mutex struct {
 //go:linkname scheduleTask runtime.runqueuePushBack
 func scheduleTask(*task.Task)
 
+// Waiting for a lock is recorded in the block and mutex profiles.
+
+//go:linkname runtime_mutexWaitStart runtime.mutexWaitStart
+func runtime_mutexWaitStart() int64
+
+//go:linkname runtime_mutexWaitEnd runtime.mutexWaitEnd
+func runtime_mutexWaitEnd(start int64)
+
 func (m *Mutex) Lock() {
+	// The state of the mutex is only changed with interrupts disabled, which
+	// with -scheduler=cores also keeps other cores from changing it at the
+	// same time.
+	mask := interrupt.Disable()
 	if m.locked {
 		// Push self onto stack of blocked tasks, and wait to be resumed.
 		m.blocked.Push(task.Current())
-		task.Pause()
+		interrupt.Restore(mask)
+		start := runtime_mutexWaitStart()
+		task.Wait(task.WaitMutex, unsafe.Pointer(m))
+		runtime_mutexWaitEnd(start)
 		return
 	}
 
 	m.locked = true
+	interrupt.Restore(mask)
 }
 
 func (m *Mutex) Unlock() {
+	mask := interrupt.Disable()
 	if !m.locked {
+		interrupt.Restore(mask)
 		panic("sync: unlock of unlocked Mutex")
 	}
 
@@ -35,6 +54,7 @@ func (m *Mutex) Unlock() {
 	} else {
 		m.locked = false
 	}
+	interrupt.Restore(mask)
 }
 
 type RWMutex struct {
@@ -58,29 +78,37 @@ const (
 )
 
 func (rw *RWMutex) Lock() {
+	mask := interrupt.Disable()
 	if rw.state == 0 {
 		// The mutex is completely unlocked.
 		// Lock without waiting.
 		rw.state = rwMutexStateWLocked
+		interrupt.Restore(mask)
 		return
 	}
 
 	// Wait for the lock to be released.
 	rw.waitingWriters.Push(task.Current())
-	task.Pause()
+	interrupt.Restore(mask)
+	start := runtime_mutexWaitStart()
+	task.Wait(task.WaitRWMutex, unsafe.Pointer(rw))
+	runtime_mutexWaitEnd(start)
 }
 
 func (rw *RWMutex) Unlock() {
+	mask := interrupt.Disable()
 	switch rw.state {
 	case rwMutexStateWLocked:
 		// This is correct.
 
 	case rwMutexStateUnlocked:
 		// The mutex is already unlocked.
+		interrupt.Restore(mask)
 		panic("sync: unlock of unlocked RWMutex")
 
 	default:
 		// The mutex is read-locked instead of write-locked.
+		interrupt.Restore(mask)
 		panic("sync: write-unlock of read-locked RWMutex")
 	}
 
@@ -95,32 +123,42 @@ func (rw *RWMutex) Unlock() {
 		// Nothing is waiting for the lock.
 		rw.state = rwMutexStateUnlocked
 	}
+	interrupt.Restore(mask)
 }
 
 func (rw *RWMutex) RLock() {
+	mask := interrupt.Disable()
 	if rw.state == rwMutexStateWLocked {
 		// Wait for the write lock to be released.
 		rw.waitingReaders.Push(task.Current())
-		task.Pause()
+		interrupt.Restore(mask)
+		start := runtime_mutexWaitStart()
+		task.Wait(task.WaitRWMutexRead, unsafe.Pointer(rw))
+		runtime_mutexWaitEnd(start)
 		return
 	}
 
 	if rw.state == rwMutexMaxReaders {
+		interrupt.Restore(mask)
 		panic("sync: too many readers on RWMutex")
 	}
 
 	// Increase the reader count.
 	rw.state++
+	interrupt.Restore(mask)
 }
 
 func (rw *RWMutex) RUnlock() {
+	mask := interrupt.Disable()
 	switch rw.state {
 	case rwMutexStateUnlocked:
 		// The mutex is already unlocked.
+		interrupt.Restore(mask)
 		panic("sync: unlock of unlocked RWMutex")
 
 	case rwMutexStateWLocked:
 		// The mutex is write-locked instead of read-locked.
+		interrupt.Restore(mask)
 		panic("sync: read-unlock of write-locked RWMutex")
 	}
 
@@ -131,6 +169,7 @@ func (rw *RWMutex) RUnlock() {
 		// Try to unblock a writer.
 		rw.maybeUnblockWriter()
 	}
+	interrupt.Restore(mask)
 }
 
 func (rw *RWMutex) maybeUnblockReaders() bool {
@@ -203,3 +203,48 @@ func TestRWMutexReadToWrite(t *testing.T) {
 		t.Errorf("write lock acquired while %d readers were active", res)
 	}
 }
+
+// TestMutexProfile tests that waiting for a Mutex is recorded in the mutex
+// profile.
+func TestMutexProfile(t *testing.T) {
+	old := runtime.SetMutexProfileFraction(1)
+	defer runtime.SetMutexProfileFraction(old)
+
+	before, _ := runtime.MutexProfile(nil)
+	records := make([]runtime.BlockProfileRecord, before)
+	runtime.MutexProfile(records)
+	var count int64
+	for _, r := range records {
+		count += r.Count
+	}
+
+	// Hold the lock in another goroutine, so that Lock below has to wait.
+	var mu sync.Mutex
+	var locked uint32
+	go func() {
+		mu.Lock()
+		atomic.StoreUint32(&locked, 1)
+		for i := 0; i < 10; i++ {
+			runtime.Gosched()
+		}
+		mu.Unlock()
+	}()
+	for atomic.LoadUint32(&locked) == 0 {
+		runtime.Gosched()
+	}
+	mu.Lock()
+	mu.Unlock()
+
+	n, _ := runtime.MutexProfile(nil)
+	records = make([]runtime.BlockProfileRecord, n)
+	if _, ok := runtime.MutexProfile(records); !ok {
+		t.Fatal("MutexProfile: profile grew while reading it")
+	}
+	var newCount int64
+	for _, r := range records {
+		newCount += r.Count
+	}
+	if newCount <= count {
+		t.Errorf("mutex contention was not recorded: %d events before, %d after", count, newCount)
+	}
+}
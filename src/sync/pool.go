@@ -1,25 +1,115 @@
 package sync
 
-// Pool is a very simple implementation of sync.Pool.
+import "runtime/interrupt"
+
+// Pool is a set of temporary objects that may be individually saved and
+// retrieved.
+//
+// Like in upstream Go, items that haven't been used for a while are dropped
+// by the GC: at the start of every GC cycle the items in a pool are moved to a
+// victim list, and the items that were already in the victim list are freed.
+// Get takes items from the victim list when the pool itself is empty, so that
+// a pool that is in use doesn't suddenly become empty.
+//
+// Pools are shared by all goroutines (there are no per-P pools), and are
+// protected by disabling interrupts which is cheap on a microcontroller.
 type Pool struct {
-	New   func() interface{}
-	items []interface{}
+	New func() interface{}
+
+	items      []interface{} // non-nil iff the pool is in allPools
+	victim     []interface{} // items from before the last GC cycle
+	next       *Pool         // next pool in allPools
+	victimNext *Pool         // next pool in oldPools
 }
 
+var (
+	// Pools with items in them. Linked lists are used so that registering a
+	// pool doesn't need to allocate.
+	allPools *Pool
+
+	// Pools that had items in them at the start of the last GC cycle, so have
+	// a victim list.
+	oldPools *Pool
+
+	poolCleanupRegistered bool
+)
+
+//go:linkname runtime_registerPoolCleanup runtime.registerPoolCleanup
+func runtime_registerPoolCleanup(cleanup func())
+
 // Get returns an item in the pool, or the value of calling Pool.New() if there are no items.
 func (p *Pool) Get() interface{} {
-	if len(p.items) > 0 {
-		x := p.items[len(p.items)-1]
-		p.items = p.items[:len(p.items)-1]
-		return x
+	mask := interrupt.Disable()
+	var x interface{}
+	if n := len(p.items); n > 0 {
+		x = p.items[n-1]
+		p.items[n-1] = nil
+		p.items = p.items[:n-1]
+	} else if n := len(p.victim); n > 0 {
+		x = p.victim[n-1]
+		p.victim[n-1] = nil
+		p.victim = p.victim[:n-1]
 	}
-	if p.New == nil {
-		return nil
+	interrupt.Restore(mask)
+	if x == nil && p.New != nil {
+		x = p.New()
 	}
-	return p.New()
+	return x
 }
 
 // Put adds a value back into the pool.
 func (p *Pool) Put(x interface{}) {
+	if x == nil {
+		return
+	}
+	mask := interrupt.Disable()
+	for len(p.items) == cap(p.items) {
+		// The slice needs to grow. The allocation may run the GC, which clears
+		// all pools, so it must be done outside of the critical section and
+		// the pool must be checked again afterwards.
+		newCap := cap(p.items) * 2
+		if newCap == 0 {
+			newCap = 4
+		}
+		interrupt.Restore(mask)
+		if !poolCleanupRegistered {
+			poolCleanupRegistered = true
+			runtime_registerPoolCleanup(poolCleanup)
+		}
+		items := make([]interface{}, 0, newCap)
+		mask = interrupt.Disable()
+		if len(p.items) == cap(p.items) && len(p.items) < newCap {
+			if p.items == nil {
+				p.next = allPools
+				allPools = p
+			}
+			p.items = append(items, p.items...)
+		}
+	}
 	p.items = append(p.items, x)
+	interrupt.Restore(mask)
+}
+
+// poolCleanup is called by the runtime at the start of every GC cycle. It must
+// not allocate.
+func poolCleanup() {
+	// Drop the victim lists, freeing the items in them.
+	for p := oldPools; p != nil; {
+		next := p.victimNext
+		p.victim = nil
+		p.victimNext = nil
+		p = next
+	}
+
+	// Move the items of every pool to its victim list.
+	for p := allPools; p != nil; {
+		next := p.next
+		p.victim = p.items
+		p.items = nil
+		p.victimNext = next
+		p.next = nil
+		p = next
+	}
+
+	oldPools, allPools = allPools, nil
 }
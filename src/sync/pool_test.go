@@ -1,6 +1,7 @@
 package sync_test
 
 import (
+	"runtime"
 	"sync"
 	"testing"
 )
@@ -48,3 +49,23 @@ func TestPool_noNew(t *testing.T) {
 		t.Errorf("pool without New returned %v, want nil", i1)
 	}
 }
+
+func TestPool_GC(t *testing.T) {
+	p := sync.Pool{}
+
+	// An item survives one GC cycle, in the victim list.
+	p.Put(&testItem{val: 1})
+	runtime.GC()
+	i1 := p.Get()
+	if i1 == nil || i1.(*testItem).val != 1 {
+		t.Errorf("pool item after one GC cycle: got %v, want item with value 1", i1)
+	}
+
+	// It is dropped after two GC cycles.
+	p.Put(&testItem{val: 2})
+	runtime.GC()
+	runtime.GC()
+	if i2 := p.Get(); i2 != nil {
+		t.Errorf("pool item after two GC cycles: got %v, want nil", i2)
+	}
+}
@@ -1,6 +1,10 @@
 package sync
 
-import "internal/task"
+import (
+	"internal/task"
+	"runtime/interrupt"
+	"unsafe"
+)
 
 type WaitGroup struct {
 	counter uint
@@ -8,6 +12,8 @@ type WaitGroup struct {
 }
 
 func (wg *WaitGroup) Add(delta int) {
+	mask := interrupt.Disable()
+	defer interrupt.Restore(mask)
 	if delta > 0 {
 		// Check for overflow.
 		if uint(delta) > (^uint(0))-wg.counter {
@@ -41,14 +47,17 @@ func (wg *WaitGroup) Done() {
 }
 
 func (wg *WaitGroup) Wait() {
+	mask := interrupt.Disable()
 	if wg.counter == 0 {
 		// Everything already finished.
+		interrupt.Restore(mask)
 		return
 	}
 
 	// Push the current goroutine onto the waiter stack.
 	wg.waiters.Push(task.Current())
+	interrupt.Restore(mask)
 
 	// Pause until the waiters are awoken by Add/Done.
-	task.Pause()
+	task.Wait(task.WaitWaitGroup, unsafe.Pointer(wg))
 }
@@ -1,4 +1,4 @@
-//go:build !wasi && !darwin
+//go:build !wasi && !darwin && !freebsd
 
 package syscall
 
@@ -1,4 +1,4 @@
-//go:build darwin || nintendoswitch || wasi
+//go:build darwin || freebsd || nintendoswitch || wasi
 
 package syscall
 
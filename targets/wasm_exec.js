@@ -250,12 +250,19 @@
 				wasi_snapshot_preview1: {
 					// https://github.com/WebAssembly/WASI/blob/main/phases/snapshot/docs.md#fd_write
 					fd_write: function(fd, iovs_ptr, iovs_len, nwritten_ptr) {
+						// With 64-bit memory (wasm64), pointers and sizes are
+						// passed as BigInt and are 8 bytes in memory.
+						const ptrSize = typeof iovs_ptr === "bigint" ? 8 : 4;
+						const loadUintptr = (addr) => ptrSize == 8 ? getInt64(addr) : mem().getUint32(addr, true);
+						iovs_ptr = Number(iovs_ptr);
+						iovs_len = Number(iovs_len);
+						nwritten_ptr = Number(nwritten_ptr);
 						let nwritten = 0;
 						if (fd == 1) {
 							for (let iovs_i=0; iovs_i<iovs_len;iovs_i++) {
-								let iov_ptr = iovs_ptr+iovs_i*8; // assuming wasm32
-								let ptr = mem().getUint32(iov_ptr + 0, true);
-								let len = mem().getUint32(iov_ptr + 4, true);
+								let iov_ptr = iovs_ptr+iovs_i*ptrSize*2;
+								let ptr = loadUintptr(iov_ptr + 0);
+								let len = loadUintptr(iov_ptr + ptrSize);
 								nwritten += len;
 								for (let i=0; i<len; i++) {
 									let c = mem().getUint8(ptr+i);
@@ -274,7 +281,11 @@
 						} else {
 							console.error('invalid file descriptor:', fd);
 						}
-						mem().setUint32(nwritten_ptr, nwritten, true);
+						if (ptrSize == 8) {
+							setInt64(nwritten_ptr, nwritten);
+						} else {
+							mem().setUint32(nwritten_ptr, nwritten, true);
+						}
 						return 0;
 					},
 					fd_close: () => 0,      // dummy
@@ -290,7 +301,7 @@
 						}
 					},
 					random_get: (bufPtr, bufLen) => {
-						crypto.getRandomValues(loadSlice(bufPtr, bufLen));
+						crypto.getRandomValues(loadSlice(Number(bufPtr), Number(bufLen)));
 						return 0;
 					},
 				},
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+)
+
+func main() {
+	_, file, line, ok := runtime.Caller(0)
+	println("Caller:", filepath.Base(file), line, ok)
+	outer()
+}
+
+//go:noinline
+func outer() {
+	inner()
+}
+
+//go:noinline
+func inner() {
+	pc := make([]uintptr, 10)
+	n := runtime.Callers(1, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for i := 0; i < 2; i++ {
+		frame, _ := frames.Next()
+		println("frame:", frame.Function, filepath.Base(frame.File), frame.Line)
+	}
+	println("FuncForPC:", runtime.FuncForPC(pc[0]).Name())
+}
@@ -8,6 +8,13 @@ import (
 
 var wg sync.WaitGroup
 
+// Channels created in the package initializer, which are allocated statically.
+var (
+	staticChan  = make(chan int, 3)
+	staticEmpty = make(chan struct{}, 2)
+	staticSync  = make(chan string)
+)
+
 type intchan chan int
 
 func main() {
@@ -229,6 +236,38 @@ func main() {
 	}
 	wg.Wait()
 	println("blocking select sum:", sum)
+
+	// Test that select chooses randomly when several cases are ready.
+	fch1 := make(chan int, 100)
+	fch2 := make(chan int, 100)
+	for i := 0; i < 100; i++ {
+		fch1 <- 1
+		fch2 <- 2
+	}
+	var counts [3]int
+	for i := 0; i < 100; i++ {
+		select {
+		case v := <-fch1:
+			counts[v]++
+		case v := <-fch2:
+			counts[v]++
+		}
+	}
+	println("fair select:", counts[1] > 10 && counts[2] > 10)
+
+	// Test channels in package-level variables.
+	staticChan <- 1
+	staticChan <- 2
+	staticEmpty <- struct{}{}
+	println("static channel:", len(staticChan), cap(staticChan), len(staticEmpty), cap(staticEmpty))
+	println("static channel receive:", <-staticChan, <-staticChan)
+	wg.Add(1)
+	go func() {
+		staticSync <- "hello"
+		wg.Done()
+	}()
+	println("static unbuffered channel receive:", <-staticSync)
+	wg.Wait()
 }
 
 func send(ch chan<- int) {
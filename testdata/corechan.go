@@ -0,0 +1,35 @@
+package main
+
+// Test -scheduler=cores: two goroutines that run on different cores at the
+// same time and share a channel.
+
+import "sync/atomic"
+
+var started int32
+
+func main() {
+	values := make(chan int)
+	result := make(chan int)
+	go sum(values, result)
+
+	// This loop never blocks, so the other goroutine can only set started when
+	// it runs on another core.
+	for atomic.LoadInt32(&started) == 0 {
+	}
+	println("running on two cores")
+
+	for i := 1; i <= 1000; i++ {
+		values <- i
+	}
+	close(values)
+	println("sum:", <-result)
+}
+
+func sum(values <-chan int, result chan<- int) {
+	atomic.StoreInt32(&started, 1)
+	total := 0
+	for n := range values {
+		total += n
+	}
+	result <- total
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"runtime/pprof"
+	"time"
+)
+
+var sink int
+
+func main() {
+	var buf bytes.Buffer
+	println("start:", pprof.StartCPUProfile(&buf) == nil)
+	err := pprof.StartCPUProfile(io.Discard)
+	println("start again:", err.Error())
+
+	// Use some CPU time, so that the profiling timer fires.
+	for start := time.Now(); time.Since(start) < 300*time.Millisecond; {
+		busy()
+	}
+	pprof.StopCPUProfile()
+
+	r, err := gzip.NewReader(&buf)
+	if err != nil {
+		println("could not decompress profile:", err.Error())
+		return
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		println("could not decompress profile:", err.Error())
+		return
+	}
+	println("has samples:", countSamples(data) > 0)
+}
+
+//go:noinline
+func busy() {
+	for i := 0; i < 1000; i++ {
+		sink += i
+	}
+}
+
+// countSamples returns the number of Sample messages in a profile.proto
+// message, skipping all other fields.
+func countSamples(data []byte) int {
+	samples := 0
+	for len(data) != 0 {
+		key, n := uvarint(data)
+		data = data[n:]
+		switch key & 7 {
+		case 0: // varint
+			_, n = uvarint(data)
+			data = data[n:]
+		case 2: // length-delimited
+			length, n := uvarint(data)
+			data = data[n+int(length):]
+			if key>>3 == 2 {
+				samples++
+			}
+		default:
+			println("unexpected wire type:", key&7)
+			return 0
+		}
+	}
+	return samples
+}
+
+func uvarint(data []byte) (uint64, int) {
+	var x uint64
+	for i, b := range data {
+		x |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return x, i + 1
+		}
+	}
+	return 0, len(data)
+}
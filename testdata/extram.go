@@ -0,0 +1,65 @@
+package main
+
+// Test extram.Alloc and extram.Free. This test runs on riscv-qemu, which has
+// 1MB of external RAM (see targets/riscv-qemu.json).
+
+import (
+	"runtime"
+	"runtime/extram"
+	"unsafe"
+)
+
+const size = 256 * 1024
+
+func main() {
+	var bufs [3][]byte
+	for i := range bufs {
+		ptr := extram.Alloc(size)
+		if ptr == nil {
+			println("out of external RAM")
+			return
+		}
+		bufs[i] = unsafe.Slice((*byte)(ptr), size)
+		for j := range bufs[i] {
+			if bufs[i][j] != 0 {
+				println("memory is not zeroed")
+				return
+			}
+			bufs[i][j] = byte(i + j)
+		}
+	}
+
+	// External RAM isn't part of the GC heap, so this must leave the buffers
+	// alone.
+	runtime.GC()
+	for i := range bufs {
+		for j := range bufs[i] {
+			if bufs[i][j] != byte(i+j) {
+				println("buffer", i, "was overwritten")
+				return
+			}
+		}
+	}
+	println("allocated", len(bufs), "buffers")
+
+	// Less than 256kB is left.
+	println("full:", extram.Alloc(size) == nil)
+
+	// Free the buffers so that the last one freed has to be merged with free
+	// chunks on both sides. After that, all external RAM is available again.
+	extram.Free(unsafe.Pointer(&bufs[0][0]))
+	extram.Free(unsafe.Pointer(&bufs[2][0]))
+	extram.Free(unsafe.Pointer(&bufs[1][0]))
+	ptr := extram.Alloc(4 * size * 15 / 16)
+	println("merged:", ptr != nil)
+	if ptr != nil {
+		buf := unsafe.Slice((*byte)(ptr), 4*size*15/16)
+		for _, b := range buf {
+			if b != 0 {
+				println("reused memory is not zeroed")
+				return
+			}
+		}
+		extram.Free(ptr)
+	}
+}
@@ -20,6 +20,8 @@ func randuint32() uint32 {
 func main() {
 	testNonPointerHeap()
 	testKeepAlive()
+	testFinalizers()
+	testMemStats()
 }
 
 var scalarSlices [4][]byte
@@ -74,3 +76,69 @@ func testKeepAlive() {
 	var x int
 	runtime.KeepAlive(&x)
 }
+
+type finalizerObject struct {
+	id   int
+	next *finalizerObject
+}
+
+var (
+	keptObject     *finalizerObject
+	finalizedCount [64]int
+)
+
+func testFinalizers() {
+	// Finalizers must not run for objects that are still reachable, or whose
+	// finalizer was removed. Which of the unreachable objects are finalized
+	// depends on the GC, as pointers to them may remain on the stack. But a
+	// finalizer must not run more than once.
+	keptObject = &finalizerObject{id: -1}
+	runtime.SetFinalizer(keptObject, func(o *finalizerObject) {
+		println("finalizer ran for a reachable object")
+	})
+	removed := &finalizerObject{id: -2}
+	runtime.SetFinalizer(removed, func(o *finalizerObject) {
+		println("removed finalizer ran")
+	})
+	runtime.SetFinalizer(removed, nil)
+	makeFinalizedObjects()
+	for i := 0; i < 10; i++ {
+		runtime.GC()
+		runtime.Gosched()
+	}
+	for id, count := range finalizedCount {
+		if count > 1 {
+			println("finalizer ran more than once for object", id)
+		}
+	}
+	runtime.KeepAlive(keptObject)
+	println("finalizers ok")
+}
+
+//go:noinline
+func makeFinalizedObjects() {
+	var list *finalizerObject
+	for i := range finalizedCount {
+		o := &finalizerObject{id: i, next: list}
+		runtime.SetFinalizer(o, func(o *finalizerObject) {
+			finalizedCount[o.id]++
+		})
+		list = o
+	}
+}
+
+func testMemStats() {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	if (ms.HeapFreeRanges == 0) != (ms.HeapLargestFree == 0) {
+		println("free ranges:", ms.HeapFreeRanges, "largest free range:", ms.HeapLargestFree)
+	}
+	if ms.HeapLargestFree > ms.Sys {
+		println("largest free range is bigger than the heap")
+	}
+	if ms.HeapLargestFree*ms.HeapFreeRanges < ms.HeapIdle {
+		println("free memory doesn't fit in the free ranges")
+	}
+	println("memstats ok")
+}
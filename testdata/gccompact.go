@@ -0,0 +1,135 @@
+package main
+
+// Test that objects and pointers to them stay intact when the heap is compacted
+// (-gc=compacting).
+//
+// The pointers in the object types below are placed so that their inline
+// layout value on 32-bit systems looks like an address in the middle of RAM:
+// 0x20008031 on Cortex-M (cortex-m-qemu) and 0x80180035 on RISC-V
+// (riscv-qemu). These objects must still be recognized as objects that haven't
+// been moved.
+
+type armObject struct {
+	data  [9]uintptr
+	leaf  *leaf
+	data2 [13]uintptr
+	leaf2 *leaf
+}
+
+type riscvObject struct {
+	data  [13]uintptr
+	leaf  *leaf
+	leaf2 *leaf
+	data2 [10]uintptr
+	leaf3 *leaf
+}
+
+type leaf struct {
+	value uintptr
+}
+
+var xorshift32State uint32 = 1
+
+func randuint32() uint32 {
+	// Algorithm "xor" from p. 4 of Marsaglia, "Xorshift RNGs"
+	x := xorshift32State
+	x ^= x << 13
+	x ^= x >> 17
+	x ^= x << 5
+	xorshift32State = x
+	return x
+}
+
+var (
+	armObjects   [16]*armObject
+	riscvObjects [16]*riscvObject
+	garbage      [][]byte
+	large        []byte
+)
+
+func main() {
+	for i := range armObjects {
+		armObjects[i] = newARMObject(uintptr(i))
+		riscvObjects[i] = newRISCVObject(uintptr(i))
+	}
+	for round := 0; round < 50; round++ {
+		// Replace some objects, with garbage in between so that the heap gets
+		// fragmented once the garbage is freed.
+		for i := range armObjects {
+			if randuint32()%2 == 0 {
+				armObjects[i] = newARMObject(uintptr(round*100 + i))
+			}
+			garbage = append(garbage, make([]byte, 192))
+			if randuint32()%2 == 0 {
+				riscvObjects[i] = newRISCVObject(uintptr(round*100 + i))
+			}
+			garbage = append(garbage, make([]byte, 192))
+		}
+		garbage = nil
+
+		// There is more free memory now than the size of this allocation, but
+		// it isn't contiguous.
+		large = make([]byte, 2048)
+		large = nil
+
+		check()
+	}
+	println("ok")
+}
+
+func newARMObject(n uintptr) *armObject {
+	obj := &armObject{leaf: &leaf{value: n}, leaf2: &leaf{value: n}}
+	for i := range obj.data {
+		obj.data[i] = n*31 + uintptr(i)
+	}
+	for i := range obj.data2 {
+		obj.data2[i] = n*37 + uintptr(i)
+	}
+	return obj
+}
+
+func newRISCVObject(n uintptr) *riscvObject {
+	obj := &riscvObject{leaf: &leaf{value: n}, leaf2: &leaf{value: n}, leaf3: &leaf{value: n}}
+	for i := range obj.data {
+		obj.data[i] = n*41 + uintptr(i)
+	}
+	for i := range obj.data2 {
+		obj.data2[i] = n*43 + uintptr(i)
+	}
+	return obj
+}
+
+func check() {
+	for _, obj := range armObjects {
+		n := obj.leaf.value
+		if obj.leaf2.value != n {
+			panic("armObject was overwritten")
+		}
+		for i, x := range obj.data {
+			if x != n*31+uintptr(i) {
+				panic("armObject was overwritten")
+			}
+		}
+		for i, x := range obj.data2 {
+			if x != n*37+uintptr(i) {
+				panic("armObject was overwritten")
+			}
+		}
+	}
+	for _, obj := range riscvObjects {
+		n := obj.leaf.value
+		if obj.leaf2.value != n || obj.leaf3.value != n {
+			panic("riscvObject was overwritten")
+		}
+		for i, x := range obj.data {
+			if x != n*41+uintptr(i) {
+				panic("riscvObject was overwritten")
+			}
+		}
+		for i, x := range obj.data2 {
+			if x != n*43+uintptr(i) {
+				panic("riscvObject was overwritten")
+			}
+		}
+	}
+}
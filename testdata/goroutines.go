@@ -86,6 +86,8 @@ func main() {
 	testCond()
 
 	testIssue1790()
+
+	testNumGoroutine()
 }
 
 func acquire(m *sync.Mutex) {
@@ -227,6 +229,24 @@ func testIssue1790() *int {
 	return &i
 }
 
+func testNumGoroutine() {
+	// Make sure all goroutines started before have exited.
+	time.Sleep(2 * time.Millisecond)
+	before := runtime.NumGoroutine()
+
+	ch := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		go func() {
+			<-ch
+		}()
+	}
+	println("started goroutines:", runtime.NumGoroutine()-before)
+
+	close(ch)
+	time.Sleep(time.Millisecond)
+	println("remaining goroutines:", runtime.NumGoroutine()-before)
+}
+
 type Itf interface {
 	Nowait()
 	Wait()
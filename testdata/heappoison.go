@@ -0,0 +1,44 @@
+package main
+
+// Test -tags=heappoison: a write to a heap object after the GC has found it to
+// be unreachable must be detected by the next GC cycle, which panics with
+// "write to freed heap memory".
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+type object struct {
+	data [8]uintptr
+}
+
+// Addresses of the objects, hidden from the GC by flipping all bits. There are
+// several of them in case a stale pointer in a register keeps one of them
+// alive.
+var hidden [8]uintptr
+
+//go:noinline
+func allocate() {
+	for i := range hidden {
+		obj := &object{}
+		hidden[i] = ^uintptr(unsafe.Pointer(obj))
+	}
+}
+
+func main() {
+	allocate()
+
+	// The objects are unreachable now, so they are put in quarantine.
+	runtime.GC()
+
+	for _, addr := range hidden {
+		obj := (*object)(unsafe.Pointer(^addr))
+		obj.data[3] = 5
+	}
+	println("wrote to freed objects")
+
+	// This must panic.
+	runtime.GC()
+	println("writes were not detected")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"runtime"
 	"sort"
 	"unsafe"
 )
@@ -126,10 +127,18 @@ func main() {
 	testBigMap(squares, 40)
 	println("tested growing of a map")
 
+	mapsizehint()
+
 	floatcmplx()
 
 	mapgrow()
 
+	mapchurn()
+
+	mapshrink()
+
+	maporder()
+
 	interfacerehash()
 }
 
@@ -277,6 +286,96 @@ func mapgrow() {
 	println("done")
 }
 
+// mapsizehint checks that a map made with a size hint doesn't need to allocate
+// more memory (to grow) while it is filled up to that size.
+func mapsizehint() {
+	sizes := []int{1, 8, 9, 100, 1000}
+	if unsafe.Sizeof(uintptr(0)) < 4 {
+		// Reduce the size of the maps on low-memory devices like AVR.
+		sizes = []int{1, 8, 9, 20}
+	}
+	var ms runtime.MemStats
+	for _, n := range sizes {
+		m := make(map[int]int, n)
+		runtime.ReadMemStats(&ms)
+		mallocs := ms.Mallocs
+		for i := 0; i < n; i++ {
+			m[i] = i
+		}
+		runtime.ReadMemStats(&ms)
+		if ms.Mallocs != mallocs || len(m) != n {
+			println("map with size hint", n, "grew:", ms.Mallocs-mallocs, "allocations")
+		}
+	}
+	println("tested map size hints")
+}
+
+// mapchurn keeps inserting and deleting keys, so that the map has to reuse
+// slots of deleted entries.
+func mapchurn() {
+	m := make(map[int]int)
+	for i := 0; i < 1000; i++ {
+		m[i] = i
+		if i >= 10 {
+			delete(m, i-10)
+		}
+	}
+	var bad int
+	for i := 0; i < 1000; i++ {
+		v, ok := m[i]
+		if ok != (i >= 990) || (ok && v != i) {
+			bad++
+		}
+	}
+	println("churn:", len(m), bad)
+}
+
+// mapshrink deletes most elements of a big map while iterating over it, which
+// makes the map shrink.
+func mapshrink() {
+	m := make(map[int]int)
+	for i := 0; i < 1000; i++ {
+		m[i] = i
+	}
+	var seen int
+	for k := range m {
+		if k%100 != 0 {
+			delete(m, k)
+		}
+		seen++
+	}
+	var bad int
+	for i := 0; i < 1000; i++ {
+		v, ok := m[i]
+		if ok != (i%100 == 0) || (ok && v != i) {
+			bad++
+		}
+	}
+	println("shrink:", seen, len(m), bad)
+}
+
+// maporder checks that iterating over the same map doesn't always start at the
+// same key.
+func maporder() {
+	m := make(map[int]int)
+	for i := 0; i < 10; i++ {
+		m[i] = i
+	}
+	first := -1
+	random := false
+	for i := 0; i < 100 && !random; i++ {
+		for k := range m {
+			if first < 0 {
+				first = k
+			} else if k != first {
+				random = true
+			}
+			break
+		}
+	}
+	println("random order:", random)
+}
+
 type Counter interface {
 	count() int
 }
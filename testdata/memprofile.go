@@ -0,0 +1,34 @@
+package main
+
+import "runtime"
+
+var sink []byte
+
+func main() {
+	runtime.MemProfileRate = 1
+	for i := 0; i < 100; i++ {
+		allocate()
+	}
+
+	records := make([]runtime.MemProfileRecord, 100)
+	n, ok := runtime.MemProfile(records, true)
+	println("ok:", ok)
+
+	// Find the record with the most allocated bytes.
+	var top *runtime.MemProfileRecord
+	for i := range records[:n] {
+		if top == nil || records[i].AllocBytes > top.AllocBytes {
+			top = &records[i]
+		}
+	}
+	println("objects:", top.AllocObjects)
+	println("bytes:", top.AllocBytes >= 100*1000)
+	for _, pc := range top.Stack()[:2] {
+		println("frame:", runtime.FuncForPC(pc-1).Name())
+	}
+}
+
+//go:noinline
+func allocate() {
+	sink = make([]byte, 1000)
+}
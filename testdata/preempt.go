@@ -0,0 +1,18 @@
+package main
+
+import "sync/atomic"
+
+var started int32
+
+func main() {
+	go func() {
+		println("goroutine started")
+		atomic.StoreInt32(&started, 1)
+	}()
+
+	// This loop never blocks, so the goroutine above only gets to run when the
+	// main goroutine is preempted.
+	for atomic.LoadInt32(&started) == 0 {
+	}
+	println("main done")
+}
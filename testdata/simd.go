@@ -0,0 +1,86 @@
+package main
+
+import (
+	"device/wasm"
+	"unsafe"
+)
+
+func main() {
+	// Load and store.
+	buf := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17}
+	v := wasm.LoadBytes(buf[1:])
+	println("load:", wasm.I8x16ExtractLane(v, 0), wasm.I8x16ExtractLane(v, 15), wasm.I32x4ExtractLane(v, 1))
+	out := make([]byte, 16)
+	wasm.StoreBytes(out, wasm.I8x16Add(v, wasm.I8x16Splat(100)))
+	println("store:", out[0], out[7], out[15])
+
+	// Integer arithmetic.
+	a := wasm.I32x4ReplaceLane(wasm.I32x4Splat(7), 2, -3)
+	b := wasm.I32x4Splat(5)
+	printI32x4("i32x4.add:", wasm.I32x4Add(a, b))
+	printI32x4("i32x4.sub:", wasm.I32x4Sub(a, b))
+	printI32x4("i32x4.mul:", wasm.I32x4Mul(a, b))
+	printI32x4("i32x4.neg:", wasm.I32x4Neg(a))
+	printI32x4("i32x4.min_s:", wasm.I32x4MinS(a, b))
+	printI32x4("i32x4.max_u:", wasm.I32x4MaxU(a, b))
+	printI32x4("i32x4.shl:", wasm.I32x4Shl(a, 33))
+	printI32x4("i32x4.shr_s:", wasm.I32x4ShrS(a, 1))
+	printI32x4("i32x4.shr_u:", wasm.I32x4ShrU(a, 28))
+	printI32x4("i32x4.lt_s:", wasm.I32x4LtS(a, b))
+	println("i64x2.mul:", wasm.I64x2ExtractLane(wasm.I64x2Mul(wasm.I64x2Splat(1<<33), wasm.I64x2Splat(3)), 1))
+
+	// Saturating arithmetic, as used for pixels.
+	pixels := wasm.I8x16Splat(-56) // 200 when unsigned
+	println("i8x16.add_sat_u:", uint8(wasm.I8x16ExtractLane(wasm.I8x16AddSatU(pixels, wasm.I8x16Splat(100)), 3)))
+	println("i8x16.sub_sat_u:", uint8(wasm.I8x16ExtractLane(wasm.I8x16SubSatU(wasm.I8x16Splat(50), pixels), 3)))
+	println("i16x8.add_sat_s:", wasm.I16x8ExtractLane(wasm.I16x8AddSatS(wasm.I16x8Splat(30000), wasm.I16x8Splat(10000)), 5))
+	println("i16x8.sub_sat_s:", wasm.I16x8ExtractLane(wasm.I16x8SubSatS(wasm.I16x8Splat(-30000), wasm.I16x8Splat(10000)), 5))
+	println("i8x16.max_u:", uint8(wasm.I8x16ExtractLane(wasm.I8x16MaxU(pixels, wasm.I8x16Splat(100)), 0)))
+	println("i8x16.max_s:", wasm.I8x16ExtractLane(wasm.I8x16MaxS(pixels, wasm.I8x16Splat(100)), 0))
+
+	// Bitwise operations.
+	mask := wasm.I32x4Eq(a, wasm.I32x4Splat(7))
+	printI32x4("bitselect:", wasm.Bitselect(a, b, mask))
+	printI32x4("and:", wasm.And(a, wasm.I32x4Splat(3)))
+	printI32x4("andnot:", wasm.AndNot(a, wasm.I32x4Splat(3)))
+	printI32x4("xor:", wasm.Xor(a, b))
+	println("any_true:", wasm.AnyTrue(mask), wasm.AnyTrue(wasm.V128{}))
+	println("all_true:", wasm.I32x4AllTrue(mask), wasm.I32x4AllTrue(b))
+
+	// Floating point.
+	f := wasm.F32x4ConvertI32x4S(a)
+	g := wasm.F32x4Splat(2)
+	printF32x4("f32x4.convert_i32x4_s:", f)
+	printF32x4("f32x4.mul:", wasm.F32x4Mul(f, g))
+	printF32x4("f32x4.div:", wasm.F32x4Div(f, g))
+	printF32x4("f32x4.sqrt:", wasm.F32x4Sqrt(wasm.F32x4Splat(16)))
+	printF32x4("f32x4.min:", wasm.F32x4Min(f, g))
+	printI32x4("f32x4.gt:", wasm.F32x4Gt(f, g))
+	printI32x4("i32x4.trunc_sat_f32x4_s:", wasm.I32x4TruncSatF32x4S(wasm.F32x4Splat(-3e9)))
+	printI32x4("i32x4.trunc_sat_f32x4_u:", wasm.I32x4TruncSatF32x4U(wasm.F32x4Splat(2.75)))
+	println("f64x2.add:", int(wasm.F64x2ExtractLane(wasm.F64x2Add(wasm.F64x2Splat(1.5), wasm.F64x2Splat(2.5)), 0)))
+
+	// A simple loop, like in DSP code.
+	samples := make([]float32, 64)
+	for i := range samples {
+		samples[i] = float32(i)
+	}
+	gain := wasm.F32x4Splat(0.5)
+	for i := 0; i < len(samples); i += 4 {
+		p := unsafe.Pointer(&samples[i])
+		wasm.Store(p, wasm.F32x4Mul(wasm.Load(p), gain))
+	}
+	sum := float32(0)
+	for _, s := range samples {
+		sum += s
+	}
+	println("gain:", int(sum))
+}
+
+func printI32x4(name string, v wasm.V128) {
+	println(name, wasm.I32x4ExtractLane(v, 0), wasm.I32x4ExtractLane(v, 1), wasm.I32x4ExtractLane(v, 2), wasm.I32x4ExtractLane(v, 3))
+}
+
+func printF32x4(name string, v wasm.V128) {
+	println(name, int(wasm.F32x4ExtractLane(v, 0)), int(wasm.F32x4ExtractLane(v, 1)), int(wasm.F32x4ExtractLane(v, 2)), int(wasm.F32x4ExtractLane(v, 3)))
+}
@@ -51,4 +51,37 @@ func main() {
 	<-timer.C
 	println("waited on timer at 750ms")
 	time.Sleep(time.Millisecond * 500)
+
+	println("stop fired timer:", timer.Stop())
+
+	// Test many timers at the same time. They are started in reverse order
+	// and half of them are stopped before they fire.
+	var fired []int
+	var timers []*time.Timer
+	for i := 9; i >= 0; i-- {
+		i := i
+		timers = append(timers, time.AfterFunc(time.Duration(i+1)*time.Millisecond*20, func() {
+			fired = append(fired, i)
+		}))
+	}
+	stopped := 0
+	for i, t := range timers {
+		if i%2 == 0 && t.Stop() {
+			stopped++
+		}
+	}
+	time.Sleep(time.Millisecond * 300)
+	println("stopped timers:", stopped)
+	print("fired timers:")
+	for _, i := range fired {
+		print(" ", i)
+	}
+	println()
+
+	// Test resetting a ticker.
+	ticker = time.NewTicker(time.Hour)
+	ticker.Reset(time.Millisecond * 50)
+	<-ticker.C
+	println("waited on reset ticker")
+	ticker.Stop()
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"runtime/trace"
+	"strings"
+)
+
+// Event types in the trace format.
+const (
+	evBatch          = 1
+	evFrequency      = 2
+	evGCStart        = 7
+	evGoCreate       = 13
+	evGoEnd          = 15
+	evGoBlockRecv    = 23
+	evGoStartLocal   = 38
+	evGoUnblockLocal = 39
+)
+
+func main() {
+	var buf bytes.Buffer
+	println("start:", trace.Start(&buf) == nil)
+	err := trace.Start(io.Discard)
+	println("start again:", err.Error())
+	println("enabled:", trace.IsEnabled())
+
+	ch := make(chan int)
+	go func() {
+		ch <- 1
+	}()
+	<-ch
+	runtime.GC()
+	trace.Stop()
+	println("enabled:", trace.IsEnabled())
+
+	data := buf.String()
+	println("header:", strings.HasPrefix(data, "go 1.21 trace\x00\x00\x00"))
+	seen := readEvents([]byte(data[16:]))
+	println("goroutine created:", seen[evGoCreate])
+	println("goroutine started:", seen[evGoStartLocal])
+	println("goroutine blocked:", seen[evGoBlockRecv])
+	println("goroutine unblocked:", seen[evGoUnblockLocal])
+	println("goroutine ended:", seen[evGoEnd])
+	println("gc:", seen[evGCStart])
+}
+
+// readEvents returns which event types are present in the trace.
+func readEvents(data []byte) map[byte]bool {
+	seen := make(map[byte]bool)
+	for len(data) != 0 {
+		typ := data[0] & 0x3f
+		narg := int(data[0] >> 6)
+		data = data[1:]
+		seen[typ] = true
+		switch {
+		case typ == evBatch:
+			data = skipVarints(data, 2)
+		case typ == evFrequency:
+			data = skipVarints(data, 1)
+		case narg < 3:
+			data = skipVarints(data, narg+1) // arguments and timestamp
+		default:
+			length, n := uvarint(data)
+			data = data[n+int(length):]
+		}
+	}
+	return seen
+}
+
+func skipVarints(data []byte, n int) []byte {
+	for i := 0; i < n; i++ {
+		_, size := uvarint(data)
+		data = data[size:]
+	}
+	return data
+}
+
+func uvarint(data []byte) (uint64, int) {
+	var x uint64
+	for i, b := range data {
+		x |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return x, i + 1
+		}
+	}
+	return 0, len(data)
+}
@@ -0,0 +1,55 @@
+package main
+
+// Test runtime.SetWatchdog: the pet function must be called as long as all
+// goroutines get to run, and never again once a goroutine has kept the others
+// from running for longer than the deadline.
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+const deadline = 50 * time.Millisecond
+
+var pets uint32
+
+func pet() {
+	atomic.AddUint32(&pets, 1)
+}
+
+func main() {
+	println("supported:", runtime.SetWatchdog(int64(deadline), pet))
+
+	// Two goroutines that keep yielding, so that every round is short.
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			yieldFor(4 * deadline)
+			done <- struct{}{}
+		}()
+	}
+	<-done
+	<-done
+	println("pet while yielding:", atomic.LoadUint32(&pets) != 0)
+
+	// Keep the other goroutine from running for longer than the deadline.
+	go yieldFor(4 * deadline)
+	runtime.Gosched()
+	before := atomic.LoadUint32(&pets)
+	start := time.Now()
+	for time.Since(start) < 2*deadline {
+	}
+	yieldFor(4 * deadline)
+	println("pet after spinning:", atomic.LoadUint32(&pets) != before)
+
+	runtime.SetWatchdog(0, nil)
+}
+
+// yieldFor calls runtime.Gosched until the given duration has passed.
+func yieldFor(duration time.Duration) {
+	start := time.Now()
+	for time.Since(start) < duration {
+		runtime.Gosched()
+	}
+}
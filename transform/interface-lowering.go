@@ -368,9 +368,133 @@ func (p *lowerInterfacesPass) run() error {
 		}
 	}
 
+	// Create a list of all type codes, if the reflect package needs it to find
+	// types that are created at runtime (like with reflect.StructOf).
+	if typecodes := p.mod.NamedGlobal("reflect.typecodes"); !typecodes.IsNil() && typecodes.IsDeclaration() {
+		var list []llvm.Value
+		for _, name := range typeNames {
+			t := p.types[name]
+			gep := llvm.ConstInBoundsGEP(t.typecode.GlobalValueType(), t.typecode, []llvm.Value{zero, zero})
+			list = append(list, llvm.ConstBitCast(gep, p.i8ptrType))
+		}
+		p.defineReflectList(typecodes, p.i8ptrType, list)
+	}
+
+	// Create a list of the method signatures of all types that have methods,
+	// if the reflect package needs it (for Type.Implements). Only the
+	// signatures are kept: the methods themselves are not referenced.
+	if methodSets := p.mod.NamedGlobal("reflect.methodSets"); !methodSets.IsNil() && methodSets.IsDeclaration() {
+		entryType := p.ctx.StructType([]llvm.Type{p.i8ptrType, p.i8ptrType}, false)
+		var list []llvm.Value
+		for _, name := range typeNames {
+			t := p.types[name]
+			if t.methodSet.IsNil() {
+				continue
+			}
+			signatures := p.builder.CreateExtractValue(t.methodSet.Initializer(), 1, "")
+			setGlobal := p.addConstantGlobal(t.methodSet.Name()+"$signatures", p.ctx.ConstStruct([]llvm.Value{
+				llvm.ConstInt(p.uintptrType, uint64(len(t.methods)), false),
+				signatures,
+			}, false))
+			gep := llvm.ConstInBoundsGEP(t.typecode.GlobalValueType(), t.typecode, []llvm.Value{zero, zero})
+			list = append(list, p.ctx.ConstStruct([]llvm.Value{
+				llvm.ConstBitCast(gep, p.i8ptrType),
+				llvm.ConstBitCast(setGlobal, p.i8ptrType),
+			}, false))
+		}
+		p.defineReflectList(methodSets, entryType, list)
+	}
+
+	// Create a list of the methods of all types that have them, if the
+	// reflect package needs it (for Type.Method and Value.Method). Unlike the
+	// list above, this keeps the referenced method functions alive. The
+	// exported methods come first, followed by the unexported methods.
+	if methodTables := p.mod.NamedGlobal("reflect.methodTables"); !methodTables.IsNil() && methodTables.IsDeclaration() {
+		entryType := p.ctx.StructType([]llvm.Type{p.i8ptrType, p.i8ptrType}, false)
+		var list []llvm.Value
+		for _, name := range typeNames {
+			t := p.types[name]
+			if t.methodSet.IsNil() {
+				continue
+			}
+			methods := p.builder.CreateExtractValue(t.methodSet.Initializer(), 3, "")
+			if methods.Type().ArrayLength() == 0 {
+				continue
+			}
+			numExported := 0
+			for i := 0; i < methods.Type().ArrayLength(); i++ {
+				signature := stripPointerCasts(p.builder.CreateExtractValue(p.builder.CreateExtractValue(methods, i, ""), 0, ""))
+				if strings.HasPrefix(signature.Name(), "reflect/methods.") {
+					numExported++
+				}
+			}
+			tableGlobal := p.addConstantGlobal(t.methodSet.Name()+"$methods", p.ctx.ConstStruct([]llvm.Value{
+				llvm.ConstInt(p.uintptrType, uint64(methods.Type().ArrayLength()), false),
+				llvm.ConstInt(p.uintptrType, uint64(numExported), false),
+				methods,
+			}, false))
+			gep := llvm.ConstInBoundsGEP(t.typecode.GlobalValueType(), t.typecode, []llvm.Value{zero, zero})
+			list = append(list, p.ctx.ConstStruct([]llvm.Value{
+				llvm.ConstBitCast(gep, p.i8ptrType),
+				llvm.ConstBitCast(tableGlobal, p.i8ptrType),
+			}, false))
+		}
+		p.defineReflectList(methodTables, entryType, list)
+	}
+
+	// Create a list of bound method wrappers (used for method values like
+	// obj.M) together with the method they call, if the reflect package needs
+	// it (for Value.Pointer).
+	if boundMethods := p.mod.NamedGlobal("reflect.boundMethods"); !boundMethods.IsNil() && boundMethods.IsDeclaration() {
+		funcPtrType := llvm.PointerType(llvm.FunctionType(p.ctx.VoidType(), nil, false), p.mod.FirstFunction().Type().PointerAddressSpace())
+		entryType := p.ctx.StructType([]llvm.Type{funcPtrType, funcPtrType}, false)
+		var list []llvm.Value
+		for fn := p.mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+			attr := fn.GetStringAttributeAtIndex(-1, "tinygo-bound-method")
+			if attr.IsNil() {
+				continue
+			}
+			method := p.mod.NamedFunction(attr.GetStringValue())
+			if method.IsNil() {
+				continue
+			}
+			list = append(list, p.ctx.ConstStruct([]llvm.Value{
+				llvm.ConstBitCast(fn, funcPtrType),
+				llvm.ConstBitCast(method, funcPtrType),
+			}, false))
+		}
+		p.defineReflectList(boundMethods, entryType, list)
+	}
+
 	return nil
 }
 
+// addConstantGlobal adds an internal constant global with the given
+// initializer.
+func (p *lowerInterfacesPass) addConstantGlobal(name string, initializer llvm.Value) llvm.Value {
+	global := llvm.AddGlobal(p.mod, initializer.Type(), name)
+	global.SetInitializer(initializer)
+	global.SetLinkage(llvm.InternalLinkage)
+	global.SetGlobalConstant(true)
+	global.SetUnnamedAddr(true)
+	return global
+}
+
+// defineReflectList defines an external slice global from the reflect package
+// (like reflect.typecodes) to contain the given list of elements.
+func (p *lowerInterfacesPass) defineReflectList(slice llvm.Value, elementType llvm.Type, elements []llvm.Value) {
+	listGlobal := p.addConstantGlobal(slice.Name()+"$list", llvm.ConstArray(elementType, elements))
+	length := llvm.ConstInt(p.uintptrType, uint64(len(elements)), false)
+	sliceType := slice.GlobalValueType()
+	slice.SetInitializer(p.ctx.ConstStruct([]llvm.Value{
+		llvm.ConstBitCast(listGlobal, sliceType.StructElementTypes()[0]),
+		length,
+		length,
+	}, false))
+	slice.SetLinkage(llvm.InternalLinkage)
+	slice.SetGlobalConstant(true)
+}
+
 // addTypeMethods reads the method set of the given type info struct. It
 // retrieves the signatures and the references to the method functions
 // themselves for later type<->interface matching.
@@ -387,7 +511,7 @@ func (p *lowerInterfacesPass) addTypeMethods(t *typeInfo, methodSet llvm.Value)
 	wrappers := p.builder.CreateExtractValue(set, 2, "")
 	numMethods := signatures.Type().ArrayLength()
 	for i := 0; i < numMethods; i++ {
-		signatureGlobal := p.builder.CreateExtractValue(signatures, i, "")
+		signatureGlobal := stripPointerCasts(p.builder.CreateExtractValue(signatures, i, ""))
 		function := p.builder.CreateExtractValue(wrappers, i, "")
 		function = stripPointerCasts(function) // strip bitcasts
 		signatureName := signatureGlobal.Name()
@@ -71,6 +71,19 @@ func Optimize(mod llvm.Module, config *compileopts.Config, optLevel, sizeLevel i
 		}
 		fn.SetLinkage(llvm.ExternalLinkage)
 	}
+	if config.GC() == "incremental" {
+		// The write barrier is only inserted at the end, so it must not be
+		// removed as an unused function before that.
+		if fn := mod.NamedFunction("runtime.gcWriteBarrier"); !fn.IsNil() {
+			fn.SetLinkage(llvm.ExternalLinkage)
+		}
+	}
+	if config.AutoYield() {
+		// Same for safepoints.
+		if fn := mod.NamedFunction("runtime.safepoint"); !fn.IsNil() {
+			fn.SetLinkage(llvm.ExternalLinkage)
+		}
+	}
 
 	if config.PanicStrategy() == "trap" {
 		ReplacePanicsWithTrap(mod) // -panic=trap
@@ -160,6 +173,25 @@ func Optimize(mod llvm.Module, config *compileopts.Config, optLevel, sizeLevel i
 		return []error{errors.New("optimizations caused a verification failure")}
 	}
 
+	if config.GC() == "incremental" {
+		// Insert write barriers after all other TinyGo passes, so that they
+		// don't get in the way of optimizations like OptimizeAllocs.
+		InsertWriteBarriers(mod)
+		if fn := mod.NamedFunction("runtime.gcWriteBarrier"); !fn.IsNil() {
+			fn.SetLinkage(llvm.InternalLinkage)
+		}
+	}
+
+	if config.AutoYield() {
+		// Insert safepoints at the end as well, so that they can be inlined by
+		// the function passes below but don't get in the way of the TinyGo
+		// passes before.
+		InsertSafepoints(mod)
+		if fn := mod.NamedFunction("runtime.safepoint"); !fn.IsNil() {
+			fn.SetLinkage(llvm.InternalLinkage)
+		}
+	}
+
 	// After TinyGo-specific transforms have finished, undo exporting these functions.
 	for _, name := range functionsUsedInTransforms {
 		fn := mod.NamedFunction(name)
@@ -193,6 +225,13 @@ func Optimize(mod llvm.Module, config *compileopts.Config, optLevel, sizeLevel i
 			return []error{errors.New("GC pass caused a verification failure")}
 		}
 	}
+	if config.Scheduler() == "cores" && config.NeedsStackObjects() {
+		// Every thread has its own stack chain.
+		stackChainStart := mod.NamedGlobal("runtime.stackChainStart")
+		if !stackChainStart.IsNil() {
+			stackChainStart.SetThreadLocal(true)
+		}
+	}
 
 	return nil
 }
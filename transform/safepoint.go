@@ -0,0 +1,114 @@
+package transform
+
+// This file inserts safepoints for -autoyield. A goroutine that never blocks
+// (for example, one that is busy-waiting on a variable) would otherwise never
+// give other goroutines a chance to run. A safepoint is a call to the runtime
+// that checks whether the current goroutine has been running for too long, and
+// if so, switches to another goroutine.
+
+import (
+	"strings"
+
+	"tinygo.org/x/go-llvm"
+)
+
+// InsertSafepoints inserts a call to runtime.safepoint in every loop of every
+// function outside of the runtime. Every loop contains at least one backward
+// branch, so a long running goroutine will always reach a safepoint eventually
+// (unless it is blocked in a call to C or some other external code).
+//
+// The runtime and low-level packages like sync are not modified: they may
+// depend on not being interrupted, and they don't contain unbounded loops
+// that don't block. Functions with the //go:noyield pragma are not modified
+// either.
+func InsertSafepoints(mod llvm.Module) {
+	safepoint := mod.NamedFunction("runtime.safepoint")
+	if safepoint.IsNil() {
+		// The runtime wasn't compiled with -autoyield.
+		return
+	}
+
+	ctx := mod.Context()
+	builder := ctx.NewBuilder()
+	defer builder.Dispose()
+	i8ptrType := llvm.PointerType(ctx.Int8Type(), 0)
+
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if fn.IsDeclaration() || !needsSafepoints(fn.Name()) {
+			continue
+		}
+		if !fn.GetStringAttributeAtIndex(-1, "tinygo-noyield").IsNil() {
+			continue
+		}
+		for _, bb := range findBackEdges(fn) {
+			builder.SetInsertPointBefore(bb.LastInstruction())
+			builder.CreateCall(safepoint.GlobalValueType(), safepoint, []llvm.Value{llvm.Undef(i8ptrType)}, "")
+		}
+	}
+}
+
+// needsSafepoints returns whether the function with the given name should get
+// safepoints, based on the package it is part of.
+func needsSafepoints(name string) bool {
+	// Strip the receiver and type parameters, for names like
+	// "(*main.T).Method" and "main.Func[main.T]".
+	name = strings.TrimLeft(name, "(*")
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	slash := strings.LastIndexByte(name, '/')
+	dot := strings.IndexByte(name[slash+1:], '.')
+	if dot < 0 {
+		// Not a Go function, for example a function from C.
+		return false
+	}
+	pkg := name[:slash+1+dot]
+	switch {
+	case pkg == "runtime" || strings.HasPrefix(pkg, "runtime/"):
+		return false
+	case pkg == "internal" || strings.HasPrefix(pkg, "internal/"):
+		return false
+	case pkg == "sync" || pkg == "sync/atomic":
+		return false
+	}
+	return true
+}
+
+// findBackEdges returns all basic blocks in the function that end with a
+// backward branch: a branch to a block that is still on the current path of a
+// depth-first search from the entry block. Every loop contains at least one
+// such block.
+func findBackEdges(fn llvm.Value) []llvm.BasicBlock {
+	const (
+		unvisited = iota
+		active    // on the current depth-first search path
+		done
+	)
+	state := make(map[llvm.BasicBlock]int)
+	var result []llvm.BasicBlock
+	var visit func(bb llvm.BasicBlock)
+	visit = func(bb llvm.BasicBlock) {
+		state[bb] = active
+		isBackEdge := false
+		terminator := bb.LastInstruction()
+		for i := 0; i < terminator.OperandsCount(); i++ {
+			operand := terminator.Operand(i)
+			if !operand.IsBasicBlock() {
+				continue
+			}
+			succ := operand.AsBasicBlock()
+			switch state[succ] {
+			case unvisited:
+				visit(succ)
+			case active:
+				isBackEdge = true
+			}
+		}
+		if isBackEdge {
+			result = append(result, bb)
+		}
+		state[bb] = done
+	}
+	visit(fn.EntryBasicBlock())
+	return result
+}
@@ -0,0 +1,15 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/tinygo-org/tinygo/transform"
+	"tinygo.org/x/go-llvm"
+)
+
+func TestInsertSafepoints(t *testing.T) {
+	t.Parallel()
+	testTransform(t, "testdata/safepoint", func(mod llvm.Module) {
+		transform.InsertSafepoints(mod)
+	})
+}
@@ -0,0 +1,78 @@
+target datalayout = "e-m:e-p:32:32-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7m-none-eabi"
+
+@main.flag = global i1 false
+
+declare void @runtime.safepoint(ptr)
+
+define void @main.waitForFlag(ptr %context) {
+entry:
+  br label %loop
+
+loop:
+  %flag = load volatile i1, ptr @main.flag, align 1
+  call void @runtime.safepoint(ptr undef)
+  br i1 %flag, label %exit, label %loop
+
+exit:
+  ret void
+}
+
+define i32 @"(*main.T).sum"(ptr %t, i32 %n, ptr %context) {
+entry:
+  br label %header
+
+header:
+  %i = phi i32 [ 0, %entry ], [ %i.next, %body ]
+  %sum = phi i32 [ 0, %entry ], [ %sum.next, %body ]
+  %cond = icmp slt i32 %i, %n
+  br i1 %cond, label %body, label %exit
+
+body:
+  %sum.next = add i32 %sum, %i
+  %i.next = add i32 %i, 1
+  call void @runtime.safepoint(ptr undef)
+  br label %header
+
+exit:
+  ret i32 %sum
+}
+
+define i32 @main.max(i32 %a, i32 %b, ptr %context) {
+entry:
+  %cond = icmp sgt i32 %a, %b
+  br i1 %cond, label %greater, label %exit
+
+greater:
+  br label %exit
+
+exit:
+  %result = phi i32 [ %a, %greater ], [ %b, %entry ]
+  ret i32 %result
+}
+
+define void @runtime.spin(ptr %context) {
+entry:
+  br label %loop
+
+loop:
+  %flag = load volatile i1, ptr @main.flag, align 1
+  br i1 %flag, label %exit, label %loop
+
+exit:
+  ret void
+}
+
+define void @main.noYield(ptr %context) #0 {
+entry:
+  br label %loop
+
+loop:
+  %flag = load volatile i1, ptr @main.flag, align 1
+  br i1 %flag, label %exit, label %loop
+
+exit:
+  ret void
+}
+
+attributes #0 = { "tinygo-noyield" }
@@ -0,0 +1,56 @@
+target datalayout = "e-m:e-p:32:32-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7m-none-eabi"
+
+@main.global = global ptr null
+
+declare void @runtime.gcWriteBarrier(ptr, i32, ptr)
+
+declare void @main.use(ptr)
+
+; Stores to the heap need a write barrier.
+define void @main.storePointer(ptr %obj, ptr %value) {
+entry:
+  store ptr %value, ptr %obj, align 4
+  ret void
+}
+
+; Stores to globals need a write barrier, as globals are only scanned at the
+; start of a GC cycle.
+define void @main.storeGlobal(ptr %value) {
+entry:
+  store ptr %value, ptr @main.global, align 4
+  ret void
+}
+
+; Stores smaller than a pointer can't overwrite a pointer.
+define void @main.storeByte(ptr %obj) {
+entry:
+  store i8 1, ptr %obj, align 1
+  ret void
+}
+
+; Stores to the stack don't need a write barrier.
+define void @main.storeStack(ptr %value) {
+entry:
+  %slot = alloca [2 x ptr], align 4
+  %field = getelementptr inbounds [2 x ptr], ptr %slot, i32 0, i32 1
+  store ptr %value, ptr %field, align 4
+  call void @main.use(ptr %slot)
+  ret void
+}
+
+; Atomic operations overwrite memory too.
+define i32 @main.swap(ptr %addr, i32 %new) {
+entry:
+  %old = atomicrmw xchg ptr %addr, i32 %new seq_cst, align 4
+  ret i32 %old
+}
+
+; Functions with //go:nowritebarrier are left alone.
+define void @main.noWriteBarrier(ptr %obj, ptr %value) #0 {
+entry:
+  store ptr %value, ptr %obj, align 4
+  ret void
+}
+
+attributes #0 = { "tinygo-nowritebarrier" }
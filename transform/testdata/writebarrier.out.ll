@@ -0,0 +1,52 @@
+target datalayout = "e-m:e-p:32:32-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7m-none-eabi"
+
+@main.global = global ptr null
+
+declare void @runtime.gcWriteBarrier(ptr, i32, ptr)
+
+declare void @main.use(ptr)
+
+define void @main.storePointer(ptr %obj, ptr %value) {
+entry:
+  call void @runtime.gcWriteBarrier(ptr %obj, i32 4, ptr undef)
+  store ptr %value, ptr %obj, align 4
+  ret void
+}
+
+define void @main.storeGlobal(ptr %value) {
+entry:
+  call void @runtime.gcWriteBarrier(ptr @main.global, i32 4, ptr undef)
+  store ptr %value, ptr @main.global, align 4
+  ret void
+}
+
+define void @main.storeByte(ptr %obj) {
+entry:
+  store i8 1, ptr %obj, align 1
+  ret void
+}
+
+define void @main.storeStack(ptr %value) {
+entry:
+  %slot = alloca [2 x ptr], align 4
+  %field = getelementptr inbounds [2 x ptr], ptr %slot, i32 0, i32 1
+  store ptr %value, ptr %field, align 4
+  call void @main.use(ptr %slot)
+  ret void
+}
+
+define i32 @main.swap(ptr %addr, i32 %new) {
+entry:
+  call void @runtime.gcWriteBarrier(ptr %addr, i32 4, ptr undef)
+  %old = atomicrmw xchg ptr %addr, i32 %new seq_cst, align 4
+  ret i32 %old
+}
+
+define void @main.noWriteBarrier(ptr %obj, ptr %value) #0 {
+entry:
+  store ptr %value, ptr %obj, align 4
+  ret void
+}
+
+attributes #0 = { "tinygo-nowritebarrier" }
@@ -0,0 +1,124 @@
+package transform
+
+// This file inserts write barriers for the incremental garbage collector
+// (-gc=incremental). The GC uses a snapshot-at-the-beginning algorithm: every
+// object that was reachable when a GC cycle started must be marked, even if
+// the program removes the last reference to it while the GC is still marking.
+// To make this possible, the value in memory is passed to the GC right before
+// it is overwritten (a "deletion barrier").
+
+import (
+	"strings"
+
+	"tinygo.org/x/go-llvm"
+)
+
+// Opcodes that are missing from the go-llvm bindings. These values are part of
+// the stable LLVM C API (see LLVMOpcode in llvm-c/Core.h).
+const (
+	opcodeAtomicCmpXchg llvm.Opcode = 56
+	opcodeAtomicRMW     llvm.Opcode = 57
+)
+
+// InsertWriteBarriers inserts a call to runtime.gcWriteBarrier before every
+// instruction that may overwrite a pointer outside of the current stack frame.
+// These are stores of at least pointer size, atomic read-modify-write
+// instructions and the memcpy/memmove/memset intrinsics. Stores to allocas are
+// skipped, as stacks are scanned separately by the GC.
+//
+// Functions with the //go:nowritebarrier pragma are not modified. This is used
+// for the GC itself, which would otherwise call itself recursively.
+func InsertWriteBarriers(mod llvm.Module) {
+	writeBarrier := mod.NamedFunction("runtime.gcWriteBarrier")
+	if writeBarrier.IsNil() {
+		// The runtime wasn't compiled with -gc=incremental, or the program
+		// doesn't need a heap.
+		return
+	}
+
+	ctx := mod.Context()
+	builder := ctx.NewBuilder()
+	defer builder.Dispose()
+	targetData := llvm.NewTargetData(mod.DataLayout())
+	defer targetData.Dispose()
+	uintptrType := ctx.IntType(targetData.PointerSize() * 8)
+	ptrSize := uint64(targetData.PointerSize())
+
+	// Collect all instructions that need a write barrier. Collecting them first
+	// avoids modifying the basic blocks while iterating over them.
+	type barrier struct {
+		inst llvm.Value
+		ptr  llvm.Value
+		size llvm.Value
+	}
+	var barriers []barrier
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if fn.IsDeclaration() || fn == writeBarrier {
+			continue
+		}
+		if !fn.GetStringAttributeAtIndex(-1, "tinygo-nowritebarrier").IsNil() {
+			continue
+		}
+		for bb := fn.FirstBasicBlock(); !bb.IsNil(); bb = llvm.NextBasicBlock(bb) {
+			for inst := bb.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+				var ptr, size llvm.Value
+				switch inst.InstructionOpcode() {
+				case llvm.Store:
+					// The stored value is operand 0, the address is operand 1.
+					storeSize := targetData.TypeStoreSize(inst.Operand(0).Type())
+					if storeSize < ptrSize {
+						// Too small to overwrite a pointer.
+						continue
+					}
+					ptr = inst.Operand(1)
+					size = llvm.ConstInt(uintptrType, storeSize, false)
+				case opcodeAtomicRMW, opcodeAtomicCmpXchg:
+					// Operand 0 is the address, the last operand is the new
+					// value.
+					value := inst.Operand(inst.OperandsCount() - 1)
+					storeSize := targetData.TypeStoreSize(value.Type())
+					if storeSize < ptrSize {
+						continue
+					}
+					ptr = inst.Operand(0)
+					size = llvm.ConstInt(uintptrType, storeSize, false)
+				case llvm.Call:
+					callee := inst.CalledValue()
+					if callee.IsAFunction().IsNil() {
+						continue
+					}
+					name := callee.Name()
+					if !strings.HasPrefix(name, "llvm.memcpy.") && !strings.HasPrefix(name, "llvm.memmove.") && !strings.HasPrefix(name, "llvm.memset.") {
+						continue
+					}
+					ptr = inst.Operand(0)
+					size = inst.Operand(2)
+				default:
+					continue
+				}
+				if !stripPointerCasts(ptr).IsAAllocaInst().IsNil() {
+					// Stores to the stack don't need a write barrier.
+					continue
+				}
+				barriers = append(barriers, barrier{inst, ptr, size})
+			}
+		}
+	}
+
+	// Insert the calls.
+	i8ptrType := llvm.PointerType(ctx.Int8Type(), 0)
+	for _, b := range barriers {
+		builder.SetInsertPointBefore(b.inst)
+		ptr := b.ptr
+		if ptr.Type() != i8ptrType {
+			ptr = builder.CreateBitCast(ptr, i8ptrType, "")
+		}
+		size := b.size
+		if width := size.Type().IntTypeWidth(); width < uintptrType.IntTypeWidth() {
+			size = builder.CreateZExt(size, uintptrType, "")
+		} else if width > uintptrType.IntTypeWidth() {
+			size = builder.CreateTrunc(size, uintptrType, "")
+		}
+		builder.CreateCall(writeBarrier.GlobalValueType(), writeBarrier, []llvm.Value{ptr, size, llvm.Undef(i8ptrType)}, "")
+	}
+}
@@ -0,0 +1,15 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/tinygo-org/tinygo/transform"
+	"tinygo.org/x/go-llvm"
+)
+
+func TestInsertWriteBarriers(t *testing.T) {
+	t.Parallel()
+	testTransform(t, "testdata/writebarrier", func(mod llvm.Module) {
+		transform.InsertWriteBarriers(mod)
+	})
+}